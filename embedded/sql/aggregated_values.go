@@ -16,6 +16,8 @@ limitations under the License.
 
 package sql
 
+import "encoding/json"
+
 type AggregatedValue interface {
 	TypedValue
 	updateWith(val TypedValue) error
@@ -44,6 +46,10 @@ func (v *CountValue) Value() interface{} {
 	return v.c
 }
 
+func (v *CountValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.c)
+}
+
 func (v *CountValue) Compare(val TypedValue) (int, error) {
 	if val.Type() != IntegerType {
 		return 0, ErrNotComparableValues
@@ -125,6 +131,10 @@ func (v *SumValue) Value() interface{} {
 	return v.s
 }
 
+func (v *SumValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.s)
+}
+
 func (v *SumValue) Compare(val TypedValue) (int, error) {
 	if val.Type() != IntegerType {
 		return 0, ErrNotComparableValues
@@ -144,6 +154,12 @@ func (v *SumValue) Compare(val TypedValue) (int, error) {
 }
 
 func (v *SumValue) updateWith(val TypedValue) error {
+	// null values are not aggregated
+	_, isNull := val.(*NullValue)
+	if isNull {
+		return nil
+	}
+
 	if val.Type() != IntegerType {
 		return ErrNotComparableValues
 	}
@@ -211,12 +227,23 @@ func (v *MinValue) Value() interface{} {
 	return v.val.Value()
 }
 
+func (v *MinValue) MarshalJSON() ([]byte, error) {
+	return v.val.MarshalJSON()
+}
+
 func (v *MinValue) Compare(val TypedValue) (int, error) {
 	return v.val.Compare(val)
 }
 
 func (v *MinValue) updateWith(val TypedValue) error {
-	if v.val == nil {
+	// null values are not aggregated, the minimum remains null until a value is found
+	_, isNull := val.(*NullValue)
+	if isNull && v.val != nil {
+		return nil
+	}
+
+	_, isMinNull := v.val.(*NullValue)
+	if v.val == nil || isMinNull {
 		v.val = val
 		return nil
 	}
@@ -300,12 +327,23 @@ func (v *MaxValue) Value() interface{} {
 	return v.val.Value()
 }
 
+func (v *MaxValue) MarshalJSON() ([]byte, error) {
+	return v.val.MarshalJSON()
+}
+
 func (v *MaxValue) Compare(val TypedValue) (int, error) {
 	return v.val.Compare(val)
 }
 
 func (v *MaxValue) updateWith(val TypedValue) error {
-	if v.val == nil {
+	// null values are not aggregated, the maximum remains null until a value is found
+	_, isNull := val.(*NullValue)
+	if isNull && v.val != nil {
+		return nil
+	}
+
+	_, isMaxNull := v.val.(*NullValue)
+	if v.val == nil || isMaxNull {
 		v.val = val
 		return nil
 	}
@@ -387,15 +425,24 @@ func (v *AVGValue) Type() SQLValueType {
 }
 
 func (v *AVGValue) Value() interface{} {
+	// the average of null values is zero, as for an empty set
+	if v.c == 0 {
+		return int64(0)
+	}
+
 	return v.s / v.c
 }
 
+func (v *AVGValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.Value())
+}
+
 func (v *AVGValue) Compare(val TypedValue) (int, error) {
 	if val.Type() != IntegerType {
 		return 0, ErrNotComparableValues
 	}
 
-	avg := v.s / v.c
+	avg := v.Value().(int64)
 	nv := val.Value().(int64)
 
 	if avg == nv {
@@ -410,6 +457,12 @@ func (v *AVGValue) Compare(val TypedValue) (int, error) {
 }
 
 func (v *AVGValue) updateWith(val TypedValue) error {
+	// null values are not aggregated
+	_, isNull := val.(*NullValue)
+	if isNull {
+		return nil
+	}
+
 	if val.Type() != IntegerType {
 		return ErrNotComparableValues
 	}
@@ -101,6 +101,10 @@ func TestSumValue(t *testing.T) {
 	err = cval.updateWith(&Number{val: 10})
 	require.NoError(t, err)
 
+	err = cval.updateWith(&NullValue{t: IntegerType})
+	require.NoError(t, err)
+	require.Equal(t, int64(11), cval.Value())
+
 	cmp, err = cval.Compare(&Number{val: 10})
 	require.NoError(t, err)
 	require.Equal(t, 1, cmp)
@@ -166,6 +170,9 @@ func TestMinValue(t *testing.T) {
 	err = cval.updateWith(&Number{val: 2})
 	require.NoError(t, err)
 
+	err = cval.updateWith(&NullValue{t: IntegerType})
+	require.NoError(t, err)
+
 	cmp, err = cval.Compare(&Number{val: 2})
 	require.NoError(t, err)
 	require.Equal(t, 0, cmp)
@@ -231,6 +238,9 @@ func TestMaxValue(t *testing.T) {
 	err = cval.updateWith(&Number{val: 2})
 	require.NoError(t, err)
 
+	err = cval.updateWith(&NullValue{t: IntegerType})
+	require.NoError(t, err)
+
 	cmp, err = cval.Compare(&Number{val: 2})
 	require.NoError(t, err)
 	require.Equal(t, 1, cmp)
@@ -290,6 +300,9 @@ func TestAVGValue(t *testing.T) {
 	err = cval.updateWith(&Number{val: 2})
 	require.NoError(t, err)
 
+	err = cval.updateWith(&NullValue{t: IntegerType})
+	require.NoError(t, err)
+
 	cmp, err = cval.Compare(&Number{val: 6})
 	require.NoError(t, err)
 	require.Equal(t, 0, cmp)
@@ -325,3 +338,33 @@ func TestAVGValue(t *testing.T) {
 
 	require.Nil(t, cval.selectorRanges(nil, "", nil, nil))
 }
+
+func TestAggregatedValuesOfNulls(t *testing.T) {
+	for _, aggV := range []AggregatedValue{
+		&MinValue{sel: "db1.table1.amount"},
+		&MaxValue{sel: "db1.table1.amount"},
+	} {
+		err := aggV.updateWith(&NullValue{t: IntegerType})
+		require.NoError(t, err)
+		require.Equal(t, IntegerType, aggV.Type())
+		require.Nil(t, aggV.Value())
+
+		err = aggV.updateWith(&Number{val: 10})
+		require.NoError(t, err)
+		require.Equal(t, int64(10), aggV.Value())
+
+		err = aggV.updateWith(&NullValue{t: IntegerType})
+		require.NoError(t, err)
+		require.Equal(t, int64(10), aggV.Value())
+	}
+
+	avg := &AVGValue{sel: "db1.table1.amount"}
+
+	err := avg.updateWith(&NullValue{t: IntegerType})
+	require.NoError(t, err)
+	require.Equal(t, int64(0), avg.Value())
+
+	cmp, err := avg.Compare(&Number{val: 0})
+	require.NoError(t, err)
+	require.Equal(t, 0, cmp)
+}
@@ -15,7 +15,10 @@ limitations under the License.
 */
 package sql
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 type Catalog struct {
 	dbsByID   map[uint32]*Database
@@ -62,8 +65,21 @@ type Column struct {
 	colName       string
 	colType       SQLValueType
 	maxLen        int
+	collation     Collation
 	autoIncrement bool
 	notNull       bool
+	defaultNow    bool
+	updateNow     bool
+	check         ValueExp
+	stats         *ColStats
+}
+
+// ColStats holds the statistics collected by the last analysis of the table
+type ColStats struct {
+	distinctCount uint64
+	nullCount     uint64
+	min           TypedValue
+	max           TypedValue
 }
 
 func newCatalog() *Catalog {
@@ -73,6 +89,96 @@ func newCatalog() *Catalog {
 	}
 }
 
+// Clone returns a deep copy of the catalog, changes made to either of them are not reflected in the other one
+func (c *Catalog) Clone() *Catalog {
+	cc := newCatalog()
+
+	for _, db := range c.dbsByID {
+		cdb := &Database{
+			id:           db.id,
+			catalog:      cc,
+			name:         db.name,
+			tablesByID:   make(map[uint32]*Table, len(db.tablesByID)),
+			tablesByName: make(map[string]*Table, len(db.tablesByName)),
+		}
+
+		for _, t := range db.tablesByID {
+			ct := t.cloneInto(cdb)
+
+			cdb.tablesByID[ct.id] = ct
+			cdb.tablesByName[ct.name] = ct
+		}
+
+		cc.dbsByID[cdb.id] = cdb
+		cc.dbsByName[cdb.name] = cdb
+	}
+
+	return cc
+}
+
+func (t *Table) cloneInto(db *Database) *Table {
+	ct := &Table{
+		db:              db,
+		id:              t.id,
+		name:            t.name,
+		cols:            make([]*Column, len(t.cols)),
+		colsByID:        make(map[uint32]*Column, len(t.colsByID)),
+		colsByName:      make(map[string]*Column, len(t.colsByName)),
+		indexes:         make(map[string]*Index, len(t.indexes)),
+		indexesByColID:  make(map[uint32][]*Index, len(t.indexesByColID)),
+		autoIncrementPK: t.autoIncrementPK,
+		maxPK:           t.maxPK,
+	}
+
+	for i, col := range t.cols {
+		ccol := *col
+		ccol.table = ct
+
+		ct.cols[i] = &ccol
+		ct.colsByID[ccol.id] = &ccol
+		ct.colsByName[ccol.colName] = &ccol
+	}
+
+	clonedIndexes := make(map[*Index]*Index, len(t.indexes))
+
+	for key, index := range t.indexes {
+		cindex := &Index{
+			table:    ct,
+			id:       index.id,
+			unique:   index.unique,
+			cols:     make([]*Column, len(index.cols)),
+			colsByID: make(map[uint32]*Column, len(index.colsByID)),
+		}
+
+		for i, col := range index.cols {
+			cindex.cols[i] = ct.colsByID[col.id]
+			cindex.colsByID[col.id] = ct.colsByID[col.id]
+		}
+
+		ct.indexes[key] = cindex
+		clonedIndexes[index] = cindex
+	}
+
+	for colID, indexes := range t.indexesByColID {
+		cindexes := make([]*Index, len(indexes))
+
+		for i, index := range indexes {
+			cindexes[i] = clonedIndexes[index]
+		}
+
+		ct.indexesByColID[colID] = cindexes
+	}
+
+	ct.primaryIndex = clonedIndexes[t.primaryIndex]
+	ct.autoIncrementIndex = clonedIndexes[t.autoIncrementIndex]
+
+	if t.autoIncrementCol != nil {
+		ct.autoIncrementCol = ct.colsByID[t.autoIncrementCol.id]
+	}
+
+	return ct
+}
+
 func (c *Catalog) ExistDatabase(db string) bool {
 	_, exists := c.dbsByName[db]
 	return exists
@@ -94,6 +200,7 @@ func (c *Catalog) newDatabase(id uint32, name string) (*Database, error) {
 
 	c.dbsByID[db.id] = db
 	c.dbsByName[db.name] = db
+	c.mutated = true
 
 	return db, nil
 }
@@ -313,14 +420,19 @@ func (db *Database) newTable(name string, colsSpec []*ColSpec) (table *Table, er
 			return nil, ErrAutoIncrementWrongType
 		}
 
-		if cs.colType == TimestampType {
-			return nil, fmt.Errorf("%w (%v)", ErrNoSupported, TimestampType)
+		if (cs.defaultNow || cs.updateNow) && cs.colType != TimestampType {
+			return nil, fmt.Errorf("%w (%s)", ErrCurrentTimestampWrongType, cs.colName)
 		}
 
 		if !validMaxLenForType(cs.maxLen, cs.colType) {
 			return nil, ErrLimitedMaxLen
 		}
 
+		collation, err := collationFor(cs.collation, cs.colType)
+		if err != nil {
+			return nil, err
+		}
+
 		id := len(table.colsByID) + 1
 
 		col := &Column{
@@ -329,8 +441,12 @@ func (db *Database) newTable(name string, colsSpec []*ColSpec) (table *Table, er
 			colName:       cs.colName,
 			colType:       cs.colType,
 			maxLen:        cs.maxLen,
+			collation:     collation,
 			autoIncrement: cs.autoIncrement,
 			notNull:       cs.notNull,
+			defaultNow:    cs.defaultNow,
+			updateNow:     cs.updateNow,
+			check:         cs.check,
 		}
 
 		table.cols[i] = col
@@ -338,6 +454,11 @@ func (db *Database) newTable(name string, colsSpec []*ColSpec) (table *Table, er
 		table.colsByName[col.colName] = col
 	}
 
+	err = table.validateChecks()
+	if err != nil {
+		return nil, err
+	}
+
 	db.tablesByID[table.id] = table
 	db.tablesByName[table.name] = table
 	db.catalog.mutated = true
@@ -345,6 +466,34 @@ func (db *Database) newTable(name string, colsSpec []*ColSpec) (table *Table, er
 	return table, nil
 }
 
+// validateChecks ensures check constraints are boolean expressions over the columns of the table
+func (t *Table) validateChecks() error {
+	cols := make(map[string]ColDescriptor, len(t.cols))
+
+	for _, col := range t.cols {
+		colDescriptor := ColDescriptor{Database: t.db.name, Table: t.name, Column: col.colName, Type: col.colType}
+		cols[colDescriptor.Selector()] = colDescriptor
+	}
+
+	for _, col := range t.cols {
+		if col.check == nil {
+			continue
+		}
+
+		_, err := expString(col.check)
+		if err != nil {
+			return err
+		}
+
+		err = col.check.requiresType(BooleanType, cols, nil, t.db.name, t.name)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidCheckConstraint, err)
+		}
+	}
+
+	return nil
+}
+
 func (t *Table) newIndex(unique bool, colIDs []uint32) (index *Index, err error) {
 	if len(colIDs) < 1 {
 		return nil, ErrIllegalArguments
@@ -435,6 +584,53 @@ func (c *Column) MaxLen() int {
 	return c.maxLen
 }
 
+// Stats returns the statistics of the column, nil if the table was not analyzed
+func (c *Column) Stats() *ColStats {
+	return c.stats
+}
+
+func (s *ColStats) DistinctCount() uint64 {
+	return s.distinctCount
+}
+
+func (s *ColStats) NullCount() uint64 {
+	return s.nullCount
+}
+
+// Min returns the smallest non-null value of the column, nil if there is none
+func (s *ColStats) Min() TypedValue {
+	return s.min
+}
+
+// Max returns the greatest non-null value of the column, nil if there is none
+func (s *ColStats) Max() TypedValue {
+	return s.max
+}
+
+func (c *Column) Collation() Collation {
+	return c.collation
+}
+
+// collate makes comparisons involving the value follow the collation of the column
+func (c *Column) collate(val TypedValue) TypedValue {
+	v, ok := val.(*Varchar)
+	if !ok || c.collation == v.collation {
+		return val
+	}
+
+	return &Varchar{val: v.val, collation: c.collation}
+}
+
+// encodeAsKey encodes the value as part of an index key, following the collation of the column
+func (c *Column) encodeAsKey(val interface{}) ([]byte, error) {
+	s, ok := val.(string)
+	if ok && c.collation == NoCaseCollation {
+		val = foldCase(s)
+	}
+
+	return EncodeAsKey(val, c.colType, c.MaxLen())
+}
+
 func (c *Column) IsNullable() bool {
 	return !c.notNull
 }
@@ -443,6 +639,20 @@ func (c *Column) IsAutoIncremental() bool {
 	return c.autoIncrement
 }
 
+func collationFor(collation string, sqlType SQLValueType) (Collation, error) {
+	switch strings.ToUpper(collation) {
+	case "", BinaryCollation:
+		return BinaryCollation, nil
+	case NoCaseCollation:
+		if sqlType != VarcharType {
+			return "", fmt.Errorf("%w: %s can only be used with %s", ErrInvalidCollation, NoCaseCollation, VarcharType)
+		}
+		return NoCaseCollation, nil
+	}
+
+	return "", fmt.Errorf("%w (%s)", ErrInvalidCollation, collation)
+}
+
 func validMaxLenForType(maxLen int, sqlType SQLValueType) bool {
 	switch sqlType {
 	case BooleanType:
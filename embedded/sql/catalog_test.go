@@ -137,3 +137,63 @@ func TestFromEmptyCatalog(t *testing.T) {
 	require.ErrorIs(t, err, ErrDuplicatedColumn)
 
 }
+
+func TestCatalogClone(t *testing.T) {
+	catalog := newCatalog()
+
+	db, err := catalog.newDatabase(1, "db1")
+	require.NoError(t, err)
+
+	table, err := db.newTable("table1", []*ColSpec{
+		{colName: "id", colType: IntegerType, autoIncrement: true},
+		{colName: "title", colType: VarcharType, maxLen: 32},
+	})
+	require.NoError(t, err)
+
+	_, err = table.newIndex(true, []uint32{1})
+	require.NoError(t, err)
+
+	_, err = table.newIndex(false, []uint32{2})
+	require.NoError(t, err)
+
+	table.maxPK = 10
+
+	clone := catalog.Clone()
+
+	cdb, err := clone.GetDatabaseByName("db1")
+	require.NoError(t, err)
+	require.Equal(t, clone, cdb.catalog)
+
+	ctable, err := cdb.GetTableByName("table1")
+	require.NoError(t, err)
+	require.NotSame(t, table, ctable)
+	require.Equal(t, cdb, ctable.db)
+	require.Equal(t, int64(10), ctable.maxPK)
+	require.True(t, ctable.autoIncrementPK)
+	require.Same(t, ctable.colsByID[1], ctable.autoIncrementCol)
+	require.Same(t, ctable.indexes[indexKeyFrom(ctable.cols[:1])], ctable.primaryIndex)
+	require.Same(t, ctable.primaryIndex, ctable.autoIncrementIndex)
+
+	for _, col := range ctable.cols {
+		require.Same(t, ctable, col.table)
+		require.Same(t, col, ctable.colsByName[col.colName])
+	}
+
+	for _, index := range ctable.indexes {
+		require.Same(t, ctable, index.table)
+
+		for _, col := range index.cols {
+			require.Same(t, ctable.colsByID[col.id], col)
+			require.Contains(t, ctable.indexesByColID[col.id], index)
+		}
+	}
+
+	// changes to the clone are not reflected in the original catalog
+	_, err = cdb.newTable("table2", []*ColSpec{{colName: "id", colType: IntegerType}})
+	require.NoError(t, err)
+
+	ctable.maxPK++
+
+	require.False(t, db.ExistTable("table2"))
+	require.Equal(t, int64(10), table.maxPK)
+}
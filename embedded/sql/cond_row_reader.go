@@ -23,6 +23,9 @@ type conditionalRowReader struct {
 	condition ValueExp
 
 	params map[string]interface{}
+
+	// condition with parameters already substituted, reset whenever parameters change
+	substitutedCond ValueExp
 }
 
 func (e *Engine) newConditionalRowReader(rowReader RowReader, condition ValueExp, params map[string]interface{}) (*conditionalRowReader, error) {
@@ -48,7 +51,8 @@ func (cr *conditionalRowReader) SetParameters(params map[string]interface{}) err
 		return err
 	}
 
-	cr.params, err = normalizeParams(params)
+	cr.params, err = cr.e.normalizeParams(params)
+	cr.substitutedCond = nil
 
 	return err
 }
@@ -92,12 +96,14 @@ func (cr *conditionalRowReader) Read() (*Row, error) {
 			return nil, err
 		}
 
-		cond, err := cr.condition.substitute(cr.params)
-		if err != nil {
-			return nil, err
+		if cr.substitutedCond == nil {
+			cr.substitutedCond, err = cr.condition.substitute(cr.params)
+			if err != nil {
+				return nil, err
+			}
 		}
 
-		r, err := cond.reduce(cr.e.catalog, row, cr.rowReader.ImplicitDB(), cr.rowReader.ImplicitTable())
+		r, err := cr.substitutedCond.reduce(cr.e.catalog, row, cr.rowReader.ImplicitDB(), cr.rowReader.ImplicitTable())
 		if err != nil {
 			return nil, err
 		}
@@ -118,6 +124,10 @@ func (cr *conditionalRowReader) Read() (*Row, error) {
 	}
 }
 
+func (cr *conditionalRowReader) Rewind() error {
+	return cr.rowReader.Rewind()
+}
+
 func (cr *conditionalRowReader) Close() error {
 	return cr.rowReader.Close()
 }
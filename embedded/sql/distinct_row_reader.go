@@ -15,7 +15,18 @@ limitations under the License.
 */
 package sql
 
-import "crypto/sha256"
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+
+	"github.com/codenotary/immudb/embedded/tbtree"
+)
+
+// node size of the temporary index, encoded rows sharing a digest must fit in a single node
+const distinctSpillMaxNodeSize = 64 * 1024
 
 type distinctRowReader struct {
 	e *Engine
@@ -24,6 +35,11 @@ type distinctRowReader struct {
 	cols      []ColDescriptor
 
 	readRows map[[sha256.Size]byte]struct{}
+
+	// rows read once readRows reached the spill threshold
+	spillDir   string
+	spillIndex *tbtree.TBtree
+	spillCount int
 }
 
 func (e *Engine) newDistinctRowReader(rowReader RowReader) (*distinctRowReader, error) {
@@ -74,7 +90,7 @@ func (dr *distinctRowReader) InferParameters(params map[string]SQLValueType) err
 
 func (dr *distinctRowReader) Read() (*Row, error) {
 	for {
-		if len(dr.readRows) == dr.e.distinctLimit {
+		if len(dr.readRows)+dr.spillCount == dr.e.distinctLimit {
 			return nil, ErrTooManyRows
 		}
 
@@ -93,12 +109,114 @@ func (dr *distinctRowReader) Read() (*Row, error) {
 			continue
 		}
 
-		dr.readRows[digest] = struct{}{}
+		if dr.e.distinctSpillThld == 0 || len(dr.readRows) < dr.e.distinctSpillThld {
+			dr.readRows[digest] = struct{}{}
+			return row, nil
+		}
 
-		return row, nil
+		spilled, err := dr.spill(digest, row)
+		if err != nil {
+			return nil, err
+		}
+
+		if spilled {
+			return row, nil
+		}
 	}
 }
 
+// spill keeps the row in the temporary index unless it was already read,
+// rows with the same digest are compared using their encoded values
+func (dr *distinctRowReader) spill(digest [sha256.Size]byte, row *Row) (bool, error) {
+	if dr.spillIndex == nil {
+		dir, err := ioutil.TempDir("", "immudb_distinct")
+		if err != nil {
+			return false, err
+		}
+
+		opts := tbtree.DefaultOptions().WithMaxNodeSize(distinctSpillMaxNodeSize)
+
+		index, err := tbtree.Open(dir, opts)
+		if err != nil {
+			os.RemoveAll(dir)
+			return false, err
+		}
+
+		dr.spillDir = dir
+		dr.spillIndex = index
+	}
+
+	encRow, err := row.encode(dr.cols)
+	if err != nil {
+		return false, err
+	}
+
+	// value holds every encoded row with the same digest as len(row) + row
+	encRows, _, _, err := dr.spillIndex.Get(digest[:])
+	if err != nil && err != tbtree.ErrKeyNotFound {
+		return false, err
+	}
+
+	for r := encRows; len(r) > 0; {
+		l := binary.BigEndian.Uint32(r)
+		if bytes.Equal(r[EncLenLen:EncLenLen+l], encRow) {
+			return false, nil
+		}
+		r = r[EncLenLen+l:]
+	}
+
+	var encLen [EncLenLen]byte
+	binary.BigEndian.PutUint32(encLen[:], uint32(len(encRow)))
+
+	v := make([]byte, 0, len(encRows)+EncLenLen+len(encRow))
+	v = append(v, encRows...)
+	v = append(v, encLen[:]...)
+	v = append(v, encRow...)
+
+	err = dr.spillIndex.Insert(digest[:], v)
+	if err != nil {
+		return false, err
+	}
+
+	dr.spillCount++
+
+	return true, nil
+}
+
+func (dr *distinctRowReader) Rewind() error {
+	err := dr.dropSpillIndex()
+	if err != nil {
+		return err
+	}
+
+	dr.readRows = make(map[[sha256.Size]byte]struct{})
+
+	return dr.rowReader.Rewind()
+}
+
+// dropSpillIndex discards the rows spilled so far
+func (dr *distinctRowReader) dropSpillIndex() error {
+	if dr.spillIndex == nil {
+		return nil
+	}
+
+	err := dr.spillIndex.Close()
+	os.RemoveAll(dr.spillDir)
+
+	dr.spillIndex = nil
+	dr.spillDir = ""
+	dr.spillCount = 0
+
+	return err
+}
+
 func (dr *distinctRowReader) Close() error {
-	return dr.rowReader.Close()
+	err := dr.rowReader.Close()
+
+	cerr := dr.dropSpillIndex()
+	if err == nil {
+		err = cerr
+	}
+
+	return err
 }
@@ -16,6 +16,7 @@ limitations under the License.
 package sql
 
 import (
+	"fmt"
 	"os"
 	"testing"
 
@@ -57,3 +58,121 @@ func TestDistinctRowReader(t *testing.T) {
 	err = rowReader.InferParameters(nil)
 	require.Equal(t, errDummy, err)
 }
+
+func TestDistinctRowReaderSpill(t *testing.T) {
+	catalogStore, err := store.Open("catalog_distinct_spill", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_distinct_spill")
+
+	dataStore, err := store.Open("sqldata_distinct_spill", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_distinct_spill")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix).WithDistinctSpillThld(10))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	rowCount := 500
+	distinctCount := 100
+
+	for i := 0; i < rowCount; i += 50 {
+		stmt := "INSERT INTO table1 (id, title) VALUES "
+
+		for j := i; j < i+50; j++ {
+			if j > i {
+				stmt += ", "
+			}
+			stmt += fmt.Sprintf("(%d, 'title%d')", j, j%distinctCount)
+		}
+
+		_, err = engine.ExecStmt(stmt, nil, true)
+		require.NoError(t, err)
+	}
+
+	r, err := engine.QueryStmt("SELECT DISTINCT title FROM table1", nil, true)
+	require.NoError(t, err)
+
+	titles := make(map[string]struct{})
+
+	for {
+		row, err := r.Read()
+		if err == ErrNoMoreRows {
+			break
+		}
+		require.NoError(t, err)
+
+		title := row.Values[EncodeSelector("", "db1", "table1", "title")].Value().(string)
+
+		_, duplicated := titles[title]
+		require.False(t, duplicated)
+
+		titles[title] = struct{}{}
+	}
+
+	require.Len(t, titles, distinctCount)
+
+	dr := r.(*distinctRowReader)
+	require.Len(t, dr.readRows, 10)
+	require.Equal(t, distinctCount-10, dr.spillCount)
+	require.DirExists(t, dr.spillDir)
+
+	err = r.Close()
+	require.NoError(t, err)
+	require.NoDirExists(t, dr.spillDir)
+
+	t.Run("colliding digests are resolved using encoded rows", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT DISTINCT title FROM table1", nil, true)
+		require.NoError(t, err)
+		defer r.Close()
+
+		dr := r.(*distinctRowReader)
+
+		var digest [32]byte
+
+		row1 := &Row{Values: map[string]TypedValue{EncodeSelector("", "db1", "table1", "title"): &Varchar{val: "title1"}}}
+		row2 := &Row{Values: map[string]TypedValue{EncodeSelector("", "db1", "table1", "title"): &Varchar{val: "title2"}}}
+
+		spilled, err := dr.spill(digest, row1)
+		require.NoError(t, err)
+		require.True(t, spilled)
+
+		spilled, err = dr.spill(digest, row2)
+		require.NoError(t, err)
+		require.True(t, spilled)
+
+		spilled, err = dr.spill(digest, row1)
+		require.NoError(t, err)
+		require.False(t, spilled)
+
+		spilled, err = dr.spill(digest, row2)
+		require.NoError(t, err)
+		require.False(t, spilled)
+	})
+
+	t.Run("distinct limit accounts for spilled rows", func(t *testing.T) {
+		engine.distinctLimit = 50
+		defer func() { engine.distinctLimit = defultDistinctLimit }()
+
+		r, err := engine.QueryStmt("SELECT DISTINCT title FROM table1", nil, true)
+		require.NoError(t, err)
+
+		for i := 0; i < 50; i++ {
+			_, err = r.Read()
+			require.NoError(t, err)
+		}
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrTooManyRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+}
@@ -38,6 +38,10 @@ func (r *dummyRowReader) Read() (*Row, error) {
 	return nil, errDummy
 }
 
+func (r *dummyRowReader) Rewind() error {
+	return errDummy
+}
+
 func (r *dummyRowReader) Close() error {
 	return errDummy
 }
@@ -17,13 +17,16 @@ package sql
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
 	"math"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/codenotary/immudb/embedded/store"
 	"github.com/codenotary/immudb/embedded/tbtree"
@@ -31,6 +34,7 @@ import (
 
 var ErrNoSupported = errors.New("not yet supported")
 var ErrIllegalArguments = store.ErrIllegalArguments
+var ErrSavepointDoesNotExist = errors.New("savepoint does not exist")
 var ErrDDLorDMLTxOnly = errors.New("transactions can NOT combine DDL and DML statements")
 var ErrDatabaseDoesNotExist = errors.New("database does not exist")
 var ErrDatabaseAlreadyExists = errors.New("database already exists")
@@ -39,8 +43,9 @@ var ErrTableAlreadyExists = errors.New("table already exists")
 var ErrTableDoesNotExist = errors.New("table does not exist")
 var ErrColumnDoesNotExist = errors.New("column does not exist")
 var ErrColumnNotIndexed = errors.New("column is not indexed")
-var ErrLimitedKeyType = errors.New("indexed key of invalid type. Supported types are: INTEGER, VARCHAR[256] OR BLOB[256]")
+var ErrLimitedKeyType = errors.New("indexed key of invalid type. Supported types are: INTEGER, BOOLEAN, TIMESTAMP, VARCHAR[256] OR BLOB[256]")
 var ErrAutoIncrementWrongType = errors.New("auto incremented column need to be INTEGER type")
+var ErrCurrentTimestampWrongType = errors.New("CURRENT_TIMESTAMP can only be assigned to TIMESTAMP columns")
 var ErrAutoIncrementMultiple = errors.New("several auto incremental column were found. Wrong schema")
 var ErrLimitedAutoIncrement = errors.New("only INTEGER single-column primary keys can be set as auto incremental")
 var ErrLimitedUpsert = errors.New("upsert is only supported in tables without secondary indexes")
@@ -59,6 +64,8 @@ var ErrInvalidNumberOfValues = errors.New("invalid number of values provided")
 var ErrInvalidValue = errors.New("invalid value provided")
 var ErrInferredMultipleTypes = errors.New("inferred multiple types")
 var ErrExpectingDQLStmt = errors.New("illegal statement. DQL statement expected")
+var ErrInvalidTimestamp = errors.New("invalid timestamp")
+var ErrColumnMismatchInUnionStmt = errors.New("column mismatch in union statement")
 var ErrLimitedOrderBy = errors.New("order is limit to one indexed column")
 var ErrLimitedGroupBy = errors.New("group by requires ordering by the grouping column")
 var ErrIllegalMappedKey = errors.New("error illegal mapped key")
@@ -73,6 +80,7 @@ var ErrNotComparableValues = errors.New("values are not comparable")
 var ErrUnexpected = errors.New("unexpected error")
 var ErrMaxKeyLengthExceeded = errors.New("max key length exceeded")
 var ErrMaxLengthExceeded = errors.New("max length exceeded")
+var ErrValueTooLong = fmt.Errorf("%w: value too long", ErrMaxLengthExceeded)
 var ErrColumnIsNotAnAggregation = errors.New("column is not an aggregation")
 var ErrLimitedCount = errors.New("only unbounded counting is supported i.e. COUNT()")
 var ErrTxDoesNotExist = errors.New("tx does not exist")
@@ -80,10 +88,16 @@ var ErrDivisionByZero = errors.New("division by zero")
 var ErrMissingParameter = errors.New("missing parameter")
 var ErrUnsupportedParameter = errors.New("unsupported parameter")
 var ErrDuplicatedParameters = errors.New("duplicated parameters")
-var ErrLimitedIndexCreation = errors.New("index creation is only supported on empty tables")
 var ErrTooManyRows = errors.New("too many rows")
 var ErrAlreadyClosed = errors.New("sql engine already closed")
 var ErrAmbiguousSelector = errors.New("ambiguous selector")
+var ErrAmbiguousColumn = fmt.Errorf("%w: ambiguous column", ErrAmbiguousSelector)
+var ErrInvalidCollation = errors.New("invalid collation")
+var ErrColumnNotGrouped = errors.New("column must appear in the group by clause or be used in an aggregation")
+var ErrRowUpdatedTwice = errors.New("a row can not be updated twice when resolving conflicts")
+var ErrInvalidCheckConstraint = errors.New("invalid check constraint")
+var ErrCheckConstraintViolated = errors.New("check constraint violated")
+var ErrUniqueConstraintViolated = fmt.Errorf("%w: unique constraint violated", store.ErrKeyAlreadyExists)
 
 var maxKeyLen = 256
 var maxKeyVal []byte = greatestKeyOfSize(maxKeyLen)
@@ -97,8 +111,12 @@ type Engine struct {
 	catalogStore *store.ImmuStore
 	dataStore    *store.ImmuStore
 
-	prefix        []byte
-	distinctLimit int
+	prefix            []byte
+	distinctLimit     int
+	distinctSpillThld int
+	sortSpillThld     int
+	maxRowsPerQuery   int
+	now               func() time.Time
 
 	catalog *Catalog // in-mem current catalog (used for INSERT, DDL statements and SELECT statements without UseSnapshotStmt)
 
@@ -118,10 +136,18 @@ func NewEngine(catalogStore, dataStore *store.ImmuStore, opts *Options) (*Engine
 	}
 
 	e := &Engine{
-		catalogStore:  catalogStore,
-		dataStore:     dataStore,
-		prefix:        make([]byte, len(opts.prefix)),
-		distinctLimit: opts.distinctLimit,
+		catalogStore:      catalogStore,
+		dataStore:         dataStore,
+		prefix:            make([]byte, len(opts.prefix)),
+		distinctLimit:     opts.distinctLimit,
+		distinctSpillThld: opts.distinctSpillThld,
+		sortSpillThld:     opts.sortSpillThld,
+		maxRowsPerQuery:   opts.maxRowsPerQuery,
+		now:               opts.now,
+	}
+
+	if e.now == nil {
+		e.now = time.Now
 	}
 
 	copy(e.prefix, opts.prefix)
@@ -279,10 +305,10 @@ func (e *Engine) UseSnapshot(sinceTx uint64, asBeforeTx uint64) error {
 		return ErrAlreadyClosed
 	}
 
-	return e.useSnapshot(sinceTx, asBeforeTx)
+	return e.useSnapshot(sinceTx, asBeforeTx, nil)
 }
 
-func (e *Engine) useSnapshot(sinceTx uint64, asBeforeTx uint64) error {
+func (e *Engine) useSnapshot(sinceTx uint64, asBeforeTx uint64, cancellation <-chan struct{}) error {
 	if sinceTx > 0 && sinceTx < asBeforeTx {
 		return ErrIllegalArguments
 	}
@@ -292,7 +318,7 @@ func (e *Engine) useSnapshot(sinceTx uint64, asBeforeTx uint64) error {
 		return ErrTxDoesNotExist
 	}
 
-	err := e.dataStore.WaitForIndexingUpto(sinceTx, nil)
+	err := e.dataStore.WaitForIndexingUpto(sinceTx, cancellation)
 	if err != nil {
 		return err
 	}
@@ -322,7 +348,7 @@ func (e *Engine) useSnapshot(sinceTx uint64, asBeforeTx uint64) error {
 
 func (e *Engine) getSnapshot() (*store.Snapshot, error) {
 	if e.snapshot == nil {
-		err := e.useSnapshot(0, 0)
+		err := e.useSnapshot(0, 0, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -339,15 +365,15 @@ func (e *Engine) RenewSnapshot() error {
 		return ErrAlreadyClosed
 	}
 
-	return e.renewSnapshot()
+	return e.renewSnapshot(nil)
 }
 
-func (e *Engine) renewSnapshot() error {
+func (e *Engine) renewSnapshot(cancellation <-chan struct{}) error {
 	if e.snapshot == nil {
-		return e.useSnapshot(0, 0)
+		return e.useSnapshot(0, 0, cancellation)
 	}
 
-	return e.useSnapshot(0, e.snapAsBeforeTx)
+	return e.useSnapshot(0, e.snapAsBeforeTx, cancellation)
 }
 
 func (e *Engine) CloseSnapshot() error {
@@ -410,6 +436,20 @@ func (e *Engine) DumpCatalogTo(srcName, dstName string, targetStore *store.ImmuS
 
 	entries = append(entries, colEntries...)
 
+	checkEntries, err := e.entriesWithPrefix(e.mapKey(catalogCheckPrefix, EncodeID(db.ID())), snap)
+	if err != nil {
+		return err
+	}
+
+	entries = append(entries, checkEntries...)
+
+	statsEntries, err := e.entriesWithPrefix(e.mapKey(catalogStatsPrefix, EncodeID(db.ID())), snap)
+	if err != nil {
+		return err
+	}
+
+	entries = append(entries, statsEntries...)
+
 	idxEntries, err := e.entriesWithPrefix(e.mapKey(catalogIndexPrefix), snap)
 	if err != nil {
 		return err
@@ -557,6 +597,11 @@ func (e *Engine) loadTables(db *Database, catalogSnap, dataSnap *store.Snapshot)
 			return err
 		}
 
+		err = e.loadStats(table, catalogSnap)
+		if err != nil {
+			return err
+		}
+
 		if table.autoIncrementPK {
 			encMaxPK, err := e.loadMaxPK(dataSnap, table)
 			if err == store.ErrNoMoreEntries {
@@ -590,7 +635,19 @@ func indexKeyFrom(cols []*Column) string {
 	return buf.String()
 }
 
+// loadMaxPK returns the greatest primary key written since the table was last truncated,
+// keys of deleted rows are also considered so they are not reused
 func (e *Engine) loadMaxPK(dataSnap *store.Snapshot, table *Table) ([]byte, error) {
+	var truncatedAt uint64
+
+	tref, err := dataSnap.Get(e.mapKey(truncatedTablePrefix, EncodeID(table.db.id), EncodeID(table.id)))
+	if err == nil {
+		truncatedAt = tref.Tx()
+	}
+	if err != nil && err != store.ErrKeyNotFound {
+		return nil, err
+	}
+
 	pkReaderSpec := &store.KeyReaderSpec{
 		Prefix:    e.mapKey(table.autoIncrementIndex.prefix(), EncodeID(table.db.id), EncodeID(table.id), EncodeID(table.autoIncrementIndex.id)),
 		DescOrder: true,
@@ -602,12 +659,19 @@ func (e *Engine) loadMaxPK(dataSnap *store.Snapshot, table *Table) ([]byte, erro
 	}
 	defer pkReader.Close()
 
-	mkey, _, err := pkReader.Read()
-	if err != nil {
-		return nil, err
-	}
+	for {
+		mkey, vref, err := pkReader.Read()
+		if err != nil {
+			return nil, err
+		}
 
-	return e.unmapIndexEntry(table.autoIncrementIndex, mkey)
+		// keys deleted by the truncation were written before it
+		if vref.Tx() < truncatedAt || (vref.Tx() == truncatedAt && !store.IgnoreDeleted(vref)) {
+			continue
+		}
+
+		return e.unmapIndexEntry(table.autoIncrementIndex, mkey)
+	}
 }
 
 func (e *Engine) loadColSpecs(dbID, tableID uint32, snap *store.Snapshot) (specs []*ColSpec, err error) {
@@ -658,6 +722,17 @@ func (e *Engine) loadColSpecs(dbID, tableID uint32, snap *store.Snapshot) (specs
 			maxLen:        int(binary.BigEndian.Uint32(v[1:])),
 			autoIncrement: v[0]&autoIncrementFlag != 0,
 			notNull:       v[0]&nullableFlag != 0,
+			defaultNow:    v[0]&defaultNowFlag != 0,
+			updateNow:     v[0]&updateNowFlag != 0,
+		}
+
+		if v[0]&noCaseFlag != 0 {
+			spec.collation = NoCaseCollation
+		}
+
+		spec.check, err = e.loadCheck(dbID, tableID, colID, snap)
+		if err != nil {
+			return nil, err
 		}
 
 		specs = append(specs, spec)
@@ -670,6 +745,52 @@ func (e *Engine) loadColSpecs(dbID, tableID uint32, snap *store.Snapshot) (specs
 	return
 }
 
+func (e *Engine) loadCheck(dbID, tableID, colID uint32, snap *store.Snapshot) (ValueExp, error) {
+	vref, err := snap.Get(e.mapKey(catalogCheckPrefix, EncodeID(dbID), EncodeID(tableID), EncodeID(colID)), store.IgnoreDeleted)
+	if err == store.ErrKeyNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := vref.Resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	check, err := parseExp(string(v))
+	if err != nil {
+		return nil, ErrCorruptedData
+	}
+
+	return check, nil
+}
+
+func (e *Engine) loadStats(table *Table, snap *store.Snapshot) error {
+	for _, col := range table.cols {
+		vref, err := snap.Get(e.mapKey(catalogStatsPrefix, EncodeID(table.db.id), EncodeID(table.id), EncodeID(col.id)), store.IgnoreDeleted)
+		if err == store.ErrKeyNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		v, err := vref.Resolve()
+		if err != nil {
+			return err
+		}
+
+		col.stats, err = decodeColStats(col, v)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (e *Engine) loadIndexes(table *Table, snap *store.Snapshot) error {
 	initialKey := e.mapKey(catalogIndexPrefix, EncodeID(table.db.id), EncodeID(table.id))
 
@@ -882,6 +1003,121 @@ func (e *Engine) unmapIndexEntry(index *Index, mkey []byte) (encPKVals []byte, e
 	return enc[off:], nil
 }
 
+// unmapIndexEntryValues decodes the values of the indexed columns and of the primary key columns of an index entry,
+// encPKVals is ignored for the primary index as its keys are made of the primary key values
+func (e *Engine) unmapIndexEntryValues(index *Index, mkey []byte, encPKVals []byte) (map[uint32]TypedValue, error) {
+	if index == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	enc, err := e.trimPrefix(mkey, []byte(index.prefix()))
+	if err != nil {
+		return nil, ErrCorruptedData
+	}
+
+	if len(enc) <= EncIDLen*3 {
+		return nil, ErrCorruptedData
+	}
+
+	valuesByColID := make(map[uint32]TypedValue, len(index.cols)+len(index.table.primaryIndex.cols))
+
+	n, err := decodeKeyValues(enc[EncIDLen*3:], index.cols, valuesByColID)
+	if err != nil {
+		return nil, err
+	}
+
+	if index.IsPrimary() {
+		if n != len(enc)-EncIDLen*3 {
+			return nil, ErrCorruptedData
+		}
+
+		return valuesByColID, nil
+	}
+
+	n, err = decodeKeyValues(encPKVals, index.table.primaryIndex.cols, valuesByColID)
+	if err != nil {
+		return nil, err
+	}
+
+	if n != len(encPKVals) {
+		return nil, ErrCorruptedData
+	}
+
+	return valuesByColID, nil
+}
+
+// decodeKeyValues decodes the values of the columns, encoded one after the other as done by EncodeAsKey,
+// returning the number of bytes read
+func decodeKeyValues(b []byte, cols []*Column, valuesByColID map[uint32]TypedValue) (int, error) {
+	off := 0
+
+	for _, col := range cols {
+		val, n, err := DecodeKeyValue(b[off:], col.colType, col.MaxLen())
+		if err != nil {
+			return 0, err
+		}
+
+		valuesByColID[col.id] = val
+		off += n
+	}
+
+	return off, nil
+}
+
+// DecodeKeyValue decodes a value encoded with EncodeAsKey, returning the number of bytes read
+func DecodeKeyValue(b []byte, colType SQLValueType, maxLen int) (TypedValue, int, error) {
+	switch colType {
+	case VarcharType, BLOBType:
+		{
+			if len(b) < maxLen+EncLenLen {
+				return nil, 0, ErrCorruptedData
+			}
+
+			vlen := int(binary.BigEndian.Uint32(b[maxLen:]))
+			if vlen > maxLen {
+				return nil, 0, ErrCorruptedData
+			}
+
+			v := make([]byte, vlen)
+			copy(v, b)
+
+			if colType == VarcharType {
+				return &Varchar{val: string(v)}, maxLen + EncLenLen, nil
+			}
+
+			return &Blob{val: v}, maxLen + EncLenLen, nil
+		}
+	case IntegerType, TimestampType:
+		{
+			if len(b) < 8 {
+				return nil, 0, ErrCorruptedData
+			}
+
+			var encv [8]byte
+			copy(encv[:], b)
+			encv[0] ^= 0x80
+
+			v := int64(binary.BigEndian.Uint64(encv[:]))
+
+			if colType == TimestampType {
+				return &Timestamp{val: v}, 8, nil
+			}
+
+			return &Number{val: v}, 8, nil
+		}
+	case BooleanType:
+		{
+			if len(b) < 1 {
+				return nil, 0, ErrCorruptedData
+			}
+
+			return &Bool{val: b[0] == 1}, 1, nil
+		}
+	}
+
+	return nil, 0, ErrCorruptedData
+}
+
 func variableSized(sqlType SQLValueType) bool {
 	return sqlType == VarcharType || sqlType == BLOBType
 }
@@ -927,7 +1163,7 @@ func maxKeyValOf(colType SQLValueType) []byte {
 		{
 			return maxKeyVal[:1]
 		}
-	case IntegerType:
+	case IntegerType, TimestampType:
 		{
 			return maxKeyVal[:8]
 		}
@@ -936,6 +1172,11 @@ func maxKeyValOf(colType SQLValueType) []byte {
 }
 
 func EncodeValue(val interface{}, colType SQLValueType, maxLen int) ([]byte, error) {
+	return appendEncodedValue(nil, val, colType, maxLen)
+}
+
+// appendEncodedValue appends the encoding of the value to dst, so the same buffer can be used to encode several values
+func appendEncodedValue(dst []byte, val interface{}, colType SQLValueType, maxLen int) ([]byte, error) {
 	switch colType {
 	case VarcharType:
 		{
@@ -949,14 +1190,13 @@ func EncodeValue(val interface{}, colType SQLValueType, maxLen int) ([]byte, err
 			}
 
 			// len(v) + v
-			encv := make([]byte, EncLenLen+len(strVal))
-			binary.BigEndian.PutUint32(encv[:], uint32(len(strVal)))
-			copy(encv[EncLenLen:], []byte(strVal))
+			dst = appendEncLen(grow(dst, EncLenLen+len(strVal)), len(strVal))
 
-			return encv, nil
+			return append(dst, strVal...), nil
 		}
-	case IntegerType:
+	case IntegerType, TimestampType:
 		{
+			// timestamps are encoded as unix nanos
 			intVal, ok := val.(int64)
 			if !ok {
 				return nil, ErrInvalidValue
@@ -968,7 +1208,7 @@ func EncodeValue(val interface{}, colType SQLValueType, maxLen int) ([]byte, err
 			binary.BigEndian.PutUint32(encv[:], uint32(8))
 			binary.BigEndian.PutUint64(encv[EncLenLen:], uint64(intVal))
 
-			return encv[:], nil
+			return append(dst, encv[:]...), nil
 		}
 	case BooleanType:
 		{
@@ -984,7 +1224,7 @@ func EncodeValue(val interface{}, colType SQLValueType, maxLen int) ([]byte, err
 				encv[EncLenLen] = 1
 			}
 
-			return encv[:], nil
+			return append(dst, encv[:]...), nil
 		}
 	case BLOBType:
 		{
@@ -1003,11 +1243,9 @@ func EncodeValue(val interface{}, colType SQLValueType, maxLen int) ([]byte, err
 			}
 
 			// len(v) + v
-			encv := make([]byte, EncLenLen+len(blobVal))
-			binary.BigEndian.PutUint32(encv[:], uint32(len(blobVal)))
-			copy(encv[EncLenLen:], blobVal)
+			dst = appendEncLen(grow(dst, EncLenLen+len(blobVal)), len(blobVal))
 
-			return encv[:], nil
+			return append(dst, blobVal...), nil
 		}
 	}
 
@@ -1018,6 +1256,22 @@ func EncodeValue(val interface{}, colType SQLValueType, maxLen int) ([]byte, err
 	return nil, ErrInvalidValue
 }
 
+func appendEncLen(dst []byte, l int) []byte {
+	var encLen [EncLenLen]byte
+	binary.BigEndian.PutUint32(encLen[:], uint32(l))
+
+	return append(dst, encLen[:]...)
+}
+
+// grow ensures there is room for n more bytes in dst
+func grow(dst []byte, n int) []byte {
+	if cap(dst)-len(dst) >= n {
+		return dst
+	}
+
+	return append(dst, make([]byte, n)...)[:len(dst)]
+}
+
 func EncodeAsKey(val interface{}, colType SQLValueType, maxLen int) ([]byte, error) {
 	if val == nil || maxLen <= 0 {
 		return nil, ErrInvalidValue
@@ -1034,19 +1288,11 @@ func EncodeAsKey(val interface{}, colType SQLValueType, maxLen int) ([]byte, err
 				return nil, ErrInvalidValue
 			}
 
-			if len(strVal) > maxLen {
-				return nil, ErrMaxLengthExceeded
-			}
-
-			// value + padding + len(value)
-			encv := make([]byte, maxLen+EncLenLen)
-			copy(encv, []byte(strVal))
-			binary.BigEndian.PutUint32(encv[len(encv)-EncLenLen:], uint32(len(strVal)))
-
-			return encv, nil
+			return encodeVariableSizedKey([]byte(strVal), maxLen)
 		}
-	case IntegerType:
+	case IntegerType, TimestampType:
 		{
+			// timestamps are encoded as unix nanos
 			if maxLen != 8 {
 				return nil, ErrCorruptedData
 			}
@@ -1090,16 +1336,7 @@ func EncodeAsKey(val interface{}, colType SQLValueType, maxLen int) ([]byte, err
 				return nil, ErrInvalidValue
 			}
 
-			if len(blobVal) > maxLen {
-				return nil, ErrMaxLengthExceeded
-			}
-
-			// value + padding + len(value)
-			encv := make([]byte, maxLen+EncLenLen)
-			copy(encv, []byte(blobVal))
-			binary.BigEndian.PutUint32(encv[len(encv)-EncLenLen:], uint32(len(blobVal)))
-
-			return encv, nil
+			return encodeVariableSizedKey(blobVal, maxLen)
 		}
 	}
 
@@ -1110,6 +1347,23 @@ func EncodeAsKey(val interface{}, colType SQLValueType, maxLen int) ([]byte, err
 	return nil, ErrInvalidValue
 }
 
+// encodeVariableSizedKey encodes the value as {val}{padding}{valLen}, where the value is padded with zeros up to maxLen.
+// As all the keys of a column have the same size, they are compared by their padded values and then by their lengths,
+// thus distinct values never collide (e.g. "a" and "a\x00") and the order of the values is preserved,
+// maxLen is expected to be already validated by the caller to be within (0, maxKeyLen]
+func encodeVariableSizedKey(val []byte, maxLen int) ([]byte, error) {
+	// the padding can not be negative, otherwise the value would get truncated
+	if len(val) > maxLen {
+		return nil, ErrMaxLengthExceeded
+	}
+
+	encv := make([]byte, maxLen+EncLenLen)
+	copy(encv, val)
+	binary.BigEndian.PutUint32(encv[maxLen:], uint32(len(val)))
+
+	return encv, nil
+}
+
 func DecodeValue(b []byte, colType SQLValueType) (TypedValue, int, error) {
 	if len(b) < EncLenLen {
 		return nil, 0, ErrCorruptedData
@@ -1141,6 +1395,17 @@ func DecodeValue(b []byte, colType SQLValueType) (TypedValue, int, error) {
 
 			return &Number{val: int64(v)}, voff, nil
 		}
+	case TimestampType:
+		{
+			if vlen != 8 {
+				return nil, 0, ErrCorruptedData
+			}
+
+			v := binary.BigEndian.Uint64(b[voff:])
+			voff += vlen
+
+			return &Timestamp{val: int64(v)}, voff, nil
+		}
 	case BooleanType:
 		{
 			if vlen != 1 {
@@ -1220,6 +1485,37 @@ func (e *Engine) InferParameters(sql string) (map[string]SQLValueType, error) {
 	return e.inferParametersFrom(strings.NewReader(sql))
 }
 
+// InferParameterTypes returns the type name expected by each parameter of the given sql,
+// AnyType is used for parameters whose type is not constrained by the statements
+func (e *Engine) InferParameterTypes(sql string) (map[string]string, error) {
+	params, err := e.InferParameters(sql)
+	if err != nil {
+		return nil, err
+	}
+
+	types := make(map[string]string, len(params))
+
+	for name, t := range params {
+		types[name] = t
+	}
+
+	return types, nil
+}
+
+// Validate type-checks every statement of the given sql without executing any of them.
+// Statements are checked against the current catalog, thus objects the sql itself would create are not known
+func (e *Engine) Validate(sql string) error {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	if e.closed {
+		return ErrAlreadyClosed
+	}
+
+	_, err := e.inferParametersFrom(strings.NewReader(sql))
+	return err
+}
+
 func (e *Engine) inferParametersFrom(r io.ByteReader) (map[string]SQLValueType, error) {
 	stmts, err := Parse(r)
 	if err != nil {
@@ -1231,6 +1527,10 @@ func (e *Engine) inferParametersFrom(r io.ByteReader) (map[string]SQLValueType,
 		return nil, ErrCatalogNotReady
 	}
 
+	return e.inferParametersOf(stmts)
+}
+
+func (e *Engine) inferParametersOf(stmts []SQLStmt) (map[string]SQLValueType, error) {
 	implicitDB, err := e.databaseInUse()
 	if err != nil {
 		return nil, err
@@ -1279,10 +1579,20 @@ func (e *Engine) InferParametersPreparedStmt(stmt SQLStmt) (map[string]SQLValueT
 
 // exist database directly on catalogStore: // existKey(e.mapKey(catalogDatabase, db), e.catalogStore)
 func (e *Engine) QueryStmt(sql string, params map[string]interface{}, renewSnapshot bool) (RowReader, error) {
-	return e.Query(strings.NewReader(sql), params, renewSnapshot)
+	return e.QueryContext(context.Background(), strings.NewReader(sql), params, renewSnapshot)
+}
+
+func (e *Engine) QueryStmtContext(ctx context.Context, sql string, params map[string]interface{}, renewSnapshot bool) (RowReader, error) {
+	return e.QueryContext(ctx, strings.NewReader(sql), params, renewSnapshot)
 }
 
 func (e *Engine) Query(sql io.ByteReader, params map[string]interface{}, renewSnapshot bool) (RowReader, error) {
+	return e.QueryContext(context.Background(), sql, params, renewSnapshot)
+}
+
+// QueryContext resolves a query whose rows can no longer be read once the context is done,
+// Read then returns the error of the context
+func (e *Engine) QueryContext(ctx context.Context, sql io.ByteReader, params map[string]interface{}, renewSnapshot bool) (RowReader, error) {
 	stmts, err := Parse(sql)
 	if err != nil {
 		return nil, err
@@ -1291,16 +1601,20 @@ func (e *Engine) Query(sql io.ByteReader, params map[string]interface{}, renewSn
 		return nil, ErrExpectingDQLStmt
 	}
 
-	stmt, ok := stmts[0].(*SelectStmt)
+	stmt, ok := stmts[0].(DQLStmt)
 	if !ok {
 		return nil, ErrExpectingDQLStmt
 	}
 
-	return e.QueryPreparedStmt(stmt, params, renewSnapshot)
+	return e.QueryPreparedStmtContext(ctx, stmt, params, renewSnapshot)
 }
 
-func (e *Engine) QueryPreparedStmt(stmt *SelectStmt, params map[string]interface{}, renewSnapshot bool) (RowReader, error) {
-	if stmt == nil {
+func (e *Engine) QueryPreparedStmt(stmt DQLStmt, params map[string]interface{}, renewSnapshot bool) (RowReader, error) {
+	return e.QueryPreparedStmtContext(context.Background(), stmt, params, renewSnapshot)
+}
+
+func (e *Engine) QueryPreparedStmtContext(ctx context.Context, stmt DQLStmt, params map[string]interface{}, renewSnapshot bool) (RowReader, error) {
+	if ctx == nil || stmt == nil {
 		return nil, ErrIllegalArguments
 	}
 
@@ -1317,7 +1631,10 @@ func (e *Engine) QueryPreparedStmt(stmt *SelectStmt, params map[string]interface
 	}
 
 	if renewSnapshot {
-		err := e.renewSnapshot()
+		err := e.renewSnapshot(ctx.Done())
+		if err != nil && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 		if err != nil && err != tbtree.ErrReadersNotClosed {
 			return nil, err
 		}
@@ -1328,13 +1645,15 @@ func (e *Engine) QueryPreparedStmt(stmt *SelectStmt, params map[string]interface
 		return nil, err
 	}
 
+	// statements not bound to a database e.g. SHOW DATABASES can be resolved without one in use,
+	// the remaining ones fail when being compiled
 	implicitDB, err := e.databaseInUse()
-	if err != nil {
+	if err != nil && err != ErrNoDatabaseSelected {
 		return nil, err
 	}
 
 	// TODO: eval params at once
-	nparams, err := normalizeParams(params)
+	nparams, err := e.normalizeParams(params)
 	if err != nil {
 		return nil, err
 	}
@@ -1344,7 +1663,37 @@ func (e *Engine) QueryPreparedStmt(stmt *SelectStmt, params map[string]interface
 		return nil, err
 	}
 
-	return stmt.Resolve(e, snapshot, implicitDB, nparams, nil)
+	return stmt.Resolve(ctx, e, snapshot, implicitDB, nparams, nil)
+}
+
+// QueryAll resolves a query and returns all of its rows at once,
+// ErrTooManyRows is returned when they exceed the configured max rows per query
+func (e *Engine) QueryAll(sql string, params map[string]interface{}) ([]*Row, error) {
+	r, err := e.QueryStmt(sql, params, true)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var rows []*Row
+
+	for {
+		row, err := r.Read()
+		if err == ErrNoMoreRows {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if e.maxRowsPerQuery > 0 && len(rows) == e.maxRowsPerQuery {
+			return nil, fmt.Errorf("%w: max rows per query is %d", ErrTooManyRows, e.maxRowsPerQuery)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
 }
 
 func (e *Engine) ExecStmt(sql string, params map[string]interface{}, waitForIndexing bool) (summary *ExecSummary, err error) {
@@ -1368,6 +1717,26 @@ type ExecSummary struct {
 	LastInsertedPKs map[string]int64
 }
 
+// ExecResult only holds the number of rows affected by the executed statements,
+// leaving out the details of the committed transactions
+type ExecResult struct {
+	rowsAffected int
+}
+
+func (r *ExecResult) RowsAffected() int {
+	return r.rowsAffected
+}
+
+// ExecCount executes the statements as done by ExecStmt but only reports the number of affected rows
+func (e *Engine) ExecCount(sql string, params map[string]interface{}, waitForIndexing bool) (*ExecResult, error) {
+	summary, err := e.ExecStmt(sql, params, waitForIndexing)
+	if summary == nil {
+		return nil, err
+	}
+
+	return &ExecResult{rowsAffected: summary.UpdatedRows}, err
+}
+
 func (e *Engine) ExecPreparedStmts(stmts []SQLStmt, params map[string]interface{}, waitForIndexing bool) (summary *ExecSummary, err error) {
 	if len(stmts) == 0 {
 		return nil, ErrIllegalArguments
@@ -1397,66 +1766,244 @@ func (e *Engine) ExecPreparedStmts(stmts []SQLStmt, params map[string]interface{
 	}
 
 	// TODO: eval params at once
-	nparams, err := normalizeParams(params)
+	nparams, err := e.normalizeParams(params)
 	if err != nil {
 		return nil, err
 	}
 
 	for _, stmt := range stmts {
+		revertCatalog := e.catalogReverter(stmt, implicitDB)
+
 		txSummary, err := stmt.compileUsing(e, implicitDB, nparams)
 		if err != nil {
-			e.resetCatalog() // in-memory catalog changes needs to be reverted
+			revertCatalog() // in-memory catalog changes needs to be reverted
 			return summary, err
 		}
 
 		implicitDB = txSummary.db
 
-		if len(txSummary.ces) > 0 && len(txSummary.des) > 0 {
-			e.resetCatalog() // in-memory catalog changes needs to be reverted
-			return summary, ErrDDLorDMLTxOnly
+		err = e.commitTxSummary(txSummary, waitForIndexing, summary)
+		if err != nil {
+			revertCatalog() // in-memory catalog changes needs to be reverted
+			return summary, err
 		}
+	}
 
-		if len(txSummary.ces) > 0 {
-			txmd, err := e.catalogStore.Commit(&store.TxSpec{
-				Entries:         txSummary.ces,
-				WaitForIndexing: waitForIndexing,
-			})
-			// TODO (jeroiraz): implement transactional in-memory catalog
-			if err != nil {
-				e.resetCatalog() // in-memory catalog changes needs to be reverted
-				return summary, err
-			}
+	e.catalog.mutated = false
 
-			summary.DDTxs = append(summary.DDTxs, txmd)
+	return summary, nil
+}
+
+// catalogReverter returns the function restoring the in-memory catalog as it was before compiling the statement,
+// the catalog is only copied when the statement may change more than the auto-incremental counter of a table
+func (e *Engine) catalogReverter(stmt SQLStmt, implicitDB *Database) func() {
+	switch s := stmt.(type) {
+	case *SelectStmt, *UnionStmt, *UpdateStmt, *DeleteFromStmt, *UseDatabaseStmt, *UseSnapshotStmt,
+		*DescribeTableStmt, *ShowTablesStmt, *ShowDatabasesStmt:
+		return func() {}
+	case *UpsertIntoStmt:
+		table, err := s.tableRef.referencedTable(e, implicitDB)
+		if err != nil {
+			// the statement is rejected without changing the catalog
+			return func() {}
 		}
 
-		if len(txSummary.des) > 0 {
-			txmd, err := e.dataStore.Commit(&store.TxSpec{
-				Entries:         txSummary.des,
-				WaitForIndexing: waitForIndexing,
-			})
-			if err != nil {
-				e.resetCatalog() // in-memory catalog changes needs to be reverted
-				return summary, err
-			}
+		maxPK := table.maxPK
+
+		return func() { table.maxPK = maxPK }
+	}
+
+	committedCatalog := e.catalog.Clone()
+
+	return func() { e.resetCatalog(committedCatalog) }
+}
+
+// commitTxSummary commits the entries of a compiled statement and accumulates its outcome into summary,
+// in-memory catalog changes must be reverted by the caller if the statement can not be committed
+func (e *Engine) commitTxSummary(txSummary *TxSummary, waitForIndexing bool, summary *ExecSummary) error {
+	if len(txSummary.ces) > 0 && len(txSummary.des) > 0 {
+		return ErrDDLorDMLTxOnly
+	}
 
-			summary.DMTxs = append(summary.DMTxs, txmd)
+	// entries are committed into the catalog and then into the data store, limits are checked upfront
+	// so to not commit the catalog entries when the remaining ones would be rejected
+	if len(txSummary.ces) > e.catalogStore.MaxTxEntries() ||
+		len(txSummary.ies) > e.dataStore.MaxTxEntries() ||
+		len(txSummary.des) > e.dataStore.MaxTxEntries() {
+		return store.ErrorMaxTxEntriesLimitExceeded
+	}
+
+	if len(txSummary.ces) > 0 {
+		txmd, err := e.catalogStore.Commit(&store.TxSpec{
+			Entries:         txSummary.ces,
+			WaitForIndexing: waitForIndexing,
+		})
+		if err != nil {
+			return err
 		}
 
-		summary.UpdatedRows += txSummary.updatedRows
+		summary.DDTxs = append(summary.DDTxs, txmd)
+	}
 
-		for t, pk := range txSummary.lastInsertedPKs {
-			summary.LastInsertedPKs[t] = pk
+	if len(txSummary.ies) > 0 {
+		txmd, err := e.dataStore.Commit(&store.TxSpec{
+			Entries:         txSummary.ies,
+			WaitForIndexing: waitForIndexing,
+		})
+		if err != nil {
+			// catalog entries are already committed, indexes would be left registered but not backfilled
+			return e.unregisterIndexes(txSummary.ces, err)
+		}
+
+		summary.DMTxs = append(summary.DMTxs, txmd)
+	}
+
+	if len(txSummary.des) > 0 {
+		txmd, err := e.dataStore.Commit(&store.TxSpec{
+			Entries:         txSummary.des,
+			WaitForIndexing: waitForIndexing,
+		})
+		if err != nil {
+			return err
+		}
+
+		summary.DMTxs = append(summary.DMTxs, txmd)
+	}
+
+	summary.UpdatedRows += txSummary.updatedRows
+
+	for t, pk := range txSummary.lastInsertedPKs {
+		summary.LastInsertedPKs[t] = pk
+	}
+
+	return nil
+}
+
+// unregisterIndexes marks as deleted the catalog entries of the indexes created in a transaction
+// whose backfilled entries could not be committed, so they are not loaded with the catalog
+func (e *Engine) unregisterIndexes(ces []*store.EntrySpec, cause error) error {
+	indexPrefix := e.mapKey(catalogIndexPrefix)
+
+	var entries []*store.EntrySpec
+
+	for _, ce := range ces {
+		if !bytes.HasPrefix(ce.Key, indexPrefix) {
+			continue
+		}
+
+		entries = append(entries, &store.EntrySpec{
+			Key:      ce.Key,
+			Metadata: store.NewKVMetadata().AsDeleted(true),
+		})
+	}
+
+	if len(entries) == 0 {
+		return cause
+	}
+
+	_, err := e.catalogStore.Commit(&store.TxSpec{Entries: entries, WaitForIndexing: true})
+	if err != nil {
+		return fmt.Errorf("%w: indexes not backfilled could not be unregistered (%v)", cause, err)
+	}
+
+	return cause
+}
+
+// StmtResult holds the outcome of a single statement executed by ExecAll
+type StmtResult struct {
+	UpdatedRows     int
+	LastInsertedPKs map[string]int64
+}
+
+// ExecAll executes all the statements as a single transaction, as done for the statements
+// of a BEGIN TRANSACTION ... COMMIT block, but results are reported for each statement
+func (e *Engine) ExecAll(stmts []SQLStmt, params map[string]interface{}, waitForIndexing bool) ([]*StmtResult, error) {
+	if len(stmts) == 0 {
+		return nil, ErrIllegalArguments
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.closed {
+		return nil, ErrAlreadyClosed
+	}
+
+	if e.catalog == nil {
+		err := e.loadCatalog(nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	implicitDB, err := e.databaseInUse()
+	if err != nil && err != ErrNoDatabaseSelected {
+		return nil, err
+	}
+
+	nparams, err := e.normalizeParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	committedCatalog := e.catalog.Clone()
+
+	txSummary := newTxSummary(implicitDB)
+
+	results := make([]*StmtResult, len(stmts))
+
+	for i, stmt := range stmts {
+		err = checkIndexAfterDML(stmt, txSummary)
+		if err != nil {
+			e.resetCatalog(committedCatalog) // in-memory catalog changes needs to be reverted
+			return nil, err
+		}
+
+		stmtSummary, err := stmt.compileUsing(e, txSummary.db, nparams)
+		if err != nil {
+			e.resetCatalog(committedCatalog) // in-memory catalog changes needs to be reverted
+			return nil, err
+		}
+
+		err = txSummary.add(stmtSummary)
+		if err != nil {
+			e.resetCatalog(committedCatalog) // in-memory catalog changes needs to be reverted
+			return nil, err
+		}
+
+		results[i] = &StmtResult{
+			UpdatedRows:     stmtSummary.updatedRows,
+			LastInsertedPKs: stmtSummary.lastInsertedPKs,
 		}
 	}
 
+	err = e.commitTxSummary(txSummary, waitForIndexing, &ExecSummary{LastInsertedPKs: make(map[string]int64)})
+	if err != nil {
+		e.resetCatalog(committedCatalog) // in-memory catalog changes needs to be reverted
+		return nil, err
+	}
+
 	e.catalog.mutated = false
 
-	return summary, nil
+	return results, nil
 }
 
-func normalizeParams(params map[string]interface{}) (map[string]interface{}, error) {
-	nparams := make(map[string]interface{}, len(params))
+// PositionalParams builds the params map expected by Exec and Query from values
+// bound, in order, to the positional placeholders (? or $n) of a statement
+func PositionalParams(values ...interface{}) map[string]interface{} {
+	params := make(map[string]interface{}, len(values))
+
+	for i, v := range values {
+		params["param"+strconv.Itoa(i+1)] = v
+	}
+
+	return params
+}
+
+// normalizeParams lower-cases parameter names and binds the timestamp returned by NOW(),
+// so to be the same for all the statements being executed
+func (e *Engine) normalizeParams(params map[string]interface{}) (map[string]interface{}, error) {
+	nparams := make(map[string]interface{}, len(params)+1)
 
 	for name, value := range params {
 		nname := strings.ToLower(name)
@@ -1469,13 +2016,17 @@ func normalizeParams(params map[string]interface{}) (map[string]interface{}, err
 		nparams[nname] = value
 	}
 
+	nparams[nowParam] = e.now().UnixNano()
+
 	return nparams, nil
 }
 
-func (e *Engine) resetCatalog() {
+// resetCatalog restores the copy of the in-memory catalog taken before compiling statements,
+// the catalog is kept when it was not mutated so references to it remain valid
+func (e *Engine) resetCatalog(committed *Catalog) {
 	if !e.catalog.mutated {
 		return
 	}
 
-	e.catalog = nil
+	e.catalog = committed
 }
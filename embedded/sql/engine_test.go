@@ -16,11 +16,16 @@ limitations under the License.
 package sql
 
 import (
+	"bytes"
+	"context"
+	"encoding/binary"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"math"
+	"math/rand"
 	"os"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -134,11 +139,12 @@ func TestCreateTable(t *testing.T) {
 	err = engine.UseDatabase("db1")
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, ts TIMESTAMP, PRIMARY KEY id)", nil, true)
-	require.ErrorIs(t, err, ErrNoSupported)
+	_, err = engine.ExecStmt("CREATE TABLE timestamp_table (id INTEGER, ts TIMESTAMP, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
 
 	_, err = engine.ExecStmt("CREATE TABLE table1 (name VARCHAR, PRIMARY KEY id)", nil, true)
-	require.Equal(t, ErrColumnDoesNotExist, err)
+	require.ErrorIs(t, err, ErrColumnDoesNotExist)
+	require.Contains(t, err.Error(), "(id)")
 
 	_, err = engine.ExecStmt("CREATE TABLE table1 (name VARCHAR, PRIMARY KEY name)", nil, true)
 	require.ErrorIs(t, err, ErrLimitedKeyType)
@@ -162,6 +168,102 @@ func TestCreateTable(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestCreateTableWithCompositePK(t *testing.T) {
+	catalogStore, err := store.Open("catalog_composite_pk", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_composite_pk")
+
+	dataStore, err := store.Open("sqldata_composite_pk", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_composite_pk")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	t.Run("unbounded variable-sized primary key columns are rejected", func(t *testing.T) {
+		_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, name VARCHAR, PRIMARY KEY (id, name))", nil, true)
+		require.ErrorIs(t, err, ErrLimitedKeyType)
+		require.Contains(t, err.Error(), "(name)")
+
+		_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, payload BLOB[1024], PRIMARY KEY (id, payload))", nil, true)
+		require.ErrorIs(t, err, ErrLimitedKeyType)
+		require.Contains(t, err.Error(), "(payload)")
+
+		_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, name VARCHAR[50], PRIMARY KEY (id, id))", nil, true)
+		require.ErrorIs(t, err, ErrDuplicatedColumn)
+
+		_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, name VARCHAR[50], PRIMARY KEY (id, title))", nil, true)
+		require.ErrorIs(t, err, ErrColumnDoesNotExist)
+		require.Contains(t, err.Error(), "(title)")
+	})
+
+	t.Run("primary keys can be made of any of the supported key types", func(t *testing.T) {
+		_, err = engine.ExecStmt(`
+			CREATE TABLE key_types (
+				i INTEGER, b BOOLEAN, ts TIMESTAMP, v VARCHAR[256], p BLOB[256],
+				PRIMARY KEY (i, b, ts, v, p)
+			)`, nil, true)
+		require.NoError(t, err)
+	})
+
+	t.Run("tables with an invalid primary key are not registered", func(t *testing.T) {
+		stmts, err := ParseString("CREATE TABLE table1 (id INTEGER, name VARCHAR, PRIMARY KEY (id, name))")
+		require.NoError(t, err)
+		require.Len(t, stmts, 1)
+
+		db, err := engine.catalog.GetDatabaseByName("db1")
+		require.NoError(t, err)
+
+		_, err = stmts[0].compileUsing(engine, db, nil)
+		require.ErrorIs(t, err, ErrLimitedKeyType)
+		require.False(t, db.ExistTable("table1"))
+	})
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, name VARCHAR[50], amount INTEGER, PRIMARY KEY (id, name))", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		INSERT INTO table1 (id, name, amount)
+		VALUES (2, 'b', 20), (1, 'b', 10), (2, 'a', 30), (1, 'a', 40)`, nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, name, amount) VALUES (1, 'a', 50)", nil, true)
+	require.ErrorIs(t, err, store.ErrKeyAlreadyExists)
+
+	r, err := engine.QueryStmt("SELECT id, name, amount FROM table1", nil, true)
+	require.NoError(t, err)
+
+	// rows are sorted following the order in which primary key columns are declared
+	for _, e := range []struct {
+		id     int64
+		name   string
+		amount int64
+	}{
+		{1, "a", 40},
+		{1, "b", 10},
+		{2, "a", 30},
+		{2, "b", 20},
+	} {
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, e.id, row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+		require.Equal(t, e.name, row.Values[EncodeSelector("", "db1", "table1", "name")].Value())
+		require.Equal(t, e.amount, row.Values[EncodeSelector("", "db1", "table1", "amount")].Value())
+	}
+
+	_, err = r.Read()
+	require.Equal(t, ErrNoMoreRows, err)
+
+	err = r.Close()
+	require.NoError(t, err)
+}
+
 func TestDumpCatalogTo(t *testing.T) {
 	catalogStore, err := store.Open("dump_catalog_catalog", store.DefaultOptions())
 	require.NoError(t, err)
@@ -242,7 +344,8 @@ func TestAddColumn(t *testing.T) {
 	require.NoError(t, err)
 
 	_, err = engine.ExecStmt("CREATE TABLE table1 (name VARCHAR, PRIMARY KEY id)", nil, true)
-	require.Equal(t, ErrColumnDoesNotExist, err)
+	require.ErrorIs(t, err, ErrColumnDoesNotExist)
+	require.Contains(t, err.Error(), "(id)")
 
 	_, err = engine.ExecStmt("ALTER TABLE table1 ADD COLUMN surname VARCHAR", nil, true)
 	require.Equal(t, ErrNoSupported, err)
@@ -322,17 +425,17 @@ func TestCreateIndex(t *testing.T) {
 	require.ErrorIs(t, err, ErrPKCanNotBeNull)
 
 	_, err = engine.ExecStmt("CREATE INDEX ON table1(active)", nil, true)
-	require.Equal(t, ErrLimitedIndexCreation, err)
+	require.ErrorIs(t, err, ErrIndexedColumnCanNotBeNull)
 }
 
-func TestUpsertInto(t *testing.T) {
-	catalogStore, err := store.Open("catalog_upsert", store.DefaultOptions())
+func TestCreateIndexOnNonEmptyTable(t *testing.T) {
+	catalogStore, err := store.Open("catalog_create_index_backfill", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("catalog_upsert")
+	defer os.RemoveAll("catalog_create_index_backfill")
 
-	dataStore, err := store.Open("sqldata_upsert", store.DefaultOptions())
+	dataStore, err := store.Open("sqldata_create_index_backfill", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("sqldata_upsert")
+	defer os.RemoveAll("sqldata_create_index_backfill")
 
 	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
 	require.NoError(t, err)
@@ -340,149 +443,180 @@ func TestUpsertInto(t *testing.T) {
 	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title) VALUES (1, 'title1')", nil, true)
-	require.ErrorIs(t, err, ErrNoDatabaseSelected)
-
 	err = engine.UseDatabase("db1")
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title) VALUES (1, 'title1')", nil, true)
-	require.Equal(t, ErrTableDoesNotExist, err)
-
-	_, err = engine.ExecStmt(`CREATE TABLE table1 (
-								id INTEGER,
-								title VARCHAR,
-								amount INTEGER,
-								active BOOLEAN NOT NULL,
-								PRIMARY KEY id)`, nil, true)
-	require.NoError(t, err)
-
-	_, err = engine.ExecStmt("CREATE INDEX ON table1(active)", nil, true)
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR[50], amount INTEGER, category VARCHAR[10], PRIMARY KEY id)", nil, true)
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("CREATE UNIQUE INDEX ON table1(amount, active)", nil, true)
-	require.NoError(t, err)
+	rowCount := 10
 
-	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title) VALUES (1, 'title1')", nil, true)
-	require.Equal(t, ErrNotNullableColumnCannotBeNull, err)
+	for i := 0; i < rowCount; i++ {
+		params := map[string]interface{}{
+			"id":     i,
+			"title":  fmt.Sprintf("title%d", i),
+			"amount": i % 2,
+			"cat":    fmt.Sprintf("cat%d", i%3),
+		}
 
-	_, err = engine.ExecStmt("UPSERT INTO table1 (id, age) VALUES (1, 50)", nil, true)
-	require.Equal(t, ErrColumnDoesNotExist, err)
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, title, amount, category) VALUES (@id, @title, @amount, @cat)", params, true)
+		require.NoError(t, err)
+	}
 
-	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title, active) VALUES (@id, 'title1', true)", nil, true)
-	require.Equal(t, ErrMissingParameter, err)
+	summary, err := engine.ExecStmt("CREATE INDEX ON table1(amount)", nil, true)
+	require.NoError(t, err)
+	require.Len(t, summary.DDTxs, 1)
+	require.Len(t, summary.DMTxs, 1)
 
-	params := make(map[string]interface{}, 1)
-	params["id"] = [4]byte{1, 2, 3, 4}
-	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title, active) VALUES (@id, 'title1', true)", params, true)
-	require.Equal(t, ErrUnsupportedParameter, err)
+	r, err := engine.QueryStmt("SELECT id, amount FROM table1 WHERE amount = 1 ORDER BY amount DESC", nil, true)
+	require.NoError(t, err)
 
-	params = make(map[string]interface{}, 1)
-	params["id"] = []byte{1, 2, 3}
-	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title, active) VALUES (@id, 'title1', true)", params, true)
-	require.Equal(t, ErrInvalidValue, err)
+	for i := rowCount - 1; i > 0; i -= 2 {
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, int64(i), row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+		require.Equal(t, int64(1), row.Values[EncodeSelector("", "db1", "table1", "amount")].Value())
+	}
 
-	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title, active) VALUES (1, @title, false)", nil, true)
-	require.Equal(t, ErrMissingParameter, err)
+	_, err = r.Read()
+	require.Equal(t, ErrNoMoreRows, err)
 
-	params = make(map[string]interface{}, 1)
-	params["title"] = uint64(1)
-	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title, active) VALUES (1, @title, true)", params, true)
-	require.Equal(t, ErrInvalidValue, err)
+	err = r.Close()
+	require.NoError(t, err)
 
-	params = make(map[string]interface{}, 1)
-	params["title"] = uint64(1)
-	params["Title"] = uint64(2)
-	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title, active) VALUES (1, @title, true)", params, true)
-	require.Equal(t, ErrDuplicatedParameters, err)
+	t.Run("unique index backfill should fail on duplicated values", func(t *testing.T) {
+		_, err = engine.ExecStmt("CREATE UNIQUE INDEX ON table1(category)", nil, true)
+		require.ErrorIs(t, err, store.ErrKeyAlreadyExists)
 
-	summary, err := engine.ExecStmt("UPSERT INTO table1 (id, amount, active) VALUES (1, 10, true)", nil, true)
-	require.NoError(t, err)
-	require.NotNil(t, summary)
-	require.Equal(t, summary.UpdatedRows, 1)
+		_, err = engine.ExecStmt("CREATE UNIQUE INDEX ON table1(category, id)", nil, true)
+		require.NoError(t, err)
 
-	_, err = engine.ExecStmt("UPSERT INTO table1 (id, amount, active) VALUES (2, 10, true)", nil, true)
-	require.ErrorIs(t, err, store.ErrKeyAlreadyExists)
+		table, err := engine.GetTableByName("db1", "table1")
+		require.NoError(t, err)
+		require.Len(t, table.indexes, 3)
+	})
 
-	t.Run("row with pk 1 should have active in false", func(t *testing.T) {
-		summary, err = engine.ExecStmt("UPSERT INTO table1 (id, amount, active) VALUES (1, 20, false)", nil, true)
+	t.Run("backfilled unique index should reject duplicated values", func(t *testing.T) {
+		_, err = engine.ExecStmt("CREATE UNIQUE INDEX ON table1(title)", nil, true)
 		require.NoError(t, err)
-		require.NotNil(t, summary)
-		require.Equal(t, summary.UpdatedRows, 1)
 
-		r, err := engine.QueryStmt("SELECT amount, active FROM table1 WHERE id = 1", nil, true)
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, title, amount, category) VALUES (100, 'title1', 0, 'cat0')", nil, true)
+		require.ErrorIs(t, err, store.ErrKeyAlreadyExists)
+
+		r, err := engine.QueryStmt("SELECT id FROM table1 USE INDEX ON (title) WHERE title = 'title3'", nil, true)
 		require.NoError(t, err)
 
 		row, err := r.Read()
 		require.NoError(t, err)
-		require.NotNil(t, row)
-		require.Len(t, row.Values, 2)
-		require.Equal(t, int64(20), row.Values[EncodeSelector("", "db1", "table1", "amount")].Value())
-		require.False(t, row.Values[EncodeSelector("", "db1", "table1", "active")].Value().(bool))
+		require.Equal(t, int64(3), row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+
+		_, err = r.Read()
+		require.Equal(t, ErrNoMoreRows, err)
 
 		err = r.Close()
 		require.NoError(t, err)
 	})
 
-	t.Run("row with pk 1 should have active in true", func(t *testing.T) {
-		summary, err = engine.ExecStmt("UPSERT INTO table1 (id, amount, active) VALUES (1, 10, true)", nil, true)
+	t.Run("indexes can not be created after rows are written in the same transaction", func(t *testing.T) {
+		table, err := engine.GetTableByName("db1", "table1")
 		require.NoError(t, err)
-		require.NotNil(t, summary)
-		require.Equal(t, summary.UpdatedRows, 1)
 
-		r, err := engine.QueryStmt("SELECT amount, active FROM table1 WHERE id = 1", nil, true)
+		indexCount := len(table.indexes)
+
+		_, err = engine.ExecStmt(`
+			BEGIN TRANSACTION
+				INSERT INTO table1 (id, title, amount, category) VALUES (200, 'title200', 0, 'cat0');
+				CREATE INDEX ON table1(title, amount);
+			COMMIT`, nil, true)
+		require.ErrorIs(t, err, ErrDDLorDMLTxOnly)
+
+		_, err = engine.ExecAll([]SQLStmt{
+			&UpsertIntoStmt{
+				isInsert: true,
+				tableRef: &tableRef{table: "table1"},
+				cols:     []string{"id", "title", "amount", "category"},
+				rows:     []*RowSpec{{Values: []ValueExp{&Number{val: 200}, &Varchar{val: "title200"}, &Number{val: 0}, &Varchar{val: "cat0"}}}},
+			},
+			&CreateIndexStmt{table: "table1", cols: []string{"title", "amount"}},
+		}, nil, true)
+		require.ErrorIs(t, err, ErrDDLorDMLTxOnly)
+
+		table, err = engine.GetTableByName("db1", "table1")
+		require.NoError(t, err)
+		require.Len(t, table.indexes, indexCount)
+
+		r, err := engine.QueryStmt("SELECT COUNT() AS c FROM table1 WHERE id = 200", nil, true)
 		require.NoError(t, err)
 
 		row, err := r.Read()
 		require.NoError(t, err)
-		require.NotNil(t, row)
-		require.Len(t, row.Values, 2)
-		require.Equal(t, int64(10), row.Values[EncodeSelector("", "db1", "table1", "amount")].Value())
-		require.True(t, row.Values[EncodeSelector("", "db1", "table1", "active")].Value().(bool))
+		require.Equal(t, int64(0), row.Values[EncodeSelector("", "db1", "table1", "c")].Value())
 
 		err = r.Close()
 		require.NoError(t, err)
 	})
 
-	_, err = engine.ExecStmt("UPSERT INTO table1 (Id, Title, Active) VALUES (1, 'some title', false)", nil, true)
-	require.ErrorIs(t, err, ErrIndexedColumnCanNotBeNull)
+	err = engine.Close()
+	require.NoError(t, err)
+}
 
-	_, err = engine.ExecStmt("UPSERT INTO table1 (Id, Title, Amount, Active) VALUES (1, 'some title', 100, false)", nil, true)
+func TestCreateIndexBackfillLimit(t *testing.T) {
+	catalogStore, err := store.Open("catalog_create_index_limit", store.DefaultOptions())
 	require.NoError(t, err)
+	defer os.RemoveAll("catalog_create_index_limit")
 
-	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title, amount, active) VALUES (2, 'another title', 200, true)", nil, true)
+	dataStore, err := store.Open("sqldata_create_index_limit", store.DefaultOptions().WithMaxTxEntries(5))
 	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_create_index_limit")
 
-	_, err = engine.ExecStmt("UPSERT INTO table1 (id) VALUES (1, 'yat')", nil, true)
-	require.Equal(t, ErrInvalidNumberOfValues, err)
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("UPSERT INTO table1 (id, id) VALUES (1, 2)", nil, true)
-	require.Equal(t, ErrDuplicatedColumn, err)
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("UPSERT INTO table1 (id, active) VALUES ('1', true)", nil, true)
-	require.Equal(t, ErrInvalidValue, err)
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("UPSERT INTO table1 (id, active) VALUES (NULL, false)", nil, true)
-	require.Equal(t, ErrPKCanNotBeNull, err)
+	for _, table := range []string{"table1", "table2"} {
+		_, err = engine.ExecStmt(fmt.Sprintf("CREATE TABLE %s (id INTEGER, amount INTEGER, PRIMARY KEY id)", table), nil, true)
+		require.NoError(t, err)
+	}
 
-	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title, active) VALUES (2, NULL, true)", nil, true)
+	// table2 holds one row more than the entries allowed per transaction
+	for i := 0; i < 6; i++ {
+		for _, table := range []string{"table1", "table2"} {
+			if table == "table1" && i == 5 {
+				continue
+			}
+
+			_, err = engine.ExecStmt(fmt.Sprintf("INSERT INTO %s (id, amount) VALUES (@id, @id)", table), map[string]interface{}{"id": i}, true)
+			require.NoError(t, err)
+		}
+	}
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(amount)", nil, true)
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("UPSERT INTO table1 (title, active) VALUES ('interesting title', true)", nil, true)
-	require.Equal(t, ErrPKCanNotBeNull, err)
+	_, err = engine.ExecStmt("CREATE INDEX ON table2(amount)", nil, true)
+	require.ErrorIs(t, err, ErrTooManyRows)
 
-	_, err = engine.ExecStmt("CREATE TABLE IF NOT EXISTS blob_table (id BLOB[2], PRIMARY KEY id)", nil, true)
+	table, err := engine.GetTableByName("db1", "table2")
+	require.NoError(t, err)
+	require.Len(t, table.indexes, 1)
+
+	err = engine.Close()
 	require.NoError(t, err)
 }
 
-func TestInsertIntoEdgeCases(t *testing.T) {
-	catalogStore, err := store.Open("catalog_insert", store.DefaultOptions())
+func TestCreateIndexBackfillWithoutWaitingForIndexing(t *testing.T) {
+	catalogStore, err := store.Open("catalog_create_index_nowait", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("catalog_insert")
+	defer os.RemoveAll("catalog_create_index_nowait")
 
-	dataStore, err := store.Open("sqldata_insert", store.DefaultOptions())
+	dataStore, err := store.Open("sqldata_create_index_nowait", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("sqldata_insert")
+	defer os.RemoveAll("sqldata_create_index_nowait")
 
 	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
 	require.NoError(t, err)
@@ -493,54 +627,41 @@ func TestInsertIntoEdgeCases(t *testing.T) {
 	err = engine.UseDatabase("db1")
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR[10], active BOOLEAN, payload BLOB[2], PRIMARY KEY id)", nil, true)
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, amount INTEGER, PRIMARY KEY id)", nil, true)
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("CREATE INDEX ON table1 (title)", nil, true)
-	require.NoError(t, err)
+	rowCount := 300
 
-	_, err = engine.ExecStmt("CREATE INDEX ON table1 (active)", nil, true)
-	require.NoError(t, err)
+	for i := 0; i < rowCount; i++ {
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, amount) VALUES (@id, @id)", map[string]interface{}{"id": i}, false)
+		require.NoError(t, err)
+	}
 
-	_, err = engine.ExecStmt("CREATE INDEX ON table1 (payload)", nil, true)
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(amount)", nil, true)
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("INSERT INTO table1 (id, title, active, payload) VALUES (1, 'title1', true, x'00A1')", nil, true)
+	r, err := engine.QueryStmt("SELECT COUNT() AS c FROM table1 USE INDEX ON (amount)", nil, true)
 	require.NoError(t, err)
 
-	t.Run("varchar key cases", func(t *testing.T) {
-		_, err = engine.ExecStmt("INSERT INTO table1 (id, title, active, payload) VALUES (1, 'title123456789', true, x'00A1')", nil, true)
-		require.ErrorIs(t, err, ErrMaxLengthExceeded)
-
-		_, err = engine.ExecStmt("INSERT INTO table1 (id, title, active, payload) VALUES (1, 10, true, '00A1')", nil, true)
-		require.ErrorIs(t, err, ErrInvalidValue)
-	})
-
-	t.Run("boolean key cases", func(t *testing.T) {
-		_, err = engine.ExecStmt("INSERT INTO table1 (id, title, active, payload) VALUES (1, 'title1', 'true', x'00A1')", nil, true)
-		require.ErrorIs(t, err, ErrInvalidValue)
-	})
-
-	t.Run("blob key cases", func(t *testing.T) {
-		_, err = engine.ExecStmt("INSERT INTO table1 (id, title, active, payload) VALUES (1, 'title1', true, x'00A100A2')", nil, true)
-		require.ErrorIs(t, err, ErrMaxLengthExceeded)
+	row, err := r.Read()
+	require.NoError(t, err)
+	require.Equal(t, int64(rowCount), row.Values[EncodeSelector("", "db1", "table1", "c")].Value())
 
-		_, err = engine.ExecStmt("INSERT INTO table1 (id, title, active, payload) VALUES (1, 'title1', true, '00A100A2')", nil, true)
-		require.ErrorIs(t, err, ErrInvalidValue)
-	})
+	err = r.Close()
+	require.NoError(t, err)
 
 	err = engine.Close()
 	require.NoError(t, err)
 }
 
-func TestAutoIncrementPK(t *testing.T) {
-	catalogStore, err := store.Open("catalog_auto_inc", store.DefaultOptions())
+func TestCreateIndexUnregisteredWhenBackfillFails(t *testing.T) {
+	catalogStore, err := store.Open("catalog_create_index_unregistered", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("catalog_auto_inc")
+	defer os.RemoveAll("catalog_create_index_unregistered")
 
-	dataStore, err := store.Open("sqldata_auto_inc", store.DefaultOptions())
+	dataStore, err := store.Open("sqldata_create_index_unregistered", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("sqldata_auto_inc")
+	defer os.RemoveAll("sqldata_create_index_unregistered")
 
 	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
 	require.NoError(t, err)
@@ -551,67 +672,74 @@ func TestAutoIncrementPK(t *testing.T) {
 	err = engine.UseDatabase("db1")
 	require.NoError(t, err)
 
-	t.Run("invalid use of auto-increment type", func(t *testing.T) {
-		_, err = engine.ExecStmt("CREATE TABLE table1 (id VARCHAR AUTO_INCREMENT, title VARCHAR, PRIMARY KEY id)", nil, true)
-		require.ErrorIs(t, err, ErrAutoIncrementWrongType)
-	})
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, amount INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, title VARCHAR, PRIMARY KEY id)", nil, true)
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, amount) VALUES (1, 10), (2, 20), (3, 30)", nil, true)
 	require.NoError(t, err)
 
-	summary, err := engine.ExecStmt("INSERT INTO table1(title) VALUES ('name1')", nil, true)
+	stmts, err := ParseString("CREATE INDEX ON table1(amount)")
 	require.NoError(t, err)
-	require.Empty(t, summary.DDTxs)
-	require.Len(t, summary.DMTxs, 1)
-	require.Equal(t, uint64(1), summary.DMTxs[0].ID)
-	require.Len(t, summary.LastInsertedPKs, 1)
-	require.Equal(t, int64(1), summary.LastInsertedPKs["table1"])
-	require.Equal(t, 1, summary.UpdatedRows)
 
-	_, err = engine.ExecStmt("INSERT INTO table1(id, title) VALUES (2, 'name2')", nil, true)
-	require.ErrorIs(t, err, ErrNoValueForAutoIncrementalColumn)
+	db, err := engine.catalog.GetDatabaseByName("db1")
+	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("UPSERT INTO table1(id, title) VALUES (2, 'name2')", nil, true)
-	require.ErrorIs(t, err, store.ErrKeyNotFound)
+	committedCatalog := engine.catalog.Clone()
 
-	_, err = engine.ExecStmt("UPSERT INTO table1(id, title) VALUES (1, 'name11')", nil, true)
+	txSummary, err := stmts[0].compileUsing(engine, db, nil)
 	require.NoError(t, err)
+	require.Len(t, txSummary.ces, 1)
+	require.Len(t, txSummary.ies, 3)
 
-	err = engine.ReloadCatalog(nil)
+	// the catalog entry gets committed but the backfilled entries are rejected by the data store
+	txSummary.ies = append(txSummary.ies, &store.EntrySpec{})
+
+	err = engine.commitTxSummary(txSummary, true, &ExecSummary{LastInsertedPKs: make(map[string]int64)})
+	require.ErrorIs(t, err, store.ErrNullKey)
+
+	engine.resetCatalog(committedCatalog)
+
+	err = engine.Close()
 	require.NoError(t, err)
 
-	summary, err = engine.ExecStmt("INSERT INTO table1(title) VALUES ('name2')", nil, true)
+	engine, err = NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
 	require.NoError(t, err)
-	require.Empty(t, summary.DDTxs)
-	require.Len(t, summary.DMTxs, 1)
-	require.Equal(t, uint64(3), summary.DMTxs[0].ID)
-	require.Len(t, summary.LastInsertedPKs, 1)
-	require.Equal(t, int64(2), summary.LastInsertedPKs["table1"])
-	require.Equal(t, 1, summary.UpdatedRows)
 
-	summary, err = engine.ExecStmt(`
-		BEGIN TRANSACTION
-			INSERT INTO table1(title) VALUES ('name3');
-			INSERT INTO table1(title) VALUES ('name4');
-		COMMIT
-	`, nil, true)
+	err = engine.EnsureCatalogReady(nil)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	table, err := engine.GetTableByName("db1", "table1")
+	require.NoError(t, err)
+	require.Len(t, table.indexes, 1)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(amount)", nil, true)
+	require.NoError(t, err)
+
+	r, err := engine.QueryStmt("SELECT COUNT() AS c FROM table1 USE INDEX ON (amount)", nil, true)
+	require.NoError(t, err)
+
+	row, err := r.Read()
+	require.NoError(t, err)
+	require.Equal(t, int64(3), row.Values[EncodeSelector("", "db1", "table1", "c")].Value())
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	err = engine.Close()
 	require.NoError(t, err)
-	require.Empty(t, summary.DDTxs)
-	require.Len(t, summary.DMTxs, 1)
-	require.Equal(t, uint64(4), summary.DMTxs[0].ID)
-	require.Len(t, summary.LastInsertedPKs, 1)
-	require.Equal(t, int64(4), summary.LastInsertedPKs["table1"])
-	require.Equal(t, 2, summary.UpdatedRows)
 }
 
-func TestAutoIncrementUniqueIndex(t *testing.T) {
-	catalogStore, err := store.Open("catalog_auto_inc", store.DefaultOptions())
+func TestCreateIndexIfNotExists(t *testing.T) {
+	catalogStore, err := store.Open("catalog_create_index_if_not_exists", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("catalog_auto_inc")
+	defer os.RemoveAll("catalog_create_index_if_not_exists")
 
-	dataStore, err := store.Open("sqldata_auto_inc", store.DefaultOptions())
+	dataStore, err := store.Open("sqldata_create_index_if_not_exists", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("sqldata_auto_inc")
+	defer os.RemoveAll("sqldata_create_index_if_not_exists")
 
 	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
 	require.NoError(t, err)
@@ -622,68 +750,45 @@ func TestAutoIncrementUniqueIndex(t *testing.T) {
 	err = engine.UseDatabase("db1")
 	require.NoError(t, err)
 
-	t.Run("invalid use of auto-increment type", func(t *testing.T) {
-		_, err := engine.ExecStmt("CREATE TABLE table1 (uid VARCHAR[252], number VARCHAR AUTO_INCREMENT, name VARCHAR, PRIMARY KEY uid)", nil, true)
-		require.ErrorIs(t, err, ErrAutoIncrementWrongType)
-	})
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, name VARCHAR[256], age INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
 
-	t.Run("create table", func(t *testing.T) {
-		_, err := engine.ExecStmt("CREATE TABLE table1 (uid VARCHAR[252], number INTEGER AUTO_INCREMENT, name VARCHAR, PRIMARY KEY uid)", nil, true)
-		require.NoError(t, err)
-	})
+	summary, err := engine.ExecStmt("CREATE INDEX IF NOT EXISTS ON table1(name, age)", nil, true)
+	require.NoError(t, err)
+	require.Len(t, summary.DDTxs, 1)
 
-	summary, err := engine.ExecStmt("INSERT INTO table1(uid, name) VALUES ('AE1R', 'Jhon')", nil, true)
+	summary, err = engine.ExecStmt("CREATE INDEX IF NOT EXISTS ON table1(name, age)", nil, true)
 	require.NoError(t, err)
 	require.Empty(t, summary.DDTxs)
-	require.Len(t, summary.DMTxs, 1)
-	require.Equal(t, uint64(1), summary.DMTxs[0].ID)
-	require.Len(t, summary.LastInsertedPKs, 1)
-	require.Equal(t, int64(1), summary.LastInsertedPKs["table1"])
-	require.Equal(t, 1, summary.UpdatedRows)
+	require.Empty(t, summary.DMTxs)
 
-	t.Run("test bad use of insertion", func(t *testing.T) {
-		_, err := engine.ExecStmt("INSERT INTO table1(uid, number, name) VALUES ('XAPGH', 2, 'Jhon')", nil, true)
-		require.ErrorIs(t, err, ErrNoValueForAutoIncrementalColumn)
-	})
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(name, age)", nil, true)
+	require.ErrorIs(t, err, ErrIndexAlreadyExists)
 
-	summary, err = engine.ExecStmt("UPSERT INTO table1(uid, name) VALUES ('AE1R', 'Ben')", nil, true)
+	_, err = engine.ExecStmt("INSERT INTO table1(id, name, age) VALUES (1, 'name1', 50)", nil, true)
 	require.NoError(t, err)
 
-	err = engine.ReloadCatalog(nil)
+	// an existing index is not re-created, thus data already being stored is not an issue
+	summary, err = engine.ExecStmt("CREATE UNIQUE INDEX IF NOT EXISTS ON table1(name, age)", nil, true)
 	require.NoError(t, err)
+	require.Empty(t, summary.DDTxs)
 
-	summary, err = engine.ExecStmt("INSERT INTO table1(uid, name) VALUES ('BEKZ', 'Marc')", nil, true)
+	table, err := engine.GetTableByName("db1", "table1")
 	require.NoError(t, err)
-	require.Empty(t, summary.DDTxs)
-	require.Len(t, summary.DMTxs, 1)
-	require.Equal(t, uint64(3), summary.DMTxs[0].ID)
-	require.Len(t, summary.LastInsertedPKs, 1)
-	require.Equal(t, int64(2), summary.LastInsertedPKs["table1"])
-	require.Equal(t, 1, summary.UpdatedRows)
+	require.Len(t, table.indexes, 2)
 
-	summary, err = engine.ExecStmt(`
-		BEGIN TRANSACTION
-			INSERT INTO table1(uid, name) VALUES ('VBKZ', 'name3');
-			INSERT INTO table1(uid, name) VALUES ('FZKB', 'name4');
-		COMMIT
-	`, nil, true)
+	err = engine.Close()
 	require.NoError(t, err)
-	require.Empty(t, summary.DDTxs)
-	require.Len(t, summary.DMTxs, 1)
-	require.Equal(t, uint64(4), summary.DMTxs[0].ID)
-	require.Len(t, summary.LastInsertedPKs, 1)
-	require.Equal(t, int64(4), summary.LastInsertedPKs["table1"])
-	require.Equal(t, 2, summary.UpdatedRows)
 }
 
-func TestDelete(t *testing.T) {
-	catalogStore, err := store.Open("catalog_delete", store.DefaultOptions())
+func TestUpsertInto(t *testing.T) {
+	catalogStore, err := store.Open("catalog_upsert", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("catalog_delete")
+	defer os.RemoveAll("catalog_upsert")
 
-	dataStore, err := store.Open("sqldata_delete", store.DefaultOptions())
+	dataStore, err := store.Open("sqldata_upsert", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("sqldata_delete")
+	defer os.RemoveAll("sqldata_upsert")
 
 	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
 	require.NoError(t, err)
@@ -691,96 +796,149 @@ func TestDelete(t *testing.T) {
 	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("DELETE FROM table1", nil, true)
+	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title) VALUES (1, 'title1')", nil, true)
 	require.ErrorIs(t, err, ErrNoDatabaseSelected)
 
 	err = engine.UseDatabase("db1")
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt(`CREATE TABLE table1 (
-		id INTEGER,
-		title VARCHAR[50],
-		active BOOLEAN,
-		PRIMARY KEY id
-	)`, nil, true)
-	require.NoError(t, err)
+	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title) VALUES (1, 'title1')", nil, true)
+	require.Equal(t, ErrTableDoesNotExist, err)
 
-	_, err = engine.ExecStmt("CREATE UNIQUE INDEX ON table1(title)", nil, true)
+	_, err = engine.ExecStmt(`CREATE TABLE table1 (
+								id INTEGER,
+								title VARCHAR,
+								amount INTEGER,
+								active BOOLEAN NOT NULL,
+								PRIMARY KEY id)`, nil, true)
 	require.NoError(t, err)
 
 	_, err = engine.ExecStmt("CREATE INDEX ON table1(active)", nil, true)
 	require.NoError(t, err)
 
-	params, err := engine.InferParameters("DELETE FROM table1 WHERE active = @active")
+	_, err = engine.ExecStmt("CREATE UNIQUE INDEX ON table1(amount, active)", nil, true)
 	require.NoError(t, err)
-	require.NotNil(t, params)
-	require.Len(t, params, 1)
-	require.Equal(t, params["active"], BooleanType)
 
-	_, err = engine.ExecStmt("DELETE FROM table2", nil, true)
-	require.ErrorIs(t, err, ErrTableDoesNotExist)
+	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title) VALUES (1, 'title1')", nil, true)
+	require.ErrorIs(t, err, ErrNotNullableColumnCannotBeNull)
 
-	_, err = engine.ExecStmt("DELETE FROM table1 WHERE name = 'name1'", nil, true)
-	require.ErrorIs(t, err, ErrColumnDoesNotExist)
+	_, err = engine.ExecStmt("UPSERT INTO table1 (id, age) VALUES (1, 50)", nil, true)
+	require.Equal(t, ErrColumnDoesNotExist, err)
 
-	t.Run("delete on empty table should complete without issues", func(t *testing.T) {
-		summary, err := engine.ExecStmt("DELETE FROM table1", nil, true)
-		require.NoError(t, err)
-		require.NotNil(t, summary)
-		require.Zero(t, summary.UpdatedRows)
-	})
+	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title, active) VALUES (@id, 'title1', true)", nil, true)
+	require.Equal(t, ErrMissingParameter, err)
 
-	rowCount := 10
+	params := make(map[string]interface{}, 1)
+	params["id"] = [4]byte{1, 2, 3, 4}
+	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title, active) VALUES (@id, 'title1', true)", params, true)
+	require.Equal(t, ErrUnsupportedParameter, err)
 
-	for i := 0; i < rowCount; i++ {
-		_, err = engine.ExecStmt(fmt.Sprintf(`
-			INSERT INTO table1 (id, title, active) VALUES (%d, 'title%d', %v)`, i, i, i%2 == 0), nil, true)
-		require.NoError(t, err)
-	}
+	params = make(map[string]interface{}, 1)
+	params["id"] = []byte{1, 2, 3}
+	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title, active) VALUES (@id, 'title1', true)", params, true)
+	require.Equal(t, ErrInvalidValue, err)
 
-	t.Run("deleting with contradiction should not produce any change", func(t *testing.T) {
-		summary, err := engine.ExecStmt("DELETE FROM table1 WHERE false", nil, true)
-		require.NoError(t, err)
-		require.NotNil(t, summary)
-		require.Zero(t, summary.UpdatedRows)
-	})
+	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title, active) VALUES (1, @title, false)", nil, true)
+	require.Equal(t, ErrMissingParameter, err)
 
-	t.Run("deleting active rows should remove half of the rows", func(t *testing.T) {
-		summary, err := engine.ExecStmt("DELETE FROM table1 WHERE active = @active", map[string]interface{}{"active": true}, true)
+	params = make(map[string]interface{}, 1)
+	params["title"] = uint64(1)
+	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title, active) VALUES (1, @title, true)", params, true)
+	require.Equal(t, ErrInvalidValue, err)
+
+	params = make(map[string]interface{}, 1)
+	params["title"] = uint64(1)
+	params["Title"] = uint64(2)
+	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title, active) VALUES (1, @title, true)", params, true)
+	require.Equal(t, ErrDuplicatedParameters, err)
+
+	summary, err := engine.ExecStmt("UPSERT INTO table1 (id, amount, active) VALUES (1, 10, true)", nil, true)
+	require.NoError(t, err)
+	require.NotNil(t, summary)
+	require.Equal(t, summary.UpdatedRows, 1)
+
+	_, err = engine.ExecStmt("UPSERT INTO table1 (id, amount, active) VALUES (2, 10, true)", nil, true)
+	require.ErrorIs(t, err, store.ErrKeyAlreadyExists)
+
+	t.Run("row with pk 1 should have active in false", func(t *testing.T) {
+		summary, err = engine.ExecStmt("UPSERT INTO table1 (id, amount, active) VALUES (1, 20, false)", nil, true)
 		require.NoError(t, err)
 		require.NotNil(t, summary)
-		require.Equal(t, rowCount/2, summary.UpdatedRows)
+		require.Equal(t, summary.UpdatedRows, 1)
 
-		r, err := engine.QueryStmt("SELECT COUNT() FROM table1", nil, true)
+		r, err := engine.QueryStmt("SELECT amount, active FROM table1 WHERE id = 1", nil, true)
 		require.NoError(t, err)
 
 		row, err := r.Read()
 		require.NoError(t, err)
-		require.Equal(t, int64(rowCount/2), row.Values[EncodeSelector("", "db1", "table1", "col0")].Value())
+		require.NotNil(t, row)
+		require.Len(t, row.Values, 2)
+		require.Equal(t, int64(20), row.Values[EncodeSelector("", "db1", "table1", "amount")].Value())
+		require.False(t, row.Values[EncodeSelector("", "db1", "table1", "active")].Value().(bool))
 
 		err = r.Close()
 		require.NoError(t, err)
+	})
 
-		r, err = engine.QueryStmt("SELECT COUNT() FROM table1 WHERE active", nil, true)
+	t.Run("row with pk 1 should have active in true", func(t *testing.T) {
+		summary, err = engine.ExecStmt("UPSERT INTO table1 (id, amount, active) VALUES (1, 10, true)", nil, true)
+		require.NoError(t, err)
+		require.NotNil(t, summary)
+		require.Equal(t, summary.UpdatedRows, 1)
+
+		r, err := engine.QueryStmt("SELECT amount, active FROM table1 WHERE id = 1", nil, true)
 		require.NoError(t, err)
 
-		row, err = r.Read()
+		row, err := r.Read()
 		require.NoError(t, err)
-		require.Equal(t, int64(0), row.Values[EncodeSelector("", "db1", "table1", "col0")].Value())
+		require.NotNil(t, row)
+		require.Len(t, row.Values, 2)
+		require.Equal(t, int64(10), row.Values[EncodeSelector("", "db1", "table1", "amount")].Value())
+		require.True(t, row.Values[EncodeSelector("", "db1", "table1", "active")].Value().(bool))
 
 		err = r.Close()
 		require.NoError(t, err)
 	})
+
+	_, err = engine.ExecStmt("UPSERT INTO table1 (Id, Title, Active) VALUES (1, 'some title', false)", nil, true)
+	require.ErrorIs(t, err, ErrIndexedColumnCanNotBeNull)
+
+	_, err = engine.ExecStmt("UPSERT INTO table1 (Id, Title, Amount, Active) VALUES (1, 'some title', 100, false)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title, amount, active) VALUES (2, 'another title', 200, true)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("UPSERT INTO table1 (id) VALUES (1, 'yat')", nil, true)
+	require.Equal(t, ErrInvalidNumberOfValues, err)
+
+	_, err = engine.ExecStmt("UPSERT INTO table1 (id, id) VALUES (1, 2)", nil, true)
+	require.Equal(t, ErrDuplicatedColumn, err)
+
+	_, err = engine.ExecStmt("UPSERT INTO table1 (id, active) VALUES ('1', true)", nil, true)
+	require.Equal(t, ErrInvalidValue, err)
+
+	_, err = engine.ExecStmt("UPSERT INTO table1 (id, active) VALUES (NULL, false)", nil, true)
+	require.Equal(t, ErrPKCanNotBeNull, err)
+
+	_, err = engine.ExecStmt("UPSERT INTO table1 (id, title, active) VALUES (2, NULL, true)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("UPSERT INTO table1 (title, active) VALUES ('interesting title', true)", nil, true)
+	require.Equal(t, ErrPKCanNotBeNull, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE IF NOT EXISTS blob_table (id BLOB[2], PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
 }
 
-func TestUpdate(t *testing.T) {
-	catalogStore, err := store.Open("catalog_update", store.DefaultOptions())
+func TestInsertIntoEdgeCases(t *testing.T) {
+	catalogStore, err := store.Open("catalog_insert", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("catalog_update")
+	defer os.RemoveAll("catalog_insert")
 
-	dataStore, err := store.Open("sqldata_update", store.DefaultOptions())
+	dataStore, err := store.Open("sqldata_insert", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("sqldata_update")
+	defer os.RemoveAll("sqldata_insert")
 
 	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
 	require.NoError(t, err)
@@ -788,9 +946,210 @@ func TestUpdate(t *testing.T) {
 	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("UPDATE table1 SET title = 'title11' WHERE title = 'title", nil, true)
-	require.ErrorIs(t, err, ErrNoDatabaseSelected)
-
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR[10], active BOOLEAN, payload BLOB[2], PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1 (title)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1 (active)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1 (payload)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, title, active, payload) VALUES (1, 'title1', true, x'00A1')", nil, true)
+	require.NoError(t, err)
+
+	t.Run("varchar key cases", func(t *testing.T) {
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, title, active, payload) VALUES (1, 'title123456789', true, x'00A1')", nil, true)
+		require.ErrorIs(t, err, ErrMaxLengthExceeded)
+
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, title, active, payload) VALUES (1, 10, true, '00A1')", nil, true)
+		require.ErrorIs(t, err, ErrInvalidValue)
+	})
+
+	t.Run("boolean key cases", func(t *testing.T) {
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, title, active, payload) VALUES (1, 'title1', 'true', x'00A1')", nil, true)
+		require.ErrorIs(t, err, ErrInvalidValue)
+	})
+
+	t.Run("blob key cases", func(t *testing.T) {
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, title, active, payload) VALUES (1, 'title1', true, x'00A100A2')", nil, true)
+		require.ErrorIs(t, err, ErrMaxLengthExceeded)
+
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, title, active, payload) VALUES (1, 'title1', true, '00A100A2')", nil, true)
+		require.ErrorIs(t, err, ErrInvalidValue)
+	})
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestAutoIncrementPK(t *testing.T) {
+	catalogStore, err := store.Open("catalog_auto_inc", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_auto_inc")
+
+	dataStore, err := store.Open("sqldata_auto_inc", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_auto_inc")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	t.Run("invalid use of auto-increment type", func(t *testing.T) {
+		_, err = engine.ExecStmt("CREATE TABLE table1 (id VARCHAR AUTO_INCREMENT, title VARCHAR, PRIMARY KEY id)", nil, true)
+		require.ErrorIs(t, err, ErrAutoIncrementWrongType)
+	})
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, title VARCHAR, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	summary, err := engine.ExecStmt("INSERT INTO table1(title) VALUES ('name1')", nil, true)
+	require.NoError(t, err)
+	require.Empty(t, summary.DDTxs)
+	require.Len(t, summary.DMTxs, 1)
+	require.Equal(t, uint64(1), summary.DMTxs[0].ID)
+	require.Len(t, summary.LastInsertedPKs, 1)
+	require.Equal(t, int64(1), summary.LastInsertedPKs["table1"])
+	require.Equal(t, 1, summary.UpdatedRows)
+
+	_, err = engine.ExecStmt("INSERT INTO table1(id, title) VALUES (2, 'name2')", nil, true)
+	require.ErrorIs(t, err, ErrNoValueForAutoIncrementalColumn)
+
+	_, err = engine.ExecStmt("UPSERT INTO table1(id, title) VALUES (2, 'name2')", nil, true)
+	require.ErrorIs(t, err, store.ErrKeyNotFound)
+
+	_, err = engine.ExecStmt("UPSERT INTO table1(id, title) VALUES (1, 'name11')", nil, true)
+	require.NoError(t, err)
+
+	err = engine.ReloadCatalog(nil)
+	require.NoError(t, err)
+
+	summary, err = engine.ExecStmt("INSERT INTO table1(title) VALUES ('name2')", nil, true)
+	require.NoError(t, err)
+	require.Empty(t, summary.DDTxs)
+	require.Len(t, summary.DMTxs, 1)
+	require.Equal(t, uint64(3), summary.DMTxs[0].ID)
+	require.Len(t, summary.LastInsertedPKs, 1)
+	require.Equal(t, int64(2), summary.LastInsertedPKs["table1"])
+	require.Equal(t, 1, summary.UpdatedRows)
+
+	summary, err = engine.ExecStmt(`
+		BEGIN TRANSACTION
+			INSERT INTO table1(title) VALUES ('name3');
+			INSERT INTO table1(title) VALUES ('name4');
+		COMMIT
+	`, nil, true)
+	require.NoError(t, err)
+	require.Empty(t, summary.DDTxs)
+	require.Len(t, summary.DMTxs, 1)
+	require.Equal(t, uint64(4), summary.DMTxs[0].ID)
+	require.Len(t, summary.LastInsertedPKs, 1)
+	require.Equal(t, int64(4), summary.LastInsertedPKs["table1"])
+	require.Equal(t, 2, summary.UpdatedRows)
+}
+
+func TestAutoIncrementUniqueIndex(t *testing.T) {
+	catalogStore, err := store.Open("catalog_auto_inc", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_auto_inc")
+
+	dataStore, err := store.Open("sqldata_auto_inc", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_auto_inc")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	t.Run("invalid use of auto-increment type", func(t *testing.T) {
+		_, err := engine.ExecStmt("CREATE TABLE table1 (uid VARCHAR[252], number VARCHAR AUTO_INCREMENT, name VARCHAR, PRIMARY KEY uid)", nil, true)
+		require.ErrorIs(t, err, ErrAutoIncrementWrongType)
+	})
+
+	t.Run("create table", func(t *testing.T) {
+		_, err := engine.ExecStmt("CREATE TABLE table1 (uid VARCHAR[252], number INTEGER AUTO_INCREMENT, name VARCHAR, PRIMARY KEY uid)", nil, true)
+		require.NoError(t, err)
+	})
+
+	summary, err := engine.ExecStmt("INSERT INTO table1(uid, name) VALUES ('AE1R', 'Jhon')", nil, true)
+	require.NoError(t, err)
+	require.Empty(t, summary.DDTxs)
+	require.Len(t, summary.DMTxs, 1)
+	require.Equal(t, uint64(1), summary.DMTxs[0].ID)
+	require.Len(t, summary.LastInsertedPKs, 1)
+	require.Equal(t, int64(1), summary.LastInsertedPKs["table1"])
+	require.Equal(t, 1, summary.UpdatedRows)
+
+	t.Run("test bad use of insertion", func(t *testing.T) {
+		_, err := engine.ExecStmt("INSERT INTO table1(uid, number, name) VALUES ('XAPGH', 2, 'Jhon')", nil, true)
+		require.ErrorIs(t, err, ErrNoValueForAutoIncrementalColumn)
+	})
+
+	summary, err = engine.ExecStmt("UPSERT INTO table1(uid, name) VALUES ('AE1R', 'Ben')", nil, true)
+	require.NoError(t, err)
+
+	err = engine.ReloadCatalog(nil)
+	require.NoError(t, err)
+
+	summary, err = engine.ExecStmt("INSERT INTO table1(uid, name) VALUES ('BEKZ', 'Marc')", nil, true)
+	require.NoError(t, err)
+	require.Empty(t, summary.DDTxs)
+	require.Len(t, summary.DMTxs, 1)
+	require.Equal(t, uint64(3), summary.DMTxs[0].ID)
+	require.Len(t, summary.LastInsertedPKs, 1)
+	require.Equal(t, int64(2), summary.LastInsertedPKs["table1"])
+	require.Equal(t, 1, summary.UpdatedRows)
+
+	summary, err = engine.ExecStmt(`
+		BEGIN TRANSACTION
+			INSERT INTO table1(uid, name) VALUES ('VBKZ', 'name3');
+			INSERT INTO table1(uid, name) VALUES ('FZKB', 'name4');
+		COMMIT
+	`, nil, true)
+	require.NoError(t, err)
+	require.Empty(t, summary.DDTxs)
+	require.Len(t, summary.DMTxs, 1)
+	require.Equal(t, uint64(4), summary.DMTxs[0].ID)
+	require.Len(t, summary.LastInsertedPKs, 1)
+	require.Equal(t, int64(4), summary.LastInsertedPKs["table1"])
+	require.Equal(t, 2, summary.UpdatedRows)
+}
+
+func TestDelete(t *testing.T) {
+	catalogStore, err := store.Open("catalog_delete", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_delete")
+
+	dataStore, err := store.Open("sqldata_delete", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_delete")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("DELETE FROM table1", nil, true)
+	require.ErrorIs(t, err, ErrNoDatabaseSelected)
+
 	err = engine.UseDatabase("db1")
 	require.NoError(t, err)
 
@@ -808,20 +1167,20 @@ func TestUpdate(t *testing.T) {
 	_, err = engine.ExecStmt("CREATE INDEX ON table1(active)", nil, true)
 	require.NoError(t, err)
 
-	params, err := engine.InferParameters("UPDATE table1 SET active = @active")
+	params, err := engine.InferParameters("DELETE FROM table1 WHERE active = @active")
 	require.NoError(t, err)
 	require.NotNil(t, params)
 	require.Len(t, params, 1)
 	require.Equal(t, params["active"], BooleanType)
 
-	_, err = engine.ExecStmt("UPDATE table2 SET active = false", nil, true)
+	_, err = engine.ExecStmt("DELETE FROM table2", nil, true)
 	require.ErrorIs(t, err, ErrTableDoesNotExist)
 
-	_, err = engine.ExecStmt("UPDATE table1 SET name = 'name1'", nil, true)
+	_, err = engine.ExecStmt("DELETE FROM table1 WHERE name = 'name1'", nil, true)
 	require.ErrorIs(t, err, ErrColumnDoesNotExist)
 
-	t.Run("update on empty table should complete without issues", func(t *testing.T) {
-		summary, err := engine.ExecStmt("UPDATE table1 SET active = false", nil, true)
+	t.Run("delete on empty table should complete without issues", func(t *testing.T) {
+		summary, err := engine.ExecStmt("DELETE FROM table1", nil, true)
 		require.NoError(t, err)
 		require.NotNil(t, summary)
 		require.Zero(t, summary.UpdatedRows)
@@ -835,25 +1194,25 @@ func TestUpdate(t *testing.T) {
 		require.NoError(t, err)
 	}
 
-	t.Run("updating with contradiction should not produce any change", func(t *testing.T) {
-		summary, err := engine.ExecStmt("UPDATE table1 SET active = false WHERE false", nil, true)
+	t.Run("deleting with contradiction should not produce any change", func(t *testing.T) {
+		summary, err := engine.ExecStmt("DELETE FROM table1 WHERE false", nil, true)
 		require.NoError(t, err)
 		require.NotNil(t, summary)
 		require.Zero(t, summary.UpdatedRows)
 	})
 
-	t.Run("updating specific row should update only one row", func(t *testing.T) {
-		summary, err := engine.ExecStmt("UPDATE table1 SET active = true WHERE title = @title", map[string]interface{}{"title": "title1"}, true)
+	t.Run("deleting active rows should remove half of the rows", func(t *testing.T) {
+		summary, err := engine.ExecStmt("DELETE FROM table1 WHERE active = @active", map[string]interface{}{"active": true}, true)
 		require.NoError(t, err)
 		require.NotNil(t, summary)
-		require.Equal(t, 1, summary.UpdatedRows)
+		require.Equal(t, rowCount/2, summary.UpdatedRows)
 
 		r, err := engine.QueryStmt("SELECT COUNT() FROM table1", nil, true)
 		require.NoError(t, err)
 
 		row, err := r.Read()
 		require.NoError(t, err)
-		require.Equal(t, int64(rowCount), row.Values[EncodeSelector("", "db1", "table1", "col0")].Value())
+		require.Equal(t, int64(rowCount/2), row.Values[EncodeSelector("", "db1", "table1", "count(*)")].Value())
 
 		err = r.Close()
 		require.NoError(t, err)
@@ -863,20 +1222,21 @@ func TestUpdate(t *testing.T) {
 
 		row, err = r.Read()
 		require.NoError(t, err)
-		require.Equal(t, int64(rowCount/2+1), row.Values[EncodeSelector("", "db1", "table1", "col0")].Value())
+		require.Equal(t, int64(0), row.Values[EncodeSelector("", "db1", "table1", "count(*)")].Value())
 
 		err = r.Close()
 		require.NoError(t, err)
 	})
 }
-func TestTransactions(t *testing.T) {
-	catalogStore, err := store.Open("catalog_tx", store.DefaultOptions())
+
+func TestUpdate(t *testing.T) {
+	catalogStore, err := store.Open("catalog_update", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("catalog_tx")
+	defer os.RemoveAll("catalog_update")
 
-	dataStore, err := store.Open("sqldata_tx", store.DefaultOptions())
+	dataStore, err := store.Open("sqldata_update", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("sqldata_tx")
+	defer os.RemoveAll("sqldata_update")
 
 	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
 	require.NoError(t, err)
@@ -884,24 +1244,120 @@ func TestTransactions(t *testing.T) {
 	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
 	require.NoError(t, err)
 
+	_, err = engine.ExecStmt("UPDATE table1 SET title = 'title11' WHERE title = 'title", nil, true)
+	require.ErrorIs(t, err, ErrNoDatabaseSelected)
+
 	err = engine.UseDatabase("db1")
 	require.NoError(t, err)
 
 	_, err = engine.ExecStmt(`CREATE TABLE table1 (
-									id INTEGER,
-									title VARCHAR,
-									PRIMARY KEY id
-								)`, nil, true)
+		id INTEGER,
+		title VARCHAR[50],
+		active BOOLEAN,
+		PRIMARY KEY id
+	)`, nil, true)
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt(`
-		BEGIN TRANSACTION
-			CREATE INDEX ON table2(title)
-		COMMIT
-		`, nil, true)
-	require.Equal(t, ErrTableDoesNotExist, err)
+	_, err = engine.ExecStmt("CREATE UNIQUE INDEX ON table1(title)", nil, true)
+	require.NoError(t, err)
 
-	_, err = engine.ExecStmt(`
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(active)", nil, true)
+	require.NoError(t, err)
+
+	params, err := engine.InferParameters("UPDATE table1 SET active = @active")
+	require.NoError(t, err)
+	require.NotNil(t, params)
+	require.Len(t, params, 1)
+	require.Equal(t, params["active"], BooleanType)
+
+	_, err = engine.ExecStmt("UPDATE table2 SET active = false", nil, true)
+	require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+	_, err = engine.ExecStmt("UPDATE table1 SET name = 'name1'", nil, true)
+	require.ErrorIs(t, err, ErrColumnDoesNotExist)
+
+	t.Run("update on empty table should complete without issues", func(t *testing.T) {
+		summary, err := engine.ExecStmt("UPDATE table1 SET active = false", nil, true)
+		require.NoError(t, err)
+		require.NotNil(t, summary)
+		require.Zero(t, summary.UpdatedRows)
+	})
+
+	rowCount := 10
+
+	for i := 0; i < rowCount; i++ {
+		_, err = engine.ExecStmt(fmt.Sprintf(`
+			INSERT INTO table1 (id, title, active) VALUES (%d, 'title%d', %v)`, i, i, i%2 == 0), nil, true)
+		require.NoError(t, err)
+	}
+
+	t.Run("updating with contradiction should not produce any change", func(t *testing.T) {
+		summary, err := engine.ExecStmt("UPDATE table1 SET active = false WHERE false", nil, true)
+		require.NoError(t, err)
+		require.NotNil(t, summary)
+		require.Zero(t, summary.UpdatedRows)
+	})
+
+	t.Run("updating specific row should update only one row", func(t *testing.T) {
+		summary, err := engine.ExecStmt("UPDATE table1 SET active = true WHERE title = @title", map[string]interface{}{"title": "title1"}, true)
+		require.NoError(t, err)
+		require.NotNil(t, summary)
+		require.Equal(t, 1, summary.UpdatedRows)
+
+		r, err := engine.QueryStmt("SELECT COUNT() FROM table1", nil, true)
+		require.NoError(t, err)
+
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, int64(rowCount), row.Values[EncodeSelector("", "db1", "table1", "count(*)")].Value())
+
+		err = r.Close()
+		require.NoError(t, err)
+
+		r, err = engine.QueryStmt("SELECT COUNT() FROM table1 WHERE active", nil, true)
+		require.NoError(t, err)
+
+		row, err = r.Read()
+		require.NoError(t, err)
+		require.Equal(t, int64(rowCount/2+1), row.Values[EncodeSelector("", "db1", "table1", "count(*)")].Value())
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+}
+func TestTransactions(t *testing.T) {
+	catalogStore, err := store.Open("catalog_tx", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_tx")
+
+	dataStore, err := store.Open("sqldata_tx", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_tx")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`CREATE TABLE table1 (
+									id INTEGER,
+									title VARCHAR,
+									PRIMARY KEY id
+								)`, nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		BEGIN TRANSACTION
+			CREATE INDEX ON table2(title)
+		COMMIT
+		`, nil, true)
+	require.Equal(t, ErrTableDoesNotExist, err)
+
+	_, err = engine.ExecStmt(`
 		BEGIN TRANSACTION
 			UPSERT INTO table1 (id, title) VALUES (1, 'title1');
 			UPSERT INTO table1 (id, title) VALUES (2, 'title2');
@@ -1398,7 +1854,7 @@ func TestQuery(t *testing.T) {
 		require.NoError(t, err)
 	})
 
-	r, err = engine.QueryStmt("SELECT id, title, active, payload FROM table1 ORDER BY title", nil, true)
+	r, err = engine.QueryStmt("SELECT COUNT() FROM table1 ORDER BY title", nil, true)
 	require.Equal(t, ErrLimitedOrderBy, err)
 	require.Nil(t, r)
 
@@ -1457,7 +1913,7 @@ func TestQuery(t *testing.T) {
 	r, err = engine.QueryStmt(fmt.Sprintf(`
 		SELECT id, title, active
 		FROM table1
-		WHERE active = @some_param AND title > 'title' AND payload >= x'%s' AND title LIKE 't`, encPayloadPrefix), params, true)
+		WHERE active = @some_param AND title > 'title' AND payload >= x'%s' AND title LIKE 't%%'`, encPayloadPrefix), params, true)
 	require.NoError(t, err)
 
 	for i := 0; i < rowCount/2; i += 2 {
@@ -2368,7 +2824,7 @@ func TestQueryWithNullables(t *testing.T) {
 		require.NoError(t, err)
 	}
 
-	r, err := engine.QueryStmt("SELECT id, ts, title, active FROM table1 WHERE NOT(active != NULL)", nil, true)
+	r, err := engine.QueryStmt("SELECT id, ts, title, active FROM table1 WHERE active IS NULL", nil, true)
 	require.NoError(t, err)
 
 	cols, err := r.Columns()
@@ -2393,6 +2849,137 @@ func TestQueryWithNullables(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestNullComparisons(t *testing.T) {
+	catalogStore, err := store.Open("catalog_null_cmp", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_null_cmp")
+
+	dataStore, err := store.Open("sqldata_null_cmp", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_null_cmp")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR[16], PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, title) VALUES (1, 'title1'), (2, NULL), (3, 'title3')", nil, true)
+	require.NoError(t, err)
+
+	readIDs := func(t *testing.T, query string) []int64 {
+		r, err := engine.QueryStmt(query, nil, true)
+		require.NoError(t, err)
+		defer r.Close()
+
+		var ids []int64
+
+		for {
+			row, err := r.Read()
+			if err == ErrNoMoreRows {
+				return ids
+			}
+			require.NoError(t, err)
+
+			ids = append(ids, row.Values[EncodeSelector("", "db1", "table1", "id")].Value().(int64))
+		}
+	}
+
+	testCases := []struct {
+		query    string
+		expected []int64
+	}{
+		{
+			query:    "SELECT id FROM table1 WHERE title = NULL",
+			expected: nil,
+		},
+		{
+			query:    "SELECT id FROM table1 WHERE title != NULL",
+			expected: nil,
+		},
+		{
+			query:    "SELECT id FROM table1 WHERE NOT (title = NULL)",
+			expected: nil,
+		},
+		{
+			query:    "SELECT id FROM table1 WHERE id = NULL",
+			expected: nil,
+		},
+		{
+			query:    "SELECT id FROM table1 WHERE id >= NULL AND id < 3",
+			expected: nil,
+		},
+		{
+			query:    "SELECT id FROM table1 WHERE title IS NULL",
+			expected: []int64{2},
+		},
+		{
+			query:    "SELECT id FROM table1 WHERE title IS NOT NULL",
+			expected: []int64{1, 3},
+		},
+		{
+			query:    "SELECT id FROM table1 WHERE title != 'title1'",
+			expected: []int64{3},
+		},
+		{
+			query:    "SELECT id FROM table1 WHERE title = 'title1' OR title = NULL",
+			expected: []int64{1},
+		},
+		{
+			query:    "SELECT id FROM table1 WHERE title != 'title1' OR title IS NULL",
+			expected: []int64{2, 3},
+		},
+		{
+			query:    "SELECT id FROM table1 WHERE title LIKE 'title%'",
+			expected: []int64{1, 3},
+		},
+		{
+			query:    "SELECT id FROM table1 WHERE title NOT LIKE 'title1'",
+			expected: []int64{3},
+		},
+		{
+			query:    "SELECT id FROM table1 WHERE title LIKE NULL",
+			expected: nil,
+		},
+		{
+			query:    "SELECT id FROM table1 WHERE title ILIKE NULL OR id = 1",
+			expected: []int64{1},
+		},
+		{
+			query:    "SELECT id FROM table1 WHERE title LIKE 'title%' IS NULL",
+			expected: []int64{2},
+		},
+		{
+			query:    "SELECT id FROM table1 WHERE NOT title IS NULL",
+			expected: []int64{1, 3},
+		},
+		{
+			query:    "SELECT id FROM table1 WHERE id + 1 IS NULL",
+			expected: nil,
+		},
+		{
+			query:    "SELECT id FROM table1 WHERE -id IS NOT NULL AND id > 1",
+			expected: []int64{2, 3},
+		},
+		{
+			query:    "SELECT id FROM table1 WHERE title = 'title1' IS NULL",
+			expected: []int64{2},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			require.Equal(t, tc.expected, readIDs(t, tc.query))
+		})
+	}
+}
+
 func TestOrderBy(t *testing.T) {
 	catalogStore, err := store.Open("catalog_orderby", store.DefaultOptions())
 	require.NoError(t, err)
@@ -2435,7 +3022,7 @@ func TestOrderBy(t *testing.T) {
 	_, err = engine.ExecStmt("CREATE INDEX ON table1(title)", nil, true)
 	require.NoError(t, err)
 
-	_, err = engine.QueryStmt("SELECT id, title, age FROM table1 ORDER BY age", nil, true)
+	_, err = engine.QueryStmt("SELECT age, COUNT() FROM table1 GROUP BY age ORDER BY age", nil, true)
 	require.Equal(t, ErrLimitedOrderBy, err)
 
 	_, err = engine.ExecStmt("CREATE INDEX ON table1(age)", nil, true)
@@ -2634,7 +3221,7 @@ func TestQueryWithRowFiltering(t *testing.T) {
 	_, err = engine.ExecStmt(fmt.Sprintf("UPSERT INTO table1 (id, title) VALUES (%d, 'title%d')", rowCount, rowCount), nil, true)
 	require.NoError(t, err)
 
-	r, err = engine.QueryStmt("SELECT id, title FROM table1 WHERE active = null AND payload = null", nil, true)
+	r, err = engine.QueryStmt("SELECT id, title FROM table1 WHERE active IS NULL AND payload IS NULL", nil, true)
 	require.NoError(t, err)
 
 	_, err = r.Read()
@@ -2643,7 +3230,7 @@ func TestQueryWithRowFiltering(t *testing.T) {
 	err = r.Close()
 	require.NoError(t, err)
 
-	r, err = engine.QueryStmt("SELECT id, title FROM table1 WHERE active = null AND payload = null AND active = payload", nil, true)
+	r, err = engine.QueryStmt("SELECT id, title FROM table1 WHERE active IS NULL AND payload IS NULL AND active = payload", nil, true)
 	require.NoError(t, err)
 
 	_, err = r.Read()
@@ -2791,7 +3378,7 @@ func TestQueryWithInClause(t *testing.T) {
 	})
 
 	t.Run("in clause should succeed reading using 'IN' clause in join condition", func(t *testing.T) {
-		r, err := engine.QueryStmt("SELECT * FROM table1 as t1 INNER JOIN table1 as t2 ON t1.title IN (t2.title) ORDER BY title", nil, true)
+		r, err := engine.QueryStmt("SELECT * FROM table1 as t1 INNER JOIN table1 as t2 ON t1.title IN (t2.title) ORDER BY t1.title", nil, true)
 		require.NoError(t, err)
 
 		for i := 0; i < rowCount; i++ {
@@ -2805,6 +3392,80 @@ func TestQueryWithInClause(t *testing.T) {
 		require.NoError(t, err)
 	})
 
+	t.Run("in clause should expand a parameter bound to a slice", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT id, title FROM table1 WHERE id IN (@ids)", map[string]interface{}{"ids": []int64{1, 2, 3}}, true)
+		require.NoError(t, err)
+
+		for i := 1; i <= 3; i++ {
+			row, err := r.Read()
+			require.NoError(t, err)
+			require.Equal(t, int64(i), row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+		}
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("in clause should expand a slice parameter along with other values", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT id, title FROM table1 WHERE title NOT IN (@titles, 'title9')", map[string]interface{}{"titles": []interface{}{"title0", "title1", nil}}, true)
+		require.NoError(t, err)
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+
+		r, err = engine.QueryStmt("SELECT id, title FROM table1 WHERE title NOT IN (@titles, 'title9')", map[string]interface{}{"titles": []string{"title0", "title1"}}, true)
+		require.NoError(t, err)
+
+		for i := 2; i < rowCount-1; i++ {
+			row, err := r.Read()
+			require.NoError(t, err)
+			require.Equal(t, fmt.Sprintf("title%d", i), row.Values[EncodeSelector("", "db1", "table1", "title")].Value())
+		}
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("in clause should fail with a slice parameter mixing element types", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT id FROM table1 WHERE id IN (@ids)", map[string]interface{}{"ids": []interface{}{1, "2"}}, true)
+		require.NoError(t, err)
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrInvalidTypes)
+
+		err = r.Close()
+		require.NoError(t, err)
+
+		r, err = engine.QueryStmt("SELECT id FROM table1 WHERE id IN (@ids)", map[string]interface{}{"ids": []interface{}{1, 2.5}}, true)
+		require.NoError(t, err)
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrUnsupportedParameter)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("in clause should not expand a blob parameter", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT id FROM table1 WHERE id IN (@ids)", map[string]interface{}{"ids": []byte{1}}, true)
+		require.NoError(t, err)
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNotComparableValues)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
 	err = engine.Close()
 	require.NoError(t, err)
 }
@@ -2850,7 +3511,8 @@ func TestAggregations(t *testing.T) {
 	require.NoError(t, err)
 
 	_, err = r.Read()
-	require.Equal(t, ErrColumnDoesNotExist, err)
+	require.ErrorIs(t, err, ErrColumnDoesNotExist)
+	require.Contains(t, err.Error(), "db1.table1.i")
 
 	err = r.Close()
 	require.NoError(t, err)
@@ -2872,11 +3534,11 @@ func TestAggregations(t *testing.T) {
 
 	row, err = r.Read()
 	require.NoError(t, err)
-	require.Equal(t, int64(0), row.Values[EncodeSelector("", "db1", "table1", "col0")].Value())
-	require.Equal(t, int64(0), row.Values[EncodeSelector("", "db1", "table1", "col1")].Value())
-	require.Equal(t, "", row.Values[EncodeSelector("", "db1", "table1", "col2")].Value())
-	require.Equal(t, int64(0), row.Values[EncodeSelector("", "db1", "table1", "col3")].Value())
-	require.Equal(t, int64(0), row.Values[EncodeSelector("", "db1", "table1", "col4")].Value())
+	require.Equal(t, int64(0), row.Values[EncodeSelector("", "db1", "table1", "count(*)")].Value())
+	require.Equal(t, int64(0), row.Values[EncodeSelector("", "db1", "table1", "sum(age)")].Value())
+	require.Equal(t, "", row.Values[EncodeSelector("", "db1", "table1", "min(title)")].Value())
+	require.Equal(t, int64(0), row.Values[EncodeSelector("", "db1", "table1", "max(age)")].Value())
+	require.Equal(t, int64(0), row.Values[EncodeSelector("", "db1", "table1", "avg(age)")].Value())
 
 	err = r.Close()
 	require.NoError(t, err)
@@ -2895,13 +3557,13 @@ func TestAggregations(t *testing.T) {
 
 	require.Equal(t, int64(rowCount), row.Values[EncodeSelector("", "db1", "t1", "c")].Value())
 
-	require.Equal(t, int64((1+2*base+rowCount)*rowCount/2), row.Values[EncodeSelector("", "db1", "t1", "col1")].Value())
+	require.Equal(t, int64((1+2*base+rowCount)*rowCount/2), row.Values[EncodeSelector("", "db1", "t1", "sum(age)")].Value())
 
-	require.Equal(t, int64(1+base), row.Values[EncodeSelector("", "db1", "t1", "col2")].Value())
+	require.Equal(t, int64(1+base), row.Values[EncodeSelector("", "db1", "t1", "min(age)")].Value())
 
-	require.Equal(t, int64(base+rowCount), row.Values[EncodeSelector("", "db1", "t1", "col3")].Value())
+	require.Equal(t, int64(base+rowCount), row.Values[EncodeSelector("", "db1", "t1", "max(age)")].Value())
 
-	require.Equal(t, int64(base+rowCount/2), row.Values[EncodeSelector("", "db1", "t1", "col4")].Value())
+	require.Equal(t, int64(base+rowCount/2), row.Values[EncodeSelector("", "db1", "t1", "avg(age)")].Value())
 
 	_, err = r.Read()
 	require.Equal(t, ErrNoMoreRows, err)
@@ -2909,18 +3571,42 @@ func TestAggregations(t *testing.T) {
 	err = r.Close()
 	require.NoError(t, err)
 
+	// aggregations named alike are told apart
+	r, err = engine.QueryStmt("SELECT SUM(age), MAX(age), SUM(age), SUM(age) FROM table1", nil, true)
+	require.NoError(t, err)
+
+	cols, err = r.Columns()
+	require.NoError(t, err)
+	require.Len(t, cols, 4)
+	require.Equal(t, "sum(age)", cols[0].Column)
+	require.Equal(t, "max(age)", cols[1].Column)
+	require.Equal(t, "sum(age)_1", cols[2].Column)
+	require.Equal(t, "sum(age)_2", cols[3].Column)
+
+	row, err = r.Read()
+	require.NoError(t, err)
+	require.Len(t, row.Values, 4)
+
+	for _, col := range cols {
+		require.Contains(t, row.Values, col.Selector())
+	}
+	require.Equal(t, row.Values[cols[0].Selector()].Value(), row.Values[cols[2].Selector()].Value())
+
+	err = r.Close()
+	require.NoError(t, err)
+
 	err = engine.Close()
 	require.NoError(t, err)
 }
 
-func TestCount(t *testing.T) {
-	catalogStore, err := store.Open("catalog_agg", store.DefaultOptions())
+func TestAggregationsOverEmptyTable(t *testing.T) {
+	catalogStore, err := store.Open("catalog_agg_empty", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("catalog_agg")
+	defer os.RemoveAll("catalog_agg_empty")
 
-	dataStore, err := store.Open("sqldata_agg", store.DefaultOptions())
+	dataStore, err := store.Open("sqldata_agg_empty", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("sqldata_agg")
+	defer os.RemoveAll("sqldata_agg_empty")
 
 	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
 	require.NoError(t, err)
@@ -2931,15 +3617,64 @@ func TestCount(t *testing.T) {
 	err = engine.UseDatabase("db1")
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("CREATE TABLE t1(id INTEGER AUTO_INCREMENT, val1 INTEGER, PRIMARY KEY id)", nil, true)
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR[16], PRIMARY KEY id)", nil, true)
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("CREATE INDEX ON t1(val1)", nil, true)
-	require.NoError(t, err)
+	t.Run("a single row is returned without grouping", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT COUNT(), SUM(id), AVG(id), MIN(title), MAX(CAST(id AS TIMESTAMP)) AS ts FROM table1", nil, true)
+		require.NoError(t, err)
+		defer r.Close()
 
-	for i := 0; i < 10; i++ {
-		for j := 0; j < 3; j++ {
-			_, err = engine.ExecStmt("INSERT INTO t1(val1) VALUES($1)", map[string]interface{}{"param1": j}, true)
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Len(t, row.Values, 5)
+		require.Equal(t, int64(0), row.Values[EncodeSelector("", "db1", "table1", "count(*)")].Value())
+		require.Equal(t, int64(0), row.Values[EncodeSelector("", "db1", "table1", "sum(id)")].Value())
+		require.Equal(t, int64(0), row.Values[EncodeSelector("", "db1", "table1", "avg(id)")].Value())
+		require.Equal(t, "", row.Values[EncodeSelector("", "db1", "table1", "min(title)")].Value())
+		require.Equal(t, TimestampType, row.Values[EncodeSelector("", "db1", "table1", "ts")].Type())
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreRows)
+	})
+
+	t.Run("no groups are returned when grouping", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT COUNT() FROM table1 GROUP BY id", nil, true)
+		require.NoError(t, err)
+		defer r.Close()
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreRows)
+	})
+}
+
+func TestCount(t *testing.T) {
+	catalogStore, err := store.Open("catalog_agg", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_agg")
+
+	dataStore, err := store.Open("sqldata_agg", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_agg")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE t1(id INTEGER AUTO_INCREMENT, val1 INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON t1(val1)", nil, true)
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		for j := 0; j < 3; j++ {
+			_, err = engine.ExecStmt("INSERT INTO t1(val1) VALUES($1)", map[string]interface{}{"param1": j}, true)
 			require.NoError(t, err)
 		}
 	}
@@ -3011,13 +3746,13 @@ func TestGroupByHaving(t *testing.T) {
 		require.NoError(t, err)
 	}
 
-	_, err = engine.QueryStmt("SELECT active, COUNT(), SUM(age1) FROM table1 WHERE active != null HAVING AVG(age) >= MIN(age)", nil, true)
+	_, err = engine.QueryStmt("SELECT active, COUNT(), SUM(age1) FROM table1 WHERE active IS NOT NULL HAVING AVG(age) >= MIN(age)", nil, true)
 	require.Equal(t, ErrHavingClauseRequiresGroupClause, err)
 
 	r, err := engine.QueryStmt(`
 		SELECT active, COUNT(), SUM(age1)
 		FROM table1
-		WHERE active != null
+		WHERE active IS NOT NULL
 		GROUP BY active
 		HAVING AVG(age) >= MIN(age)
 		ORDER BY active`, nil, true)
@@ -3026,7 +3761,7 @@ func TestGroupByHaving(t *testing.T) {
 	r.SetParameters(nil)
 
 	_, err = r.Read()
-	require.Equal(t, ErrColumnDoesNotExist, err)
+	require.ErrorIs(t, err, ErrColumnDoesNotExist)
 
 	err = r.Close()
 	require.NoError(t, err)
@@ -3060,7 +3795,8 @@ func TestGroupByHaving(t *testing.T) {
 	require.NoError(t, err)
 
 	_, err = r.Read()
-	require.Equal(t, ErrColumnDoesNotExist, err)
+	require.ErrorIs(t, err, ErrColumnDoesNotExist)
+	require.Contains(t, err.Error(), "MIN(db1.table1.age1)")
 
 	err = r.Close()
 	require.NoError(t, err)
@@ -3093,14 +3829,302 @@ func TestGroupByHaving(t *testing.T) {
 		require.Equal(t, int64(rowCount/2), row.Values[EncodeSelector("", "db1", "table1", "c")].Value())
 
 		if i%2 == 0 {
-			require.Equal(t, int64(base), row.Values[EncodeSelector("", "db1", "table1", "col2")].Value())
-			require.Equal(t, int64(base+rowCount-2), row.Values[EncodeSelector("", "db1", "table1", "col3")].Value())
+			require.Equal(t, int64(base), row.Values[EncodeSelector("", "db1", "table1", "min(age)")].Value())
+			require.Equal(t, int64(base+rowCount-2), row.Values[EncodeSelector("", "db1", "table1", "max(age)")].Value())
 		} else {
-			require.Equal(t, int64(base+1), row.Values[EncodeSelector("", "db1", "table1", "col2")].Value())
-			require.Equal(t, int64(base+rowCount-1), row.Values[EncodeSelector("", "db1", "table1", "col3")].Value())
+			require.Equal(t, int64(base+1), row.Values[EncodeSelector("", "db1", "table1", "min(age)")].Value())
+			require.Equal(t, int64(base+rowCount-1), row.Values[EncodeSelector("", "db1", "table1", "max(age)")].Value())
+		}
+	}
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestGroupByHavingNonProjectedAggregations(t *testing.T) {
+	catalogStore, err := store.Open("catalog_having_non_projected", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_having_non_projected")
+
+	dataStore, err := store.Open("sqldata_having_non_projected", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_having_non_projected")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, category VARCHAR[16], amount INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(category)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		INSERT INTO table1 (id, category, amount)
+		VALUES (1, 'a', 10), (2, 'b', 20), (3, 'b', 30), (4, 'c', 40), (5, 'c', 50), (6, 'c', 60)`, nil, true)
+	require.NoError(t, err)
+
+	readCategories := func(query string) []string {
+		r, err := engine.QueryStmt(query, nil, true)
+		require.NoError(t, err)
+		defer r.Close()
+
+		var categories []string
+
+		for {
+			row, err := r.Read()
+			if err == ErrNoMoreRows {
+				break
+			}
+			require.NoError(t, err)
+			require.Len(t, row.Values, 1)
+
+			categories = append(categories, row.Values[EncodeSelector("", "db1", "table1", "category")].Value().(string))
+		}
+
+		return categories
+	}
+
+	require.Equal(t, []string{"a", "b", "c"}, readCategories("SELECT category FROM table1 GROUP BY category ORDER BY category"))
+	require.Equal(t, []string{"b", "c"}, readCategories("SELECT category FROM table1 GROUP BY category HAVING COUNT() > 1 ORDER BY category"))
+	require.Equal(t, []string{"c"}, readCategories("SELECT category FROM table1 GROUP BY category HAVING COUNT() > 1 AND MAX(amount) > 50 ORDER BY category"))
+
+	r, err := engine.QueryStmt("SELECT category, SUM(amount) FROM table1 GROUP BY category HAVING MIN(amount) >= 20 ORDER BY category", nil, true)
+	require.NoError(t, err)
+
+	cols, err := r.Columns()
+	require.NoError(t, err)
+	require.Len(t, cols, 2)
+
+	for _, expected := range []int64{50, 150} {
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Len(t, row.Values, 2)
+		require.Equal(t, expected, row.Values[EncodeSelector("", "db1", "table1", "sum(amount)")].Value())
+	}
+
+	_, err = r.Read()
+	require.ErrorIs(t, err, ErrNoMoreRows)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestAggregationsOverExpressions(t *testing.T) {
+	catalogStore, err := store.Open("catalog_agg_exps", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_agg_exps")
+
+	dataStore, err := store.Open("sqldata_agg_exps", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_agg_exps")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, category VARCHAR[16], price INTEGER, qty INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(category)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		INSERT INTO table1 (id, category, price, qty)
+		VALUES (1, 'a', 10, 1), (2, 'b', 20, 2), (3, 'b', 30, 3), (4, 'c', 40, 1), (5, 'c', 50, 2), (6, 'c', 60, 3)`, nil, true)
+	require.NoError(t, err)
+
+	t.Run("sum of products grouped by category", func(t *testing.T) {
+		r, err := engine.QueryStmt(`
+			SELECT category, SUM(price * qty), MAX(price - qty) AS m
+			FROM table1
+			GROUP BY category
+			ORDER BY category`, nil, true)
+		require.NoError(t, err)
+		defer r.Close()
+
+		cols, err := r.Columns()
+		require.NoError(t, err)
+		require.Len(t, cols, 3)
+		require.Equal(t, IntegerType, cols[1].Type)
+		require.Equal(t, IntegerType, cols[2].Type)
+
+		expected := []struct {
+			category string
+			total    int64
+			max      int64
+		}{
+			{"a", 10, 9},
+			{"b", 130, 27},
+			{"c", 320, 57},
+		}
+
+		for _, e := range expected {
+			row, err := r.Read()
+			require.NoError(t, err)
+			require.Len(t, row.Values, 3)
+			require.Equal(t, e.category, row.Values[EncodeSelector("", "db1", "table1", "category")].Value())
+			require.Equal(t, e.total, row.Values[EncodeSelector("", "db1", "table1", "sum(price * qty)")].Value())
+			require.Equal(t, e.max, row.Values[EncodeSelector("", "db1", "table1", "m")].Value())
+		}
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreRows)
+	})
+
+	t.Run("aggregated expression in having clause", func(t *testing.T) {
+		r, err := engine.QueryStmt(`
+			SELECT category, AVG(price * qty)
+			FROM table1
+			GROUP BY category
+			HAVING SUM(price * qty) > 100
+			ORDER BY category`, nil, true)
+		require.NoError(t, err)
+		defer r.Close()
+
+		for _, e := range []int64{65, 106} {
+			row, err := r.Read()
+			require.NoError(t, err)
+			require.Equal(t, e, row.Values[EncodeSelector("", "db1", "table1", "avg(price * qty)")].Value())
+		}
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreRows)
+	})
+
+	t.Run("aggregated expression without rows", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT SUM(price * qty) AS total FROM table1 WHERE id > 100", nil, true)
+		require.NoError(t, err)
+		defer r.Close()
+
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, int64(0), row.Values[EncodeSelector("", "db1", "table1", "total")].Value())
+	})
+
+	t.Run("non numeric expressions can not be summed", func(t *testing.T) {
+		_, err := engine.QueryStmt("SELECT SUM(price > qty) FROM table1", nil, true)
+		require.ErrorIs(t, err, ErrInvalidTypes)
+
+		_, err = engine.QueryStmt("SELECT category, AVG(price + category) FROM table1 GROUP BY category ORDER BY category", nil, true)
+		require.ErrorIs(t, err, ErrInvalidTypes)
+	})
+
+	t.Run("null values are not aggregated", func(t *testing.T) {
+		_, err := engine.ExecStmt(`
+			INSERT INTO table1 (id, category, price, qty)
+			VALUES (7, 'd', NULL, 2), (8, 'd', 70, NULL), (9, 'd', 80, 1), (10, 'e', NULL, 1)`, nil, true)
+		require.NoError(t, err)
+
+		r, err := engine.QueryStmt(`
+			SELECT category, SUM(price), SUM(price * qty), AVG(price), MIN(price), MAX(price * qty)
+			FROM table1
+			WHERE category >= 'd'
+			GROUP BY category
+			ORDER BY category`, nil, true)
+		require.NoError(t, err)
+		defer r.Close()
+
+		expected := []struct {
+			category string
+			sum      int64
+			total    int64
+			avg      int64
+			min      interface{}
+			max      interface{}
+		}{
+			{"d", 150, 80, 75, int64(70), int64(80)},
+			{"e", 0, 0, 0, nil, nil},
+		}
+
+		for _, e := range expected {
+			row, err := r.Read()
+			require.NoError(t, err)
+			require.Equal(t, e.category, row.Values[EncodeSelector("", "db1", "table1", "category")].Value())
+			require.Equal(t, e.sum, row.Values[EncodeSelector("", "db1", "table1", "sum(price)")].Value())
+			require.Equal(t, e.total, row.Values[EncodeSelector("", "db1", "table1", "sum(price * qty)")].Value())
+			require.Equal(t, e.avg, row.Values[EncodeSelector("", "db1", "table1", "avg(price)")].Value())
+			require.Equal(t, e.min, row.Values[EncodeSelector("", "db1", "table1", "min(price)")].Value())
+			require.Equal(t, e.max, row.Values[EncodeSelector("", "db1", "table1", "max(price * qty)")].Value())
 		}
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreRows)
+	})
+}
+
+func TestGroupByNonGroupedColumns(t *testing.T) {
+	catalogStore, err := store.Open("catalog_non_grouped", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_non_grouped")
+
+	dataStore, err := store.Open("sqldata_non_grouped", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_non_grouped")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, category VARCHAR[16], amount INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(category)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, category, amount) VALUES (1, 'a', 10), (2, 'b', 20), (3, 'b', 30)", nil, true)
+	require.NoError(t, err)
+
+	for _, query := range []string{
+		"SELECT category, amount FROM table1 GROUP BY category ORDER BY category",
+		"SELECT amount, COUNT() FROM table1 GROUP BY category ORDER BY category",
+		"SELECT id, COUNT() FROM table1",
+	} {
+		_, err = engine.QueryStmt(query, nil, true)
+		require.ErrorIs(t, err, ErrColumnNotGrouped, query)
 	}
 
+	_, err = engine.QueryStmt("SELECT category, amount FROM table1 GROUP BY category ORDER BY category", nil, true)
+	require.Contains(t, err.Error(), "db1.table1.amount")
+
+	r, err := engine.QueryStmt("SELECT t.category, COUNT(), SUM(amount) FROM table1 AS t GROUP BY category ORDER BY category", nil, true)
+	require.NoError(t, err)
+
+	row, err := r.Read()
+	require.NoError(t, err)
+	require.Equal(t, "a", row.Values[EncodeSelector("", "db1", "t", "category")].Value())
+	require.Equal(t, int64(10), row.Values[EncodeSelector("", "db1", "t", "sum(amount)")].Value())
+
+	row, err = r.Read()
+	require.NoError(t, err)
+	require.Equal(t, "b", row.Values[EncodeSelector("", "db1", "t", "category")].Value())
+	require.Equal(t, int64(50), row.Values[EncodeSelector("", "db1", "t", "sum(amount)")].Value())
+
+	_, err = r.Read()
+	require.ErrorIs(t, err, ErrNoMoreRows)
+
 	err = r.Close()
 	require.NoError(t, err)
 
@@ -3185,11 +4209,11 @@ func TestJoins(t *testing.T) {
 
 	t.Run("should resolve every inserted row", func(t *testing.T) {
 		r, err := engine.QueryStmt(`
-			SELECT id, title, table2.amount, table3.age
+			SELECT table1.id, title, table2.amount, table3.age
 			FROM table1 INNER JOIN table2 ON table1.fkid1 = table2.id
 			INNER JOIN table3 ON table1.fkid2 = table3.id
 			WHERE table1.id >= 0 AND table3.age >= 30
-			ORDER BY id DESC`, nil, true)
+			ORDER BY table1.id DESC`, nil, true)
 		require.NoError(t, err)
 
 		r.SetParameters(nil)
@@ -3215,31 +4239,25 @@ func TestJoins(t *testing.T) {
 	})
 
 	t.Run("should return error when joining nonexistent table", func(t *testing.T) {
-		r, err := engine.QueryStmt(`
+		_, err := engine.QueryStmt(`
 		SELECT title
 		FROM table1
 		INNER JOIN table22 ON table1.id = table11.fkid1`, nil, true)
-		require.NoError(t, err)
-
-		_, err = r.Read()
-		require.Equal(t, ErrTableDoesNotExist, err)
-
-		err = r.Close()
-		require.NoError(t, err)
+		require.ErrorIs(t, err, ErrTableDoesNotExist)
 	})
 
 	err = engine.Close()
 	require.NoError(t, err)
 }
 
-func TestJoinsWithJointTable(t *testing.T) {
-	catalogStore, err := store.Open("catalog_innerjoin_joint", store.DefaultOptions())
+func TestJoinsWithNonEqualityConditions(t *testing.T) {
+	catalogStore, err := store.Open("catalog_nonequality_join", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("catalog_innerjoin_joint")
+	defer os.RemoveAll("catalog_nonequality_join")
 
-	dataStore, err := store.Open("sqldata_innerjoin_joint", store.DefaultOptions())
+	dataStore, err := store.Open("sqldata_nonequality_join", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("sqldata_innerjoin_joint")
+	defer os.RemoveAll("sqldata_nonequality_join")
 
 	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
 	require.NoError(t, err)
@@ -3250,31 +4268,299 @@ func TestJoinsWithJointTable(t *testing.T) {
 	err = engine.UseDatabase("db1")
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, name VARCHAR, PRIMARY KEY id)", nil, true)
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, x INTEGER, PRIMARY KEY id)", nil, true)
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("CREATE TABLE table2 (id INTEGER AUTO_INCREMENT, amount INTEGER, PRIMARY KEY id)", nil, true)
+	_, err = engine.ExecStmt("CREATE TABLE table2 (id INTEGER, y INTEGER, PRIMARY KEY id)", nil, true)
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("CREATE TABLE table12 (id INTEGER AUTO_INCREMENT, fkid1 INTEGER, fkid2 INTEGER, active BOOLEAN, PRIMARY KEY id)", nil, true)
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, x) VALUES (1, 10), (2, 20), (3, 30)", nil, true)
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("INSERT INTO table1 (name) VALUES ('name1'), ('name2'), ('name3')", nil, true)
+	_, err = engine.ExecStmt("INSERT INTO table2 (id, y) VALUES (1, 15), (2, 25), (3, 5)", nil, true)
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("INSERT INTO table2 (amount) VALUES (10), (20), (30)", nil, true)
+	for _, tc := range []struct {
+		cond     string
+		expected [][2]int64
+	}{
+		{
+			// y is not indexed, every row of table2 is evaluated
+			cond:     "table1.x < table2.y",
+			expected: [][2]int64{{1, 1}, {1, 2}, {2, 2}},
+		},
+		{
+			// ranges over the primary key of table2 are used
+			cond:     "table2.id > table1.id",
+			expected: [][2]int64{{1, 2}, {1, 3}, {2, 3}},
+		},
+		{
+			cond:     "table1.x + 5 = table2.y OR NOT (table2.y > 5)",
+			expected: [][2]int64{{1, 1}, {1, 3}, {2, 2}, {2, 3}, {3, 3}},
+		},
+	} {
+		r, err := engine.QueryStmt("SELECT table1.id, table2.id FROM table1 INNER JOIN table2 ON "+tc.cond, nil, true)
+		require.NoError(t, err)
+
+		for _, ids := range tc.expected {
+			row, err := r.Read()
+			require.NoError(t, err)
+			require.Equal(t, ids[0], row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+			require.Equal(t, ids[1], row.Values[EncodeSelector("", "db1", "table2", "id")].Value())
+		}
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+	}
+}
+
+func TestLeftJoin(t *testing.T) {
+	catalogStore, err := store.Open("catalog_leftjoin", store.DefaultOptions())
 	require.NoError(t, err)
+	defer os.RemoveAll("catalog_leftjoin")
 
-	_, err = engine.ExecStmt("INSERT INTO table12 (fkid1, fkid2, active) VALUES (1,1,false),(1,2,true),(1,3,true)", nil, true)
+	dataStore, err := store.Open("sqldata_leftjoin", store.DefaultOptions())
 	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_leftjoin")
 
-	_, err = engine.ExecStmt("INSERT INTO table12 (fkid1, fkid2, active) VALUES (2,1,false),(2,2,false),(2,3,true)", nil, true)
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt("INSERT INTO table12 (fkid1, fkid2, active) VALUES (3,1,false),(3,2,false),(3,3,false)", nil, true)
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
 	require.NoError(t, err)
 
-	r, err := engine.QueryStmt(`
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR, fkid INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table2 (id INTEGER, amount INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table3 (id INTEGER, age INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, title, fkid) VALUES (1, 'title1', 10), (2, 'title2', 30), (3, 'title3', 20)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table2 (id, amount) VALUES (10, 100), (20, 200)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table3 (id, age) VALUES (1, 31), (2, 32), (3, 33)", nil, true)
+	require.NoError(t, err)
+
+	type joinedRow struct {
+		id     int64
+		id2    interface{}
+		amount interface{}
+	}
+
+	t.Run("unmatched left rows are emitted with NULL right columns", func(t *testing.T) {
+		r, err := engine.QueryStmt(`
+			SELECT table1.id, table2.id, table2.amount
+			FROM table1 LEFT JOIN table2 ON table1.fkid = table2.id`, nil, true)
+		require.NoError(t, err)
+
+		expected := []joinedRow{
+			{1, int64(10), int64(100)},
+			{2, nil, nil},
+			{3, int64(20), int64(200)},
+		}
+
+		for _, e := range expected {
+			row, err := r.Read()
+			require.NoError(t, err)
+
+			require.Equal(t, e.id, row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+			require.Equal(t, e.id2, row.Values[EncodeSelector("", "db1", "table2", "id")].Value())
+			require.Equal(t, e.amount, row.Values[EncodeSelector("", "db1", "table2", "amount")].Value())
+			require.Equal(t, IntegerType, row.Values[EncodeSelector("", "db1", "table2", "amount")].Type())
+		}
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("join condition evaluated false is an unmatched row", func(t *testing.T) {
+		r, err := engine.QueryStmt(`
+			SELECT table1.id, table2.id, table3.age
+			FROM table1
+			LEFT JOIN table2 ON table1.fkid = table2.id AND table2.amount > 100
+			INNER JOIN table3 ON table1.id = table3.id`, nil, true)
+		require.NoError(t, err)
+
+		expected := []joinedRow{
+			{1, nil, int64(31)},
+			{2, nil, int64(32)},
+			{3, int64(20), int64(33)},
+		}
+
+		for _, e := range expected {
+			row, err := r.Read()
+			require.NoError(t, err)
+
+			require.Equal(t, e.id, row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+			require.Equal(t, e.id2, row.Values[EncodeSelector("", "db1", "table2", "id")].Value())
+			require.Equal(t, e.amount, row.Values[EncodeSelector("", "db1", "table3", "age")].Value())
+		}
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+}
+
+func TestRightJoin(t *testing.T) {
+	catalogStore, err := store.Open("catalog_rightjoin", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_rightjoin")
+
+	dataStore, err := store.Open("sqldata_rightjoin", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_rightjoin")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR, fkid INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table2 (id INTEGER, amount INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, title, fkid) VALUES (1, 'title1', 10), (2, 'title2', 10), (3, 'title3', 20)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table2 (id, amount) VALUES (10, 100), (20, 200), (30, 300), (40, 400)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.QueryStmt("SELECT id FROM table1 RIGHT JOIN table2 ON table1.fkid = table2.id INNER JOIN table1 t3 ON t3.id = table1.id", nil, true)
+	require.ErrorIs(t, err, ErrUnsupportedJoinType)
+
+	r, err := engine.QueryStmt(`
+		SELECT table1.id, title, table2.id, table2.amount
+		FROM table1 RIGHT JOIN table2 ON table1.fkid = table2.id`, nil, true)
+	require.NoError(t, err)
+
+	type joinedRow struct {
+		id1   interface{}
+		title interface{}
+		id2   int64
+	}
+
+	expected := []joinedRow{
+		{int64(1), "title1", 10},
+		{int64(2), "title2", 10},
+		{int64(3), "title3", 20},
+		{nil, nil, 30},
+		{nil, nil, 40},
+	}
+
+	for _, e := range expected {
+		row, err := r.Read()
+		require.NoError(t, err)
+
+		require.Equal(t, e.id1, row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+		require.Equal(t, e.title, row.Values[EncodeSelector("", "db1", "table1", "title")].Value())
+		require.Equal(t, e.id2, row.Values[EncodeSelector("", "db1", "table2", "id")].Value())
+
+		if e.id1 == nil {
+			require.Equal(t, IntegerType, row.Values[EncodeSelector("", "db1", "table1", "id")].Type())
+			require.Equal(t, VarcharType, row.Values[EncodeSelector("", "db1", "table1", "title")].Type())
+		}
+	}
+
+	_, err = r.Read()
+	require.ErrorIs(t, err, ErrNoMoreRows)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	r, err = engine.QueryStmt(`
+		SELECT table1.id, table2.id
+		FROM table1 RIGHT JOIN table2 ON table1.fkid = table2.id AND table1.title != 'title3'`, nil, true)
+	require.NoError(t, err)
+
+	expected = []joinedRow{
+		{id1: int64(1), id2: 10},
+		{id1: int64(2), id2: 10},
+		{id1: nil, id2: 20},
+		{id1: nil, id2: 30},
+		{id1: nil, id2: 40},
+	}
+
+	for _, e := range expected {
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, e.id1, row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+		require.Equal(t, e.id2, row.Values[EncodeSelector("", "db1", "table2", "id")].Value())
+	}
+
+	_, err = r.Read()
+	require.ErrorIs(t, err, ErrNoMoreRows)
+
+	err = r.Close()
+	require.NoError(t, err)
+}
+
+func TestJoinsWithJointTable(t *testing.T) {
+	catalogStore, err := store.Open("catalog_innerjoin_joint", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_innerjoin_joint")
+
+	dataStore, err := store.Open("sqldata_innerjoin_joint", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_innerjoin_joint")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, name VARCHAR, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table2 (id INTEGER AUTO_INCREMENT, amount INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table12 (id INTEGER AUTO_INCREMENT, fkid1 INTEGER, fkid2 INTEGER, active BOOLEAN, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (name) VALUES ('name1'), ('name2'), ('name3')", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table2 (amount) VALUES (10), (20), (30)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table12 (fkid1, fkid2, active) VALUES (1,1,false),(1,2,true),(1,3,true)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table12 (fkid1, fkid2, active) VALUES (2,1,false),(2,2,false),(2,3,true)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table12 (fkid1, fkid2, active) VALUES (3,1,false),(3,2,false),(3,3,false)", nil, true)
+	require.NoError(t, err)
+
+	r, err := engine.QueryStmt(`
 		SELECT q.name, t2.amount, t12.active
 		FROM (SELECT * FROM table1 where name = 'name1') q
 		INNER JOIN table12 t12 on t12.fkid1 = q.id
@@ -3345,11 +4631,11 @@ func TestNestedJoins(t *testing.T) {
 	}
 
 	r, err := engine.QueryStmt(`
-		SELECT id, title, t2.amount AS total_amount, t3.age
+		SELECT t1.id, title, t2.amount AS total_amount, t3.age
 		FROM table1 t1
-		INNER JOIN table2 t2 ON (fkid1 = t2.id AND title != NULL)
+		INNER JOIN table2 t2 ON (t1.fkid1 = t2.id AND title IS NOT NULL)
 		INNER JOIN table3 t3 ON t2.fkid1 = t3.id
-		ORDER BY id DESC`, nil, true)
+		ORDER BY t1.id DESC`, nil, true)
 	require.NoError(t, err)
 
 	cols, err := r.Columns()
@@ -3523,14 +4809,14 @@ func TestSubQuery(t *testing.T) {
 	require.NoError(t, err)
 }
 
-func TestJoinsWithSubquery(t *testing.T) {
-	catalogStore, err := store.Open("catalog_subq", store.DefaultOptions())
+func TestQueryWithOffset(t *testing.T) {
+	catalogStore, err := store.Open("catalog_offset", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("catalog_subq")
+	defer os.RemoveAll("catalog_offset")
 
-	dataStore, err := store.Open("sqldata_subq", store.DefaultOptions())
+	dataStore, err := store.Open("sqldata_offset", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("sqldata_subq")
+	defer os.RemoveAll("sqldata_offset")
 
 	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
 	require.NoError(t, err)
@@ -3541,94 +4827,353 @@ func TestJoinsWithSubquery(t *testing.T) {
 	err = engine.UseDatabase("db1")
 	require.NoError(t, err)
 
-	_, err = engine.ExecStmt(`
-		CREATE TABLE IF NOT EXISTS customers (
-			id            INTEGER,
-			customer_name VARCHAR[60],
-			email         VARCHAR[150],
-			address       VARCHAR,
-			city          VARCHAR,
-			ip            VARCHAR[40],
-			country       VARCHAR[15],
-			age           INTEGER,
-			active        BOOLEAN,
-			PRIMARY KEY (id)
-		);
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
 
-		CREATE TABLE customer_review(
-			customerid INTEGER,
-			productid  INTEGER,
-			review     VARCHAR,
-			PRIMARY KEY (customerid, productid)
-		);
+	rowCount := 7
 
-		INSERT INTO customers (
-			id, customer_name, email, address,
-			city, ip, country, age, active
-		)
-		VALUES (
-			1,
-			'Isidro Behnen',
-			'ibehnen0@mail.ru',
-			'ibehnen0@chronoengine.com',
-			'Arvika',
-			'127.0.0.15',
-			'SE',
-			24,
-			true
-		);
+	for i := 0; i < rowCount; i++ {
+		_, err = engine.ExecStmt(fmt.Sprintf("INSERT INTO table1 (id, title) VALUES (%d, 'title%d')", rowCount-i, i), nil, true)
+		require.NoError(t, err)
+	}
 
-		INSERT INTO customer_review (customerid, productid, review)
-		VALUES(1, 1, 'Nice Juice!');
-	`, nil, true)
-	require.NoError(t, err)
+	pageSize := 2
 
-	r, err := engine.QueryStmt(`
-		SELECT * FROM (
-			SELECT id, customer_name, age
-			FROM customers
-			AS c
-		)
-		INNER JOIN (
-			SELECT MAX(customerid) as customerid, COUNT() as review_count
-			FROM customer_review
-			AS r
-		) ON r.customerid = c.id
-		WHERE c.age < 30
-		`,
-		nil, true)
-	require.NoError(t, err)
+	var ids []int64
 
-	row, err := r.Read()
-	require.NoError(t, err)
+	for page := 0; ; page++ {
+		r, err := engine.QueryStmt(fmt.Sprintf("SELECT id FROM table1 ORDER BY id DESC LIMIT %d OFFSET %d", pageSize, page*pageSize), nil, true)
+		require.NoError(t, err)
 
-	require.Len(t, row.Values, 5)
-	require.Equal(t, int64(1), row.Values[EncodeSelector("", "db1", "c", "id")].Value())
-	require.Equal(t, "Isidro Behnen", row.Values[EncodeSelector("", "db1", "c", "customer_name")].Value())
-	require.Equal(t, int64(24), row.Values[EncodeSelector("", "db1", "c", "age")].Value())
-	require.Equal(t, int64(1), row.Values[EncodeSelector("", "db1", "r", "customerid")].Value())
-	require.Equal(t, int64(1), row.Values[EncodeSelector("", "db1", "r", "review_count")].Value())
+		read := 0
 
-	err = r.Close()
+		for {
+			row, err := r.Read()
+			if err == ErrNoMoreRows {
+				break
+			}
+			require.NoError(t, err)
+
+			ids = append(ids, row.Values[EncodeSelector("", "db1", "table1", "id")].Value().(int64))
+			read++
+		}
+
+		err = r.Close()
+		require.NoError(t, err)
+
+		require.LessOrEqual(t, read, pageSize)
+
+		if read < pageSize {
+			break
+		}
+	}
+
+	require.Equal(t, []int64{7, 6, 5, 4, 3, 2, 1}, ids)
+
+	r, err := engine.QueryStmt("SELECT id FROM table1 OFFSET 10", nil, true)
 	require.NoError(t, err)
 
-	err = engine.Close()
+	_, err = r.Read()
+	require.ErrorIs(t, err, ErrNoMoreRows)
+
+	err = r.Close()
 	require.NoError(t, err)
 }
 
-func TestInferParameters(t *testing.T) {
-	catalogStore, err := store.Open("catalog_infer_params", store.DefaultOptions())
+func TestUnion(t *testing.T) {
+	catalogStore, err := store.Open("catalog_union", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("catalog_infer_params")
+	defer os.RemoveAll("catalog_union")
 
-	dataStore, err := store.Open("catalog_infer_params", store.DefaultOptions())
+	dataStore, err := store.Open("sqldata_union", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("catalog_infer_params")
+	defer os.RemoveAll("sqldata_union")
 
 	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
 	require.NoError(t, err)
 
-	stmt := "CREATE DATABASE db1"
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table2 (id INTEGER, name VARCHAR, active BOOLEAN, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, title) VALUES (1, 'a'), (2, 'b'), (3, 'c')", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table2 (id, name, active) VALUES (2, 'b', true), (3, 'z', false), (4, 'd', true)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.QueryStmt("SELECT id, title FROM table1 UNION SELECT id FROM table2", nil, true)
+	require.ErrorIs(t, err, ErrColumnMismatchInUnionStmt)
+
+	_, err = engine.QueryStmt("SELECT id, title FROM table1 UNION SELECT id, active FROM table2", nil, true)
+	require.ErrorIs(t, err, ErrColumnMismatchInUnionStmt)
+
+	_, err = engine.QueryStmt("SELECT id, title FROM table1 UNION SELECT id, name FROM table3", nil, true)
+	require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+	params, err := engine.InferParameters("SELECT id FROM table1 WHERE id > @id1 UNION SELECT id FROM table2 WHERE name = @name")
+	require.NoError(t, err)
+	require.Equal(t, map[string]SQLValueType{"id1": IntegerType, "name": VarcharType}, params)
+
+	for _, tc := range []struct {
+		query    string
+		expected [][2]interface{}
+	}{
+		{
+			query:    "SELECT id, title FROM table1 UNION ALL SELECT id, name FROM table2",
+			expected: [][2]interface{}{{int64(1), "a"}, {int64(2), "b"}, {int64(3), "c"}, {int64(2), "b"}, {int64(3), "z"}, {int64(4), "d"}},
+		},
+		{
+			query:    "SELECT id, title FROM table1 UNION SELECT id, name FROM table2",
+			expected: [][2]interface{}{{int64(1), "a"}, {int64(2), "b"}, {int64(3), "c"}, {int64(3), "z"}, {int64(4), "d"}},
+		},
+		{
+			query:    "SELECT id, title FROM table1 WHERE id > 2 UNION SELECT id, name FROM table2 WHERE active = true",
+			expected: [][2]interface{}{{int64(3), "c"}, {int64(2), "b"}, {int64(4), "d"}},
+		},
+	} {
+		r, err := engine.QueryStmt(tc.query, nil, true)
+		require.NoError(t, err)
+
+		cols, err := r.Columns()
+		require.NoError(t, err)
+		require.Len(t, cols, 2)
+		require.Equal(t, "table1", cols[0].Table)
+
+		for _, e := range tc.expected {
+			row, err := r.Read()
+			require.NoError(t, err)
+			require.Equal(t, e[0], row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+			require.Equal(t, e[1], row.Values[EncodeSelector("", "db1", "table1", "title")].Value())
+		}
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+	}
+}
+
+func TestSubqueryAsDataSource(t *testing.T) {
+	catalogStore, err := store.Open("catalog_subquery_ds", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_subquery_ds")
+
+	dataStore, err := store.Open("sqldata_subquery_ds", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_subquery_ds")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR, fkid INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table2 (id INTEGER, amount INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, title, fkid) VALUES (1, 'title1', 10), (2, 'title2', 10), (3, 'title3', 20)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table2 (id, amount) VALUES (10, 100), (20, 200), (30, 300), (40, 400)", nil, true)
+	require.NoError(t, err)
+
+	t.Run("select from a filtered subquery", func(t *testing.T) {
+		r, err := engine.QueryStmt(`
+			SELECT id, a
+			FROM (SELECT id, amount AS a FROM table2 WHERE amount > 100) AS q
+			WHERE a < 400`, nil, true)
+		require.NoError(t, err)
+
+		cols, err := r.Columns()
+		require.NoError(t, err)
+		require.Len(t, cols, 2)
+		require.Equal(t, EncodeSelector("", "db1", "q", "id"), cols[0].Selector())
+		require.Equal(t, EncodeSelector("", "db1", "q", "a"), cols[1].Selector())
+		require.Equal(t, IntegerType, cols[1].Type)
+
+		for _, id := range []int64{20, 30} {
+			row, err := r.Read()
+			require.NoError(t, err)
+			require.Equal(t, id, row.Values[EncodeSelector("", "db1", "q", "id")].Value())
+			require.Equal(t, id*10, row.Values[EncodeSelector("", "db1", "q", "a")].Value())
+		}
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("aggregate the rows of a subquery", func(t *testing.T) {
+		r, err := engine.QueryStmt(`
+			SELECT COUNT(), SUM(amount), MAX(amount)
+			FROM (SELECT id, amount FROM table2 WHERE amount > 100) AS q`, nil, true)
+		require.NoError(t, err)
+
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, int64(3), row.Values[EncodeSelector("", "db1", "q", "count(*)")].Value())
+		require.Equal(t, int64(900), row.Values[EncodeSelector("", "db1", "q", "sum(amount)")].Value())
+		require.Equal(t, int64(400), row.Values[EncodeSelector("", "db1", "q", "max(amount)")].Value())
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("join against a subquery", func(t *testing.T) {
+		r, err := engine.QueryStmt(`
+			SELECT table1.id, q.a
+			FROM table1
+			INNER JOIN (SELECT id, amount AS a FROM table2) AS q ON q.a > table1.fkid * 15`, nil, true)
+		require.NoError(t, err)
+
+		expected := [][2]int64{
+			{1, 200}, {1, 300}, {1, 400},
+			{2, 200}, {2, 300}, {2, 400},
+			{3, 400},
+		}
+
+		for _, e := range expected {
+			row, err := r.Read()
+			require.NoError(t, err)
+			require.Equal(t, e[0], row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+			require.Equal(t, e[1], row.Values[EncodeSelector("", "db1", "q", "a")].Value())
+		}
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+}
+
+func TestJoinsWithSubquery(t *testing.T) {
+	catalogStore, err := store.Open("catalog_subq", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_subq")
+
+	dataStore, err := store.Open("sqldata_subq", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_subq")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		CREATE TABLE IF NOT EXISTS customers (
+			id            INTEGER,
+			customer_name VARCHAR[60],
+			email         VARCHAR[150],
+			address       VARCHAR,
+			city          VARCHAR,
+			ip            VARCHAR[40],
+			country       VARCHAR[15],
+			age           INTEGER,
+			active        BOOLEAN,
+			PRIMARY KEY (id)
+		);
+
+		CREATE TABLE customer_review(
+			customerid INTEGER,
+			productid  INTEGER,
+			review     VARCHAR,
+			PRIMARY KEY (customerid, productid)
+		);
+
+		INSERT INTO customers (
+			id, customer_name, email, address,
+			city, ip, country, age, active
+		)
+		VALUES (
+			1,
+			'Isidro Behnen',
+			'ibehnen0@mail.ru',
+			'ibehnen0@chronoengine.com',
+			'Arvika',
+			'127.0.0.15',
+			'SE',
+			24,
+			true
+		);
+
+		INSERT INTO customer_review (customerid, productid, review)
+		VALUES(1, 1, 'Nice Juice!');
+	`, nil, true)
+	require.NoError(t, err)
+
+	r, err := engine.QueryStmt(`
+		SELECT * FROM (
+			SELECT id, customer_name, age
+			FROM customers
+			AS c
+		)
+		INNER JOIN (
+			SELECT MAX(customerid) as customerid, COUNT() as review_count
+			FROM customer_review
+			AS r
+		) ON r.customerid = c.id
+		WHERE c.age < 30
+		`,
+		nil, true)
+	require.NoError(t, err)
+
+	row, err := r.Read()
+	require.NoError(t, err)
+
+	require.Len(t, row.Values, 5)
+	require.Equal(t, int64(1), row.Values[EncodeSelector("", "db1", "c", "id")].Value())
+	require.Equal(t, "Isidro Behnen", row.Values[EncodeSelector("", "db1", "c", "customer_name")].Value())
+	require.Equal(t, int64(24), row.Values[EncodeSelector("", "db1", "c", "age")].Value())
+	require.Equal(t, int64(1), row.Values[EncodeSelector("", "db1", "r", "customerid")].Value())
+	require.Equal(t, int64(1), row.Values[EncodeSelector("", "db1", "r", "review_count")].Value())
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestInferParameters(t *testing.T) {
+	catalogStore, err := store.Open("catalog_infer_params", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_infer_params")
+
+	dataStore, err := store.Open("catalog_infer_params", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_infer_params")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	stmt := "CREATE DATABASE db1"
 
 	_, err = engine.InferParameters(stmt)
 	require.ErrorIs(t, err, ErrCatalogNotReady)
@@ -3719,7 +5264,7 @@ func TestInferParameters(t *testing.T) {
 	require.Len(t, params, 1)
 	require.Equal(t, IntegerType, params["id"])
 
-	params, err = engine.InferParameters("SELECT * FROM mytable t1 INNER JOIN mytable t2 ON t1.id = t2.id WHERE id > @id")
+	params, err = engine.InferParameters("SELECT * FROM mytable t1 INNER JOIN mytable t2 ON t1.id = t2.id WHERE t1.id > @id")
 	require.NoError(t, err)
 	require.Len(t, params, 1)
 	require.Equal(t, IntegerType, params["id"])
@@ -3761,14 +5306,14 @@ func TestInferParameters(t *testing.T) {
 	require.NoError(t, err)
 }
 
-func TestInferParametersPrepared(t *testing.T) {
-	catalogStore, err := store.Open("catalog_infer_params_prepared", store.DefaultOptions())
+func TestValidate(t *testing.T) {
+	catalogStore, err := store.Open("catalog_validate", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("catalog_infer_params_prepared")
+	defer os.RemoveAll("catalog_validate")
 
-	dataStore, err := store.Open("catalog_infer_params_prepared", store.DefaultOptions())
+	dataStore, err := store.Open("sqldata_validate", store.DefaultOptions())
 	require.NoError(t, err)
-	defer os.RemoveAll("catalog_infer_params_prepared")
+	defer os.RemoveAll("sqldata_validate")
 
 	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
 	require.NoError(t, err)
@@ -3779,38 +5324,117 @@ func TestInferParametersPrepared(t *testing.T) {
 	err = engine.UseDatabase("db1")
 	require.NoError(t, err)
 
-	stmts, err := Parse(strings.NewReader("CREATE TABLE mytable(id INTEGER, title VARCHAR, active BOOLEAN, PRIMARY KEY id)"))
-	require.NoError(t, err)
-	require.Len(t, stmts, 1)
-
-	params, err := engine.InferParametersPreparedStmt(stmts[0])
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, title VARCHAR[16], active BOOLEAN, PRIMARY KEY id)", nil, true)
 	require.NoError(t, err)
-	require.Len(t, params, 0)
 
-	_, err = engine.ExecPreparedStmts(stmts, nil, true)
+	_, err = engine.ExecStmt("INSERT INTO table1 (title, active) VALUES ('title1', true)", nil, true)
 	require.NoError(t, err)
 
-	err = engine.Close()
-	require.NoError(t, err)
-}
+	txID := dataStore.TxCount()
 
-func TestInferParametersUnbounded(t *testing.T) {
-	catalogStore, err := store.Open("catalog_infer_params_unbounded", store.DefaultOptions())
-	require.NoError(t, err)
-	defer os.RemoveAll("catalog_infer_params_unbounded")
+	t.Run("a valid script passes without being executed", func(t *testing.T) {
+		err := engine.Validate(`
+			INSERT INTO table1 (title, active) VALUES ('title2', false);
+			UPDATE table1 SET active = NOT active WHERE title = @title;
+			DELETE FROM table1 WHERE id > 1;
+			SELECT id, title FROM table1 WHERE active AND id >= @id;
+		`)
+		require.NoError(t, err)
 
-	dataStore, err := store.Open("catalog_infer_params_unbounded", store.DefaultOptions())
-	require.NoError(t, err)
-	defer os.RemoveAll("catalog_infer_params_unbounded")
+		require.Equal(t, txID, dataStore.TxCount())
 
-	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
-	require.NoError(t, err)
+		r, err := engine.QueryStmt("SELECT COUNT() FROM table1", nil, true)
+		require.NoError(t, err)
+		defer r.Close()
 
-	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
-	require.NoError(t, err)
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, int64(1), row.Values[EncodeSelector("", "db1", "table1", "count(*)")].Value())
+	})
 
-	err = engine.UseDatabase("db1")
-	require.NoError(t, err)
+	t.Run("type-incompatible comparison", func(t *testing.T) {
+		err := engine.Validate("SELECT id FROM table1 WHERE title = 1")
+		require.ErrorIs(t, err, ErrInvalidTypes)
+
+		err = engine.Validate("UPDATE table1 SET active = 'yes'")
+		require.ErrorIs(t, err, ErrInvalidTypes)
+	})
+
+	t.Run("unknown column", func(t *testing.T) {
+		err := engine.Validate("SELECT id FROM table1 WHERE name = 'title1'")
+		require.ErrorIs(t, err, ErrColumnDoesNotExist)
+
+		err = engine.Validate("INSERT INTO table1 (title, name) VALUES ('title2', 'name2')")
+		require.ErrorIs(t, err, ErrColumnDoesNotExist)
+	})
+
+	t.Run("unknown table", func(t *testing.T) {
+		err := engine.Validate("DELETE FROM table1 WHERE id > 1; DELETE FROM table2 WHERE id > 1")
+		require.ErrorIs(t, err, ErrTableDoesNotExist)
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		err := engine.Validate("SELECT FROM table1")
+		require.Error(t, err)
+	})
+
+	err = engine.Close()
+	require.NoError(t, err)
+
+	err = engine.Validate("SELECT id FROM table1")
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+}
+
+func TestInferParametersPrepared(t *testing.T) {
+	catalogStore, err := store.Open("catalog_infer_params_prepared", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_infer_params_prepared")
+
+	dataStore, err := store.Open("catalog_infer_params_prepared", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_infer_params_prepared")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	stmts, err := Parse(strings.NewReader("CREATE TABLE mytable(id INTEGER, title VARCHAR, active BOOLEAN, PRIMARY KEY id)"))
+	require.NoError(t, err)
+	require.Len(t, stmts, 1)
+
+	params, err := engine.InferParametersPreparedStmt(stmts[0])
+	require.NoError(t, err)
+	require.Len(t, params, 0)
+
+	_, err = engine.ExecPreparedStmts(stmts, nil, true)
+	require.NoError(t, err)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestInferParametersUnbounded(t *testing.T) {
+	catalogStore, err := store.Open("catalog_infer_params_unbounded", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_infer_params_unbounded")
+
+	dataStore, err := store.Open("catalog_infer_params_unbounded", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_infer_params_unbounded")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
 
 	_, err = engine.ExecStmt("CREATE TABLE mytable(id INTEGER, title VARCHAR, active BOOLEAN, PRIMARY KEY id)", nil, true)
 	require.NoError(t, err)
@@ -3983,6 +5607,13 @@ func TestDecodeValueSuccess(t *testing.T) {
 			&Number{val: math.MaxInt32},
 			12,
 		},
+		{
+			"timestamp",
+			[]byte{0, 0, 0, 8, 0, 0, 0, 0, 127, 255, 255, 255},
+			TimestampType,
+			&Timestamp{val: math.MaxInt32},
+			12,
+		},
 		{
 			"boolean false",
 			[]byte{0, 0, 0, 1, 0},
@@ -4286,6 +5917,23 @@ func TestEncodeAsKeyEdgeCases(t *testing.T) {
 		require.ErrorIs(t, err, ErrCorruptedData)
 	})
 
+	t.Run("timestamp cases", func(t *testing.T) {
+		_, err = EncodeAsKey("abc", TimestampType, 8)
+		require.ErrorIs(t, err, ErrInvalidValue)
+
+		k1, err := EncodeAsKey(int64(-1), TimestampType, 8)
+		require.NoError(t, err)
+
+		k2, err := EncodeAsKey(int64(1), TimestampType, 8)
+		require.NoError(t, err)
+
+		ik1, err := EncodeAsKey(int64(-1), IntegerType, 8)
+		require.NoError(t, err)
+		require.Equal(t, ik1, k1)
+
+		require.Less(t, bytes.Compare(k1, k2), 0)
+	})
+
 	t.Run("boolean cases", func(t *testing.T) {
 		_, err = EncodeAsKey("abc", BooleanType, 1)
 		require.ErrorIs(t, err, ErrInvalidValue)
@@ -4302,3 +5950,4929 @@ func TestEncodeAsKeyEdgeCases(t *testing.T) {
 		require.ErrorIs(t, err, ErrMaxLengthExceeded)
 	})
 }
+
+func TestDecodeKeyValue(t *testing.T) {
+	testCases := []struct {
+		val     TypedValue
+		colType SQLValueType
+		maxLen  int
+	}{
+		{&Number{val: math.MinInt64}, IntegerType, 8},
+		{&Number{val: -1}, IntegerType, 8},
+		{&Number{val: math.MaxInt64}, IntegerType, 8},
+		{&Timestamp{val: time.Now().UnixNano()}, TimestampType, 8},
+		{&Bool{val: true}, BooleanType, 1},
+		{&Bool{val: false}, BooleanType, 1},
+		{&Varchar{val: ""}, VarcharType, 10},
+		{&Varchar{val: "title\x00"}, VarcharType, 10},
+		{&Blob{val: []byte{}}, BLOBType, 4},
+		{&Blob{val: []byte{0, 1, 2, 3}}, BLOBType, 4},
+	}
+
+	for i, tc := range testCases {
+		enc, err := EncodeAsKey(tc.val.Value(), tc.colType, tc.maxLen)
+		require.NoError(t, err)
+
+		val, n, err := DecodeKeyValue(append(enc, 0xff), tc.colType, tc.maxLen)
+		require.NoError(t, err)
+		require.Equal(t, len(enc), n, fmt.Sprintf("failed on iteration %d", i))
+		require.Equal(t, tc.val, val, fmt.Sprintf("failed on iteration %d", i))
+
+		_, _, err = DecodeKeyValue(enc[:len(enc)-1], tc.colType, tc.maxLen)
+		require.ErrorIs(t, err, ErrCorruptedData)
+	}
+}
+
+func TestEncodeAsKeyPreservesOrder(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	randValue := func(maxLen int) []byte {
+		v := make([]byte, rnd.Intn(maxLen+1))
+
+		for i := range v {
+			// a small alphabet including zero bytes makes shared prefixes and trailing zeros likely
+			v[i] = byte(rnd.Intn(3))
+		}
+
+		return v
+	}
+
+	for _, maxLen := range []int{1, 4, 16, maxKeyLen} {
+		for _, colType := range []SQLValueType{VarcharType, BLOBType} {
+			t.Run(fmt.Sprintf("%s[%d]", colType, maxLen), func(t *testing.T) {
+				values := make([][]byte, 100)
+				keys := make([][]byte, len(values))
+
+				for i := range values {
+					values[i] = randValue(maxLen)
+
+					var val interface{} = values[i]
+					if colType == VarcharType {
+						val = string(values[i])
+					}
+
+					k, err := EncodeAsKey(val, colType, maxLen)
+					require.NoError(t, err)
+					require.Len(t, k, maxLen+EncLenLen)
+
+					keys[i] = k
+				}
+
+				for i := range values {
+					for j := range values {
+						require.Equal(t,
+							bytes.Compare(values[i], values[j]),
+							bytes.Compare(keys[i], keys[j]),
+							"values %x and %x", values[i], values[j],
+						)
+					}
+				}
+			})
+		}
+	}
+
+	t.Run("trailing zeros", func(t *testing.T) {
+		k1, err := EncodeAsKey("a", VarcharType, 4)
+		require.NoError(t, err)
+
+		k2, err := EncodeAsKey("a\x00", VarcharType, 4)
+		require.NoError(t, err)
+
+		require.Less(t, bytes.Compare(k1, k2), 0)
+	})
+
+	t.Run("value exceeding max length", func(t *testing.T) {
+		_, err := EncodeAsKey([]byte{0, 0}, BLOBType, 1)
+		require.ErrorIs(t, err, ErrMaxLengthExceeded)
+	})
+}
+
+func TestBLOBLiteralRoundtrip(t *testing.T) {
+	catalogStore, err := store.Open("catalog_blob_literal", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_blob_literal")
+
+	dataStore, err := store.Open("sqldata_blob_literal", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_blob_literal")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, payload BLOB, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, payload) VALUES (1, x'deadbeef'), (2, x'')", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, payload) VALUES (3, x'dead0')", nil, true)
+	require.ErrorIs(t, err, ErrInvalidBLOBLiteral)
+
+	r, err := engine.QueryStmt("SELECT payload FROM table1", nil, true)
+	require.NoError(t, err)
+
+	row, err := r.Read()
+	require.NoError(t, err)
+	require.Equal(t, []byte{0xde, 0xad, 0xbe, 0xef}, row.Values[EncodeSelector("", "db1", "table1", "payload")].Value())
+
+	row, err = r.Read()
+	require.NoError(t, err)
+	require.Equal(t, []byte{}, row.Values[EncodeSelector("", "db1", "table1", "payload")].Value())
+
+	_, err = r.Read()
+	require.Equal(t, ErrNoMoreRows, err)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestPositionalParams(t *testing.T) {
+	catalogStore, err := store.Open("catalog_positional_params", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_positional_params")
+
+	dataStore, err := store.Open("sqldata_positional_params", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_positional_params")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR, active BOOLEAN, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, title, active) VALUES (?, ?, ?)", PositionalParams(1, "title1", true), true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, title, active) VALUES ($1, $2, $3)", PositionalParams(2, "title2", false), true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, title, active) VALUES (?, ?, ?)", PositionalParams(3, "title3"), true)
+	require.ErrorIs(t, err, ErrMissingParameter)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, title, active) VALUES (?, @title, ?)", nil, true)
+	require.ErrorIs(t, err, ErrEitherNamedOrUnnamedParams)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, title, active) VALUES ($1, @title, $3)", nil, true)
+	require.ErrorIs(t, err, ErrEitherPosOrNonPosParams)
+
+	r, err := engine.QueryStmt("SELECT id, title, active FROM table1 WHERE id = ?", PositionalParams(1), true)
+	require.NoError(t, err)
+
+	row, err := r.Read()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+	require.Equal(t, "title1", row.Values[EncodeSelector("", "db1", "table1", "title")].Value())
+	require.Equal(t, true, row.Values[EncodeSelector("", "db1", "table1", "active")].Value())
+
+	_, err = r.Read()
+	require.ErrorIs(t, err, ErrNoMoreRows)
+
+	err = r.Close()
+	require.NoError(t, err)
+}
+
+func TestInferParameterTypes(t *testing.T) {
+	catalogStore, err := store.Open("catalog_infer_param_types", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_infer_param_types")
+
+	dataStore, err := store.Open("sqldata_infer_param_types", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_infer_param_types")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.InferParameterTypes("SELECT id FROM table1 WHERE id > @p")
+	require.ErrorIs(t, err, ErrCatalogNotReady)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	types, err := engine.InferParameterTypes("SELECT id FROM table1 WHERE id > @p")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"p": IntegerType}, types)
+
+	types, err = engine.InferParameterTypes("SELECT id FROM table1 WHERE title = @p")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"p": VarcharType}, types)
+
+	types, err = engine.InferParameterTypes("SELECT id FROM table1 WHERE id > @p AND title = @q LIMIT 10")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"p": IntegerType, "q": VarcharType}, types)
+
+	types, err = engine.InferParameterTypes("SELECT id FROM table1 WHERE @p = @q")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"p": AnyType, "q": AnyType}, types)
+
+	_, err = engine.InferParameterTypes("SELECT id FROM table1 WHERE id > @p AND title = @p")
+	require.ErrorIs(t, err, ErrInvalidTypes)
+
+	_, err = engine.InferParameterTypes("SELECT id FROM table1 WHERE")
+	require.Error(t, err)
+}
+
+func TestOrderByNullsOrder(t *testing.T) {
+	catalogStore, err := store.Open("catalog_nulls_order", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_nulls_order")
+
+	dataStore, err := store.Open("sqldata_nulls_order", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_nulls_order")
+
+	// rows sorted in memory are spilled in runs of up to 3 rows
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix).WithSortSpillThld(3))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR[10], note VARCHAR, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(title)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, title, note) VALUES (1, 'b', NULL), (2, 'a', 'note2'), (3, 'c', NULL), (4, 'd', 'note1')", nil, true)
+	require.NoError(t, err)
+
+	// indexed columns can not hold null values
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, note) VALUES (5, 'note5')", nil, true)
+	require.ErrorIs(t, err, ErrIndexedColumnCanNotBeNull)
+
+	for _, tc := range []struct {
+		query       string
+		expectedIDs []int64
+	}{
+		{"SELECT id FROM table1 ORDER BY title NULLS FIRST", []int64{2, 1, 3, 4}},
+		{"SELECT id FROM table1 ORDER BY title NULLS LAST", []int64{2, 1, 3, 4}},
+		{"SELECT id FROM table1 ORDER BY title DESC NULLS FIRST", []int64{4, 3, 1, 2}},
+		{"SELECT id FROM table1 ORDER BY title DESC NULLS LAST", []int64{4, 3, 1, 2}},
+
+		{"SELECT id FROM table1 ORDER BY note", []int64{1, 3, 4, 2}},
+		{"SELECT id FROM table1 ORDER BY note NULLS FIRST", []int64{1, 3, 4, 2}},
+		{"SELECT id FROM table1 ORDER BY note NULLS LAST", []int64{4, 2, 1, 3}},
+		{"SELECT id FROM table1 ORDER BY note DESC", []int64{2, 4, 1, 3}},
+		{"SELECT id FROM table1 ORDER BY note DESC NULLS FIRST", []int64{1, 3, 2, 4}},
+		{"SELECT id FROM table1 ORDER BY note DESC NULLS LAST", []int64{2, 4, 1, 3}},
+		{"SELECT id FROM table1 WHERE id > 1 ORDER BY note NULLS LAST LIMIT 2", []int64{4, 2}},
+		{"SELECT t.id FROM table1 AS t ORDER BY t.note DESC NULLS FIRST LIMIT 2 OFFSET 1", []int64{3, 2}},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			r, err := engine.QueryStmt(tc.query, nil, true)
+			require.NoError(t, err)
+
+			for _, id := range tc.expectedIDs {
+				row, err := r.Read()
+				require.NoError(t, err)
+				require.Equal(t, id, row.Values[EncodeSelector("", "db1", r.ImplicitTable(), "id")].Value())
+			}
+
+			_, err = r.Read()
+			require.ErrorIs(t, err, ErrNoMoreRows)
+
+			err = r.Close()
+			require.NoError(t, err)
+		})
+	}
+
+	_, err = engine.QueryStmt("SELECT COUNT() FROM table1 ORDER BY note NULLS LAST", nil, true)
+	require.ErrorIs(t, err, ErrLimitedOrderBy)
+
+	_, err = engine.QueryStmt("SELECT id FROM table1 ORDER BY note, id DESC", nil, true)
+	require.ErrorIs(t, err, ErrLimitedOrderBy)
+
+	t.Run("nulls ordering keywords should be valid identifiers", func(t *testing.T) {
+		_, err = engine.ExecStmt("CREATE TABLE names (id INTEGER, first VARCHAR, last VARCHAR, PRIMARY KEY id)", nil, true)
+		require.NoError(t, err)
+
+		_, err = engine.ExecStmt("INSERT INTO names (id, first, last) VALUES (1, 'john', 'smith'), (2, 'jane', NULL)", nil, true)
+		require.NoError(t, err)
+
+		r, err := engine.QueryStmt("SELECT first AS nulls FROM names ORDER BY last DESC NULLS FIRST", nil, true)
+		require.NoError(t, err)
+
+		for _, first := range []string{"jane", "john"} {
+			row, err := r.Read()
+			require.NoError(t, err)
+			require.Equal(t, first, row.Values[EncodeSelector("", "db1", "names", "nulls")].Value())
+		}
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestQueryWithTimestampFunctions(t *testing.T) {
+	catalogStore, err := store.Open("catalog_timestamp_fns", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_timestamp_fns")
+
+	dataStore, err := store.Open("sqldata_timestamp_fns", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_timestamp_fns")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, created VARCHAR, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		INSERT INTO table1 (id, created)
+		VALUES (1, '2020-12-31T23:59:59Z'), (2, '2021-01-02T03:04:05Z'), (3, '2021-03-02T03:04:05.123Z')`, nil, true)
+	require.NoError(t, err)
+
+	r, err := engine.QueryStmt("SELECT id FROM table1 WHERE YEAR(CAST(created AS TIMESTAMP)) = @year AND DAY(CAST(created AS TIMESTAMP)) = 2", map[string]interface{}{"year": 2021}, true)
+	require.NoError(t, err)
+
+	params := make(map[string]SQLValueType)
+	err = r.InferParameters(params)
+	require.NoError(t, err)
+	require.Equal(t, map[string]SQLValueType{"year": IntegerType}, params)
+
+	for _, id := range []int64{2, 3} {
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, id, row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+	}
+
+	_, err = r.Read()
+	require.Equal(t, ErrNoMoreRows, err)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	r, err = engine.QueryStmt("SELECT id FROM table1 WHERE MONTH(CAST('not a timestamp' AS TIMESTAMP)) = 1", nil, true)
+	require.NoError(t, err)
+
+	_, err = r.Read()
+	require.ErrorIs(t, err, ErrInvalidTimestamp)
+
+	err = r.Close()
+	require.NoError(t, err)
+}
+
+func TestWildcardProjection(t *testing.T) {
+	catalogStore, err := store.Open("catalog_wildcard", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_wildcard")
+
+	dataStore, err := store.Open("sqldata_wildcard", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_wildcard")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR, fkid INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table2 (id INTEGER, amount INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, title, fkid) VALUES (1, 'title1', 10), (2, 'title2', 20)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table2 (id, amount) VALUES (10, 100), (20, 200)", nil, true)
+	require.NoError(t, err)
+
+	columnsOf := func(r RowReader) []string {
+		cols, err := r.Columns()
+		require.NoError(t, err)
+
+		names := make([]string, len(cols))
+		for i, c := range cols {
+			names[i] = c.Selector()
+		}
+
+		return names
+	}
+
+	t.Run("bare wildcard expands across all joined tables", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT * FROM table1 INNER JOIN table2 ON table1.fkid = table2.id", nil, true)
+		require.NoError(t, err)
+		defer r.Close()
+
+		require.Equal(t, []string{
+			EncodeSelector("", "db1", "table1", "id"),
+			EncodeSelector("", "db1", "table1", "title"),
+			EncodeSelector("", "db1", "table1", "fkid"),
+			EncodeSelector("", "db1", "table2", "id"),
+			EncodeSelector("", "db1", "table2", "amount"),
+		}, columnsOf(r))
+
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Len(t, row.Values, 5)
+		require.Equal(t, int64(100), row.Values[EncodeSelector("", "db1", "table2", "amount")].Value())
+	})
+
+	t.Run("qualified wildcard expands to the columns of a single table", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT table2.*, table1.title FROM table1 INNER JOIN table2 ON table1.fkid = table2.id", nil, true)
+		require.NoError(t, err)
+		defer r.Close()
+
+		require.Equal(t, []string{
+			EncodeSelector("", "db1", "table2", "id"),
+			EncodeSelector("", "db1", "table2", "amount"),
+			EncodeSelector("", "db1", "table1", "title"),
+		}, columnsOf(r))
+
+		for i := 1; i <= 2; i++ {
+			row, err := r.Read()
+			require.NoError(t, err)
+			require.Len(t, row.Values, 3)
+			require.Equal(t, int64(i*10), row.Values[EncodeSelector("", "db1", "table2", "id")].Value())
+			require.Equal(t, int64(i*100), row.Values[EncodeSelector("", "db1", "table2", "amount")].Value())
+			require.Equal(t, fmt.Sprintf("title%d", i), row.Values[EncodeSelector("", "db1", "table1", "title")].Value())
+		}
+
+		_, err = r.Read()
+		require.Equal(t, ErrNoMoreRows, err)
+	})
+
+	t.Run("qualified wildcard using a table alias", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT t1.* FROM table1 AS t1 INNER JOIN table2 ON t1.fkid = table2.id", nil, true)
+		require.NoError(t, err)
+		defer r.Close()
+
+		require.Equal(t, []string{
+			EncodeSelector("", "db1", "t1", "id"),
+			EncodeSelector("", "db1", "t1", "title"),
+			EncodeSelector("", "db1", "t1", "fkid"),
+		}, columnsOf(r))
+	})
+
+	t.Run("qualified wildcard over an unknown table", func(t *testing.T) {
+		_, err := engine.QueryStmt("SELECT table3.* FROM table1 INNER JOIN table2 ON table1.fkid = table2.id", nil, true)
+		require.ErrorIs(t, err, ErrTableDoesNotExist)
+	})
+}
+
+func TestOrderByDescUsingSecondaryIndexWithRanges(t *testing.T) {
+	catalogStore, err := store.Open("catalog_desc_ranges", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_desc_ranges")
+
+	dataStore, err := store.Open("sqldata_desc_ranges", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_desc_ranges")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, age INTEGER, title VARCHAR[10], PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(age)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(title, age)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		INSERT INTO table1 (id, age, title)
+		VALUES (1, 10, 'a'), (2, 20, 'b'), (3, 30, 'a'), (4, 40, 'b'), (5, 50, 'a'), (6, 25, 'a'), (7, 30, 'b')`, nil, true)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		query       string
+		indexCols   []string
+		expectedIDs []int64
+	}{
+		{
+			query:       "SELECT id FROM table1 WHERE age > 15 AND age <= 40 ORDER BY age DESC",
+			indexCols:   []string{"age"},
+			expectedIDs: []int64{4, 7, 3, 6, 2},
+		},
+		{
+			query:       "SELECT id FROM table1 WHERE 15 < age AND 40 >= age ORDER BY age DESC",
+			indexCols:   []string{"age"},
+			expectedIDs: []int64{4, 7, 3, 6, 2},
+		},
+		{
+			query:       "SELECT id FROM table1 WHERE age < 30 ORDER BY age DESC",
+			indexCols:   []string{"age"},
+			expectedIDs: []int64{6, 2, 1},
+		},
+		{
+			query:       "SELECT id FROM table1 WHERE age >= 30 ORDER BY age DESC",
+			indexCols:   []string{"age"},
+			expectedIDs: []int64{5, 4, 7, 3},
+		},
+		{
+			query:       "SELECT id FROM table1 USE INDEX ON (title, age) WHERE title = 'a' AND age > 15 AND age < 50 ORDER BY age DESC",
+			indexCols:   []string{"title", "age"},
+			expectedIDs: []int64{3, 6},
+		},
+	}
+
+	for i, tc := range testCases {
+		r, err := engine.QueryStmt(tc.query, nil, true)
+		require.NoError(t, err, fmt.Sprintf("failed on iteration %d", i))
+
+		scanSpecs := r.ScanSpecs()
+		require.True(t, scanSpecs.descOrder, fmt.Sprintf("failed on iteration %d", i))
+		require.False(t, scanSpecs.index.IsPrimary(), fmt.Sprintf("failed on iteration %d", i))
+		require.Len(t, scanSpecs.index.cols, len(tc.indexCols), fmt.Sprintf("failed on iteration %d", i))
+
+		for j, col := range scanSpecs.index.cols {
+			require.Equal(t, tc.indexCols[j], col.colName, fmt.Sprintf("failed on iteration %d", i))
+
+			if col.colName == "age" {
+				require.Contains(t, scanSpecs.rangesByColID, col.id, fmt.Sprintf("failed on iteration %d", i))
+			}
+		}
+
+		for _, id := range tc.expectedIDs {
+			row, err := r.Read()
+			require.NoError(t, err, fmt.Sprintf("failed on iteration %d", i))
+			require.Equal(t, id, row.Values[EncodeSelector("", "db1", "table1", "id")].Value(), fmt.Sprintf("failed on iteration %d", i))
+		}
+
+		_, err = r.Read()
+		require.Equal(t, ErrNoMoreRows, err, fmt.Sprintf("failed on iteration %d", i))
+
+		err = r.Close()
+		require.NoError(t, err)
+	}
+}
+
+func TestBooleanKeys(t *testing.T) {
+	t.Run("boolean keys preserve ordering", func(t *testing.T) {
+		kfalse, err := EncodeAsKey(false, BooleanType, 1)
+		require.NoError(t, err)
+
+		ktrue, err := EncodeAsKey(true, BooleanType, 1)
+		require.NoError(t, err)
+
+		require.Less(t, bytes.Compare(kfalse, ktrue), 0)
+		require.Less(t, bytes.Compare(ktrue, maxKeyValOf(BooleanType)), 0)
+	})
+
+	t.Run("boolean values round-trip", func(t *testing.T) {
+		for _, b := range []bool{false, true} {
+			encVal, err := EncodeValue(b, BooleanType, 0)
+			require.NoError(t, err)
+
+			v, n, err := DecodeValue(encVal, BooleanType)
+			require.NoError(t, err)
+			require.Equal(t, len(encVal), n)
+			require.Equal(t, &Bool{val: b}, v)
+		}
+	})
+
+	catalogStore, err := store.Open("catalog_boolean_keys", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_boolean_keys")
+
+	dataStore, err := store.Open("sqldata_boolean_keys", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_boolean_keys")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		BEGIN TRANSACTION
+			CREATE TABLE table1 (id INTEGER, active BOOLEAN, PRIMARY KEY id);
+			CREATE INDEX ON table1(active);
+
+			CREATE TABLE table2 (id INTEGER, active BOOLEAN, PRIMARY KEY id);
+			CREATE UNIQUE INDEX ON table2(active);
+		COMMIT`, nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, active) VALUES (1, true), (2, false), (3, true), (4, false)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table2 (id, active) VALUES (1, true), (2, false)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table2 (id, active) VALUES (3, true)", nil, true)
+	require.ErrorIs(t, err, store.ErrKeyAlreadyExists)
+
+	type entry struct {
+		id     int64
+		active bool
+	}
+
+	testCases := []struct {
+		query    string
+		table    string
+		expected []entry
+	}{
+		{
+			query:    "SELECT id, active FROM table1 ORDER BY active",
+			table:    "table1",
+			expected: []entry{{2, false}, {4, false}, {1, true}, {3, true}},
+		},
+		{
+			query:    "SELECT id, active FROM table1 ORDER BY active DESC",
+			table:    "table1",
+			expected: []entry{{3, true}, {1, true}, {4, false}, {2, false}},
+		},
+		{
+			query:    "SELECT id, active FROM table1 WHERE active = true ORDER BY active",
+			table:    "table1",
+			expected: []entry{{1, true}, {3, true}},
+		},
+		{
+			query:    "SELECT id, active FROM table2 ORDER BY active",
+			table:    "table2",
+			expected: []entry{{2, false}, {1, true}},
+		},
+		{
+			query:    "SELECT id, active FROM table2 ORDER BY active DESC",
+			table:    "table2",
+			expected: []entry{{1, true}, {2, false}},
+		},
+	}
+
+	for i, tc := range testCases {
+		r, err := engine.QueryStmt(tc.query, nil, true)
+		require.NoError(t, err, fmt.Sprintf("failed on iteration %d", i))
+
+		for _, e := range tc.expected {
+			row, err := r.Read()
+			require.NoError(t, err, fmt.Sprintf("failed on iteration %d", i))
+			require.Equal(t, e.id, row.Values[EncodeSelector("", "db1", tc.table, "id")].Value(), fmt.Sprintf("failed on iteration %d", i))
+			require.Equal(t, e.active, row.Values[EncodeSelector("", "db1", tc.table, "active")].Value(), fmt.Sprintf("failed on iteration %d", i))
+		}
+
+		_, err = r.Read()
+		require.Equal(t, ErrNoMoreRows, err, fmt.Sprintf("failed on iteration %d", i))
+
+		err = r.Close()
+		require.NoError(t, err)
+	}
+}
+
+func TestTruncateTable(t *testing.T) {
+	catalogStore, err := store.Open("catalog_truncate", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_truncate")
+
+	dataStore, err := store.Open("sqldata_truncate", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_truncate")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("TRUNCATE TABLE table1", nil, true)
+	require.ErrorIs(t, err, ErrNoDatabaseSelected)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("TRUNCATE TABLE table1", nil, true)
+	require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, title VARCHAR[10], PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(title)", nil, true)
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		_, err = engine.ExecStmt("INSERT INTO table1 (title) VALUES (@title)", map[string]interface{}{"title": fmt.Sprintf("title%d", i)}, true)
+		require.NoError(t, err)
+	}
+
+	summary, err := engine.ExecStmt("TRUNCATE TABLE table1", nil, true)
+	require.NoError(t, err)
+	require.Equal(t, 5, summary.UpdatedRows)
+
+	for _, query := range []string{
+		"SELECT id FROM table1",
+		"SELECT id FROM table1 ORDER BY title",
+		"SELECT id FROM table1 WHERE title = 'title1' ORDER BY title",
+	} {
+		r, err := engine.QueryStmt(query, nil, true)
+		require.NoError(t, err)
+
+		_, err = r.Read()
+		require.Equal(t, ErrNoMoreRows, err)
+
+		err = r.Close()
+		require.NoError(t, err)
+	}
+
+	summary, err = engine.ExecStmt("INSERT INTO table1 (title) VALUES ('title1')", nil, true)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), summary.LastInsertedPKs["table1"])
+
+	r, err := engine.QueryStmt("SELECT id, title FROM table1 ORDER BY title", nil, true)
+	require.NoError(t, err)
+
+	row, err := r.Read()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+	require.Equal(t, "title1", row.Values[EncodeSelector("", "db1", "table1", "title")].Value())
+
+	_, err = r.Read()
+	require.Equal(t, ErrNoMoreRows, err)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("TRUNCATE TABLE table1", nil, true)
+	require.NoError(t, err)
+
+	// an empty table can be truncated as well
+	summary, err = engine.ExecStmt("TRUNCATE TABLE table1", nil, true)
+	require.NoError(t, err)
+	require.Zero(t, summary.UpdatedRows)
+
+	for i := 0; i < 3; i++ {
+		_, err = engine.ExecStmt("INSERT INTO table1 (title) VALUES (@title)", map[string]interface{}{"title": fmt.Sprintf("title%d", i)}, true)
+		require.NoError(t, err)
+	}
+
+	_, err = engine.ExecStmt("TRUNCATE TABLE table1", nil, true)
+	require.NoError(t, err)
+
+	reopen := func() {
+		err = engine.Close()
+		require.NoError(t, err)
+
+		engine, err = NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+		require.NoError(t, err)
+
+		err = engine.EnsureCatalogReady(nil)
+		require.NoError(t, err)
+
+		err = engine.UseDatabase("db1")
+		require.NoError(t, err)
+	}
+
+	// the reset of the auto-incremental counter survives the catalog reload
+	reopen()
+
+	for i := 1; i <= 3; i++ {
+		summary, err = engine.ExecStmt("INSERT INTO table1 (title) VALUES (@title)", map[string]interface{}{"title": fmt.Sprintf("title%d", i)}, true)
+		require.NoError(t, err)
+		require.Equal(t, int64(i), summary.LastInsertedPKs["table1"])
+	}
+
+	// keys of deleted rows are not reused, neither before nor after the catalog reload
+	_, err = engine.ExecStmt("DELETE FROM table1 WHERE id = 3", nil, true)
+	require.NoError(t, err)
+
+	summary, err = engine.ExecStmt("INSERT INTO table1 (title) VALUES ('title4')", nil, true)
+	require.NoError(t, err)
+	require.Equal(t, int64(4), summary.LastInsertedPKs["table1"])
+
+	_, err = engine.ExecStmt("DELETE FROM table1 WHERE id = 4", nil, true)
+	require.NoError(t, err)
+
+	reopen()
+
+	summary, err = engine.ExecStmt("INSERT INTO table1 (title) VALUES ('title5')", nil, true)
+	require.NoError(t, err)
+	require.Equal(t, int64(5), summary.LastInsertedPKs["table1"])
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestQueryContext(t *testing.T) {
+	catalogStore, err := store.Open("catalog_query_context", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_query_context")
+
+	dataStore, err := store.Open("sqldata_query_context", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_query_context")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.QueryPreparedStmtContext(nil, &SelectStmt{}, nil, true)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, amount INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table2 (id INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	rowCount := 10
+
+	for i := 0; i < rowCount; i++ {
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, amount) VALUES (@id, @id)", map[string]interface{}{"id": i}, true)
+		require.NoError(t, err)
+	}
+
+	_, err = engine.ExecStmt("INSERT INTO table2 (id) VALUES (1)", nil, true)
+	require.NoError(t, err)
+
+	t.Run("cancelling the context stops an ongoing scan", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		r, err := engine.QueryStmtContext(ctx, "SELECT id FROM table1", nil, true)
+		require.NoError(t, err)
+
+		for i := 0; i < rowCount/2; i++ {
+			_, err := r.Read()
+			require.NoError(t, err)
+		}
+
+		cancel()
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, context.Canceled)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("a cancelled scan is stopped even when no row satisfies the condition", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		r, err := engine.QueryStmtContext(ctx, "SELECT COUNT() AS c FROM table1 WHERE amount > 100", nil, false)
+		require.NoError(t, err)
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, context.Canceled)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("cancelling the context stops reading joint tables", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		r, err := engine.QueryStmtContext(ctx, "SELECT table1.id FROM table1 LEFT JOIN table2 ON table1.id = table2.id", nil, true)
+		require.NoError(t, err)
+
+		_, err = r.Read()
+		require.NoError(t, err)
+
+		cancel()
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, context.Canceled)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("a context without deadline does not affect the query", func(t *testing.T) {
+		r, err := engine.QueryStmtContext(context.Background(), "SELECT id FROM table1", nil, true)
+		require.NoError(t, err)
+
+		for i := 0; i < rowCount; i++ {
+			_, err := r.Read()
+			require.NoError(t, err)
+		}
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestExecAll(t *testing.T) {
+	catalogStore, err := store.Open("catalog_exec_all", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_exec_all")
+
+	dataStore, err := store.Open("sqldata_exec_all", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_exec_all")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecAll(nil, nil, true)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, title VARCHAR, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (title) VALUES ('title1'), ('title2'), ('title3')", nil, true)
+	require.NoError(t, err)
+
+	parse := func(sql string) []SQLStmt {
+		stmts, err := ParseString(sql)
+		require.NoError(t, err)
+		return stmts
+	}
+
+	t.Run("results are reported per statement within a single transaction", func(t *testing.T) {
+		txID, _ := dataStore.Alh()
+
+		results, err := engine.ExecAll(parse(`
+			INSERT INTO table1 (title) VALUES ('title4'), ('title5');
+			UPDATE table1 SET title = @title WHERE id <= 3;
+		`), map[string]interface{}{"title": "updated"}, true)
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+
+		require.Equal(t, 2, results[0].UpdatedRows)
+		require.Equal(t, map[string]int64{"table1": 5}, results[0].LastInsertedPKs)
+
+		require.Equal(t, 3, results[1].UpdatedRows)
+		require.Empty(t, results[1].LastInsertedPKs)
+
+		lastTxID, _ := dataStore.Alh()
+		require.Equal(t, txID+1, lastTxID)
+
+		r, err := engine.QueryStmt("SELECT COUNT() AS c FROM table1 WHERE title = 'updated'", nil, true)
+		require.NoError(t, err)
+
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, int64(3), row.Values[EncodeSelector("", "db1", "table1", "c")].Value())
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("nothing is committed when a statement fails", func(t *testing.T) {
+		txID, _ := dataStore.Alh()
+
+		_, err := engine.ExecAll(parse(`
+			INSERT INTO table1 (title) VALUES ('title6');
+			UPDATE table1 SET amount = 10;
+		`), nil, true)
+		require.ErrorIs(t, err, ErrColumnDoesNotExist)
+
+		lastTxID, _ := dataStore.Alh()
+		require.Equal(t, txID, lastTxID)
+	})
+
+	t.Run("DDL and DML statements can not be combined", func(t *testing.T) {
+		_, err := engine.ExecAll(parse(`
+			CREATE TABLE table2 (id INTEGER, PRIMARY KEY id);
+			INSERT INTO table1 (title) VALUES ('title6');
+		`), nil, true)
+		require.ErrorIs(t, err, ErrDDLorDMLTxOnly)
+	})
+
+	err = engine.Close()
+	require.NoError(t, err)
+
+	_, err = engine.ExecAll(parse("INSERT INTO table1 (title) VALUES ('title6')"), nil, true)
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+}
+
+func TestLimitStopsScan(t *testing.T) {
+	catalogStore, err := store.Open("catalog_limit_scan", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_limit_scan")
+
+	dataStore, err := store.Open("sqldata_limit_scan", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_limit_scan")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, amount INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(amount)", nil, true)
+	require.NoError(t, err)
+
+	rowCount := 10
+
+	for i := 0; i < rowCount; i++ {
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, amount) VALUES (@id, @amount)", map[string]interface{}{"id": i, "amount": rowCount - i}, true)
+		require.NoError(t, err)
+	}
+
+	// rows left unread by the scan once the limit is reached
+	testCases := []struct {
+		query          string
+		expectedRows   int
+		expectedUnread int
+	}{
+		{"SELECT id FROM table1 LIMIT 3", 3, 7},
+		{"SELECT id FROM table1 ORDER BY amount LIMIT 3", 3, 7},
+		{"SELECT id FROM table1 ORDER BY id DESC LIMIT 3 OFFSET 2", 3, 5},
+		{"SELECT id FROM table1 LIMIT 20", rowCount, 0},
+		{"SELECT id FROM table1 WHERE amount < 5 LIMIT 3", 3, 2},
+		{"SELECT id FROM table1 WHERE id > 0 AND amount < 5 LIMIT 3", 3, 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			r, err := engine.QueryStmt(tc.query, nil, true)
+			require.NoError(t, err)
+
+			rows := 0
+			for {
+				_, err = r.Read()
+				if err == ErrNoMoreRows {
+					break
+				}
+				require.NoError(t, err)
+				rows++
+			}
+
+			require.Equal(t, tc.expectedRows, rows)
+			require.Equal(t, tc.expectedUnread, unreadRows(t, r))
+
+			err = r.Close()
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestDescribeTable(t *testing.T) {
+	catalogStore, err := store.Open("catalog_describe_table", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_describe_table")
+
+	dataStore, err := store.Open("sqldata_describe_table", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_describe_table")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.QueryStmt("DESCRIBE TABLE table1", nil, true)
+	require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+	_, err = engine.ExecStmt(`
+		CREATE TABLE table1 (
+			id INTEGER AUTO_INCREMENT,
+			title VARCHAR[64] NOT NULL,
+			payload BLOB,
+			active BOOLEAN,
+			PRIMARY KEY id
+		)`, nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(title)", nil, true)
+	require.NoError(t, err)
+
+	r, err := engine.QueryStmt("DESCRIBE TABLE db1.table1", nil, true)
+	require.NoError(t, err)
+
+	cols, err := r.Columns()
+	require.NoError(t, err)
+	require.Len(t, cols, 6)
+	require.Equal(t, "name", cols[0].Column)
+	require.Equal(t, VarcharType, cols[0].Type)
+	require.Equal(t, "indexed", cols[5].Column)
+	require.Equal(t, BooleanType, cols[5].Type)
+
+	expectedRows := []struct {
+		name          string
+		colType       SQLValueType
+		maxLen        int64
+		nullable      bool
+		autoIncrement bool
+		indexed       bool
+	}{
+		{"id", IntegerType, 8, false, true, true},
+		{"title", VarcharType, 64, false, false, true},
+		{"payload", BLOBType, 0, true, false, false},
+		{"active", BooleanType, 1, true, false, false},
+	}
+
+	for _, expected := range expectedRows {
+		row, err := r.Read()
+		require.NoError(t, err)
+
+		require.Equal(t, expected.name, row.Values[EncodeSelector("", "db1", "table1", "name")].Value())
+		require.Equal(t, expected.colType, row.Values[EncodeSelector("", "db1", "table1", "type")].Value())
+		require.Equal(t, expected.maxLen, row.Values[EncodeSelector("", "db1", "table1", "max_length")].Value())
+		require.Equal(t, expected.nullable, row.Values[EncodeSelector("", "db1", "table1", "nullable")].Value())
+		require.Equal(t, expected.autoIncrement, row.Values[EncodeSelector("", "db1", "table1", "auto_increment")].Value())
+		require.Equal(t, expected.indexed, row.Values[EncodeSelector("", "db1", "table1", "indexed")].Value())
+	}
+
+	_, err = r.Read()
+	require.ErrorIs(t, err, ErrNoMoreRows)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestShowTablesAndDatabases(t *testing.T) {
+	catalogStore, err := store.Open("catalog_show", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_show")
+
+	dataStore, err := store.Open("sqldata_show", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_show")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	readNames := func(query string, selector string) []string {
+		r, err := engine.QueryStmt(query, nil, true)
+		require.NoError(t, err)
+		defer r.Close()
+
+		cols, err := r.Columns()
+		require.NoError(t, err)
+		require.Len(t, cols, 1)
+		require.Equal(t, selector, cols[0].Selector())
+
+		names := []string{}
+
+		for {
+			row, err := r.Read()
+			if err == ErrNoMoreRows {
+				break
+			}
+			require.NoError(t, err)
+
+			names = append(names, row.Values[selector].Value().(string))
+		}
+
+		return names
+	}
+
+	err = engine.EnsureCatalogReady(nil)
+	require.NoError(t, err)
+
+	require.Empty(t, readNames("SHOW DATABASES", EncodeSelector("", "", "databases", "name")))
+
+	_, err = engine.QueryStmt("SHOW TABLES", nil, true)
+	require.ErrorIs(t, err, ErrNoDatabaseSelected)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db2; CREATE DATABASE db1; CREATE DATABASE db3", nil, true)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"db1", "db2", "db3"}, readNames("SHOW DATABASES", EncodeSelector("", "", "databases", "name")))
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	require.Empty(t, readNames("SHOW TABLES", EncodeSelector("", "db1", "tables", "name")))
+
+	_, err = engine.ExecStmt(`
+		CREATE TABLE table2 (id INTEGER, PRIMARY KEY id);
+		CREATE TABLE table1 (id INTEGER, PRIMARY KEY id);`, nil, true)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"table1", "table2"}, readNames("SHOW TABLES", EncodeSelector("", "db1", "tables", "name")))
+
+	err = engine.UseDatabase("db2")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table3 (id INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"table3"}, readNames("SHOW TABLES", EncodeSelector("", "db2", "tables", "name")))
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestInsertOnConflictDoNothing(t *testing.T) {
+	catalogStore, err := store.Open("catalog_on_conflict_do_nothing", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_on_conflict_do_nothing")
+
+	dataStore, err := store.Open("sqldata_on_conflict_do_nothing", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_on_conflict_do_nothing")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, email VARCHAR[64], PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE UNIQUE INDEX ON table1(email)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table2 (id INTEGER AUTO_INCREMENT, email VARCHAR[64], PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE UNIQUE INDEX ON table2(email)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, email) VALUES (1, 'a@codenotary.com'), (2, 'b@codenotary.com')", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, email) VALUES (1, 'c@codenotary.com')", nil, true)
+	require.ErrorIs(t, err, store.ErrKeyAlreadyExists)
+
+	_, err = engine.ExecStmt("DELETE FROM table1 WHERE id = 2", nil, true)
+	require.NoError(t, err)
+
+	summary, err := engine.ExecStmt(`
+		INSERT INTO table1 (id, email)
+		VALUES
+			(1, 'c@codenotary.com'),
+			(2, 'd@codenotary.com'),
+			(3, 'a@codenotary.com'),
+			(4, 'e@codenotary.com'),
+			(4, 'f@codenotary.com'),
+			(5, 'e@codenotary.com')
+		ON CONFLICT DO NOTHING`, nil, true)
+	require.NoError(t, err)
+	require.Equal(t, 2, summary.UpdatedRows)
+
+	r, err := engine.QueryStmt("SELECT id, email FROM table1", nil, true)
+	require.NoError(t, err)
+
+	for _, expected := range []struct {
+		id    int64
+		email string
+	}{
+		{1, "a@codenotary.com"},
+		{2, "d@codenotary.com"},
+		{4, "e@codenotary.com"},
+	} {
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, expected.id, row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+		require.Equal(t, expected.email, row.Values[EncodeSelector("", "db1", "table1", "email")].Value())
+	}
+
+	_, err = r.Read()
+	require.ErrorIs(t, err, ErrNoMoreRows)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	summary, err = engine.ExecStmt("INSERT INTO table2 (email) VALUES ('a@codenotary.com'), ('a@codenotary.com'), ('b@codenotary.com') ON CONFLICT DO NOTHING", nil, true)
+	require.NoError(t, err)
+	require.Equal(t, 2, summary.UpdatedRows)
+	require.Equal(t, int64(2), summary.LastInsertedPKs["table2"])
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestInsertOnConflictDoUpdate(t *testing.T) {
+	catalogStore, err := store.Open("catalog_on_conflict_do_update", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_on_conflict_do_update")
+
+	dataStore, err := store.Open("sqldata_on_conflict_do_update", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_on_conflict_do_update")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR[32], hits INTEGER, note VARCHAR, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(title)", nil, true)
+	require.NoError(t, err)
+
+	upsert := "INSERT INTO table1 (id, title, hits) VALUES (@id, @title, 1) ON CONFLICT DO UPDATE SET title = excluded.title, hits = hits + excluded.hits"
+
+	summary, err := engine.ExecStmt(upsert, map[string]interface{}{"id": 1, "title": "title1"}, true)
+	require.NoError(t, err)
+	require.Equal(t, 1, summary.UpdatedRows)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, title, hits, note) VALUES (2, 'title2', 1, 'note2')", nil, true)
+	require.NoError(t, err)
+
+	summary, err = engine.ExecStmt(upsert, map[string]interface{}{"id": 2, "title": "title3"}, true)
+	require.NoError(t, err)
+	require.Equal(t, 1, summary.UpdatedRows)
+
+	summary, err = engine.ExecStmt(upsert, map[string]interface{}{"id": 2, "title": "title4"}, true)
+	require.NoError(t, err)
+	require.Equal(t, 1, summary.UpdatedRows)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, title, hits) VALUES (3, 'title3', 1), (3, 'title4', 1) ON CONFLICT DO UPDATE SET hits = hits + 1", nil, true)
+	require.ErrorIs(t, err, ErrRowUpdatedTwice)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, title) VALUES (1, 'title1') ON CONFLICT DO UPDATE SET id = 2", nil, true)
+	require.ErrorIs(t, err, ErrPKCanNotBeUpdated)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, title) VALUES (1, 'title1') ON CONFLICT DO UPDATE SET title = excluded.note", nil, true)
+	require.ErrorIs(t, err, ErrIndexedColumnCanNotBeNull)
+
+	// the index on title only holds the entries of the current values
+	r, err := engine.QueryStmt("SELECT id, title, hits, note FROM table1 ORDER BY title", nil, true)
+	require.NoError(t, err)
+
+	for _, expected := range []struct {
+		id    int64
+		title string
+		hits  int64
+		note  interface{}
+	}{
+		{1, "title1", 1, nil},
+		{2, "title4", 3, "note2"},
+	} {
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, expected.id, row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+		require.Equal(t, expected.title, row.Values[EncodeSelector("", "db1", "table1", "title")].Value())
+		require.Equal(t, expected.hits, row.Values[EncodeSelector("", "db1", "table1", "hits")].Value())
+		require.Equal(t, expected.note, row.Values[EncodeSelector("", "db1", "table1", "note")].Value())
+	}
+
+	_, err = r.Read()
+	require.ErrorIs(t, err, ErrNoMoreRows)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestInsertValueTooLong(t *testing.T) {
+	catalogStore, err := store.Open("catalog_value_too_long", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_value_too_long")
+
+	dataStore, err := store.Open("sqldata_value_too_long", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_value_too_long")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR[5], payload BLOB[2], note VARCHAR, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, title, payload, note) VALUES (1, 'title', x'00A1', 'a long enough note')", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, title) VALUES (2, 'title2')", nil, true)
+	require.ErrorIs(t, err, ErrValueTooLong)
+	require.ErrorIs(t, err, ErrMaxLengthExceeded)
+	require.Contains(t, err.Error(), "title")
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, payload) VALUES (2, @payload)", map[string]interface{}{"payload": []byte{0, 1, 2}}, true)
+	require.ErrorIs(t, err, ErrValueTooLong)
+	require.Contains(t, err.Error(), "payload")
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, title) VALUES (1, 'title') ON CONFLICT DO UPDATE SET title = 'title1'", nil, true)
+	require.ErrorIs(t, err, ErrValueTooLong)
+
+	r, err := engine.QueryStmt("SELECT COUNT() AS c FROM table1", nil, true)
+	require.NoError(t, err)
+
+	row, err := r.Read()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), row.Values[EncodeSelector("", "db1", "table1", "c")].Value())
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestCheckConstraint(t *testing.T) {
+	catalogStore, err := store.Open("catalog_check_constraint", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_check_constraint")
+
+	dataStore, err := store.Open("sqldata_check_constraint", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_check_constraint")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	t.Run("invalid check constraints", func(t *testing.T) {
+		_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, age INTEGER CHECK (age + 1), PRIMARY KEY id)", nil, true)
+		require.ErrorIs(t, err, ErrInvalidCheckConstraint)
+
+		_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, age INTEGER CHECK (height > 0), PRIMARY KEY id)", nil, true)
+		require.ErrorIs(t, err, ErrInvalidCheckConstraint)
+
+		_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, age INTEGER CHECK (age > @lower), PRIMARY KEY id)", nil, true)
+		require.ErrorIs(t, err, ErrInvalidCheckConstraint)
+
+		_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, age INTEGER CHECK (age IN (SELECT id FROM table2)), PRIMARY KEY id)", nil, true)
+		require.ErrorIs(t, err, ErrInvalidCheckConstraint)
+	})
+
+	_, err = engine.ExecStmt(`
+		CREATE TABLE table1 (
+			id INTEGER,
+			age INTEGER CHECK (age >= 0),
+			title VARCHAR CHECK (title NOT LIKE '^x' AND CASE WHEN age > 100 THEN title = 'old' ELSE TRUE END),
+			PRIMARY KEY id
+		)`, nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, age, title) VALUES (1, 0, 'title1'), (2, 101, 'old')", nil, true)
+	require.NoError(t, err)
+
+	// null values do not violate check constraints
+	_, err = engine.ExecStmt("INSERT INTO table1 (id) VALUES (3)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, age) VALUES (4, -1)", nil, true)
+	require.ErrorIs(t, err, ErrCheckConstraintViolated)
+	require.Contains(t, err.Error(), "age")
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, age, title) VALUES (4, 101, 'title4')", nil, true)
+	require.ErrorIs(t, err, ErrCheckConstraintViolated)
+	require.Contains(t, err.Error(), "title")
+
+	_, err = engine.ExecStmt("UPDATE table1 SET age = age - 1 WHERE id = 1", nil, true)
+	require.ErrorIs(t, err, ErrCheckConstraintViolated)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, age) VALUES (1, 0) ON CONFLICT DO UPDATE SET age = -1", nil, true)
+	require.ErrorIs(t, err, ErrCheckConstraintViolated)
+
+	err = engine.Close()
+	require.NoError(t, err)
+
+	// check constraints are persisted in the catalog
+	engine, err = NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	err = engine.EnsureCatalogReady(nil)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, age) VALUES (4, -1)", nil, true)
+	require.ErrorIs(t, err, ErrCheckConstraintViolated)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, age, title) VALUES (4, 101, 'old')", nil, true)
+	require.NoError(t, err)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestUniqueColumn(t *testing.T) {
+	catalogStore, err := store.Open("catalog_unique_column", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_unique_column")
+
+	dataStore, err := store.Open("sqldata_unique_column", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_unique_column")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER UNIQUE, email VARCHAR UNIQUE, PRIMARY KEY id)", nil, true)
+	require.ErrorIs(t, err, ErrLimitedKeyType)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER AUTO_INCREMENT UNIQUE, email VARCHAR[64] UNIQUE, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	table, err := engine.catalog.GetTableByName("db1", "table1")
+	require.NoError(t, err)
+	require.Len(t, table.indexes, 2)
+
+	indexed, err := table.IsIndexed("email")
+	require.NoError(t, err)
+	require.True(t, indexed)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (email) VALUES ('user1@example.com'), ('user2@example.com')", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (email) VALUES ('user1@example.com')", nil, true)
+	require.ErrorIs(t, err, store.ErrKeyAlreadyExists)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (email) VALUES ('user3@example.com'), ('user3@example.com')", nil, true)
+	require.ErrorIs(t, err, store.ErrDuplicatedKey)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestSelectorAndTableAliases(t *testing.T) {
+	catalogStore, err := store.Open("catalog_aliases", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_aliases")
+
+	dataStore, err := store.Open("sqldata_aliases", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_aliases")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, title) VALUES (1, 'title1'), (2, 'title2'), (3, 'title3')", nil, true)
+	require.NoError(t, err)
+
+	r, err := engine.QueryStmt("SELECT t.id AS tid, title AS name FROM table1 AS t WHERE t.id > 1", nil, true)
+	require.NoError(t, err)
+
+	cols, err := r.Columns()
+	require.NoError(t, err)
+	require.Len(t, cols, 2)
+	require.Equal(t, EncodeSelector("", "db1", "t", "tid"), cols[0].Selector())
+	require.Equal(t, EncodeSelector("", "db1", "t", "name"), cols[1].Selector())
+
+	for i := 2; i <= 3; i++ {
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, int64(i), row.Values[EncodeSelector("", "db1", "t", "tid")].Value())
+		require.Equal(t, fmt.Sprintf("title%d", i), row.Values[EncodeSelector("", "db1", "t", "name")].Value())
+	}
+
+	_, err = r.Read()
+	require.ErrorIs(t, err, ErrNoMoreRows)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	r, err = engine.QueryStmt("SELECT COUNT() AS total, MAX(id) AS maxid FROM table1 AS t WHERE t.title != 'title1'", nil, true)
+	require.NoError(t, err)
+
+	row, err := r.Read()
+	require.NoError(t, err)
+	require.Equal(t, int64(2), row.Values[EncodeSelector("", "db1", "t", "total")].Value())
+	require.Equal(t, int64(3), row.Values[EncodeSelector("", "db1", "t", "maxid")].Value())
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	r, err = engine.QueryStmt("SELECT id, id AS a, title, id AS b FROM table1 WHERE id = 2", nil, true)
+	require.NoError(t, err)
+
+	cols, err = r.Columns()
+	require.NoError(t, err)
+	require.Len(t, cols, 4)
+	require.Equal(t, EncodeSelector("", "db1", "table1", "id"), cols[0].Selector())
+	require.Equal(t, EncodeSelector("", "db1", "table1", "a"), cols[1].Selector())
+	require.Equal(t, EncodeSelector("", "db1", "table1", "title"), cols[2].Selector())
+	require.Equal(t, EncodeSelector("", "db1", "table1", "b"), cols[3].Selector())
+	require.Equal(t, IntegerType, cols[1].Type)
+	require.Equal(t, IntegerType, cols[3].Type)
+
+	row, err = r.Read()
+	require.NoError(t, err)
+	require.Len(t, row.Values, 4)
+	require.Equal(t, int64(2), row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+	require.Equal(t, int64(2), row.Values[EncodeSelector("", "db1", "table1", "a")].Value())
+	require.Equal(t, "title2", row.Values[EncodeSelector("", "db1", "table1", "title")].Value())
+	require.Equal(t, int64(2), row.Values[EncodeSelector("", "db1", "table1", "b")].Value())
+
+	_, err = r.Read()
+	require.ErrorIs(t, err, ErrNoMoreRows)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestSelectWithoutDataSource(t *testing.T) {
+	catalogStore, err := store.Open("catalog_select_values", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_select_values")
+
+	dataStore, err := store.Open("sqldata_select_values", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_select_values")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	err = engine.EnsureCatalogReady(nil)
+	require.NoError(t, err)
+
+	r, err := engine.QueryStmt("SELECT 1 + 2", nil, true)
+	require.NoError(t, err)
+
+	cols, err := r.Columns()
+	require.NoError(t, err)
+	require.Len(t, cols, 1)
+	require.Equal(t, IntegerType, cols[0].Type)
+
+	row, err := r.Read()
+	require.NoError(t, err)
+	require.Equal(t, int64(3), row.Values[EncodeSelector("", "", "", "col0")].Value())
+
+	_, err = r.Read()
+	require.ErrorIs(t, err, ErrNoMoreRows)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	before := time.Now().UnixNano()
+
+	r, err = engine.QueryStmt("SELECT DISTINCT NOW() AS ts, @title", map[string]interface{}{"title": "title1"}, true)
+	require.NoError(t, err)
+
+	row, err = r.Read()
+	require.NoError(t, err)
+	require.Len(t, row.Values, 2)
+	require.GreaterOrEqual(t, row.Values[EncodeSelector("", "", "", "ts")].Value(), before)
+	require.LessOrEqual(t, row.Values[EncodeSelector("", "", "", "ts")].Value(), time.Now().UnixNano())
+	require.Equal(t, "title1", row.Values[EncodeSelector("", "", "", "col1")].Value())
+
+	_, err = r.Read()
+	require.ErrorIs(t, err, ErrNoMoreRows)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	_, err = engine.QueryStmt("SELECT id", nil, true)
+	require.ErrorIs(t, err, ErrColumnDoesNotExist)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	params, err := engine.InferParameters("SELECT @a + 1")
+	require.NoError(t, err)
+	require.Equal(t, map[string]SQLValueType{"a": IntegerType}, params)
+
+	_, err = engine.QueryStmt("SELECT id + 1, COUNT() FROM table1", nil, true)
+	require.ErrorIs(t, err, ErrNoSupported)
+
+	r, err = engine.QueryStmt("SELECT t.one FROM (SELECT 1 AS one) AS t", nil, true)
+	require.NoError(t, err)
+
+	row, err = r.Read()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), row.Values[EncodeSelector("", "db1", "t", "one")].Value())
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestSelectExpressionsFromTable(t *testing.T) {
+	catalogStore, err := store.Open("catalog_select_exps", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_select_exps")
+
+	dataStore, err := store.Open("sqldata_select_exps", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_select_exps")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, n INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table2 (id INTEGER AUTO_INCREMENT, n INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (n) VALUES (1), (2), (3), (NULL)", nil, true)
+	require.NoError(t, err)
+
+	r, err := engine.QueryStmt("SELECT id, n + 1, -n AS neg, n % 2 FROM table1 WHERE id <= @maxID", map[string]interface{}{"maxID": 3}, true)
+	require.NoError(t, err)
+
+	cols, err := r.Columns()
+	require.NoError(t, err)
+	require.Len(t, cols, 4)
+	require.Equal(t, "(db1.table1.id)", cols[0].Selector())
+	require.Equal(t, "(db1.table1.col1)", cols[1].Selector())
+	require.Equal(t, "(db1.table1.neg)", cols[2].Selector())
+	require.Equal(t, "(db1.table1.col3)", cols[3].Selector())
+
+	for _, col := range cols {
+		require.Equal(t, IntegerType, col.Type)
+	}
+
+	for i := int64(1); i <= 3; i++ {
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, i, row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+		require.Equal(t, i+1, row.Values[EncodeSelector("", "db1", "table1", "col1")].Value())
+		require.Equal(t, -i, row.Values[EncodeSelector("", "db1", "table1", "neg")].Value())
+		require.Equal(t, i%2, row.Values[EncodeSelector("", "db1", "table1", "col3")].Value())
+	}
+
+	_, err = r.Read()
+	require.ErrorIs(t, err, ErrNoMoreRows)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	r, err = engine.QueryStmt("SELECT n * @factor AS m FROM table1 WHERE id = 4", map[string]interface{}{"factor": 2}, true)
+	require.NoError(t, err)
+
+	row, err := r.Read()
+	require.NoError(t, err)
+	require.Nil(t, row.Values[EncodeSelector("", "db1", "table1", "m")].Value())
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	params, err := engine.InferParameters("SELECT n * @factor FROM table1")
+	require.NoError(t, err)
+	require.Equal(t, map[string]SQLValueType{"factor": IntegerType}, params)
+
+	r, err = engine.QueryStmt("SELECT n + 'a' FROM table1", nil, true)
+	require.NoError(t, err)
+
+	_, err = r.Columns()
+	require.ErrorIs(t, err, ErrInvalidTypes)
+
+	_, err = r.Read()
+	require.ErrorIs(t, err, ErrInvalidValue)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table2 (n) SELECT n * 2 FROM table1 WHERE n IS NOT NULL", nil, true)
+	require.NoError(t, err)
+
+	r, err = engine.QueryStmt("SELECT n FROM table2", nil, true)
+	require.NoError(t, err)
+
+	for i := int64(1); i <= 3; i++ {
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, i*2, row.Values[EncodeSelector("", "db1", "table2", "n")].Value())
+	}
+
+	_, err = r.Read()
+	require.ErrorIs(t, err, ErrNoMoreRows)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestCaseOverTableRows(t *testing.T) {
+	catalogStore, err := store.Open("catalog_case_rows", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_case_rows")
+
+	dataStore, err := store.Open("sqldata_case_rows", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_case_rows")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, amount INTEGER, size VARCHAR, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (amount) VALUES (5), (50), (500)", nil, true)
+	require.NoError(t, err)
+
+	r, err := engine.QueryStmt(`
+		SELECT id, CASE WHEN amount < 10 THEN 'small' WHEN amount < 100 THEN 'medium' END AS size
+		FROM table1`, nil, true)
+	require.NoError(t, err)
+
+	cols, err := r.Columns()
+	require.NoError(t, err)
+	require.Len(t, cols, 2)
+	require.Equal(t, VarcharType, cols[1].Type)
+
+	expected := []TypedValue{&Varchar{val: "small"}, &Varchar{val: "medium"}, &NullValue{t: VarcharType}}
+
+	for _, size := range expected {
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, size, row.Values[EncodeSelector("", "db1", "table1", "size")])
+	}
+
+	_, err = r.Read()
+	require.ErrorIs(t, err, ErrNoMoreRows)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("UPDATE table1 SET size = CASE WHEN amount < 100 THEN 'small' END", nil, true)
+	require.NoError(t, err)
+
+	r, err = engine.QueryStmt("SELECT COUNT() AS c FROM table1 WHERE size IS NULL", nil, true)
+	require.NoError(t, err)
+
+	row, err := r.Read()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), row.Values[EncodeSelector("", "db1", "table1", "c")].Value())
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestNowIsFixedPerTransaction(t *testing.T) {
+	catalogStore, err := store.Open("catalog_now", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_now")
+
+	dataStore, err := store.Open("sqldata_now", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_now")
+
+	now := time.Unix(1629902962, 0)
+
+	opts := DefaultOptions().
+		WithPrefix(sqlPrefix).
+		WithNowFunc(func() time.Time {
+			now = now.Add(time.Second)
+			return now
+		})
+
+	engine, err := NewEngine(catalogStore, dataStore, opts)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, ts1 INTEGER, ts2 INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		BEGIN TRANSACTION
+			INSERT INTO table1 (id, ts1, ts2) VALUES (1, NOW(), NOW());
+			INSERT INTO table1 (id, ts1, ts2) VALUES (2, NOW(), NOW());
+		COMMIT
+	`, nil, true)
+	require.NoError(t, err)
+
+	txTime := now.UnixNano()
+
+	r, err := engine.QueryStmt("SELECT id, ts1, ts2 FROM table1 WHERE ts1 < NOW()", nil, true)
+	require.NoError(t, err)
+
+	for i := 1; i <= 2; i++ {
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, txTime, row.Values[EncodeSelector("", "db1", "table1", "ts1")].Value())
+		require.Equal(t, txTime, row.Values[EncodeSelector("", "db1", "table1", "ts2")].Value())
+	}
+
+	_, err = r.Read()
+	require.ErrorIs(t, err, ErrNoMoreRows)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	r, err = engine.QueryStmt("SELECT NOW() AS ts1, NOW() AS ts2", nil, true)
+	require.NoError(t, err)
+
+	row, err := r.Read()
+	require.NoError(t, err)
+	require.Equal(t, now.UnixNano(), row.Values[EncodeSelector("", "db1", "", "ts1")].Value())
+	require.Equal(t, now.UnixNano(), row.Values[EncodeSelector("", "db1", "", "ts2")].Value())
+	require.Greater(t, now.UnixNano(), txTime)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	// check constraints are evaluated with the clock of the engine as well
+	_, err = engine.ExecStmt("CREATE TABLE table2 (id INTEGER, ts INTEGER CHECK (ts >= NOW()), PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table2 (id, ts) VALUES (1, @ts)", map[string]interface{}{"ts": now.Add(time.Hour).UnixNano()}, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table2 (id, ts) VALUES (2, @ts)", map[string]interface{}{"ts": now.UnixNano()}, true)
+	require.ErrorIs(t, err, ErrCheckConstraintViolated)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestAnalyzeTable(t *testing.T) {
+	catalogStore, err := store.Open("catalog_analyze", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_analyze")
+
+	dataStore, err := store.Open("sqldata_analyze", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_analyze")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("ANALYZE TABLE table1", nil, true)
+	require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR, active BOOLEAN, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	table, err := engine.catalog.GetTableByName("db1", "table1")
+	require.NoError(t, err)
+	require.Nil(t, table.cols[0].Stats())
+
+	_, err = engine.ExecStmt("ANALYZE TABLE table1", nil, true)
+	require.NoError(t, err)
+
+	for _, col := range table.cols {
+		require.NotNil(t, col.Stats())
+		require.Zero(t, col.Stats().DistinctCount())
+		require.Zero(t, col.Stats().NullCount())
+		require.Nil(t, col.Stats().Min())
+		require.Nil(t, col.Stats().Max())
+	}
+
+	for i := 1; i <= 10; i++ {
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, title) VALUES (@id, @title)", map[string]interface{}{"id": 11 - i, "title": fmt.Sprintf("title%d", i%3)}, true)
+		require.NoError(t, err)
+	}
+
+	_, err = engine.ExecStmt("ANALYZE TABLE table1", nil, true)
+	require.NoError(t, err)
+
+	checkStats := func(table *Table) {
+		idStats := table.colsByName["id"].Stats()
+		require.Equal(t, uint64(10), idStats.DistinctCount())
+		require.Zero(t, idStats.NullCount())
+		require.Equal(t, int64(1), idStats.Min().Value())
+		require.Equal(t, int64(10), idStats.Max().Value())
+
+		titleStats := table.colsByName["title"].Stats()
+		require.Equal(t, uint64(3), titleStats.DistinctCount())
+		require.Zero(t, titleStats.NullCount())
+		require.Equal(t, "title0", titleStats.Min().Value())
+		require.Equal(t, "title2", titleStats.Max().Value())
+
+		activeStats := table.colsByName["active"].Stats()
+		require.Zero(t, activeStats.DistinctCount())
+		require.Equal(t, uint64(10), activeStats.NullCount())
+		require.Nil(t, activeStats.Min())
+		require.Nil(t, activeStats.Max())
+	}
+
+	checkStats(table)
+
+	err = engine.Close()
+	require.NoError(t, err)
+
+	// statistics are persisted in the catalog
+	engine, err = NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	err = engine.EnsureCatalogReady(nil)
+	require.NoError(t, err)
+
+	table, err = engine.catalog.GetTableByName("db1", "table1")
+	require.NoError(t, err)
+
+	checkStats(table)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestMostSelectiveIndexSelection(t *testing.T) {
+	catalogStore, err := store.Open("catalog_selective_index", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_selective_index")
+
+	dataStore, err := store.Open("sqldata_selective_index", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_selective_index")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, age INTEGER, title VARCHAR[16], PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(age)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(title)", nil, true)
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, age, title) VALUES (@id, @age, @title)", map[string]interface{}{"id": i, "age": 10 - i, "title": fmt.Sprintf("title%d", i)}, true)
+		require.NoError(t, err)
+	}
+
+	testCases := []struct {
+		query         string
+		expectedIndex []string
+		expectedRows  int
+	}{
+		{"SELECT id FROM table1", []string{"id"}, 10},
+		{"SELECT id FROM table1 WHERE age > 5", []string{"age"}, 5},
+		{"SELECT id FROM table1 WHERE title = 'title3'", []string{"title"}, 1},
+		{"SELECT id FROM table1 WHERE age >= 2 AND age <= 4 AND title > 'title0'", []string{"age"}, 3},
+		{"SELECT id FROM table1 WHERE age > 2 AND title = 'title3'", []string{"title"}, 1},
+		{"SELECT id FROM table1 WHERE id > 2 AND age > 2", []string{"id"}, 5},
+		{"SELECT id FROM table1 WHERE id > 2 AND age >= 2 AND age <= 4", []string{"age"}, 3},
+		{"SELECT id FROM table1 WHERE age > 5 OR title = 'title3'", []string{"id"}, 5},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			r, err := engine.QueryStmt(tc.query, nil, true)
+			require.NoError(t, err)
+
+			index := r.ScanSpecs().index
+			require.Len(t, index.cols, len(tc.expectedIndex))
+
+			for i, col := range index.cols {
+				require.Equal(t, tc.expectedIndex[i], col.colName)
+			}
+
+			rows := 0
+			for {
+				_, err = r.Read()
+				if err == ErrNoMoreRows {
+					break
+				}
+				require.NoError(t, err)
+				rows++
+			}
+			require.Equal(t, tc.expectedRows, rows)
+
+			err = r.Close()
+			require.NoError(t, err)
+		})
+	}
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestReverseScanOnPrimaryKey(t *testing.T) {
+	catalogStore, err := store.Open("catalog_reverse_scan", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_reverse_scan")
+
+	dataStore, err := store.Open("sqldata_reverse_scan", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_reverse_scan")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, title VARCHAR, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	rowCount := 100
+
+	for i := 1; i <= rowCount; i++ {
+		_, err = engine.ExecStmt("INSERT INTO table1 (title) VALUES (@title)", map[string]interface{}{"title": fmt.Sprintf("title%d", i)}, true)
+		require.NoError(t, err)
+	}
+
+	// entries are pulled from the top of the primary index, so the scan is left
+	// with the rows below the last one read once the limit is reached
+	testCases := []struct {
+		query          string
+		expectedIDs    []int64
+		expectedUnread int
+	}{
+		{"SELECT id, title FROM table1 ORDER BY id DESC LIMIT 3", []int64{100, 99, 98}, 97},
+		{"SELECT id, title FROM table1 ORDER BY id DESC LIMIT 2 OFFSET 1", []int64{99, 98}, 97},
+		{"SELECT id, title FROM table1 WHERE id <= 50 ORDER BY id DESC LIMIT 3", []int64{50, 49, 48}, 47},
+		{"SELECT id, title FROM table1 WHERE id > 10 AND id < 50 ORDER BY id DESC LIMIT 3", []int64{49, 48, 47}, 37},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			r, err := engine.QueryStmt(tc.query, nil, true)
+			require.NoError(t, err)
+
+			require.True(t, r.ScanSpecs().descOrder)
+			require.True(t, r.ScanSpecs().index.IsPrimary())
+
+			for _, id := range tc.expectedIDs {
+				row, err := r.Read()
+				require.NoError(t, err)
+				require.Equal(t, id, row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+				require.Equal(t, fmt.Sprintf("title%d", id), row.Values[EncodeSelector("", "db1", "table1", "title")].Value())
+			}
+
+			_, err = r.Read()
+			require.ErrorIs(t, err, ErrNoMoreRows)
+
+			require.Equal(t, tc.expectedUnread, unreadRows(t, r))
+
+			err = r.Close()
+			require.NoError(t, err)
+		})
+	}
+
+	t.Run("ascending scans read from the bottom of the primary index", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT id FROM table1 WHERE id <= 50 LIMIT 3", nil, true)
+		require.NoError(t, err)
+
+		require.False(t, r.ScanSpecs().descOrder)
+
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, int64(1), row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+		require.Equal(t, 49, unreadRows(t, r))
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+// unreadRows drains the table scan under the given reader, returning the number of rows it had not read yet
+func unreadRows(t *testing.T, r RowReader) int {
+	raw := rawReaderOf(t, r)
+
+	for n := 0; ; n++ {
+		_, err := raw.Read()
+		if err == ErrNoMoreRows {
+			return n
+		}
+		require.NoError(t, err)
+	}
+}
+
+// rawReaderOf unwraps the readers built on top of the table scan
+func rawReaderOf(t *testing.T, r RowReader) *rawRowReader {
+	switch rr := r.(type) {
+	case *rawRowReader:
+		return rr
+	case *limitRowReader:
+		return rawReaderOf(t, rr.rowReader)
+	case *offsetRowReader:
+		return rawReaderOf(t, rr.rowReader)
+	case *projectedRowReader:
+		return rawReaderOf(t, rr.rowReader)
+	case *conditionalRowReader:
+		return rawReaderOf(t, rr.rowReader)
+	}
+
+	require.Failf(t, "unexpected row reader", "%T", r)
+	return nil
+}
+
+func TestUnaryMinusOnColumns(t *testing.T) {
+	catalogStore, err := store.Open("catalog_unary_minus", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_unary_minus")
+
+	dataStore, err := store.Open("sqldata_unary_minus", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_unary_minus")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, amount INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (amount) VALUES (5), (-3), (NULL)", nil, true)
+	require.NoError(t, err)
+
+	r, err := engine.QueryStmt("SELECT -amount AS neg, -amount * 2 AS twice, -(amount - 10) AS diff FROM table1", nil, true)
+	require.NoError(t, err)
+
+	expected := [][]interface{}{
+		{int64(-5), int64(-10), int64(5)},
+		{int64(3), int64(6), int64(13)},
+		{nil, nil, nil},
+	}
+
+	for _, vals := range expected {
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, vals[0], row.Values[EncodeSelector("", "db1", "table1", "neg")].Value())
+		require.Equal(t, vals[1], row.Values[EncodeSelector("", "db1", "table1", "twice")].Value())
+		require.Equal(t, vals[2], row.Values[EncodeSelector("", "db1", "table1", "diff")].Value())
+	}
+
+	_, err = r.Read()
+	require.ErrorIs(t, err, ErrNoMoreRows)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	r, err = engine.QueryStmt("SELECT id FROM table1 WHERE -amount > 0", nil, true)
+	require.NoError(t, err)
+
+	row, err := r.Read()
+	require.NoError(t, err)
+	require.Equal(t, int64(2), row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+
+	_, err = r.Read()
+	require.ErrorIs(t, err, ErrNoMoreRows)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("UPDATE table1 SET amount = -amount WHERE amount IS NOT NULL", nil, true)
+	require.NoError(t, err)
+
+	r, err = engine.QueryStmt("SELECT amount FROM table1 WHERE amount IS NOT NULL", nil, true)
+	require.NoError(t, err)
+
+	for _, amount := range []int64{-5, 3} {
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, amount, row.Values[EncodeSelector("", "db1", "table1", "amount")].Value())
+	}
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestIntegerDivisionAndModulo(t *testing.T) {
+	catalogStore, err := store.Open("catalog_int_div", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_int_div")
+
+	dataStore, err := store.Open("sqldata_int_div", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_int_div")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	err = engine.EnsureCatalogReady(nil)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		exp           string
+		expected      int64
+		expectedError error
+	}{
+		{"7 / 2", 3, nil},
+		{"-7 / 2", -3, nil},
+		{"7 / -2", -3, nil},
+		{"-7 / -2", 3, nil},
+		{"7 % 2", 1, nil},
+		{"-7 % 2", -1, nil},
+		{"7 % -2", 1, nil},
+		{"-7 % -2", -1, nil},
+		{"1 + 7 % 4 * 2", 7, nil},
+		{"7 / 0", 0, ErrDivisionByZero},
+		{"7 % 0", 0, ErrDivisionByZero},
+		{"7 % (2 - 2)", 0, ErrDivisionByZero},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.exp, func(t *testing.T) {
+			r, err := engine.QueryStmt("SELECT "+tc.exp, nil, true)
+			require.ErrorIs(t, err, tc.expectedError)
+
+			if tc.expectedError != nil {
+				return
+			}
+
+			row, err := r.Read()
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, row.Values[EncodeSelector("", "", "", "col0")].Value())
+
+			err = r.Close()
+			require.NoError(t, err)
+		})
+	}
+
+	t.Run("division and modulo over table columns", func(t *testing.T) {
+		_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+		require.NoError(t, err)
+
+		err = engine.UseDatabase("db1")
+		require.NoError(t, err)
+
+		_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, n INTEGER, d INTEGER, PRIMARY KEY id)", nil, true)
+		require.NoError(t, err)
+
+		_, err = engine.ExecStmt("INSERT INTO table1 (n, d) VALUES (7, 2), (-7, 2), (7, -2), (7, NULL), (7, 0)", nil, true)
+		require.NoError(t, err)
+
+		r, err := engine.QueryStmt("SELECT n / d AS q, n % d AS m FROM table1 WHERE id < 5", nil, true)
+		require.NoError(t, err)
+
+		expected := [][]interface{}{
+			{int64(3), int64(1)},
+			{int64(-3), int64(-1)},
+			{int64(-3), int64(1)},
+			{nil, nil},
+		}
+
+		for _, vals := range expected {
+			row, err := r.Read()
+			require.NoError(t, err)
+			require.Equal(t, vals[0], row.Values[EncodeSelector("", "db1", "table1", "q")].Value())
+			require.Equal(t, vals[1], row.Values[EncodeSelector("", "db1", "table1", "m")].Value())
+		}
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+
+		for _, exp := range []string{"n / d", "n % d"} {
+			r, err = engine.QueryStmt("SELECT "+exp+" FROM table1 WHERE id = 5", nil, true)
+			require.NoError(t, err)
+
+			_, err = r.Read()
+			require.ErrorIs(t, err, ErrDivisionByZero)
+
+			err = r.Close()
+			require.NoError(t, err)
+		}
+
+		_, err = engine.ExecStmt("UPDATE table1 SET n = n % d WHERE id = 5", nil, true)
+		require.ErrorIs(t, err, ErrDivisionByZero)
+	})
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestJoinWithHistoricalTable(t *testing.T) {
+	catalogStore, err := store.Open("catalog_join_before", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_join_before")
+
+	dataStore, err := store.Open("sqldata_join_before", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_join_before")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		CREATE TABLE prices (id INTEGER, price INTEGER, PRIMARY KEY id);
+		CREATE TABLE stock (id INTEGER, amount INTEGER, PRIMARY KEY id);
+	`, nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("UPSERT INTO prices (id, price) VALUES (1, 10), (2, 20)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("UPSERT INTO stock (id, amount) VALUES (1, 100), (2, 200)", nil, true)
+	require.NoError(t, err)
+
+	summary, err := engine.ExecStmt("UPSERT INTO prices (id, price) VALUES (1, 15), (2, 25)", nil, true)
+	require.NoError(t, err)
+	require.Len(t, summary.DMTxs, 1)
+
+	_, err = engine.ExecStmt("UPSERT INTO stock (id, amount) VALUES (1, 150)", nil, true)
+	require.NoError(t, err)
+
+	query := fmt.Sprintf(`
+		SELECT p.id, p.price, s.amount
+		FROM prices BEFORE TX %d AS p
+		INNER JOIN stock AS s ON p.id = s.id
+		ORDER BY p.id`, summary.DMTxs[0].ID)
+
+	r, err := engine.QueryStmt(query, nil, true)
+	require.NoError(t, err)
+
+	expected := []struct {
+		id     int64
+		price  int64
+		amount int64
+	}{
+		{1, 10, 150},
+		{2, 20, 200},
+	}
+
+	for _, e := range expected {
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, e.id, row.Values[EncodeSelector("", "db1", "p", "id")].Value())
+		require.Equal(t, e.price, row.Values[EncodeSelector("", "db1", "p", "price")].Value())
+		require.Equal(t, e.amount, row.Values[EncodeSelector("", "db1", "s", "amount")].Value())
+	}
+
+	_, err = r.Read()
+	require.ErrorIs(t, err, ErrNoMoreRows)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+// encodeRowValues mirrors the per-row encoding of row values, so to verify the buffers reused across rows
+func encodeRowValues(t *testing.T, table *Table, valuesByColID map[uint32]TypedValue) []byte {
+	valbuf := bytes.Buffer{}
+
+	b := make([]byte, EncLenLen)
+	binary.BigEndian.PutUint32(b, uint32(len(valuesByColID)))
+	valbuf.Write(b)
+
+	for _, col := range table.cols {
+		rval, notNull := valuesByColID[col.id]
+		if !notNull {
+			continue
+		}
+
+		b := make([]byte, EncIDLen)
+		binary.BigEndian.PutUint32(b, uint32(col.id))
+		valbuf.Write(b)
+
+		encVal, err := EncodeValue(rval.Value(), col.colType, col.MaxLen())
+		require.NoError(t, err)
+
+		valbuf.Write(encVal)
+	}
+
+	return valbuf.Bytes()
+}
+
+func TestMultiRowUpsertEncoding(t *testing.T) {
+	catalogStore, err := store.Open("catalog_multirow_upsert", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_multirow_upsert")
+
+	dataStore, err := store.Open("sqldata_multirow_upsert", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_multirow_upsert")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		CREATE TABLE table1 (
+			id INTEGER,
+			title VARCHAR[32],
+			active BOOLEAN,
+			payload BLOB,
+			amount INTEGER,
+			PRIMARY KEY id
+		)`, nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(title)", nil, true)
+	require.NoError(t, err)
+
+	stmts, err := ParseString(`
+		UPSERT INTO table1 (amount, id, title, active, payload)
+		VALUES
+			(10, 1, 'title1', true, x'00'),
+			(NULL, 2, 'a much longer title2', false, NULL),
+			(30, 3, 't3', NULL, x'0102030405'),
+			(NULL, 4, '', true, x'')
+	`)
+	require.NoError(t, err)
+	require.Len(t, stmts, 1)
+
+	db, err := engine.catalog.GetDatabaseByName("db1")
+	require.NoError(t, err)
+
+	table, err := db.GetTableByName("table1")
+	require.NoError(t, err)
+
+	summary, err := stmts[0].compileUsing(engine, db, nil)
+	require.NoError(t, err)
+	require.Equal(t, 4, summary.updatedRows)
+	require.Len(t, summary.des, 8)
+
+	col := func(name string) uint32 {
+		c, err := table.GetColumnByName(name)
+		require.NoError(t, err)
+		return c.id
+	}
+
+	expectedRows := []map[uint32]TypedValue{
+		{col("id"): &Number{val: 1}, col("title"): &Varchar{val: "title1"}, col("active"): &Bool{val: true}, col("payload"): &Blob{val: []byte{0}}, col("amount"): &Number{val: 10}},
+		{col("id"): &Number{val: 2}, col("title"): &Varchar{val: "a much longer title2"}, col("active"): &Bool{val: false}},
+		{col("id"): &Number{val: 3}, col("title"): &Varchar{val: "t3"}, col("payload"): &Blob{val: []byte{1, 2, 3, 4, 5}}, col("amount"): &Number{val: 30}},
+		{col("id"): &Number{val: 4}, col("title"): &Varchar{val: ""}, col("active"): &Bool{val: true}, col("payload"): &Blob{val: []byte{}}},
+	}
+
+	for i, valuesByColID := range expectedRows {
+		pkEncVals, err := encodedPK(table, valuesByColID)
+		require.NoError(t, err)
+
+		pke := summary.des[2*i]
+		require.Equal(t, engine.mapKey(PIndexPrefix, EncodeID(db.id), EncodeID(table.id), EncodeID(PKIndexID), pkEncVals), pke.Key)
+		require.Equal(t, encodeRowValues(t, table, valuesByColID), pke.Value)
+
+		ie, err := engine.indexEntryFor(table.indexes[indexKeyFrom([]*Column{table.colsByID[col("title")]})], pkEncVals, valuesByColID)
+		require.NoError(t, err)
+		require.Equal(t, ie, summary.des[2*i+1])
+	}
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func BenchmarkMultiRowInsert(b *testing.B) {
+	catalogStore, err := store.Open("catalog_bench_insert", store.DefaultOptions())
+	require.NoError(b, err)
+	defer os.RemoveAll("catalog_bench_insert")
+
+	dataStore, err := store.Open("sqldata_bench_insert", store.DefaultOptions().WithMaxTxEntries(10000))
+	require.NoError(b, err)
+	defer os.RemoveAll("sqldata_bench_insert")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(b, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(b, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(b, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, title VARCHAR, active BOOLEAN, payload BLOB, PRIMARY KEY id)", nil, true)
+	require.NoError(b, err)
+
+	rowCount := 10000
+
+	var sb strings.Builder
+	sb.WriteString("INSERT INTO table1 (title, active, payload) VALUES ")
+
+	for i := 0; i < rowCount; i++ {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		fmt.Fprintf(&sb, "('title%d', %v, x'%08x')", i, i%2 == 0, i)
+	}
+
+	stmts, err := ParseString(sb.String())
+	require.NoError(b, err)
+
+	db, err := engine.catalog.GetDatabaseByName("db1")
+	require.NoError(b, err)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		summary, err := stmts[0].compileUsing(engine, db, nil)
+		require.NoError(b, err)
+		require.Equal(b, rowCount, summary.updatedRows)
+	}
+}
+
+func BenchmarkCountAll(b *testing.B) {
+	catalogStore, err := store.Open("catalog_bench_count", store.DefaultOptions())
+	require.NoError(b, err)
+	defer os.RemoveAll("catalog_bench_count")
+
+	dataStore, err := store.Open("sqldata_bench_count", store.DefaultOptions().WithMaxTxEntries(10000))
+	require.NoError(b, err)
+	defer os.RemoveAll("sqldata_bench_count")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(b, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(b, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(b, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, title VARCHAR, payload BLOB, PRIMARY KEY id)", nil, true)
+	require.NoError(b, err)
+
+	rowCount := 10000
+
+	var sb strings.Builder
+	sb.WriteString("INSERT INTO table1 (title, payload) VALUES ")
+
+	for i := 0; i < rowCount; i++ {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		fmt.Fprintf(&sb, "('title%d', x'%0128x')", i, i)
+	}
+
+	_, err = engine.ExecStmt(sb.String(), nil, true)
+	require.NoError(b, err)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		r, err := engine.QueryStmt("SELECT COUNT() FROM table1", nil, true)
+		require.NoError(b, err)
+
+		row, err := r.Read()
+		require.NoError(b, err)
+		require.Equal(b, int64(rowCount), row.Values[EncodeSelector("", "db1", "table1", "count(*)")].Value())
+
+		err = r.Close()
+		require.NoError(b, err)
+	}
+}
+
+func TestInsertFromSelect(t *testing.T) {
+	catalogStore, err := store.Open("catalog_insert_select", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_insert_select")
+
+	dataStore, err := store.Open("sqldata_insert_select", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_insert_select")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		CREATE TABLE products (id INTEGER, title VARCHAR, price INTEGER, active BOOLEAN, PRIMARY KEY id);
+		CREATE TABLE archive (id INTEGER AUTO_INCREMENT, product_id INTEGER, title VARCHAR, PRIMARY KEY id);
+	`, nil, true)
+	require.NoError(t, err)
+
+	rowCount := 10
+
+	for i := 1; i <= rowCount; i++ {
+		_, err = engine.ExecStmt("INSERT INTO products (id, title, price, active) VALUES (@id, @title, @price, @active)",
+			map[string]interface{}{"id": i, "title": fmt.Sprintf("product%d", i), "price": i * 10, "active": i%2 == 0}, true)
+		require.NoError(t, err)
+	}
+
+	t.Run("insert from select should copy the filtered rows", func(t *testing.T) {
+		summary, err := engine.ExecStmt("INSERT INTO archive (product_id, title) SELECT id, title FROM products WHERE active AND price > @minPrice", map[string]interface{}{"minPrice": 40}, true)
+		require.NoError(t, err)
+		require.Equal(t, 3, summary.UpdatedRows)
+		require.Equal(t, int64(3), summary.LastInsertedPKs["archive"])
+
+		r, err := engine.QueryStmt("SELECT id, product_id, title FROM archive", nil, true)
+		require.NoError(t, err)
+
+		for i, productID := range []int64{6, 8, 10} {
+			row, err := r.Read()
+			require.NoError(t, err)
+			require.Equal(t, int64(i+1), row.Values[EncodeSelector("", "db1", "archive", "id")].Value())
+			require.Equal(t, productID, row.Values[EncodeSelector("", "db1", "archive", "product_id")].Value())
+			require.Equal(t, fmt.Sprintf("product%d", productID), row.Values[EncodeSelector("", "db1", "archive", "title")].Value())
+		}
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("insert from select should allow reading from the target table", func(t *testing.T) {
+		summary, err := engine.ExecStmt("INSERT INTO products (id, title, price) SELECT price, title, id FROM products WHERE id = 2", nil, true)
+		require.NoError(t, err)
+		require.Equal(t, 1, summary.UpdatedRows)
+
+		r, err := engine.QueryStmt("SELECT COUNT() AS c FROM products", nil, true)
+		require.NoError(t, err)
+
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, int64(rowCount+1), row.Values[EncodeSelector("", "db1", "products", "c")].Value())
+
+		err = r.Close()
+		require.NoError(t, err)
+
+		r, err = engine.QueryStmt("SELECT title, price, active FROM products WHERE id = 20", nil, true)
+		require.NoError(t, err)
+
+		row, err = r.Read()
+		require.NoError(t, err)
+		require.Equal(t, "product2", row.Values[EncodeSelector("", "db1", "products", "title")].Value())
+		require.Equal(t, int64(2), row.Values[EncodeSelector("", "db1", "products", "price")].Value())
+		require.Nil(t, row.Values[EncodeSelector("", "db1", "products", "active")].Value())
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("insert from select should skip conflicting rows", func(t *testing.T) {
+		summary, err := engine.ExecStmt("INSERT INTO products (id, title, price) SELECT id, title, price FROM products ON CONFLICT DO NOTHING", nil, true)
+		require.NoError(t, err)
+		require.Zero(t, summary.UpdatedRows)
+
+		_, err = engine.ExecStmt("INSERT INTO products (id, title, price) SELECT id, title, price FROM products", nil, true)
+		require.ErrorIs(t, err, store.ErrKeyAlreadyExists)
+	})
+
+	t.Run("insert from select should fail when types do not match", func(t *testing.T) {
+		_, err := engine.ExecStmt("INSERT INTO archive (product_id, title) SELECT title, id FROM products", nil, true)
+		require.ErrorIs(t, err, ErrInvalidTypes)
+	})
+
+	t.Run("insert from select should fail when the number of columns do not match", func(t *testing.T) {
+		_, err := engine.ExecStmt("INSERT INTO archive (product_id, title) SELECT id FROM products", nil, true)
+		require.ErrorIs(t, err, ErrInvalidNumberOfValues)
+	})
+
+	t.Run("insert from select should insert the values of selected expressions", func(t *testing.T) {
+		summary, err := engine.ExecStmt("INSERT INTO archive (product_id, title) SELECT id * 100 + price % 7, title FROM products WHERE id <= 2", nil, true)
+		require.NoError(t, err)
+		require.Equal(t, 2, summary.UpdatedRows)
+
+		r, err := engine.QueryStmt("SELECT product_id, title FROM archive WHERE id > 3", nil, true)
+		require.NoError(t, err)
+
+		for _, productID := range []int64{1, 2} {
+			row, err := r.Read()
+			require.NoError(t, err)
+			require.Equal(t, productID*100+productID*10%7, row.Values[EncodeSelector("", "db1", "archive", "product_id")].Value())
+			require.Equal(t, fmt.Sprintf("product%d", productID), row.Values[EncodeSelector("", "db1", "archive", "title")].Value())
+		}
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+
+		_, err = engine.ExecStmt("INSERT INTO archive (product_id, title) SELECT -id, id + 1 FROM products", nil, true)
+		require.ErrorIs(t, err, ErrInvalidTypes)
+	})
+
+	t.Run("insert from select should infer the parameters of the select", func(t *testing.T) {
+		params, err := engine.InferParameters("INSERT INTO archive (product_id, title) SELECT id, title FROM products WHERE price > @minPrice")
+		require.NoError(t, err)
+		require.Equal(t, map[string]SQLValueType{"minprice": IntegerType}, params)
+	})
+
+	t.Run("insert from select should infer the parameters of selected expressions", func(t *testing.T) {
+		params, err := engine.InferParameters("INSERT INTO archive (product_id, title) SELECT id * @factor, title FROM products")
+		require.NoError(t, err)
+		require.Equal(t, map[string]SQLValueType{"factor": IntegerType}, params)
+	})
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestInsertDefaultValues(t *testing.T) {
+	catalogStore, err := store.Open("catalog_insert_default", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_insert_default")
+
+	dataStore, err := store.Open("sqldata_insert_default", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_insert_default")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		CREATE TABLE table1 (
+			id INTEGER AUTO_INCREMENT,
+			title VARCHAR[16],
+			amount INTEGER,
+			active BOOLEAN NOT NULL,
+			PRIMARY KEY id
+		)`, nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		INSERT INTO table1 (id, title, amount, active)
+		VALUES (DEFAULT, 'title1', DEFAULT, true), (DEFAULT, DEFAULT, 20, false)`, nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (title, amount, active) VALUES (DEFAULT, @amount, true)", map[string]interface{}{"amount": 30}, true)
+	require.NoError(t, err)
+
+	r, err := engine.QueryStmt("SELECT id, title, amount FROM table1", nil, true)
+	require.NoError(t, err)
+
+	expected := []struct {
+		id     int64
+		title  interface{}
+		amount interface{}
+	}{
+		{1, "title1", nil},
+		{2, nil, int64(20)},
+		{3, nil, int64(30)},
+	}
+
+	for _, e := range expected {
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, e.id, row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+		require.Equal(t, e.title, row.Values[EncodeSelector("", "db1", "table1", "title")].Value())
+		require.Equal(t, e.amount, row.Values[EncodeSelector("", "db1", "table1", "amount")].Value())
+	}
+
+	_, err = r.Read()
+	require.ErrorIs(t, err, ErrNoMoreRows)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	t.Run("not nullable columns can not take the default value", func(t *testing.T) {
+		_, err := engine.ExecStmt("INSERT INTO table1 (title, active) VALUES ('title4', DEFAULT)", nil, true)
+		require.ErrorIs(t, err, ErrNotNullableColumnCannotBeNull)
+	})
+
+	t.Run("auto-incremental values can not be mixed with explicit ones", func(t *testing.T) {
+		_, err := engine.ExecStmt("INSERT INTO table1 (id, active) VALUES (DEFAULT, true), (10, true)", nil, true)
+		require.ErrorIs(t, err, ErrNoValueForAutoIncrementalColumn)
+	})
+
+	t.Run("default can not be used in expressions", func(t *testing.T) {
+		_, err := engine.ExecStmt("INSERT INTO table1 (amount, active) VALUES (DEFAULT + 1, true)", nil, true)
+		require.Error(t, err)
+	})
+}
+
+func TestAmbiguousColumn(t *testing.T) {
+	catalogStore, err := store.Open("catalog_ambiguous_col", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_ambiguous_col")
+
+	dataStore, err := store.Open("sqldata_ambiguous_col", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_ambiguous_col")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		CREATE TABLE customers (id INTEGER, name VARCHAR, PRIMARY KEY id);
+		CREATE TABLE orders (id INTEGER, customer_id INTEGER, name VARCHAR, PRIMARY KEY id);
+		INSERT INTO customers (id, name) VALUES (1, 'customer1'), (2, 'customer2');
+		INSERT INTO orders (id, customer_id, name) VALUES (10, 2, 'order10');
+	`, nil, true)
+	require.NoError(t, err)
+
+	ambiguousQueries := []string{
+		"SELECT name FROM customers INNER JOIN orders ON customers.id = orders.customer_id",
+		"SELECT customers.name FROM customers INNER JOIN orders ON id = orders.customer_id",
+		"SELECT orders.name FROM customers c INNER JOIN orders ON c.id = orders.customer_id WHERE name = 'customer2'",
+		"SELECT COUNT() FROM customers INNER JOIN orders ON customers.id = orders.customer_id GROUP BY name",
+		"SELECT orders.name FROM customers INNER JOIN orders ON customers.id = orders.customer_id ORDER BY id",
+	}
+
+	for _, q := range ambiguousQueries {
+		t.Run(q, func(t *testing.T) {
+			_, err := engine.QueryStmt(q, nil, true)
+			require.ErrorIs(t, err, ErrAmbiguousColumn)
+			require.ErrorIs(t, err, ErrAmbiguousSelector)
+		})
+	}
+
+	_, err = engine.QueryStmt("SELECT name FROM customers c INNER JOIN orders o ON c.id = o.customer_id", nil, true)
+	require.EqualError(t, err, "ambiguous selector: ambiguous column: 'name' may refer to c.name, o.name")
+
+	_, err = engine.InferParameters("SELECT c.name FROM customers c INNER JOIN orders o ON c.id = o.customer_id WHERE name = @name")
+	require.ErrorIs(t, err, ErrAmbiguousColumn)
+
+	params, err := engine.InferParameters("SELECT c.name FROM customers c INNER JOIN orders o ON c.id = o.customer_id WHERE o.name = @name")
+	require.NoError(t, err)
+	require.Equal(t, map[string]SQLValueType{"name": VarcharType}, params)
+
+	r, err := engine.QueryStmt(`
+		SELECT customers.name, orders.name AS order_name, orders.customer_id
+		FROM customers INNER JOIN orders ON customers.id = orders.customer_id
+		WHERE orders.name = 'order10'`, nil, true)
+	require.NoError(t, err)
+
+	row, err := r.Read()
+	require.NoError(t, err)
+	require.Equal(t, "customer2", row.Values[EncodeSelector("", "db1", "customers", "name")].Value())
+	require.Equal(t, "order10", row.Values[EncodeSelector("", "db1", "orders", "order_name")].Value())
+	require.Equal(t, int64(2), row.Values[EncodeSelector("", "db1", "orders", "customer_id")].Value())
+
+	_, err = r.Read()
+	require.ErrorIs(t, err, ErrNoMoreRows)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestNoCaseCollation(t *testing.T) {
+	catalogStore, err := store.Open("catalog_collation", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_collation")
+
+	dataStore, err := store.Open("sqldata_collation", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_collation")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table2 (id INTEGER COLLATE NOCASE, PRIMARY KEY id)", nil, true)
+	require.ErrorIs(t, err, ErrInvalidCollation)
+
+	_, err = engine.ExecStmt("CREATE TABLE table2 (id INTEGER, title VARCHAR COLLATE UNKNOWN, PRIMARY KEY id)", nil, true)
+	require.ErrorIs(t, err, ErrInvalidCollation)
+
+	_, err = engine.ExecStmt(`
+		CREATE TABLE table1 (
+			id INTEGER AUTO_INCREMENT,
+			name VARCHAR[16] COLLATE NOCASE UNIQUE,
+			code VARCHAR[16] COLLATE binary,
+			PRIMARY KEY id
+		)`, nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(code)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (name, code) VALUES ('cherry', 'cherry'), ('apple', 'apple'), ('Banana', 'Banana')", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (name, code) VALUES ('APPLE', 'APPLE')", nil, true)
+	require.ErrorIs(t, err, store.ErrKeyAlreadyExists)
+
+	err = engine.EnsureCatalogReady(nil)
+	require.NoError(t, err)
+
+	queryCol := func(t *testing.T, query, col string) []string {
+		r, err := engine.QueryStmt(query, nil, true)
+		require.NoError(t, err)
+		defer r.Close()
+
+		var vals []string
+
+		for {
+			row, err := r.Read()
+			if err == ErrNoMoreRows {
+				break
+			}
+			require.NoError(t, err)
+
+			vals = append(vals, row.Values[EncodeSelector("", "db1", "table1", col)].Value().(string))
+		}
+
+		return vals
+	}
+
+	t.Run("comparisons should follow the collation of the column", func(t *testing.T) {
+		require.Equal(t, []string{"Banana"}, queryCol(t, "SELECT name FROM table1 WHERE name = 'BANANA'", "name"))
+		require.Equal(t, []string{"Banana"}, queryCol(t, "SELECT name FROM table1 USE INDEX ON (id) WHERE 'bAnAnA' = name", "name"))
+		require.Empty(t, queryCol(t, "SELECT code FROM table1 WHERE code = 'BANANA'", "code"))
+		require.Equal(t, []string{"Banana", "cherry"}, queryCol(t, "SELECT name FROM table1 WHERE name > 'APPLE'", "name"))
+	})
+
+	t.Run("index placement should follow the collation of the column", func(t *testing.T) {
+		require.Equal(t, []string{"apple", "Banana", "cherry"}, queryCol(t, "SELECT name FROM table1 ORDER BY name", "name"))
+		require.Equal(t, []string{"cherry", "Banana", "apple"}, queryCol(t, "SELECT name FROM table1 ORDER BY name DESC", "name"))
+		require.Equal(t, []string{"Banana", "apple", "cherry"}, queryCol(t, "SELECT code FROM table1 ORDER BY code", "code"))
+	})
+
+	err = engine.Close()
+	require.NoError(t, err)
+
+	t.Run("collation should be loaded from the catalog", func(t *testing.T) {
+		engine, err = NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+		require.NoError(t, err)
+
+		err = engine.EnsureCatalogReady(nil)
+		require.NoError(t, err)
+
+		table, err := engine.catalog.GetTableByName("db1", "table1")
+		require.NoError(t, err)
+
+		for colName, collation := range map[string]Collation{"id": BinaryCollation, "name": NoCaseCollation, "code": BinaryCollation} {
+			col, err := table.GetColumnByName(colName)
+			require.NoError(t, err)
+			require.Equal(t, collation, col.Collation())
+		}
+
+		err = engine.UseDatabase("db1")
+		require.NoError(t, err)
+
+		require.Equal(t, []string{"apple"}, queryCol(t, "SELECT name FROM table1 WHERE name = 'Apple'", "name"))
+
+		err = engine.Close()
+		require.NoError(t, err)
+	})
+}
+
+func TestRowReaderRewind(t *testing.T) {
+	catalogStore, err := store.Open("catalog_rewind", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_rewind")
+
+	dataStore, err := store.Open("sqldata_rewind", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_rewind")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR[50], active BOOLEAN, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table2 (id INTEGER, fkid INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		_, err = engine.ExecStmt(fmt.Sprintf(`
+			INSERT INTO table1 (id, title, active) VALUES (%d, 'title%d', %v);
+			INSERT INTO table2 (id, fkid) VALUES (%d, %d)
+		`, i, i, i%2 == 0, i, i%3), nil, true)
+		require.NoError(t, err)
+	}
+
+	readAll := func(t *testing.T, r RowReader) []*Row {
+		var rows []*Row
+
+		for {
+			row, err := r.Read()
+			if err == ErrNoMoreRows {
+				return rows
+			}
+			require.NoError(t, err)
+
+			rows = append(rows, row)
+		}
+	}
+
+	t.Run("raw reader", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT id, title, active FROM table1", nil, true)
+		require.NoError(t, err)
+		defer r.Close()
+
+		raw := rawReaderOf(t, r)
+
+		rows := readAll(t, raw)
+		require.Len(t, rows, 10)
+
+		err = raw.Rewind()
+		require.NoError(t, err)
+
+		require.Equal(t, rows, readAll(t, raw))
+	})
+
+	queries := []struct {
+		query    string
+		rowCount int
+	}{
+		{"SELECT id, title FROM table1", 10},
+		{"SELECT id FROM table1 WHERE active = true", 5},
+		{"SELECT id FROM table1 WHERE id > 100", 0},
+		{"SELECT id FROM table1 ORDER BY id DESC LIMIT 3 OFFSET 2", 3},
+		{"SELECT COUNT(), SUM(id) FROM table1 WHERE id > 100", 1},
+		{"SELECT COUNT(), MAX(id) FROM table1", 1},
+		{"SELECT DISTINCT active FROM table1", 2},
+		{"SELECT id FROM table1 WHERE id < 2 UNION ALL SELECT id FROM table2 WHERE id > 7", 4},
+		{"SELECT table1.id, table2.id FROM table1 INNER JOIN table2 ON table2.fkid = table1.id", 10},
+		{"SELECT table1.id, table2.id FROM table1 LEFT JOIN table2 ON table2.fkid = table1.id", 17},
+	}
+
+	for _, q := range queries {
+		t.Run(q.query, func(t *testing.T) {
+			r, err := engine.QueryStmt(q.query, nil, true)
+			require.NoError(t, err)
+			defer r.Close()
+
+			rows := readAll(t, r)
+			require.Len(t, rows, q.rowCount)
+
+			err = r.Rewind()
+			require.NoError(t, err)
+
+			require.Equal(t, rows, readAll(t, r))
+
+			if len(rows) > 0 {
+				// rewind after a partial read
+				err = r.Rewind()
+				require.NoError(t, err)
+
+				_, err = r.Read()
+				require.NoError(t, err)
+
+				err = r.Rewind()
+				require.NoError(t, err)
+
+				require.Equal(t, rows, readAll(t, r))
+			}
+		})
+	}
+}
+
+func TestUniqueConstraintViolation(t *testing.T) {
+	catalogStore, err := store.Open("catalog_unique_constraint", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_unique_constraint")
+
+	dataStore, err := store.Open("sqldata_unique_constraint", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_unique_constraint")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		CREATE TABLE table1 (id INTEGER, email VARCHAR[64], country VARCHAR[2], PRIMARY KEY id);
+		CREATE UNIQUE INDEX ON table1(email);
+		CREATE UNIQUE INDEX ON table1(country, id);
+	`, nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, email, country) VALUES (1, 'a@b.c', 'uk')", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, email, country) VALUES (2, 'a@b.c', 'us')", nil, true)
+	require.ErrorIs(t, err, ErrUniqueConstraintViolated)
+	require.ErrorIs(t, err, store.ErrKeyAlreadyExists)
+	require.Contains(t, err.Error(), "(email)")
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, email, country) VALUES (1, 'b@b.c', 'us')", nil, true)
+	require.ErrorIs(t, err, ErrUniqueConstraintViolated)
+	require.Contains(t, err.Error(), "(id)")
+
+	_, err = engine.ExecStmt("CREATE TABLE table2 (id INTEGER, name VARCHAR[32], PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table2 (id, name) VALUES (1, 'name1'), (2, 'name1')", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE UNIQUE INDEX ON table2(name)", nil, true)
+	require.ErrorIs(t, err, ErrUniqueConstraintViolated)
+	require.Contains(t, err.Error(), "(name)")
+
+	err = engine.EnsureCatalogReady(nil)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, email, country) VALUES (2, 'b@b.c', 'uk')", nil, true)
+	require.NoError(t, err)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestQuotedIdentifiers(t *testing.T) {
+	catalogStore, err := store.Open("catalog_quoted_identifiers", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_quoted_identifiers")
+
+	dataStore, err := store.Open("sqldata_quoted_identifiers", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_quoted_identifiers")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, select VARCHAR, PRIMARY KEY id)", nil, true)
+	require.Error(t, err)
+
+	_, err = engine.ExecStmt(`
+		CREATE TABLE "table" (
+			id INTEGER,
+			"select" VARCHAR,
+			"my col" INTEGER CHECK ("my col" > 0),
+			PRIMARY KEY id
+		)`, nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`INSERT INTO "table" (id, "select", "my col") VALUES (1, 'title1', 10), (2, 'title2', 20)`, nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`INSERT INTO "table" (id, "my col") VALUES (3, 0)`, nil, true)
+	require.ErrorIs(t, err, ErrCheckConstraintViolated)
+
+	r, err := engine.QueryStmt(`SELECT "select", "my col" FROM "table" WHERE "table"."my col" > 10`, nil, true)
+	require.NoError(t, err)
+
+	row, err := r.Read()
+	require.NoError(t, err)
+	require.Equal(t, "title2", row.Values[EncodeSelector("", "db1", "table", "select")].Value())
+	require.Equal(t, int64(20), row.Values[EncodeSelector("", "db1", "table", "my col")].Value())
+
+	_, err = r.Read()
+	require.ErrorIs(t, err, ErrNoMoreRows)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	err = engine.Close()
+	require.NoError(t, err)
+
+	// check constraints over quoted columns are parsed back when loading the catalog
+	engine, err = NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	err = engine.EnsureCatalogReady(nil)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`INSERT INTO "table" (id, "my col") VALUES (3, 0)`, nil, true)
+	require.ErrorIs(t, err, ErrCheckConstraintViolated)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestSystemColumns(t *testing.T) {
+	catalogStore, err := store.Open("catalog_system_columns", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_system_columns")
+
+	dataStore, err := store.Open("sqldata_system_columns", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_system_columns")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table2 (id INTEGER, _tx INTEGER, PRIMARY KEY id)", nil, true)
+	require.ErrorIs(t, err, ErrInvalidColumn)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR[32], PRIMARY KEY id); CREATE INDEX ON table1(title)", nil, true)
+	require.NoError(t, err)
+
+	summary1, err := engine.ExecStmt("INSERT INTO table1 (id, title) VALUES (1, 'title1')", nil, true)
+	require.NoError(t, err)
+
+	summary2, err := engine.ExecStmt("INSERT INTO table1 (id, title) VALUES (2, 'title2')", nil, true)
+	require.NoError(t, err)
+
+	summary3, err := engine.ExecStmt("UPDATE table1 SET title = 'title3' WHERE id = 1", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, _tx) VALUES (3, 1)", nil, true)
+	require.ErrorIs(t, err, ErrColumnDoesNotExist)
+
+	_, err = engine.ExecStmt("UPDATE table1 SET _tx = 1 WHERE id = 1", nil, true)
+	require.ErrorIs(t, err, ErrColumnDoesNotExist)
+
+	rowTxs := []*store.TxHeader{summary3.DMTxs[0], summary2.DMTxs[0]}
+
+	t.Run("system columns are not part of the table columns", func(t *testing.T) {
+		r, err := engine.QueryStmt("SELECT * FROM table1", nil, true)
+		require.NoError(t, err)
+		defer r.Close()
+
+		cols, err := r.Columns()
+		require.NoError(t, err)
+		require.Len(t, cols, 2)
+	})
+
+	t.Run("system columns hold the transaction that last wrote the row", func(t *testing.T) {
+		for _, query := range []string{
+			"SELECT _tx, _ts, id FROM table1",
+			"SELECT _tx, table1._ts, id FROM table1 WHERE title >= 'title' ORDER BY title DESC",
+		} {
+			r, err := engine.QueryStmt(query, nil, true)
+			require.NoError(t, err)
+
+			for i := range rowTxs {
+				row, err := r.Read()
+				require.NoError(t, err)
+				require.Equal(t, int64(i+1), row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+				require.Equal(t, int64(rowTxs[i].ID), row.Values[EncodeSelector("", "db1", "table1", "_tx")].Value())
+				require.Equal(t, time.Unix(rowTxs[i].Ts, 0).UnixNano(), row.Values[EncodeSelector("", "db1", "table1", "_ts")].Value())
+			}
+
+			_, err = r.Read()
+			require.ErrorIs(t, err, ErrNoMoreRows)
+
+			err = r.Close()
+			require.NoError(t, err)
+		}
+	})
+
+	t.Run("system columns can be used in conditions", func(t *testing.T) {
+		params := map[string]interface{}{"txID": summary1.DMTxs[0].ID}
+
+		r, err := engine.QueryStmt("SELECT id FROM table1 AS t WHERE t._tx > @txID AND _ts IS NOT NULL", params, true)
+		require.NoError(t, err)
+
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, int64(1), row.Values[EncodeSelector("", "db1", "t", "id")].Value())
+
+		row, err = r.Read()
+		require.NoError(t, err)
+		require.Equal(t, int64(2), row.Values[EncodeSelector("", "db1", "t", "id")].Value())
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+
+		r, err = engine.QueryStmt("SELECT COUNT() FROM table1 WHERE _tx = @txID", params, true)
+		require.NoError(t, err)
+
+		row, err = r.Read()
+		require.NoError(t, err)
+		require.Equal(t, int64(0), row.Values[EncodeSelector("", "db1", "table1", "count(*)")].Value())
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	t.Run("system columns of joint tables", func(t *testing.T) {
+		_, err := engine.ExecStmt("CREATE TABLE table3 (id INTEGER, fk INTEGER, PRIMARY KEY id)", nil, true)
+		require.NoError(t, err)
+
+		summary4, err := engine.ExecStmt("INSERT INTO table3 (id, fk) VALUES (1, 2), (2, 1), (3, 4)", nil, true)
+		require.NoError(t, err)
+
+		tx4 := summary4.DMTxs[0]
+
+		r, err := engine.QueryStmt(`
+			SELECT t3.id, t3._tx, t1._tx, t1._ts
+			FROM table3 AS t3
+			INNER JOIN table1 AS t1 ON t1.id = t3.fk
+			WHERE t1._tx > 0`, nil, true)
+		require.NoError(t, err)
+
+		for i, rowTx := range []*store.TxHeader{rowTxs[1], rowTxs[0]} {
+			row, err := r.Read()
+			require.NoError(t, err)
+			require.Equal(t, int64(i+1), row.Values[EncodeSelector("", "db1", "t3", "id")].Value())
+			require.Equal(t, int64(tx4.ID), row.Values[EncodeSelector("", "db1", "t3", "_tx")].Value())
+			require.Equal(t, int64(rowTx.ID), row.Values[EncodeSelector("", "db1", "t1", "_tx")].Value())
+			require.Equal(t, time.Unix(rowTx.Ts, 0).UnixNano(), row.Values[EncodeSelector("", "db1", "t1", "_ts")].Value())
+		}
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+
+		r, err = engine.QueryStmt(`
+			SELECT t3.id, t1._tx, t1._ts
+			FROM table3 AS t3
+			LEFT JOIN table1 AS t1 ON t1.id = t3.fk
+			WHERE t3.id = 3`, nil, true)
+		require.NoError(t, err)
+
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, int64(3), row.Values[EncodeSelector("", "db1", "t3", "id")].Value())
+		require.Nil(t, row.Values[EncodeSelector("", "db1", "t1", "_tx")].Value())
+		require.Nil(t, row.Values[EncodeSelector("", "db1", "t1", "_ts")].Value())
+
+		err = r.Close()
+		require.NoError(t, err)
+
+		r, err = engine.QueryStmt(`
+			SELECT t3.id, t3._tx, t3._ts, t1.id, t1._ts
+			FROM table3 AS t3
+			RIGHT JOIN table1 AS t1 ON t1.id = t3.fk AND t3.id = 1`, nil, true)
+		require.NoError(t, err)
+
+		row, err = r.Read()
+		require.NoError(t, err)
+		require.Equal(t, int64(1), row.Values[EncodeSelector("", "db1", "t3", "id")].Value())
+		require.Equal(t, int64(tx4.ID), row.Values[EncodeSelector("", "db1", "t3", "_tx")].Value())
+		require.Equal(t, time.Unix(tx4.Ts, 0).UnixNano(), row.Values[EncodeSelector("", "db1", "t3", "_ts")].Value())
+		require.Equal(t, int64(2), row.Values[EncodeSelector("", "db1", "t1", "id")].Value())
+		require.Equal(t, time.Unix(rowTxs[1].Ts, 0).UnixNano(), row.Values[EncodeSelector("", "db1", "t1", "_ts")].Value())
+
+		row, err = r.Read()
+		require.NoError(t, err)
+		require.Nil(t, row.Values[EncodeSelector("", "db1", "t3", "id")].Value())
+		require.Nil(t, row.Values[EncodeSelector("", "db1", "t3", "_tx")].Value())
+		require.Nil(t, row.Values[EncodeSelector("", "db1", "t3", "_ts")].Value())
+		require.Equal(t, int64(1), row.Values[EncodeSelector("", "db1", "t1", "id")].Value())
+		require.Equal(t, time.Unix(rowTxs[0].Ts, 0).UnixNano(), row.Values[EncodeSelector("", "db1", "t1", "_ts")].Value())
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+	})
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestIndexOnlyScan(t *testing.T) {
+	catalogStore, err := store.Open("catalog_index_only", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_index_only")
+
+	dataStore, err := store.Open("sqldata_index_only", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_index_only")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		CREATE TABLE table1 (
+			id INTEGER,
+			country VARCHAR[2],
+			active BOOLEAN,
+			email VARCHAR[64],
+			payload BLOB[8],
+			amount INTEGER,
+			PRIMARY KEY (id, country)
+		);
+		CREATE INDEX ON table1(active, payload);
+		CREATE UNIQUE INDEX ON table1(email);
+	`, nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt(`
+		INSERT INTO table1 (id, country, active, email, payload, amount) VALUES
+			(1, 'uk', true, 'user1@example.com', x'01', 10),
+			(-2, 'us', false, 'user2@example.com', x'0203', 20),
+			(3, 'uk', true, 'user3@example.com', x'', 30)
+	`, nil, true)
+	require.NoError(t, err)
+
+	query := func(sql string, indexOnly bool) []map[string]interface{} {
+		r, err := engine.QueryStmt(sql, nil, true)
+		require.NoError(t, err)
+		defer r.Close()
+
+		require.Equal(t, indexOnly, r.ScanSpecs().indexOnly, sql)
+
+		cols, err := r.Columns()
+		require.NoError(t, err)
+
+		var rows []map[string]interface{}
+
+		for {
+			row, err := r.Read()
+			if err == ErrNoMoreRows {
+				break
+			}
+			require.NoError(t, err)
+
+			vals := make(map[string]interface{}, len(cols))
+			for _, col := range cols {
+				vals[col.Column] = row.Values[col.Selector()].Value()
+			}
+			rows = append(rows, vals)
+		}
+
+		return rows
+	}
+
+	t.Run("non-unique index entries provide indexed and primary key values", func(t *testing.T) {
+		covered := query("SELECT id, country, active, payload FROM table1 WHERE active = true AND id > 0", true)
+		require.Equal(t, []map[string]interface{}{
+			{"id": int64(3), "country": "uk", "active": true, "payload": []byte{}},
+			{"id": int64(1), "country": "uk", "active": true, "payload": []byte{0x01}},
+		}, covered)
+
+		require.Equal(t, covered, query("SELECT id, country, active, payload FROM table1 USE INDEX ON (active, payload) WHERE active = true AND id > 0 AND amount > 0", false))
+	})
+
+	t.Run("unique index entries provide indexed and primary key values", func(t *testing.T) {
+		covered := query("SELECT email, id FROM table1 WHERE email >= 'user2' ORDER BY email DESC", true)
+		require.Equal(t, []map[string]interface{}{
+			{"email": "user3@example.com", "id": int64(3)},
+			{"email": "user2@example.com", "id": int64(-2)},
+		}, covered)
+
+		rows := query("SELECT COUNT() AS c FROM table1 USE INDEX ON (email)", true)
+		require.Equal(t, []map[string]interface{}{{"c": int64(3)}}, rows)
+	})
+
+	t.Run("rows are not read when the query is covered by the index", func(t *testing.T) {
+		db, err := engine.catalog.GetDatabaseByName("db1")
+		require.NoError(t, err)
+
+		table, err := db.GetTableByName("table1")
+		require.NoError(t, err)
+
+		idCol, err := table.GetColumnByName("id")
+		require.NoError(t, err)
+
+		countryCol, err := table.GetColumnByName("country")
+		require.NoError(t, err)
+
+		// rows are overwritten with undecodable values, only index entries are left readable
+		var entries []*store.EntrySpec
+
+		for _, pk := range []struct {
+			id      int64
+			country string
+		}{{1, "uk"}, {-2, "us"}, {3, "uk"}} {
+			pkEncVals, err := encodedPK(table, map[uint32]TypedValue{
+				idCol.id:      &Number{val: pk.id},
+				countryCol.id: &Varchar{val: pk.country},
+			})
+			require.NoError(t, err)
+
+			entries = append(entries, &store.EntrySpec{
+				Key:   engine.mapKey(PIndexPrefix, EncodeID(db.id), EncodeID(table.id), EncodeID(PKIndexID), pkEncVals),
+				Value: []byte{0},
+			})
+		}
+
+		_, err = dataStore.Commit(&store.TxSpec{Entries: entries, WaitForIndexing: true})
+		require.NoError(t, err)
+
+		r, err := engine.QueryStmt("SELECT id, amount FROM table1 WHERE active = true", nil, true)
+		require.NoError(t, err)
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrCorruptedData)
+
+		err = r.Close()
+		require.NoError(t, err)
+
+		require.Equal(t, []map[string]interface{}{
+			{"id": int64(3), "country": "uk", "active": true, "payload": []byte{}},
+			{"id": int64(1), "country": "uk", "active": true, "payload": []byte{0x01}},
+		}, query("SELECT id, country, active, payload FROM table1 WHERE active = true AND id > 0", true))
+
+		require.Equal(t, []map[string]interface{}{
+			{"email": "user3@example.com", "id": int64(3)},
+			{"email": "user2@example.com", "id": int64(-2)},
+		}, query("SELECT email, id FROM table1 WHERE email >= 'user2' ORDER BY email DESC", true))
+
+		require.Equal(t, []map[string]interface{}{{"c": int64(3)}}, query("SELECT COUNT() AS c FROM table1", true))
+	})
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestNotNullableColumnsOnUpdate(t *testing.T) {
+	catalogStore, err := store.Open("catalog_not_null_update", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_not_null_update")
+
+	dataStore, err := store.Open("sqldata_not_null_update", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_not_null_update")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR NOT NULL, notes VARCHAR, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, title, notes) VALUES (1, 'title1', 'notes1')", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, notes) VALUES (2, 'notes2')", nil, true)
+	require.ErrorIs(t, err, ErrNotNullableColumnCannotBeNull)
+	require.Contains(t, err.Error(), "title")
+
+	_, err = engine.ExecStmt("UPDATE table1 SET title = NULL WHERE id = 1", nil, true)
+	require.ErrorIs(t, err, ErrNotNullableColumnCannotBeNull)
+	require.Contains(t, err.Error(), "title")
+
+	_, err = engine.ExecStmt("UPDATE table1 SET title = @title WHERE id = 1", map[string]interface{}{"title": nil}, true)
+	require.ErrorIs(t, err, ErrNotNullableColumnCannotBeNull)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, title) VALUES (1, 'title2') ON CONFLICT DO UPDATE SET title = NULL", nil, true)
+	require.ErrorIs(t, err, ErrNotNullableColumnCannotBeNull)
+	require.Contains(t, err.Error(), "title")
+
+	// nullable columns can still be set to NULL
+	_, err = engine.ExecStmt("UPDATE table1 SET notes = NULL WHERE id = 1", nil, true)
+	require.NoError(t, err)
+
+	// rows with NULL values can be updated
+	_, err = engine.ExecStmt("UPDATE table1 SET title = 'title2' WHERE id = 1", nil, true)
+	require.NoError(t, err)
+
+	r, err := engine.QueryStmt("SELECT title, notes FROM table1 WHERE id = 1", nil, true)
+	require.NoError(t, err)
+
+	row, err := r.Read()
+	require.NoError(t, err)
+	require.Equal(t, "title2", row.Values[EncodeSelector("", "db1", "table1", "title")].Value())
+	require.Nil(t, row.Values[EncodeSelector("", "db1", "table1", "notes")].Value())
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestCreateIndexWithOversizedKeys(t *testing.T) {
+	catalogStore, err := store.Open("catalog_oversized_index", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_oversized_index")
+
+	dataStore, err := store.Open("sqldata_oversized_index", store.DefaultOptions().WithMaxKeyLen(512))
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_oversized_index")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id VARCHAR[200], name VARCHAR[200], email VARCHAR[256], age INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(name, age)", nil, true)
+	require.NoError(t, err)
+
+	// entries of non-unique indexes include the pk values as part of the key
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(name, email)", nil, true)
+	require.ErrorIs(t, err, ErrMaxKeyLengthExceeded)
+
+	_, err = engine.ExecStmt("CREATE UNIQUE INDEX ON table1(name, email)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE UNIQUE INDEX ON table1(id, name, email)", nil, true)
+	require.ErrorIs(t, err, ErrMaxKeyLengthExceeded)
+
+	table, err := engine.catalog.GetTableByName("db1", "table1")
+	require.NoError(t, err)
+	require.Len(t, table.indexes, 3)
+
+	_, err = engine.ExecStmt(fmt.Sprintf("INSERT INTO table1 (id, name, email, age) VALUES ('%s', '%s', '%s', 10)",
+		strings.Repeat("i", 200), strings.Repeat("n", 200), strings.Repeat("e", 256)), nil, true)
+	require.NoError(t, err)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestCountAllFromPrimaryKeys(t *testing.T) {
+	catalogStore, err := store.Open("catalog_count_all", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_count_all")
+
+	dataStore, err := store.Open("sqldata_count_all", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_count_all")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, title VARCHAR, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	count := func() int64 {
+		r, err := engine.QueryStmt("SELECT COUNT() FROM table1", nil, true)
+		require.NoError(t, err)
+		defer r.Close()
+
+		require.True(t, r.ScanSpecs().index.IsPrimary())
+		require.True(t, r.ScanSpecs().indexOnly)
+
+		row, err := r.Read()
+		require.NoError(t, err)
+
+		return row.Values[EncodeSelector("", "db1", "table1", "count(*)")].Value().(int64)
+	}
+
+	require.Equal(t, int64(0), count())
+
+	for i := 0; i < 10; i++ {
+		_, err = engine.ExecStmt("INSERT INTO table1 (title) VALUES (@title)", map[string]interface{}{"title": fmt.Sprintf("title%d", i)}, true)
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, int64(10), count())
+
+	_, err = engine.ExecStmt("DELETE FROM table1 WHERE id > 7", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("DELETE FROM table1 WHERE title = 'title0'", nil, true)
+	require.NoError(t, err)
+
+	require.Equal(t, int64(6), count())
+
+	// updated rows are counted once
+	_, err = engine.ExecStmt("UPDATE table1 SET title = 'updated' WHERE id <= 5", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (title) VALUES ('title10')", nil, true)
+	require.NoError(t, err)
+
+	require.Equal(t, int64(7), count())
+
+	r, err := engine.QueryStmt("SELECT id FROM table1 WHERE id >= 7", nil, true)
+	require.NoError(t, err)
+	require.True(t, r.ScanSpecs().indexOnly)
+
+	for _, id := range []int64{7, 11} {
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, id, row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+	}
+
+	_, err = r.Read()
+	require.ErrorIs(t, err, ErrNoMoreRows)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestTimestampIndexRangeScan(t *testing.T) {
+	catalogStore, err := store.Open("catalog_timestamp_index", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_timestamp_index")
+
+	dataStore, err := store.Open("sqldata_timestamp_index", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_timestamp_index")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, ts TIMESTAMP, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1 (ts)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (ts) VALUES ('2021-08-25')", nil, true)
+	require.ErrorIs(t, err, ErrInvalidValue)
+
+	// timestamps before the unix epoch must sort before the ones after it
+	times := []time.Time{
+		time.Date(2021, 8, 25, 0, 0, 0, 0, time.UTC),
+		time.Date(1969, 7, 20, 20, 17, 0, 0, time.UTC),
+		time.Date(2021, 8, 25, 0, 0, 0, 1, time.UTC),
+		time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2000, 1, 1, 12, 30, 0, 0, time.UTC),
+	}
+
+	for _, ts := range times {
+		_, err = engine.ExecStmt("INSERT INTO table1 (ts) VALUES (@ts)", map[string]interface{}{"ts": ts}, true)
+		require.NoError(t, err)
+	}
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (ts) VALUES (CAST('1900-01-01T00:00:00Z' AS TIMESTAMP))", nil, true)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		query       string
+		params      map[string]interface{}
+		expectedIDs []int64
+	}{
+		{
+			query:       "SELECT id, ts FROM table1 ORDER BY ts",
+			expectedIDs: []int64{6, 2, 4, 5, 1, 3},
+		},
+		{
+			query:       "SELECT id, ts FROM table1 ORDER BY ts DESC",
+			expectedIDs: []int64{3, 1, 5, 4, 2, 6},
+		},
+		{
+			query:       "SELECT id, ts FROM table1 WHERE ts >= @lower AND ts < @upper ORDER BY ts",
+			params:      map[string]interface{}{"lower": times[1], "upper": times[2]},
+			expectedIDs: []int64{2, 4, 5, 1},
+		},
+		{
+			query:       "SELECT id, ts FROM table1 WHERE ts < CAST('1970-01-01T00:00:00Z' AS TIMESTAMP) ORDER BY ts",
+			expectedIDs: []int64{6, 2},
+		},
+		{
+			query:       "SELECT id, ts FROM table1 WHERE ts = @ts ORDER BY ts",
+			params:      map[string]interface{}{"ts": times[2]},
+			expectedIDs: []int64{3},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			r, err := engine.QueryStmt(tc.query, tc.params, true)
+			require.NoError(t, err)
+
+			defer r.Close()
+
+			require.Len(t, r.ScanSpecs().index.Cols(), 1)
+			require.Equal(t, "ts", r.ScanSpecs().index.Cols()[0].Name())
+
+			for _, id := range tc.expectedIDs {
+				row, err := r.Read()
+				require.NoError(t, err)
+				require.Equal(t, id, row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+
+				ts := row.Values[EncodeSelector("", "db1", "table1", "ts")]
+				require.Equal(t, TimestampType, ts.Type())
+
+				if id <= int64(len(times)) {
+					require.Equal(t, times[id-1].UnixNano(), ts.Value())
+				}
+			}
+
+			_, err = r.Read()
+			require.ErrorIs(t, err, ErrNoMoreRows)
+		})
+	}
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestNowAndIntegersAsTimestamps(t *testing.T) {
+	catalogStore, err := store.Open("catalog_now_timestamp", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_now_timestamp")
+
+	dataStore, err := store.Open("sqldata_now_timestamp", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_now_timestamp")
+
+	now := time.Unix(1629902962, 0)
+
+	opts := DefaultOptions().
+		WithPrefix(sqlPrefix).
+		WithNowFunc(func() time.Time {
+			now = now.Add(time.Second)
+			return now
+		})
+
+	engine, err := NewEngine(catalogStore, dataStore, opts)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, ts TIMESTAMP, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, ts) VALUES (1, NOW())", nil, true)
+	require.NoError(t, err)
+
+	insertedAt := now.UnixNano()
+
+	// integer parameters hold unix nanos when assigned to timestamp columns e.g. values read through gRPC
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, ts) VALUES (2, @ts)", map[string]interface{}{"ts": now.Add(time.Hour).UnixNano()}, true)
+	require.NoError(t, err)
+
+	r, err := engine.QueryStmt("SELECT id, ts FROM table1 WHERE ts < NOW()", nil, true)
+	require.NoError(t, err)
+
+	row, err := r.Read()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+	require.Equal(t, TimestampType, row.Values[EncodeSelector("", "db1", "table1", "ts")].Type())
+	require.Equal(t, insertedAt, row.Values[EncodeSelector("", "db1", "table1", "ts")].Value())
+
+	_, err = r.Read()
+	require.ErrorIs(t, err, ErrNoMoreRows)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("UPDATE table1 SET ts = NOW() WHERE id = 2", nil, true)
+	require.NoError(t, err)
+
+	r, err = engine.QueryStmt("SELECT id, ts FROM table1 WHERE ts = @ts", map[string]interface{}{"ts": now.UnixNano()}, true)
+	require.NoError(t, err)
+
+	row, err = r.Read()
+	require.NoError(t, err)
+	require.Equal(t, int64(2), row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+	require.Equal(t, TimestampType, row.Values[EncodeSelector("", "db1", "table1", "ts")].Type())
+
+	_, err = r.Read()
+	require.ErrorIs(t, err, ErrNoMoreRows)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestCurrentTimestampColumns(t *testing.T) {
+	catalogStore, err := store.Open("catalog_current_timestamp", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_current_timestamp")
+
+	dataStore, err := store.Open("sqldata_current_timestamp", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_current_timestamp")
+
+	now := time.Unix(1629902962, 0)
+
+	opts := DefaultOptions().
+		WithPrefix(sqlPrefix).
+		WithNowFunc(func() time.Time {
+			now = now.Add(time.Second)
+			return now
+		})
+
+	engine, err := NewEngine(catalogStore, dataStore, opts)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table0 (id INTEGER, created INTEGER DEFAULT CURRENT_TIMESTAMP, PRIMARY KEY id)", nil, true)
+	require.ErrorIs(t, err, ErrCurrentTimestampWrongType)
+
+	_, err = engine.ExecStmt("CREATE TABLE table0 (id INTEGER, updated TIMESTAMP ON UPDATE CURRENT_TIMESTAMP, PRIMARY KEY (id, updated))", nil, true)
+	require.ErrorIs(t, err, ErrPKCanNotBeUpdated)
+
+	_, err = engine.ExecStmt(`
+		CREATE TABLE table1 (
+			id INTEGER,
+			title VARCHAR,
+			created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+			PRIMARY KEY id
+		)`, nil, true)
+	require.NoError(t, err)
+
+	// the catalog is reloaded so to ensure the column options are persisted
+	err = engine.Close()
+	require.NoError(t, err)
+
+	engine, err = NewEngine(catalogStore, dataStore, opts)
+	require.NoError(t, err)
+
+	err = engine.EnsureCatalogReady(nil)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	assertTimestamps := func(id int64, created, updated time.Time) {
+		r, err := engine.QueryStmt("SELECT created, updated FROM table1 WHERE id = @id", map[string]interface{}{"id": id}, true)
+		require.NoError(t, err)
+		defer r.Close()
+
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, &Timestamp{val: created.UnixNano()}, row.Values[EncodeSelector("", "db1", "table1", "created")])
+		require.Equal(t, &Timestamp{val: updated.UnixNano()}, row.Values[EncodeSelector("", "db1", "table1", "updated")])
+	}
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, title) VALUES (1, 'title1'), (2, 'title2')", nil, true)
+	require.NoError(t, err)
+
+	insertedAt := now
+
+	assertTimestamps(1, insertedAt, insertedAt)
+	assertTimestamps(2, insertedAt, insertedAt)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, title, created) VALUES (3, 'title3', DEFAULT)", nil, true)
+	require.NoError(t, err)
+
+	assertTimestamps(3, now, now)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, title, created) VALUES (4, 'title4', NULL)", nil, true)
+	require.ErrorIs(t, err, ErrNotNullableColumnCannotBeNull)
+
+	_, err = engine.ExecStmt("UPDATE table1 SET title = 'updated' WHERE id = 1", nil, true)
+	require.NoError(t, err)
+
+	updatedAt := now
+
+	assertTimestamps(1, insertedAt, updatedAt)
+	assertTimestamps(2, insertedAt, insertedAt)
+
+	// explicitly updated values are kept
+	_, err = engine.ExecStmt("UPDATE table1 SET updated = CAST(0 AS TIMESTAMP) WHERE id = 2", nil, true)
+	require.NoError(t, err)
+
+	assertTimestamps(2, insertedAt, time.Unix(0, 0))
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, title) VALUES (2, 'title2') ON CONFLICT DO UPDATE SET title = excluded.title", nil, true)
+	require.NoError(t, err)
+
+	assertTimestamps(2, insertedAt, now)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestCatalogUnchangedOnFailedCommit(t *testing.T) {
+	catalogStore, err := store.Open("catalog_failed_commit", store.DefaultOptions().WithMaxTxEntries(8))
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_failed_commit")
+
+	dataStore, err := store.Open("sqldata_failed_commit", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_failed_commit")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, title VARCHAR[32], PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (title) VALUES ('title1')", nil, true)
+	require.NoError(t, err)
+
+	// the entries of the new table exceed the limit of the catalog store
+	_, err = engine.ExecStmt(`
+		CREATE TABLE table2 (
+			id INTEGER,
+			c1 INTEGER, c2 INTEGER, c3 INTEGER, c4 INTEGER, c5 INTEGER, c6 INTEGER, c7 INTEGER,
+			PRIMARY KEY id
+		)`, nil, true)
+	require.ErrorIs(t, err, store.ErrorMaxTxEntriesLimitExceeded)
+
+	_, err = engine.ExecStmt(`
+		BEGIN TRANSACTION
+			INSERT INTO table1 (title) VALUES ('title2');
+			CREATE TABLE table3 (id INTEGER, PRIMARY KEY id);
+		COMMIT`, nil, true)
+	require.ErrorIs(t, err, ErrDDLorDMLTxOnly)
+
+	_, err = engine.ExecStmt(`
+		BEGIN TRANSACTION
+			TRUNCATE TABLE table1;
+			CREATE DATABASE db2;
+		COMMIT`, nil, true)
+	require.ErrorIs(t, err, ErrDDLorDMLTxOnly)
+
+	exists, err := engine.ExistDatabase("db2")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	_, err = engine.ExecAll([]SQLStmt{
+		&CreateIndexStmt{table: "table1", cols: []string{"title"}},
+		&CreateIndexStmt{table: "table4", cols: []string{"title"}},
+	}, nil, true)
+	require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+	catalog := engine.catalog
+
+	// only the auto-incremental counter is reverted when rows can not be inserted, the catalog is not replaced
+	_, err = engine.ExecStmt("INSERT INTO table1 (title) VALUES ('title2'), (@title)", map[string]interface{}{"title": strings.Repeat("x", 33)}, true)
+	require.ErrorIs(t, err, ErrMaxLengthExceeded)
+	require.Same(t, catalog, engine.catalog)
+
+	// the catalog remains ready and without the changes of the failed statements
+	db, err := engine.DatabaseInUse()
+	require.NoError(t, err)
+	require.Equal(t, []string{"table1"}, tableNames(db))
+
+	table1, err := engine.GetTableByName("db1", "table1")
+	require.NoError(t, err)
+	require.Len(t, table1.indexes, 1)
+	require.Equal(t, int64(1), table1.maxPK)
+
+	summary, err := engine.ExecStmt("INSERT INTO table1 (title) VALUES ('title2')", nil, true)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), summary.LastInsertedPKs["table1"])
+
+	_, err = engine.ExecStmt("CREATE TABLE table2 (id INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"table1", "table2"}, tableNames(db))
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func tableNames(db *Database) []string {
+	var names []string
+
+	for _, table := range db.GetTables() {
+		names = append(names, table.Name())
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func TestTxStmtAllOrNothing(t *testing.T) {
+	catalogStore, err := store.Open("catalog_tx_all_or_nothing", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_tx_all_or_nothing")
+
+	dataStore, err := store.Open("sqldata_tx_all_or_nothing", store.DefaultOptions().WithMaxTxEntries(5))
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_tx_all_or_nothing")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR[32], active BOOLEAN, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, title, active) VALUES (1, 'title1', true), (2, 'title2', false), (3, 'title3', true)", nil, true)
+	require.NoError(t, err)
+
+	countRows := func() int64 {
+		r, err := engine.QueryStmt("SELECT COUNT() FROM table1", nil, true)
+		require.NoError(t, err)
+		defer r.Close()
+
+		row, err := r.Read()
+		require.NoError(t, err)
+
+		return row.Values[EncodeSelector("", "db1", "table1", "count(*)")].Value().(int64)
+	}
+
+	t.Run("rows inserted before a failing statement are not committed", func(t *testing.T) {
+		_, err = engine.ExecStmt(`
+			BEGIN TRANSACTION
+				INSERT INTO table1 (id, title) VALUES (4, 'title4');
+				INSERT INTO table1 (id, title) VALUES (5, 5);
+			COMMIT`, nil, true)
+		require.ErrorIs(t, err, ErrInvalidValue)
+
+		require.Equal(t, int64(3), countRows())
+	})
+
+	t.Run("tables created before a failing statement are not committed", func(t *testing.T) {
+		_, err = engine.ExecStmt(`
+			BEGIN TRANSACTION
+				CREATE TABLE table2 (id INTEGER, PRIMARY KEY id);
+				CREATE INDEX ON table3 (id);
+			COMMIT`, nil, true)
+		require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+		db, err := engine.DatabaseInUse()
+		require.NoError(t, err)
+		require.False(t, db.ExistTable("table2"))
+	})
+
+	t.Run("databases created before a failing statement are not committed", func(t *testing.T) {
+		_, err = engine.ExecStmt(`
+			BEGIN TRANSACTION
+				CREATE DATABASE db2;
+				CREATE INDEX ON table3 (id);
+			COMMIT`, nil, true)
+		require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+		exists, err := engine.ExistDatabase("db2")
+		require.NoError(t, err)
+		require.False(t, exists)
+	})
+
+	t.Run("columns added before a failing statement are not committed", func(t *testing.T) {
+		_, err = engine.ExecStmt(`
+			BEGIN TRANSACTION
+				CREATE TABLE table2 (id INTEGER, PRIMARY KEY id);
+				ALTER TABLE table2 ADD COLUMN title VARCHAR;
+			COMMIT`, nil, true)
+		require.ErrorIs(t, err, ErrNoSupported)
+
+		db, err := engine.DatabaseInUse()
+		require.NoError(t, err)
+		require.False(t, db.ExistTable("table2"))
+
+		table, err := engine.GetTableByName("db1", "table1")
+		require.NoError(t, err)
+		require.Len(t, table.Cols(), 3)
+	})
+
+	t.Run("tables truncated before a failing statement keep their rows and counter", func(t *testing.T) {
+		_, err = engine.ExecStmt(`
+			CREATE TABLE table4 (id INTEGER AUTO_INCREMENT, title VARCHAR[32], PRIMARY KEY id);
+			INSERT INTO table4 (title) VALUES ('title1'), ('title2');
+		`, nil, true)
+		require.NoError(t, err)
+
+		_, err = engine.ExecStmt(`
+			BEGIN TRANSACTION
+				TRUNCATE TABLE table4;
+				INSERT INTO table1 (id, title) VALUES (5, 5);
+			COMMIT`, nil, true)
+		require.ErrorIs(t, err, ErrInvalidValue)
+
+		table, err := engine.GetTableByName("db1", "table4")
+		require.NoError(t, err)
+		require.Equal(t, int64(2), table.maxPK)
+
+		r, err := engine.QueryStmt("SELECT COUNT() FROM table4", nil, true)
+		require.NoError(t, err)
+
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, int64(2), row.Values[EncodeSelector("", "db1", "table4", "count(*)")].Value())
+
+		err = r.Close()
+		require.NoError(t, err)
+
+		summary, err := engine.ExecStmt("INSERT INTO table4 (title) VALUES ('title3')", nil, true)
+		require.NoError(t, err)
+		require.Equal(t, int64(3), summary.LastInsertedPKs["table4"])
+	})
+
+	t.Run("catalog entries are not committed when index entries exceed the limit", func(t *testing.T) {
+		// each index fits into a transaction but both of them do not
+		_, err = engine.ExecStmt(`
+			BEGIN TRANSACTION
+				CREATE INDEX ON table1 (title);
+				CREATE INDEX ON table1 (active);
+			COMMIT`, nil, true)
+		require.ErrorIs(t, err, store.ErrorMaxTxEntriesLimitExceeded)
+
+		err = engine.ReloadCatalog(nil)
+		require.NoError(t, err)
+
+		table, err := engine.GetTableByName("db1", "table1")
+		require.NoError(t, err)
+		require.Len(t, table.indexes, 1)
+	})
+
+	_, err = engine.ExecStmt(`
+		BEGIN TRANSACTION
+			INSERT INTO table1 (id, title) VALUES (4, 'title4');
+			CREATE TABLE table2 (id INTEGER, PRIMARY KEY id);
+		COMMIT`, nil, true)
+	require.ErrorIs(t, err, ErrDDLorDMLTxOnly)
+
+	require.Equal(t, int64(3), countRows())
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestSavepoints(t *testing.T) {
+	catalogStore, err := store.Open("catalog_savepoints", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_savepoints")
+
+	dataStore, err := store.Open("sqldata_savepoints", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_savepoints")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER AUTO_INCREMENT, title VARCHAR[32], PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	titles := func() []string {
+		r, err := engine.QueryStmt("SELECT title FROM table1", nil, true)
+		require.NoError(t, err)
+		defer r.Close()
+
+		var titles []string
+
+		for {
+			row, err := r.Read()
+			if err == ErrNoMoreRows {
+				break
+			}
+			require.NoError(t, err)
+
+			titles = append(titles, row.Values[EncodeSelector("", "db1", "table1", "title")].Value().(string))
+		}
+
+		return titles
+	}
+
+	t.Run("only the changes made after the savepoint are rolled back", func(t *testing.T) {
+		summary, err := engine.ExecStmt(`
+			BEGIN TRANSACTION
+				INSERT INTO table1 (title) VALUES ('title1');
+				SAVEPOINT sp1;
+				INSERT INTO table1 (title) VALUES ('discarded');
+				UPDATE table1 SET title = 'discarded';
+				ROLLBACK TO SAVEPOINT sp1;
+				INSERT INTO table1 (title) VALUES ('title2');
+			COMMIT`, nil, true)
+		require.NoError(t, err)
+		require.Equal(t, 2, summary.UpdatedRows)
+		require.Equal(t, int64(2), summary.LastInsertedPKs["table1"])
+
+		require.Equal(t, []string{"title1", "title2"}, titles())
+	})
+
+	t.Run("a savepoint can be rolled back to more than once", func(t *testing.T) {
+		_, err := engine.ExecStmt(`
+			BEGIN TRANSACTION
+				SAVEPOINT sp1;
+				INSERT INTO table1 (title) VALUES ('discarded');
+				ROLLBACK TO SAVEPOINT sp1;
+				INSERT INTO table1 (title) VALUES ('discarded');
+				ROLLBACK TO SAVEPOINT sp1;
+				INSERT INTO table1 (title) VALUES ('title3');
+			COMMIT`, nil, true)
+		require.NoError(t, err)
+
+		require.Equal(t, []string{"title1", "title2", "title3"}, titles())
+	})
+
+	t.Run("savepoints established after the one rolled back to are discarded", func(t *testing.T) {
+		_, err := engine.ExecStmt(`
+			BEGIN TRANSACTION
+				SAVEPOINT sp1;
+				INSERT INTO table1 (title) VALUES ('discarded');
+				SAVEPOINT sp2;
+				ROLLBACK TO SAVEPOINT sp1;
+				ROLLBACK TO SAVEPOINT sp2;
+			COMMIT`, nil, true)
+		require.ErrorIs(t, err, ErrSavepointDoesNotExist)
+
+		require.Equal(t, []string{"title1", "title2", "title3"}, titles())
+	})
+
+	t.Run("released savepoints keep their changes", func(t *testing.T) {
+		_, err := engine.ExecStmt(`
+			BEGIN TRANSACTION
+				SAVEPOINT sp1;
+				INSERT INTO table1 (title) VALUES ('title4');
+				RELEASE SAVEPOINT sp1;
+			COMMIT`, nil, true)
+		require.NoError(t, err)
+
+		_, err = engine.ExecStmt(`
+			BEGIN TRANSACTION
+				SAVEPOINT sp1;
+				INSERT INTO table1 (title) VALUES ('discarded');
+				RELEASE SAVEPOINT sp1;
+				ROLLBACK TO SAVEPOINT sp1;
+			COMMIT`, nil, true)
+		require.ErrorIs(t, err, ErrSavepointDoesNotExist)
+
+		require.Equal(t, []string{"title1", "title2", "title3", "title4"}, titles())
+	})
+
+	t.Run("catalog changes made after the savepoint are rolled back", func(t *testing.T) {
+		_, err := engine.ExecStmt(`
+			BEGIN TRANSACTION
+				CREATE TABLE table2 (id INTEGER, PRIMARY KEY id);
+				SAVEPOINT sp1;
+				CREATE TABLE table3 (id INTEGER, PRIMARY KEY id);
+				CREATE INDEX ON table1 (title);
+				ROLLBACK TO SAVEPOINT sp1;
+				CREATE TABLE table4 (id INTEGER, PRIMARY KEY id);
+			COMMIT`, nil, true)
+		require.NoError(t, err)
+
+		checkCatalog := func() {
+			db, err := engine.DatabaseInUse()
+			require.NoError(t, err)
+			require.Equal(t, []string{"table1", "table2", "table4"}, tableNames(db))
+
+			table, err := db.GetTableByName("table1")
+			require.NoError(t, err)
+			require.Len(t, table.indexes, 1)
+		}
+
+		checkCatalog()
+
+		err = engine.ReloadCatalog(nil)
+		require.NoError(t, err)
+
+		checkCatalog()
+	})
+
+	_, err = engine.ExecPreparedStmts([]SQLStmt{&SavepointStmt{name: "sp1"}}, nil, true)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = engine.ExecPreparedStmts([]SQLStmt{&RollbackToSavepointStmt{name: "sp1"}}, nil, true)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = engine.ExecPreparedStmts([]SQLStmt{&ReleaseSavepointStmt{name: "sp1"}}, nil, true)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestQueryAll(t *testing.T) {
+	catalogStore, err := store.Open("catalog_query_all", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_query_all")
+
+	dataStore, err := store.Open("sqldata_query_all", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_query_all")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix).WithMaxRowsPerQuery(5))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, amount INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	rowCount := 10
+
+	for i := 0; i < rowCount; i++ {
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, amount) VALUES (@id, @amount)", map[string]interface{}{"id": i, "amount": rowCount - i}, true)
+		require.NoError(t, err)
+	}
+
+	_, err = engine.QueryAll("SELECT id FROM table2", nil)
+	require.ErrorIs(t, err, ErrTableDoesNotExist)
+
+	t.Run("all rows are returned in order", func(t *testing.T) {
+		rows, err := engine.QueryAll("SELECT id, amount FROM table1 WHERE id >= 5 ORDER BY id DESC", nil)
+		require.NoError(t, err)
+		require.Len(t, rows, 5)
+
+		for i, row := range rows {
+			id := int64(rowCount - 1 - i)
+			require.Equal(t, id, row.Values[EncodeSelector("", "db1", "table1", "id")].Value())
+			require.Equal(t, int64(rowCount)-id, row.Values[EncodeSelector("", "db1", "table1", "amount")].Value())
+		}
+	})
+
+	t.Run("an empty result returns no rows", func(t *testing.T) {
+		rows, err := engine.QueryAll("SELECT id FROM table1 WHERE id > @id", map[string]interface{}{"id": rowCount})
+		require.NoError(t, err)
+		require.Empty(t, rows)
+	})
+
+	t.Run("exceeding the max rows per query fails", func(t *testing.T) {
+		_, err := engine.QueryAll("SELECT id FROM table1", nil)
+		require.ErrorIs(t, err, ErrTooManyRows)
+
+		rows, err := engine.QueryAll("SELECT id FROM table1 LIMIT 5", nil)
+		require.NoError(t, err)
+		require.Len(t, rows, 5)
+	})
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestExecCount(t *testing.T) {
+	catalogStore, err := store.Open("catalog_exec_count", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_exec_count")
+
+	dataStore, err := store.Open("sqldata_exec_count", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_exec_count")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecCount("INSERT INTO table1 (id) VALUES (1)", nil, true)
+	require.ErrorIs(t, err, ErrNoDatabaseSelected)
+
+	res, err := engine.ExecCount("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+	require.Zero(t, res.RowsAffected())
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, amount INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	res, err = engine.ExecCount("UPSERT INTO table1 (id, amount) VALUES (1, 10), (2, 20), (3, 30)", nil, true)
+	require.NoError(t, err)
+	require.Equal(t, 3, res.RowsAffected())
+
+	res, err = engine.ExecCount("UPSERT INTO table1 (id, amount) VALUES (3, 300), (4, 40)", nil, true)
+	require.NoError(t, err)
+	require.Equal(t, 2, res.RowsAffected())
+
+	res, err = engine.ExecCount("DELETE FROM table1 WHERE amount > @amount", map[string]interface{}{"amount": 25}, true)
+	require.NoError(t, err)
+	require.Equal(t, 2, res.RowsAffected())
+
+	res, err = engine.ExecCount("DELETE FROM table1 WHERE amount > @amount", map[string]interface{}{"amount": 25}, true)
+	require.NoError(t, err)
+	require.Zero(t, res.RowsAffected())
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestTwoSidedRangeOnIndexedColumn(t *testing.T) {
+	catalogStore, err := store.Open("catalog_two_sided_range", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_two_sided_range")
+
+	dataStore, err := store.Open("sqldata_two_sided_range", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_two_sided_range")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, a INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(a)", nil, true)
+	require.NoError(t, err)
+
+	for i := 0; i <= 12; i++ {
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, a) VALUES (@id, @id)", map[string]interface{}{"id": i}, true)
+		require.NoError(t, err)
+	}
+
+	testCases := []struct {
+		where      string
+		lower      int64
+		lInclusive bool
+		upper      int64
+		hInclusive bool
+		count      int64
+	}{
+		{"a >= 1 AND a <= 10", 1, true, 10, true, 10},
+		{"a <= 10 AND a >= 1", 1, true, 10, true, 10},
+		{"a >= 1 AND id > 0 AND a <= 10", 1, true, 10, true, 10},
+		{"a >= 1 AND a <= 10 AND a > 0 AND a < 11", 1, true, 10, true, 10},
+		{"a > 0 AND a < 11 AND a >= 1 AND a <= 10", 1, true, 10, true, 10},
+		{"a >= 1 AND a > 1 AND a <= 10", 1, false, 10, true, 9},
+		{"a >= 1 AND (a <= 10 OR a <= 5)", 1, true, 10, true, 10},
+		{"(a <= 10 OR a <= 5) AND a >= 1", 1, true, 10, true, 10},
+		{"a >= 1 AND (a <= 10 OR a < 10)", 1, true, 10, true, 10},
+		{"a >= 1 AND (a < 10 OR a < 10)", 1, true, 10, false, 9},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.where, func(t *testing.T) {
+			r, err := engine.QueryStmt("SELECT COUNT() AS c FROM table1 WHERE "+tc.where, nil, true)
+			require.NoError(t, err)
+
+			scanSpecs := r.ScanSpecs()
+			require.NotNil(t, scanSpecs)
+			require.Len(t, scanSpecs.index.cols, 1)
+			require.Equal(t, "a", scanSpecs.index.cols[0].colName)
+
+			aRange := scanSpecs.rangesByColID[2]
+			require.NotNil(t, aRange)
+			require.NotNil(t, aRange.lRange)
+			require.Equal(t, tc.lower, aRange.lRange.val.Value())
+			require.Equal(t, tc.lInclusive, aRange.lRange.inclusive)
+			require.NotNil(t, aRange.hRange)
+			require.Equal(t, tc.upper, aRange.hRange.val.Value())
+			require.Equal(t, tc.hInclusive, aRange.hRange.inclusive)
+
+			row, err := r.Read()
+			require.NoError(t, err)
+			require.Equal(t, tc.count, row.Values[EncodeSelector("", "db1", "table1", "c")].Value())
+
+			err = r.Close()
+			require.NoError(t, err)
+		})
+	}
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
+
+func TestQueryComparingColumns(t *testing.T) {
+	catalogStore, err := store.Open("catalog_cmp_columns", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_cmp_columns")
+
+	dataStore, err := store.Open("sqldata_cmp_columns", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_cmp_columns")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, col1 INTEGER, col2 INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(col1)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table2 (id INTEGER, val INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table1 (id, col1, col2) VALUES (1, 1, 2), (2, 3, 2), (3, 5, 5), (4, 7, NULL), (5, 9, 1)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("INSERT INTO table2 (id, val) VALUES (1, 3), (2, 5), (3, 8)", nil, true)
+	require.NoError(t, err)
+
+	ids := func(rows []*Row, col string) []int64 {
+		var ids []int64
+		for _, row := range rows {
+			ids = append(ids, row.Values[col].Value().(int64))
+		}
+		return ids
+	}
+
+	t.Run("comparing two columns of the same table", func(t *testing.T) {
+		testCases := []struct {
+			where    string
+			ranged   bool
+			expected []int64
+		}{
+			{"col1 > col2", false, []int64{2, 5}},
+			{"col2 < col1", false, []int64{2, 5}},
+			{"col1 >= col2", false, []int64{2, 3, 5}},
+			{"col1 = col2", false, []int64{3}},
+			{"col1 != col2", false, []int64{1, 2, 5}},
+			{"col1 > col2 AND col1 > 3", true, []int64{5}},
+		}
+
+		for _, tc := range testCases {
+			r, err := engine.QueryStmt("SELECT id FROM table1 WHERE "+tc.where, nil, true)
+			require.NoError(t, err)
+
+			// no range can be derived from the comparison of two columns
+			_, ranged := r.ScanSpecs().rangesByColID[2]
+			require.Equal(t, tc.ranged, ranged, tc.where)
+
+			err = r.Close()
+			require.NoError(t, err)
+
+			rows, err := engine.QueryAll("SELECT id FROM table1 WHERE "+tc.where, nil)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, ids(rows, EncodeSelector("", "db1", "table1", "id")), tc.where)
+		}
+	})
+
+	t.Run("comparing columns of different tables", func(t *testing.T) {
+		rows, err := engine.QueryAll(`
+			SELECT t1.id, t2.id AS id2
+			FROM table1 AS t1
+			INNER JOIN table2 AS t2 ON t2.val > 0
+			WHERE t1.col1 = t2.val`, nil)
+		require.NoError(t, err)
+		require.Equal(t, []int64{2, 3}, ids(rows, EncodeSelector("", "db1", "t1", "id")))
+		require.Equal(t, []int64{1, 2}, ids(rows, EncodeSelector("", "db1", "t2", "id2")))
+	})
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
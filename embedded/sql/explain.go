@@ -0,0 +1,247 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sql
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// QueryPlan describes how a select statement is going to be resolved
+type QueryPlan struct {
+	// Table is empty when the data source is not a table e.g. a subquery or the values of a selection without one
+	Table string
+	// Index used to scan the table, empty when the data source is not a table
+	Index string
+	// Desc is set when the index is scanned in descending order
+	Desc bool
+	// IndexOnly is set when rows are decoded from the secondary index without fetching them
+	IndexOnly bool
+	// Ranges inferred from the where clause, one per constrained column
+	Ranges []string
+	// Readers stacked to resolve the query, starting from the one reading the data source
+	Readers []string
+}
+
+func (p *QueryPlan) String() string {
+	var b strings.Builder
+
+	if p.Table == "" {
+		fmt.Fprintf(&b, "SCAN %s", p.Readers[0])
+	} else {
+		order := "ASC"
+		if p.Desc {
+			order = "DESC"
+		}
+
+		fmt.Fprintf(&b, "SCAN %s USING %s %s", p.Table, p.Index, order)
+
+		if p.IndexOnly {
+			b.WriteString(" INDEX ONLY")
+		}
+	}
+
+	for _, r := range p.Ranges {
+		fmt.Fprintf(&b, "\n  RANGE %s", r)
+	}
+
+	fmt.Fprintf(&b, "\nREADERS %s", strings.Join(p.Readers, " -> "))
+
+	return b.String()
+}
+
+// Explain returns the plan of a select statement without reading any data,
+// ranges and the chosen index depend on the values of the parameters
+func (e *Engine) Explain(stmt *SelectStmt, params map[string]interface{}) (*QueryPlan, error) {
+	if stmt == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	if e.closed {
+		return nil, ErrAlreadyClosed
+	}
+
+	if e.catalog == nil {
+		return nil, ErrCatalogNotReady
+	}
+
+	implicitDB, err := e.databaseInUse()
+	if err != nil {
+		return nil, err
+	}
+
+	nparams, err := e.normalizeParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = stmt.compileUsing(e, implicitDB, nparams)
+	if err != nil {
+		return nil, err
+	}
+
+	// selections without a data source are resolved as a single row of values
+	if stmt.ds == nil {
+		return &QueryPlan{Readers: []string{"values"}}, nil
+	}
+
+	scanSpecs, err := stmt.genScanSpecs(e, nil, implicitDB, nparams)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &QueryPlan{}
+
+	if scanSpecs == nil {
+		plan.Readers = append(plan.Readers, "subquery")
+	} else {
+		index := scanSpecs.index
+
+		plan.Table = fmt.Sprintf("%s.%s", index.table.db.name, index.table.name)
+		plan.Index = describeIndex(index)
+		plan.Desc = scanSpecs.descOrder
+		plan.IndexOnly = scanSpecs.indexOnly
+
+		colIDs := make([]int, 0, len(scanSpecs.rangesByColID))
+		for colID := range scanSpecs.rangesByColID {
+			colIDs = append(colIDs, int(colID))
+		}
+		sort.Ints(colIDs)
+
+		for _, colID := range colIDs {
+			col, err := index.table.GetColumnByID(uint32(colID))
+			if err != nil {
+				return nil, err
+			}
+
+			plan.Ranges = append(plan.Ranges, describeRange(col.colName, scanSpecs.rangesByColID[uint32(colID)]))
+		}
+
+		plan.Readers = append(plan.Readers, "raw")
+	}
+
+	for _, join := range stmt.joins {
+		plan.Readers = append(plan.Readers, fmt.Sprintf("joint (%s)", describeJoinType(join.joinType)))
+	}
+
+	if stmt.where != nil {
+		plan.Readers = append(plan.Readers, "conditional")
+	}
+
+	sortsInMemory, err := stmt.sortsInMemory(scanSpecs)
+	if err != nil {
+		return nil, err
+	}
+
+	if sortsInMemory {
+		plan.Readers = append(plan.Readers, "sort")
+	}
+
+	if stmt.isGrouped() {
+		plan.Readers = append(plan.Readers, "grouped")
+
+		if stmt.having != nil {
+			plan.Readers = append(plan.Readers, "conditional")
+		}
+	}
+
+	plan.Readers = append(plan.Readers, "projected")
+
+	if stmt.distinct {
+		plan.Readers = append(plan.Readers, "distinct")
+	}
+
+	if stmt.offset > 0 {
+		plan.Readers = append(plan.Readers, "offset")
+	}
+
+	if stmt.limit > 0 {
+		plan.Readers = append(plan.Readers, "limit")
+	}
+
+	return plan, nil
+}
+
+func describeJoinType(joinType JoinType) string {
+	switch joinType {
+	case LeftJoin:
+		return "LEFT JOIN"
+	case RightJoin:
+		return "RIGHT JOIN"
+	}
+
+	return "INNER JOIN"
+}
+
+func describeIndex(index *Index) string {
+	cols := make([]string, len(index.cols))
+	for i, col := range index.cols {
+		cols[i] = col.colName
+	}
+
+	if index.IsPrimary() {
+		return fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(cols, ", "))
+	}
+
+	if index.IsUnique() {
+		return fmt.Sprintf("UNIQUE INDEX ON (%s)", strings.Join(cols, ", "))
+	}
+
+	return fmt.Sprintf("INDEX ON (%s)", strings.Join(cols, ", "))
+}
+
+func describeRange(colName string, r *typedValueRange) string {
+	if r.unitary() {
+		return fmt.Sprintf("%s = %s", colName, describeValue(r.lRange.val))
+	}
+
+	var bounds []string
+
+	if r.lRange != nil {
+		op := ">"
+		if r.lRange.inclusive {
+			op = ">="
+		}
+
+		bounds = append(bounds, fmt.Sprintf("%s %s %s", colName, op, describeValue(r.lRange.val)))
+	}
+
+	if r.hRange != nil {
+		op := "<"
+		if r.hRange.inclusive {
+			op = "<="
+		}
+
+		bounds = append(bounds, fmt.Sprintf("%s %s %s", colName, op, describeValue(r.hRange.val)))
+	}
+
+	return strings.Join(bounds, " AND ")
+}
+
+func describeValue(v TypedValue) string {
+	switch v.Type() {
+	case VarcharType:
+		return fmt.Sprintf("'%s'", v.Value())
+	case BLOBType:
+		return fmt.Sprintf("x'%x'", v.Value())
+	}
+
+	return fmt.Sprintf("%v", v.Value())
+}
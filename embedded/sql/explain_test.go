@@ -0,0 +1,246 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sql
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplain(t *testing.T) {
+	catalogStore, err := store.Open("catalog_explain", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_explain")
+
+	dataStore, err := store.Open("sqldata_explain", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_explain")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.Explain(nil, nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	explainWithParams := func(sql string, params map[string]interface{}) (*QueryPlan, error) {
+		stmts, err := ParseString(sql)
+		require.NoError(t, err)
+		require.Len(t, stmts, 1)
+
+		return engine.Explain(stmts[0].(*SelectStmt), params)
+	}
+
+	explain := func(sql string) (*QueryPlan, error) {
+		return explainWithParams(sql, nil)
+	}
+
+	_, err = explain("SELECT id FROM table1")
+	require.ErrorIs(t, err, ErrCatalogNotReady)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	_, err = explain("SELECT id FROM table1")
+	require.ErrorIs(t, err, ErrNoDatabaseSelected)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR[50], amount INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE INDEX ON table1(title)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table2 (id INTEGER, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	t.Run("unindexed filter scans the primary index", func(t *testing.T) {
+		plan, err := explain("SELECT id, title FROM table1 WHERE amount > 10")
+		require.NoError(t, err)
+
+		require.Equal(t, "db1.table1", plan.Table)
+		require.Equal(t, "PRIMARY KEY (id)", plan.Index)
+		require.False(t, plan.Desc)
+		require.Equal(t, []string{"amount > 10"}, plan.Ranges)
+		require.Equal(t, []string{"raw", "conditional", "projected"}, plan.Readers)
+
+		require.Equal(t,
+			"SCAN db1.table1 USING PRIMARY KEY (id) ASC\n"+
+				"  RANGE amount > 10\n"+
+				"READERS raw -> conditional -> projected",
+			plan.String())
+	})
+
+	t.Run("indexed equality scans the secondary index", func(t *testing.T) {
+		plan, err := explain("SELECT id FROM table1 USE INDEX ON (title) WHERE title = 'title1' AND id >= 1 AND id < 10")
+		require.NoError(t, err)
+
+		require.Equal(t, "INDEX ON (title)", plan.Index)
+		require.Equal(t, []string{"id >= 1 AND id < 10", "title = 'title1'"}, plan.Ranges)
+
+		plan, err = explain("SELECT DISTINCT id FROM table1 WHERE title = 'title1' ORDER BY title DESC LIMIT 10 OFFSET 5")
+		require.NoError(t, err)
+
+		require.Equal(t, "INDEX ON (title)", plan.Index)
+		require.True(t, plan.Desc)
+		require.Equal(t, []string{"title = 'title1'"}, plan.Ranges)
+		require.Equal(t, []string{"raw", "conditional", "projected", "distinct", "offset", "limit"}, plan.Readers)
+		require.Contains(t, plan.String(), "USING INDEX ON (title) DESC")
+	})
+
+	t.Run("parameters are substituted before inferring ranges", func(t *testing.T) {
+		plan, err := explainWithParams("SELECT id FROM table1 WHERE title = @title AND id >= @minID", map[string]interface{}{"title": "title1", "MinID": 1})
+		require.NoError(t, err)
+
+		require.Equal(t, "INDEX ON (title)", plan.Index)
+		require.Equal(t, []string{"id >= 1", "title = 'title1'"}, plan.Ranges)
+	})
+
+	t.Run("covering queries scan the index only", func(t *testing.T) {
+		plan, err := explain("SELECT id, title FROM table1 WHERE title = 'title1'")
+		require.NoError(t, err)
+		require.True(t, plan.IndexOnly)
+		require.Equal(t,
+			"SCAN db1.table1 USING INDEX ON (title) ASC INDEX ONLY\n"+
+				"  RANGE title = 'title1'\n"+
+				"READERS raw -> conditional -> projected",
+			plan.String())
+
+		for _, sql := range []string{
+			"SELECT id, amount FROM table1 WHERE title = 'title1'",
+			"SELECT * FROM table1 WHERE title = 'title1'",
+			"SELECT id, amount FROM table1 WHERE id > 10",
+			"SELECT _tx FROM table1 WHERE title = 'title1'",
+		} {
+			plan, err := explain(sql)
+			require.NoError(t, err)
+			require.False(t, plan.IndexOnly, sql)
+		}
+	})
+
+	t.Run("stacked readers of joins and aggregations", func(t *testing.T) {
+		plan, err := explain("SELECT COUNT() AS c FROM table1 LEFT JOIN table2 ON table1.amount = table2.id GROUP BY id HAVING COUNT() > 0")
+		require.NoError(t, err)
+		require.Empty(t, plan.Ranges)
+		require.Equal(t, []string{"raw", "joint (LEFT JOIN)", "grouped", "conditional", "projected"}, plan.Readers)
+
+		plan, err = explain("SELECT id FROM (SELECT id FROM table1)")
+		require.NoError(t, err)
+		require.Empty(t, plan.Table)
+		require.Equal(t, []string{"subquery", "projected"}, plan.Readers)
+		require.Equal(t, "SCAN subquery\nREADERS subquery -> projected", plan.String())
+	})
+
+	t.Run("plans match the resolved readers", func(t *testing.T) {
+		_, err := engine.ExecStmt("INSERT INTO table1 (id, title, amount) VALUES (1, 'title1', 10)", nil, true)
+		require.NoError(t, err)
+
+		for _, tc := range []struct {
+			sql     string
+			readers []string
+		}{
+			{"SELECT id FROM table1 GROUP BY id", []string{"raw", "grouped", "projected"}},
+			{"SELECT id FROM table1 GROUP BY id HAVING MAX(amount) > 0", []string{"raw", "grouped", "conditional", "projected"}},
+			{"SELECT id, MAX(amount) FROM table1 WHERE amount > 0 GROUP BY id", []string{"raw", "conditional", "grouped", "projected"}},
+			{"SELECT id FROM table1 ORDER BY amount DESC LIMIT 1", []string{"raw", "sort", "projected", "limit"}},
+			{"SELECT table1.id FROM table1 INNER JOIN table2 ON table1.amount = table2.id WHERE table1.id > 0", []string{"raw", "joint (INNER JOIN)", "conditional", "projected"}},
+			{"SELECT 1 + 2", []string{"values"}},
+			{"SELECT DISTINCT 1 AS one", []string{"values"}},
+		} {
+			plan, err := explain(tc.sql)
+			require.NoError(t, err)
+			require.Equal(t, tc.readers, plan.Readers, tc.sql)
+
+			r, err := engine.QueryStmt(tc.sql, nil, true)
+			require.NoError(t, err)
+			require.Equal(t, plan.Readers, resolvedReaders(r), tc.sql)
+
+			err = r.Close()
+			require.NoError(t, err)
+		}
+
+		plan, err := explain("SELECT id FROM table1 ORDER BY amount DESC")
+		require.NoError(t, err)
+		require.Equal(t, "PRIMARY KEY (id)", plan.Index)
+		require.False(t, plan.Desc)
+
+		plan, err = explain("SELECT 1 + 2")
+		require.NoError(t, err)
+		require.Empty(t, plan.Table)
+		require.Equal(t, "SCAN values\nREADERS values", plan.String())
+	})
+
+	t.Run("invalid statements", func(t *testing.T) {
+		_, err := explain("SELECT COUNT() FROM table1 ORDER BY amount")
+		require.ErrorIs(t, err, ErrLimitedOrderBy)
+
+		_, err = explain("SELECT id FROM table3")
+		require.ErrorIs(t, err, ErrTableDoesNotExist)
+	})
+
+	err = engine.Close()
+	require.NoError(t, err)
+
+	_, err = explain("SELECT id FROM table1")
+	require.ErrorIs(t, err, ErrAlreadyClosed)
+}
+
+// resolvedReaders names the readers stacked by the engine to resolve a query, as they are named in query plans
+func resolvedReaders(r RowReader) []string {
+	var readers []string
+
+	for r != nil {
+		var name string
+
+		switch rr := r.(type) {
+		case *rawRowReader:
+			name, r = "raw", nil
+		case *valuesRowReader:
+			name, r = "values", nil
+		case *jointRowReader:
+			for i := len(rr.joins) - 1; i >= 0; i-- {
+				readers = append([]string{fmt.Sprintf("joint (%s)", describeJoinType(rr.joins[i].joinType))}, readers...)
+			}
+			r = rr.rowReader
+			continue
+		case *conditionalRowReader:
+			name, r = "conditional", rr.rowReader
+		case *sortRowReader:
+			name, r = "sort", rr.rowReader
+		case *groupedRowReader:
+			name, r = "grouped", rr.rowReader
+		case *projectedRowReader:
+			name, r = "projected", rr.rowReader
+		case *distinctRowReader:
+			name, r = "distinct", rr.rowReader
+		case *offsetRowReader:
+			name, r = "offset", rr.rowReader
+		case *limitRowReader:
+			name, r = "limit", rr.rowReader
+		default:
+			name, r = fmt.Sprintf("%T", rr), nil
+		}
+
+		readers = append([]string{name}, readers...)
+	}
+
+	return readers
+}
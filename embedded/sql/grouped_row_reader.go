@@ -15,7 +15,11 @@ limitations under the License.
 */
 package sql
 
-import "github.com/codenotary/immudb/embedded/store"
+import (
+	"fmt"
+
+	"github.com/codenotary/immudb/embedded/store"
+)
 
 type groupedRowReader struct {
 	e *Engine
@@ -41,6 +45,37 @@ func (e *Engine) newGroupedRowReader(rowReader RowReader, selectors []Selector,
 		return nil, ErrLimitedGroupBy
 	}
 
+	cols, err := rowReader.colsBySelector()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, sel := range selectors {
+		_, isExp := sel.(*expSelector)
+		if isExp {
+			return nil, fmt.Errorf("%w: expressions can not be selected when grouping rows", ErrNoSupported)
+		}
+
+		aggSel, isAggregation := sel.(*AggColSelector)
+		if isAggregation && aggSel.exp != nil {
+			// aggregated expressions are reduced for every row, thus they are validated in advance
+			_, err := aggSel.inferType(cols, map[string]SQLValueType{}, rowReader.ImplicitDB(), rowReader.ImplicitTable())
+			if err != nil {
+				return nil, err
+			}
+		}
+		if isAggregation {
+			continue
+		}
+
+		aggFn, db, table, col := sel.resolve(rowReader.ImplicitDB(), rowReader.ImplicitTable())
+		encSel := EncodeSelector(aggFn, db, table, col)
+
+		if len(groupBy) == 0 || encSel != EncodeSelector(groupBy[0].resolve(rowReader.ImplicitDB(), rowReader.ImplicitTable())) {
+			return nil, fmt.Errorf("%w: %s.%s.%s", ErrColumnNotGrouped, db, table, col)
+		}
+	}
+
 	return &groupedRowReader{
 		e:         e,
 		rowReader: rowReader,
@@ -110,6 +145,17 @@ func (gr *groupedRowReader) colsBySelector() (map[string]ColDescriptor, error) {
 		}
 
 		colDesc, ok := colDescriptors[EncodeSelector("", db, table, col)]
+
+		aggSel, isAggregation := sel.(*AggColSelector)
+		if isAggregation && aggSel.exp != nil {
+			t, err := aggSel.inferType(colDescriptors, map[string]SQLValueType{}, gr.rowReader.ImplicitDB(), gr.rowReader.ImplicitTable())
+			if err != nil {
+				return nil, err
+			}
+
+			colDesc, ok = ColDescriptor{Database: db, Table: table, Column: col, Type: t}, true
+		}
+
 		if !ok {
 			return nil, ErrColumnDoesNotExist
 		}
@@ -153,10 +199,10 @@ func zeroForType(t SQLValueType) TypedValue {
 		{
 			return &Blob{}
 		}
-		/*case TimestampType:
+	case TimestampType:
 		{
-			return &Number{}
-		}*/
+			return &Timestamp{}
+		}
 	}
 	return nil
 }
@@ -173,8 +219,9 @@ func (gr *groupedRowReader) Read() (*Row, error) {
 	for {
 		row, err := gr.rowReader.Read()
 		if err == store.ErrNoMoreEntries {
-			if !gr.nonEmpty && allAgregations(gr.selectors) {
-				// special case when all selectors are aggregations
+			if !gr.nonEmpty && len(gr.groupBy) == 0 && allAgregations(gr.selectors) {
+				// special case when all selectors are aggregations, without grouping a single row is returned
+				// even if there are no rows to aggregate, grouping empty rows returns no groups instead
 				zeroRow := &Row{Values: make(map[string]TypedValue, len(gr.selectors))}
 
 				colsBySelector, err := gr.colsBySelector()
@@ -216,6 +263,11 @@ func (gr *groupedRowReader) Read() (*Row, error) {
 
 		gr.nonEmpty = true
 
+		err = gr.reduceAggregatedExps(row)
+		if err != nil {
+			return nil, err
+		}
+
 		if gr.currRow == nil {
 			gr.currRow = row
 			err = gr.initAggregations()
@@ -270,6 +322,27 @@ func (gr *groupedRowReader) Read() (*Row, error) {
 	}
 }
 
+// reduceAggregatedExps adds to the row the values of the aggregated expressions e.g. a * b in SUM(a * b)
+func (gr *groupedRowReader) reduceAggregatedExps(row *Row) error {
+	for _, sel := range gr.selectors {
+		aggSel, isAggregation := sel.(*AggColSelector)
+		if !isAggregation || aggSel.exp == nil {
+			continue
+		}
+
+		_, db, table, col := sel.resolve(gr.rowReader.ImplicitDB(), gr.rowReader.ImplicitTable())
+
+		val, err := aggSel.exp.reduce(gr.e.catalog, row, gr.rowReader.ImplicitDB(), gr.rowReader.ImplicitTable())
+		if err != nil {
+			return err
+		}
+
+		row.Values[EncodeSelector("", db, table, col)] = val
+	}
+
+	return nil
+}
+
 func (gr *groupedRowReader) initAggregations() error {
 	// augment row with aggregated values
 	for _, sel := range gr.selectors {
@@ -277,6 +350,13 @@ func (gr *groupedRowReader) initAggregations() error {
 
 		encSel := EncodeSelector(aggFn, db, table, col)
 
+		if aggFn != "" && aggFn != COUNT {
+			_, exists := gr.currRow.Values[EncodeSelector("", db, table, col)]
+			if !exists {
+				return fmt.Errorf("%w: %s(%s.%s.%s)", ErrColumnDoesNotExist, aggFn, db, table, col)
+			}
+		}
+
 		switch aggFn {
 		case COUNT:
 			{
@@ -333,6 +413,13 @@ func (gr *groupedRowReader) initAggregations() error {
 	return nil
 }
 
+func (gr *groupedRowReader) Rewind() error {
+	gr.currRow = nil
+	gr.nonEmpty = false
+
+	return gr.rowReader.Rewind()
+}
+
 func (gr *groupedRowReader) Close() error {
 	return gr.rowReader.Close()
 }
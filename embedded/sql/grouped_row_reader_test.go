@@ -16,6 +16,7 @@ limitations under the License.
 package sql
 
 import (
+	"context"
 	"os"
 	"testing"
 
@@ -55,7 +56,7 @@ func TestGroupedRowReader(t *testing.T) {
 	snap, err := engine.getSnapshot()
 	require.NoError(t, err)
 
-	r, err := engine.newRawRowReader(snap, table, 0, "", &ScanSpecs{index: table.primaryIndex})
+	r, err := engine.newRawRowReader(context.Background(), snap, table, 0, "", &ScanSpecs{index: table.primaryIndex})
 	require.NoError(t, err)
 
 	gr, err := engine.newGroupedRowReader(r, []Selector{&ColSelector{col: "id"}}, []*ColSelector{{col: "id"}})
@@ -17,6 +17,8 @@ limitations under the License.
 package sql
 
 import (
+	"context"
+	"crypto/sha256"
 	"fmt"
 
 	"github.com/codenotary/immudb/embedded/multierr"
@@ -24,6 +26,7 @@ import (
 )
 
 type jointRowReader struct {
+	ctx        context.Context
 	e          *Engine
 	implicitDB *Database
 
@@ -36,21 +39,39 @@ type jointRowReader struct {
 	rowReaders       []RowReader
 	rowReadersValues []map[string]TypedValue
 
+	// rows of a trailing RIGHT JOIN already emitted with a matching row
+	matchedRows map[[sha256.Size]byte]struct{}
+	// reader over a trailing RIGHT JOIN used once matching rows were all emitted
+	unmatchedReader RowReader
+	// columns of the joint data sources, including system columns, filled with NULLs in unmatched rows
+	jointCols map[string]ColDescriptor
+
+	// txTimestamps tells for each join whether the timestamps of the joint table have to be resolved
+	txTimestamps []bool
+
 	params map[string]interface{}
 }
 
-func (e *Engine) newJointRowReader(db *Database, snap *store.Snapshot, params map[string]interface{}, rowReader RowReader, joins []*JoinSpec) (*jointRowReader, error) {
-	if db == nil || snap == nil || rowReader == nil || len(joins) == 0 {
+func (e *Engine) newJointRowReader(ctx context.Context, db *Database, snap *store.Snapshot, params map[string]interface{}, rowReader RowReader, joins []*JoinSpec) (*jointRowReader, error) {
+	if ctx == nil || db == nil || snap == nil || rowReader == nil || len(joins) == 0 {
 		return nil, ErrIllegalArguments
 	}
 
-	for _, jspec := range joins {
-		if jspec.joinType != InnerJoin {
-			return nil, ErrUnsupportedJoinType
+	for i, jspec := range joins {
+		if jspec.joinType == InnerJoin || jspec.joinType == LeftJoin {
+			continue
+		}
+
+		// unmatched rows of a right join are emitted once the left side is exhausted
+		if jspec.joinType == RightJoin && i == len(joins)-1 {
+			continue
 		}
+
+		return nil, ErrUnsupportedJoinType
 	}
 
 	return &jointRowReader{
+		ctx:              ctx,
 		e:                e,
 		implicitDB:       db,
 		snap:             snap,
@@ -59,6 +80,7 @@ func (e *Engine) newJointRowReader(db *Database, snap *store.Snapshot, params ma
 		joins:            joins,
 		rowReaders:       []RowReader{rowReader},
 		rowReadersValues: make([]map[string]TypedValue, 1+len(joins)),
+		matchedRows:      make(map[[sha256.Size]byte]struct{}),
 	}, nil
 }
 
@@ -94,7 +116,7 @@ func (jointr *jointRowReader) colsBySelector() (map[string]ColDescriptor, error)
 		//            on jointRowReader creation,
 		// Note: We're using a dummy ScanSpec object that is only used during read, we're only interested
 		//       in column list though
-		rr, err := jspec.ds.Resolve(jointr.e, jointr.snap, jointr.implicitDB, nil, &ScanSpecs{index: &Index{}})
+		rr, err := jspec.ds.Resolve(jointr.ctx, jointr.e, jointr.snap, jointr.implicitDB, nil, &ScanSpecs{index: &Index{}})
 		if err != nil {
 			return nil, err
 		}
@@ -134,7 +156,7 @@ func (jointr *jointRowReader) colsByPos() ([]ColDescriptor, error) {
 		//            on jointRowReader creation,
 		// Note: We're using a dummy ScanSpec object that is only used during read, we're only interested
 		//       in column list though
-		rr, err := jspec.ds.Resolve(jointr.e, jointr.snap, jointr.implicitDB, nil, &ScanSpecs{index: &Index{}})
+		rr, err := jspec.ds.Resolve(jointr.ctx, jointr.e, jointr.snap, jointr.implicitDB, nil, &ScanSpecs{index: &Index{}})
 		if err != nil {
 			return nil, err
 		}
@@ -183,12 +205,123 @@ func (jointr *jointRowReader) SetParameters(params map[string]interface{}) error
 		return err
 	}
 
-	jointr.params, err = normalizeParams(params)
+	jointr.params, err = jointr.e.normalizeParams(params)
 
 	return err
 }
 
-func (jointr *jointRowReader) Read() (row *Row, err error) {
+// jointSelectors returns the columns selected from the joint data source, system columns are not
+// part of the wildcard so they are explicitly selected, the timestamp only when it's used
+func (jointr *jointRowReader) jointSelectors(joinIdx int) []Selector {
+	tableRef, isTableRef := jointr.joins[joinIdx].ds.(*tableRef)
+	if !isTableRef {
+		return nil
+	}
+
+	selectors := []Selector{
+		&wildcardSelector{},
+		&ColSelector{table: tableRef.Alias(), col: TxIDColumn},
+	}
+
+	if joinIdx < len(jointr.txTimestamps) && jointr.txTimestamps[joinIdx] {
+		selectors = append(selectors, &ColSelector{table: tableRef.Alias(), col: TxTimestampColumn})
+	}
+
+	return selectors
+}
+
+func (jointr *jointRowReader) rightJoin() bool {
+	return jointr.joins[len(jointr.joins)-1].joinType == RightJoin
+}
+
+func (jointr *jointRowReader) Read() (*Row, error) {
+	if jointr.unmatchedReader == nil {
+		row, err := jointr.readMatching()
+		if err != ErrNoMoreRows || !jointr.rightJoin() {
+			return row, err
+		}
+
+		jointr.jointCols, err = jointr.colsBySelector()
+		if err != nil {
+			return nil, err
+		}
+
+		jspec := jointr.joins[len(jointr.joins)-1]
+
+		rightq := &SelectStmt{
+			selectors: jointr.jointSelectors(len(jointr.joins) - 1),
+			ds:        jspec.ds,
+			indexOn:   jspec.indexOn,
+		}
+
+		jointr.unmatchedReader, err = rightq.Resolve(jointr.ctx, jointr.e, jointr.snap, jointr.implicitDB, jointr.params, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return jointr.readUnmatched()
+}
+
+// markMatched keeps track of the rows of a trailing RIGHT JOIN matching at least one row
+func (jointr *jointRowReader) markMatched(readerIdx int, reader RowReader, r *Row) error {
+	if readerIdx != len(jointr.joins) || !jointr.rightJoin() {
+		return nil
+	}
+
+	cols, err := reader.Columns()
+	if err != nil {
+		return err
+	}
+
+	digest, err := r.digest(cols)
+	if err != nil {
+		return err
+	}
+
+	jointr.matchedRows[digest] = struct{}{}
+
+	return nil
+}
+
+// readUnmatched emits rows of a trailing RIGHT JOIN without a matching row, left columns are filled with NULLs
+func (jointr *jointRowReader) readUnmatched() (*Row, error) {
+	cols, err := jointr.unmatchedReader.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		r, err := jointr.unmatchedReader.Read()
+		if err != nil {
+			return nil, err
+		}
+
+		digest, err := r.digest(cols)
+		if err != nil {
+			return nil, err
+		}
+
+		_, matched := jointr.matchedRows[digest]
+		if matched {
+			continue
+		}
+
+		row := &Row{Values: make(map[string]TypedValue, len(jointr.jointCols))}
+
+		for sel, col := range jointr.jointCols {
+			row.Values[sel] = &NullValue{t: col.Type}
+		}
+
+		for c, v := range r.Values {
+			row.Values[c] = v
+		}
+
+		return row, nil
+	}
+}
+
+func (jointr *jointRowReader) readMatching() (*Row, error) {
 	for {
 		row := &Row{Values: make(map[string]TypedValue)}
 
@@ -200,6 +333,11 @@ func (jointr *jointRowReader) Read() (row *Row, err error) {
 				// previous reader will need to read next row
 				jointr.rowReaders = jointr.rowReaders[:len(jointr.rowReaders)-1]
 
+				if len(jointr.rowReaders) == 0 {
+					// the leading reader is kept open so it can be rewound
+					continue
+				}
+
 				err = lastReader.Close()
 				if err != nil {
 					return nil, err
@@ -211,6 +349,11 @@ func (jointr *jointRowReader) Read() (row *Row, err error) {
 				return nil, err
 			}
 
+			err = jointr.markMatched(len(jointr.rowReaders)-1, lastReader, r)
+			if err != nil {
+				return nil, err
+			}
+
 			// override row data
 			jointr.rowReadersValues[len(jointr.rowReaders)-1] = r.Values
 
@@ -233,18 +376,27 @@ func (jointr *jointRowReader) Read() (row *Row, err error) {
 		for i := len(jointr.rowReaders) - 1; i < len(jointr.joins); i++ {
 			jspec := jointr.joins[i]
 
+			// the join condition, once reduced with the values of the current row, filters the joint
+			// data source. Indexed ranges are used when the condition allows it, otherwise
+			// every row is evaluated against the condition as in a nested-loop join
 			jointq := &SelectStmt{
-				ds:      jspec.ds,
-				where:   jspec.cond.reduceSelectors(row, jointr.ImplicitDB(), jointr.ImplicitTable()),
-				indexOn: jspec.indexOn,
+				selectors: jointr.jointSelectors(i),
+				ds:        jspec.ds,
+				where:     jspec.cond.reduceSelectors(row, jointr.ImplicitDB(), jointr.ImplicitTable()),
+				indexOn:   jspec.indexOn,
 			}
 
-			reader, err := jointq.Resolve(jointr.e, jointr.snap, jointr.implicitDB, jointr.params, nil)
+			reader, err := jointq.Resolve(jointr.ctx, jointr.e, jointr.snap, jointr.implicitDB, jointr.params, nil)
 			if err != nil {
 				return nil, err
 			}
 
 			r, err := reader.Read()
+			if err == ErrNoMoreRows && jspec.joinType == LeftJoin {
+				// no matching row, columns are filled with NULLs and the exhausted
+				// reader is kept so the previous reader moves to its next row afterwards
+				r, err = nullRowFor(reader)
+			}
 			if err == ErrNoMoreRows {
 				// previous reader will need to read next row
 				unsolvedFK = true
@@ -260,6 +412,11 @@ func (jointr *jointRowReader) Read() (row *Row, err error) {
 				return nil, err
 			}
 
+			err = jointr.markMatched(i+1, reader, r)
+			if err != nil {
+				return nil, err
+			}
+
 			// progress with the joint readers
 			// append the reader and kept the values for following rows
 			jointr.rowReaders = append(jointr.rowReaders, reader)
@@ -277,13 +434,60 @@ func (jointr *jointRowReader) Read() (row *Row, err error) {
 	}
 }
 
-func (jointr *jointRowReader) Close() error {
+func nullRowFor(reader RowReader) (*Row, error) {
+	cols, err := reader.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Row{Values: nullValuesFor(cols)}, nil
+}
+
+func nullValuesFor(cols []ColDescriptor) map[string]TypedValue {
+	values := make(map[string]TypedValue, len(cols))
+
+	for _, col := range cols {
+		values[col.Selector()] = &NullValue{t: col.Type}
+	}
+
+	return values
+}
+
+// Rewind closes the readers of the joint data sources, they are resolved again as rows of the leading reader are read
+func (jointr *jointRowReader) Rewind() error {
 	merr := multierr.NewMultiErr()
+	jointr.closeJointReaders(merr)
+
+	if merr.HasErrors() {
+		return merr
+	}
+
+	jointr.rowReaders = []RowReader{jointr.rowReader}
+	jointr.unmatchedReader = nil
+	jointr.matchedRows = make(map[[sha256.Size]byte]struct{})
+
+	return jointr.rowReader.Rewind()
+}
+
+func (jointr *jointRowReader) closeJointReaders(merr *multierr.MultiErr) {
+	for i := 1; i < len(jointr.rowReaders); i++ {
+		err := jointr.rowReaders[i].Close()
+		merr.Append(err)
+	}
 
-	for _, rowReader := range jointr.rowReaders {
-		err := rowReader.Close()
+	if jointr.unmatchedReader != nil {
+		err := jointr.unmatchedReader.Close()
 		merr.Append(err)
 	}
+}
+
+func (jointr *jointRowReader) Close() error {
+	merr := multierr.NewMultiErr()
+
+	err := jointr.rowReader.Close()
+	merr.Append(err)
+
+	jointr.closeJointReaders(merr)
 
 	if merr.HasErrors() {
 		return merr
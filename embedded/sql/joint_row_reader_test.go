@@ -17,6 +17,7 @@ limitations under the License.
 package sql
 
 import (
+	"context"
 	"errors"
 	"os"
 	"testing"
@@ -40,7 +41,7 @@ func TestJointRowReader(t *testing.T) {
 	err = engine.EnsureCatalogReady(nil)
 	require.NoError(t, err)
 
-	_, err = engine.newJointRowReader(nil, nil, nil, nil, nil)
+	_, err = engine.newJointRowReader(context.Background(), nil, nil, nil, nil, nil)
 	require.Equal(t, ErrIllegalArguments, err)
 
 	db, err := engine.catalog.newDatabase(1, "db1")
@@ -57,16 +58,22 @@ func TestJointRowReader(t *testing.T) {
 	snap, err := engine.getSnapshot()
 	require.NoError(t, err)
 
-	r, err := engine.newRawRowReader(snap, table, 0, "", &ScanSpecs{index: table.primaryIndex})
+	r, err := engine.newRawRowReader(context.Background(), snap, table, 0, "", &ScanSpecs{index: table.primaryIndex})
 	require.NoError(t, err)
 
-	_, err = engine.newJointRowReader(db, snap, nil, r, []*JoinSpec{{joinType: LeftJoin}})
+	_, err = engine.newJointRowReader(context.Background(), db, snap, nil, r, []*JoinSpec{{joinType: RightJoin}, {joinType: InnerJoin}})
 	require.Equal(t, ErrUnsupportedJoinType, err)
 
-	_, err = engine.newJointRowReader(db, snap, nil, r, []*JoinSpec{{joinType: InnerJoin, ds: &SelectStmt{}}})
+	_, err = engine.newJointRowReader(context.Background(), db, snap, nil, r, []*JoinSpec{{joinType: InnerJoin, ds: &SelectStmt{}}})
 	require.NoError(t, err)
 
-	jr, err := engine.newJointRowReader(db, snap, nil, r, []*JoinSpec{{joinType: InnerJoin, ds: &tableRef{table: "table1", as: "table2"}}})
+	_, err = engine.newJointRowReader(context.Background(), db, snap, nil, r, []*JoinSpec{{joinType: InnerJoin, ds: &SelectStmt{}}, {joinType: RightJoin, ds: &SelectStmt{}}})
+	require.NoError(t, err)
+
+	_, err = engine.newJointRowReader(context.Background(), db, snap, nil, r, []*JoinSpec{{joinType: LeftJoin, ds: &SelectStmt{}}, {joinType: InnerJoin, ds: &SelectStmt{}}})
+	require.NoError(t, err)
+
+	jr, err := engine.newJointRowReader(context.Background(), db, snap, nil, r, []*JoinSpec{{joinType: InnerJoin, ds: &tableRef{table: "table1", as: "table2"}}})
 	require.NoError(t, err)
 
 	orderBy := jr.OrderBy()
@@ -95,7 +102,7 @@ func TestJointRowReader(t *testing.T) {
 	t.Run("corner cases", func(t *testing.T) {
 
 		t.Run("detect ambiguous selectors", func(t *testing.T) {
-			jr, err = engine.newJointRowReader(db, snap, nil, r, []*JoinSpec{{joinType: InnerJoin, ds: &tableRef{table: "table1"}}})
+			jr, err = engine.newJointRowReader(context.Background(), db, snap, nil, r, []*JoinSpec{{joinType: InnerJoin, ds: &tableRef{table: "table1"}}})
 			require.NoError(t, err)
 
 			_, err = jr.colsBySelector()
@@ -143,9 +150,9 @@ func TestJointRowReader(t *testing.T) {
 		t.Run("must propagate error from joined reader on colsBySelector", func(t *testing.T) {
 			injectedErr := errors.New("err")
 
-			jr, err := engine.newJointRowReader(db, snap, nil, r,
+			jr, err := engine.newJointRowReader(context.Background(), db, snap, nil, r,
 				[]*JoinSpec{{joinType: InnerJoin, ds: &dummyDataSource{
-					ResolveFunc: func(e *Engine, snap *store.Snapshot, implicitDB *Database, params map[string]interface{}, ScanSpecs *ScanSpecs) (RowReader, error) {
+					ResolveFunc: func(ctx context.Context, e *Engine, snap *store.Snapshot, implicitDB *Database, params map[string]interface{}, ScanSpecs *ScanSpecs) (RowReader, error) {
 						return nil, injectedErr
 					},
 				}}})
@@ -158,9 +165,9 @@ func TestJointRowReader(t *testing.T) {
 
 		t.Run("must propagate error from joined reader on colsBySelector from Resolve", func(t *testing.T) {
 
-			jr, err := engine.newJointRowReader(db, snap, nil, r,
+			jr, err := engine.newJointRowReader(context.Background(), db, snap, nil, r,
 				[]*JoinSpec{{joinType: InnerJoin, ds: &dummyDataSource{
-					ResolveFunc: func(e *Engine, snap *store.Snapshot, implicitDB *Database, params map[string]interface{}, ScanSpecs *ScanSpecs) (RowReader, error) {
+					ResolveFunc: func(ctx context.Context, e *Engine, snap *store.Snapshot, implicitDB *Database, params map[string]interface{}, ScanSpecs *ScanSpecs) (RowReader, error) {
 						return &dummyRowReader{}, nil
 					},
 				}}})
@@ -79,6 +79,11 @@ func (lr *limitRowReader) Read() (*Row, error) {
 	return row, nil
 }
 
+func (lr *limitRowReader) Rewind() error {
+	lr.read = 0
+	return lr.rowReader.Rewind()
+}
+
 func (lr *limitRowReader) Close() error {
 	return lr.rowReader.Close()
 }
@@ -15,21 +15,40 @@ limitations under the License.
 */
 package sql
 
+import "time"
+
 var defultDistinctLimit = 1 << 20 // ~ 1mi rows
+var defaultMaxRowsPerQuery = 1000
+var defaultSortSpillThld = 1 << 16
 
 type Options struct {
 	prefix        []byte
 	distinctLimit int
+
+	// number of distinct rows kept in memory before spilling to a temporary index, disabled when zero
+	distinctSpillThld int
+
+	// number of rows sorted in memory before spilling them to temporary files, disabled when zero
+	sortSpillThld int
+
+	// max number of rows QueryAll loads into memory, disabled when zero
+	maxRowsPerQuery int
+
+	// clock providing the value of NOW(), evaluated once per transaction
+	now func() time.Time
 }
 
 func DefaultOptions() *Options {
 	return &Options{
-		distinctLimit: defultDistinctLimit,
+		distinctLimit:   defultDistinctLimit,
+		maxRowsPerQuery: defaultMaxRowsPerQuery,
+		sortSpillThld:   defaultSortSpillThld,
+		now:             time.Now,
 	}
 }
 
 func ValidOpts(opts *Options) bool {
-	return opts != nil && opts.distinctLimit > 0
+	return opts != nil && opts.distinctLimit > 0 && opts.distinctSpillThld >= 0 && opts.maxRowsPerQuery >= 0 && opts.sortSpillThld >= 0
 }
 
 func (opts *Options) WithPrefix(prefix []byte) *Options {
@@ -41,3 +60,23 @@ func (opts *Options) WithDistinctLimit(distinctLimit int) *Options {
 	opts.distinctLimit = distinctLimit
 	return opts
 }
+
+func (opts *Options) WithDistinctSpillThld(distinctSpillThld int) *Options {
+	opts.distinctSpillThld = distinctSpillThld
+	return opts
+}
+
+func (opts *Options) WithSortSpillThld(sortSpillThld int) *Options {
+	opts.sortSpillThld = sortSpillThld
+	return opts
+}
+
+func (opts *Options) WithMaxRowsPerQuery(maxRowsPerQuery int) *Options {
+	opts.maxRowsPerQuery = maxRowsPerQuery
+	return opts
+}
+
+func (opts *Options) WithNowFunc(now func() time.Time) *Options {
+	opts.now = now
+	return opts
+}
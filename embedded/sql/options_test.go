@@ -17,6 +17,7 @@ package sql
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -37,4 +38,29 @@ func TestOptions(t *testing.T) {
 	require.Equal(t, []byte("sqlPrefix"), opts.prefix)
 
 	require.True(t, ValidOpts(opts))
+
+	opts.WithDistinctSpillThld(-1)
+	require.False(t, ValidOpts(opts))
+
+	opts.WithDistinctSpillThld(100)
+	require.Equal(t, 100, opts.distinctSpillThld)
+	require.True(t, ValidOpts(opts))
+
+	opts.WithSortSpillThld(-1)
+	require.False(t, ValidOpts(opts))
+
+	opts.WithSortSpillThld(defaultSortSpillThld)
+	require.Equal(t, defaultSortSpillThld, opts.sortSpillThld)
+	require.True(t, ValidOpts(opts))
+
+	opts.WithMaxRowsPerQuery(-1)
+	require.False(t, ValidOpts(opts))
+
+	opts.WithMaxRowsPerQuery(defaultMaxRowsPerQuery)
+	require.Equal(t, defaultMaxRowsPerQuery, opts.maxRowsPerQuery)
+	require.True(t, ValidOpts(opts))
+
+	now := time.Unix(1, 0)
+	opts.WithNowFunc(func() time.Time { return now })
+	require.Equal(t, now, opts.now())
 }
@@ -29,54 +29,86 @@ import (
 //go:generate go run golang.org/x/tools/cmd/goyacc -l -o sql_parser.go sql_grammar.y
 
 var reservedWords = map[string]int{
-	"CREATE":         CREATE,
-	"USE":            USE,
-	"DATABASE":       DATABASE,
-	"SNAPSHOT":       SNAPSHOT,
-	"SINCE":          SINCE,
-	"UP":             UP,
-	"TO":             TO,
-	"TABLE":          TABLE,
-	"PRIMARY":        PRIMARY,
-	"KEY":            KEY,
-	"UNIQUE":         UNIQUE,
-	"INDEX":          INDEX,
-	"ON":             ON,
-	"ALTER":          ALTER,
-	"ADD":            ADD,
-	"COLUMN":         COLUMN,
-	"INSERT":         INSERT,
-	"UPSERT":         UPSERT,
-	"INTO":           INTO,
-	"VALUES":         VALUES,
-	"UPDATE":         UPDATE,
-	"SET":            SET,
-	"DELETE":         DELETE,
-	"BEGIN":          BEGIN,
-	"TRANSACTION":    TRANSACTION,
-	"COMMIT":         COMMIT,
-	"SELECT":         SELECT,
-	"DISTINCT":       DISTINCT,
-	"FROM":           FROM,
-	"BEFORE":         BEFORE,
-	"TX":             TX,
-	"JOIN":           JOIN,
-	"HAVING":         HAVING,
-	"WHERE":          WHERE,
-	"GROUP":          GROUP,
-	"BY":             BY,
-	"LIMIT":          LIMIT,
-	"ORDER":          ORDER,
-	"AS":             AS,
-	"ASC":            ASC,
-	"DESC":           DESC,
-	"NOT":            NOT,
-	"LIKE":           LIKE,
-	"EXISTS":         EXISTS,
-	"IN":             IN,
-	"AUTO_INCREMENT": AUTO_INCREMENT,
-	"NULL":           NULL,
-	"IF":             IF,
+	"CREATE":            CREATE,
+	"USE":               USE,
+	"DATABASE":          DATABASE,
+	"SNAPSHOT":          SNAPSHOT,
+	"SINCE":             SINCE,
+	"UP":                UP,
+	"TO":                TO,
+	"TABLE":             TABLE,
+	"PRIMARY":           PRIMARY,
+	"KEY":               KEY,
+	"UNIQUE":            UNIQUE,
+	"INDEX":             INDEX,
+	"ON":                ON,
+	"ALTER":             ALTER,
+	"ADD":               ADD,
+	"COLUMN":            COLUMN,
+	"INSERT":            INSERT,
+	"UPSERT":            UPSERT,
+	"INTO":              INTO,
+	"VALUES":            VALUES,
+	"UPDATE":            UPDATE,
+	"TRUNCATE":          TRUNCATE,
+	"ANALYZE":           ANALYZE,
+	"SET":               SET,
+	"DELETE":            DELETE,
+	"BEGIN":             BEGIN,
+	"TRANSACTION":       TRANSACTION,
+	"COMMIT":            COMMIT,
+	"SAVEPOINT":         SAVEPOINT,
+	"RELEASE":           RELEASE,
+	"ROLLBACK":          ROLLBACK,
+	"SELECT":            SELECT,
+	"DISTINCT":          DISTINCT,
+	"FROM":              FROM,
+	"BEFORE":            BEFORE,
+	"TX":                TX,
+	"JOIN":              JOIN,
+	"HAVING":            HAVING,
+	"WHERE":             WHERE,
+	"GROUP":             GROUP,
+	"BY":                BY,
+	"LIMIT":             LIMIT,
+	"OFFSET":            OFFSET,
+	"ORDER":             ORDER,
+	"AS":                AS,
+	"ASC":               ASC,
+	"DESC":              DESC,
+	"NULLS":             NULLS,
+	"FIRST":             FIRST,
+	"LAST":              LAST,
+	"NOT":               NOT,
+	"AND":               ANDOP,
+	"OR":                OROP,
+	"LIKE":              LIKE,
+	"ILIKE":             ILIKE,
+	"EXISTS":            EXISTS,
+	"IN":                IN,
+	"CAST":              CAST,
+	"CASE":              CASE,
+	"WHEN":              WHEN,
+	"THEN":              THEN,
+	"ELSE":              ELSE,
+	"END":               END,
+	"UNION":             UNION,
+	"ALL":               ALL,
+	"AUTO_INCREMENT":    AUTO_INCREMENT,
+	"CURRENT_TIMESTAMP": CURRENT_TIMESTAMP,
+	"NULL":              NULL,
+	"IF":                IF,
+	"DESCRIBE":          DESCRIBE,
+	"SHOW":              SHOW,
+	"TABLES":            TABLES,
+	"DATABASES":         DATABASES,
+	"CONFLICT":          CONFLICT,
+	"DO":                DO,
+	"NOTHING":           NOTHING,
+	"CHECK":             CHECK,
+	"COLLATE":           COLLATE,
+	"IS":                IS,
+	"DEFAULT":           DEFAULT,
 }
 
 var joinTypes = map[string]JoinType{
@@ -109,20 +141,18 @@ var boolValues = map[string]bool{
 var cmpOps = map[string]CmpOperator{
 	"=":  EQ,
 	"!=": NE,
+	"<>": NE,
 	"<":  LT,
 	"<=": LE,
 	">":  GT,
 	">=": GE,
 }
 
-var logicOps = map[string]LogicOperator{
-	"AND": AND,
-	"OR":  OR,
-}
-
 var ErrEitherNamedOrUnnamedParams = errors.New("either named or unnamed params")
 var ErrEitherPosOrNonPosParams = errors.New("either positional or non-positional named params")
 var ErrInvalidPositionalParameter = errors.New("invalid positional parameter")
+var ErrInvalidBLOBLiteral = errors.New("invalid blob literal, expecting an even number of hex digits")
+var ErrInvalidQuotedIdentifier = errors.New("invalid quoted identifier")
 
 type positionalParamType int
 
@@ -132,22 +162,57 @@ const (
 	UnnamedParamType
 )
 
+// ParseError is returned when the input can not be parsed, it holds the position
+// of the token at which parsing failed
+type ParseError struct {
+	// Offset is the byte offset of the token, starting at zero
+	Offset int
+	// Line and Column of the token, both starting at one
+	Line   int
+	Column int
+	// Token is the text of the token, empty when the input ended unexpectedly
+	Token string
+
+	err error
+}
+
+func (e *ParseError) Error() string {
+	return e.err.Error()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.err
+}
+
 type lexer struct {
 	r               *aheadByteReader
 	err             error
 	namedParamsType positionalParamType
 	paramsCount     int
 	result          []SQLStmt
+
+	// position of the last token read
+	tokenPos position
+}
+
+type position struct {
+	offset int
+	line   int
+	column int
 }
 
 type aheadByteReader struct {
 	nextChar byte
 	nextErr  error
 	r        io.ByteReader
+
+	// position of the next char and the chars read since the last mark
+	pos    position
+	marked []byte
 }
 
 func newAheadByteReader(r io.ByteReader) *aheadByteReader {
-	ar := &aheadByteReader{r: r}
+	ar := &aheadByteReader{r: r, pos: position{line: 1, column: 1}}
 	ar.nextChar, ar.nextErr = r.ReadByte()
 	return ar
 }
@@ -159,9 +224,26 @@ func (ar *aheadByteReader) ReadByte() (byte, error) {
 		}
 	}()
 
+	if ar.nextErr == nil {
+		ar.marked = append(ar.marked, ar.nextChar)
+
+		ar.pos.offset++
+		ar.pos.column++
+
+		if ar.nextChar == '\n' {
+			ar.pos.line++
+			ar.pos.column = 1
+		}
+	}
+
 	return ar.nextChar, ar.nextErr
 }
 
+// mark discards the chars read so far
+func (ar *aheadByteReader) mark() {
+	ar.marked = ar.marked[:0]
+}
+
 func (ar *aheadByteReader) NextByte() (byte, error) {
 	return ar.nextChar, ar.nextErr
 }
@@ -179,6 +261,25 @@ func Parse(r io.ByteReader) ([]SQLStmt, error) {
 	return lexer.result, lexer.err
 }
 
+// parseExp parses a single value expression by wrapping it into the condition of a query
+func parseExp(exp string) (ValueExp, error) {
+	stmts, err := ParseString("SELECT * FROM t WHERE " + exp)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(stmts) != 1 {
+		return nil, ErrIllegalArguments
+	}
+
+	sel, ok := stmts[0].(*SelectStmt)
+	if !ok || sel.where == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	return sel.where, nil
+}
+
 func newLexer(r io.ByteReader) *lexer {
 	return &lexer{
 		r:   newAheadByteReader(r),
@@ -191,6 +292,9 @@ func (l *lexer) Lex(lval *yySymType) int {
 	var err error
 
 	for {
+		l.tokenPos = l.r.pos
+		l.r.mark()
+
 		ch, err = l.r.ReadByte()
 		if err == io.EOF {
 			return 0
@@ -200,10 +304,6 @@ func (l *lexer) Lex(lval *yySymType) int {
 			return ERROR
 		}
 
-		if '\t' == ch {
-			continue
-		}
-
 		if '/' == ch && '*' == l.r.nextChar {
 			l.r.ReadByte()
 
@@ -260,7 +360,8 @@ func (l *lexer) Lex(lval *yySymType) int {
 
 		val, err := hex.DecodeString(tail)
 		if err != nil {
-			lval.err = err
+			lval.err = fmt.Errorf("%w: x'%s'", ErrInvalidBLOBLiteral, tail)
+			l.err = l.parseError(lval.err)
 			return ERROR
 		}
 
@@ -290,12 +391,6 @@ func (l *lexer) Lex(lval *yySymType) int {
 			return BOOLEAN
 		}
 
-		lop, ok := logicOps[tid]
-		if ok {
-			lval.logicOp = lop
-			return LOP
-		}
-
 		afn, ok := aggregateFns[tid]
 		if ok {
 			lval.aggFn = afn
@@ -318,6 +413,19 @@ func (l *lexer) Lex(lval *yySymType) int {
 		return IDENTIFIER
 	}
 
+	if isDoubleQuote(ch) {
+		// quoted identifiers are taken verbatim, even if they match a reserved word or a type
+		id, err := l.readQuotedIdentifier()
+		if err != nil {
+			lval.err = err
+			l.err = l.parseError(lval.err)
+			return ERROR
+		}
+
+		lval.id = id
+		return IDENTIFIER
+	}
+
 	if isNumber(ch) {
 		tail, err := l.readNumber()
 		if err != nil {
@@ -370,11 +478,13 @@ func (l *lexer) Lex(lval *yySymType) int {
 	if ch == '@' {
 		if l.namedParamsType == UnnamedParamType {
 			lval.err = ErrEitherNamedOrUnnamedParams
+			l.err = l.parseError(lval.err)
 			return ERROR
 		}
 
 		if l.namedParamsType == NamedPositionalParamType {
 			lval.err = ErrEitherPosOrNonPosParams
+			l.err = l.parseError(lval.err)
 			return ERROR
 		}
 
@@ -386,11 +496,13 @@ func (l *lexer) Lex(lval *yySymType) int {
 	if ch == '$' {
 		if l.namedParamsType == UnnamedParamType {
 			lval.err = ErrEitherNamedOrUnnamedParams
+			l.err = l.parseError(lval.err)
 			return ERROR
 		}
 
 		if l.namedParamsType == NamedNonPositionalParamType {
 			lval.err = ErrEitherPosOrNonPosParams
+			l.err = l.parseError(lval.err)
 			return ERROR
 		}
 
@@ -408,6 +520,7 @@ func (l *lexer) Lex(lval *yySymType) int {
 
 		if pid < 1 {
 			lval.err = ErrInvalidPositionalParameter
+			l.err = l.parseError(lval.err)
 			return ERROR
 		}
 
@@ -421,6 +534,7 @@ func (l *lexer) Lex(lval *yySymType) int {
 	if ch == '?' {
 		if l.namedParamsType == NamedNonPositionalParamType || l.namedParamsType == NamedPositionalParamType {
 			lval.err = ErrEitherNamedOrUnnamedParams
+			l.err = l.parseError(lval.err)
 			return ERROR
 		}
 
@@ -436,7 +550,22 @@ func (l *lexer) Lex(lval *yySymType) int {
 }
 
 func (l *lexer) Error(err string) {
-	l.err = errors.New(err)
+	if l.err != nil {
+		// a lexing error is more descriptive than the resulting syntax error
+		return
+	}
+
+	l.err = l.parseError(errors.New(err))
+}
+
+func (l *lexer) parseError(err error) *ParseError {
+	return &ParseError{
+		Offset: l.tokenPos.offset,
+		Line:   l.tokenPos.line,
+		Column: l.tokenPos.column,
+		Token:  string(l.r.marked),
+		err:    err,
+	}
 }
 
 func (l *lexer) readWord() (string, error) {
@@ -455,6 +584,38 @@ func (l *lexer) readString() (string, error) {
 	})
 }
 
+// readQuotedIdentifier reads up to the closing double quote, two consecutive double quotes standing for one
+func (l *lexer) readQuotedIdentifier() (string, error) {
+	var b bytes.Buffer
+
+	for {
+		ch, err := l.r.ReadByte()
+		if err == io.EOF {
+			return "", fmt.Errorf("%w: missing closing double quote", ErrInvalidQuotedIdentifier)
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if isDoubleQuote(ch) {
+			next, err := l.r.NextByte()
+			if err != nil || !isDoubleQuote(next) {
+				break
+			}
+
+			l.r.ReadByte() // consume escaped double quote
+		}
+
+		b.WriteByte(ch)
+	}
+
+	if b.Len() == 0 {
+		return "", fmt.Errorf("%w: identifier can not be empty", ErrInvalidQuotedIdentifier)
+	}
+
+	return b.String(), nil
+}
+
 func (l *lexer) readComparison() (string, error) {
 	return l.readWhile(func(ch byte) bool {
 		return isComparison(ch)
@@ -484,6 +645,37 @@ func (l *lexer) readWhile(condFn func(b byte) bool) (string, error) {
 	return b.String(), nil
 }
 
+// quoteIdentifier returns id as it must be written in a statement to be lexed back as the same identifier
+func quoteIdentifier(id string) string {
+	if isPlainIdentifier(id) {
+		return id
+	}
+
+	return `"` + strings.ReplaceAll(id, `"`, `""`) + `"`
+}
+
+func isPlainIdentifier(id string) bool {
+	if len(id) == 0 || !isLetter(id[0]) || id != strings.ToLower(id) {
+		return false
+	}
+
+	for i := 1; i < len(id); i++ {
+		if !isLetter(id[i]) && !isNumber(id[i]) {
+			return false
+		}
+	}
+
+	w := strings.ToUpper(id)
+
+	_, isType := types[w]
+	_, isBool := boolValues[w]
+	_, isAggFn := aggregateFns[w]
+	_, isJoinType := joinTypes[w]
+	_, isReserved := reservedWords[w]
+
+	return !isType && !isBool && !isAggFn && !isJoinType && !isReserved
+}
+
 func isBLOBPrefix(ch byte) bool {
 	return 'x' == ch
 }
@@ -497,7 +689,7 @@ func isLineBreak(ch byte) bool {
 }
 
 func isSpace(ch byte) bool {
-	return 32 == ch || 9 == ch //SPACE or TAB
+	return 32 == ch || 9 == ch || 11 == ch || 12 == ch //SPACE, TAB, VT or FF
 }
 
 func isNumber(ch byte) bool {
@@ -515,3 +707,7 @@ func isComparison(ch byte) bool {
 func isQuote(ch byte) bool {
 	return '\'' == ch
 }
+
+func isDoubleQuote(ch byte) bool {
+	return '"' == ch
+}
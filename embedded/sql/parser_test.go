@@ -49,7 +49,7 @@ func TestCreateDatabaseStmt(t *testing.T) {
 
 	for i, tc := range testCases {
 		res, err := ParseString(tc.input)
-		require.Equal(t, tc.expectedError, err, fmt.Sprintf("failed on iteration %d", i))
+		require.Equal(t, tc.expectedError, errors.Unwrap(err), fmt.Sprintf("failed on iteration %d", i))
 
 		if tc.expectedError == nil {
 			require.Equal(t, tc.expectedOutput, res, fmt.Sprintf("failed on iteration %d", i))
@@ -77,7 +77,7 @@ func TestUseDatabaseStmt(t *testing.T) {
 
 	for i, tc := range testCases {
 		res, err := ParseString(tc.input)
-		require.Equal(t, tc.expectedError, err, fmt.Sprintf("failed on iteration %d", i))
+		require.Equal(t, tc.expectedError, errors.Unwrap(err), fmt.Sprintf("failed on iteration %d", i))
 
 		if tc.expectedError == nil {
 			require.Equal(t, tc.expectedOutput, res, fmt.Sprintf("failed on iteration %d", i))
@@ -107,7 +107,7 @@ func TestUseSnapshotStmt(t *testing.T) {
 
 	for i, tc := range testCases {
 		res, err := ParseString(tc.input)
-		require.Equal(t, tc.expectedError, err, fmt.Sprintf("failed on iteration %d", i))
+		require.Equal(t, tc.expectedError, errors.Unwrap(err), fmt.Sprintf("failed on iteration %d", i))
 
 		if tc.expectedError == nil {
 			require.Equal(t, tc.expectedOutput, res, fmt.Sprintf("failed on iteration %d", i))
@@ -143,6 +143,53 @@ func TestCreateTableStmt(t *testing.T) {
 				}},
 			expectedError: nil,
 		},
+		{
+			input: "CREATE TABLE table1 (id INTEGER, age INTEGER NOT NULL CHECK (age >= 0), PRIMARY KEY id)",
+			expectedOutput: []SQLStmt{
+				&CreateTableStmt{
+					table:       "table1",
+					ifNotExists: false,
+					colsSpec: []*ColSpec{
+						{colName: "id", colType: IntegerType},
+						{colName: "age", colType: IntegerType, notNull: true, check: &CmpBoolExp{op: GE, left: &ColSelector{col: "age"}, right: &Number{val: 0}}},
+					},
+					pkColNames: []string{"id"},
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "CREATE TABLE table1 (id INTEGER, email VARCHAR[64] NOT NULL UNIQUE, PRIMARY KEY id)",
+			expectedOutput: []SQLStmt{
+				&CreateTableStmt{
+					table:       "table1",
+					ifNotExists: false,
+					colsSpec: []*ColSpec{
+						{colName: "id", colType: IntegerType},
+						{colName: "email", colType: VarcharType, maxLen: 64, notNull: true, unique: true},
+					},
+					pkColNames: []string{"id"},
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "CREATE TABLE table1 (id INTEGER, email VARCHAR[64] COLLATE nocase NOT NULL UNIQUE, PRIMARY KEY id)",
+			expectedOutput: []SQLStmt{
+				&CreateTableStmt{
+					table:       "table1",
+					ifNotExists: false,
+					colsSpec: []*ColSpec{
+						{colName: "id", colType: IntegerType},
+						{colName: "email", colType: VarcharType, maxLen: 64, collation: "nocase", notNull: true, unique: true},
+					},
+					pkColNames: []string{"id"},
+				}},
+			expectedError: nil,
+		},
+		{
+			input:          "CREATE TABLE table1 (id INTEGER CHECK, PRIMARY KEY id)",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected ',', expecting '('"),
+		},
 		{
 			input: "CREATE TABLE xtable1 (xid INTEGER, PRIMARY KEY xid)",
 			expectedOutput: []SQLStmt{
@@ -182,6 +229,21 @@ func TestCreateTableStmt(t *testing.T) {
 				}},
 			expectedError: nil,
 		},
+		{
+			input: "CREATE TABLE table1 (id INTEGER, created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP, updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP, touched TIMESTAMP ON UPDATE current_timestamp, PRIMARY KEY id)",
+			expectedOutput: []SQLStmt{
+				&CreateTableStmt{
+					table: "table1",
+					colsSpec: []*ColSpec{
+						{colName: "id", colType: IntegerType},
+						{colName: "created", colType: TimestampType, notNull: true, defaultNow: true},
+						{colName: "updated", colType: TimestampType, defaultNow: true, updateNow: true},
+						{colName: "touched", colType: TimestampType, updateNow: true},
+					},
+					pkColNames: []string{"id"},
+				}},
+			expectedError: nil,
+		},
 		{
 			input:          "CREATE table1",
 			expectedOutput: nil,
@@ -195,13 +257,13 @@ func TestCreateTableStmt(t *testing.T) {
 		{
 			input:          "CREATE TABLE table1()",
 			expectedOutput: []SQLStmt{&CreateTableStmt{table: "table1"}},
-			expectedError:  errors.New("syntax error: unexpected ')', expecting IDENTIFIER"),
+			expectedError:  errors.New("syntax error: unexpected ')', expecting NULLS or FIRST or LAST or IDENTIFIER"),
 		},
 	}
 
 	for i, tc := range testCases {
 		res, err := ParseString(tc.input)
-		require.Equal(t, tc.expectedError, err, fmt.Sprintf("failed on iteration %d", i))
+		require.Equal(t, tc.expectedError, errors.Unwrap(err), fmt.Sprintf("failed on iteration %d", i))
 
 		if tc.expectedError == nil {
 			require.Equal(t, tc.expectedOutput, res, fmt.Sprintf("failed on iteration %d", i))
@@ -235,11 +297,16 @@ func TestCreateIndexStmt(t *testing.T) {
 			expectedOutput: []SQLStmt{&CreateIndexStmt{unique: true, table: "table1", cols: []string{"id", "title"}}},
 			expectedError:  nil,
 		},
+		{
+			input:          "CREATE UNIQUE INDEX IF NOT EXISTS ON table1(id, title)",
+			expectedOutput: []SQLStmt{&CreateIndexStmt{unique: true, ifNotExists: true, table: "table1", cols: []string{"id", "title"}}},
+			expectedError:  nil,
+		},
 	}
 
 	for i, tc := range testCases {
 		res, err := ParseString(tc.input)
-		require.Equal(t, tc.expectedError, err, fmt.Sprintf("failed on iteration %d", i))
+		require.Equal(t, tc.expectedError, errors.Unwrap(err), fmt.Sprintf("failed on iteration %d", i))
 
 		if tc.expectedError == nil {
 			require.Equal(t, tc.expectedOutput, res, fmt.Sprintf("failed on iteration %d", i))
@@ -280,7 +347,7 @@ func TestAlterTableStmt(t *testing.T) {
 
 	for i, tc := range testCases {
 		res, err := ParseString(tc.input)
-		require.Equal(t, tc.expectedError, err, fmt.Sprintf("failed on iteration %d", i))
+		require.Equal(t, tc.expectedError, errors.Unwrap(err), fmt.Sprintf("failed on iteration %d", i))
 
 		if tc.expectedError == nil {
 			require.Equal(t, tc.expectedOutput, res, fmt.Sprintf("failed on iteration %d", i))
@@ -297,6 +364,90 @@ func TestInsertIntoStmt(t *testing.T) {
 		expectedOutput []SQLStmt
 		expectedError  error
 	}{
+		{
+			input: "INSERT INTO table1(id, title) VALUES (1, 'title1'), (2, 'title2') ON CONFLICT DO NOTHING",
+			expectedOutput: []SQLStmt{
+				&UpsertIntoStmt{
+					isInsert: true,
+					tableRef: &tableRef{table: "table1"},
+					cols:     []string{"id", "title"},
+					rows: []*RowSpec{
+						{Values: []ValueExp{&Number{val: 1}, &Varchar{val: "title1"}}},
+						{Values: []ValueExp{&Number{val: 2}, &Varchar{val: "title2"}}},
+					},
+					onConflict: &OnConflictDo{},
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			input: "INSERT INTO table2(id, title) SELECT id, title FROM table1 WHERE id > 10 ON CONFLICT DO NOTHING",
+			expectedOutput: []SQLStmt{
+				&UpsertIntoStmt{
+					isInsert: true,
+					tableRef: &tableRef{table: "table2"},
+					cols:     []string{"id", "title"},
+					ds: &SelectStmt{
+						selectors: []Selector{
+							&ColSelector{col: "id"},
+							&ColSelector{col: "title"},
+						},
+						ds: &tableRef{table: "table1"},
+						where: &CmpBoolExp{
+							op:    GT,
+							left:  &ColSelector{col: "id"},
+							right: &Number{val: 10},
+						},
+					},
+					onConflict: &OnConflictDo{},
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			input: "INSERT INTO table1(id, title) VALUES (1, 'title1') ON CONFLICT DO UPDATE SET title = excluded.title, amount = amount + 1",
+			expectedOutput: []SQLStmt{
+				&UpsertIntoStmt{
+					isInsert: true,
+					tableRef: &tableRef{table: "table1"},
+					cols:     []string{"id", "title"},
+					rows: []*RowSpec{
+						{Values: []ValueExp{&Number{val: 1}, &Varchar{val: "title1"}}},
+					},
+					onConflict: &OnConflictDo{
+						updates: []*colUpdate{
+							{col: "title", op: EQ, val: &ColSelector{table: "excluded", col: "title"}},
+							{col: "amount", op: EQ, val: &NumExp{op: ADDOP, left: &ColSelector{col: "amount"}, right: &Number{val: 1}}},
+						},
+					},
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			input: "INSERT INTO table1(id, title, active) VALUES (DEFAULT, 'title1', DEFAULT)",
+			expectedOutput: []SQLStmt{
+				&UpsertIntoStmt{
+					isInsert: true,
+					tableRef: &tableRef{table: "table1"},
+					cols:     []string{"id", "title", "active"},
+					rows: []*RowSpec{
+						{Values: []ValueExp{&DefaultValue{}, &Varchar{val: "title1"}, &DefaultValue{}}},
+					},
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			input:          "SELECT id FROM table1 WHERE title = DEFAULT",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected DEFAULT"),
+		},
+		{
+			input:          "INSERT INTO table1(id) VALUES (1) ON CONFLICT",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected $end, expecting DO"),
+		},
 		{
 			input: "UPSERT INTO table1(id, time, title, active, compressed, payload, note) VALUES (2, now(), 'untitled row', TRUE, false, x'AED0393F', @param1)",
 			expectedOutput: []SQLStmt{
@@ -366,37 +517,37 @@ func TestInsertIntoStmt(t *testing.T) {
 		{
 			input:          "UPSERT INTO table1(id, title) VALUES ($0, $1)",
 			expectedOutput: nil,
-			expectedError:  errors.New("syntax error: unexpected ERROR, expecting ')'"),
+			expectedError:  ErrInvalidPositionalParameter,
 		},
 		{
 			input:          "UPSERT INTO table1(id, title) VALUES (?, @title)",
 			expectedOutput: nil,
-			expectedError:  errors.New("syntax error: unexpected ERROR"),
+			expectedError:  ErrEitherNamedOrUnnamedParams,
 		},
 		{
 			input:          "UPSERT INTO table1(id, title) VALUES (@id, ?)",
 			expectedOutput: nil,
-			expectedError:  errors.New("syntax error: unexpected ERROR"),
+			expectedError:  ErrEitherNamedOrUnnamedParams,
 		},
 		{
 			input:          "UPSERT INTO table1(id, title) VALUES (@id, $1)",
 			expectedOutput: nil,
-			expectedError:  errors.New("syntax error: unexpected ERROR"),
+			expectedError:  ErrEitherPosOrNonPosParams,
 		},
 		{
 			input:          "UPSERT INTO table1(id, title) VALUES ($1, @title)",
 			expectedOutput: nil,
-			expectedError:  errors.New("syntax error: unexpected ERROR"),
+			expectedError:  ErrEitherPosOrNonPosParams,
 		},
 		{
 			input:          "UPSERT INTO table1(id, title) VALUES ($1, ?)",
 			expectedOutput: nil,
-			expectedError:  errors.New("syntax error: unexpected ERROR"),
+			expectedError:  ErrEitherNamedOrUnnamedParams,
 		},
 		{
 			input:          "UPSERT INTO table1(id, title) VALUES (?, $1)",
 			expectedOutput: nil,
-			expectedError:  errors.New("syntax error: unexpected ERROR"),
+			expectedError:  ErrEitherNamedOrUnnamedParams,
 		},
 		{
 			input:          "UPSERT INTO table1(id, title) VALUES ($1, $title)",
@@ -421,18 +572,18 @@ func TestInsertIntoStmt(t *testing.T) {
 		{
 			input:          "UPSERT INTO table1() VALUES (2, 'untitled')",
 			expectedOutput: nil,
-			expectedError:  errors.New("syntax error: unexpected ')', expecting IDENTIFIER"),
+			expectedError:  errors.New("syntax error: unexpected ')', expecting NULLS or FIRST or LAST or IDENTIFIER"),
 		},
 		{
 			input:          "UPSERT INTO VALUES (2)",
 			expectedOutput: nil,
-			expectedError:  errors.New("syntax error: unexpected VALUES, expecting IDENTIFIER"),
+			expectedError:  errors.New("syntax error: unexpected VALUES, expecting NULLS or FIRST or LAST or IDENTIFIER"),
 		},
 	}
 
 	for i, tc := range testCases {
 		res, err := ParseString(tc.input)
-		require.Equal(t, tc.expectedError, err, fmt.Sprintf("failed on iteration %d", i))
+		require.Equal(t, tc.expectedError, errors.Unwrap(err), fmt.Sprintf("failed on iteration %d", i))
 
 		if tc.expectedError == nil {
 			require.Equal(t, tc.expectedOutput, res, fmt.Sprintf("failed on iteration %d", i))
@@ -510,16 +661,47 @@ func TestStmtSeparator(t *testing.T) {
 			},
 			expectedError: nil,
 		},
+		{
+			input: "; CREATE DATABASE db1;; ;\n; USE DATABASE db1 ;;",
+			expectedOutput: []SQLStmt{
+				&CreateDatabaseStmt{DB: "db1"},
+				&UseDatabaseStmt{DB: "db1"},
+			},
+			expectedError: nil,
+		},
+		{
+			input: "INSERT INTO table1 (id) VALUES (1); ; SELECT id FROM table1;",
+			expectedOutput: []SQLStmt{
+				&UpsertIntoStmt{
+					isInsert: true,
+					tableRef: &tableRef{table: "table1"},
+					cols:     []string{"id"},
+					rows: []*RowSpec{
+						{Values: []ValueExp{&Number{val: 1}}},
+					},
+				},
+				&SelectStmt{
+					selectors: []Selector{&ColSelector{col: "id"}},
+					ds:        &tableRef{table: "table1"},
+				},
+			},
+			expectedError: nil,
+		},
 		{
 			input:          "CREATE DATABASE db1 USE DATABASE db1",
 			expectedOutput: nil,
 			expectedError:  errors.New("syntax error: unexpected USE"),
 		},
+		{
+			input:          ";;",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected $end"),
+		},
 	}
 
 	for i, tc := range testCases {
 		res, err := ParseString(tc.input)
-		require.Equal(t, tc.expectedError, err, fmt.Sprintf("failed on iteration %d", i))
+		require.Equal(t, tc.expectedError, errors.Unwrap(err), fmt.Sprintf("failed on iteration %d", i))
 
 		if tc.expectedError == nil {
 			require.Equal(t, tc.expectedOutput, res, fmt.Sprintf("failed on iteration %d", i))
@@ -617,11 +799,35 @@ func TestTxStmt(t *testing.T) {
 			expectedOutput: nil,
 			expectedError:  errors.New("syntax error: unexpected BEGIN, expecting COMMIT"),
 		},
+		{
+			input: "BEGIN TRANSACTION SAVEPOINT sp1; DELETE FROM table1; RELEASE SAVEPOINT sp1; ROLLBACK TO SAVEPOINT sp1; COMMIT",
+			expectedOutput: []SQLStmt{
+				&TxStmt{
+					stmts: []SQLStmt{
+						&SavepointStmt{name: "sp1"},
+						&DeleteFromStmt{tableRef: &tableRef{table: "table1"}},
+						&ReleaseSavepointStmt{name: "sp1"},
+						&RollbackToSavepointStmt{name: "sp1"},
+					},
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			input:          "SAVEPOINT sp1",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected SAVEPOINT"),
+		},
+		{
+			input:          "BEGIN TRANSACTION ROLLBACK TO sp1; COMMIT",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected IDENTIFIER, expecting SAVEPOINT"),
+		},
 	}
 
 	for i, tc := range testCases {
 		res, err := ParseString(tc.input)
-		require.Equal(t, tc.expectedError, err, fmt.Sprintf("failed on iteration %d", i))
+		require.Equal(t, tc.expectedError, errors.Unwrap(err), fmt.Sprintf("failed on iteration %d", i))
 
 		if tc.expectedError == nil {
 			require.Equal(t, tc.expectedOutput, res, fmt.Sprintf("failed on iteration %d", i))
@@ -650,6 +856,60 @@ func TestSelectStmt(t *testing.T) {
 				}},
 			expectedError: nil,
 		},
+		{
+			input: "SELECT t1.*, db1.table2.*, table2.id FROM table1 AS t1 INNER JOIN db1.table2 ON t1.fkid = table2.id",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					distinct: false,
+					selectors: []Selector{
+						&wildcardSelector{table: "t1"},
+						&wildcardSelector{db: "db1", table: "table2"},
+						&ColSelector{table: "table2", col: "id"},
+					},
+					ds: &tableRef{table: "table1", as: "t1"},
+					joins: []*JoinSpec{
+						{
+							joinType: InnerJoin,
+							ds:       &tableRef{db: "db1", table: "table2"},
+							cond: &CmpBoolExp{
+								op:    EQ,
+								left:  &ColSelector{table: "t1", col: "fkid"},
+								right: &ColSelector{table: "table2", col: "id"},
+							},
+						},
+					},
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT t1.id, t2.amount FROM table1 BEFORE TX 42 AS t1 INNER JOIN table2 AS t2 ON t1.id = t2.id",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					distinct: false,
+					selectors: []Selector{
+						&ColSelector{table: "t1", col: "id"},
+						&ColSelector{table: "t2", col: "amount"},
+					},
+					ds: &tableRef{table: "table1", asBefore: 42, as: "t1"},
+					joins: []*JoinSpec{
+						{
+							joinType: InnerJoin,
+							ds:       &tableRef{table: "table2", as: "t2"},
+							cond: &CmpBoolExp{
+								op:    EQ,
+								left:  &ColSelector{table: "t1", col: "id"},
+								right: &ColSelector{table: "t2", col: "id"},
+							},
+						},
+					},
+				}},
+			expectedError: nil,
+		},
+		{
+			input:          "SELECT t1.* AS t FROM table1 AS t1",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected AS"),
+		},
 		{
 			input: "SELECT id, title FROM db1.table1 AS t1",
 			expectedOutput: []SQLStmt{
@@ -663,6 +923,47 @@ func TestSelectStmt(t *testing.T) {
 				}},
 			expectedError: nil,
 		},
+		{
+			input: "SELECT 1 + 2",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{
+						&expSelector{exp: &NumExp{op: ADDOP, left: &Number{val: 1}, right: &Number{val: 2}}},
+					},
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT DISTINCT NOW() AS ts, 'title' title",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					distinct: true,
+					selectors: []Selector{
+						&expSelector{exp: &SysFn{fn: "now"}, as: "ts"},
+						&expSelector{exp: &Varchar{val: "title"}, as: "title"},
+					},
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT t1.id AS tid, title ttl, COUNT() AS c FROM db1.table1 AS t1 WHERE t1.id > 0",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					distinct: false,
+					selectors: []Selector{
+						&ColSelector{table: "t1", col: "id", as: "tid"},
+						&ColSelector{col: "title", as: "ttl"},
+						&AggColSelector{aggFn: COUNT, col: "*", as: "c"},
+					},
+					ds: &tableRef{db: "db1", table: "table1", as: "t1"},
+					where: &CmpBoolExp{
+						op:    GT,
+						left:  &ColSelector{table: "t1", col: "id"},
+						right: &Number{val: 0},
+					},
+				}},
+			expectedError: nil,
+		},
 		{
 			input: "SELECT t1.id, title FROM db1.table1 t1",
 			expectedOutput: []SQLStmt{
@@ -755,6 +1056,45 @@ func TestSelectStmt(t *testing.T) {
 				}},
 			expectedError: nil,
 		},
+		{
+			input: "SELECT id, title, year FROM table1 ORDER BY title NULLS LAST, year DESC NULLS FIRST",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					distinct: false,
+					selectors: []Selector{
+						&ColSelector{col: "id"},
+						&ColSelector{col: "title"},
+						&ColSelector{col: "year"},
+					},
+					ds: &tableRef{table: "table1"},
+					orderBy: []*OrdCol{
+						{sel: &ColSelector{col: "title"}, nullsOrder: NullsLast},
+						{sel: &ColSelector{col: "year"}, descOrder: true, nullsOrder: NullsFirst},
+					},
+				}},
+			expectedError: nil,
+		},
+		{
+			input:          "SELECT id FROM table1 ORDER BY title NULLS",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected $end, expecting FIRST or LAST"),
+		},
+		{
+			input: "SELECT first, t.last AS nulls FROM table1 AS t ORDER BY last DESC NULLS LAST",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					distinct: false,
+					selectors: []Selector{
+						&ColSelector{col: "first"},
+						&ColSelector{table: "t", col: "last", as: "nulls"},
+					},
+					ds: &tableRef{table: "table1", as: "t"},
+					orderBy: []*OrdCol{
+						{sel: &ColSelector{col: "last"}, descOrder: true, nullsOrder: NullsLast},
+					},
+				}},
+			expectedError: nil,
+		},
 		{
 			input: "SELECT id, name, table2.status FROM table1 INNER JOIN table2 ON table1.id = table2.id WHERE name = 'John' ORDER BY name DESC",
 			expectedOutput: []SQLStmt{
@@ -895,25 +1235,56 @@ func TestSelectStmt(t *testing.T) {
 			expectedError: nil,
 		},
 		{
-			input: "SELECT id, name, time FROM table1 WHERE time >= '20210101 00:00:00.000' AND time < '20210211 00:00:00.000'",
+			input: "SELECT id, title FROM table1 ORDER BY id LIMIT 2 OFFSET 4",
 			expectedOutput: []SQLStmt{
 				&SelectStmt{
 					distinct: false,
 					selectors: []Selector{
 						&ColSelector{col: "id"},
-						&ColSelector{col: "name"},
-						&ColSelector{col: "time"},
+						&ColSelector{col: "title"},
 					},
 					ds: &tableRef{table: "table1"},
-					where: &BinBoolExp{
-						op: AND,
-						left: &CmpBoolExp{
-							op: GE,
-							left: &ColSelector{
-								col: "time",
-							},
-							right: &Varchar{val: "20210101 00:00:00.000"},
-						},
+					orderBy: []*OrdCol{
+						{sel: &ColSelector{col: "id"}},
+					},
+					limit:  2,
+					offset: 4,
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT id FROM table1 OFFSET 1",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					distinct: false,
+					selectors: []Selector{
+						&ColSelector{col: "id"},
+					},
+					ds:     &tableRef{table: "table1"},
+					offset: 1,
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT id, name, time FROM table1 WHERE time >= '20210101 00:00:00.000' AND time < '20210211 00:00:00.000'",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					distinct: false,
+					selectors: []Selector{
+						&ColSelector{col: "id"},
+						&ColSelector{col: "name"},
+						&ColSelector{col: "time"},
+					},
+					ds: &tableRef{table: "table1"},
+					where: &BinBoolExp{
+						op: AND,
+						left: &CmpBoolExp{
+							op: GE,
+							left: &ColSelector{
+								col: "time",
+							},
+							right: &Varchar{val: "20210101 00:00:00.000"},
+						},
 						right: &CmpBoolExp{
 							op: LT,
 							left: &ColSelector{
@@ -929,7 +1300,7 @@ func TestSelectStmt(t *testing.T) {
 
 	for i, tc := range testCases {
 		res, err := ParseString(tc.input)
-		require.Equal(t, tc.expectedError, err, fmt.Sprintf("failed on iteration %d", i))
+		require.Equal(t, tc.expectedError, errors.Unwrap(err), fmt.Sprintf("failed on iteration %d", i))
 
 		if tc.expectedError == nil {
 			require.Equal(t, tc.expectedOutput, res, fmt.Sprintf("failed on iteration %d", i))
@@ -976,11 +1347,109 @@ func TestAggFnStmt(t *testing.T) {
 				}},
 			expectedError: nil,
 		},
+		{
+			input: "SELECT SUM(price * qty) AS total, MAX(-t.qty) FROM table1 AS t",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					distinct: false,
+					selectors: []Selector{
+						&AggColSelector{
+							aggFn: SUM,
+							col:   "price * qty",
+							as:    "total",
+							exp: &NumExp{
+								op:    MULTOP,
+								left:  &ColSelector{col: "price"},
+								right: &ColSelector{col: "qty"},
+							},
+						},
+						&AggColSelector{
+							aggFn: MAX,
+							col:   "-(t.qty)",
+							exp:   &NegExp{exp: &ColSelector{table: "t", col: "qty"}},
+						},
+					},
+					ds: &tableRef{table: "table1", as: "t"},
+				}},
+			expectedError: nil,
+		},
+		{
+			input:          "SELECT SUM(amount * @factor) FROM table1",
+			expectedOutput: nil,
+			expectedError:  errors.New("not yet supported: SUM argument must only contain values and columns"),
+		},
 	}
 
 	for i, tc := range testCases {
 		res, err := ParseString(tc.input)
-		require.Equal(t, tc.expectedError, err, fmt.Sprintf("failed on iteration %d", i))
+		require.Equal(t, tc.expectedError, errors.Unwrap(err), fmt.Sprintf("failed on iteration %d", i))
+
+		if tc.expectedError == nil {
+			require.Equal(t, tc.expectedOutput, res, fmt.Sprintf("failed on iteration %d", i))
+		}
+	}
+}
+
+func TestUnionStmt(t *testing.T) {
+	testCases := []struct {
+		input          string
+		expectedOutput []SQLStmt
+		expectedError  error
+	}{
+		{
+			input: "SELECT id, title FROM table1 UNION SELECT id, name FROM table2",
+			expectedOutput: []SQLStmt{
+				&UnionStmt{
+					distinct: true,
+					left: &SelectStmt{
+						selectors: []Selector{&ColSelector{col: "id"}, &ColSelector{col: "title"}},
+						ds:        &tableRef{table: "table1"},
+					},
+					right: &SelectStmt{
+						selectors: []Selector{&ColSelector{col: "id"}, &ColSelector{col: "name"}},
+						ds:        &tableRef{table: "table2"},
+					},
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT id FROM table1 UNION ALL SELECT id FROM table2 WHERE id > 0 UNION SELECT id FROM table3;",
+			expectedOutput: []SQLStmt{
+				&UnionStmt{
+					distinct: true,
+					left: &UnionStmt{
+						distinct: false,
+						left: &SelectStmt{
+							selectors: []Selector{&ColSelector{col: "id"}},
+							ds:        &tableRef{table: "table1"},
+						},
+						right: &SelectStmt{
+							selectors: []Selector{&ColSelector{col: "id"}},
+							ds:        &tableRef{table: "table2"},
+							where: &CmpBoolExp{
+								op:    GT,
+								left:  &ColSelector{col: "id"},
+								right: &Number{val: 0},
+							},
+						},
+					},
+					right: &SelectStmt{
+						selectors: []Selector{&ColSelector{col: "id"}},
+						ds:        &tableRef{table: "table3"},
+					},
+				}},
+			expectedError: nil,
+		},
+		{
+			input:          "SELECT id FROM table1 UNION ALL",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected $end, expecting SELECT"),
+		},
+	}
+
+	for i, tc := range testCases {
+		res, err := ParseString(tc.input)
+		require.Equal(t, tc.expectedError, errors.Unwrap(err), fmt.Sprintf("failed on iteration %d", i))
 
 		if tc.expectedError == nil {
 			require.Equal(t, tc.expectedOutput, res, fmt.Sprintf("failed on iteration %d", i))
@@ -1164,6 +1633,65 @@ func TestExpressions(t *testing.T) {
 				}},
 			expectedError: nil,
 		},
+		{
+			input: "SELECT id FROM table1 WHERE table1.title NOT LIKE 'J%O'",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{
+						&ColSelector{col: "id"},
+					},
+					ds: &tableRef{table: "table1"},
+					where: &LikeBoolExp{
+						val: &ColSelector{
+							table: "table1",
+							col:   "title",
+						},
+						notLike: true,
+						pattern: &Varchar{val: "J%O"},
+					},
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT id FROM table1 WHERE title ILIKE 'j%o' AND title NOT ILIKE 'jo'",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{
+						&ColSelector{col: "id"},
+					},
+					ds: &tableRef{table: "table1"},
+					where: &BinBoolExp{
+						op: AND,
+						left: &LikeBoolExp{
+							val:             &ColSelector{col: "title"},
+							caseInsensitive: true,
+							pattern:         &Varchar{val: "j%o"},
+						},
+						right: &LikeBoolExp{
+							val:             &ColSelector{col: "title"},
+							notLike:         true,
+							caseInsensitive: true,
+							pattern:         &Varchar{val: "jo"},
+						},
+					},
+				}},
+			expectedError: nil,
+		},
+		{
+			input: "SELECT id FROM table1 WHERE LOWER(title) LIKE 'a%'",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{
+						&ColSelector{col: "id"},
+					},
+					ds: &tableRef{table: "table1"},
+					where: &LikeBoolExp{
+						val:     &FnCall{fn: "lower", params: []ValueExp{&ColSelector{col: "title"}}},
+						pattern: &Varchar{val: "a%"},
+					},
+				}},
+			expectedError: nil,
+		},
 		{
 			input: "SELECT id FROM table1 WHERE (id > 0 AND NOT table1.id >= 10) OR table1.title LIKE 'J%O'",
 			expectedOutput: []SQLStmt{
@@ -1236,11 +1764,27 @@ func TestExpressions(t *testing.T) {
 				}},
 			expectedError: nil,
 		},
+		{
+			input: "SELECT id FROM table1 WHERE title IS NULL OR active IS NOT NULL",
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					selectors: []Selector{
+						&ColSelector{col: "id"},
+					},
+					ds: &tableRef{table: "table1"},
+					where: &BinBoolExp{
+						op:    OR,
+						left:  &IsNullBoolExp{exp: &ColSelector{col: "title"}},
+						right: &IsNullBoolExp{exp: &ColSelector{col: "active"}, not: true},
+					},
+				}},
+			expectedError: nil,
+		},
 	}
 
 	for i, tc := range testCases {
 		res, err := ParseString(tc.input)
-		require.Equal(t, tc.expectedError, err, fmt.Sprintf("failed on iteration %d", i))
+		require.Equal(t, tc.expectedError, errors.Unwrap(err), fmt.Sprintf("failed on iteration %d", i))
 
 		if tc.expectedError == nil {
 			require.Equal(t, tc.expectedOutput, res, fmt.Sprintf("failed on iteration %d", i))
@@ -1248,6 +1792,275 @@ func TestExpressions(t *testing.T) {
 	}
 }
 
+func TestNegExpPrecedence(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected ValueExp
+	}{
+		{
+			input:    "SELECT id FROM table1 WHERE -a * b > 0",
+			expected: &NumExp{op: MULTOP, left: &NegExp{exp: &ColSelector{col: "a"}}, right: &ColSelector{col: "b"}},
+		},
+		{
+			input:    "SELECT id FROM table1 WHERE -a + b > 0",
+			expected: &NumExp{op: ADDOP, left: &NegExp{exp: &ColSelector{col: "a"}}, right: &ColSelector{col: "b"}},
+		},
+		{
+			input:    "SELECT id FROM table1 WHERE -(a + b) > 0",
+			expected: &NegExp{exp: &NumExp{op: ADDOP, left: &ColSelector{col: "a"}, right: &ColSelector{col: "b"}}},
+		},
+		{
+			input:    "SELECT id FROM table1 WHERE - -5 > 0",
+			expected: &NegExp{exp: &NegExp{exp: &Number{val: 5}}},
+		},
+		{
+			input:    "SELECT id FROM table1 WHERE a - -b > 0",
+			expected: &NumExp{op: SUBSOP, left: &ColSelector{col: "a"}, right: &NegExp{exp: &ColSelector{col: "b"}}},
+		},
+		{
+			input:    "SELECT id FROM table1 WHERE -a % b > 0",
+			expected: &NumExp{op: MODOP, left: &NegExp{exp: &ColSelector{col: "a"}}, right: &ColSelector{col: "b"}},
+		},
+	}
+
+	for i, tc := range testCases {
+		res, err := ParseString(tc.input)
+		require.NoError(t, err, fmt.Sprintf("failed on iteration %d", i))
+		require.Len(t, res, 1)
+
+		where := res[0].(*SelectStmt).where.(*CmpBoolExp)
+		require.Equal(t, tc.expected, where.left, fmt.Sprintf("failed on iteration %d", i))
+	}
+}
+
+func TestNotExpPrecedence(t *testing.T) {
+	aEq1 := &CmpBoolExp{op: EQ, left: &ColSelector{col: "a"}, right: &Number{val: 1}}
+	bEq2 := &CmpBoolExp{op: EQ, left: &ColSelector{col: "b"}, right: &Number{val: 2}}
+
+	testCases := []struct {
+		input    string
+		expected ValueExp
+	}{
+		{
+			input:    "SELECT id FROM table1 WHERE NOT a = 1",
+			expected: &NotBoolExp{exp: aEq1},
+		},
+		{
+			input:    "SELECT id FROM table1 WHERE NOT (a = 1 AND b = 2)",
+			expected: &NotBoolExp{exp: &BinBoolExp{op: AND, left: aEq1, right: bEq2}},
+		},
+		{
+			input:    "SELECT id FROM table1 WHERE NOT a = 1 AND b = 2",
+			expected: &BinBoolExp{op: AND, left: &NotBoolExp{exp: aEq1}, right: bEq2},
+		},
+		{
+			input:    "SELECT id FROM table1 WHERE a = 1 OR NOT b = 2",
+			expected: &BinBoolExp{op: OR, left: aEq1, right: &NotBoolExp{exp: bEq2}},
+		},
+		{
+			input:    "SELECT id FROM table1 WHERE NOT NOT a = 1",
+			expected: &NotBoolExp{exp: &NotBoolExp{exp: aEq1}},
+		},
+		{
+			input:    "SELECT id FROM table1 WHERE NOT (NOT a = 1)",
+			expected: &NotBoolExp{exp: &NotBoolExp{exp: aEq1}},
+		},
+	}
+
+	for i, tc := range testCases {
+		res, err := ParseString(tc.input)
+		require.NoError(t, err, fmt.Sprintf("failed on iteration %d", i))
+		require.Len(t, res, 1)
+		require.Equal(t, tc.expected, res[0].(*SelectStmt).where, fmt.Sprintf("failed on iteration %d", i))
+	}
+}
+
+func TestIsNullPrecedence(t *testing.T) {
+	n := &ColSelector{col: "n"}
+	name := &ColSelector{col: "name"}
+
+	testCases := []struct {
+		input    string
+		expected ValueExp
+	}{
+		{
+			input:    "SELECT id FROM table1 WHERE n + 1 IS NULL",
+			expected: &IsNullBoolExp{exp: &NumExp{op: ADDOP, left: n, right: &Number{val: 1}}},
+		},
+		{
+			input:    "SELECT id FROM table1 WHERE -n IS NULL",
+			expected: &IsNullBoolExp{exp: &NegExp{exp: n}},
+		},
+		{
+			input:    "SELECT id FROM table1 WHERE n > 1 IS NOT NULL",
+			expected: &IsNullBoolExp{exp: &CmpBoolExp{op: GT, left: n, right: &Number{val: 1}}, not: true},
+		},
+		{
+			input:    "SELECT id FROM table1 WHERE name LIKE 'x' IS NULL",
+			expected: &IsNullBoolExp{exp: &LikeBoolExp{val: name, pattern: &Varchar{val: "x"}}},
+		},
+		{
+			input:    "SELECT id FROM table1 WHERE NOT n IS NULL",
+			expected: &NotBoolExp{exp: &IsNullBoolExp{exp: n}},
+		},
+		{
+			input:    "SELECT id FROM table1 WHERE n IS NULL AND name IS NOT NULL",
+			expected: &BinBoolExp{op: AND, left: &IsNullBoolExp{exp: n}, right: &IsNullBoolExp{exp: name, not: true}},
+		},
+	}
+
+	for i, tc := range testCases {
+		res, err := ParseString(tc.input)
+		require.NoError(t, err, fmt.Sprintf("failed on iteration %d", i))
+		require.Len(t, res, 1)
+		require.Equal(t, tc.expected, res[0].(*SelectStmt).where, fmt.Sprintf("failed on iteration %d", i))
+	}
+}
+
+func TestLogicOpPrecedence(t *testing.T) {
+	aEq1 := &CmpBoolExp{op: EQ, left: &ColSelector{col: "a"}, right: &Number{val: 1}}
+	bEq2 := &CmpBoolExp{op: EQ, left: &ColSelector{col: "b"}, right: &Number{val: 2}}
+	cEq3 := &CmpBoolExp{op: EQ, left: &ColSelector{col: "c"}, right: &Number{val: 3}}
+
+	testCases := []struct {
+		input    string
+		expected ValueExp
+	}{
+		{
+			input:    "SELECT id FROM table1 WHERE a = 1 AND b = 2 OR c = 3",
+			expected: &BinBoolExp{op: OR, left: &BinBoolExp{op: AND, left: aEq1, right: bEq2}, right: cEq3},
+		},
+		{
+			input:    "SELECT id FROM table1 WHERE a = 1 OR b = 2 AND c = 3",
+			expected: &BinBoolExp{op: OR, left: aEq1, right: &BinBoolExp{op: AND, left: bEq2, right: cEq3}},
+		},
+		{
+			input:    "SELECT id FROM table1 WHERE (a = 1 OR b = 2) AND c = 3",
+			expected: &BinBoolExp{op: AND, left: &BinBoolExp{op: OR, left: aEq1, right: bEq2}, right: cEq3},
+		},
+		{
+			input:    "SELECT id FROM table1 WHERE a = 1 OR b = 2 OR c = 3",
+			expected: &BinBoolExp{op: OR, left: &BinBoolExp{op: OR, left: aEq1, right: bEq2}, right: cEq3},
+		},
+		{
+			input:    "SELECT id FROM table1 WHERE NOT a = 1 OR b = 2 and NOT c = 3",
+			expected: &BinBoolExp{op: OR, left: &NotBoolExp{exp: aEq1}, right: &BinBoolExp{op: AND, left: bEq2, right: &NotBoolExp{exp: cEq3}}},
+		},
+	}
+
+	for i, tc := range testCases {
+		res, err := ParseString(tc.input)
+		require.NoError(t, err, fmt.Sprintf("failed on iteration %d", i))
+		require.Len(t, res, 1)
+		require.Equal(t, tc.expected, res[0].(*SelectStmt).where, fmt.Sprintf("failed on iteration %d", i))
+	}
+
+	_, err := ParseString("SELECT id FROM table1 WHERE a = 1 AND")
+	require.Error(t, err)
+
+	_, err = ParseString("SELECT and FROM table1")
+	require.Error(t, err)
+}
+
+func TestComparisonOperators(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected ValueExp
+	}{
+		{
+			input: "SELECT id FROM table1 WHERE a >= 1 AND b <> 2",
+			expected: &BinBoolExp{
+				op:    AND,
+				left:  &CmpBoolExp{op: GE, left: &ColSelector{col: "a"}, right: &Number{val: 1}},
+				right: &CmpBoolExp{op: NE, left: &ColSelector{col: "b"}, right: &Number{val: 2}},
+			},
+		},
+		{
+			input:    "SELECT id FROM table1 WHERE a<>b",
+			expected: &CmpBoolExp{op: NE, left: &ColSelector{col: "a"}, right: &ColSelector{col: "b"}},
+		},
+		{
+			input:    "SELECT id FROM table1 WHERE a != b",
+			expected: &CmpBoolExp{op: NE, left: &ColSelector{col: "a"}, right: &ColSelector{col: "b"}},
+		},
+		{
+			input:    "SELECT id FROM table1 WHERE a<=-1",
+			expected: &CmpBoolExp{op: LE, left: &ColSelector{col: "a"}, right: &NegExp{exp: &Number{val: 1}}},
+		},
+		{
+			input:    "SELECT id FROM table1 WHERE a < 1",
+			expected: &CmpBoolExp{op: LT, left: &ColSelector{col: "a"}, right: &Number{val: 1}},
+		},
+		{
+			input:    "SELECT id FROM table1 WHERE a>1",
+			expected: &CmpBoolExp{op: GT, left: &ColSelector{col: "a"}, right: &Number{val: 1}},
+		},
+	}
+
+	for i, tc := range testCases {
+		res, err := ParseString(tc.input)
+		require.NoError(t, err, fmt.Sprintf("failed on iteration %d", i))
+		require.Len(t, res, 1)
+		require.Equal(t, tc.expected, res[0].(*SelectStmt).where, fmt.Sprintf("failed on iteration %d", i))
+	}
+
+	for _, op := range []string{"=<", "=>", "><", "<<", "!"} {
+		_, err := ParseString("SELECT id FROM table1 WHERE a " + op + " 1")
+		require.Error(t, err, op)
+	}
+}
+
+func TestCaseExp(t *testing.T) {
+	res, err := ParseString("SELECT id FROM table1 WHERE CASE WHEN amount < 10 THEN 'small' WHEN amount < 100 THEN 'medium' ELSE 'large' END = 'medium'")
+	require.NoError(t, err)
+	require.Equal(t,
+		&CmpBoolExp{
+			op: EQ,
+			left: &CaseExp{
+				whenThens: []*whenThen{
+					{
+						when: &CmpBoolExp{op: LT, left: &ColSelector{col: "amount"}, right: &Number{val: 10}},
+						then: &Varchar{val: "small"},
+					},
+					{
+						when: &CmpBoolExp{op: LT, left: &ColSelector{col: "amount"}, right: &Number{val: 100}},
+						then: &Varchar{val: "medium"},
+					},
+				},
+				elseExp: &Varchar{val: "large"},
+			},
+			right: &Varchar{val: "medium"},
+		}, res[0].(*SelectStmt).where)
+
+	res, err = ParseString("UPDATE table1 SET active = CASE WHEN amount > 0 THEN TRUE END")
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+
+	_, err = ParseString("SELECT id FROM table1 WHERE CASE ELSE 1 END = 1")
+	require.Error(t, err)
+
+	_, err = ParseString("SELECT id FROM table1 WHERE CASE WHEN amount > 0 THEN 1 = 1")
+	require.Error(t, err)
+}
+
+func TestTruncateTableStmt(t *testing.T) {
+	res, err := ParseString("TRUNCATE TABLE table1")
+	require.NoError(t, err)
+	require.Equal(t, []SQLStmt{&TruncateTableStmt{table: "table1"}}, res)
+
+	_, err = ParseString("TRUNCATE table1")
+	require.Error(t, err)
+}
+
+func TestAnalyzeTableStmt(t *testing.T) {
+	res, err := ParseString("ANALYZE TABLE table1")
+	require.NoError(t, err)
+	require.Equal(t, []SQLStmt{&AnalyzeTableStmt{table: "table1"}}, res)
+
+	_, err = ParseString("ANALYZE table1")
+	require.Error(t, err)
+}
+
 func TestMultiLineStmts(t *testing.T) {
 	testCases := []struct {
 		input          string
@@ -1339,7 +2152,308 @@ func TestMultiLineStmts(t *testing.T) {
 
 	for i, tc := range testCases {
 		res, err := ParseString(tc.input)
-		require.Equal(t, tc.expectedError, err, fmt.Sprintf("failed on iteration %d", i))
+		require.Equal(t, tc.expectedError, errors.Unwrap(err), fmt.Sprintf("failed on iteration %d", i))
+
+		if tc.expectedError == nil {
+			require.Equal(t, tc.expectedOutput, res, fmt.Sprintf("failed on iteration %d", i))
+		}
+	}
+}
+
+func TestSpaceCharacters(t *testing.T) {
+	expected, err := ParseString("SELECT id, title FROM table1 WHERE id > 10")
+	require.NoError(t, err)
+
+	for i, input := range []string{
+		"SELECT\tid,\ttitle\tFROM\ttable1\tWHERE\tid > 10",
+		"\tSELECT id, title\n\t\tFROM table1\n\t\tWHERE id > 10",
+		"SELECT\vid,\ftitle FROM table1 WHERE id\t>\t10",
+	} {
+		res, err := ParseString(input)
+		require.NoError(t, err, fmt.Sprintf("failed on iteration %d", i))
+		require.Equal(t, expected, res, fmt.Sprintf("failed on iteration %d", i))
+	}
+}
+
+func TestCaseInsensitiveKeywords(t *testing.T) {
+	expected, err := ParseString(`
+		CREATE TABLE IF NOT EXISTS table1 (id INTEGER AUTO_INCREMENT, title VARCHAR[50] NOT NULL, active BOOLEAN, PRIMARY KEY id);
+		CREATE UNIQUE INDEX ON table1(title);
+		INSERT INTO table1 (title, active) VALUES ('title1', TRUE);
+		SELECT COUNT() AS c FROM table1 WHERE active = FALSE AND title IS NOT NULL ORDER BY id DESC`)
+	require.NoError(t, err)
+
+	for i, input := range []string{
+		`create table if not exists table1 (id integer auto_increment, title varchar[50] not null, active boolean, primary key id);
+		create unique index on table1(title);
+		insert into table1 (title, active) values ('title1', true);
+		select count() as c from table1 where active = false and title is not null order by id desc`,
+		`Create Table If Not Exists Table1 (Id Integer Auto_Increment, TITLE VarChar[50] Not Null, Active Boolean, Primary Key ID);
+		Create Unique Index On TABLE1(Title);
+		Insert Into table1 (Title, ACTIVE) Values ('title1', True);
+		Select Count() As C From Table1 Where Active = False And Title Is Not Null Order By Id Desc`,
+	} {
+		res, err := ParseString(input)
+		require.NoError(t, err, fmt.Sprintf("failed on iteration %d", i))
+		require.Equal(t, expected, res, fmt.Sprintf("failed on iteration %d", i))
+	}
+
+	// identifiers are case-folded unless quoted
+	res, err := ParseString(`Select Title, "Title" From Table1`)
+	require.NoError(t, err)
+	require.Equal(t,
+		[]Selector{&ColSelector{col: "title"}, &ColSelector{col: "Title"}},
+		res[0].(*SelectStmt).selectors)
+}
+
+func TestBLOBLiterals(t *testing.T) {
+	res, err := ParseString("INSERT INTO table1 (id, payload) VALUES (1, x'deadBEEF')")
+	require.NoError(t, err)
+	require.Equal(t,
+		[]SQLStmt{
+			&UpsertIntoStmt{
+				isInsert: true,
+				tableRef: &tableRef{table: "table1"},
+				cols:     []string{"id", "payload"},
+				rows: []*RowSpec{
+					{Values: []ValueExp{&Number{val: 1}, &Blob{val: []byte{0xde, 0xad, 0xbe, 0xef}}}},
+				},
+			},
+		}, res)
+
+	_, err = ParseString("INSERT INTO table1 (id, payload) VALUES (1, x'abc')")
+	require.ErrorIs(t, err, ErrInvalidBLOBLiteral)
+
+	_, err = ParseString("INSERT INTO table1 (id, payload) VALUES (1, x'zz')")
+	require.ErrorIs(t, err, ErrInvalidBLOBLiteral)
+}
+
+func TestQuotedIdentifiersStmt(t *testing.T) {
+	testCases := []struct {
+		input          string
+		expectedOutput []SQLStmt
+		expectedError  error
+	}{
+		{
+			input: `SELECT "select", "my col", "Say ""hi""" FROM "table" WHERE "table"."from" > 10`,
+			expectedOutput: []SQLStmt{
+				&SelectStmt{
+					distinct: false,
+					selectors: []Selector{
+						&ColSelector{col: "select"},
+						&ColSelector{col: "my col"},
+						&ColSelector{col: `Say "hi"`},
+					},
+					ds: &tableRef{table: "table"},
+					where: &CmpBoolExp{
+						op:    GT,
+						left:  &ColSelector{table: "table", col: "from"},
+						right: &Number{val: 10},
+					},
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			input: `CREATE TABLE table1 ("integer" INTEGER, "Order" VARCHAR, PRIMARY KEY "integer")`,
+			expectedOutput: []SQLStmt{
+				&CreateTableStmt{
+					table:       "table1",
+					ifNotExists: false,
+					colsSpec: []*ColSpec{
+						{colName: "integer", colType: IntegerType},
+						{colName: "Order", colType: VarcharType},
+					},
+					pkColNames: []string{"integer"},
+				},
+			},
+			expectedError: nil,
+		},
+		{
+			input:          `SELECT id FROM "table1`,
+			expectedOutput: nil,
+			expectedError:  ErrInvalidQuotedIdentifier,
+		},
+		{
+			input:          `SELECT "" FROM table1`,
+			expectedOutput: nil,
+			expectedError:  ErrInvalidQuotedIdentifier,
+		},
+	}
+
+	for i, tc := range testCases {
+		res, err := ParseString(tc.input)
+		require.ErrorIs(t, err, tc.expectedError, fmt.Sprintf("failed on iteration %d", i))
+
+		if tc.expectedError == nil {
+			require.Equal(t, tc.expectedOutput, res, fmt.Sprintf("failed on iteration %d", i))
+		}
+	}
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	for _, id := range []string{"col1", "select", "my col", "Title", `say "hi"`, "1st", "count", "varchar", "true", "and", "inner"} {
+		stmts, err := ParseString("SELECT " + quoteIdentifier(id) + " FROM table1")
+		require.NoError(t, err)
+		require.Equal(t, &ColSelector{col: id}, stmts[0].(*SelectStmt).selectors[0])
+	}
+
+	require.Equal(t, "col1", quoteIdentifier("col1"))
+	require.Equal(t, `"select"`, quoteIdentifier("select"))
+}
+
+func TestTimestampExpressions(t *testing.T) {
+	res, err := ParseString("SELECT id FROM table1 WHERE YEAR(CAST('2021-01-02T03:04:05Z' AS TIMESTAMP)) = 2021")
+	require.NoError(t, err)
+	require.Equal(t,
+		[]SQLStmt{
+			&SelectStmt{
+				ds:        &tableRef{table: "table1"},
+				selectors: []Selector{&ColSelector{col: "id"}},
+				where: &CmpBoolExp{
+					op: EQ,
+					left: &FnCall{
+						fn:     "year",
+						params: []ValueExp{&Cast{val: &Varchar{val: "2021-01-02T03:04:05Z"}, t: TimestampType}},
+					},
+					right: &Number{val: 2021},
+				},
+			},
+		}, res)
+
+	_, err = ParseString("SELECT id FROM table1 WHERE CAST('2021-01-02T03:04:05Z' AS) = 2021")
+	require.Error(t, err)
+}
+
+func TestParseErrorPosition(t *testing.T) {
+	testCases := []struct {
+		input          string
+		expectedLine   int
+		expectedColumn int
+		expectedOffset int
+		expectedToken  string
+	}{
+		{
+			input:          "CREATE TABL table1",
+			expectedLine:   1,
+			expectedColumn: 8,
+			expectedOffset: 7,
+			expectedToken:  "TABL",
+		},
+		{
+			input:          "SELECT id FROM table1\nWHERE id > 1\n  ORDER id",
+			expectedLine:   3,
+			expectedColumn: 9,
+			expectedOffset: 43,
+			expectedToken:  "id",
+		},
+		{
+			input:          "SELECT id\r\nFROM /* comment */ table1 WHERE",
+			expectedLine:   2,
+			expectedColumn: 32,
+			expectedOffset: 42,
+			expectedToken:  "",
+		},
+		{
+			input:          "INSERT INTO table1 (id, data) VALUES (1, x'a')",
+			expectedLine:   1,
+			expectedColumn: 42,
+			expectedOffset: 41,
+			expectedToken:  "x'a'",
+		},
+		{
+			input:          "CREATE DATABASE db1;\nCREATE DATABASE;",
+			expectedLine:   2,
+			expectedColumn: 16,
+			expectedOffset: 36,
+			expectedToken:  ";",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			_, err := ParseString(tc.input)
+			require.Error(t, err)
+
+			var parseErr *ParseError
+			require.True(t, errors.As(err, &parseErr))
+
+			require.Equal(t, tc.expectedLine, parseErr.Line)
+			require.Equal(t, tc.expectedColumn, parseErr.Column)
+			require.Equal(t, tc.expectedOffset, parseErr.Offset)
+			require.Equal(t, tc.expectedToken, parseErr.Token)
+			require.Equal(t, tc.expectedToken, tc.input[parseErr.Offset:parseErr.Offset+len(parseErr.Token)])
+		})
+	}
+
+	_, err := ParseString("INSERT INTO table1 (id, data) VALUES (1, x'a')")
+	require.ErrorIs(t, err, ErrInvalidBLOBLiteral)
+}
+
+func TestDescribeTableStmt(t *testing.T) {
+	testCases := []struct {
+		input          string
+		expectedOutput []SQLStmt
+		expectedError  error
+	}{
+		{
+			input:          "DESCRIBE TABLE table1",
+			expectedOutput: []SQLStmt{&DescribeTableStmt{table: &tableRef{table: "table1"}}},
+			expectedError:  nil,
+		},
+		{
+			input:          "describe table db1.table1;",
+			expectedOutput: []SQLStmt{&DescribeTableStmt{table: &tableRef{db: "db1", table: "table1"}}},
+			expectedError:  nil,
+		},
+		{
+			input:          "DESCRIBE table1",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected IDENTIFIER, expecting TABLE"),
+		},
+	}
+
+	for i, tc := range testCases {
+		res, err := ParseString(tc.input)
+		require.Equal(t, tc.expectedError, errors.Unwrap(err), fmt.Sprintf("failed on iteration %d", i))
+
+		if tc.expectedError == nil {
+			require.Equal(t, tc.expectedOutput, res, fmt.Sprintf("failed on iteration %d", i))
+		}
+	}
+}
+
+func TestShowStmt(t *testing.T) {
+	testCases := []struct {
+		input          string
+		expectedOutput []SQLStmt
+		expectedError  error
+	}{
+		{
+			input:          "SHOW TABLES",
+			expectedOutput: []SQLStmt{&ShowTablesStmt{}},
+			expectedError:  nil,
+		},
+		{
+			input:          "show databases;",
+			expectedOutput: []SQLStmt{&ShowDatabasesStmt{}},
+			expectedError:  nil,
+		},
+		{
+			input:          "CREATE DATABASE db1; SHOW DATABASES",
+			expectedOutput: []SQLStmt{&CreateDatabaseStmt{DB: "db1"}, &ShowDatabasesStmt{}},
+			expectedError:  nil,
+		},
+		{
+			input:          "SHOW INDEXES",
+			expectedOutput: nil,
+			expectedError:  errors.New("syntax error: unexpected IDENTIFIER, expecting TABLES or DATABASES"),
+		},
+	}
+
+	for i, tc := range testCases {
+		res, err := ParseString(tc.input)
+		require.Equal(t, tc.expectedError, errors.Unwrap(err), fmt.Sprintf("failed on iteration %d", i))
 
 		if tc.expectedError == nil {
 			require.Equal(t, tc.expectedOutput, res, fmt.Sprintf("failed on iteration %d", i))
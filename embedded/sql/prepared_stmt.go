@@ -0,0 +1,85 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sql
+
+import "strings"
+
+// PreparedStmt holds statements parsed once and executed multiple times.
+// Parameter substitution does not mutate the parsed statements, so they
+// can be safely reused across executions.
+type PreparedStmt struct {
+	e      *Engine
+	stmts  []SQLStmt
+	params map[string]SQLValueType
+}
+
+func (e *Engine) Prepare(sql string) (*PreparedStmt, error) {
+	stmts, err := Parse(strings.NewReader(sql))
+	if err != nil {
+		return nil, err
+	}
+
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	if e.closed {
+		return nil, ErrAlreadyClosed
+	}
+
+	// TODO (jeroiraz): won't be needed when in-memory catalog becomes transactional
+	if e.catalog == nil {
+		return nil, ErrCatalogNotReady
+	}
+
+	params, err := e.inferParametersOf(stmts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PreparedStmt{
+		e:      e,
+		stmts:  stmts,
+		params: params,
+	}, nil
+}
+
+// Parameters returns the types inferred for the parameters of the prepared statements
+func (ps *PreparedStmt) Parameters() map[string]SQLValueType {
+	params := make(map[string]SQLValueType, len(ps.params))
+
+	for name, t := range ps.params {
+		params[name] = t
+	}
+
+	return params
+}
+
+func (ps *PreparedStmt) Exec(params map[string]interface{}, waitForIndexing bool) (*ExecSummary, error) {
+	return ps.e.ExecPreparedStmts(ps.stmts, params, waitForIndexing)
+}
+
+func (ps *PreparedStmt) Query(params map[string]interface{}, renewSnapshot bool) (RowReader, error) {
+	if len(ps.stmts) != 1 {
+		return nil, ErrExpectingDQLStmt
+	}
+
+	stmt, ok := ps.stmts[0].(DQLStmt)
+	if !ok {
+		return nil, ErrExpectingDQLStmt
+	}
+
+	return ps.e.QueryPreparedStmt(stmt, params, renewSnapshot)
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sql
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreparedStmt(t *testing.T) {
+	catalogStore, err := store.Open("catalog_prepared_stmt", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_prepared_stmt")
+
+	dataStore, err := store.Open("sqldata_prepared_stmt", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_prepared_stmt")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = engine.Prepare("SELECT id FROM table1")
+	require.ErrorIs(t, err, ErrCatalogNotReady)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	_, err = engine.Prepare("INSERT INTO table1 (id, title) VALUES (@id")
+	require.Error(t, err)
+
+	insertStmt, err := engine.Prepare("INSERT INTO table1 (id, title) VALUES (@id, @title)")
+	require.NoError(t, err)
+	require.Equal(t, map[string]SQLValueType{"id": IntegerType, "title": VarcharType}, insertStmt.Parameters())
+
+	_, err = insertStmt.Query(nil, true)
+	require.ErrorIs(t, err, ErrExpectingDQLStmt)
+
+	for i := 1; i <= 3; i++ {
+		_, err = insertStmt.Exec(map[string]interface{}{"id": i, "title": fmt.Sprintf("title%d", i)}, true)
+		require.NoError(t, err)
+	}
+
+	_, err = insertStmt.Exec(map[string]interface{}{"id": 4}, true)
+	require.ErrorIs(t, err, ErrMissingParameter)
+
+	queryStmt, err := engine.Prepare("SELECT title FROM table1 WHERE id = @id AND title != @title")
+	require.NoError(t, err)
+	require.Equal(t, map[string]SQLValueType{"id": IntegerType, "title": VarcharType}, queryStmt.Parameters())
+
+	for i := 1; i <= 3; i++ {
+		r, err := queryStmt.Query(map[string]interface{}{"id": i, "title": ""}, true)
+		require.NoError(t, err)
+
+		row, err := r.Read()
+		require.NoError(t, err)
+		require.Equal(t, fmt.Sprintf("title%d", i), row.Values[EncodeSelector("", "db1", "table1", "title")].Value())
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreRows)
+
+		err = r.Close()
+		require.NoError(t, err)
+	}
+
+	r, err := queryStmt.Query(map[string]interface{}{"id": 1, "title": "title1"}, true)
+	require.NoError(t, err)
+
+	_, err = r.Read()
+	require.ErrorIs(t, err, ErrNoMoreRows)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	r, err = queryStmt.Query(map[string]interface{}{"id": 1}, true)
+	require.NoError(t, err)
+
+	_, err = r.Read()
+	require.ErrorIs(t, err, ErrMissingParameter)
+
+	err = r.Close()
+	require.NoError(t, err)
+}
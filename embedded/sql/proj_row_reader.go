@@ -25,23 +25,50 @@ type projectedRowReader struct {
 	tableAlias string
 
 	selectors []Selector
+
+	params map[string]interface{}
+
+	// selected expressions with parameters already substituted, reset whenever parameters change
+	substitutedExps map[int]ValueExp
 }
 
-func (e *Engine) newProjectedRowReader(rowReader RowReader, tableAlias string, selectors []Selector) (*projectedRowReader, error) {
+func (e *Engine) newProjectedRowReader(rowReader RowReader, tableAlias string, selectors []Selector, params map[string]interface{}) (*projectedRowReader, error) {
 	// case: SELECT *
 	if len(selectors) == 0 {
-		cols, err := rowReader.Columns()
-		if err != nil {
-			return nil, err
+		selectors = []Selector{&wildcardSelector{}}
+	}
+
+	selectors, err := expandWildcards(rowReader, selectors)
+	if err != nil {
+		return nil, err
+	}
+
+	// unaliased expressions are named after their position e.g. col1
+	for i, sel := range selectors {
+		esel, isExp := sel.(*expSelector)
+		if isExp && esel.as == "" {
+			selectors[i] = &expSelector{exp: esel.exp, as: fmt.Sprintf("col%d", i)}
 		}
+	}
 
-		for _, col := range cols {
-			sel := &ColSelector{
-				db:    col.Database,
-				table: col.Table,
-				col:   col.Column,
-			}
-			selectors = append(selectors, sel)
+	// unaliased aggregations named alike are numbered after the first one e.g. sum(id) and sum(id)_1
+	aggNames := make(map[string]int)
+
+	for i, sel := range selectors {
+		aggSel, isAggregation := sel.(*AggColSelector)
+		if !isAggregation || aggSel.as != "" {
+			continue
+		}
+
+		name := aggSel.alias()
+
+		n := aggNames[name]
+		aggNames[name] = n + 1
+
+		if n > 0 {
+			renamed := *aggSel
+			renamed.as = fmt.Sprintf("%s_%d", name, n)
+			selectors[i] = &renamed
 		}
 	}
 
@@ -50,9 +77,56 @@ func (e *Engine) newProjectedRowReader(rowReader RowReader, tableAlias string, s
 		rowReader:  rowReader,
 		tableAlias: tableAlias,
 		selectors:  selectors,
+		params:     params,
 	}, nil
 }
 
+// expandWildcards replaces every wildcard selector with the columns of the table it refers to,
+// an unqualified wildcard expands to the columns of all the tables
+func expandWildcards(rowReader RowReader, selectors []Selector) ([]Selector, error) {
+	var cols []ColDescriptor
+	var expanded []Selector
+
+	for _, sel := range selectors {
+		wsel, isWildcard := sel.(*wildcardSelector)
+		if !isWildcard {
+			expanded = append(expanded, sel)
+			continue
+		}
+
+		if cols == nil {
+			var err error
+
+			cols, err = rowReader.Columns()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		matched := false
+
+		for _, col := range cols {
+			if (wsel.db != "" && wsel.db != col.Database) || (wsel.table != "" && wsel.table != col.Table) {
+				continue
+			}
+
+			expanded = append(expanded, &ColSelector{
+				db:    col.Database,
+				table: col.Table,
+				col:   col.Column,
+			})
+
+			matched = true
+		}
+
+		if !matched && wsel.table != "" {
+			return nil, fmt.Errorf("%w (%s)", ErrTableDoesNotExist, wsel.table)
+		}
+	}
+
+	return expanded, nil
+}
+
 func (pr *projectedRowReader) ImplicitDB() string {
 	return pr.rowReader.ImplicitDB()
 }
@@ -96,9 +170,6 @@ func (pr *projectedRowReader) Columns() ([]ColDescriptor, error) {
 		if aggFn != "" {
 			aggFn = ""
 			col = sel.alias()
-			if col == "" {
-				col = fmt.Sprintf("col%d", i)
-			}
 		}
 
 		colsByPos[i] = ColDescriptor{
@@ -124,14 +195,24 @@ func (pr *projectedRowReader) colsBySelector() (map[string]ColDescriptor, error)
 
 	colDescriptors := make(map[string]ColDescriptor, len(pr.selectors))
 
-	for i, sel := range pr.selectors {
+	for _, sel := range pr.selectors {
 		aggFn, db, table, col := sel.resolve(pr.rowReader.ImplicitDB(), pr.rowReader.ImplicitTable())
 
-		encSel := EncodeSelector(aggFn, db, table, col)
+		var colType SQLValueType
+
+		esel, isExp := sel.(*expSelector)
+		if isExp {
+			colType, err = esel.inferType(dsColDescriptors, map[string]SQLValueType{}, pr.rowReader.ImplicitDB(), pr.rowReader.ImplicitTable())
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			colDesc, ok := dsColDescriptors[EncodeSelector(aggFn, db, table, col)]
+			if !ok {
+				return nil, ErrColumnDoesNotExist
+			}
 
-		colDesc, ok := dsColDescriptors[encSel]
-		if !ok {
-			return nil, ErrColumnDoesNotExist
+			colType = colDesc.Type
 		}
 
 		if pr.tableAlias != "" {
@@ -146,9 +227,6 @@ func (pr *projectedRowReader) colsBySelector() (map[string]ColDescriptor, error)
 		if aggFn != "" {
 			aggFn = ""
 			col = sel.alias()
-			if col == "" {
-				col = fmt.Sprintf("col%d", i)
-			}
 		}
 
 		des := ColDescriptor{
@@ -156,7 +234,7 @@ func (pr *projectedRowReader) colsBySelector() (map[string]ColDescriptor, error)
 			Database: db,
 			Table:    table,
 			Column:   col,
-			Type:     colDesc.Type,
+			Type:     colType,
 		}
 
 		colDescriptors[des.Selector()] = des
@@ -166,11 +244,62 @@ func (pr *projectedRowReader) colsBySelector() (map[string]ColDescriptor, error)
 }
 
 func (pr *projectedRowReader) InferParameters(params map[string]SQLValueType) error {
-	return pr.rowReader.InferParameters(params)
+	err := pr.rowReader.InferParameters(params)
+	if err != nil {
+		return err
+	}
+
+	cols, err := pr.rowReader.colsBySelector()
+	if err != nil {
+		return err
+	}
+
+	for _, sel := range pr.selectors {
+		esel, isExp := sel.(*expSelector)
+		if !isExp {
+			continue
+		}
+
+		_, err = esel.inferType(cols, params, pr.rowReader.ImplicitDB(), pr.rowReader.ImplicitTable())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (pr *projectedRowReader) SetParameters(params map[string]interface{}) error {
-	return pr.rowReader.SetParameters(params)
+	err := pr.rowReader.SetParameters(params)
+	if err != nil {
+		return err
+	}
+
+	pr.params, err = pr.e.normalizeParams(params)
+	pr.substitutedExps = nil
+
+	return err
+}
+
+// reduceExp returns the value of the selected expression for the row
+func (pr *projectedRowReader) reduceExp(i int, esel *expSelector, row *Row) (TypedValue, error) {
+	if pr.substitutedExps == nil {
+		pr.substitutedExps = make(map[int]ValueExp)
+	}
+
+	exp, ok := pr.substitutedExps[i]
+	if !ok {
+		var err error
+
+		exp, err = esel.substitute(pr.params)
+		if err != nil {
+			return nil, err
+		}
+
+		pr.substitutedExps[i] = exp
+	}
+
+	return exp.reduce(pr.e.catalog, row, pr.rowReader.ImplicitDB(), pr.rowReader.ImplicitTable())
 }
 
 func (pr *projectedRowReader) Read() (*Row, error) {
@@ -186,11 +315,21 @@ func (pr *projectedRowReader) Read() (*Row, error) {
 	for i, sel := range pr.selectors {
 		aggFn, db, table, col := sel.resolve(pr.rowReader.ImplicitDB(), pr.rowReader.ImplicitTable())
 
-		encSel := EncodeSelector(aggFn, db, table, col)
+		var val TypedValue
+
+		esel, isExp := sel.(*expSelector)
+		if isExp {
+			val, err = pr.reduceExp(i, esel, row)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			var ok bool
 
-		val, ok := row.Values[encSel]
-		if !ok {
-			return nil, ErrColumnDoesNotExist
+			val, ok = row.Values[EncodeSelector(aggFn, db, table, col)]
+			if !ok {
+				return nil, ErrColumnDoesNotExist
+			}
 		}
 
 		if pr.tableAlias != "" {
@@ -205,9 +344,6 @@ func (pr *projectedRowReader) Read() (*Row, error) {
 		if aggFn != "" {
 			aggFn = ""
 			col = sel.alias()
-			if col == "" {
-				col = fmt.Sprintf("col%d", i)
-			}
 		}
 
 		prow.Values[EncodeSelector(aggFn, db, table, col)] = val
@@ -216,6 +352,10 @@ func (pr *projectedRowReader) Read() (*Row, error) {
 	return prow, nil
 }
 
+func (pr *projectedRowReader) Rewind() error {
+	return pr.rowReader.Rewind()
+}
+
 func (pr *projectedRowReader) Close() error {
 	return pr.rowReader.Close()
 }
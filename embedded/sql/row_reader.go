@@ -17,8 +17,12 @@ limitations under the License.
 package sql
 
 import (
+	"bytes"
+	"context"
 	"crypto/sha256"
 	"encoding/binary"
+	"encoding/json"
+	"time"
 
 	"github.com/codenotary/immudb/embedded/store"
 )
@@ -28,6 +32,8 @@ type RowReader interface {
 	ImplicitTable() string
 	SetParameters(params map[string]interface{}) error
 	Read() (*Row, error)
+	// Rewind repositions the reader at the beginning of its rows so they can be read again
+	Rewind() error
 	Close() error
 	Columns() ([]ColDescriptor, error)
 	OrderBy() []ColDescriptor
@@ -40,6 +46,11 @@ type Row struct {
 	Values map[string]TypedValue
 }
 
+// MarshalJSON encodes the row as an object mapping each encoded selector to its value
+func (row *Row) MarshalJSON() ([]byte, error) {
+	return json.Marshal(row.Values)
+}
+
 // rows are selector-compatible if both rows have the same assigned value for all specified selectors
 func (row *Row) compatible(aRow *Row, selectors []*ColSelector, db, table string) (bool, error) {
 	for _, sel := range selectors {
@@ -69,14 +80,24 @@ func (row *Row) compatible(aRow *Row, selectors []*ColSelector, db, table string
 }
 
 func (row *Row) digest(cols []ColDescriptor) (d [sha256.Size]byte, err error) {
-	h := sha256.New()
+	encRow, err := row.encode(cols)
+	if err != nil {
+		return d, err
+	}
+
+	return sha256.Sum256(encRow), nil
+}
+
+// encode serializes the values of the given columns, null values are omitted
+func (row *Row) encode(cols []ColDescriptor) ([]byte, error) {
+	var b bytes.Buffer
 
 	for i, col := range cols {
 		v := row.Values[col.Selector()]
 
-		var b [4]byte
-		binary.BigEndian.PutUint32(b[:], uint32(i))
-		h.Write(b[:])
+		var pos [4]byte
+		binary.BigEndian.PutUint32(pos[:], uint32(i))
+		b.Write(pos[:])
 
 		_, isNull := v.(*NullValue)
 		if isNull {
@@ -85,18 +106,28 @@ func (row *Row) digest(cols []ColDescriptor) (d [sha256.Size]byte, err error) {
 
 		encVal, err := EncodeValue(v.Value(), v.Type(), 0)
 		if err != nil {
-			return d, err
+			return nil, err
 		}
 
-		h.Write(encVal)
+		b.Write(encVal)
 	}
 
-	copy(d[:], h.Sum(nil))
+	return b.Bytes(), nil
+}
 
-	return
+// system columns can be selected from any table without being part of it,
+// they hold the id and the timestamp of the transaction that last wrote the row
+const (
+	TxIDColumn        = "_tx"
+	TxTimestampColumn = "_ts"
+)
+
+func isSystemColumn(colName string) bool {
+	return colName == TxIDColumn || colName == TxTimestampColumn
 }
 
 type rawRowReader struct {
+	ctx        context.Context
 	e          *Engine
 	snap       *store.Snapshot
 	table      *Table
@@ -106,6 +137,9 @@ type rawRowReader struct {
 	colsBySel  map[string]ColDescriptor
 	scanSpecs  *ScanSpecs
 	reader     *store.KeyReader
+
+	// holder used to read the timestamp of the transaction of each row, allocated on first use
+	tx *store.Tx
 }
 
 type ColDescriptor struct {
@@ -120,8 +154,8 @@ func (d *ColDescriptor) Selector() string {
 	return EncodeSelector(d.AggFn, d.Database, d.Table, d.Column)
 }
 
-func (e *Engine) newRawRowReader(snap *store.Snapshot, table *Table, asBefore uint64, tableAlias string, scanSpecs *ScanSpecs) (*rawRowReader, error) {
-	if snap == nil || table == nil || scanSpecs == nil || scanSpecs.index == nil {
+func (e *Engine) newRawRowReader(ctx context.Context, snap *store.Snapshot, table *Table, asBefore uint64, tableAlias string, scanSpecs *ScanSpecs) (*rawRowReader, error) {
+	if ctx == nil || snap == nil || table == nil || scanSpecs == nil || scanSpecs.index == nil {
 		return nil, ErrIllegalArguments
 	}
 
@@ -154,7 +188,23 @@ func (e *Engine) newRawRowReader(snap *store.Snapshot, table *Table, asBefore ui
 		colsBySel[colDescriptor.Selector()] = colDescriptor
 	}
 
+	// system columns are not returned as columns of the table, so they are only provided when explicitly selected
+	for colName, colType := range map[string]SQLValueType{TxIDColumn: IntegerType, TxTimestampColumn: TimestampType} {
+		colDescriptor := ColDescriptor{
+			Database: table.db.name,
+			Table:    tableAlias,
+			Column:   colName,
+			Type:     colType,
+		}
+
+		_, shadowed := colsBySel[colDescriptor.Selector()]
+		if !shadowed {
+			colsBySel[colDescriptor.Selector()] = colDescriptor
+		}
+	}
+
 	return &rawRowReader{
+		ctx:        ctx,
 		e:          e,
 		snap:       snap,
 		table:      table,
@@ -207,7 +257,7 @@ func keyReaderSpecFrom(e *Engine, table *Table, scanSpecs *ScanSpecs) (spec *sto
 				}
 
 				if colRange.hRange != nil {
-					encVal, err := EncodeAsKey(colRange.hRange.val.Value(), col.colType, col.MaxLen())
+					encVal, err := col.encodeAsKey(colRange.hRange.val.Value())
 					if err != nil {
 						return nil, err
 					}
@@ -219,7 +269,7 @@ func keyReaderSpecFrom(e *Engine, table *Table, scanSpecs *ScanSpecs) (spec *sto
 				endKeyReady = colRange.lRange == nil
 
 				if colRange.lRange != nil {
-					encVal, err := EncodeAsKey(colRange.lRange.val.Value(), col.colType, col.MaxLen())
+					encVal, err := col.encodeAsKey(colRange.lRange.val.Value())
 					if err != nil {
 						return nil, err
 					}
@@ -233,7 +283,7 @@ func keyReaderSpecFrom(e *Engine, table *Table, scanSpecs *ScanSpecs) (spec *sto
 				seekKeyReady = colRange.lRange == nil
 
 				if colRange.lRange != nil {
-					encVal, err := EncodeAsKey(colRange.lRange.val.Value(), col.colType, col.MaxLen())
+					encVal, err := col.encodeAsKey(colRange.lRange.val.Value())
 					if err != nil {
 						return nil, err
 					}
@@ -247,7 +297,7 @@ func keyReaderSpecFrom(e *Engine, table *Table, scanSpecs *ScanSpecs) (spec *sto
 				}
 
 				if colRange.hRange != nil {
-					encVal, err := EncodeAsKey(colRange.hRange.val.Value(), col.colType, col.MaxLen())
+					encVal, err := col.encodeAsKey(colRange.hRange.val.Value())
 					if err != nil {
 						return nil, err
 					}
@@ -336,6 +386,13 @@ func (r *rawRowReader) SetParameters(params map[string]interface{}) error {
 }
 
 func (r *rawRowReader) Read() (row *Row, err error) {
+	// readers on top of this one may keep reading rows without returning any e.g. conditional readers,
+	// thus a cancelled scan is stopped at this level
+	err = r.ctx.Err()
+	if err != nil {
+		return nil, err
+	}
+
 	var mkey []byte
 	var vref *store.ValueRef
 
@@ -352,6 +409,12 @@ func (r *rawRowReader) Read() (row *Row, err error) {
 
 	//decompose key, determine if it's pk, when it's pk, the value holds the actual row data
 	if r.scanSpecs.index.IsPrimary() {
+		if r.scanSpecs.indexOnly {
+			// only primary key values are used by the query e.g. SELECT COUNT() FROM table1,
+			// thus rows are not read but decoded from the keys
+			return r.rowFromIndexEntry(mkey, nil)
+		}
+
 		v, err = vref.Resolve()
 		if err != nil {
 			return nil, err
@@ -373,6 +436,11 @@ func (r *rawRowReader) Read() (row *Row, err error) {
 			}
 		}
 
+		if r.scanSpecs.indexOnly {
+			// all the columns used by the query are part of the index entry, thus the row is not fetched
+			return r.rowFromIndexEntry(mkey, encPKVals)
+		}
+
 		vref, err = r.snap.Get(r.e.mapKey(PIndexPrefix, EncodeID(r.table.db.id), EncodeID(r.table.id), EncodeID(PKIndexID), encPKVals))
 		if err != nil {
 			return nil, err
@@ -384,7 +452,12 @@ func (r *rawRowReader) Read() (row *Row, err error) {
 		}
 	}
 
-	values := make(map[string]TypedValue, len(r.table.Cols()))
+	values := make(map[string]TypedValue, len(r.table.Cols())+2)
+
+	err = r.addSystemValues(values, vref)
+	if err != nil {
+		return nil, err
+	}
 
 	for _, col := range r.table.Cols() {
 		values[EncodeSelector("", r.table.db.name, r.tableAlias, col.colName)] = &NullValue{t: col.colType}
@@ -418,7 +491,7 @@ func (r *rawRowReader) Read() (row *Row, err error) {
 		}
 
 		voff += n
-		values[EncodeSelector("", r.table.db.name, r.tableAlias, col.colName)] = val
+		values[EncodeSelector("", r.table.db.name, r.tableAlias, col.colName)] = col.collate(val)
 	}
 
 	if len(v)-voff > 0 {
@@ -428,6 +501,65 @@ func (r *rawRowReader) Read() (row *Row, err error) {
 	return &Row{Values: values}, nil
 }
 
+// rowFromIndexEntry builds the row out of the values encoded in the index entry,
+// the columns not included in the index are set to NULL
+func (r *rawRowReader) rowFromIndexEntry(mkey []byte, encPKVals []byte) (*Row, error) {
+	valuesByColID, err := r.e.unmapIndexEntryValues(r.scanSpecs.index, mkey, encPKVals)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]TypedValue, len(r.table.Cols()))
+
+	for _, col := range r.table.Cols() {
+		var val TypedValue = &NullValue{t: col.colType}
+
+		v, ok := valuesByColID[col.id]
+		if ok {
+			val = col.collate(v)
+		}
+
+		values[EncodeSelector("", r.table.db.name, r.tableAlias, col.colName)] = val
+	}
+
+	return &Row{Values: values}, nil
+}
+
+// addSystemValues sets the values of the system columns, the columns of the table take precedence over them
+func (r *rawRowReader) addSystemValues(values map[string]TypedValue, vref *store.ValueRef) error {
+	values[EncodeSelector("", r.table.db.name, r.tableAlias, TxIDColumn)] = &Number{val: int64(vref.Tx())}
+
+	// reading the transaction is only worth it when its timestamp is used
+	if !r.scanSpecs.txTimestamps {
+		return nil
+	}
+
+	if r.tx == nil || r.tx.ID != vref.Tx() {
+		if r.tx == nil {
+			r.tx = r.e.dataStore.NewTx()
+		}
+
+		err := r.e.dataStore.ReadTx(vref.Tx(), r.tx)
+		if err != nil {
+			return err
+		}
+	}
+
+	values[EncodeSelector("", r.table.db.name, r.tableAlias, TxTimestampColumn)] = &Timestamp{val: time.Unix(r.tx.Ts, 0).UnixNano()}
+
+	return nil
+}
+
+func (r *rawRowReader) Rewind() error {
+	err := r.reader.Reset()
+	// an empty range can not be sought, the reader is exhausted anyway
+	if err != nil && err != store.ErrKeyNotFound {
+		return err
+	}
+
+	return nil
+}
+
 func (r *rawRowReader) Close() error {
 	return r.reader.Close()
 }
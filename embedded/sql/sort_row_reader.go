@@ -0,0 +1,478 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sql
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// sortRowReader returns the rows of the underlying reader ordered by a single column which is not indexed,
+// all the rows are read and sorted before the first one is returned. Once the sort spill threshold is reached,
+// the rows are sorted in runs written into temporary files and merged while being read
+type sortRowReader struct {
+	e *Engine
+
+	rowReader RowReader
+
+	ordCol  *OrdCol
+	orderBy ColDescriptor
+
+	rows []*Row
+	read int
+
+	// sorted runs spilled into temporary files
+	spillDir string
+	runs     []*sortedRun
+}
+
+// sortedRun reads back the rows of a spilled run, head holds the next row to be returned
+type sortedRun struct {
+	f    *os.File
+	r    *bufio.Reader
+	head *Row
+}
+
+func (e *Engine) newSortRowReader(rowReader RowReader, ordCol *OrdCol) (*sortRowReader, error) {
+	if rowReader == nil || ordCol == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	cols, err := rowReader.colsBySelector()
+	if err != nil {
+		return nil, err
+	}
+
+	orderBy, ok := cols[EncodeSelector(ordCol.sel.resolve(rowReader.ImplicitDB(), rowReader.ImplicitTable()))]
+	if !ok {
+		return nil, ErrColumnDoesNotExist
+	}
+
+	return &sortRowReader{
+		e:         e,
+		rowReader: rowReader,
+		ordCol:    ordCol,
+		orderBy:   orderBy,
+	}, nil
+}
+
+func (sr *sortRowReader) ImplicitDB() string {
+	return sr.rowReader.ImplicitDB()
+}
+
+func (sr *sortRowReader) ImplicitTable() string {
+	return sr.rowReader.ImplicitTable()
+}
+
+func (sr *sortRowReader) SetParameters(params map[string]interface{}) error {
+	return sr.rowReader.SetParameters(params)
+}
+
+func (sr *sortRowReader) OrderBy() []ColDescriptor {
+	return []ColDescriptor{sr.orderBy}
+}
+
+func (sr *sortRowReader) ScanSpecs() *ScanSpecs {
+	return sr.rowReader.ScanSpecs()
+}
+
+func (sr *sortRowReader) Columns() ([]ColDescriptor, error) {
+	return sr.rowReader.Columns()
+}
+
+func (sr *sortRowReader) colsBySelector() (map[string]ColDescriptor, error) {
+	return sr.rowReader.colsBySelector()
+}
+
+func (sr *sortRowReader) InferParameters(params map[string]SQLValueType) error {
+	return sr.rowReader.InferParameters(params)
+}
+
+func (sr *sortRowReader) Read() (*Row, error) {
+	if sr.rows == nil {
+		err := sr.sortRows()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(sr.runs) > 0 {
+		return sr.mergeRuns()
+	}
+
+	if sr.read >= len(sr.rows) {
+		return nil, ErrNoMoreRows
+	}
+
+	row := sr.rows[sr.read]
+	sr.read++
+
+	return row, nil
+}
+
+// sortRows reads all the rows of the underlying reader and sorts them,
+// rows are spilled in sorted runs every time the spill threshold is reached
+func (sr *sortRowReader) sortRows() error {
+	rows := []*Row{}
+
+	for {
+		row, err := sr.rowReader.Read()
+		if err == ErrNoMoreRows {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		rows = append(rows, row)
+
+		if sr.e.sortSpillThld > 0 && len(rows) == sr.e.sortSpillThld {
+			err = sr.spill(rows)
+			if err != nil {
+				return err
+			}
+
+			rows = []*Row{}
+		}
+	}
+
+	if len(sr.runs) == 0 {
+		err := sr.sort(rows)
+		if err != nil {
+			return err
+		}
+
+		sr.rows = rows
+
+		return nil
+	}
+
+	if len(rows) > 0 {
+		err := sr.spill(rows)
+		if err != nil {
+			return err
+		}
+	}
+
+	sr.rows = []*Row{}
+
+	for _, run := range sr.runs {
+		_, err := run.f.Seek(0, io.SeekStart)
+		if err != nil {
+			return err
+		}
+
+		run.r = bufio.NewReader(run.f)
+
+		err = run.next()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (sr *sortRowReader) sort(rows []*Row) error {
+	encSel := sr.orderBy.Selector()
+
+	var err error
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		if err != nil {
+			return false
+		}
+
+		var less bool
+		less, err = sr.ordCol.less(rows[i].Values[encSel], rows[j].Values[encSel])
+		return less
+	})
+
+	return err
+}
+
+// spill sorts the rows and writes them into a new temporary file as len(row) + row
+func (sr *sortRowReader) spill(rows []*Row) error {
+	err := sr.sort(rows)
+	if err != nil {
+		return err
+	}
+
+	if sr.spillDir == "" {
+		dir, err := ioutil.TempDir("", "immudb_sort")
+		if err != nil {
+			return err
+		}
+
+		sr.spillDir = dir
+	}
+
+	f, err := os.Create(filepath.Join(sr.spillDir, strconv.Itoa(len(sr.runs))))
+	if err != nil {
+		return err
+	}
+
+	sr.runs = append(sr.runs, &sortedRun{f: f})
+
+	w := bufio.NewWriter(f)
+
+	for _, row := range rows {
+		encRow, err := encodeSpilledRow(row)
+		if err != nil {
+			return err
+		}
+
+		var encLen [EncLenLen]byte
+		binary.BigEndian.PutUint32(encLen[:], uint32(len(encRow)))
+
+		_, err = w.Write(encLen[:])
+		if err != nil {
+			return err
+		}
+
+		_, err = w.Write(encRow)
+		if err != nil {
+			return err
+		}
+	}
+
+	return w.Flush()
+}
+
+// mergeRuns returns the first row among the heads of the runs,
+// ties are resolved in favour of the earliest run so the sort remains stable
+func (sr *sortRowReader) mergeRuns() (*Row, error) {
+	encSel := sr.orderBy.Selector()
+
+	var next *sortedRun
+
+	for _, run := range sr.runs {
+		if run.head == nil {
+			continue
+		}
+
+		if next == nil {
+			next = run
+			continue
+		}
+
+		less, err := sr.ordCol.less(run.head.Values[encSel], next.head.Values[encSel])
+		if err != nil {
+			return nil, err
+		}
+
+		if less {
+			next = run
+		}
+	}
+
+	if next == nil {
+		return nil, ErrNoMoreRows
+	}
+
+	row := next.head
+
+	err := next.next()
+	if err != nil {
+		return nil, err
+	}
+
+	return row, nil
+}
+
+// next reads the following row of the run, head is set to nil once all the rows were read
+func (run *sortedRun) next() error {
+	var encLen [EncLenLen]byte
+
+	_, err := io.ReadFull(run.r, encLen[:])
+	if err == io.EOF {
+		run.head = nil
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	encRow := make([]byte, binary.BigEndian.Uint32(encLen[:]))
+
+	_, err = io.ReadFull(run.r, encRow)
+	if err != nil {
+		return err
+	}
+
+	run.head, err = decodeSpilledRow(encRow)
+
+	return err
+}
+
+// encodeSpilledRow serializes every value of the row along with its selector and type,
+// as {count}({len(sel)}{sel}{len(type)}{type}{null}{value})*, the value is omitted when null
+func encodeSpilledRow(row *Row) ([]byte, error) {
+	sels := make([]string, 0, len(row.Values))
+	for sel := range row.Values {
+		sels = append(sels, sel)
+	}
+	sort.Strings(sels)
+
+	b := make([]byte, EncLenLen)
+	binary.BigEndian.PutUint32(b, uint32(len(sels)))
+
+	for _, sel := range sels {
+		v := row.Values[sel]
+
+		b = appendSpilledString(b, sel)
+		b = appendSpilledString(b, v.Type())
+
+		_, isNull := v.(*NullValue)
+		if isNull {
+			b = append(b, 1)
+			continue
+		}
+
+		b = append(b, 0)
+
+		var err error
+
+		b, err = appendEncodedValue(b, v.Value(), v.Type(), 0)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
+
+func appendSpilledString(b []byte, s string) []byte {
+	var encLen [EncLenLen]byte
+	binary.BigEndian.PutUint32(encLen[:], uint32(len(s)))
+
+	b = append(b, encLen[:]...)
+	return append(b, s...)
+}
+
+func decodeSpilledString(b []byte) (string, int, error) {
+	if len(b) < EncLenLen {
+		return "", 0, ErrCorruptedData
+	}
+
+	l := int(binary.BigEndian.Uint32(b))
+	if len(b) < EncLenLen+l {
+		return "", 0, ErrCorruptedData
+	}
+
+	return string(b[EncLenLen : EncLenLen+l]), EncLenLen + l, nil
+}
+
+func decodeSpilledRow(b []byte) (*Row, error) {
+	if len(b) < EncLenLen {
+		return nil, ErrCorruptedData
+	}
+
+	count := int(binary.BigEndian.Uint32(b))
+	off := EncLenLen
+
+	row := &Row{Values: make(map[string]TypedValue, count)}
+
+	for i := 0; i < count; i++ {
+		sel, n, err := decodeSpilledString(b[off:])
+		if err != nil {
+			return nil, err
+		}
+		off += n
+
+		t, n, err := decodeSpilledString(b[off:])
+		if err != nil {
+			return nil, err
+		}
+		off += n
+
+		if len(b) == off {
+			return nil, ErrCorruptedData
+		}
+
+		isNull := b[off] == 1
+		off++
+
+		if isNull {
+			row.Values[sel] = &NullValue{t: t}
+			continue
+		}
+
+		v, n, err := DecodeValue(b[off:], t)
+		if err != nil {
+			return nil, err
+		}
+		off += n
+
+		row.Values[sel] = v
+	}
+
+	if off != len(b) {
+		return nil, ErrCorruptedData
+	}
+
+	return row, nil
+}
+
+func (sr *sortRowReader) Rewind() error {
+	err := sr.dropRuns()
+	if err != nil {
+		return err
+	}
+
+	sr.rows = nil
+	sr.read = 0
+
+	return sr.rowReader.Rewind()
+}
+
+// dropRuns discards the rows spilled so far
+func (sr *sortRowReader) dropRuns() error {
+	var err error
+
+	for _, run := range sr.runs {
+		cerr := run.f.Close()
+		if err == nil {
+			err = cerr
+		}
+	}
+
+	if sr.spillDir != "" {
+		os.RemoveAll(sr.spillDir)
+	}
+
+	sr.runs = nil
+	sr.spillDir = ""
+
+	return err
+}
+
+func (sr *sortRowReader) Close() error {
+	err := sr.rowReader.Close()
+
+	cerr := sr.dropRuns()
+	if err == nil {
+		err = cerr
+	}
+
+	return err
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sql
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSortRowReader(t *testing.T) {
+	e := &Engine{}
+
+	_, err := e.newSortRowReader(nil, &OrdCol{sel: &ColSelector{col: "n"}})
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	cols := []ColDescriptor{{Column: "id", Type: IntegerType}, {Column: "n", Type: IntegerType}}
+
+	rows := [][]TypedValue{
+		{&Number{val: 1}, &Number{val: 20}},
+		{&Number{val: 2}, &NullValue{t: IntegerType}},
+		{&Number{val: 3}, &Number{val: 10}},
+		{&Number{val: 4}, &NullValue{t: IntegerType}},
+	}
+
+	readIDs := func(t *testing.T, r RowReader) []int64 {
+		var ids []int64
+
+		for {
+			row, err := r.Read()
+			if err == ErrNoMoreRows {
+				return ids
+			}
+			require.NoError(t, err)
+
+			ids = append(ids, row.Values[EncodeSelector("", "db1", "table1", "id")].Value().(int64))
+		}
+	}
+
+	testCases := []struct {
+		ordCol      *OrdCol
+		expectedIDs []int64
+	}{
+		{&OrdCol{}, []int64{2, 4, 3, 1}},
+		{&OrdCol{nullsOrder: NullsLast}, []int64{3, 1, 2, 4}},
+		{&OrdCol{descOrder: true}, []int64{1, 3, 2, 4}},
+		{&OrdCol{descOrder: true, nullsOrder: NullsFirst}, []int64{2, 4, 1, 3}},
+	}
+
+	for _, tc := range testCases {
+		tc.ordCol.sel = &ColSelector{col: "n"}
+
+		vr, err := e.newValuesRowReader("db1", "table1", cols, rows)
+		require.NoError(t, err)
+
+		r, err := e.newSortRowReader(vr, tc.ordCol)
+		require.NoError(t, err)
+
+		require.Equal(t, "db1", r.ImplicitDB())
+		require.Equal(t, "table1", r.ImplicitTable())
+		require.Nil(t, r.ScanSpecs())
+		require.Equal(t, "(db1.table1.n)", r.OrderBy()[0].Selector())
+
+		require.Equal(t, tc.expectedIDs, readIDs(t, r))
+
+		err = r.Rewind()
+		require.NoError(t, err)
+
+		require.Equal(t, tc.expectedIDs, readIDs(t, r))
+
+		err = r.Close()
+		require.NoError(t, err)
+	}
+
+	t.Run("rows should be sorted in runs once the spill threshold is reached", func(t *testing.T) {
+		for _, spillThld := range []int{1, 2, 3, 4} {
+			e := &Engine{sortSpillThld: spillThld}
+
+			for _, tc := range testCases {
+				vr, err := e.newValuesRowReader("db1", "table1", cols, rows)
+				require.NoError(t, err)
+
+				r, err := e.newSortRowReader(vr, tc.ordCol)
+				require.NoError(t, err)
+
+				require.Equal(t, tc.expectedIDs, readIDs(t, r))
+
+				spillDir := r.spillDir
+				require.NotEmpty(t, spillDir)
+
+				err = r.Rewind()
+				require.NoError(t, err)
+
+				_, err = os.Stat(spillDir)
+				require.True(t, os.IsNotExist(err))
+
+				require.Equal(t, tc.expectedIDs, readIDs(t, r))
+
+				spillDir = r.spillDir
+
+				err = r.Close()
+				require.NoError(t, err)
+
+				_, err = os.Stat(spillDir)
+				require.True(t, os.IsNotExist(err))
+			}
+		}
+	})
+
+	t.Run("spilled rows should keep their values", func(t *testing.T) {
+		e := &Engine{sortSpillThld: 1}
+
+		cols := []ColDescriptor{
+			{Column: "id", Type: IntegerType},
+			{Column: "title", Type: VarcharType},
+			{Column: "active", Type: BooleanType},
+			{Column: "payload", Type: BLOBType},
+			{Column: "ts", Type: TimestampType},
+		}
+
+		rows := [][]TypedValue{
+			{&Number{val: 2}, &Varchar{val: "title2"}, &Bool{val: true}, &Blob{val: []byte{2}}, &Timestamp{val: 2}},
+			{&Number{val: 1}, &NullValue{t: VarcharType}, &NullValue{t: BooleanType}, &NullValue{t: BLOBType}, &NullValue{t: TimestampType}},
+		}
+
+		vr, err := e.newValuesRowReader("db1", "table1", cols, rows)
+		require.NoError(t, err)
+
+		r, err := e.newSortRowReader(vr, &OrdCol{sel: &ColSelector{col: "id"}})
+		require.NoError(t, err)
+		defer r.Close()
+
+		for _, i := range []int{1, 0} {
+			row, err := r.Read()
+			require.NoError(t, err)
+			require.Len(t, row.Values, len(cols))
+
+			for j, col := range cols {
+				v := row.Values[EncodeSelector("", "db1", "table1", col.Column)]
+				require.Equal(t, rows[i][j].Type(), v.Type())
+				require.Equal(t, rows[i][j].Value(), v.Value())
+			}
+		}
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNoMoreRows)
+	})
+
+	t.Run("corrupted spilled rows should fail", func(t *testing.T) {
+		encRow, err := encodeSpilledRow(&Row{Values: map[string]TypedValue{
+			"(db1.table1.id)":    &Number{val: 1},
+			"(db1.table1.title)": &NullValue{t: VarcharType},
+		}})
+		require.NoError(t, err)
+
+		for i := 0; i < len(encRow); i++ {
+			_, err = decodeSpilledRow(encRow[:i])
+			require.ErrorIs(t, err, ErrCorruptedData)
+		}
+
+		_, err = decodeSpilledRow(append(encRow, 0))
+		require.ErrorIs(t, err, ErrCorruptedData)
+	})
+
+	t.Run("sorting by an unknown column should fail", func(t *testing.T) {
+		vr, err := e.newValuesRowReader("db1", "table1", cols, rows)
+		require.NoError(t, err)
+
+		_, err = e.newSortRowReader(vr, &OrdCol{sel: &ColSelector{col: "amount"}})
+		require.ErrorIs(t, err, ErrColumnDoesNotExist)
+	})
+
+	t.Run("values which can not be compared should fail", func(t *testing.T) {
+		vr, err := e.newValuesRowReader("db1", "table1", []ColDescriptor{{Column: "id", Type: AnyType}}, [][]TypedValue{
+			{&Number{val: 1}},
+			{&Varchar{val: "title"}},
+		})
+		require.NoError(t, err)
+
+		r, err := e.newSortRowReader(vr, &OrdCol{sel: &ColSelector{col: "id"}})
+		require.NoError(t, err)
+
+		_, err = r.Read()
+		require.ErrorIs(t, err, ErrNotComparableValues)
+	})
+}
@@ -56,22 +56,28 @@ func setResult(l yyLexer, stmts []SQLStmt) {
     err error
     ordcols []*OrdCol
     opt_ord bool
-    logicOp LogicOperator
+    nullsOrder NullsOrder
     cmpOp CmpOperator
     pparam int
     update *colUpdate
     updates []*colUpdate
+    whenThens []*whenThen
+    onConflict *OnConflictDo
 }
 
 %token CREATE USE DATABASE SNAPSHOT SINCE UP TO TABLE UNIQUE INDEX ON ALTER ADD COLUMN PRIMARY KEY
-%token BEGIN TRANSACTION COMMIT
-%token INSERT UPSERT INTO VALUES DELETE UPDATE SET
-%token SELECT DISTINCT FROM BEFORE TX JOIN HAVING WHERE GROUP BY LIMIT ORDER ASC DESC AS
-%token NOT LIKE IF EXISTS IN
-%token AUTO_INCREMENT NULL NPARAM
+%token BEGIN TRANSACTION COMMIT SAVEPOINT RELEASE ROLLBACK
+%token INSERT UPSERT INTO VALUES DELETE UPDATE SET TRUNCATE ANALYZE
+%token SELECT DISTINCT FROM BEFORE TX JOIN HAVING WHERE GROUP BY LIMIT OFFSET ORDER ASC DESC NULLS FIRST LAST AS
+%token NOT LIKE ILIKE IF EXISTS IN CAST
+%token UNION ALL
+%token DESCRIBE SHOW TABLES DATABASES
+%token CONFLICT DO NOTHING
+%token CASE WHEN THEN ELSE END
+%token AUTO_INCREMENT NULL NPARAM CHECK COLLATE IS DEFAULT CURRENT_TIMESTAMP
 %token <pparam> PPARAM
 %token <joinType> JOINTYPE
-%token <logicOp> LOP
+%token ANDOP OROP
 %token <cmpOp> CMPOP
 %token <id> IDENTIFIER
 %token <sqlType> TYPE
@@ -84,27 +90,30 @@ func setResult(l yyLexer, stmts []SQLStmt) {
 
 %left  ','
 %right AS
-%left  LOP
-%right LIKE
+%left  OROP
+%left  ANDOP
 %right NOT
+%nonassoc IS
+%right LIKE ILIKE
 %left  CMPOP
 %left '+' '-'
-%left '*' '/'
+%left '*' '/' '%'
+%right UMINUS
 %left  '.'
 %right STMT_SEPARATOR
 
 %type <stmts> sql
 %type <stmts> sqlstmts dstmts
-%type <stmt> sqlstmt dstmt ddlstmt dmlstmt dqlstmt
+%type <stmt> sqlstmt dstmt ddlstmt dmlstmt dqlstmt unionstmt infostmt txdstmt savepointstmt
 %type <colsSpec> colsSpec
 %type <colSpec> colSpec
 %type <ids> ids one_or_more_ids opt_ids
 %type <cols> cols
 %type <rows> rows
 %type <row> row
-%type <values> values opt_values
+%type <values> values opt_values row_values
 %type <value> val
-%type <sel> selector
+%type <sel> selector wildcard
 %type <sels> opt_selectors selectors
 %type <col> col
 %type <distinct> opt_distinct
@@ -114,15 +123,19 @@ func setResult(l yyLexer, stmts []SQLStmt) {
 %type <joins> opt_joins joins
 %type <join> join
 %type <joinType> opt_join_type
-%type <exp> exp opt_where opt_having boundexp
+%type <exp> exp opt_where opt_having boundexp opt_else opt_check row_value
+%type <onConflict> opt_on_conflict
+%type <whenThens> when_thens
 %type <binExp> binExp
 %type <cols> opt_groupby
-%type <number> opt_limit opt_max_len
-%type <id> opt_as
+%type <number> opt_limit opt_offset opt_max_len
+%type <id> opt_as opt_collate ident unreserved_keyword
 %type <ordcols> ordcols opt_orderby
 %type <opt_ord> opt_ord
+%type <nullsOrder> opt_nulls_order
 %type <ids> opt_indexon
-%type <boolean> opt_if_not_exists opt_auto_increment opt_not_null opt_not
+%type <boolean> opt_if_not_exists opt_auto_increment opt_not_null opt_unique opt_not opt_all
+%type <boolean> opt_default_now opt_update_now
 %type <update> update
 %type <updates> updates
 
@@ -130,30 +143,39 @@ func setResult(l yyLexer, stmts []SQLStmt) {
 
 %%
 
-sql: sqlstmts
+sql: opt_separators sqlstmts
 {
-    $$ = $1
-    setResult(yylex, $1)
+    $$ = $2
+    setResult(yylex, $2)
 }
 
 sqlstmts:
-    sqlstmt opt_separator
+    sqlstmt opt_separators
     {
         $$ = []SQLStmt{$1}
     }
 |
-    dqlstmt opt_separator
+    unionstmt opt_separators
     {
         $$ = []SQLStmt{$1}
     }
 |
-    sqlstmt STMT_SEPARATOR sqlstmts
+    infostmt opt_separators
+    {
+        $$ = []SQLStmt{$1}
+    }
+|
+    sqlstmt separators sqlstmts
     {
         $$ = append([]SQLStmt{$1}, $3...)
     }
 
 opt_separator: {} | STMT_SEPARATOR
 
+opt_separators: {} | separators
+
+separators: STMT_SEPARATOR | separators STMT_SEPARATOR
+
 sqlstmt:
     dstmt
     {
@@ -167,24 +189,42 @@ sqlstmt:
 
 dstmt: ddlstmt | dmlstmt
 
+txdstmt: dstmt | savepointstmt
+
 dstmts:
-    dstmt opt_separator
+    txdstmt opt_separator
     {
         $$ = []SQLStmt{$1}
     }
 |
-    dstmt STMT_SEPARATOR dstmts
+    txdstmt STMT_SEPARATOR dstmts
     {
         $$ = append([]SQLStmt{$1}, $3...)
     }
 
+savepointstmt:
+    SAVEPOINT ident
+    {
+        $$ = &SavepointStmt{name: $2}
+    }
+|
+    RELEASE SAVEPOINT ident
+    {
+        $$ = &ReleaseSavepointStmt{name: $3}
+    }
+|
+    ROLLBACK TO SAVEPOINT ident
+    {
+        $$ = &RollbackToSavepointStmt{name: $4}
+    }
+
 ddlstmt:
-    CREATE DATABASE IDENTIFIER
+    CREATE DATABASE ident
     {
         $$ = &CreateDatabaseStmt{DB: $3}
     }
 |
-    USE DATABASE IDENTIFIER
+    USE DATABASE ident
     {
         $$ = &UseDatabaseStmt{DB: $3}
     }
@@ -194,25 +234,30 @@ ddlstmt:
         $$ = &UseSnapshotStmt{sinceTx: $3, asBefore: $4}
     }
 |
-    CREATE TABLE opt_if_not_exists IDENTIFIER '(' colsSpec ',' PRIMARY KEY one_or_more_ids ')'
+    CREATE TABLE opt_if_not_exists ident '(' colsSpec ',' PRIMARY KEY one_or_more_ids ')'
     {
         $$ = &CreateTableStmt{ifNotExists: $3, table: $4, colsSpec: $6, pkColNames: $10}
     }
 |
-    CREATE INDEX opt_if_not_exists ON IDENTIFIER '(' ids ')'
+    CREATE INDEX opt_if_not_exists ON ident '(' ids ')'
     {
         $$ = &CreateIndexStmt{ifNotExists: $3, table: $5, cols: $7}
     }
 |
-    CREATE UNIQUE INDEX opt_if_not_exists ON IDENTIFIER '(' ids ')'
+    CREATE UNIQUE INDEX opt_if_not_exists ON ident '(' ids ')'
     {
         $$ = &CreateIndexStmt{unique: true, ifNotExists: $4, table: $6, cols: $8}
     }
 |
-    ALTER TABLE IDENTIFIER ADD COLUMN colSpec
+    ALTER TABLE ident ADD COLUMN colSpec
     {
         $$ = &AddColumnStmt{table: $3, colSpec: $6}
     }
+|
+    ANALYZE TABLE ident
+    {
+        $$ = &AnalyzeTableStmt{table: $3}
+    }
 
 opt_since:
     {
@@ -235,7 +280,7 @@ opt_if_not_exists:
     }
 
 one_or_more_ids:
-    IDENTIFIER
+    ident
     {
         $$ = []string{$1}
     }
@@ -246,9 +291,14 @@ one_or_more_ids:
     }
 
 dmlstmt:
-    INSERT INTO tableRef '(' opt_ids ')' VALUES rows
+    INSERT INTO tableRef '(' opt_ids ')' VALUES rows opt_on_conflict
     {
-        $$ = &UpsertIntoStmt{isInsert: true, tableRef: $3, cols: $5, rows: $8}
+        $$ = &UpsertIntoStmt{isInsert: true, tableRef: $3, cols: $5, rows: $8, onConflict: $9}
+    }
+|
+    INSERT INTO tableRef '(' opt_ids ')' dqlstmt opt_on_conflict
+    {
+        $$ = &UpsertIntoStmt{isInsert: true, tableRef: $3, cols: $5, ds: $7.(DataSource), onConflict: $8}
     }
 |
     UPSERT INTO tableRef '(' ids ')' VALUES rows
@@ -265,6 +315,11 @@ dmlstmt:
     {
         $$ = &UpdateStmt{tableRef: $2, updates: $4, where: $5, indexOn: $6, limit: int($7)}
     }
+|
+    TRUNCATE TABLE ident
+    {
+        $$ = &TruncateTableStmt{table: $3}
+    }
 
 updates:
     update
@@ -278,7 +333,7 @@ updates:
     }
 
 update:
-    IDENTIFIER CMPOP exp
+    ident CMPOP exp
     {
         $$ = &colUpdate{col: $1, op: $2, val: $3}
     }
@@ -311,12 +366,12 @@ row:
     }
 
 ids:
-    IDENTIFIER
+    ident
     {
         $$ = []string{$1}
     }
 |
-    ids ',' IDENTIFIER
+    ids ',' ident
     {
         $$ = append($1, $3)
     }
@@ -337,11 +392,33 @@ opt_values:
         $$ = nil
     }
 |
-    values
+    row_values
     {
         $$ = $1
     }
 
+row_values:
+    row_value
+    {
+        $$ = []ValueExp{$1}
+    }
+|
+    row_values ',' row_value
+    {
+        $$ = append($1, $3)
+    }
+
+row_value:
+    exp
+    {
+        $$ = $1
+    }
+|
+    DEFAULT
+    {
+        $$ = &DefaultValue{}
+    }
+
 values:
     exp
     {
@@ -379,7 +456,17 @@ val:
         $$ = &SysFn{fn: $1}
     }
 |
-    NPARAM IDENTIFIER
+    IDENTIFIER '(' values ')'
+    {
+        $$ = &FnCall{fn: $1, params: $3}
+    }
+|
+    CAST '(' exp AS TYPE ')'
+    {
+        $$ = &Cast{val: $3, t: $5}
+    }
+|
+    NPARAM ident
     {
         $$ = &Param{id: $2}
     }
@@ -406,9 +493,39 @@ colsSpec:
     }
 
 colSpec:
-    IDENTIFIER TYPE opt_max_len opt_auto_increment opt_not_null
+    ident TYPE opt_max_len opt_collate opt_auto_increment opt_not_null opt_default_now opt_update_now opt_unique opt_check
+    {
+        $$ = &ColSpec{colName: $1, colType: $2, maxLen: int($3), collation: $4, autoIncrement: $5, notNull: $6, defaultNow: $7, updateNow: $8, unique: $9, check: $10}
+    }
+
+opt_collate:
+    {
+        $$ = ""
+    }
+|
+    COLLATE IDENTIFIER
+    {
+        $$ = $2
+    }
+
+opt_unique:
     {
-        $$ = &ColSpec{colName: $1, colType: $2, maxLen: int($3), autoIncrement: $4, notNull: $5}
+        $$ = false
+    }
+|
+    UNIQUE
+    {
+        $$ = true
+    }
+
+opt_check:
+    {
+        $$ = nil
+    }
+|
+    CHECK '(' exp ')'
+    {
+        $$ = $3
     }
 
 opt_max_len:
@@ -431,6 +548,26 @@ opt_auto_increment:
         $$ = true
     }
 
+opt_default_now:
+    {
+        $$ = false
+    }
+|
+    DEFAULT CURRENT_TIMESTAMP
+    {
+        $$ = true
+    }
+
+opt_update_now:
+    {
+        $$ = false
+    }
+|
+    ON UPDATE CURRENT_TIMESTAMP
+    {
+        $$ = true
+    }
+
 opt_not_null:
     {
         $$ = false
@@ -446,8 +583,64 @@ opt_not_null:
         $$ = true
     }
 
+infostmt:
+    DESCRIBE TABLE tableRef
+    {
+        $$ = &DescribeTableStmt{table: $3}
+    }
+|
+    SHOW TABLES
+    {
+        $$ = &ShowTablesStmt{}
+    }
+|
+    SHOW DATABASES
+    {
+        $$ = &ShowDatabasesStmt{}
+    }
+
+unionstmt:
+    dqlstmt
+    {
+        $$ = $1
+    }
+|
+    unionstmt UNION opt_all dqlstmt
+    {
+        $$ = &UnionStmt{
+                distinct: !$3,
+                left: $1.(DQLStmt),
+                right: $4.(DQLStmt),
+            }
+    }
+
+opt_on_conflict:
+    {
+        $$ = nil
+    }
+|
+    ON CONFLICT DO NOTHING
+    {
+        $$ = &OnConflictDo{}
+    }
+|
+    ON CONFLICT DO UPDATE SET updates
+    {
+        $$ = &OnConflictDo{updates: $6}
+    }
+
+opt_all:
+    {
+        $$ = false
+    }
+|
+    ALL
+    {
+        $$ = true
+    }
+
 dqlstmt:
-    SELECT opt_distinct opt_selectors FROM ds opt_indexon opt_joins opt_where opt_groupby opt_having opt_orderby opt_limit
+    SELECT opt_distinct opt_selectors FROM ds opt_indexon opt_joins opt_where opt_groupby opt_having opt_orderby opt_limit opt_offset
     {
         $$ = &SelectStmt{
                 distinct: $2,
@@ -460,6 +653,15 @@ dqlstmt:
                 having: $10,
                 orderBy: $11,
                 limit: int($12),
+                offset: int($13),
+            }
+    }
+|
+    SELECT opt_distinct selectors
+    {
+        $$ = &SelectStmt{
+                distinct: $2,
+                selectors: $3,
             }
     }
 
@@ -485,18 +687,37 @@ opt_selectors:
     }
 
 selectors:
-    selector opt_as
+    exp opt_as
+    {
+        $$ = []Selector{newSelector($1, $2)}
+    }
+|
+    wildcard
     {
-        $1.setAlias($2)
         $$ = []Selector{$1}
     }
 |
-    selectors ',' selector opt_as
+    selectors ',' exp opt_as
+    {
+        $$ = append($1, newSelector($3, $4))
+    }
+|
+    selectors ',' wildcard
     {
-        $3.setAlias($4)
         $$ = append($1, $3)
     }
 
+wildcard:
+    ident '.' '*'
+    {
+        $$ = &wildcardSelector{table: $1}
+    }
+|
+    ident '.' ident '.' '*'
+    {
+        $$ = &wildcardSelector{db: $1, table: $3}
+    }
+
 selector:
     col
     {
@@ -508,23 +729,28 @@ selector:
         $$ = &AggColSelector{aggFn: $1, col: "*"}
     }
 |
-    AGGREGATE_FUNC '(' col ')'
+    AGGREGATE_FUNC '(' exp ')'
     {
-        $$ = &AggColSelector{aggFn: $1, db: $3.db, table: $3.table, col: $3.col}
+        sel, err := newAggColSelector($1, $3)
+        if err != nil {
+            yylex.Error(err.Error())
+        }
+
+        $$ = sel
     }
 
 col:
-    IDENTIFIER
+    ident
     {
         $$ = &ColSelector{col: $1}
     }
 |
-    IDENTIFIER '.' IDENTIFIER
+    ident '.' ident
     {
         $$ = &ColSelector{table: $1, col: $3}
     }
 |
-    IDENTIFIER '.' IDENTIFIER '.' IDENTIFIER
+    ident '.' ident '.' ident
     {
         $$ = &ColSelector{db: $1, table: $3, col: $5}
     }
@@ -544,12 +770,12 @@ ds:
     }
 
 tableRef:
-    IDENTIFIER
+    ident
     {
         $$ = &tableRef{table: $1}
     }
 |
-    IDENTIFIER '.' IDENTIFIER
+    ident '.' ident
     {
         $$ = &tableRef{db: $1, table: $3}
     }
@@ -641,6 +867,16 @@ opt_limit:
         $$ = $2
     }
 
+opt_offset:
+    {
+        $$ = 0
+    }
+|
+    OFFSET NUMBER
+    {
+        $$ = $2
+    }
+
 opt_orderby:
     {
         $$ = nil
@@ -662,14 +898,14 @@ opt_indexon:
     }
 
 ordcols:
-    col opt_ord
+    col opt_ord opt_nulls_order
     {
-        $$ = []*OrdCol{{sel: $1, descOrder: $2}}
+        $$ = []*OrdCol{{sel: $1, descOrder: $2, nullsOrder: $3}}
     }
 |
-    ordcols ',' col opt_ord
+    ordcols ',' col opt_ord opt_nulls_order
     {
-        $$ = append($1, &OrdCol{sel: $3, descOrder: $4})
+        $$ = append($1, &OrdCol{sel: $3, descOrder: $4, nullsOrder: $5})
     }
 
 opt_ord:
@@ -687,17 +923,59 @@ opt_ord:
         $$ = true
     }
 
+opt_nulls_order:
+    {
+        $$ = NullsDefault
+    }
+|
+    NULLS FIRST
+    {
+        $$ = NullsFirst
+    }
+|
+    NULLS LAST
+    {
+        $$ = NullsLast
+    }
+
+ident:
+    IDENTIFIER
+    {
+        $$ = $1
+    }
+|
+    unreserved_keyword
+    {
+        $$ = $1
+    }
+
+unreserved_keyword:
+    NULLS
+    {
+        $$ = "nulls"
+    }
+|
+    FIRST
+    {
+        $$ = "first"
+    }
+|
+    LAST
+    {
+        $$ = "last"
+    }
+
 opt_as:
     {
         $$ = ""
     }
 |
-    IDENTIFIER
+    ident
     {
         $$ = $1
     }
 |
-    AS IDENTIFIER
+    AS ident
     {
         $$ = $2
     }
@@ -718,15 +996,25 @@ exp:
         $$ = &NotBoolExp{exp: $2}
     }
 |
-    '-' exp
+    '-' exp %prec UMINUS
     {
-        $$ = &NumExp{left: &Number{val: 0}, op: SUBSOP, right: $2}
+        $$ = &NegExp{exp: $2}
     }
 |
     boundexp opt_not LIKE exp
     {
         $$ = &LikeBoolExp{val: $1, notLike: $2, pattern: $4}
     }
+|
+    boundexp opt_not ILIKE exp
+    {
+        $$ = &LikeBoolExp{val: $1, notLike: $2, pattern: $4, caseInsensitive: true}
+    }
+|
+    exp IS opt_not NULL
+    {
+        $$ = &IsNullBoolExp{exp: $1, not: $3}
+    }
 |
     EXISTS '(' dqlstmt ')'
     {
@@ -758,6 +1046,32 @@ boundexp:
     {
         $$ = $2
     }
+|
+    CASE when_thens opt_else END
+    {
+        $$ = &CaseExp{whenThens: $2, elseExp: $3}
+    }
+
+when_thens:
+    WHEN exp THEN exp
+    {
+        $$ = []*whenThen{{when: $2, then: $4}}
+    }
+|
+    when_thens WHEN exp THEN exp
+    {
+        $$ = append($1, &whenThen{when: $3, then: $5})
+    }
+
+opt_else:
+    {
+        $$ = nil
+    }
+|
+    ELSE exp
+    {
+        $$ = $2
+    }
 
 opt_not:
     {
@@ -790,9 +1104,19 @@ binExp:
         $$ = &NumExp{left: $1, op: MULTOP, right: $3}
     }
 |
-    exp LOP exp
+    exp '%' exp
+    {
+        $$ = &NumExp{left: $1, op: MODOP, right: $3}
+    }
+|
+    exp ANDOP exp
+    {
+        $$ = &BinBoolExp{left: $1, op: AND, right: $3}
+    }
+|
+    exp OROP exp
     {
-        $$ = &BinBoolExp{left: $1, op: $2, right: $3}
+        $$ = &BinBoolExp{left: $1, op: OR, right: $3}
     }
 |
     exp CMPOP exp
@@ -10,43 +10,45 @@ func setResult(l yyLexer, stmts []SQLStmt) {
 }
 
 type yySymType struct {
-	yys      int
-	stmts    []SQLStmt
-	stmt     SQLStmt
-	colsSpec []*ColSpec
-	colSpec  *ColSpec
-	cols     []*ColSelector
-	rows     []*RowSpec
-	row      *RowSpec
-	values   []ValueExp
-	value    ValueExp
-	id       string
-	number   uint64
-	str      string
-	boolean  bool
-	blob     []byte
-	sqlType  SQLValueType
-	aggFn    AggregateFn
-	ids      []string
-	col      *ColSelector
-	sel      Selector
-	sels     []Selector
-	distinct bool
-	ds       DataSource
-	tableRef *tableRef
-	joins    []*JoinSpec
-	join     *JoinSpec
-	joinType JoinType
-	exp      ValueExp
-	binExp   ValueExp
-	err      error
-	ordcols  []*OrdCol
-	opt_ord  bool
-	logicOp  LogicOperator
-	cmpOp    CmpOperator
-	pparam   int
-	update   *colUpdate
-	updates  []*colUpdate
+	yys        int
+	stmts      []SQLStmt
+	stmt       SQLStmt
+	colsSpec   []*ColSpec
+	colSpec    *ColSpec
+	cols       []*ColSelector
+	rows       []*RowSpec
+	row        *RowSpec
+	values     []ValueExp
+	value      ValueExp
+	id         string
+	number     uint64
+	str        string
+	boolean    bool
+	blob       []byte
+	sqlType    SQLValueType
+	aggFn      AggregateFn
+	ids        []string
+	col        *ColSelector
+	sel        Selector
+	sels       []Selector
+	distinct   bool
+	ds         DataSource
+	tableRef   *tableRef
+	joins      []*JoinSpec
+	join       *JoinSpec
+	joinType   JoinType
+	exp        ValueExp
+	binExp     ValueExp
+	err        error
+	ordcols    []*OrdCol
+	opt_ord    bool
+	nullsOrder NullsOrder
+	cmpOp      CmpOperator
+	pparam     int
+	update     *colUpdate
+	updates    []*colUpdate
+	whenThens  []*whenThen
+	onConflict *OnConflictDo
 }
 
 const CREATE = 57346
@@ -68,49 +70,81 @@ const KEY = 57361
 const BEGIN = 57362
 const TRANSACTION = 57363
 const COMMIT = 57364
-const INSERT = 57365
-const UPSERT = 57366
-const INTO = 57367
-const VALUES = 57368
-const DELETE = 57369
-const UPDATE = 57370
-const SET = 57371
-const SELECT = 57372
-const DISTINCT = 57373
-const FROM = 57374
-const BEFORE = 57375
-const TX = 57376
-const JOIN = 57377
-const HAVING = 57378
-const WHERE = 57379
-const GROUP = 57380
-const BY = 57381
-const LIMIT = 57382
-const ORDER = 57383
-const ASC = 57384
-const DESC = 57385
-const AS = 57386
-const NOT = 57387
-const LIKE = 57388
-const IF = 57389
-const EXISTS = 57390
-const IN = 57391
-const AUTO_INCREMENT = 57392
-const NULL = 57393
-const NPARAM = 57394
-const PPARAM = 57395
-const JOINTYPE = 57396
-const LOP = 57397
-const CMPOP = 57398
-const IDENTIFIER = 57399
-const TYPE = 57400
-const NUMBER = 57401
-const VARCHAR = 57402
-const BOOLEAN = 57403
-const BLOB = 57404
-const AGGREGATE_FUNC = 57405
-const ERROR = 57406
-const STMT_SEPARATOR = 57407
+const SAVEPOINT = 57365
+const RELEASE = 57366
+const ROLLBACK = 57367
+const INSERT = 57368
+const UPSERT = 57369
+const INTO = 57370
+const VALUES = 57371
+const DELETE = 57372
+const UPDATE = 57373
+const SET = 57374
+const TRUNCATE = 57375
+const ANALYZE = 57376
+const SELECT = 57377
+const DISTINCT = 57378
+const FROM = 57379
+const BEFORE = 57380
+const TX = 57381
+const JOIN = 57382
+const HAVING = 57383
+const WHERE = 57384
+const GROUP = 57385
+const BY = 57386
+const LIMIT = 57387
+const OFFSET = 57388
+const ORDER = 57389
+const ASC = 57390
+const DESC = 57391
+const NULLS = 57392
+const FIRST = 57393
+const LAST = 57394
+const AS = 57395
+const NOT = 57396
+const LIKE = 57397
+const ILIKE = 57398
+const IF = 57399
+const EXISTS = 57400
+const IN = 57401
+const CAST = 57402
+const UNION = 57403
+const ALL = 57404
+const DESCRIBE = 57405
+const SHOW = 57406
+const TABLES = 57407
+const DATABASES = 57408
+const CONFLICT = 57409
+const DO = 57410
+const NOTHING = 57411
+const CASE = 57412
+const WHEN = 57413
+const THEN = 57414
+const ELSE = 57415
+const END = 57416
+const AUTO_INCREMENT = 57417
+const NULL = 57418
+const NPARAM = 57419
+const CHECK = 57420
+const COLLATE = 57421
+const IS = 57422
+const DEFAULT = 57423
+const CURRENT_TIMESTAMP = 57424
+const PPARAM = 57425
+const JOINTYPE = 57426
+const ANDOP = 57427
+const OROP = 57428
+const CMPOP = 57429
+const IDENTIFIER = 57430
+const TYPE = 57431
+const NUMBER = 57432
+const VARCHAR = 57433
+const BOOLEAN = 57434
+const BLOB = 57435
+const AGGREGATE_FUNC = 57436
+const ERROR = 57437
+const UMINUS = 57438
+const STMT_SEPARATOR = 57439
 
 var yyToknames = [...]string{
 	"$end",
@@ -135,6 +169,9 @@ var yyToknames = [...]string{
 	"BEGIN",
 	"TRANSACTION",
 	"COMMIT",
+	"SAVEPOINT",
+	"RELEASE",
+	"ROLLBACK",
 	"INSERT",
 	"UPSERT",
 	"INTO",
@@ -142,6 +179,8 @@ var yyToknames = [...]string{
 	"DELETE",
 	"UPDATE",
 	"SET",
+	"TRUNCATE",
+	"ANALYZE",
 	"SELECT",
 	"DISTINCT",
 	"FROM",
@@ -153,21 +192,47 @@ var yyToknames = [...]string{
 	"GROUP",
 	"BY",
 	"LIMIT",
+	"OFFSET",
 	"ORDER",
 	"ASC",
 	"DESC",
+	"NULLS",
+	"FIRST",
+	"LAST",
 	"AS",
 	"NOT",
 	"LIKE",
+	"ILIKE",
 	"IF",
 	"EXISTS",
 	"IN",
+	"CAST",
+	"UNION",
+	"ALL",
+	"DESCRIBE",
+	"SHOW",
+	"TABLES",
+	"DATABASES",
+	"CONFLICT",
+	"DO",
+	"NOTHING",
+	"CASE",
+	"WHEN",
+	"THEN",
+	"ELSE",
+	"END",
 	"AUTO_INCREMENT",
 	"NULL",
 	"NPARAM",
+	"CHECK",
+	"COLLATE",
+	"IS",
+	"DEFAULT",
+	"CURRENT_TIMESTAMP",
 	"PPARAM",
 	"JOINTYPE",
-	"LOP",
+	"ANDOP",
+	"OROP",
 	"CMPOP",
 	"IDENTIFIER",
 	"TYPE",
@@ -182,6 +247,8 @@ var yyToknames = [...]string{
 	"'-'",
 	"'*'",
 	"'/'",
+	"'%'",
+	"UMINUS",
 	"'.'",
 	"STMT_SEPARATOR",
 	"'('",
@@ -200,199 +267,299 @@ var yyExca = [...]int{
 	-1, 1,
 	1, -1,
 	-2, 0,
-	-1, 109,
-	46, 121,
-	49, 121,
-	-2, 110,
-	-1, 128,
-	35, 88,
-	-2, 83,
-	-1, 166,
-	35, 88,
-	-2, 85,
+	-1, 69,
+	37, 108,
+	-2, 104,
+	-1, 73,
+	55, 182,
+	56, 182,
+	59, 182,
+	-2, 164,
+	-1, 215,
+	40, 132,
+	-2, 127,
+	-1, 252,
+	40, 132,
+	-2, 129,
 }
 
 const yyPrivate = 57344
 
-const yyLast = 318
+const yyLast = 751
 
 var yyAct = [...]int{
-	260, 43, 144, 224, 204, 4, 106, 207, 103, 223,
-	87, 203, 135, 165, 80, 65, 83, 7, 74, 236,
-	239, 142, 142, 142, 200, 142, 45, 249, 242, 240,
-	220, 201, 111, 143, 241, 113, 238, 208, 124, 122,
-	123, 35, 92, 212, 121, 191, 117, 118, 119, 120,
-	44, 170, 209, 69, 112, 159, 89, 111, 141, 116,
-	113, 132, 205, 124, 122, 123, 211, 175, 157, 121,
-	93, 117, 118, 119, 120, 44, 137, 95, 79, 112,
-	147, 148, 150, 149, 116, 108, 133, 78, 105, 68,
-	151, 152, 62, 20, 128, 130, 18, 125, 151, 152,
-	69, 147, 148, 150, 149, 259, 131, 129, 190, 147,
-	148, 150, 149, 152, 140, 155, 156, 150, 149, 81,
-	158, 59, 45, 147, 148, 150, 149, 114, 44, 254,
-	37, 163, 161, 40, 239, 221, 172, 142, 64, 5,
-	219, 173, 45, 162, 197, 169, 174, 126, 44, 179,
-	42, 181, 182, 183, 184, 185, 186, 139, 100, 67,
-	45, 38, 104, 189, 192, 177, 171, 84, 160, 136,
-	138, 97, 66, 94, 91, 85, 70, 35, 54, 193,
-	194, 51, 46, 136, 202, 198, 196, 127, 168, 235,
-	206, 210, 90, 86, 250, 234, 47, 218, 187, 96,
-	48, 188, 38, 154, 71, 244, 215, 261, 262, 145,
-	17, 253, 230, 214, 81, 19, 229, 226, 225, 195,
-	99, 227, 231, 49, 232, 76, 237, 75, 63, 33,
-	23, 7, 247, 245, 10, 11, 58, 178, 176, 32,
-	31, 60, 88, 251, 21, 12, 252, 73, 216, 61,
-	6, 2, 255, 13, 14, 257, 258, 15, 16, 34,
-	7, 263, 10, 11, 264, 101, 77, 248, 180, 98,
-	36, 72, 24, 12, 55, 56, 57, 25, 27, 26,
-	146, 13, 14, 50, 30, 15, 16, 53, 28, 29,
-	107, 82, 153, 233, 217, 243, 256, 199, 213, 110,
-	109, 228, 167, 166, 164, 52, 22, 41, 39, 115,
-	222, 246, 102, 134, 9, 8, 3, 1,
+	193, 370, 363, 84, 157, 312, 244, 292, 294, 208,
+	164, 297, 291, 205, 235, 119, 251, 192, 149, 72,
+	155, 158, 120, 9, 131, 60, 325, 126, 127, 128,
+	288, 337, 327, 284, 281, 11, 242, 71, 256, 121,
+	122, 124, 123, 125, 134, 120, 330, 241, 381, 224,
+	126, 127, 128, 378, 293, 269, 62, 53, 54, 55,
+	242, 237, 121, 122, 124, 123, 125, 222, 197, 50,
+	307, 232, 154, 153, 143, 142, 133, 135, 50, 120,
+	141, 78, 138, 94, 126, 127, 128, 99, 136, 102,
+	103, 50, 50, 50, 110, 51, 121, 122, 124, 123,
+	125, 109, 113, 259, 242, 186, 4, 230, 242, 114,
+	230, 30, 299, 26, 289, 212, 129, 282, 243, 167,
+	231, 225, 170, 171, 172, 173, 174, 175, 176, 177,
+	182, 53, 54, 55, 137, 108, 144, 62, 168, 161,
+	145, 190, 362, 195, 196, 169, 121, 122, 124, 123,
+	125, 156, 159, 160, 4, 346, 328, 210, 309, 266,
+	162, 295, 50, 78, 124, 123, 125, 242, 207, 51,
+	118, 267, 183, 361, 215, 178, 95, 306, 211, 274,
+	220, 221, 185, 218, 216, 239, 166, 202, 265, 227,
+	228, 324, 199, 17, 18, 53, 54, 55, 206, 206,
+	213, 254, 217, 374, 19, 212, 359, 349, 214, 10,
+	305, 373, 129, 350, 249, 21, 22, 97, 240, 23,
+	24, 247, 25, 20, 16, 128, 219, 223, 340, 323,
+	263, 264, 255, 51, 248, 121, 122, 124, 123, 125,
+	258, 226, 236, 309, 260, 238, 140, 188, 236, 189,
+	339, 268, 12, 13, 276, 329, 59, 159, 257, 315,
+	198, 129, 96, 283, 53, 54, 55, 130, 371, 278,
+	261, 277, 280, 34, 35, 148, 285, 271, 120, 132,
+	343, 286, 206, 290, 146, 128, 296, 272, 179, 180,
+	302, 332, 181, 26, 313, 121, 122, 124, 123, 125,
+	308, 129, 51, 353, 261, 53, 54, 55, 53, 54,
+	55, 236, 320, 316, 206, 321, 245, 326, 342, 333,
+	298, 376, 377, 335, 50, 53, 54, 55, 345, 313,
+	364, 365, 319, 301, 341, 156, 318, 347, 344, 279,
+	201, 151, 150, 51, 206, 117, 51, 270, 298, 355,
+	356, 48, 37, 16, 184, 16, 360, 351, 107, 368,
+	53, 54, 55, 51, 75, 273, 369, 47, 77, 46,
+	90, 120, 375, 163, 115, 111, 126, 379, 128, 380,
+	82, 32, 303, 203, 152, 5, 93, 91, 121, 122,
+	124, 123, 125, 92, 358, 336, 159, 165, 83, 295,
+	86, 87, 88, 89, 85, 275, 200, 147, 76, 53,
+	54, 55, 262, 75, 57, 81, 194, 77, 246, 90,
+	120, 367, 49, 98, 56, 126, 127, 128, 45, 82,
+	44, 67, 33, 116, 101, 93, 91, 121, 122, 124,
+	123, 125, 92, 112, 104, 105, 106, 83, 209, 86,
+	87, 88, 89, 85, 42, 43, 3, 76, 53, 54,
+	55, 357, 75, 28, 81, 191, 77, 38, 90, 348,
+	58, 366, 39, 41, 40, 338, 322, 331, 82, 2,
+	354, 52, 304, 287, 93, 91, 27, 29, 31, 314,
+	352, 92, 16, 300, 74, 139, 83, 372, 86, 87,
+	88, 89, 85, 187, 73, 317, 76, 53, 54, 55,
+	229, 75, 253, 81, 252, 77, 250, 90, 120, 100,
+	36, 69, 68, 126, 127, 128, 79, 82, 80, 311,
+	310, 334, 204, 93, 91, 121, 122, 124, 123, 125,
+	92, 234, 63, 61, 8, 83, 7, 86, 87, 88,
+	89, 85, 15, 14, 6, 76, 53, 54, 55, 1,
+	75, 0, 81, 0, 77, 0, 90, 120, 0, 0,
+	0, 0, 126, 127, 128, 0, 82, 0, 0, 0,
+	0, 0, 93, 91, 121, 122, 124, 123, 125, 92,
+	0, 0, 0, 0, 83, 0, 86, 87, 88, 89,
+	85, 0, 0, 0, 76, 70, 53, 54, 55, 0,
+	75, 81, 0, 0, 77, 0, 90, 0, 0, 0,
+	0, 53, 54, 55, 130, 0, 82, 0, 0, 0,
+	0, 0, 93, 91, 0, 0, 0, 0, 0, 92,
+	0, 0, 0, 0, 83, 0, 86, 87, 88, 89,
+	85, 120, 233, 0, 76, 0, 126, 127, 128, 51,
+	0, 81, 0, 0, 0, 0, 0, 0, 121, 122,
+	124, 123, 125, 0, 0, 0, 0, 0, 0, 120,
+	0, 0, 0, 0, 126, 127, 128, 0, 0, 0,
+	17, 18, 0, 0, 0, 0, 121, 122, 124, 123,
+	125, 19, 0, 17, 18, 0, 10, 0, 0, 0,
+	0, 0, 21, 22, 19, 0, 23, 24, 0, 25,
+	20, 16, 64, 65, 66, 21, 22, 0, 0, 23,
+	24, 0, 25, 20, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 12,
+	13,
 }
 
 var yyPact = [...]int{
-	230, -1000, -1000, 25, 22, -1000, 223, 199, -1000, -1000,
-	266, 282, 273, 215, 214, 197, 120, -1000, 230, -1000,
-	-1000, 258, 65, -1000, 125, 153, 153, 270, 124, 279,
-	121, 120, 120, 120, 207, 51, -1000, 219, 21, 196,
-	-1000, 73, 115, -1000, 17, 30, -1000, 119, 159, 257,
-	153, -1000, 194, 191, 250, 15, 6, 177, 110, 118,
-	-1000, -1000, 258, -16, 85, -1000, -1000, 117, -31, 116,
-	5, 151, 114, 255, -1000, 186, 99, 248, 105, 105,
-	285, 12, 82, -1000, 131, -1000, -1000, 285, 194, 201,
-	115, -1000, -1000, -12, 16, 112, -1000, 4, 113, 98,
-	-1000, 112, -15, 72, -1000, -40, 169, 267, 43, 158,
-	-1000, 12, 12, -4, -1000, -1000, 12, -1000, -1000, -1000,
-	-1000, -17, 111, -1000, -1000, 285, 110, 12, 134, 115,
-	-22, -1000, -1000, 109, 71, -1000, 83, 105, -5, -1000,
-	-1000, 212, 108, 211, -1000, 90, 254, 12, 12, 12,
-	12, 12, 12, 152, -1000, 57, 49, 201, 35, -28,
-	-1000, 169, -1000, 43, 177, -1000, 134, 184, -1000, -1000,
-	115, -1000, 126, -50, -42, 105, -10, -1000, -10, -1000,
-	-20, 49, 49, -1000, -1000, 57, 14, 12, -6, -30,
-	-1000, -1000, -1000, 175, -1000, -16, -1000, 229, -1000, 147,
-	81, -1000, -43, 70, -1000, 12, 70, -1000, -1000, 105,
-	57, -13, -1000, 180, 173, 285, -20, 144, -1000, -56,
-	-1000, -10, -37, 69, 43, -44, -39, -45, 164, 12,
-	103, 253, -46, -1000, -1000, 143, -1000, -1000, -1000, 12,
-	-1000, -1000, -1000, 169, 172, 43, 64, -1000, 12, -1000,
-	-1000, 43, -1000, 103, 103, 43, 40, 165, -1000, 103,
-	-1000, -1000, -1000, 165, -1000,
+	2, -1000, 686, 9, -1000, -1000, 2, 50, 2, -1000,
+	360, -1000, 421, 208, -1000, -1000, 316, 461, 448, 419,
+	417, 341, 339, 314, 275, 413, -1000, -1000, 189, -1000,
+	194, -1000, 699, 275, -1000, -1000, 506, -1000, 275, 205,
+	205, 410, 275, 426, 275, 275, 275, 275, 275, 326,
+	32, -1000, -1000, -1000, -1000, -1000, 275, -1000, 320, -1000,
+	353, -2, -1000, -1000, 275, 351, 423, -1000, 308, 74,
+	-1000, 571, -1000, 225, -1000, 556, 556, -17, 31, -1000,
+	-1000, 556, 175, -25, -1000, -30, -1000, -1000, -1000, -1000,
+	-31, 275, -1000, -1000, -1000, 275, 230, 393, 205, -1000,
+	304, 302, 368, -1000, -32, -33, 293, 275, 275, -1000,
+	-1000, -1000, -1000, 699, -1000, 275, 350, 81, 556, -1000,
+	225, 556, 556, 556, 556, 556, 556, 556, 556, -1000,
+	275, 233, -1000, 198, 27, -1000, 320, 255, -1, 176,
+	556, 359, 310, 556, -1000, -37, 202, 275, 392, -1000,
+	301, 97, 366, 275, 275, 443, 556, 109, -1000, 113,
+	-1000, -1000, -1000, 275, 443, 304, 320, 571, -1000, 150,
+	65, 65, -1000, -1000, -1000, 198, 291, 49, -1000, 556,
+	556, -38, 275, -57, -1000, 18, -1000, 167, 556, 556,
+	438, -1000, 14, 487, -1000, -35, 599, 275, -1000, -44,
+	275, 95, -1000, 275, -59, 71, -1000, 12, 271, 405,
+	487, 443, 275, 556, -1000, 117, 214, -68, -1000, -1000,
+	138, 138, 457, 0, -1000, 145, -1000, 340, 487, 556,
+	556, -1000, -1000, 99, 63, -1000, 82, 275, -50, -1000,
+	-1000, 318, 275, 336, -1000, 89, 391, 271, -1000, 487,
+	293, -1000, 117, 299, -1000, -1000, 214, -72, 11, 275,
+	-1000, -1000, 556, 487, 487, -73, 258, -77, 8, 275,
+	-51, 385, -1000, -51, -1000, 7, -1000, 290, -1000, 81,
+	-1000, -1000, -1000, 487, -1000, 363, -1000, 131, 87, -1000,
+	-36, 147, -1000, 408, -1000, 192, 62, -1000, -1000, 275,
+	295, 288, 443, 7, 154, 103, -82, -1000, -1000, -51,
+	-74, 60, -1000, 487, -1000, 187, -60, 244, 556, 275,
+	381, -75, 174, -1000, -1000, -1000, -1000, -1000, 408, 249,
+	-1000, 271, 284, 487, 59, -1000, 556, -1000, 126, -1000,
+	137, -1000, -1000, 325, 257, 275, 275, 487, 380, 124,
+	-1000, 275, -1000, 83, 46, 282, -1000, 409, 328, -1000,
+	19, -1000, 275, 218, -1000, -1000, 133, -1000, 121, 282,
+	-1000, 270, -1000, -52, -1000, 218, -1000, -1000, 556, -1000,
+	-58, -1000,
 }
 
 var yyPgo = [...]int{
-	0, 317, 251, 130, 316, 139, 315, 314, 5, 313,
-	12, 8, 7, 312, 311, 11, 4, 9, 310, 309,
-	127, 308, 307, 1, 306, 10, 242, 305, 18, 304,
-	13, 303, 302, 3, 14, 301, 300, 299, 298, 2,
-	297, 15, 296, 295, 0, 6, 196, 294, 293, 292,
-	16, 291, 210,
+	0, 559, 385, 25, 554, 23, 553, 552, 35, 546,
+	544, 543, 542, 541, 14, 13, 11, 532, 531, 12,
+	7, 17, 530, 529, 528, 526, 19, 522, 521, 3,
+	520, 10, 397, 519, 18, 516, 16, 514, 512, 0,
+	20, 505, 504, 503, 497, 5, 8, 495, 494, 493,
+	6, 490, 483, 15, 482, 44, 481, 480, 477, 2,
+	1, 9, 176, 476, 475, 471, 24, 470, 469, 461,
+	21, 4, 479, 456, 443,
 }
 
 var yyR1 = [...]int{
-	0, 1, 2, 2, 2, 52, 52, 4, 4, 5,
-	5, 3, 3, 6, 6, 6, 6, 6, 6, 6,
-	27, 27, 46, 46, 12, 12, 7, 7, 7, 7,
-	51, 51, 50, 13, 13, 15, 15, 16, 11, 11,
-	14, 14, 18, 18, 17, 17, 19, 19, 19, 19,
-	19, 19, 19, 19, 9, 9, 10, 40, 40, 47,
-	47, 48, 48, 48, 8, 24, 24, 21, 21, 22,
-	22, 20, 20, 20, 23, 23, 23, 25, 25, 26,
-	26, 28, 28, 29, 29, 30, 30, 31, 32, 32,
-	34, 34, 38, 38, 35, 35, 39, 39, 43, 43,
-	45, 45, 42, 42, 44, 44, 44, 41, 41, 41,
-	33, 33, 33, 33, 33, 33, 33, 33, 36, 36,
-	36, 49, 49, 37, 37, 37, 37, 37, 37,
+	0, 1, 2, 2, 2, 2, 74, 74, 72, 72,
+	73, 73, 4, 4, 5, 5, 11, 11, 3, 3,
+	12, 12, 12, 6, 6, 6, 6, 6, 6, 6,
+	6, 33, 33, 62, 62, 16, 16, 7, 7, 7,
+	7, 7, 7, 71, 71, 70, 17, 17, 19, 19,
+	20, 15, 15, 18, 18, 22, 22, 23, 23, 45,
+	45, 21, 21, 24, 24, 24, 24, 24, 24, 24,
+	24, 24, 24, 13, 13, 14, 54, 54, 65, 65,
+	44, 44, 52, 52, 63, 63, 68, 68, 69, 69,
+	64, 64, 64, 10, 10, 10, 9, 9, 46, 46,
+	46, 67, 67, 8, 8, 30, 30, 27, 27, 28,
+	28, 28, 28, 26, 26, 25, 25, 25, 29, 29,
+	29, 31, 31, 32, 32, 34, 34, 35, 35, 36,
+	36, 37, 38, 38, 40, 40, 49, 49, 41, 41,
+	50, 50, 51, 51, 58, 58, 61, 61, 57, 57,
+	59, 59, 59, 60, 60, 60, 55, 55, 56, 56,
+	56, 53, 53, 53, 39, 39, 39, 39, 39, 39,
+	39, 39, 39, 39, 42, 42, 42, 42, 47, 47,
+	43, 43, 66, 66, 48, 48, 48, 48, 48, 48,
+	48, 48,
 }
 
 var yyR2 = [...]int{
-	0, 1, 2, 2, 3, 0, 1, 1, 4, 1,
-	1, 2, 3, 3, 3, 4, 11, 8, 9, 6,
-	0, 3, 0, 3, 1, 3, 8, 8, 6, 7,
-	1, 3, 3, 0, 1, 1, 3, 3, 1, 3,
-	1, 3, 0, 1, 1, 3, 1, 1, 1, 1,
-	3, 2, 1, 1, 1, 3, 5, 0, 3, 0,
-	1, 0, 1, 2, 12, 0, 1, 1, 1, 2,
-	4, 1, 3, 4, 1, 3, 5, 3, 4, 1,
-	3, 0, 3, 0, 1, 1, 2, 6, 0, 1,
-	0, 2, 0, 3, 0, 2, 0, 2, 0, 3,
-	0, 4, 2, 4, 0, 1, 1, 0, 1, 2,
-	1, 1, 2, 2, 4, 4, 6, 6, 1, 1,
-	3, 0, 1, 3, 3, 3, 3, 3, 3,
+	0, 2, 2, 2, 2, 3, 0, 1, 0, 1,
+	1, 2, 1, 4, 1, 1, 1, 1, 2, 3,
+	2, 3, 4, 3, 3, 4, 11, 8, 9, 6,
+	3, 0, 3, 0, 3, 1, 3, 9, 8, 8,
+	6, 7, 3, 1, 3, 3, 0, 1, 1, 3,
+	3, 1, 3, 1, 3, 0, 1, 1, 3, 1,
+	1, 1, 3, 1, 1, 1, 1, 3, 4, 6,
+	2, 1, 1, 1, 3, 10, 0, 2, 0, 1,
+	0, 4, 0, 3, 0, 1, 0, 2, 0, 3,
+	0, 1, 2, 3, 2, 2, 1, 4, 0, 4,
+	6, 0, 1, 13, 3, 0, 1, 1, 1, 2,
+	1, 4, 3, 3, 5, 1, 3, 4, 1, 3,
+	5, 3, 4, 1, 3, 0, 3, 0, 1, 1,
+	2, 6, 0, 1, 0, 2, 0, 3, 0, 2,
+	0, 2, 0, 2, 0, 3, 0, 4, 3, 5,
+	0, 1, 1, 0, 2, 2, 1, 1, 1, 1,
+	1, 0, 1, 2, 1, 1, 2, 2, 4, 4,
+	4, 4, 6, 6, 1, 1, 3, 4, 4, 5,
+	0, 2, 0, 1, 3, 3, 3, 3, 3, 3,
+	3, 3,
 }
 
 var yyChk = [...]int{
-	-1000, -1, -2, -4, -8, -5, 20, 30, -6, -7,
-	4, 5, 15, 23, 24, 27, 28, -52, 71, -52,
-	71, 21, -24, 31, 6, 11, 13, 12, 6, 7,
-	11, 25, 25, 32, -26, 57, -2, -3, -5, -21,
-	68, -22, -20, -23, 63, 57, 57, -46, 47, -46,
-	13, 57, -27, 8, 57, -26, -26, -26, 29, 70,
-	22, -52, 71, 32, 65, -41, 57, 44, 72, 70,
-	57, 45, 14, -46, -28, 33, 34, 16, 72, 72,
-	-34, 37, -51, -50, 57, 57, -3, -25, -26, 72,
-	-20, 57, 73, -23, 57, 72, 48, 57, 14, 34,
-	59, 17, -13, -11, 57, -11, -45, 5, -33, -36,
-	-37, 45, 67, 48, -20, -19, 72, 59, 60, 61,
-	62, 57, 52, 53, 51, -34, 65, 56, -45, -28,
-	-8, -41, 73, 70, -9, -10, 57, 72, 57, 59,
-	-10, 73, 65, 73, -39, 40, 13, 66, 67, 69,
-	68, 55, 56, -49, 45, -33, -33, 72, -33, 72,
-	57, -45, -50, -33, -29, -30, -31, -32, 54, -41,
-	73, 57, 65, 58, -11, 72, 26, 57, 26, 59,
-	14, -33, -33, -33, -33, -33, -33, 46, 49, -8,
-	73, 73, -39, -34, -30, 35, -41, 18, -10, -40,
-	74, 73, -11, -15, -16, 72, -15, -12, 57, 72,
-	-33, 72, 73, -38, 38, -25, 19, -47, 50, 59,
-	73, 65, -18, -17, -33, -11, -8, -17, -35, 36,
-	39, -45, -12, -48, 51, 45, 75, -16, 73, 65,
-	73, 73, 73, -43, 41, -33, -14, -23, 14, 73,
-	51, -33, -39, 39, 65, -33, -42, -23, -23, 65,
-	-44, 42, 43, -23, -44,
+	-1000, -1, -72, -73, 104, -2, -4, -9, -10, -5,
+	20, -8, 63, 64, -6, -7, 35, 4, 5, 15,
+	34, 26, 27, 30, 31, 33, 104, -72, -73, -72,
+	61, -72, 21, 11, 65, 66, -30, 36, 6, 11,
+	13, 12, 6, 7, 11, 11, 28, 28, 37, -32,
+	-55, 88, -56, 50, 51, 52, 11, -2, -67, 62,
+	-3, -11, -5, -12, 23, 24, 25, -32, -27, -28,
+	99, -39, -26, -42, -48, 54, 98, 58, -55, -25,
+	-24, 105, 70, 88, -29, 94, 90, 91, 92, 93,
+	60, 77, 83, 76, -55, -62, 57, -62, 13, -55,
+	-33, 8, -55, -55, -32, -32, -32, 32, 103, -55,
+	-8, 22, -74, 104, -55, 23, 10, 37, 96, -53,
+	80, 97, 98, 100, 99, 101, 85, 86, 87, -55,
+	53, -66, 54, -39, -55, -39, 105, 103, -39, -47,
+	71, 105, 105, 105, -55, -55, 54, 14, -62, -34,
+	38, 39, 16, 105, 105, -40, 42, -71, -70, -55,
+	-55, -3, -55, 23, -31, -32, 105, -39, -26, -66,
+	-39, -39, -39, -39, -39, -39, -39, -39, -55, 55,
+	56, 59, 103, -8, 99, -55, 106, -43, 71, 73,
+	-39, 106, -21, -39, 106, -39, -39, 105, 58, -55,
+	14, 39, 90, 17, -17, -15, -55, -15, -61, 5,
+	-39, -40, 96, 87, -55, -61, -34, -8, -53, 76,
+	-39, -39, 105, -55, 106, 103, 74, -39, -39, 72,
+	96, 106, 106, 53, -13, -14, -55, 105, -55, 90,
+	-14, 106, 96, 106, -50, 45, 13, -61, -70, -39,
+	-35, -36, -37, -38, 84, -53, 106, -8, -21, 103,
+	99, -55, 72, -39, -39, 89, 96, 89, -15, 105,
+	29, -8, -55, 29, 90, 14, -50, -40, -36, 40,
+	-53, 106, 106, -39, 106, 18, -14, -52, 107, 106,
+	-15, -19, -20, 105, -46, 14, -19, -16, -55, 105,
+	-49, 43, -31, 19, -54, 79, 90, 106, -46, 96,
+	-22, -23, -45, -39, 81, 67, -15, -41, 41, 44,
+	-61, -16, -63, 75, 88, 108, -20, 106, 96, 68,
+	106, -58, 47, -39, -18, -29, 14, 106, -64, 76,
+	54, -45, 69, 31, -50, 44, 96, -39, -68, 81,
+	76, 32, -51, 46, -57, -29, -29, -69, 14, 82,
+	-71, 90, 96, -59, 48, 49, -65, 12, 31, -29,
+	-60, 50, -44, 78, 82, -59, 51, 52, 105, -60,
+	-39, 106,
 }
 
 var yyDef = [...]int{
-	0, -2, 1, 5, 5, 7, 0, 65, 9, 10,
-	0, 0, 0, 0, 0, 0, 0, 2, 6, 3,
-	6, 0, 0, 66, 0, 22, 22, 0, 0, 20,
-	0, 0, 0, 0, 0, 79, 4, 0, 5, 0,
-	67, 68, 107, 71, 0, 74, 13, 0, 0, 0,
-	22, 14, 81, 0, 0, 0, 0, 90, 0, 0,
-	8, 11, 6, 0, 0, 69, 108, 0, 0, 0,
-	0, 0, 0, 0, 15, 0, 0, 0, 33, 0,
-	100, 0, 90, 30, 0, 80, 12, 100, 81, 0,
-	107, 109, 72, 0, 75, 0, 23, 0, 0, 0,
-	21, 0, 0, 34, 38, 0, 96, 0, 91, -2,
-	111, 0, 0, 0, 118, 119, 0, 46, 47, 48,
-	49, 74, 0, 52, 53, 100, 0, 0, -2, 107,
-	0, 70, 73, 0, 0, 54, 0, 0, 0, 82,
-	19, 0, 0, 0, 28, 0, 0, 0, 0, 0,
-	0, 0, 0, 0, 122, 112, 113, 0, 0, 0,
-	51, 96, 31, 32, 90, 84, -2, 0, 89, 77,
-	107, 76, 0, 57, 0, 0, 0, 39, 0, 97,
-	0, 123, 124, 125, 126, 127, 128, 0, 0, 0,
-	120, 50, 29, 92, 86, 0, 78, 0, 55, 59,
-	0, 17, 0, 26, 35, 42, 27, 101, 24, 0,
-	114, 0, 115, 94, 0, 100, 0, 61, 60, 0,
-	18, 0, 0, 43, 44, 0, 0, 0, 98, 0,
-	0, 0, 0, 56, 62, 0, 58, 36, 37, 0,
-	25, 116, 117, 96, 0, 95, 93, 40, 0, 16,
-	63, 45, 64, 0, 0, 87, 99, 104, 41, 0,
-	102, 105, 106, 104, 103,
+	8, -2, 0, 9, 10, 1, 8, 8, 8, 12,
+	0, 96, 0, 0, 14, 15, 105, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 11, 2, 9, 3,
+	101, 4, 0, 0, 94, 95, 0, 106, 0, 33,
+	33, 0, 0, 31, 0, 0, 0, 0, 0, 0,
+	123, 156, 157, 158, 159, 160, 0, 5, 0, 102,
+	0, 6, 16, 17, 0, 0, 0, 93, 0, -2,
+	107, 161, 110, -2, 165, 0, 0, 0, 118, 174,
+	175, 0, 0, 156, 115, 0, 63, 64, 65, 66,
+	0, 0, 71, 72, 23, 0, 0, 0, 33, 24,
+	125, 0, 0, 30, 0, 0, 134, 0, 0, 42,
+	97, 13, 18, 7, 20, 0, 0, 0, 0, 109,
+	182, 0, 0, 0, 0, 0, 0, 0, 0, 162,
+	0, 0, 183, 166, 118, 167, 0, 0, 0, 180,
+	0, 0, 0, 0, 70, 0, 0, 0, 0, 25,
+	0, 0, 0, 46, 0, 146, 0, 134, 43, 0,
+	124, 19, 21, 0, 146, 125, 0, 161, 112, 0,
+	184, 185, 186, 187, 188, 189, 190, 191, 163, 0,
+	0, 0, 0, 0, 113, 119, 176, 0, 0, 0,
+	0, 67, 0, 61, 116, 0, 0, 0, 34, 0,
+	0, 0, 32, 0, 0, 47, 51, 0, 140, 0,
+	135, 146, 0, 0, 22, -2, 161, 0, 111, 170,
+	168, 169, 0, 119, 171, 0, 177, 0, 181, 0,
+	0, 68, 117, 0, 0, 73, 0, 0, 0, 126,
+	29, 0, 0, 0, 40, 0, 0, 140, 44, 45,
+	134, 128, -2, 0, 133, 121, 161, 0, 0, 0,
+	114, 120, 0, 178, 62, 0, 0, 82, 0, 0,
+	0, 98, 52, 0, 141, 0, 41, 136, 130, 0,
+	122, 172, 173, 179, 69, 0, 74, 76, 0, 27,
+	0, 98, 48, 55, 38, 0, 39, 147, 35, 0,
+	138, 0, 146, 0, 84, 0, 0, 28, 37, 0,
+	0, 56, 57, 59, 60, 0, 0, 144, 0, 0,
+	0, 0, 90, 85, 77, 83, 49, 50, 0, 0,
+	36, 140, 0, 139, 137, 53, 0, 26, 86, 91,
+	0, 58, 99, 0, 142, 0, 0, 131, 88, 0,
+	92, 0, 103, 0, 145, 150, 54, 78, 0, 87,
+	100, 143, 0, 153, 151, 152, 80, 79, 0, 150,
+	148, 0, 75, 0, 89, 153, 154, 155, 0, 149,
+	0, 81,
 }
 
 var yyTok1 = [...]int{
 	1, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
+	3, 3, 3, 3, 3, 3, 3, 101, 3, 3,
+	105, 106, 99, 97, 96, 98, 103, 100, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	72, 73, 68, 66, 65, 67, 70, 69, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
 	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	3, 3, 3, 3, 3, 3, 3, 3, 3, 3,
-	3, 74, 3, 75,
+	3, 107, 3, 108,
 }
 
 var yyTok2 = [...]int{
@@ -402,7 +569,10 @@ var yyTok2 = [...]int{
 	32, 33, 34, 35, 36, 37, 38, 39, 40, 41,
 	42, 43, 44, 45, 46, 47, 48, 49, 50, 51,
 	52, 53, 54, 55, 56, 57, 58, 59, 60, 61,
-	62, 63, 64, 71,
+	62, 63, 64, 65, 66, 67, 68, 69, 70, 71,
+	72, 73, 74, 75, 76, 77, 78, 79, 80, 81,
+	82, 83, 84, 85, 86, 87, 88, 89, 90, 91,
+	92, 93, 94, 95, 102, 104,
 }
 
 var yyTok3 = [...]int{
@@ -745,10 +915,10 @@ yydefault:
 	switch yynt {
 
 	case 1:
-		yyDollar = yyS[yypt-1 : yypt+1]
+		yyDollar = yyS[yypt-2 : yypt+1]
 		{
-			yyVAL.stmts = yyDollar[1].stmts
-			setResult(yylex, yyDollar[1].stmts)
+			yyVAL.stmts = yyDollar[2].stmts
+			setResult(yylex, yyDollar[2].stmts)
 		}
 	case 2:
 		yyDollar = yyS[yypt-2 : yypt+1]
@@ -761,291 +931,464 @@ yydefault:
 			yyVAL.stmts = []SQLStmt{yyDollar[1].stmt}
 		}
 	case 4:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.stmts = []SQLStmt{yyDollar[1].stmt}
+		}
+	case 5:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.stmts = append([]SQLStmt{yyDollar[1].stmt}, yyDollar[3].stmts...)
 		}
-	case 5:
+	case 6:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 		}
-	case 7:
+	case 8:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+		}
+	case 12:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.stmt = yyDollar[1].stmt
 		}
-	case 8:
+	case 13:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyVAL.stmt = &TxStmt{stmts: yyDollar[3].stmts}
 		}
-	case 11:
+	case 18:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.stmts = []SQLStmt{yyDollar[1].stmt}
 		}
-	case 12:
+	case 19:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.stmts = append([]SQLStmt{yyDollar[1].stmt}, yyDollar[3].stmts...)
 		}
-	case 13:
+	case 20:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.stmt = &SavepointStmt{name: yyDollar[2].id}
+		}
+	case 21:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.stmt = &ReleaseSavepointStmt{name: yyDollar[3].id}
+		}
+	case 22:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.stmt = &RollbackToSavepointStmt{name: yyDollar[4].id}
+		}
+	case 23:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.stmt = &CreateDatabaseStmt{DB: yyDollar[3].id}
 		}
-	case 14:
+	case 24:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.stmt = &UseDatabaseStmt{DB: yyDollar[3].id}
 		}
-	case 15:
+	case 25:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyVAL.stmt = &UseSnapshotStmt{sinceTx: yyDollar[3].number, asBefore: yyDollar[4].number}
 		}
-	case 16:
+	case 26:
 		yyDollar = yyS[yypt-11 : yypt+1]
 		{
 			yyVAL.stmt = &CreateTableStmt{ifNotExists: yyDollar[3].boolean, table: yyDollar[4].id, colsSpec: yyDollar[6].colsSpec, pkColNames: yyDollar[10].ids}
 		}
-	case 17:
+	case 27:
 		yyDollar = yyS[yypt-8 : yypt+1]
 		{
 			yyVAL.stmt = &CreateIndexStmt{ifNotExists: yyDollar[3].boolean, table: yyDollar[5].id, cols: yyDollar[7].ids}
 		}
-	case 18:
+	case 28:
 		yyDollar = yyS[yypt-9 : yypt+1]
 		{
 			yyVAL.stmt = &CreateIndexStmt{unique: true, ifNotExists: yyDollar[4].boolean, table: yyDollar[6].id, cols: yyDollar[8].ids}
 		}
-	case 19:
+	case 29:
 		yyDollar = yyS[yypt-6 : yypt+1]
 		{
 			yyVAL.stmt = &AddColumnStmt{table: yyDollar[3].id, colSpec: yyDollar[6].colSpec}
 		}
-	case 20:
+	case 30:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.stmt = &AnalyzeTableStmt{table: yyDollar[3].id}
+		}
+	case 31:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.number = 0
 		}
-	case 21:
+	case 32:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.number = yyDollar[3].number
 		}
-	case 22:
+	case 33:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.boolean = false
 		}
-	case 23:
+	case 34:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.boolean = true
 		}
-	case 24:
+	case 35:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.ids = []string{yyDollar[1].id}
 		}
-	case 25:
+	case 36:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.ids = yyDollar[2].ids
 		}
-	case 26:
+	case 37:
+		yyDollar = yyS[yypt-9 : yypt+1]
+		{
+			yyVAL.stmt = &UpsertIntoStmt{isInsert: true, tableRef: yyDollar[3].tableRef, cols: yyDollar[5].ids, rows: yyDollar[8].rows, onConflict: yyDollar[9].onConflict}
+		}
+	case 38:
 		yyDollar = yyS[yypt-8 : yypt+1]
 		{
-			yyVAL.stmt = &UpsertIntoStmt{isInsert: true, tableRef: yyDollar[3].tableRef, cols: yyDollar[5].ids, rows: yyDollar[8].rows}
+			yyVAL.stmt = &UpsertIntoStmt{isInsert: true, tableRef: yyDollar[3].tableRef, cols: yyDollar[5].ids, ds: yyDollar[7].stmt.(DataSource), onConflict: yyDollar[8].onConflict}
 		}
-	case 27:
+	case 39:
 		yyDollar = yyS[yypt-8 : yypt+1]
 		{
 			yyVAL.stmt = &UpsertIntoStmt{tableRef: yyDollar[3].tableRef, cols: yyDollar[5].ids, rows: yyDollar[8].rows}
 		}
-	case 28:
+	case 40:
 		yyDollar = yyS[yypt-6 : yypt+1]
 		{
 			yyVAL.stmt = &DeleteFromStmt{tableRef: yyDollar[3].tableRef, where: yyDollar[4].exp, indexOn: yyDollar[5].ids, limit: int(yyDollar[6].number)}
 		}
-	case 29:
+	case 41:
 		yyDollar = yyS[yypt-7 : yypt+1]
 		{
 			yyVAL.stmt = &UpdateStmt{tableRef: yyDollar[2].tableRef, updates: yyDollar[4].updates, where: yyDollar[5].exp, indexOn: yyDollar[6].ids, limit: int(yyDollar[7].number)}
 		}
-	case 30:
+	case 42:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.stmt = &TruncateTableStmt{table: yyDollar[3].id}
+		}
+	case 43:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.updates = []*colUpdate{yyDollar[1].update}
 		}
-	case 31:
+	case 44:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.updates = append(yyDollar[1].updates, yyDollar[3].update)
 		}
-	case 32:
+	case 45:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.update = &colUpdate{col: yyDollar[1].id, op: yyDollar[2].cmpOp, val: yyDollar[3].exp}
 		}
-	case 33:
+	case 46:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.ids = nil
 		}
-	case 34:
+	case 47:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.ids = yyDollar[1].ids
 		}
-	case 35:
+	case 48:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.rows = []*RowSpec{yyDollar[1].row}
 		}
-	case 36:
+	case 49:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.rows = append(yyDollar[1].rows, yyDollar[3].row)
 		}
-	case 37:
+	case 50:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.row = &RowSpec{Values: yyDollar[2].values}
 		}
-	case 38:
+	case 51:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.ids = []string{yyDollar[1].id}
 		}
-	case 39:
+	case 52:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.ids = append(yyDollar[1].ids, yyDollar[3].id)
 		}
-	case 40:
+	case 53:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.cols = []*ColSelector{yyDollar[1].col}
 		}
-	case 41:
+	case 54:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.cols = append(yyDollar[1].cols, yyDollar[3].col)
 		}
-	case 42:
+	case 55:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.values = nil
 		}
-	case 43:
+	case 56:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.values = yyDollar[1].values
 		}
-	case 44:
+	case 57:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.values = []ValueExp{yyDollar[1].exp}
 		}
-	case 45:
+	case 58:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.values = append(yyDollar[1].values, yyDollar[3].exp)
 		}
-	case 46:
+	case 59:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.exp = yyDollar[1].exp
+		}
+	case 60:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.exp = &DefaultValue{}
+		}
+	case 61:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.values = []ValueExp{yyDollar[1].exp}
+		}
+	case 62:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.values = append(yyDollar[1].values, yyDollar[3].exp)
+		}
+	case 63:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.value = &Number{val: int64(yyDollar[1].number)}
 		}
-	case 47:
+	case 64:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.value = &Varchar{val: yyDollar[1].str}
 		}
-	case 48:
+	case 65:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.value = &Bool{val: yyDollar[1].boolean}
 		}
-	case 49:
+	case 66:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.value = &Blob{val: yyDollar[1].blob}
 		}
-	case 50:
+	case 67:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.value = &SysFn{fn: yyDollar[1].id}
 		}
-	case 51:
+	case 68:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.value = &FnCall{fn: yyDollar[1].id, params: yyDollar[3].values}
+		}
+	case 69:
+		yyDollar = yyS[yypt-6 : yypt+1]
+		{
+			yyVAL.value = &Cast{val: yyDollar[3].exp, t: yyDollar[5].sqlType}
+		}
+	case 70:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.value = &Param{id: yyDollar[2].id}
 		}
-	case 52:
+	case 71:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.value = &Param{id: fmt.Sprintf("param%d", yyDollar[1].pparam), pos: yyDollar[1].pparam}
 		}
-	case 53:
+	case 72:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.value = &NullValue{t: AnyType}
 		}
-	case 54:
+	case 73:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.colsSpec = []*ColSpec{yyDollar[1].colSpec}
 		}
-	case 55:
+	case 74:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.colsSpec = append(yyDollar[1].colsSpec, yyDollar[3].colSpec)
 		}
-	case 56:
-		yyDollar = yyS[yypt-5 : yypt+1]
+	case 75:
+		yyDollar = yyS[yypt-10 : yypt+1]
 		{
-			yyVAL.colSpec = &ColSpec{colName: yyDollar[1].id, colType: yyDollar[2].sqlType, maxLen: int(yyDollar[3].number), autoIncrement: yyDollar[4].boolean, notNull: yyDollar[5].boolean}
+			yyVAL.colSpec = &ColSpec{colName: yyDollar[1].id, colType: yyDollar[2].sqlType, maxLen: int(yyDollar[3].number), collation: yyDollar[4].id, autoIncrement: yyDollar[5].boolean, notNull: yyDollar[6].boolean, defaultNow: yyDollar[7].boolean, updateNow: yyDollar[8].boolean, unique: yyDollar[9].boolean, check: yyDollar[10].exp}
 		}
-	case 57:
+	case 76:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.id = ""
+		}
+	case 77:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.id = yyDollar[2].id
+		}
+	case 78:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.boolean = false
+		}
+	case 79:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.boolean = true
+		}
+	case 80:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.exp = nil
+		}
+	case 81:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.exp = yyDollar[3].exp
+		}
+	case 82:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.number = 0
 		}
-	case 58:
+	case 83:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.number = yyDollar[2].number
 		}
-	case 59:
+	case 84:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.boolean = false
 		}
-	case 60:
+	case 85:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.boolean = true
 		}
-	case 61:
+	case 86:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.boolean = false
 		}
-	case 62:
+	case 87:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.boolean = true
+		}
+	case 88:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.boolean = false
+		}
+	case 89:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.boolean = true
+		}
+	case 90:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.boolean = false
+		}
+	case 91:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.boolean = false
 		}
-	case 63:
+	case 92:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.boolean = true
 		}
-	case 64:
-		yyDollar = yyS[yypt-12 : yypt+1]
+	case 93:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.stmt = &DescribeTableStmt{table: yyDollar[3].tableRef}
+		}
+	case 94:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.stmt = &ShowTablesStmt{}
+		}
+	case 95:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.stmt = &ShowDatabasesStmt{}
+		}
+	case 96:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.stmt = yyDollar[1].stmt
+		}
+	case 97:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.stmt = &UnionStmt{
+				distinct: !yyDollar[3].boolean,
+				left:     yyDollar[1].stmt.(DQLStmt),
+				right:    yyDollar[4].stmt.(DQLStmt),
+			}
+		}
+	case 98:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.onConflict = nil
+		}
+	case 99:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.onConflict = &OnConflictDo{}
+		}
+	case 100:
+		yyDollar = yyS[yypt-6 : yypt+1]
+		{
+			yyVAL.onConflict = &OnConflictDo{updates: yyDollar[6].updates}
+		}
+	case 101:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.boolean = false
+		}
+	case 102:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.boolean = true
+		}
+	case 103:
+		yyDollar = yyS[yypt-13 : yypt+1]
 		{
 			yyVAL.stmt = &SelectStmt{
 				distinct:  yyDollar[2].distinct,
@@ -1058,329 +1401,456 @@ yydefault:
 				having:    yyDollar[10].exp,
 				orderBy:   yyDollar[11].ordcols,
 				limit:     int(yyDollar[12].number),
+				offset:    int(yyDollar[13].number),
 			}
 		}
-	case 65:
+	case 104:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.stmt = &SelectStmt{
+				distinct:  yyDollar[2].distinct,
+				selectors: yyDollar[3].sels,
+			}
+		}
+	case 105:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.distinct = false
 		}
-	case 66:
+	case 106:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.distinct = true
 		}
-	case 67:
+	case 107:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.sels = nil
 		}
-	case 68:
+	case 108:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.sels = yyDollar[1].sels
 		}
-	case 69:
+	case 109:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
-			yyDollar[1].sel.setAlias(yyDollar[2].id)
+			yyVAL.sels = []Selector{newSelector(yyDollar[1].exp, yyDollar[2].id)}
+		}
+	case 110:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
 			yyVAL.sels = []Selector{yyDollar[1].sel}
 		}
-	case 70:
+	case 111:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
-			yyDollar[3].sel.setAlias(yyDollar[4].id)
+			yyVAL.sels = append(yyDollar[1].sels, newSelector(yyDollar[3].exp, yyDollar[4].id))
+		}
+	case 112:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
 			yyVAL.sels = append(yyDollar[1].sels, yyDollar[3].sel)
 		}
-	case 71:
+	case 113:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.sel = &wildcardSelector{table: yyDollar[1].id}
+		}
+	case 114:
+		yyDollar = yyS[yypt-5 : yypt+1]
+		{
+			yyVAL.sel = &wildcardSelector{db: yyDollar[1].id, table: yyDollar[3].id}
+		}
+	case 115:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.sel = yyDollar[1].col
 		}
-	case 72:
+	case 116:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.sel = &AggColSelector{aggFn: yyDollar[1].aggFn, col: "*"}
 		}
-	case 73:
+	case 117:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
-			yyVAL.sel = &AggColSelector{aggFn: yyDollar[1].aggFn, db: yyDollar[3].col.db, table: yyDollar[3].col.table, col: yyDollar[3].col.col}
+			sel, err := newAggColSelector(yyDollar[1].aggFn, yyDollar[3].exp)
+			if err != nil {
+				yylex.Error(err.Error())
+			}
+
+			yyVAL.sel = sel
 		}
-	case 74:
+	case 118:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.col = &ColSelector{col: yyDollar[1].id}
 		}
-	case 75:
+	case 119:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.col = &ColSelector{table: yyDollar[1].id, col: yyDollar[3].id}
 		}
-	case 76:
+	case 120:
 		yyDollar = yyS[yypt-5 : yypt+1]
 		{
 			yyVAL.col = &ColSelector{db: yyDollar[1].id, table: yyDollar[3].id, col: yyDollar[5].id}
 		}
-	case 77:
+	case 121:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyDollar[1].tableRef.asBefore = yyDollar[2].number
 			yyDollar[1].tableRef.as = yyDollar[3].id
 			yyVAL.ds = yyDollar[1].tableRef
 		}
-	case 78:
+	case 122:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyDollar[2].stmt.(*SelectStmt).as = yyDollar[4].id
 			yyVAL.ds = yyDollar[2].stmt.(DataSource)
 		}
-	case 79:
+	case 123:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.tableRef = &tableRef{table: yyDollar[1].id}
 		}
-	case 80:
+	case 124:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.tableRef = &tableRef{db: yyDollar[1].id, table: yyDollar[3].id}
 		}
-	case 81:
+	case 125:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.number = 0
 		}
-	case 82:
+	case 126:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.number = yyDollar[3].number
 		}
-	case 83:
+	case 127:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.joins = nil
 		}
-	case 84:
+	case 128:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.joins = yyDollar[1].joins
 		}
-	case 85:
+	case 129:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.joins = []*JoinSpec{yyDollar[1].join}
 		}
-	case 86:
+	case 130:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.joins = append([]*JoinSpec{yyDollar[1].join}, yyDollar[2].joins...)
 		}
-	case 87:
+	case 131:
 		yyDollar = yyS[yypt-6 : yypt+1]
 		{
 			yyVAL.join = &JoinSpec{joinType: yyDollar[1].joinType, ds: yyDollar[3].ds, indexOn: yyDollar[4].ids, cond: yyDollar[6].exp}
 		}
-	case 88:
+	case 132:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.joinType = InnerJoin
 		}
-	case 89:
+	case 133:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.joinType = yyDollar[1].joinType
 		}
-	case 90:
+	case 134:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.exp = nil
 		}
-	case 91:
+	case 135:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[2].exp
 		}
-	case 92:
+	case 136:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.cols = nil
 		}
-	case 93:
+	case 137:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.cols = yyDollar[3].cols
 		}
-	case 94:
+	case 138:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.exp = nil
 		}
-	case 95:
+	case 139:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[2].exp
 		}
-	case 96:
+	case 140:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.number = 0
 		}
-	case 97:
+	case 141:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.number = yyDollar[2].number
 		}
-	case 98:
+	case 142:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.number = 0
+		}
+	case 143:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.number = yyDollar[2].number
+		}
+	case 144:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.ordcols = nil
 		}
-	case 99:
+	case 145:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.ordcols = yyDollar[3].ordcols
 		}
-	case 100:
+	case 146:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.ids = nil
 		}
-	case 101:
+	case 147:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyVAL.ids = yyDollar[4].ids
 		}
-	case 102:
-		yyDollar = yyS[yypt-2 : yypt+1]
+	case 148:
+		yyDollar = yyS[yypt-3 : yypt+1]
 		{
-			yyVAL.ordcols = []*OrdCol{{sel: yyDollar[1].col, descOrder: yyDollar[2].opt_ord}}
+			yyVAL.ordcols = []*OrdCol{{sel: yyDollar[1].col, descOrder: yyDollar[2].opt_ord, nullsOrder: yyDollar[3].nullsOrder}}
 		}
-	case 103:
-		yyDollar = yyS[yypt-4 : yypt+1]
+	case 149:
+		yyDollar = yyS[yypt-5 : yypt+1]
 		{
-			yyVAL.ordcols = append(yyDollar[1].ordcols, &OrdCol{sel: yyDollar[3].col, descOrder: yyDollar[4].opt_ord})
+			yyVAL.ordcols = append(yyDollar[1].ordcols, &OrdCol{sel: yyDollar[3].col, descOrder: yyDollar[4].opt_ord, nullsOrder: yyDollar[5].nullsOrder})
 		}
-	case 104:
+	case 150:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.opt_ord = false
 		}
-	case 105:
+	case 151:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.opt_ord = false
 		}
-	case 106:
+	case 152:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.opt_ord = true
 		}
-	case 107:
+	case 153:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.nullsOrder = NullsDefault
+		}
+	case 154:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.nullsOrder = NullsFirst
+		}
+	case 155:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.nullsOrder = NullsLast
+		}
+	case 156:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.id = yyDollar[1].id
+		}
+	case 157:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.id = yyDollar[1].id
+		}
+	case 158:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.id = "nulls"
+		}
+	case 159:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.id = "first"
+		}
+	case 160:
+		yyDollar = yyS[yypt-1 : yypt+1]
+		{
+			yyVAL.id = "last"
+		}
+	case 161:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.id = ""
 		}
-	case 108:
+	case 162:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.id = yyDollar[1].id
 		}
-	case 109:
+	case 163:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.id = yyDollar[2].id
 		}
-	case 110:
+	case 164:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[1].exp
 		}
-	case 111:
+	case 165:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[1].binExp
 		}
-	case 112:
+	case 166:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
 			yyVAL.exp = &NotBoolExp{exp: yyDollar[2].exp}
 		}
-	case 113:
+	case 167:
 		yyDollar = yyS[yypt-2 : yypt+1]
 		{
-			yyVAL.exp = &NumExp{left: &Number{val: 0}, op: SUBSOP, right: yyDollar[2].exp}
+			yyVAL.exp = &NegExp{exp: yyDollar[2].exp}
 		}
-	case 114:
+	case 168:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyVAL.exp = &LikeBoolExp{val: yyDollar[1].exp, notLike: yyDollar[2].boolean, pattern: yyDollar[4].exp}
 		}
-	case 115:
+	case 169:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.exp = &LikeBoolExp{val: yyDollar[1].exp, notLike: yyDollar[2].boolean, pattern: yyDollar[4].exp, caseInsensitive: true}
+		}
+	case 170:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.exp = &IsNullBoolExp{exp: yyDollar[1].exp, not: yyDollar[3].boolean}
+		}
+	case 171:
 		yyDollar = yyS[yypt-4 : yypt+1]
 		{
 			yyVAL.exp = &ExistsBoolExp{q: (yyDollar[3].stmt).(*SelectStmt)}
 		}
-	case 116:
+	case 172:
 		yyDollar = yyS[yypt-6 : yypt+1]
 		{
 			yyVAL.exp = &InSubQueryExp{val: yyDollar[1].exp, notIn: yyDollar[2].boolean, q: yyDollar[5].stmt.(*SelectStmt)}
 		}
-	case 117:
+	case 173:
 		yyDollar = yyS[yypt-6 : yypt+1]
 		{
 			yyVAL.exp = &InListExp{val: yyDollar[1].exp, notIn: yyDollar[2].boolean, values: yyDollar[5].values}
 		}
-	case 118:
+	case 174:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[1].sel
 		}
-	case 119:
+	case 175:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[1].value
 		}
-	case 120:
+	case 176:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.exp = yyDollar[2].exp
 		}
-	case 121:
+	case 177:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.exp = &CaseExp{whenThens: yyDollar[2].whenThens, elseExp: yyDollar[3].exp}
+		}
+	case 178:
+		yyDollar = yyS[yypt-4 : yypt+1]
+		{
+			yyVAL.whenThens = []*whenThen{{when: yyDollar[2].exp, then: yyDollar[4].exp}}
+		}
+	case 179:
+		yyDollar = yyS[yypt-5 : yypt+1]
+		{
+			yyVAL.whenThens = append(yyDollar[1].whenThens, &whenThen{when: yyDollar[3].exp, then: yyDollar[5].exp})
+		}
+	case 180:
+		yyDollar = yyS[yypt-0 : yypt+1]
+		{
+			yyVAL.exp = nil
+		}
+	case 181:
+		yyDollar = yyS[yypt-2 : yypt+1]
+		{
+			yyVAL.exp = yyDollar[2].exp
+		}
+	case 182:
 		yyDollar = yyS[yypt-0 : yypt+1]
 		{
 			yyVAL.boolean = false
 		}
-	case 122:
+	case 183:
 		yyDollar = yyS[yypt-1 : yypt+1]
 		{
 			yyVAL.boolean = true
 		}
-	case 123:
+	case 184:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.binExp = &NumExp{left: yyDollar[1].exp, op: ADDOP, right: yyDollar[3].exp}
 		}
-	case 124:
+	case 185:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.binExp = &NumExp{left: yyDollar[1].exp, op: SUBSOP, right: yyDollar[3].exp}
 		}
-	case 125:
+	case 186:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.binExp = &NumExp{left: yyDollar[1].exp, op: DIVOP, right: yyDollar[3].exp}
 		}
-	case 126:
+	case 187:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.binExp = &NumExp{left: yyDollar[1].exp, op: MULTOP, right: yyDollar[3].exp}
 		}
-	case 127:
+	case 188:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
-			yyVAL.binExp = &BinBoolExp{left: yyDollar[1].exp, op: yyDollar[2].logicOp, right: yyDollar[3].exp}
+			yyVAL.binExp = &NumExp{left: yyDollar[1].exp, op: MODOP, right: yyDollar[3].exp}
 		}
-	case 128:
+	case 189:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.binExp = &BinBoolExp{left: yyDollar[1].exp, op: AND, right: yyDollar[3].exp}
+		}
+	case 190:
+		yyDollar = yyS[yypt-3 : yypt+1]
+		{
+			yyVAL.binExp = &BinBoolExp{left: yyDollar[1].exp, op: OR, right: yyDollar[3].exp}
+		}
+	case 191:
 		yyDollar = yyS[yypt-3 : yypt+1]
 		{
 			yyVAL.binExp = &CmpBoolExp{left: yyDollar[1].exp, op: yyDollar[2].cmpOp, right: yyDollar[3].exp}
@@ -18,10 +18,17 @@ package sql
 
 import (
 	"bytes"
+	"context"
+	"encoding/base64"
 	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"reflect"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -31,18 +38,36 @@ import (
 const (
 	catalogDatabasePrefix = "CTL.DATABASE." // (key=CTL.DATABASE.{dbID}, value={dbNAME})
 	catalogTablePrefix    = "CTL.TABLE."    // (key=CTL.TABLE.{dbID}{tableID}, value={tableNAME})
-	catalogColumnPrefix   = "CTL.COLUMN."   // (key=CTL.COLUMN.{dbID}{tableID}{colID}{colTYPE}, value={(auto_incremental | nullable){maxLen}{colNAME}})
+	catalogColumnPrefix   = "CTL.COLUMN."   // (key=CTL.COLUMN.{dbID}{tableID}{colID}{colTYPE}, value={(auto_incremental | nullable | nocase){maxLen}{colNAME}})
 	catalogIndexPrefix    = "CTL.INDEX."    // (key=CTL.INDEX.{dbID}{tableID}{indexID}, value={unique {colID1}(ASC|DESC)...{colIDN}(ASC|DESC)})
+	catalogCheckPrefix    = "CTL.CHECK."    // (key=CTL.CHECK.{dbID}{tableID}{colID}, value={checkEXP})
+	catalogStatsPrefix    = "CTL.STATS."    // (key=CTL.STATS.{dbID}{tableID}{colID}, value={distinctCount nullCount [{min}{max}]})
 	PIndexPrefix          = "P."            // (key=P.{dbID}{tableID}{0}({pkVal}{padding}{pkValLen})+, value={count (colID valLen val)+})
 	SIndexPrefix          = "S."            // (key=S.{dbID}{tableID}{indexID}({val}{padding}{valLen})+({pkVal}{padding}{pkValLen})+, value={})
 	UIndexPrefix          = "U."            // (key=U.{dbID}{tableID}{indexID}({val}{padding}{valLen})+, value={({pkVal}{padding}{pkValLen})+})
+	truncatedTablePrefix  = "TRUNC."        // (key=TRUNC.{dbID}{tableID}, value={})
 )
 
 const PKIndexID = uint32(0)
 
+// nowParam is the internal parameter holding the timestamp returned by NOW(),
+// it can not be referenced from sql as it is not a valid identifier
+const nowParam = "@now"
+
 const (
 	nullableFlag      byte = 1 << iota
 	autoIncrementFlag byte = 1 << iota
+	noCaseFlag        byte = 1 << iota
+	defaultNowFlag    byte = 1 << iota
+	updateNowFlag     byte = 1 << iota
+)
+
+type Collation = string
+
+const (
+	BinaryCollation Collation = "BINARY"
+	// NoCaseCollation compares strings ignoring the case of ASCII letters
+	NoCaseCollation Collation = "NOCASE"
 )
 
 type SQLValueType = string
@@ -91,6 +116,7 @@ const (
 	SUBSOP
 	DIVOP
 	MULTOP
+	MODOP
 )
 
 type JoinType = int
@@ -106,9 +132,14 @@ type TxSummary struct {
 
 	ces []*store.EntrySpec
 	des []*store.EntrySpec
+	// index entries backfilled by DDL statements, committed into the data store after catalog changes
+	ies []*store.EntrySpec
 
 	updatedRows     int
 	lastInsertedPKs map[string]int64
+
+	// valbuf is reused to encode the rows written by the statement
+	valbuf []byte
 }
 
 func newTxSummary(db *Database) *TxSummary {
@@ -129,6 +160,7 @@ func (s *TxSummary) add(summary *TxSummary) error {
 
 	s.ces = append(s.ces, summary.ces...)
 	s.des = append(s.des, summary.des...)
+	s.ies = append(s.ies, summary.ies...)
 
 	for t, pk := range summary.lastInsertedPKs {
 		s.lastInsertedPKs[t] = pk
@@ -142,6 +174,13 @@ type SQLStmt interface {
 	inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error
 }
 
+// DQLStmt is a statement whose resulting rows can be read
+type DQLStmt interface {
+	SQLStmt
+	Resolve(ctx context.Context, e *Engine, snap *store.Snapshot, implicitDB *Database, params map[string]interface{}, scanSpecs *ScanSpecs) (RowReader, error)
+	Alias() string
+}
+
 type TxStmt struct {
 	stmts []SQLStmt
 }
@@ -160,7 +199,45 @@ func (stmt *TxStmt) inferParameters(e *Engine, implicitDB *Database, params map[
 func (stmt *TxStmt) compileUsing(e *Engine, implicitDB *Database, params map[string]interface{}) (summary *TxSummary, err error) {
 	summary = newTxSummary(implicitDB)
 
+	var savepoints []*savepoint
+
 	for _, stmt := range stmt.stmts {
+		switch sp := stmt.(type) {
+		case *SavepointStmt:
+			{
+				savepoints = append(savepoints, newSavepoint(sp.name, e.catalog, summary))
+				continue
+			}
+		case *ReleaseSavepointStmt:
+			{
+				i, err := lookupSavepoint(savepoints, sp.name)
+				if err != nil {
+					return nil, err
+				}
+
+				// savepoints established after the released one are released as well
+				savepoints = savepoints[:i]
+				continue
+			}
+		case *RollbackToSavepointStmt:
+			{
+				i, err := lookupSavepoint(savepoints, sp.name)
+				if err != nil {
+					return nil, err
+				}
+
+				// the savepoint remains established, so it can be rolled back to again
+				savepoints[i].restore(e, summary)
+				savepoints = savepoints[:i+1]
+				continue
+			}
+		}
+
+		err = checkIndexAfterDML(stmt, summary)
+		if err != nil {
+			return nil, err
+		}
+
 		stmtSummary, err := stmt.compileUsing(e, summary.db, params)
 		if err != nil {
 			return nil, err
@@ -175,6 +252,119 @@ func (stmt *TxStmt) compileUsing(e *Engine, implicitDB *Database, params map[str
 	return summary, nil
 }
 
+// checkIndexAfterDML rejects the creation of an index after rows were written in the same transaction,
+// the backfill only reads committed rows so the pending ones would be left unindexed
+func checkIndexAfterDML(stmt SQLStmt, summary *TxSummary) error {
+	_, isCreateIndex := stmt.(*CreateIndexStmt)
+	if isCreateIndex && len(summary.des) > 0 {
+		return ErrDDLorDMLTxOnly
+	}
+
+	return nil
+}
+
+// savepoint holds the state of a transaction at the time the savepoint was established
+type savepoint struct {
+	name            string
+	catalog         *Catalog
+	db              *Database
+	updatedRows     int
+	ces, des, ies   int
+	lastInsertedPKs map[string]int64
+}
+
+func newSavepoint(name string, catalog *Catalog, summary *TxSummary) *savepoint {
+	sp := &savepoint{
+		name:            name,
+		catalog:         catalog.Clone(),
+		db:              summary.db,
+		updatedRows:     summary.updatedRows,
+		ces:             len(summary.ces),
+		des:             len(summary.des),
+		ies:             len(summary.ies),
+		lastInsertedPKs: make(map[string]int64, len(summary.lastInsertedPKs)),
+	}
+
+	for t, pk := range summary.lastInsertedPKs {
+		sp.lastInsertedPKs[t] = pk
+	}
+
+	return sp
+}
+
+// restore discards the entries and catalog changes made after the savepoint was established
+func (sp *savepoint) restore(e *Engine, summary *TxSummary) {
+	// the savepoint keeps its own copy, as it may be restored more than once
+	e.catalog = sp.catalog.Clone()
+	// the catalog is reverted when the transaction is not committed
+	e.catalog.mutated = true
+
+	summary.db = nil
+	if sp.db != nil {
+		summary.db = e.catalog.dbsByID[sp.db.id]
+	}
+
+	summary.updatedRows = sp.updatedRows
+	summary.ces = summary.ces[:sp.ces]
+	summary.des = summary.des[:sp.des]
+	summary.ies = summary.ies[:sp.ies]
+
+	summary.lastInsertedPKs = make(map[string]int64, len(sp.lastInsertedPKs))
+	for t, pk := range sp.lastInsertedPKs {
+		summary.lastInsertedPKs[t] = pk
+	}
+}
+
+// lookupSavepoint returns the position of the latest savepoint established with the given name
+func lookupSavepoint(savepoints []*savepoint, name string) (int, error) {
+	for i := len(savepoints) - 1; i >= 0; i-- {
+		if savepoints[i].name == name {
+			return i, nil
+		}
+	}
+
+	return -1, fmt.Errorf("%w (%s)", ErrSavepointDoesNotExist, name)
+}
+
+// SavepointStmt establishes a savepoint within a transaction
+type SavepointStmt struct {
+	name string
+}
+
+func (stmt *SavepointStmt) inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error {
+	return nil
+}
+
+func (stmt *SavepointStmt) compileUsing(e *Engine, implicitDB *Database, params map[string]interface{}) (summary *TxSummary, err error) {
+	return nil, fmt.Errorf("%w: savepoints can only be used within transactions", ErrIllegalArguments)
+}
+
+// ReleaseSavepointStmt releases a savepoint, keeping the changes made after it was established
+type ReleaseSavepointStmt struct {
+	name string
+}
+
+func (stmt *ReleaseSavepointStmt) inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error {
+	return nil
+}
+
+func (stmt *ReleaseSavepointStmt) compileUsing(e *Engine, implicitDB *Database, params map[string]interface{}) (summary *TxSummary, err error) {
+	return nil, fmt.Errorf("%w: savepoints can only be used within transactions", ErrIllegalArguments)
+}
+
+// RollbackToSavepointStmt discards the changes made after a savepoint was established
+type RollbackToSavepointStmt struct {
+	name string
+}
+
+func (stmt *RollbackToSavepointStmt) inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error {
+	return nil
+}
+
+func (stmt *RollbackToSavepointStmt) compileUsing(e *Engine, implicitDB *Database, params map[string]interface{}) (summary *TxSummary, err error) {
+	return nil, fmt.Errorf("%w: savepoints can only be used within transactions", ErrIllegalArguments)
+}
+
 type CreateDatabaseStmt struct {
 	DB string
 }
@@ -244,6 +434,52 @@ func (stmt *CreateTableStmt) inferParameters(e *Engine, implicitDB *Database, pa
 	return nil
 }
 
+// validatePK checks primary key columns are defined and can be encoded as part of a key,
+// the order in which they are declared is the order in which rows are sorted
+func (stmt *CreateTableStmt) validatePK() error {
+	if len(stmt.pkColNames) == 0 {
+		return ErrIllegalArguments
+	}
+
+	if len(stmt.pkColNames) > MaxNumberOfColumnsInIndex {
+		return ErrMaxNumberOfColumnsInIndexExceeded
+	}
+
+	specsByName := make(map[string]*ColSpec, len(stmt.colsSpec))
+	for _, cs := range stmt.colsSpec {
+		specsByName[cs.colName] = cs
+	}
+
+	pkCols := make(map[string]struct{}, len(stmt.pkColNames))
+
+	for _, colName := range stmt.pkColNames {
+		cs, ok := specsByName[colName]
+		if !ok {
+			return fmt.Errorf("%w (%s)", ErrColumnDoesNotExist, colName)
+		}
+
+		_, duplicated := pkCols[colName]
+		if duplicated {
+			return fmt.Errorf("%w (%s)", ErrDuplicatedColumn, colName)
+		}
+		pkCols[colName] = struct{}{}
+
+		if cs.autoIncrement && cs.colType != IntegerType {
+			return ErrAutoIncrementWrongType
+		}
+
+		if variableSized(cs.colType) && (cs.maxLen == 0 || cs.maxLen > maxKeyLen) {
+			return fmt.Errorf("%w (%s)", ErrLimitedKeyType, colName)
+		}
+
+		if cs.updateNow {
+			return fmt.Errorf("%w (%s)", ErrPKCanNotBeUpdated, colName)
+		}
+	}
+
+	return nil
+}
+
 func (stmt *CreateTableStmt) compileUsing(e *Engine, implicitDB *Database, params map[string]interface{}) (summary *TxSummary, err error) {
 	if implicitDB == nil {
 		return nil, ErrNoDatabaseSelected
@@ -255,6 +491,18 @@ func (stmt *CreateTableStmt) compileUsing(e *Engine, implicitDB *Database, param
 		return summary, nil
 	}
 
+	for _, cs := range stmt.colsSpec {
+		if isSystemColumn(cs.colName) {
+			return nil, fmt.Errorf("%w: %s is a system column", ErrInvalidColumn, cs.colName)
+		}
+	}
+
+	// the primary key is validated before the table gets registered into the catalog
+	err = stmt.validatePK()
+	if err != nil {
+		return nil, err
+	}
+
 	table, err := implicitDB.newTable(stmt.table, stmt.colsSpec)
 	if err != nil {
 		return nil, err
@@ -272,7 +520,7 @@ func (stmt *CreateTableStmt) compileUsing(e *Engine, implicitDB *Database, param
 	}
 
 	for _, col := range table.Cols() {
-		//{auto_incremental | nullable}{maxLen}{colNAME})
+		//{auto_incremental | nullable | nocase | default_now | update_now}{maxLen}{colNAME})
 		v := make([]byte, 1+4+len(col.colName))
 
 		if col.autoIncrement {
@@ -299,6 +547,18 @@ func (stmt *CreateTableStmt) compileUsing(e *Engine, implicitDB *Database, param
 			v[0] = v[0] | nullableFlag
 		}
 
+		if col.collation == NoCaseCollation {
+			v[0] = v[0] | noCaseFlag
+		}
+
+		if col.defaultNow {
+			v[0] = v[0] | defaultNowFlag
+		}
+
+		if col.updateNow {
+			v[0] = v[0] | updateNowFlag
+		}
+
 		binary.BigEndian.PutUint32(v[1:], uint32(col.MaxLen()))
 
 		copy(v[5:], []byte(col.Name()))
@@ -308,6 +568,44 @@ func (stmt *CreateTableStmt) compileUsing(e *Engine, implicitDB *Database, param
 			Value: v,
 		}
 		summary.ces = append(summary.ces, ce)
+
+		if col.check != nil {
+			check, err := expString(col.check)
+			if err != nil {
+				return nil, err
+			}
+
+			che := &store.EntrySpec{
+				Key:   e.mapKey(catalogCheckPrefix, EncodeID(implicitDB.id), EncodeID(table.id), EncodeID(col.id)),
+				Value: []byte(check),
+			}
+			summary.ces = append(summary.ces, che)
+		}
+	}
+
+	for _, cs := range stmt.colsSpec {
+		if !cs.unique {
+			continue
+		}
+
+		col := table.colsByName[cs.colName]
+
+		// primary and auto incremental keys are already unique
+		_, indexed := table.indexes[indexKeyFrom([]*Column{col})]
+		if indexed {
+			continue
+		}
+
+		createUniqueIndexStmt := &CreateIndexStmt{unique: true, table: table.name, cols: []string{cs.colName}}
+		uniqueIndexSummary, err := createUniqueIndexStmt.compileUsing(e, implicitDB, params)
+		if err != nil {
+			return nil, err
+		}
+
+		err = summary.add(uniqueIndexSummary)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	te := &store.EntrySpec{
@@ -323,8 +621,13 @@ type ColSpec struct {
 	colName       string
 	colType       SQLValueType
 	maxLen        int
+	collation     Collation
 	autoIncrement bool
 	notNull       bool
+	defaultNow    bool
+	updateNow     bool
+	unique        bool
+	check         ValueExp
 }
 
 type CreateIndexStmt struct {
@@ -359,6 +662,7 @@ func (stmt *CreateIndexStmt) compileUsing(e *Engine, implicitDB *Database, param
 	}
 
 	colIDs := make([]uint32, len(stmt.cols))
+	cols := make([]*Column, len(stmt.cols))
 
 	for i, colName := range stmt.cols {
 		col, err := table.GetColumnByName(colName)
@@ -367,10 +671,18 @@ func (stmt *CreateIndexStmt) compileUsing(e *Engine, implicitDB *Database, param
 		}
 
 		if variableSized(col.colType) && (col.MaxLen() == 0 || col.MaxLen() > maxKeyLen) {
-			return nil, ErrLimitedKeyType
+			return nil, fmt.Errorf("%w (%s)", ErrLimitedKeyType, colName)
 		}
 
 		colIDs[i] = col.id
+		cols[i] = col
+	}
+
+	// oversized keys are rejected when the index is created rather than when the first row is inserted
+	keyLen := e.maxIndexKeyLen(table, stmt.unique, cols)
+	if keyLen > e.dataStore.MaxKeyLen() {
+		return nil, fmt.Errorf("%w: index entries may take up to %d bytes but keys are limited to %d bytes",
+			ErrMaxKeyLengthExceeded, keyLen, e.dataStore.MaxKeyLen())
 	}
 
 	index, err := table.newIndex(stmt.unique, colIDs)
@@ -381,24 +693,6 @@ func (stmt *CreateIndexStmt) compileUsing(e *Engine, implicitDB *Database, param
 		return nil, err
 	}
 
-	// check table is empty
-	{
-		lastTxID, _ := e.dataStore.Alh()
-		err = e.dataStore.WaitForIndexingUpto(lastTxID, nil)
-		if err != nil {
-			return nil, err
-		}
-
-		pkPrefix := e.mapKey(PIndexPrefix, EncodeID(table.db.id), EncodeID(table.id), EncodeID(PKIndexID))
-		existKey, err := e.dataStore.ExistKeyWith(pkPrefix, pkPrefix, false)
-		if err != nil {
-			return nil, err
-		}
-		if existKey {
-			return nil, ErrLimitedIndexCreation
-		}
-	}
-
 	// v={unique {colID1}(ASC|DESC)...{colIDN}(ASC|DESC)}
 	// TODO: currently only ASC order is supported
 	colSpecLen := EncIDLen + 1
@@ -419,9 +713,94 @@ func (stmt *CreateIndexStmt) compileUsing(e *Engine, implicitDB *Database, param
 	}
 	summary.ces = append(summary.ces, te)
 
+	err = e.backfillIndex(index, summary)
+	if err != nil {
+		return nil, err
+	}
+
 	return summary, nil
 }
 
+// backfillIndex emits the entries of a newly created index for every row already stored in the table.
+// Only committed rows are read, and the entries are committed in a single transaction, thus tables
+// holding more rows than the entries allowed per transaction can not be indexed (ErrTooManyRows)
+func (e *Engine) backfillIndex(index *Index, summary *TxSummary) error {
+	// rows committed but not yet indexed would be missed otherwise
+	lastTxID, _ := e.dataStore.Alh()
+	err := e.dataStore.WaitForIndexingUpto(lastTxID, nil)
+	if err != nil {
+		return err
+	}
+
+	snap, err := e.dataStore.SnapshotSince(lastTxID)
+	if err != nil {
+		return err
+	}
+	defer snap.Close()
+
+	table := index.table
+
+	r, err := e.newRawRowReader(context.Background(), snap, table, 0, table.name, &ScanSpecs{index: table.primaryIndex})
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	uniqueKeys := make(map[string]struct{})
+
+	for {
+		row, err := r.Read()
+		if err == ErrNoMoreRows {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		valuesByColID := make(map[uint32]TypedValue, len(row.Values))
+
+		for _, col := range table.cols {
+			encSel := EncodeSelector("", table.db.name, table.name, col.colName)
+
+			val := row.Values[encSel]
+
+			_, isNull := val.(*NullValue)
+			if isNull {
+				continue
+			}
+
+			valuesByColID[col.id] = val
+		}
+
+		pkEncVals, err := encodedPK(table, valuesByColID)
+		if err != nil {
+			return err
+		}
+
+		ie, err := e.indexEntryFor(index, pkEncVals, valuesByColID)
+		if err != nil {
+			return err
+		}
+
+		if index.IsUnique() {
+			_, duplicated := uniqueKeys[string(ie.Key)]
+			if duplicated {
+				return uniqueConstraintViolation(index)
+			}
+
+			uniqueKeys[string(ie.Key)] = struct{}{}
+		}
+
+		if len(summary.ies) >= e.dataStore.MaxTxEntries() {
+			return ErrTooManyRows
+		}
+
+		summary.ies = append(summary.ies, ie)
+	}
+
+	return nil
+}
+
 type AddColumnStmt struct {
 	table   string
 	colSpec *ColSpec
@@ -436,12 +815,25 @@ func (stmt *AddColumnStmt) compileUsing(e *Engine, implicitDB *Database, params
 }
 
 type UpsertIntoStmt struct {
-	isInsert bool
-	tableRef *tableRef
-	cols     []string
-	rows     []*RowSpec
+	isInsert   bool
+	tableRef   *tableRef
+	cols       []string
+	rows       []*RowSpec
+	ds         DataSource // rows are read from the data source when values are not specified
+	onConflict *OnConflictDo
+}
+
+// OnConflictDo specifies how rows colliding with existing ones are handled. Without updates,
+// rows colliding on the primary key or a unique index are skipped. Otherwise, the updates
+// are applied to the row with the same primary key, the proposed values can be referenced
+// through the excluded table e.g. "title = excluded.title"
+type OnConflictDo struct {
+	updates []*colUpdate
 }
 
+// excludedTable is the name under which the proposed values are referenced in on conflict updates
+const excludedTable = "excluded"
+
 type RowSpec struct {
 	Values []ValueExp
 }
@@ -470,6 +862,41 @@ func (stmt *UpsertIntoStmt) inferParameters(e *Engine, implicitDB *Database, par
 		}
 	}
 
+	if stmt.ds != nil {
+		err := stmt.ds.inferParameters(e, implicitDB, params)
+		if err != nil {
+			return err
+		}
+	}
+
+	if stmt.onConflict != nil && len(stmt.onConflict.updates) > 0 {
+		table, err := stmt.tableRef.referencedTable(e, implicitDB)
+		if err != nil {
+			return err
+		}
+
+		cols := make(map[string]ColDescriptor, 2*len(table.cols))
+
+		for _, col := range table.cols {
+			for _, t := range []string{table.name, excludedTable} {
+				colDescriptor := ColDescriptor{Database: table.db.name, Table: t, Column: col.colName, Type: col.colType}
+				cols[colDescriptor.Selector()] = colDescriptor
+			}
+		}
+
+		for _, update := range stmt.onConflict.updates {
+			col, err := table.GetColumnByName(update.col)
+			if err != nil {
+				return err
+			}
+
+			err = update.val.requiresType(col.colType, cols, params, table.db.name, table.name)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -493,87 +920,108 @@ func (stmt *UpsertIntoStmt) validate(table *Table) (map[uint32]int, error) {
 	return selPosByColID, nil
 }
 
-func (stmt *UpsertIntoStmt) compileUsing(e *Engine, implicitDB *Database, params map[string]interface{}) (summary *TxSummary, err error) {
-	if implicitDB == nil {
-		return nil, ErrNoDatabaseSelected
-	}
-
-	summary = newTxSummary(implicitDB)
+// specifiedCols returns the columns of the table with a value in the statement, following the order of the table
+func (stmt *UpsertIntoStmt) specifiedCols(table *Table, selPosByColID map[uint32]int) ([]*Column, error) {
+	cols := make([]*Column, 0, len(stmt.cols))
 
-	table, err := stmt.tableRef.referencedTable(e, implicitDB)
-	if err != nil {
-		return nil, err
-	}
+	for _, col := range table.cols {
+		_, specified := selPosByColID[col.id]
+		if !specified {
+			if col.defaultNow {
+				// the value is assigned when the row is inserted
+				continue
+			}
 
-	if len(stmt.rows)*len(table.indexes) > e.dataStore.MaxTxEntries() {
-		return nil, ErrTooManyRows
+			err := validateNotNull(col, &NullValue{t: col.colType})
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if stmt.isInsert && col.autoIncrement {
+			if !stmt.defaultAt(selPosByColID[col.id]) {
+				return nil, ErrNoValueForAutoIncrementalColumn
+			}
+
+			// the value is assigned to every row as when the column is not specified
+			continue
+		}
+
+		cols = append(cols, col)
 	}
 
-	selPosByColID, err := stmt.validate(table)
-	if err != nil {
-		return nil, err
+	return cols, nil
+}
+
+// defaultAt returns true when every row takes the default value at the given position
+func (stmt *UpsertIntoStmt) defaultAt(pos int) bool {
+	if len(stmt.rows) == 0 {
+		return false
 	}
 
 	for _, row := range stmt.rows {
-		if len(row.Values) != len(stmt.cols) {
-			return nil, ErrInvalidNumberOfValues
+		if pos >= len(row.Values) {
+			return false
 		}
 
-		valuesByColID := make(map[uint32]TypedValue)
-
-		for colID, col := range table.colsByID {
-			colPos, specified := selPosByColID[colID]
-			if !specified {
-				if col.notNull {
-					return nil, ErrNotNullableColumnCannotBeNull
-				}
-				continue
-			}
+		_, isDefault := row.Values[pos].(*DefaultValue)
+		if !isDefault {
+			return false
+		}
+	}
 
-			if stmt.isInsert && col.autoIncrement {
-				return nil, ErrNoValueForAutoIncrementalColumn
-			}
+	return true
+}
 
-			cVal := row.Values[colPos]
+func (stmt *UpsertIntoStmt) compileUsing(e *Engine, implicitDB *Database, params map[string]interface{}) (summary *TxSummary, err error) {
+	if implicitDB == nil {
+		return nil, ErrNoDatabaseSelected
+	}
 
-			val, err := cVal.substitute(params)
-			if err != nil {
-				return nil, err
-			}
+	summary = newTxSummary(implicitDB)
 
-			rval, err := val.reduce(e.catalog, nil, implicitDB.name, table.name)
-			if err != nil {
-				return nil, err
-			}
+	table, err := stmt.tableRef.referencedTable(e, implicitDB)
+	if err != nil {
+		return nil, err
+	}
 
-			_, isNull := rval.(*NullValue)
-			if isNull {
-				if col.notNull {
-					return nil, ErrNotNullableColumnCannotBeNull
-				}
+	if len(stmt.rows)*len(table.indexes) > e.dataStore.MaxTxEntries() {
+		return nil, ErrTooManyRows
+	}
 
-				continue
-			}
+	selPosByColID, err := stmt.validate(table)
+	if err != nil {
+		return nil, err
+	}
 
-			valuesByColID[colID] = rval
+	if stmt.onConflict != nil {
+		err = validateUpdates(table, stmt.onConflict.updates)
+		if err != nil {
+			return nil, err
 		}
+	}
 
-		// inject auto-incremental pk value
-		if stmt.isInsert && table.autoIncrementPK {
-			table.maxPK++
-			e.catalog.mutated = true // TODO: implement transactional in-memory catalog
+	if stmt.ds != nil {
+		return stmt.upsertFrom(e, implicitDB, table, selPosByColID, params, summary)
+	}
 
-			valuesByColID[table.autoIncrementCol.id] = &Number{val: table.maxPK}
+	var specifiedCols []*Column
 
-			summary.lastInsertedPKs[table.name] = table.maxPK
+	for i, row := range stmt.rows {
+		if len(row.Values) != len(stmt.cols) {
+			return nil, ErrInvalidNumberOfValues
 		}
 
-		pkEncVals, err := encodedPK(table, valuesByColID)
-		if err != nil {
-			return nil, err
+		// columns are resolved once as they are the same for every row
+		if i == 0 {
+			specifiedCols, err = stmt.specifiedCols(table, selPosByColID)
+			if err != nil {
+				return nil, err
+			}
 		}
 
-		err = e.doUpsert(pkEncVals, valuesByColID, table, stmt.isInsert, summary)
+		err = stmt.upsertRow(e, table, specifiedCols, selPosByColID, row.Values, params, summary)
 		if err != nil {
 			return nil, err
 		}
@@ -582,548 +1030,900 @@ func (stmt *UpsertIntoStmt) compileUsing(e *Engine, implicitDB *Database, params
 	return summary, nil
 }
 
-func (e *Engine) doUpsert(pkEncVals []byte, valuesByColID map[uint32]TypedValue, table *Table, isInsert bool, summary *TxSummary) error {
-	var reusableIndexEntries map[uint32]struct{}
+// upsertFrom streams the rows read from the data source into the table
+func (stmt *UpsertIntoStmt) upsertFrom(e *Engine, implicitDB *Database, table *Table, selPosByColID map[uint32]int, params map[string]interface{}, summary *TxSummary) (*TxSummary, error) {
+	err := e.renewSnapshot(nil)
+	if err != nil {
+		return nil, err
+	}
 
-	if !isInsert && len(table.indexes) > 1 {
-		currPKRow, err := e.fetchPKRow(table, valuesByColID)
-		if err != nil && err != ErrNoMoreRows {
-			return err
-		}
+	rowReader, err := stmt.ds.Resolve(context.Background(), e, e.snapshot, implicitDB, params, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer rowReader.Close()
 
-		if err == nil {
-			currValuesByColID := make(map[uint32]TypedValue, len(currPKRow.Values))
+	cols, err := rowReader.Columns()
+	if err != nil {
+		return nil, err
+	}
 
-			for _, col := range table.cols {
-				encSel := EncodeSelector("", table.db.name, table.name, col.colName)
-				currValuesByColID[col.id] = currPKRow.Values[encSel]
-			}
+	if len(cols) != len(stmt.cols) {
+		return nil, ErrInvalidNumberOfValues
+	}
 
-			reusableIndexEntries, err = e.deprecateIndexEntries(pkEncVals, currValuesByColID, valuesByColID, table, summary)
-			if err != nil {
-				return err
-			}
+	specifiedCols, err := stmt.specifiedCols(table, selPosByColID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, col := range specifiedCols {
+		t := cols[selPosByColID[col.id]].Type
+
+		if t != col.colType && t != AnyType {
+			return nil, fmt.Errorf("%w: column '%s' is %s but %s is selected", ErrInvalidTypes, col.colName, col.colType, t)
 		}
 	}
 
-	// create primary index entry
-	mkey := e.mapKey(PIndexPrefix, EncodeID(table.db.id), EncodeID(table.id), EncodeID(table.primaryIndex.id), pkEncVals)
+	values := make([]ValueExp, len(cols))
 
-	var constraint store.KVConstraint
+	for {
+		if summary.updatedRows*len(table.indexes) > e.dataStore.MaxTxEntries() {
+			return nil, ErrTooManyRows
+		}
 
-	if isInsert && !table.autoIncrementPK {
-		constraint = store.MustNotExistOrDeleted
-	}
+		row, err := rowReader.Read()
+		if err == ErrNoMoreRows {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
 
-	if !isInsert && table.autoIncrementPK {
-		constraint = store.MustExist
+		for i, col := range cols {
+			values[i] = row.Values[col.Selector()]
+		}
+
+		err = stmt.upsertRow(e, table, specifiedCols, selPosByColID, values, params, summary)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	valbuf := bytes.Buffer{}
+	return summary, nil
+}
 
-	b := make([]byte, EncLenLen)
-	binary.BigEndian.PutUint32(b, uint32(len(valuesByColID)))
+func (stmt *UpsertIntoStmt) upsertRow(e *Engine, table *Table, specifiedCols []*Column, selPosByColID map[uint32]int, values []ValueExp, params map[string]interface{}, summary *TxSummary) error {
+	valuesByColID := make(map[uint32]TypedValue, len(specifiedCols))
 
-	_, err := valbuf.Write(b)
-	if err != nil {
-		return err
-	}
+	for _, col := range specifiedCols {
+		cVal := values[selPosByColID[col.id]]
 
-	for _, col := range table.cols {
-		rval, notNull := valuesByColID[col.id]
-		if !notNull {
-			continue
+		_, isDefault := cVal.(*DefaultValue)
+		if isDefault && col.defaultNow {
+			cVal = currentTimestamp(params)
+		} else if isDefault {
+			// only timestamps can default to the current one, other columns default to NULL
+			cVal = &NullValue{t: col.colType}
 		}
 
-		b := make([]byte, EncIDLen)
-		binary.BigEndian.PutUint32(b, uint32(col.id))
+		val, err := cVal.substitute(params)
+		if err != nil {
+			return err
+		}
 
-		_, err = valbuf.Write(b)
+		rval, err := val.reduce(e.catalog, nil, table.db.name, table.name)
 		if err != nil {
 			return err
 		}
 
-		encVal, err := EncodeValue(rval.Value(), col.colType, col.MaxLen())
+		err = validateNotNull(col, rval)
 		if err != nil {
 			return err
 		}
 
-		_, err = valbuf.Write(encVal)
+		_, isNull := rval.(*NullValue)
+		if isNull {
+			continue
+		}
+
+		rval = convertToColType(col, rval)
+
+		// integers and timestamps share their encoding, thus types are checked before encoding the row
+		if rval.Type() != col.colType {
+			return ErrInvalidValue
+		}
+
+		err = validateMaxLen(col, rval)
 		if err != nil {
 			return err
 		}
+
+		valuesByColID[col.id] = rval
 	}
 
-	pke := &store.EntrySpec{
-		Key:        mkey,
-		Value:      valbuf.Bytes(),
-		Constraint: constraint,
+	for _, col := range table.cols {
+		_, specified := selPosByColID[col.id]
+		if col.defaultNow && !specified {
+			valuesByColID[col.id] = currentTimestamp(params)
+		}
 	}
-	summary.des = append(summary.des, pke)
 
-	// create entries for secondary indexes
-	for _, index := range table.indexes {
-		if index.IsPrimary() {
-			continue
+	if stmt.onConflict != nil && len(stmt.onConflict.updates) == 0 {
+		conflict, err := e.conflicts(table, valuesByColID, summary)
+		if err != nil {
+			return err
 		}
 
-		if reusableIndexEntries != nil {
-			_, reusable := reusableIndexEntries[index.id]
-			if reusable {
-				continue
-			}
+		if conflict {
+			return nil
 		}
+	}
 
-		var prefix string
-		var encodedValues [][]byte
-		var val []byte
+	// auto-incremental pk values are assigned to new rows, thus never collide
+	if stmt.onConflict != nil && len(stmt.onConflict.updates) > 0 && !table.autoIncrementPK {
+		updated, err := e.updateOnConflict(table, valuesByColID, stmt.onConflict.updates, params, summary)
+		if err != nil {
+			return err
+		}
 
-		if index.IsUnique() {
-			prefix = UIndexPrefix
-			encodedValues = make([][]byte, 3+len(index.cols))
-			val = pkEncVals
-		} else {
-			prefix = SIndexPrefix
-			encodedValues = make([][]byte, 4+len(index.cols))
-			encodedValues[len(encodedValues)-1] = pkEncVals
+		if updated {
+			return nil
 		}
+	}
 
-		encodedValues[0] = EncodeID(table.db.id)
-		encodedValues[1] = EncodeID(table.id)
-		encodedValues[2] = EncodeID(index.id)
+	// inject auto-incremental pk value
+	if stmt.isInsert && table.autoIncrementPK {
+		table.maxPK++
+		e.catalog.mutated = true
 
-		for i, col := range index.cols {
-			if col.MaxLen() > maxKeyLen {
-				return ErrMaxKeyLengthExceeded
-			}
+		valuesByColID[table.autoIncrementCol.id] = &Number{val: table.maxPK}
 
-			rval, notNull := valuesByColID[col.id]
-			if !notNull {
-				return ErrIndexedColumnCanNotBeNull
-			}
+		summary.lastInsertedPKs[table.name] = table.maxPK
+	}
 
-			encVal, err := EncodeAsKey(rval.Value(), col.colType, col.MaxLen())
-			if err != nil {
-				return err
-			}
+	pkEncVals, err := encodedPK(table, valuesByColID)
+	if err != nil {
+		return err
+	}
 
-			encodedValues[i+3] = encVal
+	return e.doUpsert(pkEncVals, valuesByColID, table, stmt.isInsert, params, summary)
+}
+
+// updateOnConflict applies the updates to the live row with the same primary key, false is returned
+// when there is no such row
+func (e *Engine) updateOnConflict(table *Table, valuesByColID map[uint32]TypedValue, updates []*colUpdate, params map[string]interface{}, summary *TxSummary) (bool, error) {
+	pkEncVals, err := encodedPK(table, valuesByColID)
+	if err != nil {
+		return false, err
+	}
+
+	pkKey := e.mapKey(PIndexPrefix, EncodeID(table.db.id), EncodeID(table.id), EncodeID(table.primaryIndex.id), pkEncVals)
+
+	for _, de := range summary.des {
+		if bytes.Equal(de.Key, pkKey) {
+			return false, ErrRowUpdatedTwice
 		}
+	}
 
-		var constraint store.KVConstraint
+	currRow, err := e.fetchPKRow(table, valuesByColID)
+	if err == ErrNoMoreRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
 
-		if index.IsUnique() {
-			constraint = store.MustNotExistOrDeleted
+	row := &Row{Values: make(map[string]TypedValue, 2*len(table.cols))}
+	newValuesByColID := make(map[uint32]TypedValue, len(table.cols))
+
+	for _, col := range table.cols {
+		currVal := currRow.Values[EncodeSelector("", table.db.name, table.name, col.colName)]
+
+		row.Values[EncodeSelector("", table.db.name, table.name, col.colName)] = currVal
+
+		_, isNull := currVal.(*NullValue)
+		if !isNull {
+			newValuesByColID[col.id] = currVal
 		}
 
-		ie := &store.EntrySpec{
-			Key:        e.mapKey(prefix, encodedValues...),
-			Value:      val,
-			Constraint: constraint,
+		proposedVal, ok := valuesByColID[col.id]
+		if !ok {
+			proposedVal = &NullValue{t: col.colType}
 		}
 
-		summary.des = append(summary.des, ie)
+		row.Values[EncodeSelector("", table.db.name, excludedTable, col.colName)] = proposedVal
 	}
 
-	summary.updatedRows++
+	for _, update := range updates {
+		col, err := table.GetColumnByName(update.col)
+		if err != nil {
+			return false, err
+		}
 
-	return nil
-}
+		sval, err := update.val.substitute(params)
+		if err != nil {
+			return false, err
+		}
 
-func encodedPK(table *Table, valuesByColID map[uint32]TypedValue) ([]byte, error) {
-	valbuf := bytes.Buffer{}
+		rval, err := sval.reduce(e.catalog, row, table.db.name, table.name)
+		if err != nil {
+			return false, err
+		}
 
-	for _, col := range table.primaryIndex.cols {
-		rval, notNull := valuesByColID[col.id]
-		if !notNull {
-			return nil, ErrPKCanNotBeNull
+		rval = convertToColType(col, rval)
+
+		err = rval.requiresType(col.colType, make(map[string]ColDescriptor), nil, table.db.name, table.name)
+		if err != nil {
+			return false, err
 		}
 
-		encVal, err := EncodeAsKey(rval.Value(), col.colType, col.MaxLen())
+		err = validateNotNull(col, rval)
 		if err != nil {
-			return nil, err
+			return false, err
 		}
 
-		if len(encVal) > maxKeyLen {
-			return nil, ErrMaxKeyLengthExceeded
+		_, isNull := rval.(*NullValue)
+		if isNull {
+			if len(table.IndexesByColID(col.id)) > 0 {
+				return false, ErrIndexedColumnCanNotBeNull
+			}
+
+			delete(newValuesByColID, col.id)
+			continue
 		}
 
-		_, err = valbuf.Write(encVal)
+		err = validateMaxLen(col, rval)
 		if err != nil {
-			return nil, err
+			return false, err
 		}
+
+		newValuesByColID[col.id] = rval
 	}
 
-	return valbuf.Bytes(), nil
-}
+	refreshTimestamps(table, updates, newValuesByColID, params)
 
-func (e *Engine) fetchPKRow(table *Table, valuesByColID map[uint32]TypedValue) (*Row, error) {
-	pkRanges := make(map[uint32]*typedValueRange, len(table.primaryIndex.cols))
+	return true, e.doUpsert(pkEncVals, newValuesByColID, table, false, params, summary)
+}
 
-	for _, pkCol := range table.primaryIndex.cols {
-		pkVal := valuesByColID[pkCol.id]
+// currentTimestamp returns the timestamp of the statements being executed, the same one returned by NOW()
+func currentTimestamp(params map[string]interface{}) *Timestamp {
+	now, _ := params[nowParam].(int64)
+	return &Timestamp{val: now}
+}
 
-		pkRanges[pkCol.id] = &typedValueRange{
-			lRange: &typedValueSemiRange{val: pkVal, inclusive: true},
-			hRange: &typedValueSemiRange{val: pkVal, inclusive: true},
+// refreshTimestamps sets the columns declared with ON UPDATE CURRENT_TIMESTAMP to the current timestamp,
+// unless they are explicitly updated
+func refreshTimestamps(table *Table, updates []*colUpdate, valuesByColID map[uint32]TypedValue, params map[string]interface{}) {
+	for _, col := range table.cols {
+		if !col.updateNow {
+			continue
 		}
-	}
 
-	scanSpecs := &ScanSpecs{
-		index:         table.primaryIndex,
-		rangesByColID: pkRanges,
-	}
+		updated := false
 
-	lastTxID, _ := e.dataStore.Alh()
-	err := e.dataStore.WaitForIndexingUpto(lastTxID, nil)
-	if err != nil {
-		return nil, err
+		for _, update := range updates {
+			if update.col == col.colName {
+				updated = true
+				break
+			}
+		}
+
+		if !updated {
+			valuesByColID[col.id] = currentTimestamp(params)
+		}
 	}
+}
 
-	snapshot := e.dataStore.CurrentSnapshot()
-	defer func() {
-		snapshot.Close()
-	}()
+// validateNotNull rejects NULL values for columns declared as NOT NULL, both when inserting and updating rows
+func validateNotNull(col *Column, val TypedValue) error {
+	_, isNull := val.(*NullValue)
+	if isNull && col.notNull {
+		return fmt.Errorf("%w (%s)", ErrNotNullableColumnCannotBeNull, col.colName)
+	}
 
-	r, err := e.newRawRowReader(snapshot, table, 0, table.name, scanSpecs)
-	if err != nil {
-		return nil, err
+	return nil
+}
+
+func validateMaxLen(col *Column, val TypedValue) error {
+	if col.MaxLen() == 0 {
+		return nil
 	}
 
-	defer func() {
-		r.Close()
-	}()
+	var l int
 
-	return r.Read()
-}
+	switch v := val.Value().(type) {
+	case string:
+		if col.colType != VarcharType {
+			return nil
+		}
+		l = len(v)
+	case []byte:
+		if col.colType != BLOBType {
+			return nil
+		}
+		l = len(v)
+	default:
+		return nil
+	}
 
-// deprecateIndexEntries mark previous index entries as deleted
-func (e *Engine) deprecateIndexEntries(
-	pkEncVals []byte,
-	currValuesByColID, newValuesByColID map[uint32]TypedValue,
-	table *Table,
-	summary *TxSummary) (reusableIndexEntries map[uint32]struct{}, err error) {
+	if l > col.MaxLen() {
+		return fmt.Errorf("%w: column '%s' accepts up to %d bytes but got %d", ErrValueTooLong, col.colName, col.MaxLen(), l)
+	}
 
-	reusableIndexEntries = make(map[uint32]struct{})
+	return nil
+}
 
-	for _, index := range table.indexes {
-		if index.IsPrimary() {
+// verifyChecks evaluates the check constraints of the table against the values of the row,
+// the constraint of a column is not evaluated when the column is null
+func (e *Engine) verifyChecks(table *Table, valuesByColID map[uint32]TypedValue, params map[string]interface{}) error {
+	var row *Row
+
+	for _, col := range table.cols {
+		if col.check == nil {
 			continue
 		}
 
-		var prefix string
-		var encodedValues [][]byte
+		val, notNull := valuesByColID[col.id]
+		if !notNull {
+			continue
+		}
 
-		if index.IsUnique() {
-			prefix = UIndexPrefix
-			encodedValues = make([][]byte, 3+len(index.cols))
-		} else {
-			prefix = SIndexPrefix
-			encodedValues = make([][]byte, 4+len(index.cols))
-			encodedValues[len(encodedValues)-1] = pkEncVals
+		_, isNull := val.(*NullValue)
+		if isNull {
+			continue
 		}
 
-		encodedValues[0] = EncodeID(table.db.id)
-		encodedValues[1] = EncodeID(table.id)
-		encodedValues[2] = EncodeID(index.id)
+		if row == nil {
+			row = &Row{Values: make(map[string]TypedValue, len(table.cols))}
 
-		// existent index entry is deleted only if it differs from existent one
-		sameIndexKey := true
+			for _, c := range table.cols {
+				val, ok := valuesByColID[c.id]
+				if !ok {
+					val = &NullValue{t: c.colType}
+				}
 
-		for i, col := range index.cols {
-			currVal, notNull := currValuesByColID[col.id]
-			if !notNull {
-				return nil, ErrCorruptedData
+				row.Values[EncodeSelector("", table.db.name, table.name, c.colName)] = val
 			}
+		}
 
-			newVal, notNull := newValuesByColID[col.id]
-			if notNull {
-				r, err := currVal.Compare(newVal)
-				if err != nil {
-					return nil, err
-				}
+		check, err := col.check.substitute(params)
+		if err != nil {
+			return err
+		}
 
-				sameIndexKey = sameIndexKey && r == 0
-			}
+		rval, err := check.reduce(e.catalog, row, table.db.name, table.name)
+		if err != nil {
+			return err
+		}
+
+		satisfied, ok := rval.Value().(bool)
+		if ok && !satisfied {
+			return fmt.Errorf("%w (%s)", ErrCheckConstraintViolated, col.colName)
+		}
+	}
 
-			encVal, _ := EncodeAsKey(currVal.Value(), col.colType, col.MaxLen())
+	return nil
+}
 
-			encodedValues[i+3] = encVal
+// expString returns the textual representation of an expression used as check constraint,
+// so to be persisted and parsed back when the catalog is loaded
+func expString(exp ValueExp) (string, error) {
+	switch e := exp.(type) {
+	case *NullValue:
+		return "NULL", nil
+	case *Number:
+		return strconv.FormatInt(e.val, 10), nil
+	case *Varchar:
+		if strings.ContainsRune(e.val, '\'') {
+			return "", fmt.Errorf("%w: quotes can not be used in strings", ErrInvalidCheckConstraint)
+		}
+		return "'" + e.val + "'", nil
+	case *Bool:
+		if e.val {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case *Blob:
+		return "x'" + hex.EncodeToString(e.val) + "'", nil
+	case *SysFn:
+		return e.fn + "()", nil
+	case *FnCall:
+		params, err := expStrings(e.params)
+		if err != nil {
+			return "", err
 		}
+		return e.fn + "(" + params + ")", nil
+	case *Cast:
+		val, err := expString(e.val)
+		if err != nil {
+			return "", err
+		}
+		return "CAST(" + val + " AS " + e.t + ")", nil
+	case *ColSelector:
+		sel := quoteIdentifier(e.col)
+		if e.table != "" {
+			sel = quoteIdentifier(e.table) + "." + sel
+		}
+		if e.db != "" {
+			sel = quoteIdentifier(e.db) + "." + sel
+		}
+		return sel, nil
+	case *NegExp:
+		exp, err := expString(e.exp)
+		if err != nil {
+			return "", err
+		}
+		return "-(" + exp + ")", nil
+	case *NotBoolExp:
+		exp, err := expString(e.exp)
+		if err != nil {
+			return "", err
+		}
+		return "NOT (" + exp + ")", nil
+	case *IsNullBoolExp:
+		exp, err := expString(e.exp)
+		if err != nil {
+			return "", err
+		}
+		if e.not {
+			return "(" + exp + " IS NOT NULL)", nil
+		}
+		return "(" + exp + " IS NULL)", nil
+	case *NumExp:
+		return binExpString(e.left, []string{"+", "-", "/", "*", "%"}[e.op], e.right)
+	case *CmpBoolExp:
+		return binExpString(e.left, []string{"=", "!=", "<", "<=", ">", ">="}[e.op], e.right)
+	case *BinBoolExp:
+		return binExpString(e.left, []string{"AND", "OR"}[e.op], e.right)
+	case *LikeBoolExp:
+		op := "LIKE"
+		if e.caseInsensitive {
+			op = "ILIKE"
+		}
+		if e.notLike {
+			op = "NOT " + op
+		}
+		return binExpString(e.val, op, e.pattern)
+	case *InListExp:
+		val, err := expString(e.val)
+		if err != nil {
+			return "", err
+		}
+		values, err := expStrings(e.values)
+		if err != nil {
+			return "", err
+		}
+		op := "IN"
+		if e.notIn {
+			op = "NOT IN"
+		}
+		return "(" + val + " " + op + " (" + values + "))", nil
+	case *CaseExp:
+		var b strings.Builder
 
-		// mark existent index entry as deleted
-		if sameIndexKey {
-			reusableIndexEntries[index.id] = struct{}{}
-		} else {
-			ie := &store.EntrySpec{
-				Key:      e.mapKey(prefix, encodedValues...),
-				Metadata: store.NewKVMetadata().AsDeleted(true),
+		b.WriteString("CASE")
+
+		for _, wt := range e.whenThens {
+			when, err := expString(wt.when)
+			if err != nil {
+				return "", err
+			}
+			then, err := expString(wt.then)
+			if err != nil {
+				return "", err
 			}
+			b.WriteString(" WHEN " + when + " THEN " + then)
+		}
 
-			summary.des = append(summary.des, ie)
+		if e.elseExp != nil {
+			elseExp, err := expString(e.elseExp)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(" ELSE " + elseExp)
 		}
-	}
 
-	return reusableIndexEntries, nil
-}
+		b.WriteString(" END")
 
-type UpdateStmt struct {
-	tableRef *tableRef
-	where    ValueExp
-	updates  []*colUpdate
-	indexOn  []string
-	limit    int
-}
+		return b.String(), nil
+	}
 
-type colUpdate struct {
-	col string
-	op  CmpOperator
-	val ValueExp
+	return "", fmt.Errorf("%w: only values and columns of the row can be used", ErrInvalidCheckConstraint)
 }
 
-func (stmt *UpdateStmt) inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error {
-	selectStmt := &SelectStmt{
-		ds:    stmt.tableRef,
-		where: stmt.where,
+func expStrings(exps []ValueExp) (string, error) {
+	strs := make([]string, len(exps))
+
+	for i, exp := range exps {
+		str, err := expString(exp)
+		if err != nil {
+			return "", err
+		}
+		strs[i] = str
 	}
 
-	err := selectStmt.inferParameters(e, implicitDB, params)
+	return strings.Join(strs, ", "), nil
+}
+
+func binExpString(left ValueExp, op string, right ValueExp) (string, error) {
+	l, err := expString(left)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	table, err := stmt.tableRef.referencedTable(e, implicitDB)
+	r, err := expString(right)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	for _, update := range stmt.updates {
-		col, err := table.GetColumnByName(update.col)
+	return "(" + l + " " + op + " " + r + ")", nil
+}
+
+// conflicts returns true when the row collides with a live one on the primary key or a unique index,
+// including the rows already added to the summary
+func (e *Engine) conflicts(table *Table, valuesByColID map[uint32]TypedValue, summary *TxSummary) (bool, error) {
+	// auto-incremental pk values are assigned after conflicts are checked, thus never collide
+	if !table.autoIncrementPK {
+		pkEncVals, err := encodedPK(table, valuesByColID)
 		if err != nil {
-			return err
+			return false, err
 		}
 
-		err = update.val.requiresType(col.colType, make(map[string]ColDescriptor), params, implicitDB.name, table.name)
-		if err != nil {
-			return err
+		exists, err := e.existKey(e.mapKey(PIndexPrefix, EncodeID(table.db.id), EncodeID(table.id), EncodeID(table.primaryIndex.id), pkEncVals), summary)
+		if err != nil || exists {
+			return exists, err
 		}
 	}
 
-	return nil
-}
-
-func (stmt *UpdateStmt) validate(table *Table) error {
-	colIDs := make(map[uint32]struct{}, len(stmt.updates))
-
-	for _, update := range stmt.updates {
-		if update.op != EQ {
-			return ErrIllegalArguments
+	for _, index := range table.indexes {
+		if index.IsPrimary() || !index.IsUnique() {
+			continue
 		}
 
-		col, err := table.GetColumnByName(update.col)
-		if err != nil {
-			return err
+		// unique index entries do not include pk values
+		ie, err := e.indexEntryFor(index, nil, valuesByColID)
+		if err == ErrIndexedColumnCanNotBeNull {
+			// either an auto-incremental column or a null value which is rejected when upserting the row
+			continue
 		}
-
-		if table.PrimaryIndex().IncludesCol(col.id) {
-			return ErrPKCanNotBeUpdated
+		if err != nil {
+			return false, err
 		}
 
-		_, duplicated := colIDs[col.id]
-		if duplicated {
-			return ErrDuplicatedColumn
+		exists, err := e.existKey(ie.Key, summary)
+		if err != nil || exists {
+			return exists, err
 		}
-
-		colIDs[col.id] = struct{}{}
 	}
 
-	return nil
+	return false, nil
 }
 
-func (stmt *UpdateStmt) compileUsing(e *Engine, implicitDB *Database, params map[string]interface{}) (summary *TxSummary, err error) {
-	if implicitDB == nil {
-		return nil, ErrNoDatabaseSelected
+// existKey returns true when the key is set and not deleted, either in the summary or in the data store
+func (e *Engine) existKey(key []byte, summary *TxSummary) (bool, error) {
+	for i := len(summary.des) - 1; i >= 0; i-- {
+		if bytes.Equal(summary.des[i].Key, key) {
+			md := summary.des[i].Metadata
+			return md == nil || !md.Deleted(), nil
+		}
 	}
 
-	err = e.renewSnapshot()
+	lastTxID, _ := e.dataStore.Alh()
+	err := e.dataStore.WaitForIndexingUpto(lastTxID, nil)
 	if err != nil {
-		return nil, err
+		return false, err
 	}
 
-	selectStmt := &SelectStmt{
-		ds:      stmt.tableRef,
-		where:   stmt.where,
-		indexOn: stmt.indexOn,
-		limit:   stmt.limit,
-	}
+	snapshot := e.dataStore.CurrentSnapshot()
+	defer snapshot.Close()
 
-	rowReader, err := selectStmt.Resolve(e, e.snapshot, implicitDB, params, nil)
-	if err != nil {
-		return nil, err
+	_, err = snapshot.Get(key, store.IgnoreDeleted)
+	if err == store.ErrKeyNotFound {
+		return false, nil
 	}
-	defer rowReader.Close()
-
-	table := rowReader.ScanSpecs().index.table
-
-	err = stmt.validate(table)
 	if err != nil {
-		return nil, err
+		return false, err
 	}
 
-	cols, err := rowReader.colsBySelector()
+	return true, nil
+}
+
+func (e *Engine) doUpsert(pkEncVals []byte, valuesByColID map[uint32]TypedValue, table *Table, isInsert bool, params map[string]interface{}, summary *TxSummary) error {
+	err := e.verifyChecks(table, valuesByColID, params)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	summary = newTxSummary(implicitDB)
-
-	for {
-		if summary.updatedRows*len(table.indexes) > e.dataStore.MaxTxEntries() {
-			return nil, ErrTooManyRows
-		}
-
-		row, err := rowReader.Read()
-		if err == ErrNoMoreRows {
-			break
-		}
-
-		valuesByColID := make(map[uint32]TypedValue, len(row.Values))
+	var reusableIndexEntries map[uint32]struct{}
 
-		for _, col := range table.cols {
-			encSel := EncodeSelector("", table.db.name, table.name, col.colName)
-			valuesByColID[col.id] = row.Values[encSel]
+	if !isInsert && len(table.indexes) > 1 {
+		currPKRow, err := e.fetchPKRow(table, valuesByColID)
+		if err != nil && err != ErrNoMoreRows {
+			return err
 		}
 
-		for _, update := range stmt.updates {
-			col, err := table.GetColumnByName(update.col)
-			if err != nil {
-				return nil, err
-			}
+		if err == nil {
+			currValuesByColID := make(map[uint32]TypedValue, len(currPKRow.Values))
 
-			sval, err := update.val.substitute(params)
-			if err != nil {
-				return nil, err
+			for _, col := range table.cols {
+				encSel := EncodeSelector("", table.db.name, table.name, col.colName)
+				currValuesByColID[col.id] = currPKRow.Values[encSel]
 			}
 
-			rval, err := sval.reduce(e.catalog, row, table.db.name, table.name)
+			reusableIndexEntries, err = e.deprecateIndexEntries(pkEncVals, currValuesByColID, valuesByColID, table, summary)
 			if err != nil {
-				return nil, err
+				return err
 			}
+		}
+	}
 
-			err = rval.requiresType(col.colType, cols, nil, table.db.name, table.name)
-			if err != nil {
-				return nil, err
-			}
+	// create primary index entry
+	mkey := e.mapKey(PIndexPrefix, EncodeID(table.db.id), EncodeID(table.id), EncodeID(table.primaryIndex.id), pkEncVals)
 
-			valuesByColID[col.id] = rval
+	var constraint store.KVConstraint
+
+	if isInsert && !table.autoIncrementPK {
+		constraint = uniqueConstraintFor(table.primaryIndex)
+	}
+
+	if !isInsert && table.autoIncrementPK {
+		constraint = store.MustExist
+	}
+
+	val, err := summary.encodeRow(table, valuesByColID)
+	if err != nil {
+		return err
+	}
+
+	pke := &store.EntrySpec{
+		Key:        mkey,
+		Value:      val,
+		Constraint: constraint,
+	}
+	summary.des = append(summary.des, pke)
+
+	// create entries for secondary indexes
+	for _, index := range table.indexes {
+		if index.IsPrimary() {
+			continue
 		}
 
-		pkEncVals, err := encodedPK(table, valuesByColID)
-		if err != nil {
-			return nil, err
+		if reusableIndexEntries != nil {
+			_, reusable := reusableIndexEntries[index.id]
+			if reusable {
+				continue
+			}
 		}
 
-		err = e.doUpsert(pkEncVals, valuesByColID, table, false, summary)
+		ie, err := e.indexEntryFor(index, pkEncVals, valuesByColID)
 		if err != nil {
-			return nil, err
+			return err
 		}
+
+		summary.des = append(summary.des, ie)
 	}
 
-	return summary, nil
-}
+	summary.updatedRows++
 
-type DeleteFromStmt struct {
-	tableRef *tableRef
-	where    ValueExp
-	indexOn  []string
-	limit    int
+	return nil
 }
 
-func (stmt *DeleteFromStmt) inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error {
-	selectStmt := &SelectStmt{
-		ds:    stmt.tableRef,
-		where: stmt.where,
+// encodeRow encodes the non-null values of the row, the buffer used for the encoding is kept
+// in the summary so to be reused by the following rows
+func (s *TxSummary) encodeRow(table *Table, valuesByColID map[uint32]TypedValue) ([]byte, error) {
+	buf := appendEncLen(s.valbuf[:0], len(valuesByColID))
+
+	for _, col := range table.cols {
+		rval, notNull := valuesByColID[col.id]
+		if !notNull {
+			continue
+		}
+
+		var id [EncIDLen]byte
+		binary.BigEndian.PutUint32(id[:], uint32(col.id))
+
+		buf = append(buf, id[:]...)
+
+		var err error
+
+		buf, err = appendEncodedValue(buf, rval.Value(), col.colType, col.MaxLen())
+		if err != nil {
+			return nil, err
+		}
 	}
-	return selectStmt.inferParameters(e, implicitDB, params)
+
+	s.valbuf = buf
+
+	val := make([]byte, len(buf))
+	copy(val, buf)
+
+	return val, nil
 }
 
-func (stmt *DeleteFromStmt) compileUsing(e *Engine, implicitDB *Database, params map[string]interface{}) (summary *TxSummary, err error) {
-	if implicitDB == nil {
-		return nil, ErrNoDatabaseSelected
+// maxIndexKeyLen returns the length of the largest key of the entries of an index over the columns of the table
+func (e *Engine) maxIndexKeyLen(table *Table, unique bool, cols []*Column) int {
+	prefix := SIndexPrefix
+	if unique {
+		prefix = UIndexPrefix
 	}
 
-	err = e.renewSnapshot()
-	if err != nil {
-		return nil, err
+	keyLen := len(e.prefix) + len(prefix) + EncIDLen*3
+
+	if !unique {
+		// non-unique index entries include encoded pk values as suffix
+		cols = append(cols[:len(cols):len(cols)], table.primaryIndex.cols...)
 	}
 
-	selectStmt := &SelectStmt{
-		ds:      stmt.tableRef,
-		where:   stmt.where,
-		indexOn: stmt.indexOn,
-		limit:   stmt.limit,
+	for _, col := range cols {
+		keyLen += col.MaxLen()
+
+		if variableSized(col.colType) {
+			keyLen += EncLenLen
+		}
 	}
 
-	rowReader, err := selectStmt.Resolve(e, e.snapshot, implicitDB, params, nil)
-	if err != nil {
-		return nil, err
+	return keyLen
+}
+
+// indexEntryFor builds the entry of a secondary index for the row identified by pkEncVals
+func (e *Engine) indexEntryFor(index *Index, pkEncVals []byte, valuesByColID map[uint32]TypedValue) (*store.EntrySpec, error) {
+	var prefix string
+	var encodedValues [][]byte
+	var val []byte
+
+	if index.IsUnique() {
+		prefix = UIndexPrefix
+		encodedValues = make([][]byte, 3+len(index.cols))
+		val = pkEncVals
+	} else {
+		prefix = SIndexPrefix
+		encodedValues = make([][]byte, 4+len(index.cols))
+		encodedValues[len(encodedValues)-1] = pkEncVals
 	}
-	defer rowReader.Close()
 
-	table := rowReader.ScanSpecs().index.table
+	encodedValues[0] = EncodeID(index.table.db.id)
+	encodedValues[1] = EncodeID(index.table.id)
+	encodedValues[2] = EncodeID(index.id)
 
-	summary = newTxSummary(implicitDB)
+	for i, col := range index.cols {
+		if col.MaxLen() > maxKeyLen {
+			return nil, ErrMaxKeyLengthExceeded
+		}
 
-	for {
-		if summary.updatedRows*len(table.indexes) > e.dataStore.MaxTxEntries() {
-			return nil, ErrTooManyRows
+		rval, notNull := valuesByColID[col.id]
+		if !notNull {
+			return nil, ErrIndexedColumnCanNotBeNull
 		}
 
-		row, err := rowReader.Read()
-		if err == ErrNoMoreRows {
-			break
+		encVal, err := col.encodeAsKey(rval.Value())
+		if err != nil {
+			return nil, err
 		}
 
-		valuesByColID := make(map[uint32]TypedValue, len(row.Values))
+		encodedValues[i+3] = encVal
+	}
 
-		for _, col := range table.cols {
-			encSel := EncodeSelector("", table.db.name, table.name, col.colName)
-			valuesByColID[col.id] = row.Values[encSel]
+	var constraint store.KVConstraint
+
+	if index.IsUnique() {
+		constraint = uniqueConstraintFor(index)
+	}
+
+	return &store.EntrySpec{
+		Key:        e.mapKey(prefix, encodedValues...),
+		Value:      val,
+		Constraint: constraint,
+	}, nil
+}
+
+// uniqueConstraintFor checks at commit time that the entry does not collide with a live entry of index
+func uniqueConstraintFor(index *Index) store.KVConstraint {
+	return func(key []byte, valRef *store.ValueRef) error {
+		err := store.MustNotExistOrDeleted(key, valRef)
+		if errors.Is(err, store.ErrKeyAlreadyExists) {
+			return uniqueConstraintViolation(index)
 		}
+		return err
+	}
+}
 
-		pkEncVals, err := encodedPK(table, valuesByColID)
+func uniqueConstraintViolation(index *Index) error {
+	colNames := make([]string, len(index.cols))
+
+	for i, col := range index.cols {
+		colNames[i] = col.colName
+	}
+
+	return fmt.Errorf("%w (%s)", ErrUniqueConstraintViolated, strings.Join(colNames, ", "))
+}
+
+func encodedPK(table *Table, valuesByColID map[uint32]TypedValue) ([]byte, error) {
+	valbuf := bytes.Buffer{}
+
+	for _, col := range table.primaryIndex.cols {
+		rval, notNull := valuesByColID[col.id]
+		if !notNull {
+			return nil, ErrPKCanNotBeNull
+		}
+
+		encVal, err := col.encodeAsKey(rval.Value())
 		if err != nil {
 			return nil, err
 		}
 
-		err = e.deleteIndexEntries(pkEncVals, valuesByColID, table, summary)
+		if len(encVal) > maxKeyLen {
+			return nil, ErrMaxKeyLengthExceeded
+		}
+
+		_, err = valbuf.Write(encVal)
 		if err != nil {
 			return nil, err
 		}
+	}
 
-		summary.updatedRows++
+	return valbuf.Bytes(), nil
+}
+
+func (e *Engine) fetchPKRow(table *Table, valuesByColID map[uint32]TypedValue) (*Row, error) {
+	pkRanges := make(map[uint32]*typedValueRange, len(table.primaryIndex.cols))
+
+	for _, pkCol := range table.primaryIndex.cols {
+		pkVal := valuesByColID[pkCol.id]
+
+		pkRanges[pkCol.id] = &typedValueRange{
+			lRange: &typedValueSemiRange{val: pkVal, inclusive: true},
+			hRange: &typedValueSemiRange{val: pkVal, inclusive: true},
+		}
 	}
 
-	return summary, nil
+	scanSpecs := &ScanSpecs{
+		index:         table.primaryIndex,
+		rangesByColID: pkRanges,
+	}
+
+	lastTxID, _ := e.dataStore.Alh()
+	err := e.dataStore.WaitForIndexingUpto(lastTxID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := e.dataStore.CurrentSnapshot()
+	defer func() {
+		snapshot.Close()
+	}()
+
+	r, err := e.newRawRowReader(context.Background(), snapshot, table, 0, table.name, scanSpecs)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		r.Close()
+	}()
+
+	return r.Read()
 }
 
-func (e *Engine) deleteIndexEntries(
+// deprecateIndexEntries mark previous index entries as deleted
+func (e *Engine) deprecateIndexEntries(
 	pkEncVals []byte,
-	valuesByColID map[uint32]TypedValue,
+	currValuesByColID, newValuesByColID map[uint32]TypedValue,
 	table *Table,
-	summary *TxSummary) error {
+	summary *TxSummary) (reusableIndexEntries map[uint32]struct{}, err error) {
+
+	reusableIndexEntries = make(map[uint32]struct{})
 
 	for _, index := range table.indexes {
+		if index.IsPrimary() {
+			continue
+		}
+
 		var prefix string
 		var encodedValues [][]byte
 
 		if index.IsUnique() {
-			if index.IsPrimary() {
-				prefix = PIndexPrefix
-			} else {
-				prefix = UIndexPrefix
-			}
-
+			prefix = UIndexPrefix
 			encodedValues = make([][]byte, 3+len(index.cols))
 		} else {
 			prefix = SIndexPrefix
@@ -1135,957 +1935,2975 @@ func (e *Engine) deleteIndexEntries(
 		encodedValues[1] = EncodeID(table.id)
 		encodedValues[2] = EncodeID(index.id)
 
-		// some rows might not indexed by every index
-		indexed := true
+		// existent index entry is deleted only if it differs from existent one
+		sameIndexKey := true
 
 		for i, col := range index.cols {
-			val, notNull := valuesByColID[col.id]
+			currVal, notNull := currValuesByColID[col.id]
 			if !notNull {
-				indexed = false
-				break
+				return nil, ErrCorruptedData
+			}
+
+			newVal, notNull := newValuesByColID[col.id]
+			if notNull {
+				r, err := currVal.Compare(newVal)
+				if err != nil {
+					return nil, err
+				}
+
+				sameIndexKey = sameIndexKey && r == 0
 			}
 
-			encVal, _ := EncodeAsKey(val.Value(), col.colType, col.MaxLen())
+			encVal, _ := col.encodeAsKey(currVal.Value())
 
 			encodedValues[i+3] = encVal
 		}
 
-		if !indexed {
-			continue
-		}
+		// mark existent index entry as deleted
+		if sameIndexKey {
+			reusableIndexEntries[index.id] = struct{}{}
+		} else {
+			ie := &store.EntrySpec{
+				Key:      e.mapKey(prefix, encodedValues...),
+				Metadata: store.NewKVMetadata().AsDeleted(true),
+			}
 
-		ie := &store.EntrySpec{
-			Key:      e.mapKey(prefix, encodedValues...),
-			Metadata: store.NewKVMetadata().AsDeleted(true),
+			summary.des = append(summary.des, ie)
 		}
-
-		summary.des = append(summary.des, ie)
 	}
 
-	return nil
-}
-
-type ValueExp interface {
-	inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error)
-	requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error
-	substitute(params map[string]interface{}) (ValueExp, error)
-	reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error)
-	reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp
-	isConstant() bool
-	selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error
+	return reusableIndexEntries, nil
 }
 
-type typedValueRange struct {
-	lRange *typedValueSemiRange
-	hRange *typedValueSemiRange
+type UpdateStmt struct {
+	tableRef *tableRef
+	where    ValueExp
+	updates  []*colUpdate
+	indexOn  []string
+	limit    int
 }
 
-type typedValueSemiRange struct {
-	val       TypedValue
-	inclusive bool
+type colUpdate struct {
+	col string
+	op  CmpOperator
+	val ValueExp
 }
 
-func (r *typedValueRange) unitary() bool {
-	// TODO: this simplified implementation doesn't cover all unitary cases e.g. 3<=v<4
-	if r.lRange == nil || r.hRange == nil {
-		return false
+func (stmt *UpdateStmt) inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error {
+	selectStmt := &SelectStmt{
+		ds:    stmt.tableRef,
+		where: stmt.where,
 	}
 
-	res, _ := r.lRange.val.Compare(r.hRange.val)
-	return res == 0 && r.lRange.inclusive && r.hRange.inclusive
-}
+	err := selectStmt.inferParameters(e, implicitDB, params)
+	if err != nil {
+		return err
+	}
 
-func (r *typedValueRange) refineWith(refiningRange *typedValueRange) error {
-	if r.lRange == nil {
-		r.lRange = refiningRange.lRange
-	} else if r.lRange != nil && refiningRange.lRange != nil {
-		maxRange, err := maxSemiRange(r.lRange, refiningRange.lRange)
+	table, err := stmt.tableRef.referencedTable(e, implicitDB)
+	if err != nil {
+		return err
+	}
+
+	// updated values may refer to the current values of the row e.g. SET active = NOT active
+	cols := make(map[string]ColDescriptor, len(table.cols))
+
+	for _, col := range table.cols {
+		colDescriptor := ColDescriptor{Database: table.db.name, Table: table.name, Column: col.colName, Type: col.colType}
+		cols[colDescriptor.Selector()] = colDescriptor
+	}
+
+	for _, update := range stmt.updates {
+		col, err := table.GetColumnByName(update.col)
 		if err != nil {
 			return err
 		}
-		r.lRange = maxRange
-	}
 
-	if r.hRange == nil {
-		r.hRange = refiningRange.hRange
-	} else if r.hRange != nil && refiningRange.hRange != nil {
-		minRange, err := minSemiRange(r.hRange, refiningRange.hRange)
+		err = update.val.requiresType(col.colType, cols, params, table.db.name, table.name)
 		if err != nil {
 			return err
 		}
-		r.hRange = minRange
 	}
 
 	return nil
 }
 
-func (r *typedValueRange) extendWith(extendingRange *typedValueRange) error {
-	if r.lRange == nil || extendingRange.lRange == nil {
-		r.lRange = nil
-	} else {
-		minRange, err := minSemiRange(r.lRange, extendingRange.lRange)
-		if err != nil {
-			return err
+func (stmt *UpdateStmt) validate(table *Table) error {
+	return validateUpdates(table, stmt.updates)
+}
+
+func validateUpdates(table *Table, updates []*colUpdate) error {
+	colIDs := make(map[uint32]struct{}, len(updates))
+
+	for _, update := range updates {
+		if update.op != EQ {
+			return ErrIllegalArguments
 		}
-		r.lRange = minRange
-	}
 
-	if r.hRange == nil || extendingRange.hRange == nil {
-		r.hRange = nil
-	} else {
-		maxRange, err := maxSemiRange(r.hRange, extendingRange.hRange)
+		col, err := table.GetColumnByName(update.col)
 		if err != nil {
 			return err
 		}
-		r.hRange = maxRange
+
+		if table.PrimaryIndex().IncludesCol(col.id) {
+			return ErrPKCanNotBeUpdated
+		}
+
+		_, duplicated := colIDs[col.id]
+		if duplicated {
+			return ErrDuplicatedColumn
+		}
+
+		colIDs[col.id] = struct{}{}
 	}
 
 	return nil
 }
 
-func maxSemiRange(or1, or2 *typedValueSemiRange) (*typedValueSemiRange, error) {
-	r, err := or1.val.Compare(or2.val)
+func (stmt *UpdateStmt) compileUsing(e *Engine, implicitDB *Database, params map[string]interface{}) (summary *TxSummary, err error) {
+	if implicitDB == nil {
+		return nil, ErrNoDatabaseSelected
+	}
+
+	err = e.renewSnapshot(nil)
 	if err != nil {
 		return nil, err
 	}
 
-	maxVal := or1.val
-	if r < 0 {
-		maxVal = or2.val
+	selectStmt := &SelectStmt{
+		ds:      stmt.tableRef,
+		where:   stmt.where,
+		indexOn: stmt.indexOn,
+		limit:   stmt.limit,
 	}
 
-	return &typedValueSemiRange{
-		val:       maxVal,
-		inclusive: or1.inclusive && or2.inclusive,
-	}, nil
-}
-
-func minSemiRange(or1, or2 *typedValueSemiRange) (*typedValueSemiRange, error) {
-	r, err := or1.val.Compare(or2.val)
+	rowReader, err := selectStmt.Resolve(context.Background(), e, e.snapshot, implicitDB, params, nil)
 	if err != nil {
 		return nil, err
 	}
+	defer rowReader.Close()
 
-	minVal := or1.val
-	if r > 0 {
-		minVal = or2.val
-	}
+	table := rowReader.ScanSpecs().index.table
 
-	return &typedValueSemiRange{
-		val:       minVal,
-		inclusive: or1.inclusive || or2.inclusive,
-	}, nil
-}
+	err = stmt.validate(table)
+	if err != nil {
+		return nil, err
+	}
 
-type TypedValue interface {
-	ValueExp
-	Type() SQLValueType
-	Value() interface{}
-	Compare(val TypedValue) (int, error)
-}
+	cols, err := rowReader.colsBySelector()
+	if err != nil {
+		return nil, err
+	}
 
-type NullValue struct {
-	t SQLValueType
-}
+	summary = newTxSummary(implicitDB)
 
-func (n *NullValue) Type() SQLValueType {
-	return n.t
-}
+	for {
+		if summary.updatedRows*len(table.indexes) > e.dataStore.MaxTxEntries() {
+			return nil, ErrTooManyRows
+		}
 
-func (n *NullValue) Value() interface{} {
-	return nil
-}
+		row, err := rowReader.Read()
+		if err == ErrNoMoreRows {
+			break
+		}
 
-func (n *NullValue) Compare(val TypedValue) (int, error) {
-	if n.t != AnyType && val.Type() != AnyType && n.t != val.Type() {
-		return 0, ErrNotComparableValues
-	}
+		valuesByColID := make(map[uint32]TypedValue, len(row.Values))
 
-	if val.Value() == nil {
-		return 0, nil
-	}
+		for _, col := range table.cols {
+			encSel := EncodeSelector("", table.db.name, table.name, col.colName)
 
-	return -1, nil
-}
+			val := row.Values[encSel]
 
-func (v *NullValue) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
-	return v.t, nil
-}
+			_, isNull := val.(*NullValue)
+			if isNull {
+				continue
+			}
 
-func (v *NullValue) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
-	if v.t == t {
-		return nil
-	}
+			valuesByColID[col.id] = val
+		}
 
-	if v.t != AnyType {
-		return ErrInvalidTypes
-	}
+		for _, update := range stmt.updates {
+			col, err := table.GetColumnByName(update.col)
+			if err != nil {
+				return nil, err
+			}
 
-	v.t = t
+			sval, err := update.val.substitute(params)
+			if err != nil {
+				return nil, err
+			}
 
-	return nil
-}
+			rval, err := sval.reduce(e.catalog, row, table.db.name, table.name)
+			if err != nil {
+				return nil, err
+			}
 
-func (v *NullValue) substitute(params map[string]interface{}) (ValueExp, error) {
-	return v, nil
-}
+			rval = convertToColType(col, rval)
 
-func (v *NullValue) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
-	return v, nil
-}
+			err = rval.requiresType(col.colType, cols, nil, table.db.name, table.name)
+			if err != nil {
+				return nil, err
+			}
 
-func (v *NullValue) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
-	return v
-}
+			err = validateNotNull(col, rval)
+			if err != nil {
+				return nil, err
+			}
 
-func (v *NullValue) isConstant() bool {
-	return true
-}
+			_, isNull := rval.(*NullValue)
+			if isNull {
+				if len(table.IndexesByColID(col.id)) > 0 {
+					return nil, ErrIndexedColumnCanNotBeNull
+				}
 
-func (v *NullValue) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
-	return nil
-}
+				delete(valuesByColID, col.id)
+				continue
+			}
 
-type Number struct {
-	val int64
-}
+			valuesByColID[col.id] = rval
+		}
 
-func (v *Number) Type() SQLValueType {
-	return IntegerType
-}
+		refreshTimestamps(table, stmt.updates, valuesByColID, params)
 
-func (v *Number) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
-	return IntegerType, nil
-}
+		pkEncVals, err := encodedPK(table, valuesByColID)
+		if err != nil {
+			return nil, err
+		}
 
-func (v *Number) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
-	if t != IntegerType {
-		return ErrInvalidTypes
+		err = e.doUpsert(pkEncVals, valuesByColID, table, false, params, summary)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	return nil
+	return summary, nil
 }
 
-func (v *Number) substitute(params map[string]interface{}) (ValueExp, error) {
-	return v, nil
+type DeleteFromStmt struct {
+	tableRef *tableRef
+	where    ValueExp
+	indexOn  []string
+	limit    int
 }
 
-func (v *Number) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
-	return v, nil
+func (stmt *DeleteFromStmt) inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error {
+	selectStmt := &SelectStmt{
+		ds:    stmt.tableRef,
+		where: stmt.where,
+	}
+	return selectStmt.inferParameters(e, implicitDB, params)
 }
 
-func (v *Number) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
-	return v
-}
+func (stmt *DeleteFromStmt) compileUsing(e *Engine, implicitDB *Database, params map[string]interface{}) (summary *TxSummary, err error) {
+	if implicitDB == nil {
+		return nil, ErrNoDatabaseSelected
+	}
 
-func (v *Number) isConstant() bool {
-	return true
-}
+	err = e.renewSnapshot(nil)
+	if err != nil {
+		return nil, err
+	}
 
-func (v *Number) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
-	return nil
-}
-
-func (v *Number) Value() interface{} {
-	return v.val
-}
-
-func (v *Number) Compare(val TypedValue) (int, error) {
-	_, isNull := val.(*NullValue)
-	if isNull {
-		return 1, nil
+	selectStmt := &SelectStmt{
+		ds:      stmt.tableRef,
+		where:   stmt.where,
+		indexOn: stmt.indexOn,
+		limit:   stmt.limit,
 	}
 
-	if val.Type() != IntegerType {
-		return 0, ErrNotComparableValues
+	rowReader, err := selectStmt.Resolve(context.Background(), e, e.snapshot, implicitDB, params, nil)
+	if err != nil {
+		return nil, err
 	}
+	defer rowReader.Close()
 
-	rval := val.Value().(int64)
+	table := rowReader.ScanSpecs().index.table
 
-	if v.val == rval {
-		return 0, nil
-	}
+	summary = newTxSummary(implicitDB)
 
-	if v.val > rval {
-		return 1, nil
+	for {
+		if summary.updatedRows*len(table.indexes) > e.dataStore.MaxTxEntries() {
+			return nil, ErrTooManyRows
+		}
+
+		row, err := rowReader.Read()
+		if err == ErrNoMoreRows {
+			break
+		}
+
+		valuesByColID := make(map[uint32]TypedValue, len(row.Values))
+
+		for _, col := range table.cols {
+			encSel := EncodeSelector("", table.db.name, table.name, col.colName)
+			valuesByColID[col.id] = row.Values[encSel]
+		}
+
+		pkEncVals, err := encodedPK(table, valuesByColID)
+		if err != nil {
+			return nil, err
+		}
+
+		err = e.deleteIndexEntries(pkEncVals, valuesByColID, table, summary)
+		if err != nil {
+			return nil, err
+		}
+
+		summary.updatedRows++
 	}
 
-	return -1, nil
+	return summary, nil
 }
 
-type Varchar struct {
-	val string
+type TruncateTableStmt struct {
+	table string
 }
 
-func (v *Varchar) Type() SQLValueType {
-	return VarcharType
+func (stmt *TruncateTableStmt) inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error {
+	return nil
 }
 
-func (v *Varchar) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
-	return VarcharType, nil
-}
+func (stmt *TruncateTableStmt) compileUsing(e *Engine, implicitDB *Database, params map[string]interface{}) (summary *TxSummary, err error) {
+	if implicitDB == nil {
+		return nil, ErrNoDatabaseSelected
+	}
 
-func (v *Varchar) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
-	if t != VarcharType {
-		return ErrInvalidTypes
+	table, err := implicitDB.GetTableByName(stmt.table)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
-}
+	// every entry of the primary and secondary indexes gets deleted, the table definition is kept
+	deleteStmt := &DeleteFromStmt{
+		tableRef: &tableRef{table: stmt.table},
+	}
 
-func (v *Varchar) substitute(params map[string]interface{}) (ValueExp, error) {
-	return v, nil
-}
+	summary, err = deleteStmt.compileUsing(e, implicitDB, params)
+	if err != nil {
+		return nil, err
+	}
 
-func (v *Varchar) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
-	return v, nil
-}
+	// auto-incremental values restart from the beginning, the truncation is recorded along with the deleted
+	// entries so only keys written since then are considered when the counter is recovered on catalog reload
+	te := &store.EntrySpec{
+		Key: e.mapKey(truncatedTablePrefix, EncodeID(implicitDB.id), EncodeID(table.id)),
+	}
+	summary.des = append(summary.des, te)
 
-func (v *Varchar) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
-	return v
+	table.maxPK = 0
+	e.catalog.mutated = true
+
+	return summary, nil
 }
 
-func (v *Varchar) isConstant() bool {
-	return true
+// AnalyzeTableStmt scans all the rows of a table collecting statistics of the values of each column
+type AnalyzeTableStmt struct {
+	table string
 }
 
-func (v *Varchar) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+func (stmt *AnalyzeTableStmt) inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error {
 	return nil
 }
 
-func (v *Varchar) Value() interface{} {
-	return v.val
-}
+func (stmt *AnalyzeTableStmt) compileUsing(e *Engine, implicitDB *Database, params map[string]interface{}) (summary *TxSummary, err error) {
+	if implicitDB == nil {
+		return nil, ErrNoDatabaseSelected
+	}
 
-func (v *Varchar) Compare(val TypedValue) (int, error) {
-	_, isNull := val.(*NullValue)
-	if isNull {
-		return 1, nil
+	table, err := implicitDB.GetTableByName(stmt.table)
+	if err != nil {
+		return nil, err
 	}
 
-	if val.Type() != VarcharType {
-		return 0, ErrNotComparableValues
+	err = e.renewSnapshot(nil)
+	if err != nil {
+		return nil, err
 	}
 
-	rval := val.Value().(string)
+	rowReader, err := e.newRawRowReader(context.Background(), e.snapshot, table, 0, table.name, &ScanSpecs{index: table.primaryIndex})
+	if err != nil {
+		return nil, err
+	}
+	defer rowReader.Close()
 
-	return bytes.Compare([]byte(v.val), []byte(rval)), nil
-}
+	collectors := make([]*statsCollector, len(table.cols))
+	for i := range table.cols {
+		collectors[i] = newStatsCollector(e.distinctLimit)
+	}
 
-type Bool struct {
-	val bool
-}
+	for {
+		row, err := rowReader.Read()
+		if err == ErrNoMoreRows {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
 
-func (v *Bool) Type() SQLValueType {
-	return BooleanType
-}
+		for i, col := range table.cols {
+			err = collectors[i].add(col, row.Values[EncodeSelector("", table.db.name, table.name, col.colName)])
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
 
-func (v *Bool) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
-	return BooleanType, nil
-}
+	summary = newTxSummary(implicitDB)
 
-func (v *Bool) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
-	if t != BooleanType {
-		return ErrInvalidTypes
-	}
+	for i, col := range table.cols {
+		stats := collectors[i].stats
 
-	return nil
-}
+		v, err := encodeColStats(col, stats)
+		if err != nil {
+			return nil, err
+		}
 
-func (v *Bool) substitute(params map[string]interface{}) (ValueExp, error) {
-	return v, nil
-}
+		se := &store.EntrySpec{
+			Key:   e.mapKey(catalogStatsPrefix, EncodeID(implicitDB.id), EncodeID(table.id), EncodeID(col.id)),
+			Value: v,
+		}
+		summary.ces = append(summary.ces, se)
 
-func (v *Bool) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
-	return v, nil
-}
+		col.stats = stats
+	}
 
-func (v *Bool) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
-	return v
-}
+	e.catalog.mutated = true
 
-func (v *Bool) isConstant() bool {
-	return true
+	return summary, nil
 }
 
-func (v *Bool) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
-	return nil
+type statsCollector struct {
+	stats *ColStats
+
+	distinctLimit int
+	distinct      map[string]struct{}
 }
 
-func (v *Bool) Value() interface{} {
-	return v.val
+func newStatsCollector(distinctLimit int) *statsCollector {
+	return &statsCollector{
+		stats:         &ColStats{},
+		distinctLimit: distinctLimit,
+		distinct:      make(map[string]struct{}),
+	}
 }
 
-func (v *Bool) Compare(val TypedValue) (int, error) {
+// add accounts for a value of the column, distinct values are tracked up to the distinct limit
+// so the distinct count is a lower bound for large tables
+func (sc *statsCollector) add(col *Column, val TypedValue) error {
+	if val == nil {
+		sc.stats.nullCount++
+		return nil
+	}
+
 	_, isNull := val.(*NullValue)
 	if isNull {
-		return 1, nil
+		sc.stats.nullCount++
+		return nil
 	}
 
-	if val.Type() != BooleanType {
-		return 0, ErrNotComparableValues
-	}
+	if len(sc.distinct) < sc.distinctLimit {
+		encVal, err := EncodeValue(val.Value(), col.colType, 0)
+		if err != nil {
+			return err
+		}
 
-	rval := val.Value().(bool)
+		sc.distinct[string(encVal)] = struct{}{}
+		sc.stats.distinctCount = uint64(len(sc.distinct))
+	}
 
-	if v.val == rval {
-		return 0, nil
+	if sc.stats.min == nil {
+		sc.stats.min = val
+		sc.stats.max = val
+		return nil
 	}
 
-	if v.val {
-		return 1, nil
+	cmp, err := val.Compare(sc.stats.min)
+	if err != nil {
+		return err
+	}
+	if cmp < 0 {
+		sc.stats.min = val
 	}
 
-	return -1, nil
-}
+	cmp, err = val.Compare(sc.stats.max)
+	if err != nil {
+		return err
+	}
+	if cmp > 0 {
+		sc.stats.max = val
+	}
 
-type Blob struct {
-	val []byte
+	return nil
 }
 
-func (v *Blob) Type() SQLValueType {
-	return BLOBType
-}
+func encodeColStats(col *Column, stats *ColStats) ([]byte, error) {
+	var b bytes.Buffer
 
-func (v *Blob) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
-	return BLOBType, nil
-}
+	cnt := make([]byte, 16)
+	binary.BigEndian.PutUint64(cnt, stats.distinctCount)
+	binary.BigEndian.PutUint64(cnt[8:], stats.nullCount)
+	b.Write(cnt)
 
-func (v *Blob) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
-	if t != BLOBType {
-		return ErrInvalidTypes
+	if stats.min == nil {
+		return b.Bytes(), nil
 	}
 
-	return nil
-}
+	for _, val := range []TypedValue{stats.min, stats.max} {
+		encVal, err := EncodeValue(val.Value(), col.colType, 0)
+		if err != nil {
+			return nil, err
+		}
 
-func (v *Blob) substitute(params map[string]interface{}) (ValueExp, error) {
-	return v, nil
-}
+		b.Write(encVal)
+	}
 
-func (v *Blob) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
-	return v, nil
+	return b.Bytes(), nil
 }
 
-func (v *Blob) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
-	return v
-}
+func decodeColStats(col *Column, b []byte) (*ColStats, error) {
+	if len(b) < 16 {
+		return nil, ErrCorruptedData
+	}
 
-func (v *Blob) isConstant() bool {
-	return true
-}
+	stats := &ColStats{
+		distinctCount: binary.BigEndian.Uint64(b),
+		nullCount:     binary.BigEndian.Uint64(b[8:]),
+	}
 
-func (v *Blob) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
-	return nil
-}
+	b = b[16:]
 
-func (v *Blob) Value() interface{} {
-	return v.val
-}
+	if len(b) == 0 {
+		return stats, nil
+	}
 
-func (v *Blob) Compare(val TypedValue) (int, error) {
-	_, isNull := val.(*NullValue)
-	if isNull {
-		return 1, nil
+	min, n, err := DecodeValue(b, col.colType)
+	if err != nil {
+		return nil, err
 	}
 
-	if val.Type() != BLOBType {
-		return 0, ErrNotComparableValues
+	max, m, err := DecodeValue(b[n:], col.colType)
+	if err != nil {
+		return nil, err
 	}
 
-	rval := val.Value().([]byte)
+	if n+m != len(b) {
+		return nil, ErrCorruptedData
+	}
 
-	return bytes.Compare(v.val, rval), nil
+	stats.min = min
+	stats.max = max
+
+	return stats, nil
+}
+
+func (e *Engine) deleteIndexEntries(
+	pkEncVals []byte,
+	valuesByColID map[uint32]TypedValue,
+	table *Table,
+	summary *TxSummary) error {
+
+	for _, index := range table.indexes {
+		var prefix string
+		var encodedValues [][]byte
+
+		if index.IsUnique() {
+			if index.IsPrimary() {
+				prefix = PIndexPrefix
+			} else {
+				prefix = UIndexPrefix
+			}
+
+			encodedValues = make([][]byte, 3+len(index.cols))
+		} else {
+			prefix = SIndexPrefix
+			encodedValues = make([][]byte, 4+len(index.cols))
+			encodedValues[len(encodedValues)-1] = pkEncVals
+		}
+
+		encodedValues[0] = EncodeID(table.db.id)
+		encodedValues[1] = EncodeID(table.id)
+		encodedValues[2] = EncodeID(index.id)
+
+		// some rows might not indexed by every index
+		indexed := true
+
+		for i, col := range index.cols {
+			val, notNull := valuesByColID[col.id]
+			if !notNull {
+				indexed = false
+				break
+			}
+
+			encVal, _ := col.encodeAsKey(val.Value())
+
+			encodedValues[i+3] = encVal
+		}
+
+		if !indexed {
+			continue
+		}
+
+		ie := &store.EntrySpec{
+			Key:      e.mapKey(prefix, encodedValues...),
+			Metadata: store.NewKVMetadata().AsDeleted(true),
+		}
+
+		summary.des = append(summary.des, ie)
+	}
+
+	return nil
+}
+
+type ValueExp interface {
+	inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error)
+	requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error
+	substitute(params map[string]interface{}) (ValueExp, error)
+	reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error)
+	reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp
+	isConstant() bool
+	selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error
+}
+
+type typedValueRange struct {
+	lRange *typedValueSemiRange
+	hRange *typedValueSemiRange
+}
+
+type typedValueSemiRange struct {
+	val       TypedValue
+	inclusive bool
+}
+
+func (r *typedValueRange) unitary() bool {
+	// TODO: this simplified implementation doesn't cover all unitary cases e.g. 3<=v<4
+	if r.lRange == nil || r.hRange == nil {
+		return false
+	}
+
+	res, _ := r.lRange.val.Compare(r.hRange.val)
+	return res == 0 && r.lRange.inclusive && r.hRange.inclusive
+}
+
+func (r *typedValueRange) refineWith(refiningRange *typedValueRange) error {
+	if r.lRange == nil {
+		r.lRange = refiningRange.lRange
+	} else if r.lRange != nil && refiningRange.lRange != nil {
+		maxRange, err := maxSemiRange(r.lRange, refiningRange.lRange, true)
+		if err != nil {
+			return err
+		}
+		r.lRange = maxRange
+	}
+
+	if r.hRange == nil {
+		r.hRange = refiningRange.hRange
+	} else if r.hRange != nil && refiningRange.hRange != nil {
+		minRange, err := minSemiRange(r.hRange, refiningRange.hRange, true)
+		if err != nil {
+			return err
+		}
+		r.hRange = minRange
+	}
+
+	return nil
+}
+
+func (r *typedValueRange) extendWith(extendingRange *typedValueRange) error {
+	if r.lRange == nil || extendingRange.lRange == nil {
+		r.lRange = nil
+	} else {
+		minRange, err := minSemiRange(r.lRange, extendingRange.lRange, false)
+		if err != nil {
+			return err
+		}
+		r.lRange = minRange
+	}
+
+	if r.hRange == nil || extendingRange.hRange == nil {
+		r.hRange = nil
+	} else {
+		maxRange, err := maxSemiRange(r.hRange, extendingRange.hRange, false)
+		if err != nil {
+			return err
+		}
+		r.hRange = maxRange
+	}
+
+	return nil
+}
+
+// maxSemiRange returns the semi range with the greatest value, when both have the same value
+// it's inclusive only if both are when intersecting them, or if any of them is otherwise
+func maxSemiRange(or1, or2 *typedValueSemiRange, intersecting bool) (*typedValueSemiRange, error) {
+	r, err := or1.val.Compare(or2.val)
+	if err != nil {
+		return nil, err
+	}
+
+	return pickSemiRange(or1, or2, r, intersecting), nil
+}
+
+// minSemiRange returns the semi range with the smallest value, when both have the same value
+// it's inclusive only if both are when intersecting them, or if any of them is otherwise
+func minSemiRange(or1, or2 *typedValueSemiRange, intersecting bool) (*typedValueSemiRange, error) {
+	r, err := or1.val.Compare(or2.val)
+	if err != nil {
+		return nil, err
+	}
+
+	return pickSemiRange(or1, or2, -r, intersecting), nil
+}
+
+func pickSemiRange(or1, or2 *typedValueSemiRange, cmp int, intersecting bool) *typedValueSemiRange {
+	if cmp > 0 {
+		return or1
+	}
+
+	if cmp < 0 {
+		return or2
+	}
+
+	inclusive := or1.inclusive && or2.inclusive
+	if !intersecting {
+		inclusive = or1.inclusive || or2.inclusive
+	}
+
+	return &typedValueSemiRange{
+		val:       or1.val,
+		inclusive: inclusive,
+	}
+}
+
+type TypedValue interface {
+	ValueExp
+	Type() SQLValueType
+	Value() interface{}
+	Compare(val TypedValue) (int, error)
+	json.Marshaler
+}
+
+type NullValue struct {
+	t SQLValueType
+}
+
+func (n *NullValue) Type() SQLValueType {
+	return n.t
+}
+
+func (n *NullValue) Value() interface{} {
+	return nil
+}
+
+func (n *NullValue) MarshalJSON() ([]byte, error) {
+	return []byte("null"), nil
+}
+
+func (n *NullValue) Compare(val TypedValue) (int, error) {
+	if n.t != AnyType && val.Type() != AnyType && n.t != val.Type() {
+		return 0, ErrNotComparableValues
+	}
+
+	if val.Value() == nil {
+		return 0, nil
+	}
+
+	return -1, nil
+}
+
+func (v *NullValue) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	return v.t, nil
+}
+
+func (v *NullValue) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	if v.t == t {
+		return nil
+	}
+
+	if v.t != AnyType {
+		return ErrInvalidTypes
+	}
+
+	v.t = t
+
+	return nil
+}
+
+func (v *NullValue) substitute(params map[string]interface{}) (ValueExp, error) {
+	return v, nil
+}
+
+func (v *NullValue) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	return v, nil
+}
+
+func (v *NullValue) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return v
+}
+
+func (v *NullValue) isConstant() bool {
+	return true
+}
+
+func (v *NullValue) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
+
+// DefaultValue stands for the default value of a column in the rows of an insertion e.g. VALUES (1, DEFAULT),
+// which is the next value of auto-incremental columns and NULL for any other column
+type DefaultValue struct{}
+
+func (v *DefaultValue) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	return AnyType, nil
+}
+
+func (v *DefaultValue) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	return nil
+}
+
+func (v *DefaultValue) substitute(params map[string]interface{}) (ValueExp, error) {
+	return v, nil
+}
+
+func (v *DefaultValue) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	return nil, fmt.Errorf("%w: DEFAULT can only be used as a value of the inserted rows", ErrInvalidValue)
+}
+
+func (v *DefaultValue) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return v
+}
+
+func (v *DefaultValue) isConstant() bool {
+	return false
+}
+
+func (v *DefaultValue) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
+
+type Number struct {
+	val int64
+}
+
+func (v *Number) Type() SQLValueType {
+	return IntegerType
+}
+
+func (v *Number) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	return IntegerType, nil
+}
+
+func (v *Number) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	if t != IntegerType {
+		return ErrInvalidTypes
+	}
+
+	return nil
+}
+
+func (v *Number) substitute(params map[string]interface{}) (ValueExp, error) {
+	return v, nil
+}
+
+func (v *Number) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	return v, nil
+}
+
+func (v *Number) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return v
+}
+
+func (v *Number) isConstant() bool {
+	return true
+}
+
+func (v *Number) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
+
+func (v *Number) Value() interface{} {
+	return v.val
+}
+
+func (v *Number) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.val)
+}
+
+func (v *Number) Compare(val TypedValue) (int, error) {
+	_, isNull := val.(*NullValue)
+	if isNull {
+		return 1, nil
+	}
+
+	if val.Type() != IntegerType && !timestampCompatible(IntegerType, val.Type()) {
+		return 0, ErrNotComparableValues
+	}
+
+	rval := val.Value().(int64)
+
+	if v.val == rval {
+		return 0, nil
+	}
+
+	if v.val > rval {
+		return 1, nil
+	}
+
+	return -1, nil
+}
+
+// Timestamp holds a point in time as unix nanos
+type Timestamp struct {
+	val int64
+}
+
+func (v *Timestamp) Type() SQLValueType {
+	return TimestampType
+}
+
+func (v *Timestamp) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	return TimestampType, nil
+}
+
+func (v *Timestamp) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	if t != TimestampType {
+		return ErrInvalidTypes
+	}
+
+	return nil
+}
+
+func (v *Timestamp) substitute(params map[string]interface{}) (ValueExp, error) {
+	return v, nil
+}
+
+func (v *Timestamp) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	return v, nil
+}
+
+func (v *Timestamp) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return v
+}
+
+func (v *Timestamp) isConstant() bool {
+	return true
+}
+
+func (v *Timestamp) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
+
+func (v *Timestamp) Value() interface{} {
+	return v.val
+}
+
+// MarshalJSON encodes the timestamp as an RFC3339 string in UTC, including its fractional seconds
+func (v *Timestamp) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Unix(0, v.val).UTC().Format(time.RFC3339Nano))
+}
+
+func (v *Timestamp) Compare(val TypedValue) (int, error) {
+	_, isNull := val.(*NullValue)
+	if isNull {
+		return 1, nil
+	}
+
+	if val.Type() != TimestampType && !timestampCompatible(TimestampType, val.Type()) {
+		return 0, ErrNotComparableValues
+	}
+
+	rval := val.Value().(int64)
+
+	if v.val == rval {
+		return 0, nil
+	}
+
+	if v.val > rval {
+		return 1, nil
+	}
+
+	return -1, nil
+}
+
+// timestampCompatible returns true when values of the given types, one INTEGER and the other TIMESTAMP,
+// can be compared and assigned to each other, as integers hold unix nanos when used as timestamps
+func timestampCompatible(t1, t2 SQLValueType) bool {
+	return (t1 == IntegerType && t2 == TimestampType) || (t1 == TimestampType && t2 == IntegerType)
+}
+
+// convertToColType converts integers into timestamps and vice versa when assigned to a column of the other type
+// e.g. NOW() or an integer parameter assigned to a TIMESTAMP column
+func convertToColType(col *Column, val TypedValue) TypedValue {
+	if !timestampCompatible(val.Type(), col.colType) {
+		return val
+	}
+
+	if col.colType == TimestampType {
+		return &Timestamp{val: val.Value().(int64)}
+	}
+
+	return &Number{val: val.Value().(int64)}
+}
+
+type Varchar struct {
+	val       string
+	collation Collation
+}
+
+func (v *Varchar) Type() SQLValueType {
+	return VarcharType
+}
+
+func (v *Varchar) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	return VarcharType, nil
+}
+
+func (v *Varchar) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	if t != VarcharType {
+		return ErrInvalidTypes
+	}
+
+	return nil
+}
+
+func (v *Varchar) substitute(params map[string]interface{}) (ValueExp, error) {
+	return v, nil
+}
+
+func (v *Varchar) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	return v, nil
+}
+
+func (v *Varchar) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return v
+}
+
+func (v *Varchar) isConstant() bool {
+	return true
+}
+
+func (v *Varchar) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
+
+func (v *Varchar) Value() interface{} {
+	return v.val
+}
+
+func (v *Varchar) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.val)
+}
+
+func (v *Varchar) Compare(val TypedValue) (int, error) {
+	_, isNull := val.(*NullValue)
+	if isNull {
+		return 1, nil
+	}
+
+	if val.Type() != VarcharType {
+		return 0, ErrNotComparableValues
+	}
+
+	rval := val.Value().(string)
+
+	rv, ok := val.(*Varchar)
+	if v.collation == NoCaseCollation || (ok && rv.collation == NoCaseCollation) {
+		return strings.Compare(foldCase(v.val), foldCase(rval)), nil
+	}
+
+	return bytes.Compare([]byte(v.val), []byte(rval)), nil
+}
+
+// foldCase maps ASCII upper case letters to lower case, the length of the string is preserved
+func foldCase(s string) string {
+	b := []byte(s)
+
+	for i, c := range b {
+		if 'A' <= c && c <= 'Z' {
+			b[i] = c + 'a' - 'A'
+		}
+	}
+
+	return string(b)
+}
+
+type Bool struct {
+	val bool
+}
+
+func (v *Bool) Type() SQLValueType {
+	return BooleanType
+}
+
+func (v *Bool) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	return BooleanType, nil
+}
+
+func (v *Bool) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	if t != BooleanType {
+		return ErrInvalidTypes
+	}
+
+	return nil
+}
+
+func (v *Bool) substitute(params map[string]interface{}) (ValueExp, error) {
+	return v, nil
+}
+
+func (v *Bool) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	return v, nil
+}
+
+func (v *Bool) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return v
+}
+
+func (v *Bool) isConstant() bool {
+	return true
+}
+
+func (v *Bool) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
+
+func (v *Bool) Value() interface{} {
+	return v.val
+}
+
+func (v *Bool) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.val)
+}
+
+func (v *Bool) Compare(val TypedValue) (int, error) {
+	_, isNull := val.(*NullValue)
+	if isNull {
+		return 1, nil
+	}
+
+	if val.Type() != BooleanType {
+		return 0, ErrNotComparableValues
+	}
+
+	rval := val.Value().(bool)
+
+	if v.val == rval {
+		return 0, nil
+	}
+
+	if v.val {
+		return 1, nil
+	}
+
+	return -1, nil
+}
+
+type Blob struct {
+	val []byte
+}
+
+func (v *Blob) Type() SQLValueType {
+	return BLOBType
+}
+
+func (v *Blob) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	return BLOBType, nil
+}
+
+func (v *Blob) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	if t != BLOBType {
+		return ErrInvalidTypes
+	}
+
+	return nil
+}
+
+func (v *Blob) substitute(params map[string]interface{}) (ValueExp, error) {
+	return v, nil
+}
+
+func (v *Blob) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	return v, nil
+}
+
+func (v *Blob) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return v
+}
+
+func (v *Blob) isConstant() bool {
+	return true
+}
+
+func (v *Blob) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
+
+func (v *Blob) Value() interface{} {
+	return v.val
+}
+
+// MarshalJSON encodes the blob as a base64 string
+func (v *Blob) MarshalJSON() ([]byte, error) {
+	return json.Marshal(base64.StdEncoding.EncodeToString(v.val))
+}
+
+func (v *Blob) Compare(val TypedValue) (int, error) {
+	_, isNull := val.(*NullValue)
+	if isNull {
+		return 1, nil
+	}
+
+	if val.Type() != BLOBType {
+		return 0, ErrNotComparableValues
+	}
+
+	rval := val.Value().([]byte)
+
+	return bytes.Compare(v.val, rval), nil
+}
+
+type SysFn struct {
+	fn string
+}
+
+func (v *SysFn) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	if strings.ToUpper(v.fn) == "NOW" {
+		return IntegerType, nil
+	}
+
+	return AnyType, ErrIllegalArguments
+}
+
+func (v *SysFn) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	if strings.ToUpper(v.fn) == "NOW" {
+		if t != IntegerType {
+			return ErrInvalidTypes
+		}
+
+		return nil
+	}
+
+	return ErrIllegalArguments
+}
+
+func (v *SysFn) substitute(params map[string]interface{}) (ValueExp, error) {
+	if strings.ToUpper(v.fn) == "NOW" {
+		now, ok := params[nowParam]
+		if ok {
+			return &Number{val: now.(int64)}, nil
+		}
+	}
+
+	return v, nil
+}
+
+func (v *SysFn) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	if strings.ToUpper(v.fn) == "NOW" {
+		// the current timestamp is taken from the clock of the engine once per transaction
+		// and bound when substituting parameters, thus it must not be read here
+		return nil, fmt.Errorf("%w: NOW() was not bound to the current timestamp", ErrUnexpected)
+	}
+
+	return nil, errors.New("not yet supported")
+}
+
+func (v *SysFn) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return v
+}
+
+func (v *SysFn) isConstant() bool {
+	return false
+}
+
+func (v *SysFn) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
+
+// Cast converts the value of an expression into the given type,
+// only conversions into TIMESTAMP are currently supported
+type Cast struct {
+	val ValueExp
+	t   SQLValueType
+}
+
+func (c *Cast) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	if c.t != TimestampType {
+		return AnyType, fmt.Errorf("%w: cast into %s", ErrNoSupported, c.t)
+	}
+
+	t, err := c.val.inferType(cols, params, implicitDB, implicitTable)
+	if err != nil {
+		return AnyType, err
+	}
+
+	if t != VarcharType && t != IntegerType && t != TimestampType && t != AnyType {
+		return AnyType, ErrInvalidTypes
+	}
+
+	return c.t, nil
+}
+
+func (c *Cast) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	if t != c.t {
+		return ErrInvalidTypes
+	}
+
+	_, err := c.inferType(cols, params, implicitDB, implicitTable)
+
+	return err
+}
+
+func (c *Cast) substitute(params map[string]interface{}) (ValueExp, error) {
+	val, err := c.val.substitute(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cast{val: val, t: c.t}, nil
+}
+
+func (c *Cast) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	if c.t != TimestampType {
+		return nil, fmt.Errorf("%w: cast into %s", ErrNoSupported, c.t)
+	}
+
+	val, err := c.val.reduce(catalog, row, implicitDB, implicitTable)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v := val.(type) {
+	case *NullValue:
+		{
+			return &NullValue{t: TimestampType}, nil
+		}
+	case *Timestamp:
+		{
+			return v, nil
+		}
+	case *Number:
+		{
+			return &Timestamp{val: v.val}, nil
+		}
+	case *Varchar:
+		{
+			ts, err := time.Parse(time.RFC3339Nano, v.val)
+			if err != nil {
+				return nil, fmt.Errorf("%w: '%s'", ErrInvalidTimestamp, v.val)
+			}
+
+			return &Timestamp{val: ts.UnixNano()}, nil
+		}
+	}
+
+	return nil, ErrInvalidTypes
+}
+
+func (c *Cast) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return &Cast{
+		val: c.val.reduceSelectors(row, implicitDB, implicitTable),
+		t:   c.t,
+	}
+}
+
+func (c *Cast) isConstant() bool {
+	return c.val.isConstant()
+}
+
+func (c *Cast) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
+
+// FnCall is a call to a function receiving arguments, currently YEAR, MONTH and DAY
+// extracting the corresponding part of a timestamp in UTC, and LOWER and UPPER over varchars
+type FnCall struct {
+	fn     string
+	params []ValueExp
+}
+
+// signature returns the type of the argument and the type of the value returned by the function
+func (v *FnCall) signature() (argType SQLValueType, retType SQLValueType, err error) {
+	switch strings.ToUpper(v.fn) {
+	case "YEAR", "MONTH", "DAY":
+		{
+			argType, retType = TimestampType, IntegerType
+		}
+	case "LOWER", "UPPER":
+		{
+			argType, retType = VarcharType, VarcharType
+		}
+	default:
+		{
+			return AnyType, AnyType, fmt.Errorf("%w: function %s", ErrNoSupported, v.fn)
+		}
+	}
+
+	if len(v.params) != 1 {
+		return AnyType, AnyType, fmt.Errorf("%w: %s expects a single argument", ErrIllegalArguments, strings.ToUpper(v.fn))
+	}
+
+	return argType, retType, nil
+}
+
+func (v *FnCall) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	argType, retType, err := v.signature()
+	if err != nil {
+		return AnyType, err
+	}
+
+	err = v.params[0].requiresType(argType, cols, params, implicitDB, implicitTable)
+	if err != nil {
+		return AnyType, err
+	}
+
+	return retType, nil
+}
+
+func (v *FnCall) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	it, err := v.inferType(cols, params, implicitDB, implicitTable)
+	if err != nil {
+		return err
+	}
+
+	if t != it {
+		return ErrInvalidTypes
+	}
+
+	return nil
+}
+
+func (v *FnCall) substitute(params map[string]interface{}) (ValueExp, error) {
+	fparams := make([]ValueExp, len(v.params))
+
+	for i, p := range v.params {
+		fp, err := p.substitute(params)
+		if err != nil {
+			return nil, err
+		}
+
+		fparams[i] = fp
+	}
+
+	return &FnCall{fn: v.fn, params: fparams}, nil
+}
+
+func (v *FnCall) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	argType, retType, err := v.signature()
+	if err != nil {
+		return nil, err
+	}
+
+	val, err := v.params[0].reduce(catalog, row, implicitDB, implicitTable)
+	if err != nil {
+		return nil, err
+	}
+
+	_, isNull := val.(*NullValue)
+	if isNull {
+		return &NullValue{t: retType}, nil
+	}
+
+	if val.Type() != argType {
+		return nil, ErrInvalidTypes
+	}
+
+	switch strings.ToUpper(v.fn) {
+	case "LOWER":
+		return &Varchar{val: strings.ToLower(val.Value().(string))}, nil
+	case "UPPER":
+		return &Varchar{val: strings.ToUpper(val.Value().(string))}, nil
+	}
+
+	t := time.Unix(0, val.Value().(int64)).UTC()
+
+	switch strings.ToUpper(v.fn) {
+	case "YEAR":
+		return &Number{val: int64(t.Year())}, nil
+	case "MONTH":
+		return &Number{val: int64(t.Month())}, nil
+	}
+
+	return &Number{val: int64(t.Day())}, nil
+}
+
+func (v *FnCall) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	fparams := make([]ValueExp, len(v.params))
+
+	for i, p := range v.params {
+		fparams[i] = p.reduceSelectors(row, implicitDB, implicitTable)
+	}
+
+	return &FnCall{fn: v.fn, params: fparams}
+}
+
+func (v *FnCall) isConstant() bool {
+	return false
+}
+
+func (v *FnCall) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
+
+type Param struct {
+	id  string
+	pos int
+}
+
+func (v *Param) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	t, ok := params[v.id]
+	if !ok {
+		params[v.id] = AnyType
+		return AnyType, nil
+	}
+
+	return t, nil
+}
+
+func (v *Param) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	currT, ok := params[v.id]
+	if ok && currT != t && currT != AnyType {
+		return ErrInferredMultipleTypes
+	}
+
+	params[v.id] = t
+
+	return nil
+}
+
+func (p *Param) substitute(params map[string]interface{}) (ValueExp, error) {
+	val, ok := params[p.id]
+	if !ok {
+		return nil, ErrMissingParameter
+	}
+
+	return paramValue(val)
+}
+
+// substituteList expands a parameter bound to a slice into its elements,
+// ok is false when the parameter is not bound to a slice
+func (p *Param) substituteList(params map[string]interface{}) (values []ValueExp, ok bool, err error) {
+	val, exists := params[p.id]
+	if !exists {
+		return nil, false, ErrMissingParameter
+	}
+
+	rval := reflect.ValueOf(val)
+
+	_, isBlob := val.([]byte)
+	if isBlob || rval.Kind() != reflect.Slice {
+		return nil, false, nil
+	}
+
+	values = make([]ValueExp, rval.Len())
+	elemType := AnyType
+
+	for i := 0; i < rval.Len(); i++ {
+		v, err := paramValue(rval.Index(i).Interface())
+		if err != nil {
+			return nil, true, err
+		}
+
+		t, _ := v.inferType(nil, nil, "", "")
+
+		if t != AnyType {
+			if elemType != AnyType && elemType != t {
+				return nil, true, fmt.Errorf("%w: parameter '%s' mixes %s and %s elements", ErrInvalidTypes, p.id, elemType, t)
+			}
+
+			elemType = t
+		}
+
+		values[i] = v
+	}
+
+	return values, true, nil
+}
+
+func paramValue(val interface{}) (ValueExp, error) {
+	if val == nil {
+		return &NullValue{t: AnyType}, nil
+	}
+
+	switch v := val.(type) {
+	case bool:
+		{
+			return &Bool{val: v}, nil
+		}
+	case string:
+		{
+			return &Varchar{val: v}, nil
+		}
+	case int:
+		{
+			return &Number{val: int64(v)}, nil
+		}
+	case uint:
+		{
+			return &Number{val: int64(v)}, nil
+		}
+	case uint64:
+		{
+			return &Number{val: int64(v)}, nil
+		}
+	case int64:
+		{
+			return &Number{val: v}, nil
+		}
+	case []byte:
+		{
+			return &Blob{val: v}, nil
+		}
+	case time.Time:
+		{
+			return &Timestamp{val: v.UnixNano()}, nil
+		}
+	}
+
+	return nil, ErrUnsupportedParameter
+}
+
+func (p *Param) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	return nil, ErrUnexpected
+}
+
+func (p *Param) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return p
+}
+
+func (p *Param) isConstant() bool {
+	return true
+}
+
+func (v *Param) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
+
+type Comparison int
+
+const (
+	EqualTo Comparison = iota
+	LowerThan
+	LowerOrEqualTo
+	GreaterThan
+	GreaterOrEqualTo
+)
+
+type DataSource interface {
+	inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error
+	Resolve(ctx context.Context, e *Engine, snap *store.Snapshot, implicitDB *Database, params map[string]interface{}, ScanSpecs *ScanSpecs) (RowReader, error)
+	Alias() string
+}
+
+type SelectStmt struct {
+	distinct  bool
+	selectors []Selector
+	ds        DataSource
+	indexOn   []string
+	joins     []*JoinSpec
+	where     ValueExp
+	groupBy   []*ColSelector
+	having    ValueExp
+	limit     int
+	offset    int
+	orderBy   []*OrdCol
+	as        string
+}
+
+type ScanSpecs struct {
+	index         *Index
+	rangesByColID map[uint32]*typedValueRange
+	descOrder     bool
+	// txTimestamps is set when the timestamp system column has to be resolved for each row
+	txTimestamps bool
+	// indexOnly is set when the rows can be decoded from the entries of the secondary index
+	indexOnly bool
+}
+
+func (stmt *SelectStmt) Limit() int {
+	return stmt.limit
+}
+
+func (stmt *SelectStmt) Offset() int {
+	return stmt.offset
+}
+
+func (stmt *SelectStmt) inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error {
+	if stmt.ds == nil {
+		var db string
+		if implicitDB != nil {
+			db = implicitDB.name
+		}
+
+		for _, sel := range stmt.selectors {
+			_, err := sel.inferType(map[string]ColDescriptor{}, params, db, stmt.as)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	_, err := stmt.compileUsing(e, implicitDB, nil)
+	if err != nil {
+		return err
+	}
+
+	snapshot, err := e.getSnapshot()
+	if err != nil {
+		return err
+	}
+
+	// TODO (jeroiraz) may be optimized so to resolve the query statement just once
+	rowReader, err := stmt.Resolve(context.Background(), e, snapshot, implicitDB, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer rowReader.Close()
+
+	return rowReader.InferParameters(params)
+}
+
+func (stmt *SelectStmt) compileUsing(e *Engine, implicitDB *Database, params map[string]interface{}) (summary *TxSummary, err error) {
+	if stmt.ds == nil {
+		return newTxSummary(implicitDB), nil
+	}
+
+	if implicitDB == nil {
+		return nil, ErrNoDatabaseSelected
+	}
+
+	if stmt.groupBy == nil && stmt.having != nil {
+		return nil, ErrHavingClauseRequiresGroupClause
+	}
+
+	if len(stmt.groupBy) > 1 {
+		return nil, ErrLimitedGroupBy
+	}
+
+	if len(stmt.orderBy) > 1 {
+		return nil, ErrLimitedOrderBy
+	}
+
+	if len(stmt.orderBy) > 0 {
+		tableRef, ok := stmt.ds.(*tableRef)
+		if !ok {
+			return nil, ErrLimitedOrderBy
+		}
+
+		table, err := tableRef.referencedTable(e, implicitDB)
+		if err != nil {
+			return nil, err
+		}
+
+		sortedByIndex, err := stmt.sortedByIndex(table)
+		if err != nil {
+			return nil, err
+		}
+
+		// grouped rows are read in the order they are scanned
+		if !sortedByIndex && stmt.isGrouped() {
+			return nil, ErrLimitedOrderBy
+		}
+	}
+
+	return newTxSummary(implicitDB), nil
+}
+
+// sortsInMemory returns true when the scanned rows have to be sorted to be returned in the requested order
+func (stmt *SelectStmt) sortsInMemory(scanSpecs *ScanSpecs) (bool, error) {
+	if len(stmt.orderBy) == 0 {
+		return false, nil
+	}
+
+	sortedByIndex, err := stmt.sortedByIndex(scanSpecs.index.table)
+
+	return !sortedByIndex, err
+}
+
+// sortedByIndex returns true when the rows are scanned in the requested order,
+// otherwise they have to be sorted in memory
+func (stmt *SelectStmt) sortedByIndex(table *Table) (bool, error) {
+	col, err := table.GetColumnByName(stmt.orderBy[0].sel.col)
+	if err != nil {
+		return false, err
+	}
+
+	_, indexed := table.indexesByColID[col.id]
+
+	return indexed, nil
+}
+
+func (stmt *SelectStmt) Resolve(ctx context.Context, e *Engine, snap *store.Snapshot, implicitDB *Database, params map[string]interface{}, _ *ScanSpecs) (rowReader RowReader, err error) {
+	if stmt.ds == nil {
+		return stmt.resolveValues(e, implicitDB, params)
+	}
+
+	scanSpecs, err := stmt.genScanSpecs(e, snap, implicitDB, params)
+	if err != nil {
+		return nil, err
+	}
+
+	rowReader, err = stmt.ds.Resolve(ctx, e, snap, implicitDB, params, scanSpecs)
+	if err != nil {
+		return nil, err
+	}
+
+	if stmt.joins != nil {
+		jointRowReader, err := e.newJointRowReader(ctx, implicitDB, snap, params, rowReader, stmt.joins)
+		if err != nil {
+			return nil, err
+		}
+
+		rowReader = jointRowReader
+
+		jointRowReader.txTimestamps, err = stmt.jointTxTimestamps(e, implicitDB)
+		if err != nil {
+			rowReader.Close()
+			return nil, err
+		}
+
+		err = stmt.checkAmbiguousCols(rowReader)
+		if err != nil {
+			rowReader.Close()
+			return nil, err
+		}
+	}
+
+	if stmt.where != nil {
+		rowReader, err = e.newConditionalRowReader(rowReader, stmt.where, params)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sortsInMemory, err := stmt.sortsInMemory(scanSpecs)
+	if err != nil {
+		rowReader.Close()
+		return nil, err
+	}
+
+	if sortsInMemory {
+		rowReader, err = e.newSortRowReader(rowReader, stmt.orderBy[0])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if stmt.isGrouped() {
+		groupedRowReader, err := e.newGroupedRowReader(rowReader, stmt.groupedSelectors(rowReader.ImplicitDB(), rowReader.ImplicitTable()), stmt.groupBy)
+		if err != nil {
+			rowReader.Close()
+			return nil, err
+		}
+
+		rowReader = groupedRowReader
+
+		if stmt.having != nil {
+			rowReader, err = e.newConditionalRowReader(rowReader, stmt.having, params)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	rowReader, err = e.newProjectedRowReader(rowReader, stmt.as, stmt.selectors, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if stmt.distinct {
+		rowReader, err = e.newDistinctRowReader(rowReader)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if stmt.offset > 0 {
+		rowReader, err = e.newOffsetRowReader(rowReader, stmt.offset)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if stmt.limit > 0 {
+		return e.newLimitRowReader(rowReader, stmt.limit)
+	}
+
+	return rowReader, nil
+}
+
+// resolveValues produces the single row of a selection without a data source,
+// made of the values of its selectors. Unaliased values are named after their position e.g. col0
+func (stmt *SelectStmt) resolveValues(e *Engine, implicitDB *Database, params map[string]interface{}) (RowReader, error) {
+	var db string
+	if implicitDB != nil {
+		db = implicitDB.name
+	}
+
+	cols := make([]ColDescriptor, len(stmt.selectors))
+	row := make([]TypedValue, len(stmt.selectors))
+
+	for i, sel := range stmt.selectors {
+		val, err := sel.substitute(params)
+		if err != nil {
+			return nil, err
+		}
+
+		rval, err := val.reduce(e.catalog, &Row{Values: map[string]TypedValue{}}, db, stmt.as)
+		if err != nil {
+			return nil, err
+		}
+
+		col := sel.alias()
+		if col == "" {
+			col = fmt.Sprintf("col%d", i)
+		}
+
+		cols[i] = ColDescriptor{Column: col, Type: rval.Type()}
+		row[i] = rval
+	}
+
+	return e.newValuesRowReader(db, stmt.as, cols, [][]TypedValue{row})
+}
+
+// isGrouped returns true when rows are grouped before being projected,
+// either by the group by clause or by the selected aggregations
+func (stmt *SelectStmt) isGrouped() bool {
+	return stmt.groupBy != nil || stmt.containsAggregations()
+}
+
+func (stmt *SelectStmt) containsAggregations() bool {
+	for _, sel := range stmt.selectors {
+		_, isAgg := sel.(*AggColSelector)
+		if isAgg {
+			return true
+		}
+	}
+
+	return false
+}
+
+// groupedSelectors returns the selectors to be computed when grouping rows,
+// aggregations only referenced in the having clause are appended to the projected ones
+func (stmt *SelectStmt) groupedSelectors(implicitDB, implicitTable string) []Selector {
+	if stmt.having == nil {
+		return stmt.selectors
+	}
+
+	selectors := make([]Selector, len(stmt.selectors))
+	copy(selectors, stmt.selectors)
+
+	projected := make(map[string]struct{}, len(stmt.selectors))
+	for _, sel := range stmt.selectors {
+		projected[EncodeSelector(sel.resolve(implicitDB, implicitTable))] = struct{}{}
+	}
+
+	for _, sel := range aggregationsIn(stmt.having) {
+		encSel := EncodeSelector(sel.resolve(implicitDB, implicitTable))
+
+		_, ok := projected[encSel]
+		if ok {
+			continue
+		}
+
+		selectors = append(selectors, sel)
+		projected[encSel] = struct{}{}
+	}
+
+	return selectors
+}
+
+// colSelectorsIn returns the columns referenced in the expression, including the aggregated ones
+func colSelectorsIn(exp ValueExp) []*ColSelector {
+	switch e := exp.(type) {
+	case *ColSelector:
+		return []*ColSelector{e}
+	case *AggColSelector:
+		if e.col == "*" {
+			return nil
+		}
+		return colSelectorsIn(e.arg())
+	case *expSelector:
+		return colSelectorsIn(e.exp)
+	case *NegExp:
+		return colSelectorsIn(e.exp)
+	case *NotBoolExp:
+		return colSelectorsIn(e.exp)
+	case *IsNullBoolExp:
+		return colSelectorsIn(e.exp)
+	case *NumExp:
+		return append(colSelectorsIn(e.left), colSelectorsIn(e.right)...)
+	case *CmpBoolExp:
+		return append(colSelectorsIn(e.left), colSelectorsIn(e.right)...)
+	case *BinBoolExp:
+		return append(colSelectorsIn(e.left), colSelectorsIn(e.right)...)
+	case *LikeBoolExp:
+		return append(colSelectorsIn(e.val), colSelectorsIn(e.pattern)...)
+	case *Cast:
+		return colSelectorsIn(e.val)
+	case *InListExp:
+		sels := colSelectorsIn(e.val)
+		for _, v := range e.values {
+			sels = append(sels, colSelectorsIn(v)...)
+		}
+		return sels
+	case *FnCall:
+		var sels []*ColSelector
+		for _, p := range e.params {
+			sels = append(sels, colSelectorsIn(p)...)
+		}
+		return sels
+	case *CaseExp:
+		var sels []*ColSelector
+		for _, wt := range e.whenThens {
+			sels = append(sels, colSelectorsIn(wt.when)...)
+			sels = append(sels, colSelectorsIn(wt.then)...)
+		}
+		if e.elseExp != nil {
+			sels = append(sels, colSelectorsIn(e.elseExp)...)
+		}
+		return sels
+	}
+
+	return nil
+}
+
+// checkAmbiguousCols ensures unqualified columns are provided by a single source of the joint reader
+func (stmt *SelectStmt) checkAmbiguousCols(rowReader RowReader) error {
+	cols, err := rowReader.colsBySelector()
+	if err != nil {
+		return err
+	}
+
+	exps := []ValueExp{stmt.where, stmt.having}
+
+	for _, sel := range stmt.selectors {
+		exps = append(exps, sel)
+	}
+
+	for _, join := range stmt.joins {
+		exps = append(exps, join.cond)
+	}
+
+	for _, sel := range stmt.groupBy {
+		exps = append(exps, sel)
+	}
+
+	for _, col := range stmt.orderBy {
+		exps = append(exps, col.sel)
+	}
+
+	for _, exp := range exps {
+		for _, sel := range colSelectorsIn(exp) {
+			err = sel.checkAmbiguity(cols, rowReader.ImplicitDB())
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// aggregationsIn returns the aggregations referenced in the expression
+func aggregationsIn(exp ValueExp) []*AggColSelector {
+	switch e := exp.(type) {
+	case *AggColSelector:
+		return []*AggColSelector{e}
+	case *NegExp:
+		return aggregationsIn(e.exp)
+	case *NotBoolExp:
+		return aggregationsIn(e.exp)
+	case *IsNullBoolExp:
+		return aggregationsIn(e.exp)
+	case *NumExp:
+		return append(aggregationsIn(e.left), aggregationsIn(e.right)...)
+	case *CmpBoolExp:
+		return append(aggregationsIn(e.left), aggregationsIn(e.right)...)
+	case *BinBoolExp:
+		return append(aggregationsIn(e.left), aggregationsIn(e.right)...)
+	case *LikeBoolExp:
+		return aggregationsIn(e.val)
+	case *Cast:
+		return aggregationsIn(e.val)
+	case *InListExp:
+		aggs := aggregationsIn(e.val)
+		for _, v := range e.values {
+			aggs = append(aggs, aggregationsIn(v)...)
+		}
+		return aggs
+	case *FnCall:
+		var aggs []*AggColSelector
+		for _, p := range e.params {
+			aggs = append(aggs, aggregationsIn(p)...)
+		}
+		return aggs
+	case *CaseExp:
+		var aggs []*AggColSelector
+		for _, wt := range e.whenThens {
+			aggs = append(aggs, aggregationsIn(wt.when)...)
+			aggs = append(aggs, aggregationsIn(wt.then)...)
+		}
+		if e.elseExp != nil {
+			aggs = append(aggs, aggregationsIn(e.elseExp)...)
+		}
+		return aggs
+	}
+
+	return nil
+}
+
+func (stmt *SelectStmt) Alias() string {
+	if stmt.as == "" && stmt.ds != nil {
+		return stmt.ds.Alias()
+	}
+
+	return stmt.as
+}
+
+func (stmt *SelectStmt) genScanSpecs(e *Engine, snap *store.Snapshot, implicitDB *Database, params map[string]interface{}) (*ScanSpecs, error) {
+	tableRef, isTableRef := stmt.ds.(*tableRef)
+	if !isTableRef {
+		return nil, nil
+	}
+
+	table, err := tableRef.referencedTable(e, implicitDB)
+	if err != nil {
+		return nil, err
+	}
+
+	rangesByColID := make(map[uint32]*typedValueRange)
+	if stmt.where != nil {
+		err = stmt.where.selectorRanges(table, tableRef.Alias(), params, rangesByColID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var preferredIndex *Index
+
+	if len(stmt.indexOn) > 0 {
+		cols := make([]*Column, len(stmt.indexOn))
+
+		for i, colName := range stmt.indexOn {
+			col, err := table.GetColumnByName(colName)
+			if err != nil {
+				return nil, err
+			}
+
+			cols[i] = col
+		}
+
+		index, ok := table.indexes[indexKeyFrom(cols)]
+		if !ok {
+			return nil, ErrNoAvailableIndex
+		}
+
+		preferredIndex = index
+	}
+
+	var sortingIndex *Index
+	var descOrder bool
+
+	if stmt.orderBy == nil {
+		if preferredIndex == nil {
+			sortingIndex = mostSelectiveIndex(table, rangesByColID)
+		} else {
+			sortingIndex = preferredIndex
+		}
+	}
+
+	if len(stmt.orderBy) > 0 {
+		col, err := table.GetColumnByName(stmt.orderBy[0].sel.col)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, idx := range table.indexesByColID[col.id] {
+			if idx.sortableUsing(col.id, rangesByColID) {
+				if preferredIndex == nil || idx.id == preferredIndex.id {
+					sortingIndex = idx
+					break
+				}
+			}
+		}
+
+		// indexed columns can not hold null values, thus the nulls ordering
+		// does not change the order in which index entries are scanned
+		descOrder = stmt.orderBy[0].descOrder
+
+		sortedByIndex, err := stmt.sortedByIndex(table)
+		if err != nil {
+			return nil, err
+		}
+
+		// rows are sorted in memory after being scanned as if they were not ordered
+		if !sortedByIndex {
+			sortingIndex = preferredIndex
+			if sortingIndex == nil {
+				sortingIndex = mostSelectiveIndex(table, rangesByColID)
+			}
+
+			descOrder = false
+		}
+	}
+
+	if sortingIndex == nil {
+		return nil, ErrNoAvailableIndex
+	}
+
+	return &ScanSpecs{
+		index:         sortingIndex,
+		rangesByColID: rangesByColID,
+		descOrder:     descOrder,
+		txTimestamps:  stmt.refersTo(table, tableRef.Alias(), TxTimestampColumn),
+		indexOnly:     stmt.coveredBy(sortingIndex, tableRef.Alias()),
+	}, nil
+}
+
+// colsUsedFrom returns the names of the columns of the table used anywhere in the statement
+func (stmt *SelectStmt) colsUsedFrom(table *Table, asTable string) map[string]struct{} {
+	exps := []ValueExp{stmt.where, stmt.having}
+
+	for _, sel := range stmt.selectors {
+		exps = append(exps, sel)
+	}
+	for _, sel := range stmt.groupBy {
+		exps = append(exps, sel)
+	}
+	for _, col := range stmt.orderBy {
+		exps = append(exps, col.sel)
+	}
+	for _, jspec := range stmt.joins {
+		exps = append(exps, jspec.cond)
+	}
+
+	cols := make(map[string]struct{})
+
+	for _, exp := range exps {
+		for _, sel := range colSelectorsIn(exp) {
+			_, db, t, col := sel.resolve(table.db.name, asTable)
+			if db == table.db.name && t == asTable {
+				cols[col] = struct{}{}
+			}
+		}
+	}
+
+	return cols
+}
+
+// jointTxTimestamps returns for each join whether the timestamp system column of the joint table is used in the statement
+func (stmt *SelectStmt) jointTxTimestamps(e *Engine, implicitDB *Database) ([]bool, error) {
+	txTimestamps := make([]bool, len(stmt.joins))
+
+	for i, jspec := range stmt.joins {
+		tableRef, isTableRef := jspec.ds.(*tableRef)
+		if !isTableRef {
+			continue
+		}
+
+		table, err := tableRef.referencedTable(e, implicitDB)
+		if err != nil {
+			return nil, err
+		}
+
+		txTimestamps[i] = stmt.refersTo(table, tableRef.Alias(), TxTimestampColumn)
+	}
+
+	return txTimestamps, nil
 }
 
-type SysFn struct {
-	fn string
+// refersTo returns true when the column of the table is used anywhere in the statement
+func (stmt *SelectStmt) refersTo(table *Table, asTable, colName string) bool {
+	_, used := stmt.colsUsedFrom(table, asTable)[colName]
+	return used
 }
 
-func (v *SysFn) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
-	if strings.ToUpper(v.fn) == "NOW" {
-		return IntegerType, nil
+// coveredBy returns true when all the columns used by the statement are part of the keys of the index,
+// i.e. indexed columns and primary key columns, so rows can be decoded from them without reading the row values
+func (stmt *SelectStmt) coveredBy(index *Index, asTable string) bool {
+	if (!index.IsPrimary() && index.IsAutoIncrement()) || len(stmt.joins) > 0 || len(stmt.selectors) == 0 {
+		return false
 	}
 
-	return AnyType, ErrIllegalArguments
+	for _, sel := range stmt.selectors {
+		_, isWildcard := sel.(*wildcardSelector)
+		if isWildcard {
+			return false
+		}
+	}
+
+	covered := make(map[string]struct{})
+
+	for _, cols := range [][]*Column{index.cols, index.table.primaryIndex.cols} {
+		for _, col := range cols {
+			// case-insensitive values are folded when encoded as keys
+			if col.collation != NoCaseCollation {
+				covered[col.colName] = struct{}{}
+			}
+		}
+	}
+
+	for col := range stmt.colsUsedFrom(index.table, asTable) {
+		_, ok := covered[col]
+		if !ok {
+			return false
+		}
+	}
+
+	return true
 }
 
-func (v *SysFn) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
-	if strings.ToUpper(v.fn) == "NOW" {
-		if t != IntegerType {
-			return ErrInvalidTypes
+// mostSelectiveIndex returns the index whose leading column is the most constrained by the ranges,
+// the primary index is used unless another index is strictly more constrained
+func mostSelectiveIndex(table *Table, rangesByColID map[uint32]*typedValueRange) *Index {
+	selected := table.primaryIndex
+	selectedScore := rangeScore(rangesByColID[selected.cols[0].id])
+
+	for _, index := range table.indexes {
+		score := rangeScore(rangesByColID[index.cols[0].id])
+
+		if score > selectedScore || (score == selectedScore && score > 0 && index.id < selected.id) {
+			selected = index
+			selectedScore = score
 		}
+	}
 
-		return nil
+	return selected
+}
+
+// rangeScore ranks how much a range narrows a scan: unitary ranges first, then ranges bounded at both ends,
+// then ranges bounded at one end. Zero is returned for unconstrained columns
+func rangeScore(r *typedValueRange) int {
+	if r == nil {
+		return 0
 	}
 
-	return ErrIllegalArguments
+	if r.unitary() {
+		return 3
+	}
+
+	if r.lRange != nil && r.hRange != nil {
+		return 2
+	}
+
+	if r.lRange != nil || r.hRange != nil {
+		return 1
+	}
+
+	return 0
 }
 
-func (v *SysFn) substitute(params map[string]interface{}) (ValueExp, error) {
-	return v, nil
+type UnionStmt struct {
+	distinct    bool
+	left, right DQLStmt
 }
 
-func (v *SysFn) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
-	if strings.ToUpper(v.fn) == "NOW" {
-		return &Number{val: time.Now().UnixNano()}, nil
+func (stmt *UnionStmt) inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error {
+	err := stmt.left.inferParameters(e, implicitDB, params)
+	if err != nil {
+		return err
 	}
 
-	return nil, errors.New("not yet supported")
+	return stmt.right.inferParameters(e, implicitDB, params)
 }
 
-func (v *SysFn) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
-	return v
+func (stmt *UnionStmt) compileUsing(e *Engine, implicitDB *Database, params map[string]interface{}) (summary *TxSummary, err error) {
+	_, err = stmt.left.compileUsing(e, implicitDB, params)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = stmt.right.compileUsing(e, implicitDB, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return newTxSummary(implicitDB), nil
 }
 
-func (v *SysFn) isConstant() bool {
-	return false
+func (stmt *UnionStmt) Resolve(ctx context.Context, e *Engine, snap *store.Snapshot, implicitDB *Database, params map[string]interface{}, _ *ScanSpecs) (rowReader RowReader, err error) {
+	leftRowReader, err := stmt.left.Resolve(ctx, e, snap, implicitDB, params, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	rightRowReader, err := stmt.right.Resolve(ctx, e, snap, implicitDB, params, nil)
+	if err != nil {
+		leftRowReader.Close()
+		return nil, err
+	}
+
+	rowReader, err = e.newUnionRowReader([]RowReader{leftRowReader, rightRowReader})
+	if err != nil {
+		leftRowReader.Close()
+		rightRowReader.Close()
+		return nil, err
+	}
+
+	if stmt.distinct {
+		return e.newDistinctRowReader(rowReader)
+	}
+
+	return rowReader, nil
 }
 
-func (v *SysFn) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
-	return nil
+func (stmt *UnionStmt) Alias() string {
+	return stmt.left.Alias()
 }
 
-type Param struct {
-	id  string
-	pos int
+// DescribeTableStmt returns a row per column of the table, with its name, type, max length
+// and whether it's nullable, auto incremental or indexed
+type DescribeTableStmt struct {
+	table *tableRef
 }
 
-func (v *Param) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
-	t, ok := params[v.id]
-	if !ok {
-		params[v.id] = AnyType
-		return AnyType, nil
+var describeTableCols = []ColDescriptor{
+	{Column: "name", Type: VarcharType},
+	{Column: "type", Type: VarcharType},
+	{Column: "max_length", Type: IntegerType},
+	{Column: "nullable", Type: BooleanType},
+	{Column: "auto_increment", Type: BooleanType},
+	{Column: "indexed", Type: BooleanType},
+}
+
+func (stmt *DescribeTableStmt) inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error {
+	return nil
+}
+
+func (stmt *DescribeTableStmt) compileUsing(e *Engine, implicitDB *Database, params map[string]interface{}) (summary *TxSummary, err error) {
+	_, err = stmt.table.referencedTable(e, implicitDB)
+	if err != nil {
+		return nil, err
 	}
 
-	return t, nil
+	return newTxSummary(implicitDB), nil
 }
 
-func (v *Param) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
-	currT, ok := params[v.id]
-	if ok && currT != t && currT != AnyType {
-		return ErrInferredMultipleTypes
+func (stmt *DescribeTableStmt) Resolve(ctx context.Context, e *Engine, snap *store.Snapshot, implicitDB *Database, params map[string]interface{}, _ *ScanSpecs) (RowReader, error) {
+	table, err := stmt.table.referencedTable(e, implicitDB)
+	if err != nil {
+		return nil, err
 	}
 
-	params[v.id] = t
+	rows := make([][]TypedValue, len(table.cols))
+
+	for i, col := range table.cols {
+		// indexed columns can not hold null values
+		_, indexed := table.indexesByColID[col.id]
+
+		rows[i] = []TypedValue{
+			&Varchar{val: col.colName},
+			&Varchar{val: col.colType},
+			&Number{val: int64(col.MaxLen())},
+			&Bool{val: col.IsNullable() && !indexed},
+			&Bool{val: col.IsAutoIncremental()},
+			&Bool{val: indexed},
+		}
+	}
+
+	return e.newValuesRowReader(table.db.name, table.name, describeTableCols, rows)
+}
+
+func (stmt *DescribeTableStmt) Alias() string {
+	return stmt.table.table
+}
+
+// ShowTablesStmt returns the names of the tables in the database in use
+type ShowTablesStmt struct{}
 
+func (stmt *ShowTablesStmt) inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error {
 	return nil
 }
 
-func (p *Param) substitute(params map[string]interface{}) (ValueExp, error) {
-	val, ok := params[p.id]
-	if !ok {
-		return nil, ErrMissingParameter
+func (stmt *ShowTablesStmt) compileUsing(e *Engine, implicitDB *Database, params map[string]interface{}) (summary *TxSummary, err error) {
+	if implicitDB == nil {
+		return nil, ErrNoDatabaseSelected
 	}
 
-	if val == nil {
-		return &NullValue{t: AnyType}, nil
+	return newTxSummary(implicitDB), nil
+}
+
+func (stmt *ShowTablesStmt) Resolve(ctx context.Context, e *Engine, snap *store.Snapshot, implicitDB *Database, params map[string]interface{}, _ *ScanSpecs) (RowReader, error) {
+	if implicitDB == nil {
+		return nil, ErrNoDatabaseSelected
 	}
 
-	switch v := val.(type) {
-	case bool:
-		{
-			return &Bool{val: v}, nil
-		}
-	case string:
-		{
-			return &Varchar{val: v}, nil
-		}
-	case int:
-		{
-			return &Number{val: int64(v)}, nil
-		}
-	case uint:
-		{
-			return &Number{val: int64(v)}, nil
-		}
-	case uint64:
-		{
-			return &Number{val: int64(v)}, nil
-		}
-	case int64:
-		{
-			return &Number{val: v}, nil
-		}
-	case []byte:
-		{
-			return &Blob{val: v}, nil
-		}
+	names := make([]string, 0, len(implicitDB.tablesByName))
+	for name := range implicitDB.tablesByName {
+		names = append(names, name)
 	}
 
-	return nil, ErrUnsupportedParameter
+	return e.newValuesRowReader(implicitDB.name, stmt.Alias(), []ColDescriptor{{Column: "name", Type: VarcharType}}, namesAsRows(names))
 }
 
-func (p *Param) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
-	return nil, ErrUnexpected
+func (stmt *ShowTablesStmt) Alias() string {
+	return "tables"
 }
 
-func (p *Param) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
-	return p
+// ShowDatabasesStmt returns the names of all the databases
+type ShowDatabasesStmt struct{}
+
+func (stmt *ShowDatabasesStmt) inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error {
+	return nil
 }
 
-func (p *Param) isConstant() bool {
-	return true
+func (stmt *ShowDatabasesStmt) compileUsing(e *Engine, implicitDB *Database, params map[string]interface{}) (summary *TxSummary, err error) {
+	return newTxSummary(implicitDB), nil
 }
 
-func (v *Param) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+func (stmt *ShowDatabasesStmt) Resolve(ctx context.Context, e *Engine, snap *store.Snapshot, implicitDB *Database, params map[string]interface{}, _ *ScanSpecs) (RowReader, error) {
+	names := make([]string, 0, len(e.catalog.dbsByName))
+	for name := range e.catalog.dbsByName {
+		names = append(names, name)
+	}
+
+	return e.newValuesRowReader("", stmt.Alias(), []ColDescriptor{{Column: "name", Type: VarcharType}}, namesAsRows(names))
+}
+
+func (stmt *ShowDatabasesStmt) Alias() string {
+	return "databases"
+}
+
+// namesAsRows returns a single column row per name, sorted by name
+func namesAsRows(names []string) [][]TypedValue {
+	sort.Strings(names)
+
+	rows := make([][]TypedValue, len(names))
+	for i, name := range names {
+		rows[i] = []TypedValue{&Varchar{val: name}}
+	}
+
+	return rows
+}
+
+type tableRef struct {
+	db       string
+	table    string
+	asBefore uint64
+	as       string
+}
+
+func (stmt *tableRef) referencedTable(e *Engine, implicitDB *Database) (*Table, error) {
+	var db *Database
+
+	if stmt.db != "" {
+		rdb, err := e.catalog.GetDatabaseByName(stmt.db)
+		if err != nil {
+			return nil, err
+		}
+
+		db = rdb
+	}
+
+	if db == nil {
+		if implicitDB == nil {
+			return nil, ErrNoDatabaseSelected
+		}
+
+		db = implicitDB
+	}
+
+	table, err := db.GetTableByName(stmt.table)
+	if err != nil {
+		return nil, err
+	}
+
+	return table, nil
+}
+
+func (stmt *tableRef) inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error {
 	return nil
 }
 
-type Comparison int
+func (stmt *tableRef) Resolve(ctx context.Context, e *Engine, snap *store.Snapshot, implicitDB *Database, params map[string]interface{}, scanSpecs *ScanSpecs) (RowReader, error) {
+	if e == nil || snap == nil {
+		return nil, ErrIllegalArguments
+	}
 
-const (
-	EqualTo Comparison = iota
-	LowerThan
-	LowerOrEqualTo
-	GreaterThan
-	GreaterOrEqualTo
-)
+	table, err := stmt.referencedTable(e, implicitDB)
+	if err != nil {
+		return nil, err
+	}
 
-type DataSource interface {
-	inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error
-	Resolve(e *Engine, snap *store.Snapshot, implicitDB *Database, params map[string]interface{}, ScanSpecs *ScanSpecs) (RowReader, error)
-	Alias() string
+	asBefore := stmt.asBefore
+	if asBefore == 0 {
+		asBefore = e.snapAsBeforeTx
+	}
+
+	return e.newRawRowReader(ctx, snap, table, asBefore, stmt.as, scanSpecs)
 }
 
-type SelectStmt struct {
-	distinct  bool
-	selectors []Selector
-	ds        DataSource
-	indexOn   []string
-	joins     []*JoinSpec
-	where     ValueExp
-	groupBy   []*ColSelector
-	having    ValueExp
-	limit     int
-	orderBy   []*OrdCol
-	as        string
+func (stmt *tableRef) Alias() string {
+	if stmt.as == "" {
+		return stmt.table
+	}
+	return stmt.as
 }
 
-type ScanSpecs struct {
-	index         *Index
-	rangesByColID map[uint32]*typedValueRange
-	descOrder     bool
+type JoinSpec struct {
+	joinType JoinType
+	ds       DataSource
+	cond     ValueExp
+	indexOn  []string
 }
 
-func (stmt *SelectStmt) Limit() int {
-	return stmt.limit
+type NullsOrder = int
+
+const (
+	// NullsDefault sorts nulls as the smallest values
+	NullsDefault NullsOrder = iota
+	NullsFirst
+	NullsLast
+)
+
+type OrdCol struct {
+	sel        *ColSelector
+	descOrder  bool
+	nullsOrder NullsOrder
 }
 
-func (stmt *SelectStmt) inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error {
-	_, err := stmt.compileUsing(e, implicitDB, nil)
-	if err != nil {
-		return err
+// nullsFirst returns true when nulls precede the rest of the values,
+// by default nulls are the smallest values
+func (col *OrdCol) nullsFirst() bool {
+	if col.nullsOrder == NullsDefault {
+		return !col.descOrder
 	}
 
-	snapshot, err := e.getSnapshot()
-	if err != nil {
-		return err
+	return col.nullsOrder == NullsFirst
+}
+
+// less returns true when the value v1 has to be placed before v2
+func (col *OrdCol) less(v1, v2 TypedValue) (bool, error) {
+	_, isNull1 := v1.(*NullValue)
+	_, isNull2 := v2.(*NullValue)
+
+	if isNull1 || isNull2 {
+		if isNull1 && isNull2 {
+			return false, nil
+		}
+
+		return isNull1 == col.nullsFirst(), nil
 	}
 
-	// TODO (jeroiraz) may be optimized so to resolve the query statement just once
-	rowReader, err := stmt.Resolve(e, snapshot, implicitDB, nil, nil)
+	cmp, err := v1.Compare(v2)
 	if err != nil {
-		return err
+		return false, err
 	}
-	defer rowReader.Close()
 
-	return rowReader.InferParameters(params)
+	if col.descOrder {
+		return cmp > 0, nil
+	}
+
+	return cmp < 0, nil
 }
 
-func (stmt *SelectStmt) compileUsing(e *Engine, implicitDB *Database, params map[string]interface{}) (summary *TxSummary, err error) {
-	if implicitDB == nil {
-		return nil, ErrNoDatabaseSelected
-	}
+type Selector interface {
+	ValueExp
+	resolve(implicitDB, implicitTable string) (aggFn, db, table, col string)
+	alias() string
+	setAlias(alias string)
+}
 
-	if stmt.groupBy == nil && stmt.having != nil {
-		return nil, ErrHavingClauseRequiresGroupClause
+type ColSelector struct {
+	db    string
+	table string
+	col   string
+	as    string
+}
+
+func (sel *ColSelector) resolve(implicitDB, implicitTable string) (aggFn, db, table, col string) {
+	db = implicitDB
+	if sel.db != "" {
+		db = sel.db
 	}
 
-	if len(stmt.groupBy) > 1 {
-		return nil, ErrLimitedGroupBy
+	table = implicitTable
+	if sel.table != "" {
+		table = sel.table
 	}
 
-	if len(stmt.orderBy) > 1 {
-		return nil, ErrLimitedOrderBy
+	return "", db, table, sel.col
+}
+
+func (sel *ColSelector) alias() string {
+	if sel.as == "" {
+		return sel.col
 	}
 
-	if len(stmt.orderBy) > 0 {
-		tableRef, ok := stmt.ds.(*tableRef)
-		if !ok {
-			return nil, ErrLimitedOrderBy
-		}
+	return sel.as
+}
 
-		table, err := tableRef.referencedTable(e, implicitDB)
-		if err != nil {
-			return nil, err
-		}
+func (sel *ColSelector) setAlias(alias string) {
+	sel.as = alias
+}
 
-		col, err := table.GetColumnByName(stmt.orderBy[0].sel.col)
-		if err != nil {
-			return nil, err
-		}
+func (sel *ColSelector) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	err := sel.checkAmbiguity(cols, implicitDB)
+	if err != nil {
+		return AnyType, err
+	}
 
-		_, indexed := table.indexesByColID[col.id]
-		if !indexed {
-			return nil, ErrLimitedOrderBy
-		}
+	_, db, table, col := sel.resolve(implicitDB, implicitTable)
+	encSel := EncodeSelector("", db, table, col)
+
+	desc, ok := cols[encSel]
+	if !ok {
+		return AnyType, fmt.Errorf("%w (%s)", ErrColumnDoesNotExist, col)
 	}
 
-	return newTxSummary(implicitDB), nil
+	return desc.Type, nil
 }
 
-func (stmt *SelectStmt) Resolve(e *Engine, snap *store.Snapshot, implicitDB *Database, params map[string]interface{}, _ *ScanSpecs) (rowReader RowReader, err error) {
-	scanSpecs, err := stmt.genScanSpecs(e, snap, implicitDB, params)
+func (sel *ColSelector) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	err := sel.checkAmbiguity(cols, implicitDB)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	rowReader, err = stmt.ds.Resolve(e, snap, implicitDB, params, scanSpecs)
-	if err != nil {
-		return nil, err
+	_, db, table, col := sel.resolve(implicitDB, implicitTable)
+	encSel := EncodeSelector("", db, table, col)
+
+	desc, ok := cols[encSel]
+	if !ok {
+		return fmt.Errorf("%w (%s)", ErrColumnDoesNotExist, col)
 	}
 
-	if stmt.joins != nil {
-		rowReader, err = e.newJointRowReader(implicitDB, snap, params, rowReader, stmt.joins)
-		if err != nil {
-			return nil, err
-		}
+	if desc.Type != t {
+		return ErrInvalidTypes
 	}
 
-	if stmt.where != nil {
-		rowReader, err = e.newConditionalRowReader(rowReader, stmt.where, params)
-		if err != nil {
-			return nil, err
-		}
+	return nil
+}
+
+// checkAmbiguity returns ErrAmbiguousColumn when the column is not qualified and more than one source provides it,
+// the proposed values of an upsert (excluded table) are not considered a source
+func (sel *ColSelector) checkAmbiguity(cols map[string]ColDescriptor, implicitDB string) error {
+	if sel.table != "" {
+		return nil
 	}
 
-	containsAggregations := false
-	for _, sel := range stmt.selectors {
-		_, containsAggregations = sel.(*AggColSelector)
-		if containsAggregations {
-			break
-		}
+	db := implicitDB
+	if sel.db != "" {
+		db = sel.db
 	}
 
-	if containsAggregations {
-		var groupBy []*ColSelector
-		if stmt.groupBy != nil {
-			groupBy = stmt.groupBy
-		}
+	tables := make(map[string]struct{})
 
-		rowReader, err = e.newGroupedRowReader(rowReader, stmt.selectors, groupBy)
-		if err != nil {
-			return nil, err
+	for _, c := range cols {
+		if c.AggFn != "" || c.Database != db || c.Column != sel.col || c.Table == excludedTable {
+			continue
 		}
 
-		if stmt.having != nil {
-			rowReader, err = e.newConditionalRowReader(rowReader, stmt.having, params)
-			if err != nil {
-				return nil, err
-			}
-		}
+		tables[c.Table] = struct{}{}
 	}
 
-	rowReader, err = e.newProjectedRowReader(rowReader, stmt.as, stmt.selectors)
-	if err != nil {
-		return nil, err
+	if len(tables) < 2 {
+		return nil
 	}
 
-	if stmt.distinct {
-		rowReader, err = e.newDistinctRowReader(rowReader)
-		if err != nil {
-			return nil, err
-		}
-	}
+	candidates := make([]string, 0, len(tables))
 
-	if stmt.limit > 0 {
-		return e.newLimitRowReader(rowReader, stmt.limit)
+	for table := range tables {
+		candidates = append(candidates, table+"."+sel.col)
 	}
 
-	return rowReader, nil
-}
+	sort.Strings(candidates)
 
-func (stmt *SelectStmt) Alias() string {
-	if stmt.as == "" {
-		return stmt.ds.Alias()
-	}
+	return fmt.Errorf("%w: '%s' may refer to %s", ErrAmbiguousColumn, sel.col, strings.Join(candidates, ", "))
+}
 
-	return stmt.as
+func (sel *ColSelector) substitute(params map[string]interface{}) (ValueExp, error) {
+	return sel, nil
 }
 
-func (stmt *SelectStmt) genScanSpecs(e *Engine, snap *store.Snapshot, implicitDB *Database, params map[string]interface{}) (*ScanSpecs, error) {
-	tableRef, isTableRef := stmt.ds.(*tableRef)
-	if !isTableRef {
-		return nil, nil
+func (sel *ColSelector) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	if row == nil {
+		return nil, ErrInvalidValue
 	}
 
-	table, err := tableRef.referencedTable(e, implicitDB)
-	if err != nil {
-		return nil, err
+	aggFn, db, table, col := sel.resolve(implicitDB, implicitTable)
+
+	v, ok := row.Values[EncodeSelector(aggFn, db, table, col)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s.%s.%s", ErrColumnDoesNotExist, db, table, col)
 	}
 
-	rangesByColID := make(map[uint32]*typedValueRange)
-	if stmt.where != nil {
-		err = stmt.where.selectorRanges(table, tableRef.Alias(), params, rangesByColID)
-		if err != nil {
-			return nil, err
-		}
+	return v, nil
+}
+
+func (sel *ColSelector) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	aggFn, db, table, col := sel.resolve(implicitDB, implicitTable)
+
+	v, ok := row.Values[EncodeSelector(aggFn, db, table, col)]
+	if !ok {
+		return sel
 	}
 
-	var preferredIndex *Index
+	return v
+}
 
-	if len(stmt.indexOn) > 0 {
-		cols := make([]*Column, len(stmt.indexOn))
+func (sel *ColSelector) isConstant() bool {
+	return false
+}
 
-		for i, colName := range stmt.indexOn {
-			col, err := table.GetColumnByName(colName)
-			if err != nil {
-				return nil, err
-			}
+func (sel *ColSelector) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
 
-			cols[i] = col
-		}
+// wildcardSelector stands for all the columns of a table i.e. t.*,
+// it gets expanded into column selectors when the query is resolved
+type wildcardSelector struct {
+	db    string
+	table string
+}
 
-		index, ok := table.indexes[indexKeyFrom(cols)]
-		if !ok {
-			return nil, ErrNoAvailableIndex
-		}
+func (sel *wildcardSelector) resolve(implicitDB, implicitTable string) (aggFn, db, table, col string) {
+	db = implicitDB
+	if sel.db != "" {
+		db = sel.db
+	}
 
-		preferredIndex = index
+	table = implicitTable
+	if sel.table != "" {
+		table = sel.table
 	}
 
-	var sortingIndex *Index
-	var descOrder bool
+	return "", db, table, "*"
+}
 
-	if stmt.orderBy == nil {
-		if preferredIndex == nil {
-			sortingIndex = table.primaryIndex
-		} else {
-			sortingIndex = preferredIndex
-		}
-	}
+func (sel *wildcardSelector) alias() string {
+	return "*"
+}
 
-	if len(stmt.orderBy) > 0 {
-		col, err := table.GetColumnByName(stmt.orderBy[0].sel.col)
-		if err != nil {
-			return nil, err
-		}
+func (sel *wildcardSelector) setAlias(alias string) {
+}
 
-		for _, idx := range table.indexesByColID[col.id] {
-			if idx.sortableUsing(col.id, rangesByColID) {
-				if preferredIndex == nil || idx.id == preferredIndex.id {
-					sortingIndex = idx
-					break
-				}
-			}
-		}
+func (sel *wildcardSelector) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	return AnyType, fmt.Errorf("%w (%s.*)", ErrInvalidColumn, sel.table)
+}
 
-		descOrder = stmt.orderBy[0].descOrder
-	}
+func (sel *wildcardSelector) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	return fmt.Errorf("%w (%s.*)", ErrInvalidColumn, sel.table)
+}
 
-	if sortingIndex == nil {
-		return nil, ErrNoAvailableIndex
-	}
+func (sel *wildcardSelector) substitute(params map[string]interface{}) (ValueExp, error) {
+	return sel, nil
+}
 
-	return &ScanSpecs{
-		index:         sortingIndex,
-		rangesByColID: rangesByColID,
-		descOrder:     descOrder,
-	}, nil
+func (sel *wildcardSelector) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	return nil, fmt.Errorf("%w (%s.*)", ErrInvalidColumn, sel.table)
 }
 
-type tableRef struct {
-	db       string
-	table    string
-	asBefore uint64
-	as       string
+func (sel *wildcardSelector) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return sel
 }
 
-func (stmt *tableRef) referencedTable(e *Engine, implicitDB *Database) (*Table, error) {
-	var db *Database
+func (sel *wildcardSelector) isConstant() bool {
+	return false
+}
 
-	if stmt.db != "" {
-		rdb, err := e.catalog.GetDatabaseByName(stmt.db)
-		if err != nil {
-			return nil, err
-		}
+func (sel *wildcardSelector) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
 
-		db = rdb
+// newSelector returns the selector projecting the value of the expression,
+// columns and aggregations are selectors by themselves
+func newSelector(exp ValueExp, as string) Selector {
+	sel, ok := exp.(Selector)
+	if !ok {
+		sel = &expSelector{exp: exp}
 	}
 
-	if db == nil {
-		if implicitDB == nil {
-			return nil, ErrNoDatabaseSelected
-		}
+	sel.setAlias(as)
 
-		db = implicitDB
-	}
+	return sel
+}
 
-	table, err := db.GetTableByName(stmt.table)
-	if err != nil {
-		return nil, err
-	}
+// expSelector projects the value of an arbitrary expression e.g. SELECT 1 + 2 or SELECT n * 2 FROM t,
+// expressions are reduced against every row except when grouping rows, where they are not supported
+type expSelector struct {
+	exp ValueExp
+	as  string
+}
 
-	return table, nil
+func (sel *expSelector) resolve(implicitDB, implicitTable string) (aggFn, db, table, col string) {
+	return "", implicitDB, implicitTable, sel.as
 }
 
-func (stmt *tableRef) inferParameters(e *Engine, implicitDB *Database, params map[string]SQLValueType) error {
-	return nil
+func (sel *expSelector) alias() string {
+	return sel.as
 }
 
-func (stmt *tableRef) Resolve(e *Engine, snap *store.Snapshot, implicitDB *Database, params map[string]interface{}, scanSpecs *ScanSpecs) (RowReader, error) {
-	if e == nil || snap == nil {
-		return nil, ErrIllegalArguments
-	}
+func (sel *expSelector) setAlias(alias string) {
+	sel.as = alias
+}
 
-	table, err := stmt.referencedTable(e, implicitDB)
-	if err != nil {
-		return nil, err
-	}
+func (sel *expSelector) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	return sel.exp.inferType(cols, params, implicitDB, implicitTable)
+}
 
-	asBefore := stmt.asBefore
-	if asBefore == 0 {
-		asBefore = e.snapAsBeforeTx
-	}
+func (sel *expSelector) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	return sel.exp.requiresType(t, cols, params, implicitDB, implicitTable)
+}
 
-	return e.newRawRowReader(snap, table, asBefore, stmt.as, scanSpecs)
+func (sel *expSelector) substitute(params map[string]interface{}) (ValueExp, error) {
+	return sel.exp.substitute(params)
 }
 
-func (stmt *tableRef) Alias() string {
-	if stmt.as == "" {
-		return stmt.table
-	}
-	return stmt.as
+func (sel *expSelector) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	return sel.exp.reduce(catalog, row, implicitDB, implicitTable)
 }
 
-type JoinSpec struct {
-	joinType JoinType
-	ds       DataSource
-	cond     ValueExp
-	indexOn  []string
+func (sel *expSelector) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return sel.exp.reduceSelectors(row, implicitDB, implicitTable)
 }
 
-type OrdCol struct {
-	sel       *ColSelector
-	descOrder bool
+func (sel *expSelector) isConstant() bool {
+	return sel.exp.isConstant()
 }
 
-type Selector interface {
-	ValueExp
-	resolve(implicitDB, implicitTable string) (aggFn, db, table, col string)
-	alias() string
-	setAlias(alias string)
+func (sel *expSelector) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
 }
 
-type ColSelector struct {
+type AggColSelector struct {
+	aggFn AggregateFn
 	db    string
 	table string
 	col   string
 	as    string
+
+	// argument of the aggregation when it's not a column, col holds its textual form
+	exp ValueExp
 }
 
-func (sel *ColSelector) resolve(implicitDB, implicitTable string) (aggFn, db, table, col string) {
+// newAggColSelector keeps the aggregation of a column as such, any other argument is reduced for every aggregated row
+func newAggColSelector(aggFn AggregateFn, exp ValueExp) (*AggColSelector, error) {
+	col, ok := exp.(*ColSelector)
+	if ok {
+		return &AggColSelector{aggFn: aggFn, db: col.db, table: col.table, col: col.col}, nil
+	}
+
+	str, err := expString(exp)
+	if err != nil {
+		// the selector is returned anyway so parsing can go on until the error is reported
+		return &AggColSelector{aggFn: aggFn, exp: exp}, fmt.Errorf("%w: %s argument must only contain values and columns", ErrNoSupported, aggFn)
+	}
+
+	_, isNumExp := exp.(*NumExp)
+	if isNumExp {
+		// outer parentheses are omitted e.g. sum(a * b)
+		str = str[1 : len(str)-1]
+	}
+
+	return &AggColSelector{aggFn: aggFn, col: str, exp: exp}, nil
+}
+
+func EncodeSelector(aggFn, db, table, col string) string {
+	return aggFn + "(" + db + "." + table + "." + col + ")"
+}
+
+func (sel *AggColSelector) resolve(implicitDB, implicitTable string) (aggFn, db, table, col string) {
 	db = implicitDB
 	if sel.db != "" {
 		db = sel.db
@@ -2096,178 +4914,336 @@ func (sel *ColSelector) resolve(implicitDB, implicitTable string) (aggFn, db, ta
 		table = sel.table
 	}
 
-	return "", db, table, sel.col
+	return sel.aggFn, db, table, sel.col
 }
 
-func (sel *ColSelector) alias() string {
+func (sel *AggColSelector) alias() string {
 	if sel.as == "" {
-		return sel.col
+		// unaliased aggregations are named after the function and its column e.g. sum(age)
+		return fmt.Sprintf("%s(%s)", strings.ToLower(sel.aggFn), sel.col)
 	}
 
 	return sel.as
 }
 
-func (sel *ColSelector) setAlias(alias string) {
+func (sel *AggColSelector) setAlias(alias string) {
 	sel.as = alias
 }
 
-func (sel *ColSelector) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
-	_, db, table, col := sel.resolve(implicitDB, implicitTable)
-	encSel := EncodeSelector("", db, table, col)
-
-	desc, ok := cols[encSel]
-	if !ok {
-		return AnyType, fmt.Errorf("%w (%s)", ErrColumnDoesNotExist, col)
+// arg returns the aggregated expression
+func (sel *AggColSelector) arg() ValueExp {
+	if sel.exp != nil {
+		return sel.exp
 	}
 
-	return desc.Type, nil
+	return &ColSelector{db: sel.db, table: sel.table, col: sel.col}
 }
 
-func (sel *ColSelector) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
-	_, db, table, col := sel.resolve(implicitDB, implicitTable)
-	encSel := EncodeSelector("", db, table, col)
+func (sel *AggColSelector) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	if sel.aggFn == COUNT {
+		return IntegerType, nil
+	}
 
-	desc, ok := cols[encSel]
-	if !ok {
-		return fmt.Errorf("%w (%s)", ErrColumnDoesNotExist, col)
+	if sel.aggFn == SUM || sel.aggFn == AVG {
+		err := sel.arg().requiresType(IntegerType, cols, params, implicitDB, implicitTable)
+		if err != nil {
+			return AnyType, ErrInvalidTypes
+		}
+
+		return IntegerType, nil
 	}
 
-	if desc.Type != t {
-		return ErrInvalidTypes
+	return sel.arg().inferType(cols, params, implicitDB, implicitTable)
+}
+
+func (sel *AggColSelector) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	if sel.aggFn == COUNT {
+		if t != IntegerType {
+			return ErrInvalidTypes
+		}
+		return nil
 	}
 
-	return nil
+	if sel.aggFn == SUM || sel.aggFn == AVG {
+		return sel.arg().requiresType(IntegerType, cols, params, implicitDB, implicitTable)
+	}
+
+	return sel.arg().requiresType(t, cols, params, implicitDB, implicitTable)
 }
 
-func (sel *ColSelector) substitute(params map[string]interface{}) (ValueExp, error) {
+func (sel *AggColSelector) substitute(params map[string]interface{}) (ValueExp, error) {
 	return sel, nil
 }
 
-func (sel *ColSelector) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
-	if row == nil {
-		return nil, ErrInvalidValue
-	}
-
+func (sel *AggColSelector) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
 	aggFn, db, table, col := sel.resolve(implicitDB, implicitTable)
 
 	v, ok := row.Values[EncodeSelector(aggFn, db, table, col)]
 	if !ok {
-		return nil, ErrColumnDoesNotExist
+		return nil, fmt.Errorf("%w: %s(%s.%s.%s)", ErrColumnDoesNotExist, aggFn, db, table, col)
 	}
-
 	return v, nil
 }
 
-func (sel *ColSelector) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
-	aggFn, db, table, col := sel.resolve(implicitDB, implicitTable)
+func (sel *AggColSelector) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return sel
+}
 
-	v, ok := row.Values[EncodeSelector(aggFn, db, table, col)]
-	if !ok {
-		return sel
+func (sel *AggColSelector) isConstant() bool {
+	return false
+}
+
+func (sel *AggColSelector) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
+
+// NegExp is the arithmetic negation of a numeric expression i.e. -exp
+type NegExp struct {
+	exp ValueExp
+}
+
+func (bexp *NegExp) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	err := bexp.exp.requiresType(IntegerType, cols, params, implicitDB, implicitTable)
+	if err != nil {
+		return AnyType, err
 	}
 
-	return v
+	return IntegerType, nil
 }
 
-func (sel *ColSelector) isConstant() bool {
-	return false
+func (bexp *NegExp) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	if t != IntegerType {
+		return ErrInvalidTypes
+	}
+
+	return bexp.exp.requiresType(IntegerType, cols, params, implicitDB, implicitTable)
 }
 
-func (sel *ColSelector) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+func (bexp *NegExp) substitute(params map[string]interface{}) (ValueExp, error) {
+	rexp, err := bexp.exp.substitute(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NegExp{exp: rexp}, nil
+}
+
+func (bexp *NegExp) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	v, err := bexp.exp.reduce(catalog, row, implicitDB, implicitTable)
+	if err != nil {
+		return nil, err
+	}
+
+	_, isNull := v.(*NullValue)
+	if isNull {
+		return &NullValue{t: IntegerType}, nil
+	}
+
+	n, isNumber := v.Value().(int64)
+	if !isNumber || v.Type() != IntegerType {
+		return nil, fmt.Errorf("%w (expecting numeric value)", ErrInvalidValue)
+	}
+
+	return &Number{val: -n}, nil
+}
+
+func (bexp *NegExp) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return &NegExp{exp: bexp.exp.reduceSelectors(row, implicitDB, implicitTable)}
+}
+
+func (bexp *NegExp) isConstant() bool {
+	return bexp.exp.isConstant()
+}
+
+func (bexp *NegExp) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
 	return nil
 }
 
-type AggColSelector struct {
-	aggFn AggregateFn
-	db    string
-	table string
-	col   string
-	as    string
+type whenThen struct {
+	when ValueExp
+	then ValueExp
+}
+
+// CaseExp evaluates to the result of the first branch whose condition holds,
+// or to the ELSE result (NULL if not provided) when none of them does
+type CaseExp struct {
+	whenThens []*whenThen
+	elseExp   ValueExp
+}
+
+func (bexp *CaseExp) results() []ValueExp {
+	results := make([]ValueExp, 0, len(bexp.whenThens)+1)
+
+	for _, wt := range bexp.whenThens {
+		results = append(results, wt.then)
+	}
+
+	if bexp.elseExp != nil {
+		results = append(results, bexp.elseExp)
+	}
+
+	return results
+}
+
+func (bexp *CaseExp) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	for _, wt := range bexp.whenThens {
+		err := wt.when.requiresType(BooleanType, cols, params, implicitDB, implicitTable)
+		if err != nil {
+			return AnyType, err
+		}
+	}
+
+	t := AnyType
+
+	for _, r := range bexp.results() {
+		rt, err := r.inferType(cols, params, implicitDB, implicitTable)
+		if err != nil {
+			return AnyType, err
+		}
+
+		if rt == AnyType {
+			continue
+		}
+
+		if t != AnyType && t != rt {
+			return AnyType, fmt.Errorf("%w: CASE branches of types %s and %s", ErrInvalidTypes, t, rt)
+		}
+
+		t = rt
+	}
+
+	return t, nil
 }
 
-func EncodeSelector(aggFn, db, table, col string) string {
-	return aggFn + "(" + db + "." + table + "." + col + ")"
+func (bexp *CaseExp) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	for _, wt := range bexp.whenThens {
+		err := wt.when.requiresType(BooleanType, cols, params, implicitDB, implicitTable)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, r := range bexp.results() {
+		err := r.requiresType(t, cols, params, implicitDB, implicitTable)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
-func (sel *AggColSelector) resolve(implicitDB, implicitTable string) (aggFn, db, table, col string) {
-	db = implicitDB
-	if sel.db != "" {
-		db = sel.db
+func (bexp *CaseExp) substitute(params map[string]interface{}) (ValueExp, error) {
+	whenThens := make([]*whenThen, len(bexp.whenThens))
+
+	for i, wt := range bexp.whenThens {
+		when, err := wt.when.substitute(params)
+		if err != nil {
+			return nil, err
+		}
+
+		then, err := wt.then.substitute(params)
+		if err != nil {
+			return nil, err
+		}
+
+		whenThens[i] = &whenThen{when: when, then: then}
 	}
 
-	table = implicitTable
-	if sel.table != "" {
-		table = sel.table
+	var elseExp ValueExp
+
+	if bexp.elseExp != nil {
+		var err error
+
+		elseExp, err = bexp.elseExp.substitute(params)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	return sel.aggFn, db, table, sel.col
+	return &CaseExp{whenThens: whenThens, elseExp: elseExp}, nil
 }
 
-func (sel *AggColSelector) alias() string {
-	return sel.as
-}
+func (bexp *CaseExp) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	for _, wt := range bexp.whenThens {
+		cond, err := wt.when.reduce(catalog, row, implicitDB, implicitTable)
+		if err != nil {
+			return nil, err
+		}
 
-func (sel *AggColSelector) setAlias(alias string) {
-	sel.as = alias
-}
+		nval, isNull := cond.(*NullValue)
+		if isNull && (nval.Type() == BooleanType || nval.Type() == AnyType) {
+			continue
+		}
 
-func (sel *AggColSelector) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
-	if sel.aggFn == COUNT {
-		return IntegerType, nil
-	}
+		satisfied, isBool := cond.(*Bool)
+		if !isBool {
+			return nil, ErrInvalidCondition
+		}
 
-	colSelector := &ColSelector{db: sel.db, table: sel.table, col: sel.col}
+		if satisfied.val {
+			return wt.then.reduce(catalog, row, implicitDB, implicitTable)
+		}
+	}
 
-	if sel.aggFn == SUM || sel.aggFn == AVG {
-		err := colSelector.requiresType(IntegerType, cols, params, implicitDB, implicitTable)
+	if bexp.elseExp == nil {
+		// the NULL result has the type the branches would produce over the same row
+		t, err := bexp.inferType(rowColDescriptors(row), map[string]SQLValueType{}, implicitDB, implicitTable)
 		if err != nil {
-			return AnyType, ErrInvalidTypes
+			return nil, err
 		}
 
-		return IntegerType, nil
+		return &NullValue{t: t}, nil
 	}
 
-	return colSelector.inferType(cols, params, implicitDB, implicitTable)
+	return bexp.elseExp.reduce(catalog, row, implicitDB, implicitTable)
 }
 
-func (sel *AggColSelector) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
-	if sel.aggFn == COUNT {
-		if t != IntegerType {
-			return ErrInvalidTypes
-		}
+// rowColDescriptors describes the columns of the row by the types of their values
+func rowColDescriptors(row *Row) map[string]ColDescriptor {
+	if row == nil {
 		return nil
 	}
 
-	colSelector := &ColSelector{db: sel.db, table: sel.table, col: sel.col}
+	cols := make(map[string]ColDescriptor, len(row.Values))
 
-	if sel.aggFn == SUM || sel.aggFn == AVG {
-		return colSelector.requiresType(IntegerType, cols, params, implicitDB, implicitTable)
+	for encSel, val := range row.Values {
+		cols[encSel] = ColDescriptor{Type: val.Type()}
 	}
 
-	return colSelector.requiresType(t, cols, params, implicitDB, implicitTable)
+	return cols
 }
 
-func (sel *AggColSelector) substitute(params map[string]interface{}) (ValueExp, error) {
-	return sel, nil
-}
+func (bexp *CaseExp) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	whenThens := make([]*whenThen, len(bexp.whenThens))
 
-func (sel *AggColSelector) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
-	v, ok := row.Values[EncodeSelector(sel.resolve(implicitDB, implicitTable))]
-	if !ok {
-		return nil, ErrColumnDoesNotExist
+	for i, wt := range bexp.whenThens {
+		whenThens[i] = &whenThen{
+			when: wt.when.reduceSelectors(row, implicitDB, implicitTable),
+			then: wt.then.reduceSelectors(row, implicitDB, implicitTable),
+		}
 	}
-	return v, nil
-}
 
-func (sel *AggColSelector) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
-	return sel
+	var elseExp ValueExp
+
+	if bexp.elseExp != nil {
+		elseExp = bexp.elseExp.reduceSelectors(row, implicitDB, implicitTable)
+	}
+
+	return &CaseExp{whenThens: whenThens, elseExp: elseExp}
 }
 
-func (sel *AggColSelector) isConstant() bool {
-	return false
+func (bexp *CaseExp) isConstant() bool {
+	for _, wt := range bexp.whenThens {
+		if !wt.when.isConstant() || !wt.then.isConstant() {
+			return false
+		}
+	}
+
+	return bexp.elseExp == nil || bexp.elseExp.isConstant()
 }
 
-func (sel *AggColSelector) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+func (bexp *CaseExp) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
 	return nil
 }
 
@@ -2319,10 +5295,11 @@ func (bexp *NumExp) substitute(params map[string]interface{}) (ValueExp, error)
 		return nil, err
 	}
 
-	bexp.left = rlexp
-	bexp.right = rrexp
-
-	return bexp, nil
+	return &NumExp{
+		op:    bexp.op,
+		left:  rlexp,
+		right: rrexp,
+	}, nil
 }
 
 func (bexp *NumExp) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
@@ -2336,6 +5313,13 @@ func (bexp *NumExp) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable
 		return nil, err
 	}
 
+	_, isNullL := vl.(*NullValue)
+	_, isNullR := vr.(*NullValue)
+	if isNullL || isNullR {
+		// arithmetic with NULL operands results in NULL e.g. 5 / NULL
+		return &NullValue{t: IntegerType}, nil
+	}
+
 	nl, isNumber := vl.Value().(int64)
 	if !isNumber {
 		return nil, fmt.Errorf("%w (expecting numeric value)", ErrInvalidValue)
@@ -2361,12 +5345,22 @@ func (bexp *NumExp) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable
 				return nil, ErrDivisionByZero
 			}
 
+			// integer division truncates toward zero e.g. -7 / 2 = -3
 			return &Number{val: nl / nr}, nil
 		}
 	case MULTOP:
 		{
 			return &Number{val: nl * nr}, nil
 		}
+	case MODOP:
+		{
+			if nr == 0 {
+				return nil, ErrDivisionByZero
+			}
+
+			// the remainder takes the sign of the dividend, consistent with truncated division e.g. -7 % 2 = -1
+			return &Number{val: nl % nr}, nil
+		}
 	}
 
 	return nil, ErrUnexpected
@@ -2415,9 +5409,7 @@ func (bexp *NotBoolExp) substitute(params map[string]interface{}) (ValueExp, err
 		return nil, err
 	}
 
-	bexp.exp = rexp
-
-	return bexp, nil
+	return &NotBoolExp{exp: rexp}, nil
 }
 
 func (bexp *NotBoolExp) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
@@ -2426,6 +5418,11 @@ func (bexp *NotBoolExp) reduce(catalog *Catalog, row *Row, implicitDB, implicitT
 		return nil, err
 	}
 
+	_, isNull := v.(*NullValue)
+	if isNull {
+		return &NullValue{t: BooleanType}, nil
+	}
+
 	r, isBool := v.Value().(bool)
 	if !isBool {
 		return nil, ErrInvalidCondition
@@ -2448,10 +5445,101 @@ func (bexp *NotBoolExp) selectorRanges(table *Table, asTable string, params map[
 	return nil
 }
 
+// IsNullBoolExp checks whether the value of the expression is NULL i.e. exp IS [NOT] NULL
+type IsNullBoolExp struct {
+	exp ValueExp
+	not bool
+}
+
+func (bexp *IsNullBoolExp) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
+	_, err := bexp.exp.inferType(cols, params, implicitDB, implicitTable)
+	if err != nil {
+		return AnyType, err
+	}
+
+	return BooleanType, nil
+}
+
+func (bexp *IsNullBoolExp) requiresType(t SQLValueType, cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) error {
+	if t != BooleanType {
+		return ErrInvalidTypes
+	}
+
+	_, err := bexp.inferType(cols, params, implicitDB, implicitTable)
+
+	return err
+}
+
+func (bexp *IsNullBoolExp) substitute(params map[string]interface{}) (ValueExp, error) {
+	rexp, err := bexp.exp.substitute(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &IsNullBoolExp{exp: rexp, not: bexp.not}, nil
+}
+
+func (bexp *IsNullBoolExp) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
+	v, err := bexp.exp.reduce(catalog, row, implicitDB, implicitTable)
+	if err != nil {
+		return nil, err
+	}
+
+	_, isNull := v.(*NullValue)
+
+	return &Bool{val: isNull != bexp.not}, nil
+}
+
+func (bexp *IsNullBoolExp) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
+	return &IsNullBoolExp{
+		exp: bexp.exp.reduceSelectors(row, implicitDB, implicitTable),
+		not: bexp.not,
+	}
+}
+
+func (bexp *IsNullBoolExp) isConstant() bool {
+	return bexp.exp.isConstant()
+}
+
+func (bexp *IsNullBoolExp) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
+	return nil
+}
+
 type LikeBoolExp struct {
-	val     ValueExp
-	notLike bool
-	pattern ValueExp
+	val             ValueExp
+	notLike         bool
+	caseInsensitive bool
+	pattern         ValueExp
+
+	// compiled pattern, only set once the pattern is known to be constant
+	re *regexp.Regexp
+}
+
+// likeRegexp translates a pattern with SQL wildcards into an anchored regular expression,
+// '%' matches any sequence of characters and '_' any single one, everything else is literal
+func likeRegexp(pattern string, caseInsensitive bool) (*regexp.Regexp, error) {
+	var b strings.Builder
+
+	if caseInsensitive {
+		b.WriteString("(?i)")
+	}
+
+	b.WriteString("(?s)^")
+
+	for _, c := range pattern {
+		switch c {
+		case '%':
+			b.WriteString(".*")
+		case '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	b.WriteString("$")
+
+	return regexp.Compile(b.String())
 }
 
 func (bexp *LikeBoolExp) inferType(cols map[string]ColDescriptor, params map[string]SQLValueType, implicitDB, implicitTable string) (SQLValueType, error) {
@@ -2464,6 +5552,11 @@ func (bexp *LikeBoolExp) inferType(cols map[string]ColDescriptor, params map[str
 		return AnyType, fmt.Errorf("error in 'LIKE' clause: %w", err)
 	}
 
+	err = bexp.val.requiresType(VarcharType, cols, params, implicitDB, implicitTable)
+	if err != nil {
+		return AnyType, fmt.Errorf("error in 'LIKE' clause: %w", err)
+	}
+
 	return BooleanType, nil
 }
 
@@ -2476,12 +5569,9 @@ func (bexp *LikeBoolExp) requiresType(t SQLValueType, cols map[string]ColDescrip
 		return fmt.Errorf("error using the value of the LIKE operator as %s: %w", t, ErrInvalidTypes)
 	}
 
-	err := bexp.pattern.requiresType(VarcharType, cols, params, implicitDB, implicitTable)
-	if err != nil {
-		return fmt.Errorf("error in 'LIKE' clause: %w", err)
-	}
+	_, err := bexp.inferType(cols, params, implicitDB, implicitTable)
 
-	return nil
+	return err
 }
 
 func (bexp *LikeBoolExp) substitute(params map[string]interface{}) (ValueExp, error) {
@@ -2499,10 +5589,22 @@ func (bexp *LikeBoolExp) substitute(params map[string]interface{}) (ValueExp, er
 		return nil, fmt.Errorf("error in 'LIKE' clause: %w", err)
 	}
 
+	var re *regexp.Regexp
+
+	vpattern, isConstant := pattern.(*Varchar)
+	if isConstant {
+		re, err = likeRegexp(vpattern.val, bexp.caseInsensitive)
+		if err != nil {
+			return nil, fmt.Errorf("error in 'LIKE' clause: %w", err)
+		}
+	}
+
 	return &LikeBoolExp{
-		val:     val,
-		notLike: bexp.notLike,
-		pattern: pattern,
+		val:             val,
+		notLike:         bexp.notLike,
+		caseInsensitive: bexp.caseInsensitive,
+		pattern:         pattern,
+		re:              re,
 	}, nil
 }
 
@@ -2516,7 +5618,9 @@ func (bexp *LikeBoolExp) reduce(catalog *Catalog, row *Row, implicitDB, implicit
 		return nil, fmt.Errorf("error in 'LIKE' clause: %w", err)
 	}
 
-	if rval.Type() != VarcharType {
+	_, isNullVal := rval.(*NullValue)
+
+	if !isNullVal && rval.Type() != VarcharType {
 		return nil, fmt.Errorf("error in 'LIKE' clause: %w (expecting %s)", ErrInvalidTypes, VarcharType)
 	}
 
@@ -2525,20 +5629,43 @@ func (bexp *LikeBoolExp) reduce(catalog *Catalog, row *Row, implicitDB, implicit
 		return nil, fmt.Errorf("error in 'LIKE' clause: %w", err)
 	}
 
-	if rpattern.Type() != VarcharType {
+	_, isNullPattern := rpattern.(*NullValue)
+
+	if !isNullPattern && rpattern.Type() != VarcharType {
 		return nil, fmt.Errorf("error evaluating 'LIKE' clause: %w", ErrInvalidTypes)
 	}
 
-	matched, err := regexp.MatchString(rpattern.Value().(string), rval.Value().(string))
-	if err != nil {
-		return nil, fmt.Errorf("error in 'LIKE' clause: %w", err)
+	// matching a NULL value or pattern is unknown
+	if isNullVal || isNullPattern {
+		return &NullValue{t: BooleanType}, nil
+	}
+
+	re := bexp.re
+
+	if re == nil {
+		re, err = likeRegexp(rpattern.Value().(string), bexp.caseInsensitive)
+		if err != nil {
+			return nil, fmt.Errorf("error in 'LIKE' clause: %w", err)
+		}
 	}
 
+	matched := re.MatchString(rval.Value().(string))
+
 	return &Bool{val: matched != bexp.notLike}, nil
 }
 
 func (bexp *LikeBoolExp) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
-	return bexp
+	if bexp.val == nil || bexp.pattern == nil {
+		return bexp
+	}
+
+	return &LikeBoolExp{
+		val:             bexp.val.reduceSelectors(row, implicitDB, implicitTable),
+		notLike:         bexp.notLike,
+		caseInsensitive: bexp.caseInsensitive,
+		pattern:         bexp.pattern.reduceSelectors(row, implicitDB, implicitTable),
+		re:              bexp.re,
+	}
 }
 
 func (bexp *LikeBoolExp) isConstant() bool {
@@ -2567,7 +5694,7 @@ func (bexp *CmpBoolExp) inferType(cols map[string]ColDescriptor, params map[stri
 
 	// unification step
 
-	if tleft == tright {
+	if tleft == tright || timestampCompatible(tleft, tright) {
 		return BooleanType, nil
 	}
 
@@ -2613,10 +5740,11 @@ func (bexp *CmpBoolExp) substitute(params map[string]interface{}) (ValueExp, err
 		return nil, err
 	}
 
-	bexp.left = rlexp
-	bexp.right = rrexp
-
-	return bexp, nil
+	return &CmpBoolExp{
+		op:    bexp.op,
+		left:  rlexp,
+		right: rrexp,
+	}, nil
 }
 
 func (bexp *CmpBoolExp) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
@@ -2630,6 +5758,17 @@ func (bexp *CmpBoolExp) reduce(catalog *Catalog, row *Row, implicitDB, implicitT
 		return nil, err
 	}
 
+	_, isNullL := vl.(*NullValue)
+	_, isNullR := vr.(*NullValue)
+	if isNullL || isNullR {
+		if vl.Type() != AnyType && vr.Type() != AnyType && vl.Type() != vr.Type() && !timestampCompatible(vl.Type(), vr.Type()) {
+			return nil, ErrNotComparableValues
+		}
+
+		// comparisons with NULL are unknown, thus not satisfied in conditions e.g. col = NULL
+		return &NullValue{t: BooleanType}, nil
+	}
+
 	r, err := vl.Compare(vr)
 	if err != nil {
 		return nil, err
@@ -2652,16 +5791,20 @@ func (bexp *CmpBoolExp) isConstant() bool {
 
 func (bexp *CmpBoolExp) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
 	matchingFunc := func(left, right ValueExp) (*ColSelector, ValueExp, bool) {
-		s, isSel := bexp.left.(*ColSelector)
-		if isSel && bexp.right.isConstant() {
+		s, isSel := left.(*ColSelector)
+		if isSel && right.isConstant() {
 			return s, right, true
 		}
 		return nil, nil, false
 	}
 
+	op := bexp.op
+
 	sel, c, ok := matchingFunc(bexp.left, bexp.right)
 	if !ok {
 		sel, c, ok = matchingFunc(bexp.right, bexp.left)
+		// the column is on the right side e.g. 10 < col, which is equivalent to col > 10
+		op = swapCmpOperator(op)
 	}
 
 	if !ok {
@@ -2674,6 +5817,10 @@ func (bexp *CmpBoolExp) selectorRanges(table *Table, asTable string, params map[
 	}
 
 	column, err := table.GetColumnByName(col)
+	if err == ErrColumnDoesNotExist && isSystemColumn(col) {
+		// system columns are not indexed
+		return nil
+	}
 	if err != nil {
 		return err
 	}
@@ -2692,7 +5839,28 @@ func (bexp *CmpBoolExp) selectorRanges(table *Table, asTable string, params map[
 		return err
 	}
 
-	return updateRangeFor(column.id, rval, bexp.op, rangesByColID)
+	_, isNull := rval.(*NullValue)
+	if isNull {
+		// no row satisfies a comparison with NULL, the condition is anyway evaluated on every row
+		return nil
+	}
+
+	return updateRangeFor(column.id, column.collate(rval), op, rangesByColID)
+}
+
+func swapCmpOperator(op CmpOperator) CmpOperator {
+	switch op {
+	case LT:
+		return GT
+	case LE:
+		return GE
+	case GT:
+		return LT
+	case GE:
+		return LE
+	}
+
+	return op
 }
 
 func updateRangeFor(colID uint32, val TypedValue, cmp CmpOperator, rangesByColID map[uint32]*typedValueRange) error {
@@ -2827,10 +5995,11 @@ func (bexp *BinBoolExp) substitute(params map[string]interface{}) (ValueExp, err
 		return nil, err
 	}
 
-	bexp.left = rlexp
-	bexp.right = rrexp
-
-	return bexp, nil
+	return &BinBoolExp{
+		op:    bexp.op,
+		left:  rlexp,
+		right: rrexp,
+	}, nil
 }
 
 func (bexp *BinBoolExp) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
@@ -2839,35 +6008,76 @@ func (bexp *BinBoolExp) reduce(catalog *Catalog, row *Row, implicitDB, implicitT
 		return nil, err
 	}
 
-	vr, err := bexp.right.reduce(catalog, row, implicitDB, implicitTable)
+	// NULL operands are unknown values, the result is only known when the other operand determines it
+	// e.g. FALSE AND NULL is FALSE while TRUE AND NULL is NULL
+	bl, isNullL, err := bexp.boolOperand(vl, "left")
 	if err != nil {
 		return nil, err
 	}
 
-	bl, isBool := vl.(*Bool)
-	if !isBool {
-		return nil, fmt.Errorf("%w (expecting boolean value)", ErrInvalidValue)
+	// the right operand is not evaluated when the left one already determines the result
+	if !isNullL && ((bexp.op == AND && !bl.val) || (bexp.op == OR && bl.val)) {
+		return &Bool{val: bl.val}, nil
 	}
 
-	br, isBool := vr.(*Bool)
-	if !isBool {
-		return nil, fmt.Errorf("%w (expecting boolean value)", ErrInvalidValue)
+	vr, err := bexp.right.reduce(catalog, row, implicitDB, implicitTable)
+	if err != nil {
+		return nil, err
+	}
+
+	br, isNullR, err := bexp.boolOperand(vr, "right")
+	if err != nil {
+		return nil, err
 	}
 
 	switch bexp.op {
 	case AND:
 		{
-			return &Bool{val: bl.val && br.val}, nil
+			if !isNullR && !br.val {
+				return &Bool{val: false}, nil
+			}
+
+			if isNullL || isNullR {
+				return &NullValue{t: BooleanType}, nil
+			}
+
+			return &Bool{val: true}, nil
 		}
 	case OR:
 		{
-			return &Bool{val: bl.val || br.val}, nil
+			if !isNullR && br.val {
+				return &Bool{val: true}, nil
+			}
+
+			if isNullL || isNullR {
+				return &NullValue{t: BooleanType}, nil
+			}
+
+			return &Bool{val: false}, nil
 		}
 	}
 
 	return nil, ErrUnexpected
 }
 
+func (bexp *BinBoolExp) boolOperand(v TypedValue, side string) (b *Bool, isNull bool, err error) {
+	if _, isNull := v.(*NullValue); isNull {
+		return nil, true, nil
+	}
+
+	b, isBool := v.(*Bool)
+	if !isBool {
+		op := "AND"
+		if bexp.op == OR {
+			op = "OR"
+		}
+
+		return nil, false, fmt.Errorf("%w (%s operand of %s is not a boolean value)", ErrInvalidCondition, side, op)
+	}
+
+	return b, false, nil
+}
+
 func (bexp *BinBoolExp) reduceSelectors(row *Row, implicitDB, implicitTable string) ValueExp {
 	return &BinBoolExp{
 		op:    bexp.op,
@@ -2914,7 +6124,17 @@ func (bexp *BinBoolExp) selectorRanges(table *Table, asTable string, params map[
 			return err
 		}
 
-		rangesByColID[colID] = lr
+		// ranges already set by other AND-ed conditions are kept
+		currRange, ranged := rangesByColID[colID]
+		if !ranged {
+			rangesByColID[colID] = lr
+			continue
+		}
+
+		err = currRange.refineWith(lr)
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -3028,13 +6248,29 @@ func (bexp *InListExp) substitute(params map[string]interface{}) (ValueExp, erro
 		return nil, fmt.Errorf("error evaluating 'IN' clause: %w", err)
 	}
 
-	values := make([]ValueExp, len(bexp.values))
+	values := make([]ValueExp, 0, len(bexp.values))
 
-	for i, val := range bexp.values {
-		values[i], err = val.substitute(params)
+	for _, val := range bexp.values {
+		// a parameter bound to a slice is expanded into the elements of the list
+		param, isParam := val.(*Param)
+		if isParam {
+			elems, isList, err := param.substituteList(params)
+			if err != nil {
+				return nil, fmt.Errorf("error evaluating 'IN' clause: %w", err)
+			}
+
+			if isList {
+				values = append(values, elems...)
+				continue
+			}
+		}
+
+		v, err := val.substitute(params)
 		if err != nil {
 			return nil, fmt.Errorf("error evaluating 'IN' clause: %w", err)
 		}
+
+		values = append(values, v)
 	}
 
 	return &InListExp{
@@ -3044,13 +6280,15 @@ func (bexp *InListExp) substitute(params map[string]interface{}) (ValueExp, erro
 	}, nil
 }
 
+// reduce follows SQL three-valued logic: the result is NULL when the value is NULL or
+// when it does not match any element but the list contains a NULL element
 func (bexp *InListExp) reduce(catalog *Catalog, row *Row, implicitDB, implicitTable string) (TypedValue, error) {
 	rval, err := bexp.val.reduce(catalog, row, implicitDB, implicitTable)
 	if err != nil {
 		return nil, fmt.Errorf("error evaluating 'IN' clause: %w", err)
 	}
 
-	var found bool
+	var found, nullFound bool
 
 	for _, v := range bexp.values {
 		rv, err := v.reduce(catalog, row, implicitDB, implicitTable)
@@ -3058,6 +6296,12 @@ func (bexp *InListExp) reduce(catalog *Catalog, row *Row, implicitDB, implicitTa
 			return nil, fmt.Errorf("error evaluating 'IN' clause: %w", err)
 		}
 
+		_, isNull := rv.(*NullValue)
+		if isNull {
+			nullFound = true
+			continue
+		}
+
 		r, err := rval.Compare(rv)
 		if err != nil {
 			return nil, fmt.Errorf("error evaluating 'IN' clause: %w", err)
@@ -3069,6 +6313,11 @@ func (bexp *InListExp) reduce(catalog *Catalog, row *Row, implicitDB, implicitTa
 		}
 	}
 
+	_, isNull := rval.(*NullValue)
+	if isNull || (!found && nullFound) {
+		return &NullValue{t: BooleanType}, nil
+	}
+
 	return &Bool{val: found != bexp.notIn}, nil
 }
 
@@ -3081,6 +6330,7 @@ func (bexp *InListExp) reduceSelectors(row *Row, implicitDB, implicitTable strin
 
 	return &InListExp{
 		val:    bexp.val.reduceSelectors(row, implicitDB, implicitTable),
+		notIn:  bexp.notIn,
 		values: values,
 	}
 }
@@ -3090,6 +6340,7 @@ func (bexp *InListExp) isConstant() bool {
 }
 
 func (bexp *InListExp) selectorRanges(table *Table, asTable string, params map[string]interface{}, rangesByColID map[uint32]*typedValueRange) error {
-	// TODO: may be determiined by smallest and bigggest value in the list
+	// NOT IN excludes isolated values, thus it can not narrow down a single range and a full scan is required
+	// TODO: for IN, may be determiined by smallest and bigggest value in the list
 	return nil
 }
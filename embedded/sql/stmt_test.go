@@ -16,8 +16,10 @@ limitations under the License.
 package sql
 
 import (
+	"encoding/json"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -351,7 +353,16 @@ func TestRequiresTypeSimpleValueExp(t *testing.T) {
 			expectedError: ErrInvalidTypes,
 		},
 		{
-			exp:           &LikeBoolExp{val: &ColSelector{col: "col1"}, pattern: &Varchar{val: ""}},
+			exp:           &LikeBoolExp{val: &ColSelector{col: "title"}, pattern: &Varchar{val: ""}},
+			cols:          cols,
+			params:        params,
+			implicitDB:    "db1",
+			implicitTable: "mytable",
+			requiredType:  BooleanType,
+			expectedError: nil,
+		},
+		{
+			exp:           &LikeBoolExp{val: &FnCall{fn: "lower", params: []ValueExp{&ColSelector{col: "title"}}}, pattern: &Varchar{val: ""}},
 			cols:          cols,
 			params:        params,
 			implicitDB:    "db1",
@@ -359,6 +370,15 @@ func TestRequiresTypeSimpleValueExp(t *testing.T) {
 			requiredType:  BooleanType,
 			expectedError: nil,
 		},
+		{
+			exp:           &LikeBoolExp{val: &ColSelector{col: "id"}, pattern: &Varchar{val: ""}},
+			cols:          cols,
+			params:        params,
+			implicitDB:    "db1",
+			implicitTable: "mytable",
+			requiredType:  BooleanType,
+			expectedError: ErrInvalidTypes,
+		},
 		{
 			exp:           &LikeBoolExp{val: &ColSelector{col: "col1"}, pattern: &Varchar{val: ""}},
 			cols:          cols,
@@ -636,12 +656,503 @@ func TestLikeBoolExpEdgeCases(t *testing.T) {
 
 }
 
+func TestLikeBoolExpWildcards(t *testing.T) {
+	row := &Row{Values: map[string]TypedValue{
+		"(db1.table1.title)": &Varchar{val: "title1.txt"},
+	}}
+
+	testCases := []struct {
+		pattern  string
+		expected bool
+	}{
+		{pattern: "title%", expected: true},
+		{pattern: "%.txt", expected: true},
+		{pattern: "%", expected: true},
+		{pattern: "title", expected: false},
+		{pattern: "itle%", expected: false},
+		{pattern: "title_.txt", expected: true},
+		{pattern: "title__txt", expected: true},
+		{pattern: "title_", expected: false},
+		{pattern: "title1.txt", expected: true},
+		{pattern: "title1_txt", expected: true},
+		{pattern: "title1.tx.", expected: false},
+		{pattern: "title1xtxt", expected: false},
+		{pattern: "t.*", expected: false},
+		{pattern: "[t]itle%", expected: false},
+	}
+
+	for i, tc := range testCases {
+		exp, err := (&LikeBoolExp{val: &ColSelector{col: "title"}, pattern: &Varchar{val: tc.pattern}}).substitute(nil)
+		require.NoError(t, err, fmt.Sprintf("failed on iteration %d", i))
+		require.NotNil(t, exp.(*LikeBoolExp).re, fmt.Sprintf("failed on iteration %d", i))
+
+		v, err := exp.reduce(nil, row, "db1", "table1")
+		require.NoError(t, err, fmt.Sprintf("failed on iteration %d", i))
+		require.Equal(t, &Bool{val: tc.expected}, v, fmt.Sprintf("failed on iteration %d", i))
+	}
+
+	t.Run("not like excludes matching values", func(t *testing.T) {
+		for i, pattern := range []string{"title%", "%.txt", "title_.txt"} {
+			exp, err := (&LikeBoolExp{val: &ColSelector{col: "title"}, notLike: true, pattern: &Varchar{val: pattern}}).substitute(nil)
+			require.NoError(t, err, fmt.Sprintf("failed on iteration %d", i))
+
+			v, err := exp.reduce(nil, row, "db1", "table1")
+			require.NoError(t, err, fmt.Sprintf("failed on iteration %d", i))
+			require.Equal(t, &Bool{val: false}, v, fmt.Sprintf("failed on iteration %d", i))
+		}
+
+		exp := &LikeBoolExp{val: &ColSelector{col: "title"}, notLike: true, pattern: &Varchar{val: "other%"}}
+
+		v, err := exp.reduce(nil, row, "db1", "table1")
+		require.NoError(t, err)
+		require.Equal(t, &Bool{val: true}, v)
+	})
+
+	t.Run("ilike matches regardless of case", func(t *testing.T) {
+		for i, pattern := range []string{"TITLE%", "%.TxT", "Title1.txt"} {
+			exp, err := (&LikeBoolExp{val: &ColSelector{col: "title"}, caseInsensitive: true, pattern: &Varchar{val: pattern}}).substitute(nil)
+			require.NoError(t, err, fmt.Sprintf("failed on iteration %d", i))
+
+			v, err := exp.reduce(nil, row, "db1", "table1")
+			require.NoError(t, err, fmt.Sprintf("failed on iteration %d", i))
+			require.Equal(t, &Bool{val: true}, v, fmt.Sprintf("failed on iteration %d", i))
+
+			exp = &LikeBoolExp{val: &ColSelector{col: "title"}, pattern: &Varchar{val: pattern}}
+
+			v, err = exp.reduce(nil, row, "db1", "table1")
+			require.NoError(t, err, fmt.Sprintf("failed on iteration %d", i))
+			require.Equal(t, &Bool{val: false}, v, fmt.Sprintf("failed on iteration %d", i))
+		}
+
+		exp := &LikeBoolExp{val: &ColSelector{col: "title"}, notLike: true, caseInsensitive: true, pattern: &Varchar{val: "TITLE%"}}
+
+		v, err := exp.reduce(nil, row, "db1", "table1")
+		require.NoError(t, err)
+		require.Equal(t, &Bool{val: false}, v)
+	})
+
+	t.Run("like with a NULL value or pattern is unknown", func(t *testing.T) {
+		row := &Row{Values: map[string]TypedValue{
+			"(db1.table1.title)": &Varchar{val: "title1.txt"},
+			"(db1.table1.descr)": &NullValue{t: VarcharType},
+		}}
+
+		testCases := []*LikeBoolExp{
+			{val: &ColSelector{col: "descr"}, pattern: &Varchar{val: "title%"}},
+			{val: &ColSelector{col: "descr"}, notLike: true, pattern: &Varchar{val: "title%"}},
+			{val: &ColSelector{col: "descr"}, caseInsensitive: true, pattern: &Varchar{val: "TITLE%"}},
+			{val: &ColSelector{col: "title"}, pattern: &NullValue{t: AnyType}},
+			{val: &ColSelector{col: "title"}, notLike: true, pattern: &NullValue{t: VarcharType}},
+			{val: &ColSelector{col: "title"}, caseInsensitive: true, pattern: &ColSelector{col: "descr"}},
+			{val: &ColSelector{col: "descr"}, pattern: &NullValue{t: AnyType}},
+		}
+
+		for i, tc := range testCases {
+			exp, err := tc.substitute(nil)
+			require.NoError(t, err, fmt.Sprintf("failed on iteration %d", i))
+
+			v, err := exp.reduce(nil, row, "db1", "table1")
+			require.NoError(t, err, fmt.Sprintf("failed on iteration %d", i))
+			require.Equal(t, &NullValue{t: BooleanType}, v, fmt.Sprintf("failed on iteration %d", i))
+		}
+	})
+
+	t.Run("like applied to the result of a function call", func(t *testing.T) {
+		row := &Row{Values: map[string]TypedValue{
+			"(db1.table1.title)": &Varchar{val: "Title1.TXT"},
+		}}
+
+		exp, err := (&LikeBoolExp{
+			val:     &FnCall{fn: "lower", params: []ValueExp{&ColSelector{col: "title"}}},
+			pattern: &Varchar{val: "title_.txt"},
+		}).substitute(nil)
+		require.NoError(t, err)
+
+		v, err := exp.reduce(nil, row, "db1", "table1")
+		require.NoError(t, err)
+		require.Equal(t, &Bool{val: true}, v)
+
+		exp = exp.reduceSelectors(row, "db1", "table1")
+
+		v, err = exp.reduce(nil, nil, "db1", "table1")
+		require.NoError(t, err)
+		require.Equal(t, &Bool{val: true}, v)
+
+		exp = &LikeBoolExp{
+			val:     &FnCall{fn: "upper", params: []ValueExp{&ColSelector{col: "title"}}},
+			pattern: &Varchar{val: "title%"},
+		}
+
+		v, err = exp.reduce(nil, row, "db1", "table1")
+		require.NoError(t, err)
+		require.Equal(t, &Bool{val: false}, v)
+
+		exp = &LikeBoolExp{
+			val:     &FnCall{fn: "year", params: []ValueExp{&Cast{val: &Number{val: 0}, t: TimestampType}}},
+			pattern: &Varchar{val: "1970"},
+		}
+
+		_, err = exp.reduce(nil, row, "db1", "table1")
+		require.ErrorIs(t, err, ErrInvalidTypes)
+	})
+
+	t.Run("non-constant patterns are compiled when reduced", func(t *testing.T) {
+		exp := &LikeBoolExp{val: &ColSelector{col: "title"}, pattern: &ColSelector{col: "pattern"}}
+
+		v, err := exp.reduce(nil, &Row{Values: map[string]TypedValue{
+			"(db1.table1.title)":   &Varchar{val: "title1.txt"},
+			"(db1.table1.pattern)": &Varchar{val: "%1.t_t"},
+		}}, "db1", "table1")
+		require.NoError(t, err)
+		require.Equal(t, &Bool{val: true}, v)
+	})
+}
+
+func TestNegExp(t *testing.T) {
+	row := &Row{Values: map[string]TypedValue{
+		"(db1.table1.a)": &Number{val: 3},
+		"(db1.table1.b)": &Number{val: 4},
+		"(db1.table1.c)": &NullValue{t: IntegerType},
+		"(db1.table1.d)": &Varchar{val: "title"},
+	}}
+
+	cols := map[string]ColDescriptor{
+		"(db1.table1.a)": {Type: IntegerType},
+		"(db1.table1.b)": {Type: IntegerType},
+		"(db1.table1.d)": {Type: VarcharType},
+	}
+
+	sum := &NumExp{op: ADDOP, left: &ColSelector{col: "a"}, right: &ColSelector{col: "b"}}
+
+	testCases := []struct {
+		exp      ValueExp
+		expected TypedValue
+	}{
+		{exp: &NegExp{exp: &Number{val: 5}}, expected: &Number{val: -5}},
+		{exp: &NegExp{exp: sum}, expected: &Number{val: -7}},
+		{exp: &NegExp{exp: &NegExp{exp: sum}}, expected: &Number{val: 7}},
+		{exp: &NegExp{exp: &NegExp{exp: &Number{val: -5}}}, expected: &Number{val: -5}},
+		{exp: &NumExp{op: MULTOP, left: &NegExp{exp: &ColSelector{col: "a"}}, right: &ColSelector{col: "b"}}, expected: &Number{val: -12}},
+		{exp: &NegExp{exp: &ColSelector{col: "c"}}, expected: &NullValue{t: IntegerType}},
+	}
+
+	for i, tc := range testCases {
+		v, err := tc.exp.reduce(nil, row, "db1", "table1")
+		require.NoError(t, err, fmt.Sprintf("failed on iteration %d", i))
+		require.Equal(t, tc.expected, v, fmt.Sprintf("failed on iteration %d", i))
+	}
+
+	it, err := (&NegExp{exp: sum}).inferType(cols, nil, "db1", "table1")
+	require.NoError(t, err)
+	require.Equal(t, IntegerType, it)
+
+	err = (&NegExp{exp: sum}).requiresType(IntegerType, cols, nil, "db1", "table1")
+	require.NoError(t, err)
+
+	err = (&NegExp{exp: sum}).requiresType(VarcharType, cols, nil, "db1", "table1")
+	require.ErrorIs(t, err, ErrInvalidTypes)
+
+	_, err = (&NegExp{exp: &ColSelector{col: "d"}}).inferType(cols, nil, "db1", "table1")
+	require.ErrorIs(t, err, ErrInvalidTypes)
+
+	_, err = (&NegExp{exp: &ColSelector{col: "d"}}).reduce(nil, row, "db1", "table1")
+	require.ErrorIs(t, err, ErrInvalidValue)
+
+	rexp, err := (&NegExp{exp: &Param{id: "p"}}).substitute(map[string]interface{}{"p": int64(2)})
+	require.NoError(t, err)
+	require.Equal(t, &NegExp{exp: &Number{val: 2}}, rexp)
+	require.True(t, rexp.isConstant())
+
+	require.Equal(t, &NegExp{exp: &Number{val: 3}}, (&NegExp{exp: &ColSelector{col: "a"}}).reduceSelectors(row, "db1", "table1"))
+}
+
+func TestNumExpReduceWithNulls(t *testing.T) {
+	row := &Row{Values: map[string]TypedValue{
+		"(db1.table1.a)": &Number{val: 3},
+		"(db1.table1.c)": &NullValue{t: IntegerType},
+	}}
+
+	testCases := []struct {
+		name string
+		exp  ValueExp
+	}{
+		{"NULL + 1", &NumExp{op: ADDOP, left: &NullValue{t: AnyType}, right: &Number{val: 1}}},
+		{"a / NULL", &NumExp{op: DIVOP, left: &ColSelector{col: "a"}, right: &NullValue{t: AnyType}}},
+		{"5 * NULL", &NumExp{op: MULTOP, left: &Number{val: 5}, right: &NullValue{t: AnyType}}},
+		{"a - c", &NumExp{op: SUBSOP, left: &ColSelector{col: "a"}, right: &ColSelector{col: "c"}}},
+		{"c % 0", &NumExp{op: MODOP, left: &ColSelector{col: "c"}, right: &Number{val: 0}}},
+		{"-(c + 1)", &NegExp{exp: &NumExp{op: ADDOP, left: &ColSelector{col: "c"}, right: &Number{val: 1}}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			v, err := tc.exp.reduce(nil, row, "db1", "table1")
+			require.NoError(t, err)
+			require.Equal(t, &NullValue{t: IntegerType}, v)
+		})
+	}
+
+	_, err := (&NumExp{op: ADDOP, left: &Varchar{val: "a"}, right: &Number{val: 1}}).reduce(nil, row, "db1", "table1")
+	require.ErrorIs(t, err, ErrInvalidValue)
+}
+
+func TestBoolExpReduceWithNulls(t *testing.T) {
+	row := &Row{Values: map[string]TypedValue{
+		"(db1.table1.a)": &Number{val: 3},
+		"(db1.table1.c)": &NullValue{t: IntegerType},
+	}}
+
+	null := &NullValue{t: BooleanType}
+	isNull := &CmpBoolExp{op: EQ, left: &ColSelector{col: "c"}, right: &NullValue{t: AnyType}}
+
+	testCases := []struct {
+		name     string
+		exp      ValueExp
+		expected TypedValue
+	}{
+		{"c = NULL", isNull, null},
+		{"a != NULL", &CmpBoolExp{op: NE, left: &ColSelector{col: "a"}, right: &NullValue{t: AnyType}}, null},
+		{"c < 1", &CmpBoolExp{op: LT, left: &ColSelector{col: "c"}, right: &Number{val: 1}}, null},
+		{"NOT (c = NULL)", &NotBoolExp{exp: isNull}, null},
+		{"c = NULL AND TRUE", &BinBoolExp{op: AND, left: isNull, right: &Bool{val: true}}, null},
+		{"c = NULL AND FALSE", &BinBoolExp{op: AND, left: isNull, right: &Bool{val: false}}, &Bool{val: false}},
+		{"FALSE AND c = NULL", &BinBoolExp{op: AND, left: &Bool{val: false}, right: isNull}, &Bool{val: false}},
+		{"c = NULL OR FALSE", &BinBoolExp{op: OR, left: isNull, right: &Bool{val: false}}, null},
+		{"c = NULL OR TRUE", &BinBoolExp{op: OR, left: isNull, right: &Bool{val: true}}, &Bool{val: true}},
+		{"TRUE OR c = NULL", &BinBoolExp{op: OR, left: &Bool{val: true}, right: isNull}, &Bool{val: true}},
+		{"c IS NULL", &IsNullBoolExp{exp: &ColSelector{col: "c"}}, &Bool{val: true}},
+		{"c IS NOT NULL", &IsNullBoolExp{exp: &ColSelector{col: "c"}, not: true}, &Bool{val: false}},
+		{"a IS NULL", &IsNullBoolExp{exp: &ColSelector{col: "a"}}, &Bool{val: false}},
+		{"a + c IS NULL", &IsNullBoolExp{exp: &NumExp{op: ADDOP, left: &ColSelector{col: "a"}, right: &ColSelector{col: "c"}}}, &Bool{val: true}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			v, err := tc.exp.reduce(nil, row, "db1", "table1")
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, v)
+		})
+	}
+
+	_, err := (&CmpBoolExp{op: EQ, left: &ColSelector{col: "c"}, right: &NullValue{t: VarcharType}}).reduce(nil, row, "db1", "table1")
+	require.ErrorIs(t, err, ErrNotComparableValues)
+
+	_, err = (&BinBoolExp{op: AND, left: isNull, right: &Number{val: 1}}).reduce(nil, row, "db1", "table1")
+	require.ErrorIs(t, err, ErrInvalidCondition)
+}
+
+func TestBinBoolExpShortCircuit(t *testing.T) {
+	row := &Row{Values: map[string]TypedValue{
+		"(db1.table1.a)": &Number{val: 3},
+	}}
+
+	// reducing a selector of a missing column fails
+	missing := &CmpBoolExp{op: EQ, left: &ColSelector{col: "b"}, right: &Number{val: 1}}
+	aIs3 := &CmpBoolExp{op: EQ, left: &ColSelector{col: "a"}, right: &Number{val: 3}}
+
+	testCases := []struct {
+		name        string
+		exp         ValueExp
+		expected    TypedValue
+		expectedErr error
+	}{
+		{"FALSE AND b = 1", &BinBoolExp{op: AND, left: &Bool{val: false}, right: missing}, &Bool{val: false}, nil},
+		{"a != 3 AND b = 1", &BinBoolExp{op: AND, left: &NotBoolExp{exp: aIs3}, right: missing}, &Bool{val: false}, nil},
+		{"TRUE OR b = 1", &BinBoolExp{op: OR, left: &Bool{val: true}, right: missing}, &Bool{val: true}, nil},
+		{"a = 3 OR b = 1", &BinBoolExp{op: OR, left: aIs3, right: missing}, &Bool{val: true}, nil},
+		{"FALSE AND 1", &BinBoolExp{op: AND, left: &Bool{val: false}, right: &Number{val: 1}}, &Bool{val: false}, nil},
+		{"TRUE AND b = 1", &BinBoolExp{op: AND, left: &Bool{val: true}, right: missing}, nil, ErrColumnDoesNotExist},
+		{"FALSE OR b = 1", &BinBoolExp{op: OR, left: &Bool{val: false}, right: missing}, nil, ErrColumnDoesNotExist},
+		{"1 AND TRUE", &BinBoolExp{op: AND, left: &Number{val: 1}, right: &Bool{val: true}}, nil, ErrInvalidCondition},
+		{"FALSE OR 1", &BinBoolExp{op: OR, left: &Bool{val: false}, right: &Number{val: 1}}, nil, ErrInvalidCondition},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			v, err := tc.exp.reduce(nil, row, "db1", "table1")
+			require.ErrorIs(t, err, tc.expectedErr)
+			require.Equal(t, tc.expected, v)
+		})
+	}
+}
+
+func TestGroupedExpReduce(t *testing.T) {
+	row := &Row{Values: map[string]TypedValue{
+		"(db1.table1.a)": &Number{val: 2},
+		"(db1.table1.b)": &Number{val: 3},
+		"(db1.table1.c)": &Number{val: 4},
+		"(db1.table1.p)": &Bool{val: true},
+		"(db1.table1.q)": &Bool{val: false},
+		"(db1.table1.r)": &Bool{val: false},
+	}}
+
+	testCases := []struct {
+		exp      string
+		expected TypedValue
+	}{
+		{"a + b * c", &Number{val: 14}},
+		{"(a + b) * c", &Number{val: 20}},
+		{"a * b - c", &Number{val: 2}},
+		{"a * (b - c)", &Number{val: -2}},
+		{"c - b - a", &Number{val: -1}},
+		{"c - (b - a)", &Number{val: 3}},
+		{"-(a + b)", &Number{val: -5}},
+		{"((a))", &Number{val: 2}},
+		{"p OR q AND r", &Bool{val: true}},
+		{"(p OR q) AND r", &Bool{val: false}},
+		{"NOT p OR p", &Bool{val: true}},
+		{"NOT (p OR p)", &Bool{val: false}},
+		{"(a + b) * c = 20 AND (p OR q)", &Bool{val: true}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.exp, func(t *testing.T) {
+			stmts, err := ParseString("SELECT id FROM table1 WHERE " + tc.exp)
+			require.NoError(t, err)
+			require.Len(t, stmts, 1)
+
+			v, err := stmts[0].(*SelectStmt).where.reduce(nil, row, "db1", "table1")
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, v)
+		})
+	}
+}
+
+func TestCaseExpReduce(t *testing.T) {
+	cols := map[string]ColDescriptor{
+		"(db1.table1.amount)": {Type: IntegerType},
+		"(db1.table1.title)":  {Type: VarcharType},
+	}
+
+	rowWithAmount := func(amount int64) *Row {
+		return &Row{Values: map[string]TypedValue{
+			"(db1.table1.amount)": &Number{val: amount},
+			"(db1.table1.title)":  &Varchar{val: "title"},
+		}}
+	}
+
+	exp := &CaseExp{
+		whenThens: []*whenThen{
+			{
+				when: &CmpBoolExp{op: LT, left: &ColSelector{col: "amount"}, right: &Number{val: 10}},
+				then: &Varchar{val: "small"},
+			},
+			{
+				when: &CmpBoolExp{op: LT, left: &ColSelector{col: "amount"}, right: &Number{val: 100}},
+				then: &Varchar{val: "medium"},
+			},
+		},
+		elseExp: &Varchar{val: "large"},
+	}
+
+	it, err := exp.inferType(cols, nil, "db1", "table1")
+	require.NoError(t, err)
+	require.Equal(t, VarcharType, it)
+
+	err = exp.requiresType(VarcharType, cols, nil, "db1", "table1")
+	require.NoError(t, err)
+
+	err = exp.requiresType(IntegerType, cols, nil, "db1", "table1")
+	require.ErrorIs(t, err, ErrInvalidTypes)
+
+	t.Run("first matching branch is returned", func(t *testing.T) {
+		v, err := exp.reduce(nil, rowWithAmount(5), "db1", "table1")
+		require.NoError(t, err)
+		require.Equal(t, &Varchar{val: "small"}, v)
+
+		v, err = exp.reduce(nil, rowWithAmount(50), "db1", "table1")
+		require.NoError(t, err)
+		require.Equal(t, &Varchar{val: "medium"}, v)
+
+		v, err = exp.reduceSelectors(rowWithAmount(50), "db1", "table1").reduce(nil, nil, "db1", "table1")
+		require.NoError(t, err)
+		require.Equal(t, &Varchar{val: "medium"}, v)
+	})
+
+	t.Run("else result is returned when no branch matches", func(t *testing.T) {
+		v, err := exp.reduce(nil, rowWithAmount(500), "db1", "table1")
+		require.NoError(t, err)
+		require.Equal(t, &Varchar{val: "large"}, v)
+
+		noElse := &CaseExp{whenThens: exp.whenThens}
+
+		v, err = noElse.reduce(nil, rowWithAmount(500), "db1", "table1")
+		require.NoError(t, err)
+		require.Equal(t, &NullValue{t: VarcharType}, v)
+
+		colResult := &CaseExp{whenThens: []*whenThen{
+			{when: &Bool{val: false}, then: &ColSelector{col: "amount"}},
+		}}
+
+		v, err = colResult.reduce(nil, rowWithAmount(500), "db1", "table1")
+		require.NoError(t, err)
+		require.Equal(t, &NullValue{t: IntegerType}, v)
+
+		it, err := noElse.inferType(cols, nil, "db1", "table1")
+		require.NoError(t, err)
+		require.Equal(t, VarcharType, it)
+	})
+
+	t.Run("branches of different types are rejected", func(t *testing.T) {
+		mismatch := &CaseExp{
+			whenThens: []*whenThen{
+				{when: &Bool{val: true}, then: &Varchar{val: "small"}},
+				{when: &Bool{val: false}, then: &NullValue{t: AnyType}},
+			},
+			elseExp: &ColSelector{col: "amount"},
+		}
+
+		_, err := mismatch.inferType(cols, nil, "db1", "table1")
+		require.ErrorIs(t, err, ErrInvalidTypes)
+
+		nonBoolCond := &CaseExp{
+			whenThens: []*whenThen{
+				{when: &ColSelector{col: "title"}, then: &Number{val: 1}},
+			},
+		}
+
+		_, err = nonBoolCond.inferType(cols, nil, "db1", "table1")
+		require.ErrorIs(t, err, ErrInvalidTypes)
+
+		_, err = nonBoolCond.reduce(nil, rowWithAmount(1), "db1", "table1")
+		require.ErrorIs(t, err, ErrInvalidCondition)
+	})
+
+	t.Run("parameters are substituted in every branch", func(t *testing.T) {
+		pexp := &CaseExp{
+			whenThens: []*whenThen{
+				{when: &Param{id: "cond"}, then: &Param{id: "then"}},
+			},
+			elseExp: &Param{id: "else"},
+		}
+		require.True(t, pexp.isConstant())
+
+		sexp, err := pexp.substitute(map[string]interface{}{"cond": false, "then": int64(1), "else": int64(2)})
+		require.NoError(t, err)
+
+		v, err := sexp.reduce(nil, nil, "db1", "table1")
+		require.NoError(t, err)
+		require.Equal(t, &Number{val: 2}, v)
+
+		_, err = pexp.substitute(map[string]interface{}{"cond": false, "then": int64(1)})
+		require.ErrorIs(t, err, ErrMissingParameter)
+	})
+}
+
 func TestAliasing(t *testing.T) {
 	stmt := &SelectStmt{ds: &tableRef{table: "table1"}}
 	require.Equal(t, "table1", stmt.Alias())
 
 	stmt.as = "t1"
 	require.Equal(t, "t1", stmt.Alias())
+
+	sel := &AggColSelector{aggFn: SUM, col: "age"}
+	require.Equal(t, "sum(age)", sel.alias())
+
+	sel.setAlias("total")
+	require.Equal(t, "total", sel.alias())
+
+	require.Equal(t, "count(*)", (&AggColSelector{aggFn: COUNT, col: "*"}).alias())
 }
 
 func TestEdgeCases(t *testing.T) {
@@ -695,3 +1206,340 @@ func TestIsConstant(t *testing.T) {
 
 	require.False(t, (&ExistsBoolExp{}).isConstant())
 }
+
+func TestTimestampFunctions(t *testing.T) {
+	ts := &Cast{val: &Varchar{val: "2021-01-02T03:04:05Z"}, t: TimestampType}
+
+	v, err := ts.reduce(nil, nil, "db1", "mytable")
+	require.NoError(t, err)
+	require.Equal(t, TimestampType, v.Type())
+	require.Equal(t, time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC).UnixNano(), v.Value())
+
+	testCases := []struct {
+		fn       string
+		expected int64
+	}{
+		{fn: "YEAR", expected: 2021},
+		{fn: "MONTH", expected: 1},
+		{fn: "DAY", expected: 2},
+		{fn: "day", expected: 2},
+	}
+
+	for i, tc := range testCases {
+		exp := &FnCall{fn: tc.fn, params: []ValueExp{ts}}
+
+		it, err := exp.inferType(nil, nil, "db1", "mytable")
+		require.NoError(t, err, fmt.Sprintf("failed on iteration %d", i))
+		require.Equal(t, IntegerType, it, fmt.Sprintf("failed on iteration %d", i))
+
+		rv, err := exp.reduce(nil, nil, "db1", "mytable")
+		require.NoError(t, err, fmt.Sprintf("failed on iteration %d", i))
+		require.Equal(t, &Number{val: tc.expected}, rv, fmt.Sprintf("failed on iteration %d", i))
+	}
+
+	t.Run("timestamps can be cast from unix nanos", func(t *testing.T) {
+		exp := &FnCall{fn: "MONTH", params: []ValueExp{&Cast{val: &Number{val: 1625097600000000000}, t: TimestampType}}}
+
+		rv, err := exp.reduce(nil, nil, "db1", "mytable")
+		require.NoError(t, err)
+		require.Equal(t, &Number{val: 7}, rv)
+	})
+
+	t.Run("null timestamps are reduced to null", func(t *testing.T) {
+		exp := &FnCall{fn: "YEAR", params: []ValueExp{&Cast{val: &NullValue{t: AnyType}, t: TimestampType}}}
+
+		rv, err := exp.reduce(nil, nil, "db1", "mytable")
+		require.NoError(t, err)
+		require.Equal(t, &NullValue{t: IntegerType}, rv)
+	})
+
+	t.Run("invalid timestamps", func(t *testing.T) {
+		_, err := (&Cast{val: &Varchar{val: "2021-13-02"}, t: TimestampType}).reduce(nil, nil, "db1", "mytable")
+		require.ErrorIs(t, err, ErrInvalidTimestamp)
+
+		_, err = (&Cast{val: &Bool{val: true}, t: TimestampType}).reduce(nil, nil, "db1", "mytable")
+		require.ErrorIs(t, err, ErrInvalidTypes)
+
+		_, err = (&Cast{val: &Number{val: 1}, t: IntegerType}).reduce(nil, nil, "db1", "mytable")
+		require.ErrorIs(t, err, ErrNoSupported)
+
+		_, err = (&FnCall{fn: "YEAR", params: []ValueExp{&Number{val: 1}}}).reduce(nil, nil, "db1", "mytable")
+		require.ErrorIs(t, err, ErrInvalidTypes)
+
+		_, err = (&FnCall{fn: "YEAR"}).reduce(nil, nil, "db1", "mytable")
+		require.ErrorIs(t, err, ErrIllegalArguments)
+
+		_, err = (&FnCall{fn: "HOUR", params: []ValueExp{ts}}).reduce(nil, nil, "db1", "mytable")
+		require.ErrorIs(t, err, ErrNoSupported)
+	})
+}
+
+func TestInListExpReduce(t *testing.T) {
+	row := &Row{Values: map[string]TypedValue{
+		"(db1.table1.amount)": &Number{val: 10},
+		"(db1.table1.bonus)":  &NullValue{t: IntegerType},
+	}}
+
+	amount := &ColSelector{col: "amount"}
+	bonus := &ColSelector{col: "bonus"}
+
+	testCases := []struct {
+		name     string
+		exp      *InListExp
+		expected TypedValue
+	}{
+		{
+			name:     "IN with a matching element",
+			exp:      &InListExp{val: amount, values: []ValueExp{&Number{val: 5}, &Number{val: 10}}},
+			expected: &Bool{val: true},
+		},
+		{
+			name:     "IN without a matching element",
+			exp:      &InListExp{val: amount, values: []ValueExp{&Number{val: 5}, &Number{val: 15}}},
+			expected: &Bool{val: false},
+		},
+		{
+			name:     "NOT IN with a matching element",
+			exp:      &InListExp{val: amount, notIn: true, values: []ValueExp{&Number{val: 5}, &Number{val: 10}}},
+			expected: &Bool{val: false},
+		},
+		{
+			name:     "NOT IN without a matching element",
+			exp:      &InListExp{val: amount, notIn: true, values: []ValueExp{&Number{val: 5}, &Number{val: 15}}},
+			expected: &Bool{val: true},
+		},
+		{
+			name:     "IN with a NULL element and a matching one",
+			exp:      &InListExp{val: amount, values: []ValueExp{&NullValue{t: IntegerType}, &Number{val: 10}}},
+			expected: &Bool{val: true},
+		},
+		{
+			name:     "NOT IN with a NULL element and a matching one",
+			exp:      &InListExp{val: amount, notIn: true, values: []ValueExp{&NullValue{t: IntegerType}, &Number{val: 10}}},
+			expected: &Bool{val: false},
+		},
+		{
+			name:     "IN with a NULL element and no matching one",
+			exp:      &InListExp{val: amount, values: []ValueExp{&NullValue{t: IntegerType}, &Number{val: 15}}},
+			expected: &NullValue{t: BooleanType},
+		},
+		{
+			name:     "NOT IN with a NULL element and no matching one",
+			exp:      &InListExp{val: amount, notIn: true, values: []ValueExp{&Number{val: 5}, bonus}},
+			expected: &NullValue{t: BooleanType},
+		},
+		{
+			name:     "NOT IN of a NULL value",
+			exp:      &InListExp{val: bonus, notIn: true, values: []ValueExp{&Number{val: 5}}},
+			expected: &NullValue{t: BooleanType},
+		},
+		{
+			name:     "NOT IN of an empty list",
+			exp:      &InListExp{val: amount, notIn: true},
+			expected: &Bool{val: true},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			v, err := tc.exp.reduce(nil, row, "db1", "table1")
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, v)
+
+			v, err = tc.exp.reduceSelectors(row, "db1", "table1").reduce(nil, nil, "db1", "table1")
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, v)
+
+			rangesByColID := make(map[uint32]*typedValueRange)
+
+			err = tc.exp.selectorRanges(nil, "", nil, rangesByColID)
+			require.NoError(t, err)
+			require.Empty(t, rangesByColID)
+		})
+	}
+}
+
+func TestReduceUnknownColumn(t *testing.T) {
+	row := &Row{Values: map[string]TypedValue{
+		"(db1.table1.title)": &Varchar{val: "title"},
+	}}
+
+	testCases := []struct {
+		name string
+		exp  ValueExp
+		col  string
+	}{
+		{
+			name: "column selector",
+			exp:  &ColSelector{col: "amount"},
+			col:  "db1.table1.amount",
+		},
+		{
+			name: "qualified column selector",
+			exp:  &ColSelector{table: "table2", col: "title"},
+			col:  "db1.table2.title",
+		},
+		{
+			name: "aggregated column selector",
+			exp:  &AggColSelector{aggFn: SUM, col: "amount"},
+			col:  "SUM(db1.table1.amount)",
+		},
+		{
+			name: "like expression",
+			exp:  &LikeBoolExp{val: &ColSelector{col: "name"}, pattern: &Varchar{val: "t%"}},
+			col:  "db1.table1.name",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.exp.reduce(nil, row, "db1", "table1")
+			require.ErrorIs(t, err, ErrColumnDoesNotExist)
+			require.Contains(t, err.Error(), tc.col)
+		})
+	}
+}
+
+func TestVarcharCollation(t *testing.T) {
+	testCases := []struct {
+		left     *Varchar
+		right    TypedValue
+		expected int
+	}{
+		{&Varchar{val: "abc"}, &Varchar{val: "ABC"}, 1},
+		{&Varchar{val: "abc", collation: NoCaseCollation}, &Varchar{val: "ABC"}, 0},
+		{&Varchar{val: "abc"}, &Varchar{val: "ABC", collation: NoCaseCollation}, 0},
+		{&Varchar{val: "Banana", collation: NoCaseCollation}, &Varchar{val: "apple"}, 1},
+		{&Varchar{val: "Banana", collation: NoCaseCollation}, &Varchar{val: "cherry"}, -1},
+		{&Varchar{val: "[", collation: NoCaseCollation}, &Varchar{val: "a"}, -1},
+		{&Varchar{val: "É", collation: NoCaseCollation}, &Varchar{val: "é"}, -1},
+		{&Varchar{val: "abc", collation: NoCaseCollation}, &NullValue{t: VarcharType}, 1},
+	}
+
+	for i, tc := range testCases {
+		cmp, err := tc.left.Compare(tc.right)
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, cmp, fmt.Sprintf("failed on iteration %d", i))
+	}
+
+	require.Equal(t, "hello world [@_`{]", foldCase("HeLLo World [@_`{]"))
+}
+
+func TestCompareIntegersAndTimestamps(t *testing.T) {
+	testCases := []struct {
+		left     TypedValue
+		right    TypedValue
+		expected int
+	}{
+		{&Number{val: 10}, &Timestamp{val: 10}, 0},
+		{&Number{val: 10}, &Timestamp{val: 20}, -1},
+		{&Timestamp{val: 20}, &Number{val: 10}, 1},
+		{&Timestamp{val: 10}, &Number{val: 10}, 0},
+	}
+
+	for i, tc := range testCases {
+		cmp, err := tc.left.Compare(tc.right)
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, cmp, fmt.Sprintf("failed on iteration %d", i))
+	}
+
+	_, err := (&Timestamp{val: 10}).Compare(&Varchar{val: "10"})
+	require.ErrorIs(t, err, ErrNotComparableValues)
+
+	require.Equal(t, &Timestamp{val: 10}, convertToColType(&Column{colType: TimestampType}, &Number{val: 10}))
+	require.Equal(t, &Number{val: 10}, convertToColType(&Column{colType: IntegerType}, &Timestamp{val: 10}))
+	require.Equal(t, &Varchar{val: "10"}, convertToColType(&Column{colType: TimestampType}, &Varchar{val: "10"}))
+}
+
+func TestTypedValueMarshalJSON(t *testing.T) {
+	ts := time.Date(2021, 12, 8, 13, 46, 23, 12345000, time.UTC)
+
+	testCases := []struct {
+		val      TypedValue
+		expected string
+	}{
+		{&Number{val: -10}, `-10`},
+		{&Varchar{val: `say "hi"`}, `"say \"hi\""`},
+		{&Bool{val: true}, `true`},
+		{&Blob{val: []byte{0xde, 0xad, 0xbe, 0xef}}, `"3q2+7w=="`},
+		{&Blob{}, `""`},
+		{&Timestamp{val: ts.UnixNano()}, `"2021-12-08T13:46:23.012345Z"`},
+		{&NullValue{t: VarcharType}, `null`},
+		{&CountValue{c: 3}, `3`},
+		{&SumValue{s: 7}, `7`},
+		{&MinValue{val: &Varchar{val: "a"}}, `"a"`},
+		{&MaxValue{val: &Number{val: 4}}, `4`},
+		{&AVGValue{s: 7, c: 2}, `3`},
+	}
+
+	for i, tc := range testCases {
+		bs, err := json.Marshal(tc.val)
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, string(bs), fmt.Sprintf("failed on iteration %d", i))
+	}
+
+	row := &Row{
+		Values: map[string]TypedValue{
+			EncodeSelector("", "db1", "table1", "id"):        &Number{val: 1},
+			EncodeSelector("", "db1", "table1", "title"):     &Varchar{val: "title1"},
+			EncodeSelector("", "db1", "table1", "active"):    &Bool{val: false},
+			EncodeSelector("", "db1", "table1", "payload"):   &Blob{val: []byte{0x01, 0x02}},
+			EncodeSelector("", "db1", "table1", "createdAt"): &Timestamp{val: ts.UnixNano()},
+			EncodeSelector("", "db1", "table1", "notes"):     &NullValue{t: VarcharType},
+		},
+	}
+
+	bs, err := json.Marshal(row)
+	require.NoError(t, err)
+	require.Equal(t,
+		`{"(db1.table1.active)":false,"(db1.table1.createdAt)":"2021-12-08T13:46:23.012345Z",`+
+			`"(db1.table1.id)":1,"(db1.table1.notes)":null,"(db1.table1.payload)":"AQI=","(db1.table1.title)":"title1"}`,
+		string(bs))
+
+	var decoded struct {
+		ID        int64     `json:"(db1.table1.id)"`
+		Title     string    `json:"(db1.table1.title)"`
+		Active    bool      `json:"(db1.table1.active)"`
+		Payload   []byte    `json:"(db1.table1.payload)"`
+		CreatedAt time.Time `json:"(db1.table1.createdAt)"`
+		Notes     *string   `json:"(db1.table1.notes)"`
+	}
+
+	err = json.Unmarshal(bs, &decoded)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), decoded.ID)
+	require.Equal(t, "title1", decoded.Title)
+	require.False(t, decoded.Active)
+	require.Equal(t, []byte{0x01, 0x02}, decoded.Payload)
+	require.True(t, ts.Equal(decoded.CreatedAt))
+	require.Nil(t, decoded.Notes)
+}
+
+func TestCmpBoolExpReduceColumns(t *testing.T) {
+	row := &Row{Values: map[string]TypedValue{
+		"(db1.table1.a)": &Number{val: 3},
+		"(db1.table1.b)": &Number{val: 2},
+		"(db1.table1.c)": &NullValue{t: IntegerType},
+		"(db1.table2.x)": &Number{val: 3},
+	}}
+
+	testCases := []struct {
+		name     string
+		exp      ValueExp
+		expected TypedValue
+	}{
+		{"a > b", &CmpBoolExp{op: GT, left: &ColSelector{col: "a"}, right: &ColSelector{col: "b"}}, &Bool{val: true}},
+		{"a < b", &CmpBoolExp{op: LT, left: &ColSelector{col: "a"}, right: &ColSelector{col: "b"}}, &Bool{val: false}},
+		{"a = c", &CmpBoolExp{op: EQ, left: &ColSelector{col: "a"}, right: &ColSelector{col: "c"}}, &NullValue{t: BooleanType}},
+		{"table1.a = table2.x", &CmpBoolExp{op: EQ, left: &ColSelector{table: "table1", col: "a"}, right: &ColSelector{table: "table2", col: "x"}}, &Bool{val: true}},
+		{"table1.b >= table2.x", &CmpBoolExp{op: GE, left: &ColSelector{table: "table1", col: "b"}, right: &ColSelector{table: "table2", col: "x"}}, &Bool{val: false}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			v, err := tc.exp.reduce(nil, row, "db1", "table1")
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, v)
+		})
+	}
+}
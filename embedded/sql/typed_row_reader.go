@@ -0,0 +1,189 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sql
+
+import (
+	"fmt"
+	"time"
+)
+
+// TypedRowReader streams the rows of a RowReader, values are accessed by column name or alias,
+// optionally qualified with the table name e.g. "table1.id"
+type TypedRowReader struct {
+	rowReader RowReader
+
+	cols      []ColDescriptor
+	selByName map[string]string
+}
+
+// TypedRow holds the values of a row read through a TypedRowReader
+type TypedRow struct {
+	row       *Row
+	selByName map[string]string
+}
+
+func NewTypedRowReader(rowReader RowReader) (*TypedRowReader, error) {
+	if rowReader == nil {
+		return nil, ErrIllegalArguments
+	}
+
+	cols, err := rowReader.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	selByName := make(map[string]string, 2*len(cols))
+
+	for _, col := range cols {
+		// an unqualified name refers to the first column with such name
+		_, ok := selByName[col.Column]
+		if !ok {
+			selByName[col.Column] = col.Selector()
+		}
+
+		selByName[fmt.Sprintf("%s.%s", col.Table, col.Column)] = col.Selector()
+	}
+
+	return &TypedRowReader{
+		rowReader: rowReader,
+		cols:      cols,
+		selByName: selByName,
+	}, nil
+}
+
+// Columns returns the columns of the rows in the same order as they were projected
+func (r *TypedRowReader) Columns() []ColDescriptor {
+	return r.cols
+}
+
+// Read returns the next row or ErrNoMoreRows once all the rows were read
+func (r *TypedRowReader) Read() (*TypedRow, error) {
+	row, err := r.rowReader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	return &TypedRow{
+		row:       row,
+		selByName: r.selByName,
+	}, nil
+}
+
+func (r *TypedRowReader) Close() error {
+	return r.rowReader.Close()
+}
+
+// Value returns the value of the column, false is returned when there is no such column
+func (row *TypedRow) Value(col string) (TypedValue, bool) {
+	sel, ok := row.selByName[col]
+	if !ok {
+		return nil, false
+	}
+
+	val, ok := row.row.Values[sel]
+	return val, ok
+}
+
+// IsNull returns true when the column exists and its value is NULL
+func (row *TypedRow) IsNull(col string) bool {
+	val, ok := row.Value(col)
+	if !ok {
+		return false
+	}
+
+	_, isNull := val.(*NullValue)
+	return isNull
+}
+
+// Int returns the value of an INTEGER column, false is returned when there is no such column,
+// its value is NULL or it is of a different type
+func (row *TypedRow) Int(col string) (int64, bool) {
+	val, ok := row.Value(col)
+	if !ok {
+		return 0, false
+	}
+
+	n, ok := val.(*Number)
+	if !ok {
+		return 0, false
+	}
+
+	return n.val, true
+}
+
+// String returns the value of a VARCHAR column, false is returned when there is no such column,
+// its value is NULL or it is of a different type
+func (row *TypedRow) String(col string) (string, bool) {
+	val, ok := row.Value(col)
+	if !ok {
+		return "", false
+	}
+
+	s, ok := val.(*Varchar)
+	if !ok {
+		return "", false
+	}
+
+	return s.val, true
+}
+
+// Bool returns the value of a BOOLEAN column, false is returned when there is no such column,
+// its value is NULL or it is of a different type
+func (row *TypedRow) Bool(col string) (bool, bool) {
+	val, ok := row.Value(col)
+	if !ok {
+		return false, false
+	}
+
+	b, ok := val.(*Bool)
+	if !ok {
+		return false, false
+	}
+
+	return b.val, true
+}
+
+// Blob returns the value of a BLOB column, false is returned when there is no such column,
+// its value is NULL or it is of a different type
+func (row *TypedRow) Blob(col string) ([]byte, bool) {
+	val, ok := row.Value(col)
+	if !ok {
+		return nil, false
+	}
+
+	b, ok := val.(*Blob)
+	if !ok {
+		return nil, false
+	}
+
+	return b.val, true
+}
+
+// Timestamp returns the value of a TIMESTAMP column, false is returned when there is no such column,
+// its value is NULL or it is of a different type
+func (row *TypedRow) Timestamp(col string) (time.Time, bool) {
+	val, ok := row.Value(col)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	ts, ok := val.(*Timestamp)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	return time.Unix(0, ts.val).UTC(), true
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sql
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/codenotary/immudb/embedded/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypedRowReader(t *testing.T) {
+	catalogStore, err := store.Open("catalog_typed_row_reader", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("catalog_typed_row_reader")
+
+	dataStore, err := store.Open("sqldata_typed_row_reader", store.DefaultOptions())
+	require.NoError(t, err)
+	defer os.RemoveAll("sqldata_typed_row_reader")
+
+	engine, err := NewEngine(catalogStore, dataStore, DefaultOptions().WithPrefix(sqlPrefix))
+	require.NoError(t, err)
+
+	_, err = NewTypedRowReader(nil)
+	require.ErrorIs(t, err, ErrIllegalArguments)
+
+	_, err = NewTypedRowReader(&dummyRowReader{failReturningColumns: true})
+	require.Error(t, err)
+
+	_, err = engine.ExecStmt("CREATE DATABASE db1", nil, true)
+	require.NoError(t, err)
+
+	err = engine.UseDatabase("db1")
+	require.NoError(t, err)
+
+	_, err = engine.ExecStmt("CREATE TABLE table1 (id INTEGER, title VARCHAR, active BOOLEAN, payload BLOB, PRIMARY KEY id)", nil, true)
+	require.NoError(t, err)
+
+	rowCount := 5
+
+	for i := 0; i < rowCount; i++ {
+		params := map[string]interface{}{
+			"id":      i,
+			"title":   fmt.Sprintf("title%d", i),
+			"active":  i%2 == 0,
+			"payload": []byte{byte(i)},
+		}
+
+		if i == 0 {
+			params["title"] = nil
+		}
+
+		_, err = engine.ExecStmt("INSERT INTO table1 (id, title, active, payload) VALUES (@id, @title, @active, @payload)", params, true)
+		require.NoError(t, err)
+	}
+
+	rowReader, err := engine.QueryStmt("SELECT id AS code, title AS name, active, payload FROM table1", nil, true)
+	require.NoError(t, err)
+
+	r, err := NewTypedRowReader(rowReader)
+	require.NoError(t, err)
+
+	cols := r.Columns()
+	require.Len(t, cols, 4)
+	require.Equal(t, "code", cols[0].Column)
+	require.Equal(t, IntegerType, cols[0].Type)
+	require.Equal(t, "name", cols[1].Column)
+	require.Equal(t, VarcharType, cols[1].Type)
+	require.Equal(t, "active", cols[2].Column)
+	require.Equal(t, "payload", cols[3].Column)
+
+	for i := 0; i < rowCount; i++ {
+		row, err := r.Read()
+		require.NoError(t, err)
+
+		code, ok := row.Int("code")
+		require.True(t, ok)
+		require.Equal(t, int64(i), code)
+
+		code, ok = row.Int("table1.code")
+		require.True(t, ok)
+		require.Equal(t, int64(i), code)
+
+		name, ok := row.String("name")
+		if i == 0 {
+			require.False(t, ok)
+			require.True(t, row.IsNull("name"))
+		} else {
+			require.True(t, ok)
+			require.Equal(t, fmt.Sprintf("title%d", i), name)
+			require.False(t, row.IsNull("name"))
+		}
+
+		active, ok := row.Bool("active")
+		require.True(t, ok)
+		require.Equal(t, i%2 == 0, active)
+
+		payload, ok := row.Blob("payload")
+		require.True(t, ok)
+		require.Equal(t, []byte{byte(i)}, payload)
+
+		_, ok = row.Int("id")
+		require.False(t, ok)
+
+		_, ok = row.String("code")
+		require.False(t, ok)
+
+		_, ok = row.Timestamp("code")
+		require.False(t, ok)
+
+		require.False(t, row.IsNull("id"))
+	}
+
+	_, err = r.Read()
+	require.ErrorIs(t, err, ErrNoMoreRows)
+
+	err = r.Close()
+	require.NoError(t, err)
+
+	err = engine.Close()
+	require.NoError(t, err)
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sql
+
+import "github.com/codenotary/immudb/embedded/multierr"
+
+type unionRowReader struct {
+	e *Engine
+
+	rowReaders []RowReader
+	cols       []ColDescriptor
+
+	// position of the reader currently being read
+	readerPos int
+	// columns of the reader currently being read
+	readerCols []ColDescriptor
+}
+
+func (e *Engine) newUnionRowReader(rowReaders []RowReader) (*unionRowReader, error) {
+	if len(rowReaders) == 0 {
+		return nil, ErrIllegalArguments
+	}
+
+	cols, err := rowReaders[0].Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range rowReaders[1:] {
+		rcols, err := r.Columns()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(cols) != len(rcols) {
+			return nil, ErrColumnMismatchInUnionStmt
+		}
+
+		for i, col := range cols {
+			if col.Type != rcols[i].Type {
+				return nil, ErrColumnMismatchInUnionStmt
+			}
+		}
+	}
+
+	return &unionRowReader{
+		e:          e,
+		rowReaders: rowReaders,
+		cols:       cols,
+		readerCols: cols,
+	}, nil
+}
+
+func (ur *unionRowReader) ImplicitDB() string {
+	return ur.rowReaders[0].ImplicitDB()
+}
+
+func (ur *unionRowReader) ImplicitTable() string {
+	return ur.rowReaders[0].ImplicitTable()
+}
+
+func (ur *unionRowReader) SetParameters(params map[string]interface{}) error {
+	for _, r := range ur.rowReaders {
+		err := r.SetParameters(params)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (ur *unionRowReader) OrderBy() []ColDescriptor {
+	// rows of each reader are sorted but not the combined sequence
+	return nil
+}
+
+func (ur *unionRowReader) ScanSpecs() *ScanSpecs {
+	return ur.rowReaders[0].ScanSpecs()
+}
+
+func (ur *unionRowReader) Columns() ([]ColDescriptor, error) {
+	return ur.rowReaders[0].Columns()
+}
+
+func (ur *unionRowReader) colsBySelector() (map[string]ColDescriptor, error) {
+	return ur.rowReaders[0].colsBySelector()
+}
+
+func (ur *unionRowReader) InferParameters(params map[string]SQLValueType) error {
+	for _, r := range ur.rowReaders {
+		err := r.InferParameters(params)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (ur *unionRowReader) Read() (*Row, error) {
+	for {
+		row, err := ur.rowReaders[ur.readerPos].Read()
+		if err == ErrNoMoreRows && ur.readerPos < len(ur.rowReaders)-1 {
+			ur.readerPos++
+
+			ur.readerCols, err = ur.rowReaders[ur.readerPos].Columns()
+			if err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if ur.readerPos == 0 {
+			return row, nil
+		}
+
+		// values are named after the columns of the first reader
+		values := make(map[string]TypedValue, len(ur.cols))
+
+		for i, col := range ur.cols {
+			values[col.Selector()] = row.Values[ur.readerCols[i].Selector()]
+		}
+
+		return &Row{Values: values}, nil
+	}
+}
+
+func (ur *unionRowReader) Rewind() error {
+	for _, r := range ur.rowReaders[:ur.readerPos+1] {
+		err := r.Rewind()
+		if err != nil {
+			return err
+		}
+	}
+
+	ur.readerPos = 0
+	ur.readerCols = ur.cols
+
+	return nil
+}
+
+func (ur *unionRowReader) Close() error {
+	merr := multierr.NewMultiErr()
+
+	for _, r := range ur.rowReaders {
+		err := r.Close()
+		merr.Append(err)
+	}
+
+	if merr.HasErrors() {
+		return merr
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package sql
+
+// valuesRowReader streams rows already held in memory e.g. catalog metadata
+type valuesRowReader struct {
+	e *Engine
+
+	db    string
+	table string
+
+	colsByPos []ColDescriptor
+	colsBySel map[string]ColDescriptor
+
+	rows [][]TypedValue
+	read int
+}
+
+func (e *Engine) newValuesRowReader(db, table string, cols []ColDescriptor, rows [][]TypedValue) (*valuesRowReader, error) {
+	if len(cols) == 0 {
+		return nil, ErrIllegalArguments
+	}
+
+	colsByPos := make([]ColDescriptor, len(cols))
+	colsBySel := make(map[string]ColDescriptor, len(cols))
+
+	for i, col := range cols {
+		colDescriptor := ColDescriptor{
+			Database: db,
+			Table:    table,
+			Column:   col.Column,
+			Type:     col.Type,
+		}
+
+		colsByPos[i] = colDescriptor
+		colsBySel[colDescriptor.Selector()] = colDescriptor
+	}
+
+	for _, row := range rows {
+		if len(row) != len(cols) {
+			return nil, ErrInvalidNumberOfValues
+		}
+	}
+
+	return &valuesRowReader{
+		e:         e,
+		db:        db,
+		table:     table,
+		colsByPos: colsByPos,
+		colsBySel: colsBySel,
+		rows:      rows,
+	}, nil
+}
+
+func (vr *valuesRowReader) ImplicitDB() string {
+	return vr.db
+}
+
+func (vr *valuesRowReader) ImplicitTable() string {
+	return vr.table
+}
+
+func (vr *valuesRowReader) OrderBy() []ColDescriptor {
+	return nil
+}
+
+func (vr *valuesRowReader) ScanSpecs() *ScanSpecs {
+	return nil
+}
+
+func (vr *valuesRowReader) Columns() ([]ColDescriptor, error) {
+	ret := make([]ColDescriptor, len(vr.colsByPos))
+	copy(ret, vr.colsByPos)
+	return ret, nil
+}
+
+func (vr *valuesRowReader) colsBySelector() (map[string]ColDescriptor, error) {
+	ret := make(map[string]ColDescriptor, len(vr.colsBySel))
+	for sel := range vr.colsBySel {
+		ret[sel] = vr.colsBySel[sel]
+	}
+	return ret, nil
+}
+
+func (vr *valuesRowReader) InferParameters(params map[string]SQLValueType) error {
+	return nil
+}
+
+func (vr *valuesRowReader) SetParameters(params map[string]interface{}) error {
+	return nil
+}
+
+func (vr *valuesRowReader) Read() (*Row, error) {
+	if vr.read >= len(vr.rows) {
+		return nil, ErrNoMoreRows
+	}
+
+	values := make(map[string]TypedValue, len(vr.colsByPos))
+
+	for i, col := range vr.colsByPos {
+		values[col.Selector()] = vr.rows[vr.read][i]
+	}
+
+	vr.read++
+
+	return &Row{Values: values}, nil
+}
+
+func (vr *valuesRowReader) Rewind() error {
+	vr.read = 0
+	return nil
+}
+
+func (vr *valuesRowReader) Close() error {
+	return nil
+}
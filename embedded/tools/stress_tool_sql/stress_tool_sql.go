@@ -246,7 +246,7 @@ func main() {
 					panic(err)
 				}
 				r.Close()
-				n := ret.Values["(defaultdb.entries.col0)"].Value().(uint64)
+				n := ret.Values["(defaultdb.entries.count(*))"].Value().(uint64)
 				if n != uint64(i) {
 					log.Printf("Reader %d read %d vs %d", id, n, i)
 				}
@@ -270,7 +270,7 @@ func main() {
 		panic(err)
 	}
 
-	count := row.Values["(defaultdb.entries.col0)"].Value().(uint64)
+	count := row.Values["(defaultdb.entries.count(*))"].Value().(uint64)
 	log.Printf("- Counted %d entries\n", count)
 	defer func() {
 		err := r.Close()
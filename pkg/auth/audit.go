@@ -0,0 +1,101 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// AuditRecord describes a call handled by the server interceptors
+type AuditRecord struct {
+	ClientIP string
+	// Method is the full gRPC method name e.g. /immudb.schema.ImmuService/Set
+	Method string
+	// Username is empty when the user could not be resolved from the call
+	Username  string
+	StartTime time.Time
+	Duration  time.Duration
+	Code      codes.Code
+}
+
+// AuditSink receives an audit record for every call handled by the server interceptors
+type AuditSink interface {
+	Audit(record *AuditRecord)
+}
+
+// Auditor when set, receives an audit record for every call, including the rejected ones
+var Auditor AuditSink
+
+func audit(ctx context.Context, method string, startTime time.Time, err error) {
+	record := &AuditRecord{
+		ClientIP:  clientIP(ctx),
+		Method:    method,
+		StartTime: startTime,
+		Duration:  time.Since(startTime),
+		Code:      status.Code(err),
+	}
+
+	jsonToken, tokenErr := GetLoggedInUser(ctx)
+	if tokenErr == nil {
+		record.Username = jsonToken.Username
+	}
+
+	Auditor.Audit(record)
+}
+
+// auditedUnaryCall resolves a unary call through the given function, auditing it when an Auditor is set
+func auditedUnaryCall(ctx context.Context, info *grpc.UnaryServerInfo, call func() (interface{}, error)) (res interface{}, err error) {
+	if Auditor == nil {
+		return call()
+	}
+
+	var method string
+	if info != nil {
+		method = info.FullMethod
+	}
+
+	startTime := time.Now()
+	defer func() {
+		audit(ctx, method, startTime, err)
+	}()
+
+	return call()
+}
+
+// auditedStreamCall resolves a stream through the given function, auditing it when an Auditor is set
+func auditedStreamCall(ctx context.Context, info *grpc.StreamServerInfo, call func() error) (err error) {
+	if Auditor == nil {
+		return call()
+	}
+
+	var method string
+	if info != nil {
+		method = info.FullMethod
+	}
+
+	startTime := time.Now()
+	defer func() {
+		audit(ctx, method, startTime, err)
+	}()
+
+	return call()
+}
@@ -0,0 +1,210 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type recordingAuditSink struct {
+	records []*AuditRecord
+}
+
+func (s *recordingAuditSink) Audit(record *AuditRecord) {
+	s.records = append(s.records, record)
+}
+
+func TestServerUnaryInterceptorAudit(t *testing.T) {
+	UpdateMetrics = func(context.Context) {
+	}
+	IsTampered = false
+
+	sink := &recordingAuditSink{}
+
+	Auditor = sink
+	defer func() {
+		Auditor = nil
+	}()
+
+	u := User{
+		Username: "auditeduser",
+		Active:   true,
+	}
+
+	token, err := GenerateToken(u, 2, 60)
+	require.NoError(t, err)
+
+	ctx := metadata.NewIncomingContext(peerContext("10.0.0.1"), metadata.Pairs("authorization", token))
+	info := &grpc.UnaryServerInfo{FullMethod: "/immudb.schema.ImmuService/Set"}
+
+	h := func(ctx context.Context, req interface{}) (interface{}, error) {
+		time.Sleep(time.Millisecond)
+		return "response", nil
+	}
+
+	t.Run("successful calls are audited", func(t *testing.T) {
+		AuthEnabled = true
+
+		startTime := time.Now()
+
+		r, err := ServerUnaryInterceptor(ctx, "request", info, h)
+		require.NoError(t, err)
+		require.Equal(t, "response", r)
+
+		require.Len(t, sink.records, 1)
+
+		record := sink.records[0]
+		require.Equal(t, "10.0.0.1", record.ClientIP)
+		require.Equal(t, "/immudb.schema.ImmuService/Set", record.Method)
+		require.Equal(t, "auditeduser", record.Username)
+		require.False(t, record.StartTime.Before(startTime))
+		require.GreaterOrEqual(t, int64(record.Duration), int64(time.Millisecond))
+		require.Equal(t, codes.OK, record.Code)
+	})
+
+	t.Run("rejected calls are audited", func(t *testing.T) {
+		AuthEnabled = false
+
+		_, err := ServerUnaryInterceptor(ctx, "request", info, h)
+		require.Error(t, err)
+
+		require.Len(t, sink.records, 2)
+
+		record := sink.records[1]
+		require.Equal(t, "auditeduser", record.Username)
+		require.Equal(t, codes.PermissionDenied, record.Code)
+	})
+
+	t.Run("calls failing within the handler are audited", func(t *testing.T) {
+		AuthEnabled = true
+
+		fh := func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, errors.New("handler error")
+		}
+
+		_, err := ServerUnaryInterceptor(peerContext("10.0.0.2"), "request", nil, fh)
+		require.Error(t, err)
+
+		require.Len(t, sink.records, 3)
+
+		record := sink.records[2]
+		require.Equal(t, "10.0.0.2", record.ClientIP)
+		require.Empty(t, record.Method)
+		require.Empty(t, record.Username)
+		require.Equal(t, codes.Unknown, record.Code)
+	})
+}
+
+func TestServerStreamInterceptorAudit(t *testing.T) {
+	UpdateMetrics = func(context.Context) {
+	}
+	IsTampered = false
+
+	sink := &recordingAuditSink{}
+
+	Auditor = sink
+	defer func() {
+		Auditor = nil
+	}()
+
+	info := &grpc.StreamServerInfo{FullMethod: "/immudb.schema.ImmuService/StreamGet"}
+
+	h := func(srv interface{}, stream grpc.ServerStream) error {
+		return nil
+	}
+
+	AuthEnabled = true
+
+	err := ServerStreamInterceptor(nil, &MockedServerStream{}, info, h)
+	require.NoError(t, err)
+
+	AuthEnabled = false
+
+	err = ServerStreamInterceptor(nil, &MockedServerStream{}, info, h)
+	require.Error(t, err)
+
+	require.Len(t, sink.records, 2)
+
+	require.Equal(t, "/immudb.schema.ImmuService/StreamGet", sink.records[0].Method)
+	require.Equal(t, "10.0.0.1%zone", sink.records[0].ClientIP)
+	require.Empty(t, sink.records[0].Username)
+	require.Equal(t, codes.OK, sink.records[0].Code)
+
+	require.Equal(t, codes.PermissionDenied, sink.records[1].Code)
+}
+
+func TestRateLimitedServerInterceptorsAudit(t *testing.T) {
+	UpdateMetrics = func(context.Context) {
+	}
+	IsTampered = false
+	AuthEnabled = true
+
+	sink := &recordingAuditSink{}
+
+	Auditor = sink
+	defer func() {
+		Auditor = nil
+	}()
+
+	unaryInfo := &grpc.UnaryServerInfo{FullMethod: "/immudb.schema.ImmuService/Set"}
+
+	h := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "response", nil
+	}
+
+	unaryInterceptor := RateLimitedServerUnaryInterceptor(NewRateLimiter(1, 1, false))
+
+	_, err := unaryInterceptor(peerContext("10.0.0.1"), "request", unaryInfo, h)
+	require.NoError(t, err)
+
+	_, err = unaryInterceptor(peerContext("10.0.0.1"), "request", unaryInfo, h)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	require.Len(t, sink.records, 2)
+	require.Equal(t, codes.OK, sink.records[0].Code)
+	require.Equal(t, "10.0.0.1", sink.records[1].ClientIP)
+	require.Equal(t, "/immudb.schema.ImmuService/Set", sink.records[1].Method)
+	require.Equal(t, codes.ResourceExhausted, sink.records[1].Code)
+
+	streamInfo := &grpc.StreamServerInfo{FullMethod: "/immudb.schema.ImmuService/StreamGet"}
+
+	sh := func(srv interface{}, stream grpc.ServerStream) error {
+		return nil
+	}
+
+	streamInterceptor := RateLimitedServerStreamInterceptor(NewRateLimiter(1, 1, false))
+
+	err = streamInterceptor(nil, &MockedServerStream{}, streamInfo, sh)
+	require.NoError(t, err)
+
+	err = streamInterceptor(nil, &MockedServerStream{}, streamInfo, sh)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	require.Len(t, sink.records, 4)
+	require.Equal(t, codes.OK, sink.records[2].Code)
+	require.Equal(t, "/immudb.schema.ImmuService/StreamGet", sink.records[3].Method)
+	require.Equal(t, codes.ResourceExhausted, sink.records[3].Code)
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// ClientIPFilter when set, calls from peers not allowed by the filter are rejected by the server interceptors
+var ClientIPFilter *IPFilter
+
+// IPFilter allows or denies peers based on the CIDR blocks their IP belongs to
+type IPFilter struct {
+	allowed []*net.IPNet
+	denied  []*net.IPNet
+}
+
+// NewIPFilter returns a filter denying the peers within any of the denied CIDR blocks and,
+// when the allowed list is not empty, the ones not within any of the allowed CIDR blocks
+func NewIPFilter(allowed, denied []string) (*IPFilter, error) {
+	allowedNets, err := parseCIDRs(allowed)
+	if err != nil {
+		return nil, err
+	}
+
+	deniedNets, err := parseCIDRs(denied)
+	if err != nil {
+		return nil, err
+	}
+
+	return &IPFilter{
+		allowed: allowedNets,
+		denied:  deniedNets,
+	}, nil
+}
+
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, len(cidrs))
+
+	for i, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR block '%s': %w", cidr, err)
+		}
+
+		nets[i] = n
+	}
+
+	return nets, nil
+}
+
+// Allow returns true if the peer of the call is allowed, peers without a valid IP address are denied
+func (f *IPFilter) Allow(ctx context.Context) bool {
+	host := clientIP(ctx)
+
+	// the zone of an address is not relevant to match CIDR blocks
+	zoneIdx := strings.IndexByte(host, '%')
+	if zoneIdx >= 0 {
+		host = host[:zoneIdx]
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	for _, n := range f.denied {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+
+	if len(f.allowed) == 0 {
+		return true
+	}
+
+	for _, n := range f.allowed {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
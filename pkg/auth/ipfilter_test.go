@@ -0,0 +1,103 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type malformedAddr struct{}
+
+func (a malformedAddr) Network() string { return "unknown" }
+func (a malformedAddr) String() string  { return "not-an-address" }
+
+func TestIPFilter(t *testing.T) {
+	_, err := NewIPFilter([]string{"10.0.0.0/33"}, nil)
+	require.Error(t, err)
+
+	_, err = NewIPFilter(nil, []string{"10.0.0.1"})
+	require.Error(t, err)
+
+	f, err := NewIPFilter([]string{"10.0.0.0/8", "::1/128"}, []string{"10.1.0.0/16"})
+	require.NoError(t, err)
+
+	require.True(t, f.Allow(peerContext("10.0.0.1")))
+	require.True(t, f.Allow(peerContext("::1")))
+	require.False(t, f.Allow(peerContext("10.1.0.1")))
+	require.False(t, f.Allow(peerContext("192.168.0.1")))
+
+	require.False(t, f.Allow(context.Background()))
+	require.False(t, f.Allow(peer.NewContext(context.Background(), &peer.Peer{Addr: malformedAddr{}})))
+
+	f, err = NewIPFilter(nil, []string{"10.1.0.0/16"})
+	require.NoError(t, err)
+
+	require.True(t, f.Allow(peerContext("192.168.0.1")))
+	require.False(t, f.Allow(peerContext("10.1.2.3")))
+}
+
+func TestServerInterceptorsWithIPFilter(t *testing.T) {
+	UpdateMetrics = func(context.Context) {
+	}
+	IsTampered = false
+	AuthEnabled = true
+
+	f, err := NewIPFilter([]string{"10.0.0.0/24"}, []string{"10.0.0.128/25"})
+	require.NoError(t, err)
+
+	ClientIPFilter = f
+	defer func() {
+		ClientIPFilter = nil
+	}()
+
+	uh := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	}
+
+	_, err = ServerUnaryInterceptor(peerContext("10.0.0.1"), "method", nil, uh)
+	require.NoError(t, err)
+
+	_, err = ServerUnaryInterceptor(peerContext("10.0.0.200"), "method", nil, uh)
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = ServerUnaryInterceptor(peerContext("10.0.1.1"), "method", nil, uh)
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = ServerUnaryInterceptor(peer.NewContext(context.Background(), &peer.Peer{Addr: malformedAddr{}}), "method", nil, uh)
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	sh := func(srv interface{}, stream grpc.ServerStream) error {
+		return nil
+	}
+
+	// the peer of the mocked stream is 10.0.0.1
+	err = ServerStreamInterceptor(nil, &MockedServerStream{}, nil, sh)
+	require.NoError(t, err)
+
+	ClientIPFilter, err = NewIPFilter(nil, []string{"10.0.0.1/32"})
+	require.NoError(t, err)
+
+	err = ServerStreamInterceptor(nil, &MockedServerStream{}, nil, sh)
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+}
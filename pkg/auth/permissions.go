@@ -118,8 +118,32 @@ var methodsPermissions = map[string][]uint32{
 	"CompactIndex":     {PermissionSysAdmin, PermissionAdmin},
 }
 
+// methodsPermissionLevels holds the minimum permission required to call a method, it takes precedence over methodsPermissions
+var methodsPermissionLevels map[string]uint32
+
+// SetMethodPermissionLevels sets the minimum permission required to call each of the given methods e.g.
+// PermissionR for read methods or PermissionAdmin for admin ones, methods not included keep the default policy.
+// It's meant to be called before serving requests, a nil map restores the default policy
+func SetMethodPermissionLevels(levels map[string]uint32) {
+	if levels == nil {
+		methodsPermissionLevels = nil
+		return
+	}
+
+	methodsPermissionLevels = make(map[string]uint32, len(levels))
+
+	for method, level := range levels {
+		methodsPermissionLevels[method] = level
+	}
+}
+
 //HasPermissionForMethod checks if userPermission can access method name
 func HasPermissionForMethod(userPermission uint32, method string) bool {
+	requiredPermission, ok := methodsPermissionLevels[method]
+	if ok {
+		return userPermission != PermissionNone && userPermission >= requiredPermission
+	}
+
 	methodPermissions, ok := methodsPermissions[method]
 	if !ok {
 		return false
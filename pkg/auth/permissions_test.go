@@ -38,3 +38,47 @@ func TestHasPermissionForMethod(t *testing.T) {
 		t.Errorf("expected PermissionNone to be insufficient for CountAll")
 	}
 }
+
+func TestSetMethodPermissionLevels(t *testing.T) {
+	SetMethodPermissionLevels(map[string]uint32{
+		"CountAll":  PermissionAdmin,
+		"ListUsers": PermissionR,
+		"Custom":    PermissionRW,
+	})
+	defer SetMethodPermissionLevels(nil)
+
+	if HasPermissionForMethod(PermissionR, "CountAll") {
+		t.Errorf("expected PermissionR to be insufficient for CountAll once it's restricted to admins")
+	}
+	if !HasPermissionForMethod(PermissionAdmin, "CountAll") || !HasPermissionForMethod(PermissionSysAdmin, "CountAll") {
+		t.Errorf("expected admin permissions to be sufficient for CountAll")
+	}
+
+	if !HasPermissionForMethod(PermissionR, "ListUsers") {
+		t.Errorf("expected PermissionR to be sufficient for ListUsers once it's open to readers")
+	}
+	if HasPermissionForMethod(PermissionNone, "ListUsers") {
+		t.Errorf("expected PermissionNone to be insufficient for ListUsers")
+	}
+
+	if HasPermissionForMethod(PermissionR, "Custom") || !HasPermissionForMethod(PermissionRW, "Custom") {
+		t.Errorf("expected PermissionRW to be required for Custom")
+	}
+
+	// methods without a level keep the default policy
+	if HasPermissionForMethod(PermissionR, "CreateUser") {
+		t.Errorf("expected PermissionR to be insufficient for CreateUser")
+	}
+	if !HasPermissionForMethod(PermissionR, "Scan") {
+		t.Errorf("expected PermissionR to be sufficient for Scan")
+	}
+
+	SetMethodPermissionLevels(nil)
+
+	if !HasPermissionForMethod(PermissionR, "CountAll") {
+		t.Errorf("expected the default policy to be restored for CountAll")
+	}
+	if HasPermissionForMethod(PermissionR, "ListUsers") {
+		t.Errorf("expected the default policy to be restored for ListUsers")
+	}
+}
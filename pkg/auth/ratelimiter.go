@@ -0,0 +1,145 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxIdleBuckets is the number of tracked clients above which buckets of idle clients are discarded
+const maxIdleBuckets = 10000
+
+// RateLimiter throttles requests using a token bucket per client IP
+type RateLimiter struct {
+	limit              float64
+	burst              int
+	exemptLocalClients bool
+
+	now func() time.Time
+
+	buckets map[string]*tokenBucket
+	mutex   sync.Mutex
+}
+
+type tokenBucket struct {
+	tokens   float64
+	lastTime time.Time
+}
+
+// NewRateLimiter returns a limiter allowing up to limit requests per second for each client,
+// with bursts of up to burst requests. Local clients are not throttled if exemptLocalClients is set
+func NewRateLimiter(limit float64, burst int, exemptLocalClients bool) *RateLimiter {
+	return &RateLimiter{
+		limit:              limit,
+		burst:              burst,
+		exemptLocalClients: exemptLocalClients,
+		now:                time.Now,
+		buckets:            make(map[string]*tokenBucket),
+	}
+}
+
+// Allow consumes a token of the client making the request, false is returned when none is left
+func (rl *RateLimiter) Allow(ctx context.Context) bool {
+	if rl.exemptLocalClients && isLocalClient(ctx) {
+		return true
+	}
+
+	ip := clientIP(ctx)
+
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+
+	now := rl.now()
+
+	b, ok := rl.buckets[ip]
+	if !ok {
+		if len(rl.buckets) >= maxIdleBuckets {
+			rl.discardFullBuckets(now)
+		}
+
+		b = &tokenBucket{tokens: float64(rl.burst), lastTime: now}
+		rl.buckets[ip] = b
+	}
+
+	b.refill(now, rl.limit, rl.burst)
+
+	if b.tokens < 1 {
+		return false
+	}
+
+	b.tokens--
+
+	return true
+}
+
+func (b *tokenBucket) refill(now time.Time, limit float64, burst int) {
+	elapsed := now.Sub(b.lastTime).Seconds()
+	if elapsed > 0 {
+		b.tokens += elapsed * limit
+		b.lastTime = now
+	}
+
+	if b.tokens > float64(burst) {
+		b.tokens = float64(burst)
+	}
+}
+
+// discardFullBuckets removes the buckets of clients which have been idle long enough to be refilled,
+// as a new bucket would start with the same amount of tokens
+func (rl *RateLimiter) discardFullBuckets(now time.Time) {
+	for ip, b := range rl.buckets {
+		b.refill(now, rl.limit, rl.burst)
+
+		if b.tokens >= float64(rl.burst) {
+			delete(rl.buckets, ip)
+		}
+	}
+}
+
+// RateLimitedServerUnaryInterceptor returns a ServerUnaryInterceptor which rejects the requests exceeding the rate limit,
+// rejected requests are audited as well
+func RateLimitedServerUnaryInterceptor(rl *RateLimiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return auditedUnaryCall(ctx, info, func() (interface{}, error) {
+			if !rl.Allow(ctx) {
+				return nil, status.Errorf(codes.ResourceExhausted, "rate limit exceeded")
+			}
+
+			return serverUnaryInterceptor(ctx, req, info, handler)
+		})
+	}
+}
+
+// RateLimitedServerStreamInterceptor returns a ServerStreamInterceptor which rejects the streams exceeding the rate limit,
+// rejected streams are audited as well
+func RateLimitedServerStreamInterceptor(rl *RateLimiter) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return auditedStreamCall(ss.Context(), info, func() error {
+			if !rl.Allow(ss.Context()) {
+				return status.Errorf(codes.ResourceExhausted, "rate limit exceeded")
+			}
+
+			return serverStreamInterceptor(srv, ss, info, handler)
+		})
+	}
+}
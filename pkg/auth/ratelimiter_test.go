@@ -0,0 +1,150 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func peerContext(ip string) context.Context {
+	p := &peer.Peer{
+		Addr: &net.TCPAddr{
+			IP:   net.ParseIP(ip),
+			Port: 9999,
+		},
+	}
+
+	return peer.NewContext(context.Background(), p)
+}
+
+func TestRateLimiter(t *testing.T) {
+	rl := NewRateLimiter(1, 3, false)
+
+	now := time.Now()
+	rl.now = func() time.Time { return now }
+
+	client1 := peerContext("10.0.0.1")
+	client2 := peerContext("10.0.0.2")
+
+	for i := 0; i < 3; i++ {
+		require.True(t, rl.Allow(client1))
+	}
+	require.False(t, rl.Allow(client1))
+
+	// buckets are kept per client
+	require.True(t, rl.Allow(client2))
+
+	now = now.Add(time.Second)
+	require.True(t, rl.Allow(client1))
+	require.False(t, rl.Allow(client1))
+
+	// tokens are not accumulated beyond the burst
+	now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		require.True(t, rl.Allow(client1))
+	}
+	require.False(t, rl.Allow(client1))
+
+	t.Run("local clients may be exempted", func(t *testing.T) {
+		local := peerContext("127.0.0.1")
+
+		rl := NewRateLimiter(1, 1, true)
+		rl.now = func() time.Time { return now }
+
+		for i := 0; i < 10; i++ {
+			require.True(t, rl.Allow(local))
+		}
+
+		rl = NewRateLimiter(1, 1, false)
+		rl.now = func() time.Time { return now }
+
+		require.True(t, rl.Allow(local))
+		require.False(t, rl.Allow(local))
+	})
+
+	t.Run("buckets of idle clients are discarded", func(t *testing.T) {
+		rl := NewRateLimiter(1, 1, false)
+		rl.now = func() time.Time { return now }
+
+		for i := 0; i < maxIdleBuckets; i++ {
+			rl.buckets[net.IPv4(172, byte(i>>16), byte(i>>8), byte(i)).String()] = &tokenBucket{lastTime: now}
+		}
+
+		require.True(t, rl.Allow(client1))
+		require.Len(t, rl.buckets, maxIdleBuckets+1)
+
+		now = now.Add(time.Second)
+
+		require.True(t, rl.Allow(client2))
+		require.Len(t, rl.buckets, 1)
+	})
+}
+
+func TestRateLimitedServerUnaryInterceptor(t *testing.T) {
+	UpdateMetrics = func(context.Context) {
+	}
+	IsTampered = false
+	AuthEnabled = true
+
+	h := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "response", nil
+	}
+
+	interceptor := RateLimitedServerUnaryInterceptor(NewRateLimiter(1, 2, false))
+
+	ctx := peerContext("10.0.0.1")
+
+	for i := 0; i < 2; i++ {
+		r, err := interceptor(ctx, "method", nil, h)
+		require.NoError(t, err)
+		require.Equal(t, "response", r)
+	}
+
+	_, err := interceptor(ctx, "method", nil, h)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	_, err = interceptor(peerContext("10.0.0.2"), "method", nil, h)
+	require.NoError(t, err)
+}
+
+func TestRateLimitedServerStreamInterceptor(t *testing.T) {
+	UpdateMetrics = func(context.Context) {
+	}
+	IsTampered = false
+	AuthEnabled = true
+
+	h := func(srv interface{}, stream grpc.ServerStream) error {
+		return nil
+	}
+
+	interceptor := RateLimitedServerStreamInterceptor(NewRateLimiter(1, 1, true))
+
+	err := interceptor(nil, &MockedServerStream{}, nil, h)
+	require.NoError(t, err)
+
+	err = interceptor(nil, &MockedServerStream{}, nil, h)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
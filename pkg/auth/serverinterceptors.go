@@ -18,6 +18,7 @@ package auth
 
 import (
 	"context"
+	"net"
 	"strings"
 
 	"google.golang.org/grpc"
@@ -31,10 +32,19 @@ var UpdateMetrics func(context.Context)
 
 // ServerStreamInterceptor gRPC server interceptor for streams
 func ServerStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return auditedStreamCall(ss.Context(), info, func() error {
+		return serverStreamInterceptor(srv, ss, info, handler)
+	})
+}
+
+func serverStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
 	ctx := ss.Context()
 	if UpdateMetrics != nil {
 		UpdateMetrics(ctx)
 	}
+	if ClientIPFilter != nil && !ClientIPFilter.Allow(ctx) {
+		return status.Errorf(codes.PermissionDenied, "connections from this address are not allowed")
+	}
 	if IsTampered {
 		return status.Errorf(
 			codes.DataLoss, "the database should be checked manually as we detected possible tampering")
@@ -48,14 +58,23 @@ func ServerStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.S
 			}
 		}
 	}
-	return handler(srv, ss)
+	return handler(srv, &userServerStream{ServerStream: ss, ctx: contextWithUser(ctx)})
 }
 
 // ServerUnaryInterceptor gRPC server interceptor for unary methods
 func ServerUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return auditedUnaryCall(ctx, info, func() (interface{}, error) {
+		return serverUnaryInterceptor(ctx, req, info, handler)
+	})
+}
+
+func serverUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 	if UpdateMetrics != nil {
 		UpdateMetrics(ctx)
 	}
+	if ClientIPFilter != nil && !ClientIPFilter.Allow(ctx) {
+		return nil, status.Errorf(codes.PermissionDenied, "connections from this address are not allowed")
+	}
 	if IsTampered {
 		return nil, status.Errorf(
 			codes.DataLoss, "the database should be checked manually as we detected possible tampering")
@@ -69,23 +88,83 @@ func ServerUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.Una
 			}
 		}
 	}
-	return handler(ctx, req)
+	return handler(contextWithUser(ctx), req)
+}
+
+// MethodPermissionUnaryInterceptor returns a ServerUnaryInterceptor which enforces the levels set with SetMethodPermissionLevels,
+// it's meant to be chained after ServerUnaryInterceptor so the calling user is already resolved.
+// userPermission returns the permission the user holds on the database selected by its token
+func MethodPermissionUnaryInterceptor(userPermission func(user *JSONToken) (uint32, error)) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := checkAuth(ctx, info.FullMethod, userPermission); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// MethodPermissionStreamInterceptor returns a StreamServerInterceptor which enforces the levels set with SetMethodPermissionLevels,
+// it's meant to be chained after ServerStreamInterceptor so the calling user is already resolved
+func MethodPermissionStreamInterceptor(userPermission func(user *JSONToken) (uint32, error)) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := checkAuth(ss.Context(), info.FullMethod, userPermission); err != nil {
+			return err
+		}
+
+		return handler(srv, ss)
+	}
+}
+
+// checkAuth rejects the call when a permission level was set for the method with SetMethodPermissionLevels
+// and the calling user doesn't hold it, the calls to the rest of the methods are authorized by the server
+func checkAuth(ctx context.Context, fullMethod string, userPermission func(user *JSONToken) (uint32, error)) error {
+	method := fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+
+	_, ok := methodsPermissionLevels[method]
+	if !ok || !AuthEnabled {
+		return nil
+	}
+
+	user, ok := UserFromContext(ctx)
+	if !ok || user == LocalUser {
+		return status.Errorf(codes.PermissionDenied, "not logged in")
+	}
+
+	permission, err := userPermission(user)
+	if err != nil {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+
+	if !HasPermissionForMethod(permission, method) {
+		return status.Errorf(codes.PermissionDenied, "user does not have permission to call %s", method)
+	}
+
+	return nil
 }
 
 var localAddress = map[string]struct{}{
-	"127.0.0.1": struct{}{},
-	"localhost": struct{}{},
-	"bufconn":   struct{}{},
+	"127.0.0.1": {},
+	"::1":       {},
+	"localhost": {},
+	"bufconn":   {},
 }
 
 func isLocalClient(ctx context.Context) bool {
-	isLocal := false
+	_, isLocal := localAddress[clientIP(ctx)]
+	return isLocal
+}
+
+func clientIP(ctx context.Context) string {
 	p, ok := peer.FromContext(ctx)
-	if ok && p != nil {
-		ipAndPort := strings.Split(p.Addr.String(), ":")
-		if len(ipAndPort) > 0 {
-			_, isLocal = localAddress[ipAndPort[0]]
-		}
+	if !ok || p == nil || p.Addr == nil {
+		return ""
 	}
-	return isLocal
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+
+	return host
 }
@@ -18,13 +18,16 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"net"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
 )
 
 type MockedServerStream struct {
@@ -154,3 +157,137 @@ func TestServerUnaryInterceptorNoAuth(t *testing.T) {
 	_, err := ServerUnaryInterceptor(context.Background(), "method", nil, h)
 	require.Error(t, err)
 }
+
+type namedAddr string
+
+func (a namedAddr) Network() string { return string(a) }
+func (a namedAddr) String() string  { return string(a) }
+
+func TestIsLocalClient(t *testing.T) {
+	addrContext := func(addr net.Addr) context.Context {
+		return peer.NewContext(context.Background(), &peer.Peer{Addr: addr})
+	}
+
+	testCases := []struct {
+		name    string
+		ctx     context.Context
+		isLocal bool
+	}{
+		{"IPv4 loopback", peerContext("127.0.0.1"), true},
+		{"IPv6 loopback", peerContext("::1"), true},
+		{"bufconn", addrContext(namedAddr("bufconn")), true},
+		{"localhost", addrContext(namedAddr("localhost:3322")), true},
+		{"IPv4 remote", peerContext("10.0.0.1"), false},
+		{"IPv6 remote", peerContext("2001:db8::1"), false},
+		{"malformed address", addrContext(namedAddr("127.0.0.1:3322:3322")), false},
+		{"empty address", addrContext(namedAddr("")), false},
+		{"no peer", context.Background(), false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.isLocal, isLocalClient(tc.ctx))
+		})
+	}
+}
+
+func TestMethodPermissionInterceptors(t *testing.T) {
+	UpdateMetrics = func(context.Context) {
+	}
+	IsTampered = false
+	AuthEnabled = true
+
+	SetMethodPermissionLevels(map[string]uint32{
+		"ListUsers": PermissionAdmin,
+		"Scan":      PermissionR,
+	})
+	defer SetMethodPermissionLevels(nil)
+
+	permissions := map[string]uint32{
+		"reader": PermissionR,
+		"admin":  PermissionAdmin,
+	}
+
+	userPermission := func(user *JSONToken) (uint32, error) {
+		permission, ok := permissions[user.Username]
+		if !ok {
+			return PermissionNone, errors.New("not logged in")
+		}
+
+		return permission, nil
+	}
+
+	unaryInterceptor := MethodPermissionUnaryInterceptor(userPermission)
+	streamInterceptor := MethodPermissionStreamInterceptor(userPermission)
+
+	// calls go through the auth interceptors first, which resolve the calling user
+	unaryCall := func(ctx context.Context, method string) error {
+		info := &grpc.UnaryServerInfo{FullMethod: method}
+
+		_, err := ServerUnaryInterceptor(ctx, "request", info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return unaryInterceptor(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return "response", nil
+			})
+		})
+
+		return err
+	}
+
+	streamCall := func(ctx context.Context, method string) error {
+		info := &grpc.StreamServerInfo{FullMethod: method}
+		ss := &userServerStream{ServerStream: &MockedServerStream{}, ctx: ctx}
+
+		return ServerStreamInterceptor(nil, ss, info, func(srv interface{}, ss grpc.ServerStream) error {
+			return streamInterceptor(srv, ss, info, func(srv interface{}, ss grpc.ServerStream) error {
+				return nil
+			})
+		})
+	}
+
+	userContext := func(username string) context.Context {
+		token, err := GenerateToken(User{Username: username, Active: true}, 1, 60)
+		require.NoError(t, err)
+
+		return metadata.NewIncomingContext(peerContext("10.0.0.1"), metadata.Pairs("authorization", token))
+	}
+
+	for _, tc := range []struct {
+		ctx     context.Context
+		method  string
+		allowed bool
+	}{
+		{userContext("reader"), "/immudb.schema.ImmuService/ListUsers", false},
+		{userContext("reader"), "/immudb.schema.ImmuService/Scan", true},
+		{userContext("admin"), "/immudb.schema.ImmuService/ListUsers", true},
+		{userContext("admin"), "/immudb.schema.ImmuService/Scan", true},
+		{userContext("unknown"), "/immudb.schema.ImmuService/Scan", false},
+
+		// methods without a level are authorized by the server
+		{userContext("reader"), "/immudb.schema.ImmuService/CreateUser", true},
+
+		{peerContext("10.0.0.1"), "/immudb.schema.ImmuService/Scan", false},
+		{peerContext("127.0.0.1"), "/immudb.schema.ImmuService/Scan", false},
+	} {
+		err := unaryCall(tc.ctx, tc.method)
+		require.Equal(t, tc.allowed, err == nil, "unary call to %s", tc.method)
+		if !tc.allowed {
+			require.Equal(t, codes.PermissionDenied, status.Code(err))
+		}
+
+		err = streamCall(tc.ctx, tc.method)
+		require.Equal(t, tc.allowed, err == nil, "stream call to %s", tc.method)
+		if !tc.allowed {
+			require.Equal(t, codes.PermissionDenied, status.Code(err))
+		}
+	}
+
+	t.Run("levels should not be enforced with authentication disabled", func(t *testing.T) {
+		AuthEnabled = false
+		defer func() {
+			AuthEnabled = true
+		}()
+
+		err := unaryCall(peerContext("127.0.0.1"), "/immudb.schema.ImmuService/ListUsers")
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+type userContextKey struct{}
+
+// LocalUser is the user injected into the context of local calls made without a valid token
+var LocalUser = &JSONToken{DatabaseIndex: -1}
+
+// UserFromContext returns the user resolved by the server interceptors,
+// false is returned when the call was made without a valid token from a remote client
+func UserFromContext(ctx context.Context) (*JSONToken, bool) {
+	user, ok := ctx.Value(userContextKey{}).(*JSONToken)
+	return user, ok
+}
+
+func contextWithUser(ctx context.Context) context.Context {
+	user, err := verifyTokenFromCtx(ctx)
+	if err != nil {
+		if !isLocalClient(ctx) {
+			return ctx
+		}
+
+		user = LocalUser
+	}
+
+	return context.WithValue(ctx, userContextKey{}, user)
+}
+
+// userServerStream overrides the context of the wrapped stream
+type userServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *userServerStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+type contextServerStream struct {
+	MockedServerStream
+	ctx context.Context
+}
+
+func (ss *contextServerStream) Context() context.Context {
+	return ss.ctx
+}
+
+func TestUserFromContext(t *testing.T) {
+	UpdateMetrics = nil
+	IsTampered = false
+	AuthEnabled = true
+
+	token, err := GenerateToken(User{Username: "immudb", Active: true}, 2, 60)
+	require.NoError(t, err)
+
+	authenticated := func(ctx context.Context) context.Context {
+		return metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", token))
+	}
+
+	unaryUser := func(ctx context.Context) (*JSONToken, bool) {
+		var user *JSONToken
+		var found bool
+
+		_, err := ServerUnaryInterceptor(ctx, nil, nil, func(ctx context.Context, req interface{}) (interface{}, error) {
+			user, found = UserFromContext(ctx)
+			return nil, nil
+		})
+		require.NoError(t, err)
+
+		return user, found
+	}
+
+	streamUser := func(ctx context.Context) (*JSONToken, bool) {
+		var user *JSONToken
+		var found bool
+
+		err := ServerStreamInterceptor(nil, &contextServerStream{ctx: ctx}, nil, func(srv interface{}, stream grpc.ServerStream) error {
+			user, found = UserFromContext(stream.Context())
+			return nil
+		})
+		require.NoError(t, err)
+
+		return user, found
+	}
+
+	for _, resolve := range []func(ctx context.Context) (*JSONToken, bool){unaryUser, streamUser} {
+		user, found := resolve(authenticated(peerContext("10.0.0.1")))
+		require.True(t, found)
+		require.Equal(t, "immudb", user.Username)
+		require.Equal(t, int64(2), user.DatabaseIndex)
+
+		user, found = resolve(authenticated(peerContext("127.0.0.1")))
+		require.True(t, found)
+		require.Equal(t, "immudb", user.Username)
+
+		user, found = resolve(peerContext("127.0.0.1"))
+		require.True(t, found)
+		require.Same(t, LocalUser, user)
+
+		_, found = resolve(peerContext("10.0.0.1"))
+		require.False(t, found)
+	}
+
+	_, found := UserFromContext(context.Background())
+	require.False(t, found)
+}
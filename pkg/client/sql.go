@@ -330,6 +330,11 @@ func typedValueToRowValue(tv sql.TypedValue) *schema.SQLValue {
 		{
 			return &schema.SQLValue{Value: &schema.SQLValue_Bs{Bs: tv.Value().([]byte)}}
 		}
+	case sql.TimestampType:
+		{
+			// timestamps are sent as unix nanos
+			return &schema.SQLValue{Value: &schema.SQLValue_N{N: tv.Value().(int64)}}
+		}
 	}
 	return nil
 }
@@ -17,6 +17,7 @@ package database
 
 import (
 	"testing"
+	"time"
 
 	"github.com/codenotary/immudb/embedded/sql"
 	"github.com/codenotary/immudb/embedded/store"
@@ -92,7 +93,8 @@ func TestSQLExecAndQuery(t *testing.T) {
 	q := "SELECT t.id, t.id as id2, title, active, payload FROM table1 t WHERE id <= 3 AND active != @active"
 	res, err = db.SQLQuery(&schema.SQLQueryRequest{Sql: q, Params: params})
 	require.NoError(t, err)
-	require.Len(t, res.Rows, 2)
+	// the row with a NULL value does not satisfy the comparison
+	require.Len(t, res.Rows, 1)
 
 	inferredParams, err := db.InferParameters(q)
 	require.NoError(t, err)
@@ -178,3 +180,81 @@ func TestSQLExecAndQuery(t *testing.T) {
 	require.Equal(t, store.ErrKeyNotFound, err)
 
 }
+
+func TestSQLQueryTimestampColumns(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	_, err := db.SQLExec(&schema.SQLExecRequest{Sql: `
+		CREATE TABLE table1(id INTEGER, ts TIMESTAMP, PRIMARY KEY id);
+		INSERT INTO table1(id, ts) VALUES (1, CAST('2021-08-25T00:00:00Z' AS TIMESTAMP)), (2, NULL);
+	`})
+	require.NoError(t, err)
+
+	res, err := db.SQLQuery(&schema.SQLQueryRequest{Sql: "SELECT id, ts FROM table1"})
+	require.NoError(t, err)
+	require.Len(t, res.Rows, 2)
+
+	ts := time.Date(2021, 8, 25, 0, 0, 0, 0, time.UTC)
+	require.Equal(t, &schema.SQLValue{Value: &schema.SQLValue_N{N: ts.UnixNano()}}, res.Rows[0].Values[1])
+	require.Equal(t, &schema.SQLValue{Value: &schema.SQLValue_Null{}}, res.Rows[1].Values[1])
+
+	// timestamps read as unix nanos can be written back as parameters
+	_, err = db.SQLExec(&schema.SQLExecRequest{
+		Sql:    "INSERT INTO table1(id, ts) VALUES (3, @ts)",
+		Params: []*schema.NamedParam{{Name: "ts", Value: res.Rows[0].Values[1]}},
+	})
+	require.NoError(t, err)
+
+	res, err = db.SQLQuery(&schema.SQLQueryRequest{Sql: "SELECT id FROM table1 WHERE ts = CAST('2021-08-25T00:00:00Z' AS TIMESTAMP)"})
+	require.NoError(t, err)
+	require.Len(t, res.Rows, 2)
+}
+
+func TestSQLExecLastInsertedPKs(t *testing.T) {
+	db, closer := makeDb()
+	defer closer()
+
+	_, err := db.SQLExec(&schema.SQLExecRequest{Sql: `
+		CREATE TABLE table1(id INTEGER AUTO_INCREMENT, title VARCHAR, PRIMARY KEY id);
+		CREATE TABLE table2(id INTEGER AUTO_INCREMENT, amount INTEGER, PRIMARY KEY id);
+	`})
+	require.NoError(t, err)
+
+	lastPK := func(table string) int64 {
+		res, err := db.SQLQuery(&schema.SQLQueryRequest{Sql: "SELECT id FROM " + table + " ORDER BY id DESC LIMIT 1"})
+		require.NoError(t, err)
+		require.Len(t, res.Rows, 1)
+
+		return res.Rows[0].Values[0].GetN()
+	}
+
+	res, err := db.SQLExec(&schema.SQLExecRequest{Sql: "INSERT INTO table1(title) VALUES ('title1')"})
+	require.NoError(t, err)
+	require.Len(t, res.LastInsertedPKs, 1)
+	require.Equal(t, int64(1), res.LastInsertedPKs["table1"].GetN())
+	require.Equal(t, lastPK("table1"), res.LastInsertedPKs["table1"].GetN())
+
+	res, err = db.SQLExec(&schema.SQLExecRequest{Sql: "INSERT INTO table1(title) VALUES ('title2'), ('title3'), ('title4')"})
+	require.NoError(t, err)
+	require.Len(t, res.LastInsertedPKs, 1)
+	require.Equal(t, int64(4), res.LastInsertedPKs["table1"].GetN())
+	require.Equal(t, lastPK("table1"), res.LastInsertedPKs["table1"].GetN())
+
+	res, err = db.SQLExec(&schema.SQLExecRequest{Sql: `
+		BEGIN TRANSACTION
+			INSERT INTO table1(title) VALUES ('title5');
+			INSERT INTO table2(amount) VALUES (10), (20);
+		COMMIT
+	`})
+	require.NoError(t, err)
+	require.Len(t, res.LastInsertedPKs, 2)
+	require.Equal(t, lastPK("table1"), res.LastInsertedPKs["table1"].GetN())
+	require.Equal(t, int64(5), res.LastInsertedPKs["table1"].GetN())
+	require.Equal(t, lastPK("table2"), res.LastInsertedPKs["table2"].GetN())
+	require.Equal(t, int64(2), res.LastInsertedPKs["table2"].GetN())
+
+	res, err = db.SQLExec(&schema.SQLExecRequest{Sql: "UPSERT INTO table1(id, title) VALUES (1, 'title1')"})
+	require.NoError(t, err)
+	require.Empty(t, res.LastInsertedPKs)
+}
@@ -565,7 +565,7 @@ func TestPgsqlServer_SimpleQueryNilValues(t *testing.T) {
 	var id int64
 	var amount sql.NullInt64
 	var title sql.NullString
-	err = db.QueryRow(fmt.Sprintf("SELECT id, amount, title FROM %s where title = null", table)).Scan(&id, &amount, &title)
+	err = db.QueryRow(fmt.Sprintf("SELECT id, amount, title FROM %s where title IS NULL", table)).Scan(&id, &amount, &title)
 	require.NoError(t, err)
 	require.False(t, title.Valid)
 	require.False(t, amount.Valid)
@@ -68,6 +68,8 @@ type Options struct {
 	PgsqlServer          bool
 	PgsqlServerPort      int
 	ReplicationOptions   *ReplicationOptions
+	RateLimitOptions     *RateLimitOptions
+	StreamLimits         StreamLimits
 }
 
 type RemoteStorageOptions struct {
@@ -87,6 +89,16 @@ type ReplicationOptions struct {
 	FollowerPassword string
 }
 
+// RateLimitOptions throttles the requests of each client, requests exceeding the limit are rejected
+type RateLimitOptions struct {
+	// Limit is the number of requests per second allowed for each client
+	Limit float64
+	// Burst is the maximum number of requests a client can make at once
+	Burst int
+	// ExemptLocalClients disables the throttling of local clients
+	ExemptLocalClients bool
+}
+
 // DefaultOptions returns default server options
 func DefaultOptions() *Options {
 	return &Options{
@@ -270,6 +282,9 @@ func (o *Options) String() string {
 	opts = append(opts, rightPad("Default database", o.defaultDBName))
 	opts = append(opts, rightPad("Maintenance mode", o.maintenance))
 	opts = append(opts, rightPad("Synced mode", o.synced))
+	if o.RateLimitOptions != nil {
+		opts = append(opts, rightPad("Rate limit", fmt.Sprintf("%v req/s (burst %d)", o.RateLimitOptions.Limit, o.RateLimitOptions.Burst)))
+	}
 	if o.RemoteStorageOptions.S3Storage {
 		opts = append(opts, "S3 storage")
 		opts = append(opts, rightPad("   endpoint", o.RemoteStorageOptions.S3Endpoint))
@@ -395,6 +410,18 @@ func (o *Options) WithReplicationOptions(replicationOptions *ReplicationOptions)
 	return o
 }
 
+// WithStreamLimits sets the limits of the messages received through streams, zero values mean no limit
+func (o *Options) WithStreamLimits(streamLimits StreamLimits) *Options {
+	o.StreamLimits = streamLimits
+	return o
+}
+
+// WithRateLimitOptions sets the rate limit of the requests of each client, no limit is applied when nil
+func (o *Options) WithRateLimitOptions(rateLimitOptions *RateLimitOptions) *Options {
+	o.RateLimitOptions = rateLimitOptions
+	return o
+}
+
 // RemoteStorageOptions
 
 func (opts *RemoteStorageOptions) WithS3Storage(S3Storage bool) *RemoteStorageOptions {
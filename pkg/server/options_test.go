@@ -126,6 +126,12 @@ Superadmin default credentials
 	assert.Equal(t, expected, op.String())
 }
 
+func TestOptionsStringWithRateLimit(t *testing.T) {
+	op := DefaultOptions().WithRateLimitOptions(&RateLimitOptions{Limit: 10, Burst: 20})
+
+	assert.Contains(t, op.String(), "Rate limit       : 10 req/s (burst 20)")
+}
+
 func TestOptionsStringWithS3(t *testing.T) {
 	expected := `================ Config ================
 Data dir         : ./data
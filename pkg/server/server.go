@@ -201,18 +201,27 @@ func (s *ImmuServer) Initialize() error {
 
 	uuidContext := NewUUIDContext(s.UUID)
 
+	authUnaryInterceptor, authStreamInterceptor, err := s.authInterceptors()
+	if err != nil {
+		return err
+	}
+
 	uis := []grpc.UnaryServerInterceptor{
 		ErrorMapper, // converts errors in gRPC ones. Need to be the first
 		uuidContext.UUIDContextSetter,
 		grpc_prometheus.UnaryServerInterceptor,
-		auth.ServerUnaryInterceptor,
+		authUnaryInterceptor,
+		auth.MethodPermissionUnaryInterceptor(s.userPermission),
 	}
 	sss := []grpc.StreamServerInterceptor{
 		ErrorMapperStream, // converts errors in gRPC ones. Need to be the first
 		uuidContext.UUIDStreamContextSetter,
 		grpc_prometheus.StreamServerInterceptor,
-		auth.ServerStreamInterceptor,
+		authStreamInterceptor,
+		auth.MethodPermissionStreamInterceptor(s.userPermission),
 	}
+	sss = append(sss, s.streamLimitsInterceptors()...)
+
 	grpcSrvOpts = append(
 		grpcSrvOpts,
 		grpc.UnaryInterceptor(grpc_middleware.ChainUnaryServer(uis...)),
@@ -234,6 +243,32 @@ func (s *ImmuServer) Initialize() error {
 	return err
 }
 
+// authInterceptors returns the interceptors authorizing the calls, throttled when a rate limit is set
+func (s *ImmuServer) authInterceptors() (grpc.UnaryServerInterceptor, grpc.StreamServerInterceptor, error) {
+	rlOpts := s.Options.RateLimitOptions
+	if rlOpts == nil {
+		return auth.ServerUnaryInterceptor, auth.ServerStreamInterceptor, nil
+	}
+
+	if rlOpts.Limit <= 0 || rlOpts.Burst < 1 {
+		return nil, nil, ErrInvalidRateLimit
+	}
+
+	rl := auth.NewRateLimiter(rlOpts.Limit, rlOpts.Burst, rlOpts.ExemptLocalClients)
+
+	return auth.RateLimitedServerUnaryInterceptor(rl), auth.RateLimitedServerStreamInterceptor(rl), nil
+}
+
+// streamLimitsInterceptors returns the interceptors bounding the messages received through streams, if any limit is set
+func (s *ImmuServer) streamLimitsInterceptors() []grpc.StreamServerInterceptor {
+	limits := s.Options.StreamLimits
+	if limits.MaxRecvMsgs <= 0 && limits.MaxMsgSize <= 0 {
+		return nil
+	}
+
+	return []grpc.StreamServerInterceptor{StreamLimitsInterceptor(limits)}
+}
+
 // Start starts the immudb server
 // Loads and starts the System DB, default db and user db
 func (s *ImmuServer) Start() (err error) {
@@ -1114,6 +1149,26 @@ func (s *ImmuServer) getDBFromCtx(ctx context.Context, methodName string) (datab
 	return db, nil
 }
 
+// userPermission returns the permission the user holds on the database selected by its token
+func (s *ImmuServer) userPermission(jsUser *auth.JSONToken) (uint32, error) {
+	usr, err := s.getLoggedInUserDataFromUsername(jsUser.Username)
+	if err != nil {
+		return auth.PermissionNone, err
+	}
+
+	ind := jsUser.DatabaseIndex
+
+	switch {
+	case ind == sysDBIndex:
+		return usr.WhichPermission(s.sysDB.GetOptions().GetDBName()), nil
+	case ind >= 0 && ind < int64(s.dbList.Length()):
+		return usr.WhichPermission(s.dbList.GetByIndex(ind).GetOptions().GetDBName()), nil
+	}
+
+	// sysadmins hold their permission even before selecting a database
+	return usr.WhichPermission(""), nil
+}
+
 type dbSettings struct {
 	Database          string    `json:"database"`
 	ExcludeCommitTime bool      `json:"excludeCommitTime"`
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"net"
 	"os"
 	"path"
 	"strings"
@@ -42,7 +43,9 @@ import (
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
@@ -189,6 +192,53 @@ func TestServerErrChunkSizeTooSmall(t *testing.T) {
 	assert.Equal(t, stream.ErrChunkTooSmall, err.Error())
 }
 
+func TestServerInvalidRateLimit(t *testing.T) {
+	serverOptions := DefaultOptions().
+		WithMetricsServer(false).
+		WithListener(bufconn.Listen(1024)).
+		WithRateLimitOptions(&RateLimitOptions{Limit: 1})
+	s := DefaultServer().WithOptions(serverOptions).(*ImmuServer)
+	defer os.RemoveAll(s.Options.Dir)
+
+	err := s.Initialize()
+	require.Equal(t, ErrInvalidRateLimit, err)
+}
+
+func TestServerRateLimit(t *testing.T) {
+	auth.UpdateMetrics = func(context.Context) {}
+	auth.IsTampered = false
+	auth.AuthEnabled = true
+
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 9999}})
+
+	h := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "response", nil
+	}
+
+	s := DefaultServer()
+
+	unaryInterceptor, _, err := s.authInterceptors()
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err = unaryInterceptor(ctx, "method", nil, h)
+		require.NoError(t, err)
+	}
+
+	s.Options.WithRateLimitOptions(&RateLimitOptions{Limit: 1, Burst: 2})
+
+	unaryInterceptor, _, err = s.authInterceptors()
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		_, err = unaryInterceptor(ctx, "method", nil, h)
+		require.NoError(t, err)
+	}
+
+	_, err = unaryInterceptor(ctx, "method", nil, h)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
 func TestServerCreateDatabase(t *testing.T) {
 	serverOptions := DefaultOptions().WithMetricsServer(false).WithAdminPassword(auth.SysAdminPassword)
 	s := DefaultServer().WithOptions(serverOptions).(*ImmuServer)
@@ -1101,6 +1151,50 @@ func TestServerPID(t *testing.T) {
 	}
 }
 
+func TestServerUserPermission(t *testing.T) {
+	serverOptions := DefaultOptions().
+		WithMetricsServer(false).
+		WithListener(bufconn.Listen(1024)).
+		WithAdminPassword(auth.SysAdminPassword)
+	s := DefaultServer().WithOptions(serverOptions).(*ImmuServer)
+	defer os.RemoveAll(s.Options.Dir)
+
+	err := s.Initialize()
+	require.NoError(t, err)
+
+	_, _, err = s.insertNewUser([]byte("reader"), []byte("$omePassword1"), auth.PermissionR, DefaultDBName, true, auth.SysAdminUsername)
+	require.NoError(t, err)
+
+	for _, username := range []string{auth.SysAdminUsername, "reader"} {
+		password := auth.SysAdminPassword
+		if username == "reader" {
+			password = "$omePassword1"
+		}
+
+		_, err = s.Login(context.Background(), &schema.LoginRequest{User: []byte(username), Password: []byte(password)})
+		require.NoError(t, err)
+	}
+
+	for _, tc := range []struct {
+		username   string
+		dbIndex    int64
+		permission uint32
+	}{
+		{"reader", defaultDbIndex, auth.PermissionR},
+		{"reader", sysDBIndex, auth.PermissionNone},
+		{"reader", -1, auth.PermissionNone},
+		{auth.SysAdminUsername, defaultDbIndex, auth.PermissionSysAdmin},
+		{auth.SysAdminUsername, -1, auth.PermissionSysAdmin},
+	} {
+		permission, err := s.userPermission(&auth.JSONToken{Username: tc.username, DatabaseIndex: tc.dbIndex})
+		require.NoError(t, err)
+		require.Equal(t, tc.permission, permission)
+	}
+
+	_, err = s.userPermission(&auth.JSONToken{Username: "unknown", DatabaseIndex: defaultDbIndex})
+	require.ErrorIs(t, err, ErrNotLoggedIn)
+}
+
 func TestServerErrors(t *testing.T) {
 	serverOptions := DefaultOptions().WithMetricsServer(false).WithAdminPassword(auth.SysAdminPassword)
 	s := DefaultServer().WithOptions(serverOptions).(*ImmuServer)
@@ -0,0 +1,67 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// StreamLimits bounds the messages received through a stream, zero values mean no limit
+type StreamLimits struct {
+	// MaxRecvMsgs is the maximum number of messages received through a single stream
+	MaxRecvMsgs int
+	// MaxMsgSize is the maximum size of a decoded message, as returned by MsgSize
+	MaxMsgSize int
+	// MsgSize returns the size of a decoded message, when not set proto.Size is used
+	MsgSize func(m interface{}) int
+}
+
+func (l StreamLimits) check(recvMsgs int, m interface{}) error {
+	if l.MaxRecvMsgs > 0 && recvMsgs > l.MaxRecvMsgs {
+		return status.Errorf(codes.ResourceExhausted, "stream exceeded the maximum number of messages (%d)", l.MaxRecvMsgs)
+	}
+
+	if l.MaxMsgSize > 0 && l.msgSize(m) > l.MaxMsgSize {
+		return status.Errorf(codes.ResourceExhausted, "stream message exceeded the maximum size (%d)", l.MaxMsgSize)
+	}
+
+	return nil
+}
+
+func (l StreamLimits) msgSize(m interface{}) int {
+	if l.MsgSize != nil {
+		return l.MsgSize(m)
+	}
+
+	pm, ok := m.(proto.Message)
+	if !ok {
+		return 0
+	}
+
+	return proto.Size(pm)
+}
+
+// StreamLimitsInterceptor returns a stream interceptor rejecting, with codes.ResourceExhausted,
+// the messages received beyond the given limits
+func StreamLimitsInterceptor(limits StreamLimits) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &WrappedServerStream{ServerStream: ss, limits: limits})
+	}
+}
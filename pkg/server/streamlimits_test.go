@@ -0,0 +1,146 @@
+/*
+Copyright 2021 CodeNotary, Inc. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"io"
+	"testing"
+
+	"github.com/codenotary/immudb/pkg/api/schema"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// recvServerStream delivers the given values as key-value messages
+type recvServerStream struct {
+	mockServerStream
+	values [][]byte
+}
+
+func (r *recvServerStream) RecvMsg(m interface{}) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+
+	m.(*schema.KeyValue).Value = r.values[0]
+	r.values = r.values[1:]
+
+	return nil
+}
+
+func recvAll(stream grpc.ServerStream) (int, error) {
+	for i := 0; ; i++ {
+		err := stream.RecvMsg(&schema.KeyValue{})
+		if err == io.EOF {
+			return i, nil
+		}
+		if err != nil {
+			return i, err
+		}
+	}
+}
+
+func TestStreamLimitsInterceptor(t *testing.T) {
+	values := func(n, size int) [][]byte {
+		vs := make([][]byte, n)
+		for i := range vs {
+			vs[i] = make([]byte, size)
+		}
+		return vs
+	}
+
+	t.Run("messages within limits are received", func(t *testing.T) {
+		interceptor := StreamLimitsInterceptor(StreamLimits{MaxRecvMsgs: 3, MaxMsgSize: 32})
+
+		err := interceptor(nil, &recvServerStream{values: values(3, 16)}, &grpc.StreamServerInfo{}, func(srv interface{}, stream grpc.ServerStream) error {
+			n, err := recvAll(stream)
+			require.Equal(t, 3, n)
+			return err
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("streams exceeding the number of messages are rejected", func(t *testing.T) {
+		interceptor := StreamLimitsInterceptor(StreamLimits{MaxRecvMsgs: 3})
+
+		err := interceptor(nil, &recvServerStream{values: values(5, 16)}, &grpc.StreamServerInfo{}, func(srv interface{}, stream grpc.ServerStream) error {
+			n, err := recvAll(stream)
+			require.Equal(t, 3, n)
+			return err
+		})
+		require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	})
+
+	t.Run("oversized messages are rejected", func(t *testing.T) {
+		interceptor := StreamLimitsInterceptor(StreamLimits{MaxMsgSize: 32})
+
+		err := interceptor(nil, &recvServerStream{values: [][]byte{make([]byte, 16), make([]byte, 64)}}, &grpc.StreamServerInfo{}, func(srv interface{}, stream grpc.ServerStream) error {
+			n, err := recvAll(stream)
+			require.Equal(t, 1, n)
+			return err
+		})
+		require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	})
+
+	t.Run("message size may be customized", func(t *testing.T) {
+		interceptor := StreamLimitsInterceptor(StreamLimits{
+			MaxMsgSize: 1,
+			MsgSize: func(m interface{}) int {
+				return len(m.(*schema.KeyValue).Value) / 1024
+			},
+		})
+
+		err := interceptor(nil, &recvServerStream{values: values(2, 1024)}, &grpc.StreamServerInfo{}, func(srv interface{}, stream grpc.ServerStream) error {
+			_, err := recvAll(stream)
+			return err
+		})
+		require.NoError(t, err)
+
+		err = interceptor(nil, &recvServerStream{values: values(2, 2048)}, &grpc.StreamServerInfo{}, func(srv interface{}, stream grpc.ServerStream) error {
+			_, err := recvAll(stream)
+			return err
+		})
+		require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	})
+
+	t.Run("streams without limits are not bounded", func(t *testing.T) {
+		err := StreamLimitsInterceptor(StreamLimits{})(nil, &recvServerStream{values: values(100, 1024)}, &grpc.StreamServerInfo{}, func(srv interface{}, stream grpc.ServerStream) error {
+			n, err := recvAll(stream)
+			require.Equal(t, 100, n)
+			return err
+		})
+		require.NoError(t, err)
+	})
+}
+
+func TestServerStreamLimits(t *testing.T) {
+	s := DefaultServer()
+	require.Empty(t, s.streamLimitsInterceptors())
+
+	s.Options.WithStreamLimits(StreamLimits{MaxRecvMsgs: 2})
+
+	interceptors := s.streamLimitsInterceptors()
+	require.Len(t, interceptors, 1)
+
+	err := interceptors[0](nil, &recvServerStream{values: [][]byte{{1}, {2}, {3}}}, &grpc.StreamServerInfo{}, func(srv interface{}, stream grpc.ServerStream) error {
+		_, err := recvAll(stream)
+		return err
+	})
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
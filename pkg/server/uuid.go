@@ -111,11 +111,21 @@ func fileExists(filename string) bool {
 // WrappedServerStream ...
 type WrappedServerStream struct {
 	grpc.ServerStream
+
+	limits   StreamLimits
+	recvMsgs int
 }
 
 // RecvMsg ...
 func (w *WrappedServerStream) RecvMsg(m interface{}) error {
-	return w.ServerStream.RecvMsg(m)
+	err := w.ServerStream.RecvMsg(m)
+	if err != nil {
+		return err
+	}
+
+	w.recvMsgs++
+
+	return w.limits.check(w.recvMsgs, m)
 }
 
 // SendMsg ...
@@ -127,7 +137,7 @@ func (w *WrappedServerStream) SendMsg(m interface{}) error {
 func (u *uuidContext) UUIDStreamContextSetter(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
 	header := metadata.Pairs(SERVER_UUID_HEADER, u.UUID.String())
 	ss.SendHeader(header)
-	return handler(srv, &WrappedServerStream{ss})
+	return handler(srv, &WrappedServerStream{ServerStream: ss})
 }
 
 // UUIDContextSetter set uuid header
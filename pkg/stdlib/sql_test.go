@@ -219,7 +219,8 @@ func TestNilValues(t *testing.T) {
 	var title sql.NullString
 	var content []byte
 
-	rows, err := db.QueryContext(context.Background(), fmt.Sprintf("SELECT id, amount, title, content FROM %s where id=? and amount=? and total=? and title=?", table), 1, nil, nil, nil)
+	// comparisons with NULL are unknown, IS NULL must be used to match the nil values
+	rows, err := db.QueryContext(context.Background(), fmt.Sprintf("SELECT id, amount, title, content FROM %s where id=? and amount IS NULL and total IS NULL and title IS NULL", table), 1)
 	defer rows.Close()
 
 	require.NoError(t, err)